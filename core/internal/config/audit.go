@@ -0,0 +1,16 @@
+package config
+
+// AuditConfig controls automatic audit logging of mutating API requests
+type AuditConfig struct {
+	Enabled  bool
+	Prefixes []string // Route prefixes whose non-GET requests are audited
+}
+
+// LoadAuditConfig loads audit logging settings from the environment.
+// AUDIT_LOG_PREFIXES is comma-separated and defaults to the whole v1 API.
+func LoadAuditConfig() *AuditConfig {
+	return &AuditConfig{
+		Enabled:  getEnv("AUDIT_LOG_ENABLED", "true") == "true",
+		Prefixes: splitList(getEnv("AUDIT_LOG_PREFIXES", "/api/v1")),
+	}
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+)
+
+// BodyLimitConfig holds request body size and JSON shape limits
+type BodyLimitConfig struct {
+	MaxBodySize    int            // Bytes
+	RouteBodySizes map[string]int // Body size overrides by path prefix
+	MaxJSONDepth   int
+	MaxJSONArray   int
+}
+
+// LoadBodyLimitConfig loads body limits from the environment.
+// BODY_LIMIT_ROUTES is a comma-separated list of prefix=bytes overrides,
+// e.g. "/api/v1/modules/install/archive=104857600".
+func LoadBodyLimitConfig() *BodyLimitConfig {
+	maxBodySize, err := strconv.Atoi(getEnv("BODY_LIMIT", "1048576"))
+	if err != nil || maxBodySize < 0 {
+		maxBodySize = 1 << 20
+	}
+
+	maxDepth, err := strconv.Atoi(getEnv("JSON_MAX_DEPTH", "32"))
+	if err != nil || maxDepth < 0 {
+		maxDepth = 32
+	}
+
+	maxArray, err := strconv.Atoi(getEnv("JSON_MAX_ARRAY_LENGTH", "10000"))
+	if err != nil || maxArray < 0 {
+		maxArray = 10000
+	}
+
+	routes := make(map[string]int)
+	for _, entry := range splitList(getEnv("BODY_LIMIT_ROUTES", "")) {
+		prefix, value, ok := strings.Cut(entry, "=")
+		size, err := strconv.Atoi(strings.TrimSpace(value))
+		if !ok || err != nil || size < 0 {
+			continue
+		}
+		routes[strings.TrimSpace(prefix)] = size
+	}
+
+	return &BodyLimitConfig{
+		MaxBodySize:    maxBodySize,
+		RouteBodySizes: routes,
+		MaxJSONDepth:   maxDepth,
+		MaxJSONArray:   maxArray,
+	}
+}
@@ -0,0 +1,23 @@
+package config
+
+import "strconv"
+
+// CompressionConfig holds response compression settings
+type CompressionConfig struct {
+	Enabled bool
+	MinSize int // Smallest response body in bytes that is compressed
+}
+
+// LoadCompressionConfig loads response compression settings from the
+// environment
+func LoadCompressionConfig() *CompressionConfig {
+	minSize, err := strconv.Atoi(getEnv("COMPRESSION_MIN_SIZE", "1024"))
+	if err != nil || minSize < 0 {
+		minSize = 1024
+	}
+
+	return &CompressionConfig{
+		Enabled: getEnv("COMPRESSION_ENABLED", "true") == "true",
+		MinSize: minSize,
+	}
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+)
+
+// CORSConfig holds cross-origin settings. Empty lists keep the API defaults.
+type CORSConfig struct {
+	AllowOrigins     []string // Exact origins, "*" or subdomain wildcards like "https://*.example.com"
+	AllowMethods     []string
+	AllowHeaders     []string
+	ExposeHeaders    []string
+	AllowCredentials bool
+	MaxAge           int // Seconds browsers may cache preflight responses
+}
+
+// LoadCORSConfig loads CORS settings from the environment. Lists are
+// comma-separated.
+func LoadCORSConfig() *CORSConfig {
+	maxAge, err := strconv.Atoi(getEnv("CORS_MAX_AGE", "3600"))
+	if err != nil || maxAge < 0 {
+		maxAge = 3600
+	}
+
+	return &CORSConfig{
+		AllowOrigins:     splitList(getEnv("CORS_ALLOWED_ORIGINS", "*")),
+		AllowMethods:     splitList(getEnv("CORS_ALLOWED_METHODS", "")),
+		AllowHeaders:     splitList(getEnv("CORS_ALLOWED_HEADERS", "")),
+		ExposeHeaders:    splitList(getEnv("CORS_EXPOSED_HEADERS", "")),
+		AllowCredentials: getEnv("CORS_ALLOW_CREDENTIALS", "false") == "true",
+		MaxAge:           maxAge,
+	}
+}
+
+// splitList splits a comma-separated value, dropping empty entries
+func splitList(value string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
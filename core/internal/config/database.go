@@ -3,9 +3,13 @@ package config
 import (
 	"fmt"
 	"log"
+	"net"
 	"os"
+	"strconv"
 	"time"
 
+	"neonexcore/pkg/database"
+
 	"github.com/glebarez/sqlite"
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
@@ -24,20 +28,50 @@ type DatabaseConfig struct {
 	ParseTime       string
 	Loc             string
 	MaxIdleConns    int
-	MaxOpenConns    int
-	ConnMaxLifetime time.Duration
+	MaxOpenConns    int           // 0 is unlimited
+	ConnMaxLifetime time.Duration // 0 keeps connections forever
+	ConnMaxIdleTime time.Duration // 0 keeps idle connections forever
+	PoolSaturation  float64       // Share of MaxOpenConns in use that fails the health check; 0 disables it
 	LogLevel        logger.LogLevel
+	Replicas        []string // Read replica hosts ("host" or "host:port"), or database files for SQLite
 }
 
 type DatabaseManager struct {
-	db     *gorm.DB
-	config *DatabaseConfig
+	db       *gorm.DB
+	replicas *database.ReplicaSet
+	config   *DatabaseConfig
 }
 
 var DB *DatabaseManager
 
-// LoadDatabaseConfig loads database configuration from environment
+// LoadDatabaseConfig loads database configuration from environment. The
+// pool settings apply to the primary and to each read replica.
 func LoadDatabaseConfig() *DatabaseConfig {
+	maxIdleConns, err := strconv.Atoi(getEnv("DB_MAX_IDLE_CONNS", "10"))
+	if err != nil || maxIdleConns < 0 {
+		maxIdleConns = 10
+	}
+
+	maxOpenConns, err := strconv.Atoi(getEnv("DB_MAX_OPEN_CONNS", "100"))
+	if err != nil || maxOpenConns < 0 {
+		maxOpenConns = 100
+	}
+
+	connMaxLifetime, err := time.ParseDuration(getEnv("DB_CONN_MAX_LIFETIME", "1h"))
+	if err != nil || connMaxLifetime < 0 {
+		connMaxLifetime = time.Hour
+	}
+
+	connMaxIdleTime, err := time.ParseDuration(getEnv("DB_CONN_MAX_IDLE_TIME", "0"))
+	if err != nil || connMaxIdleTime < 0 {
+		connMaxIdleTime = 0
+	}
+
+	poolSaturation, err := strconv.ParseFloat(getEnv("DB_POOL_HEALTH_THRESHOLD", "0.95"), 64)
+	if err != nil || poolSaturation < 0 || poolSaturation > 1 {
+		poolSaturation = 0.95
+	}
+
 	return &DatabaseConfig{
 		Driver:          getEnv("DB_DRIVER", "sqlite"),
 		Host:            getEnv("DB_HOST", "localhost"),
@@ -48,15 +82,60 @@ func LoadDatabaseConfig() *DatabaseConfig {
 		Charset:         getEnv("DB_CHARSET", "utf8mb4"),
 		ParseTime:       getEnv("DB_PARSE_TIME", "True"),
 		Loc:             getEnv("DB_LOC", "Local"),
-		MaxIdleConns:    10,
-		MaxOpenConns:    100,
-		ConnMaxLifetime: time.Hour,
+		MaxIdleConns:    maxIdleConns,
+		MaxOpenConns:    maxOpenConns,
+		ConnMaxLifetime: connMaxLifetime,
+		ConnMaxIdleTime: connMaxIdleTime,
+		PoolSaturation:  poolSaturation,
 		LogLevel:        logger.Info,
+		Replicas:        splitList(getEnv("DB_REPLICAS", "")),
 	}
 }
 
-// InitDatabase initializes database connection
+// InitDatabase initializes the database connection and the read replica
+// connections. Read replicas share the primary's credentials and settings.
 func InitDatabase(config *DatabaseConfig) (*DatabaseManager, error) {
+	dialector, err := openDialector(config)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := openDatabase(dialector, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	replicas := make([]*gorm.DB, 0, len(config.Replicas))
+	for _, replica := range config.Replicas {
+		replicaDialector, err := openDialector(replicaConfig(config, replica))
+		if err != nil {
+			return nil, err
+		}
+		replicaDB, err := openDatabase(replicaDialector, config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to read replica %s: %w", replica, err)
+		}
+		replicas = append(replicas, replicaDB)
+	}
+
+	manager := &DatabaseManager{
+		db:       db,
+		replicas: database.NewReplicaSet(db, replicas...),
+		config:   config,
+	}
+
+	DB = manager
+	database.SetResolver(manager.replicas)
+
+	fmt.Printf("✅ Database connected: %s\n", config.Driver)
+	if len(replicas) > 0 {
+		fmt.Printf("✅ Read replicas connected: %d\n", len(replicas))
+	}
+	return manager, nil
+}
+
+// openDialector returns the dialector for the configured driver
+func openDialector(config *DatabaseConfig) (gorm.Dialector, error) {
 	var dialector gorm.Dialector
 
 	switch config.Driver {
@@ -94,6 +173,11 @@ func InitDatabase(config *DatabaseConfig) (*DatabaseManager, error) {
 		return nil, fmt.Errorf("unsupported database driver: %s", config.Driver)
 	}
 
+	return dialector, nil
+}
+
+// openDatabase opens a connection and applies the pool settings
+func openDatabase(dialector gorm.Dialector, config *DatabaseConfig) (*gorm.DB, error) {
 	// Configure GORM logger
 	gormLogger := logger.New(
 		log.New(os.Stdout, "\r\n", log.LstdFlags),
@@ -106,11 +190,11 @@ func InitDatabase(config *DatabaseConfig) (*DatabaseManager, error) {
 	)
 
 	db, err := gorm.Open(dialector, &gorm.Config{
-		Logger: gormLogger,
+		Logger:         gormLogger,
+		TranslateError: true, // e.g. unique violations become gorm.ErrDuplicatedKey
 	})
-
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to database: %w", err)
+		return nil, err
 	}
 
 	// Get underlying SQL DB to set connection pool settings
@@ -122,16 +206,33 @@ func InitDatabase(config *DatabaseConfig) (*DatabaseManager, error) {
 	sqlDB.SetMaxIdleConns(config.MaxIdleConns)
 	sqlDB.SetMaxOpenConns(config.MaxOpenConns)
 	sqlDB.SetConnMaxLifetime(config.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(config.ConnMaxIdleTime)
+	return db, nil
+}
 
-	manager := &DatabaseManager{
-		db:     db,
-		config: config,
-	}
+// replicaConfig returns the config of a read replica: the primary's with the
+// replica's host and port, or database file for SQLite
+func replicaConfig(config *DatabaseConfig, replica string) *DatabaseConfig {
+	replicaConfig := *config
+	replicaConfig.Replicas = nil
 
-	DB = manager
+	switch config.Driver {
+	case "sqlite", "turso":
+		replicaConfig.Database = replica
+	default:
+		if host, port, err := net.SplitHostPort(replica); err == nil {
+			replicaConfig.Host = host
+			replicaConfig.Port = port
+		} else {
+			replicaConfig.Host = replica
+		}
+	}
+	return &replicaConfig
+}
 
-	fmt.Printf("✅ Database connected: %s\n", config.Driver)
-	return manager, nil
+// Config returns the database configuration
+func (dm *DatabaseManager) Config() *DatabaseConfig {
+	return dm.config
 }
 
 // GetDB returns the database instance
@@ -139,13 +240,30 @@ func (dm *DatabaseManager) GetDB() *gorm.DB {
 	return dm.db
 }
 
-// Close closes the database connection
+// ReadReplica returns a read replica for read-only queries, round-robin,
+// or the primary when there are none. Replicas may lag behind the primary.
+func (dm *DatabaseManager) ReadReplica() *gorm.DB {
+	return dm.replicas.ReadReplica()
+}
+
+// Replicas returns the read replica connections
+func (dm *DatabaseManager) Replicas() []*gorm.DB {
+	return dm.replicas.Replicas()
+}
+
+// Close closes the database and read replica connections
 func (dm *DatabaseManager) Close() error {
-	sqlDB, err := dm.db.DB()
-	if err != nil {
-		return err
+	var firstErr error
+	for _, db := range append([]*gorm.DB{dm.db}, dm.replicas.Replicas()...) {
+		sqlDB, err := db.DB()
+		if err == nil {
+			err = sqlDB.Close()
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
 	}
-	return sqlDB.Close()
+	return firstErr
 }
 
 // Ping checks database connectivity
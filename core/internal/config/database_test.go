@@ -0,0 +1,95 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"neonexcore/pkg/database"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestLoadDatabaseConfigPool(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		maxOpen  int
+		maxIdle  int
+		lifetime time.Duration
+		idleTime time.Duration
+		limit    float64
+	}{
+		{"defaults", nil, 100, 10, time.Hour, 0, 0.95},
+		{"configured", map[string]string{
+			"DB_MAX_OPEN_CONNS":        "25",
+			"DB_MAX_IDLE_CONNS":        "5",
+			"DB_CONN_MAX_LIFETIME":     "30m",
+			"DB_CONN_MAX_IDLE_TIME":    "5m",
+			"DB_POOL_HEALTH_THRESHOLD": "0.8",
+		}, 25, 5, 30 * time.Minute, 5 * time.Minute, 0.8},
+		{"unlimited", map[string]string{"DB_MAX_OPEN_CONNS": "0", "DB_POOL_HEALTH_THRESHOLD": "0"}, 0, 10, time.Hour, 0, 0},
+		{"invalid", map[string]string{
+			"DB_MAX_OPEN_CONNS":        "-1",
+			"DB_MAX_IDLE_CONNS":        "many",
+			"DB_CONN_MAX_LIFETIME":     "forever",
+			"DB_CONN_MAX_IDLE_TIME":    "-5m",
+			"DB_POOL_HEALTH_THRESHOLD": "1.5",
+		}, 100, 10, time.Hour, 0, 0.95},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			config := LoadDatabaseConfig()
+			if config.MaxOpenConns != tt.maxOpen || config.MaxIdleConns != tt.maxIdle ||
+				config.ConnMaxLifetime != tt.lifetime || config.ConnMaxIdleTime != tt.idleTime ||
+				config.PoolSaturation != tt.limit {
+				t.Fatalf("pool config = %d open, %d idle, %s lifetime, %s idle time, %v saturation",
+					config.MaxOpenConns, config.MaxIdleConns, config.ConnMaxLifetime, config.ConnMaxIdleTime, config.PoolSaturation)
+			}
+		})
+	}
+}
+
+func TestInitDatabaseAppliesPoolConfig(t *testing.T) {
+	dir := t.TempDir()
+	config := &DatabaseConfig{
+		Driver:          "sqlite",
+		Database:        filepath.Join(dir, "primary.db"),
+		MaxOpenConns:    7,
+		MaxIdleConns:    3,
+		ConnMaxLifetime: time.Hour,
+		LogLevel:        logger.Silent,
+		Replicas:        []string{filepath.Join(dir, "replica.db")},
+	}
+
+	manager, err := InitDatabase(config)
+	if err != nil {
+		t.Fatalf("InitDatabase: %v", err)
+	}
+	t.Cleanup(func() {
+		database.SetResolver(nil)
+		manager.Close()
+	})
+
+	if len(manager.Replicas()) != 1 {
+		t.Fatalf("%d replicas, want 1", len(manager.Replicas()))
+	}
+	// The pool settings apply to the primary and each replica
+	for i, db := range append([]*gorm.DB{manager.GetDB()}, manager.Replicas()...) {
+		sqlDB, err := db.DB()
+		if err != nil {
+			t.Fatalf("DB: %v", err)
+		}
+		if got := sqlDB.Stats().MaxOpenConnections; got != 7 {
+			t.Fatalf("connection %d: MaxOpenConnections = %d, want 7", i, got)
+		}
+	}
+	if manager.ReadReplica() != manager.Replicas()[0] {
+		t.Fatal("reads don't go to the replica")
+	}
+}
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"strconv"
+	"time"
+)
+
+// LockoutConfig holds login brute-force protection settings
+type LockoutConfig struct {
+	MaxAttempts int           // Failed logins allowed within Window before locking
+	Window      time.Duration // Sliding window for counting failures
+	Duration    time.Duration // How long a lock lasts
+}
+
+// LoadLockoutConfig loads login lockout settings from the environment
+func LoadLockoutConfig() *LockoutConfig {
+	maxAttempts, err := strconv.Atoi(getEnv("AUTH_LOCKOUT_MAX_ATTEMPTS", "5"))
+	if err != nil || maxAttempts < 1 {
+		maxAttempts = 5
+	}
+
+	window, err := time.ParseDuration(getEnv("AUTH_LOCKOUT_WINDOW", "15m"))
+	if err != nil || window <= 0 {
+		window = 15 * time.Minute
+	}
+
+	duration, err := time.ParseDuration(getEnv("AUTH_LOCKOUT_DURATION", "15m"))
+	if err != nil || duration <= 0 {
+		duration = 15 * time.Minute
+	}
+
+	return &LockoutConfig{
+		MaxAttempts: maxAttempts,
+		Window:      window,
+		Duration:    duration,
+	}
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"net/mail"
+	"strconv"
+	"strings"
+)
+
+// MailConfig holds outgoing email settings
+type MailConfig struct {
+	Driver     string // "smtp" or "noop"
+	Host       string
+	Port       int
+	Username   string
+	Password   string
+	From       string // Sender, e.g. "NeonEx Framework <noreply@example.com>"
+	Encryption string // "none", "starttls" or "tls"
+
+	AppName   string // Product name used in emails
+	VerifyURL string // Email verification link; {token} is replaced with the token
+	ResetURL  string // Password reset link; {token} is replaced with the token
+}
+
+// LoadMailConfig loads email settings from the environment. Mail is
+// discarded unless MAIL_DRIVER is "smtp".
+func LoadMailConfig() *MailConfig {
+	port, err := strconv.Atoi(getEnv("MAIL_PORT", "587"))
+	if err != nil || port <= 0 {
+		port = 587
+	}
+
+	from := mail.Address{
+		Name:    getEnv("MAIL_FROM_NAME", ""),
+		Address: getEnv("MAIL_FROM_ADDRESS", "noreply@localhost"),
+	}
+	appURL := strings.TrimRight(getEnv("APP_URL", "http://localhost:8080"), "/")
+	frontendURL := strings.TrimRight(getEnv("FRONTEND_URL", appURL), "/")
+
+	return &MailConfig{
+		Driver:     getEnv("MAIL_DRIVER", "noop"),
+		Host:       getEnv("MAIL_HOST", "localhost"),
+		Port:       port,
+		Username:   getEnv("MAIL_USERNAME", ""),
+		Password:   getEnv("MAIL_PASSWORD", ""),
+		From:       from.String(),
+		Encryption: getEnv("MAIL_ENCRYPTION", "starttls"),
+		AppName:    getEnv("APP_NAME", "NeonexCore"),
+		VerifyURL:  getEnv("MAIL_VERIFY_URL", appURL+"/api/v1/auth/verify-email/{token}"),
+		ResetURL:   getEnv("MAIL_RESET_URL", frontendURL+"/reset-password?token={token}"),
+	}
+}
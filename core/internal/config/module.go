@@ -0,0 +1,25 @@
+package config
+
+import (
+	"time"
+)
+
+// ModuleWatchConfig controls development hot-reload of modules
+type ModuleWatchConfig struct {
+	Enabled  bool
+	Debounce time.Duration
+}
+
+// LoadModuleWatchConfig loads module watch settings from the environment.
+// Watching is off unless MODULE_WATCH=true and is meant for development only.
+func LoadModuleWatchConfig() *ModuleWatchConfig {
+	debounce, err := time.ParseDuration(getEnv("MODULE_WATCH_DEBOUNCE", "500ms"))
+	if err != nil || debounce <= 0 {
+		debounce = 500 * time.Millisecond
+	}
+
+	return &ModuleWatchConfig{
+		Enabled:  getEnv("MODULE_WATCH", "false") == "true",
+		Debounce: debounce,
+	}
+}
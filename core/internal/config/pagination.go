@@ -0,0 +1,29 @@
+package config
+
+import "strconv"
+
+// PaginationConfig holds the default page size limits of list endpoints
+type PaginationConfig struct {
+	DefaultLimit int  // Page size when the request has no limit
+	MaxLimit     int  // Largest limit a request may ask for
+	ClampLimit   bool // Clamp larger limits instead of rejecting them with 400
+}
+
+// LoadPaginationConfig loads pagination limits from the environment
+func LoadPaginationConfig() *PaginationConfig {
+	defaultLimit, err := strconv.Atoi(getEnv("PAGINATION_DEFAULT_LIMIT", "10"))
+	if err != nil || defaultLimit < 1 {
+		defaultLimit = 10
+	}
+
+	maxLimit, err := strconv.Atoi(getEnv("PAGINATION_MAX_LIMIT", "100"))
+	if err != nil || maxLimit < 1 {
+		maxLimit = 100
+	}
+
+	return &PaginationConfig{
+		DefaultLimit: defaultLimit,
+		MaxLimit:     maxLimit,
+		ClampLimit:   getEnv("PAGINATION_CLAMP_LIMIT", "false") == "true",
+	}
+}
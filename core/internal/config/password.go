@@ -0,0 +1,24 @@
+package config
+
+import "strconv"
+
+// PasswordConfig holds password hashing settings
+type PasswordConfig struct {
+	BcryptCost int
+	Pepper     string // Secret mixed into every password; keep it out of the database
+}
+
+// LoadPasswordConfig loads password hashing settings from the environment.
+// Changing PASSWORD_BCRYPT_COST takes effect for existing users on their
+// next login.
+func LoadPasswordConfig() *PasswordConfig {
+	cost, err := strconv.Atoi(getEnv("PASSWORD_BCRYPT_COST", "12"))
+	if err != nil || cost <= 0 {
+		cost = 12
+	}
+
+	return &PasswordConfig{
+		BcryptCost: cost,
+		Pepper:     getEnv("PASSWORD_PEPPER", ""),
+	}
+}
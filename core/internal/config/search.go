@@ -0,0 +1,39 @@
+package config
+
+import (
+	"strconv"
+	"time"
+)
+
+// UserSearchConfig bounds the user search endpoint
+type UserSearchConfig struct {
+	MinLength    int           // Shorter queries are rejected
+	MaxResults   int           // Upper bound for the limit query parameter
+	CacheTTL     time.Duration // How long identical queries are served from cache; 0 disables caching
+	TrigramIndex bool          // Create pg_trgm indexes for name/email search on PostgreSQL
+}
+
+// LoadUserSearchConfig loads user search settings from the environment
+func LoadUserSearchConfig() *UserSearchConfig {
+	minLength, err := strconv.Atoi(getEnv("USER_SEARCH_MIN_LENGTH", "2"))
+	if err != nil || minLength < 1 {
+		minLength = 2
+	}
+
+	maxResults, err := strconv.Atoi(getEnv("USER_SEARCH_MAX_RESULTS", "50"))
+	if err != nil || maxResults < 1 {
+		maxResults = 50
+	}
+
+	cacheTTL, err := time.ParseDuration(getEnv("USER_SEARCH_CACHE_TTL", "30s"))
+	if err != nil || cacheTTL < 0 {
+		cacheTTL = 30 * time.Second
+	}
+
+	return &UserSearchConfig{
+		MinLength:    minLength,
+		MaxResults:   maxResults,
+		CacheTTL:     cacheTTL,
+		TrigramIndex: getEnv("USER_SEARCH_TRIGRAM_INDEX", "false") == "true",
+	}
+}
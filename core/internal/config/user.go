@@ -0,0 +1,25 @@
+package config
+
+// Policies for a new user whose email or username belongs to a soft-deleted
+// user
+const (
+	DeletedUserReject  = "reject"  // Fail with ACCOUNT_DELETED
+	DeletedUserRestore = "restore" // Restore the deleted user with the new details
+)
+
+// UserAccountConfig holds user account settings
+type UserAccountConfig struct {
+	DeletedUserPolicy string
+}
+
+// LoadUserAccountConfig loads user account settings from the environment
+func LoadUserAccountConfig() *UserAccountConfig {
+	policy := getEnv("USER_DELETED_POLICY", DeletedUserReject)
+	if policy != DeletedUserRestore {
+		policy = DeletedUserReject
+	}
+
+	return &UserAccountConfig{
+		DeletedUserPolicy: policy,
+	}
+}
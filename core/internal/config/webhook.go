@@ -0,0 +1,40 @@
+package config
+
+import (
+	"strconv"
+	"time"
+)
+
+// WebhookConfig holds webhook delivery settings
+type WebhookConfig struct {
+	Events      []string      // Domain events forwarded to webhooks; alerts are always available
+	Timeout     time.Duration // Timeout of a single delivery attempt
+	MaxAttempts int           // Total attempts per delivery including the first
+	Retention   time.Duration // How long delivery attempts are kept; 0 keeps them
+}
+
+// LoadWebhookConfig loads webhook settings from the environment.
+// WEBHOOK_EVENTS is comma-separated.
+func LoadWebhookConfig() *WebhookConfig {
+	timeout, err := time.ParseDuration(getEnv("WEBHOOK_TIMEOUT", "10s"))
+	if err != nil || timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	maxAttempts, err := strconv.Atoi(getEnv("WEBHOOK_MAX_ATTEMPTS", "5"))
+	if err != nil || maxAttempts < 1 {
+		maxAttempts = 5
+	}
+
+	retention, err := time.ParseDuration(getEnv("WEBHOOK_RETENTION", "168h"))
+	if err != nil || retention < 0 {
+		retention = 7 * 24 * time.Hour
+	}
+
+	return &WebhookConfig{
+		Events:      splitList(getEnv("WEBHOOK_EVENTS", "user.created,user.deleted,user.locked_out,module.installed,module.uninstalled")),
+		Timeout:     timeout,
+		MaxAttempts: maxAttempts,
+		Retention:   retention,
+	}
+}
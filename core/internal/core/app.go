@@ -1,14 +1,26 @@
 package core
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
 
 	"neonexcore/internal/config"
 	"neonexcore/pkg/api"
+	"neonexcore/pkg/auth"
+	"neonexcore/pkg/cache"
 	"neonexcore/pkg/database"
+	"neonexcore/pkg/errors"
+	"neonexcore/pkg/events"
 	"neonexcore/pkg/logger"
 	"neonexcore/pkg/metrics"
+	"neonexcore/pkg/module"
+	"neonexcore/pkg/validation"
+	"neonexcore/pkg/webhook"
 	"neonexcore/pkg/websocket"
 
 	"github.com/gofiber/fiber/v2"
@@ -18,15 +30,31 @@ import (
 // 1) App Struct
 // -----------------------------------------------------------
 type App struct {
-	Registry   *ModuleRegistry
-	Container  *Container
-	Migrator   *database.Migrator
-	Logger     logger.Logger
-	WSHub      *websocket.Hub // WebSocket hub
-	Collector  *metrics.Collector
-	Dashboard  *metrics.Dashboard
+	Registry  *ModuleRegistry
+	Container *Container
+	Migrator  *database.Migrator
+	Logger    logger.Logger
+	WSHub     *websocket.Hub // WebSocket hub
+	Collector *metrics.Collector
+	Dashboard *metrics.Dashboard
+	Webhooks  *webhook.Dispatcher   // Set by InitDatabase
+	Outbox    *events.OutboxRelay   // Set by InitDatabase
+	Modules   *module.ModuleManager // Set by InitDatabase
+
+	// ShutdownTimeout bounds how long StartHTTP waits for in-flight
+	// requests and background workers after SIGINT/SIGTERM
+	ShutdownTimeout time.Duration
+
+	ctx          context.Context
+	cancel       context.CancelFunc
+	server       *fiber.App
+	shutdownOnce sync.Once
+	shutdownErr  error
 }
 
+// DefaultShutdownTimeout is used when App.ShutdownTimeout is not set
+const DefaultShutdownTimeout = 10 * time.Second
+
 // -----------------------------------------------------------
 // 2) NewApp() - สร้าง App + โหลด ModuleRegistry
 // -----------------------------------------------------------
@@ -34,28 +62,40 @@ func NewApp() *App {
 	// Initialize WebSocket hub
 	hubConfig := websocket.DefaultHubConfig()
 	wsHub := websocket.NewHub(hubConfig)
-	
+
 	// Initialize metrics collector
 	collectorConfig := metrics.DefaultCollectorConfig()
 	collectorConfig.CollectSystemMetrics = true
 	collectorConfig.SystemMetricsInterval = 5 * time.Second
 	collector := metrics.NewCollector(collectorConfig)
-	
+
 	// Initialize dashboard
 	dashConfig := metrics.DefaultDashboardConfig()
 	dashConfig.BroadcastInterval = 1 * time.Second
 	dashboard := metrics.NewDashboard(collector, wsHub, dashConfig)
-	
+
+	// Root context for background work; cancelled by Shutdown
+	ctx, cancel := context.WithCancel(context.Background())
+
 	return &App{
-		Registry:  NewModuleRegistry(),
-		Container: NewContainer(),
-		Logger:    logger.NewLogger(),
-		WSHub:     wsHub,
-		Collector: collector,
-		Dashboard: dashboard,
+		Registry:        NewModuleRegistry(),
+		Container:       NewContainer(),
+		Logger:          logger.NewLogger(),
+		WSHub:           wsHub,
+		Collector:       collector,
+		Dashboard:       dashboard,
+		ShutdownTimeout: DefaultShutdownTimeout,
+		ctx:             ctx,
+		cancel:          cancel,
 	}
 }
 
+// Context returns the application's root context. Background goroutines
+// should derive from it so they stop when the app shuts down.
+func (a *App) Context() context.Context {
+	return a.ctx
+}
+
 // -----------------------------------------------------------
 // 3) InitLogger() - Initialize Logger
 // -----------------------------------------------------------
@@ -63,7 +103,7 @@ func (a *App) InitLogger(cfg logger.Config) error {
 	if err := logger.Setup(cfg); err != nil {
 		return fmt.Errorf("failed to initialize logger: %w", err)
 	}
-	a.Logger = logger.NewLogger()
+	a.Logger = logger.Default()
 	a.Logger.Info("Logger initialized", logger.Fields{
 		"level":  cfg.Level,
 		"format": cfg.Format,
@@ -86,6 +126,56 @@ func (a *App) InitDatabase() error {
 	a.Migrator = database.NewMigrator(config.DB.GetDB())
 	a.Logger.Info("Database initialized", logger.Fields{"driver": dbConfig.Driver})
 
+	// Publish connection pool stats so the dashboard shows pool pressure
+	if sqlDB, err := config.DB.GetDB().DB(); err == nil {
+		a.Collector.CollectDBStats("primary", sqlDB, metrics.DefaultDBStatsInterval)
+	}
+	for i, replica := range config.DB.Replicas() {
+		if sqlDB, err := replica.DB(); err == nil {
+			a.Collector.CollectDBStats(fmt.Sprintf("replica_%d", i+1), sqlDB, metrics.DefaultDBStatsInterval)
+		}
+	}
+
+	// Record async events whose listeners keep failing
+	deadLetters, err := events.NewGormDeadLetterStore(config.DB.GetDB())
+	if err != nil {
+		return fmt.Errorf("failed to initialize event dead letter store: %w", err)
+	}
+	events.SetDeadLetterStore(deadLetters)
+
+	// Deliver alerts and selected domain events to subscribed webhooks
+	webhookStore, err := webhook.NewGormStore(config.DB.GetDB())
+	if err != nil {
+		return fmt.Errorf("failed to initialize webhook store: %w", err)
+	}
+	webhookConfig := config.LoadWebhookConfig()
+	deliveryConfig := webhook.DefaultConfig()
+	deliveryConfig.Timeout = webhookConfig.Timeout
+	deliveryConfig.MaxAttempts = webhookConfig.MaxAttempts
+	deliveryConfig.Retention = webhookConfig.Retention
+	a.Webhooks = webhook.NewDispatcher(webhookStore, deliveryConfig)
+	a.Webhooks.ForwardEvents(webhookConfig.Events...)
+	a.Dashboard.SetWebhooks(a.Webhooks)
+
+	// Publish events written to the outbox by committed transactions
+	a.Outbox, err = events.NewOutboxRelay(config.DB.GetDB(), nil, events.DefaultOutboxConfig())
+	if err != nil {
+		return fmt.Errorf("failed to initialize event outbox: %w", err)
+	}
+	a.Outbox.Start()
+
+	// Manage installed modules; the module watcher reloads them through it
+	a.Modules = module.NewModuleManager(
+		module.NewModuleRepository(config.DB.GetDB()),
+		config.DB.GetDB(),
+		database.NewTxManager(config.DB.GetDB()),
+		events.DefaultDispatcher(),
+		a.Logger,
+		validation.NewValidator(),
+		a.Registry.ModulesDir,
+	)
+	a.Registry.SetReloader(a.Modules)
+
 	return nil
 }
 
@@ -126,23 +216,63 @@ func (a *App) Boot() {
 // 8) StartHTTP() - HTTP Server Engine
 // -----------------------------------------------------------
 func (a *App) StartHTTP() {
+	// Request body limits; module archives may be larger than other bodies
+	bodyConfig := config.LoadBodyLimitConfig()
+	bodyLimits := api.BodyLimitConfig{
+		MaxBodySize:    bodyConfig.MaxBodySize,
+		MaxJSONDepth:   bodyConfig.MaxJSONDepth,
+		MaxJSONArray:   bodyConfig.MaxJSONArray,
+		RouteBodySizes: map[string]int{"/api/v1/modules/install/archive": module.MaxArchiveSize},
+	}
+	for prefix, size := range bodyConfig.RouteBodySizes {
+		bodyLimits.RouteBodySizes[prefix] = size
+	}
+
 	// Configure Fiber with custom branding
 	app := fiber.New(fiber.Config{
 		AppName:               "Neonex Core v0.1-alpha",
-		DisableStartupMessage: true, // Disable default Fiber banner
+		DisableStartupMessage: true,                          // Disable default Fiber banner
+		ErrorHandler:          errors.ErrorHandler(a.Logger), // Render AppErrors with the standard envelope
 	})
 
+	// Keep Fiber's default body limit for every route but those with a
+	// larger override, so only they buffer large bodies
+	app.Server().HeaderReceived = bodyLimits.RequestConfig
+
+	// Global middleware - Response compression
+	compressionConfig := config.LoadCompressionConfig()
+	if compressionConfig.Enabled {
+		compression := api.DefaultCompressionConfig()
+		compression.MinSize = compressionConfig.MinSize
+		app.Use(api.CompressionMiddleware(compression))
+	}
+
 	// Global middleware - CORS
-	app.Use(api.CORSMiddleware())
+	corsConfig := config.LoadCORSConfig()
+	app.Use(api.CORSMiddleware(api.CORSConfig{
+		AllowOrigins:     corsConfig.AllowOrigins,
+		AllowMethods:     corsConfig.AllowMethods,
+		AllowHeaders:     corsConfig.AllowHeaders,
+		ExposeHeaders:    corsConfig.ExposeHeaders,
+		AllowCredentials: corsConfig.AllowCredentials,
+		MaxAge:           corsConfig.MaxAge,
+	}))
+
+	// Page size limits shared by list endpoints
+	paginationConfig := config.LoadPaginationConfig()
+	api.SetPaginationDefaults(api.PaginationConfig{
+		DefaultLimit: paginationConfig.DefaultLimit,
+		MaxLimit:     paginationConfig.MaxLimit,
+		ClampLimit:   paginationConfig.ClampLimit,
+	})
 
 	// Global middleware - Security headers
 	app.Use(api.SecurityHeadersMiddleware())
 
-	// Global middleware - Request ID
-	app.Use(api.RequestIDMiddleware())
+	// Global middleware - Request ID, trace context and request-scoped logger
+	app.Use(logger.RequestIDMiddleware(a.Logger))
 
 	// Global middleware - Logger
-	app.Use(logger.RequestIDMiddleware(a.Logger))
 	app.Use(logger.HTTPMiddleware(a.Logger))
 
 	// Global middleware - Metrics
@@ -153,9 +283,13 @@ func (a *App) StartHTTP() {
 	// Global rate limiting (100 requests per minute per IP)
 	app.Use(api.IPRateLimitMiddleware(100, time.Minute))
 
+	// Global middleware - Body size and JSON nesting limits
+	app.Use(api.BodyLimitMiddleware(bodyLimits))
+
 	// Health check routes
 	healthChecker := api.NewHealthChecker("0.1-alpha", config.DB.GetDB())
-	api.SetupHealthRoutes(app, healthChecker, config.DB.GetDB())
+	healthChecker.SetPoolSaturation(config.DB.Config().PoolSaturation)
+	api.SetupHealthRoutes(app, healthChecker)
 
 	// API versioning
 	versionManager := api.NewVersionManager()
@@ -175,10 +309,30 @@ func (a *App) StartHTTP() {
 	// Load module routes
 	a.Logger.Info("Registering modules...")
 	a.Registry.RegisterModuleServices(a.Container)
+	a.Registry.LoadMiddleware(apiV1, a.Container)
 	a.Registry.LoadRoutes(apiV1, a.Container) // Load routes into /api/v1
 
+	// Subsystem health checks (services are registered by now)
+	if sharedCache := Resolve[cache.Cache](a.Container); sharedCache != nil {
+		healthChecker.RegisterCheckFunc("cache", api.CacheCheck(sharedCache))
+	}
+	a.Registry.RegisterHealthChecks(healthChecker)
+
+	// Development-only module hot reload
+	if watchConfig := config.LoadModuleWatchConfig(); watchConfig.Enabled {
+		a.Registry.WatchDebounce = watchConfig.Debounce
+		go func() {
+			if err := a.Registry.Watch(a.ctx); err != nil {
+				a.Logger.Error("Module watcher stopped", logger.Fields{"error": err.Error()})
+			}
+		}()
+	}
+
 	// Setup WebSocket routes
 	a.Logger.Info("Setting up WebSocket support...")
+	if jwtManager := Resolve[*auth.JWTManager](a.Container); jwtManager != nil {
+		a.WSHub.SetAuthenticator(websocket.JWTAuthenticator(jwtManager))
+	}
 	websocket.SetupRoutes(app, a.WSHub, nil) // nil = use default message handler
 
 	// Setup metrics dashboard
@@ -200,6 +354,9 @@ func (a *App) StartHTTP() {
 		})
 	})
 
+	// Document every mounted route; manual AddPath entries take precedence
+	swagger.RegisterFiberRoutes(app)
+
 	// Custom Neonex startup banner
 	fmt.Println()
 	fmt.Println("┌───────────────────────────────────────────────────┐")
@@ -217,8 +374,90 @@ func (a *App) StartHTTP() {
 	fmt.Println("└───────────────────────────────────────────────────┘")
 	fmt.Println()
 
-	a.Logger.Info("HTTP server starting", logger.Fields{"port": 8080})
-	if err := app.Listen(":8080"); err != nil {
-		a.Logger.Fatal("Failed to start server", logger.Fields{"error": err.Error()})
+	a.server = app
+
+	listenErr := make(chan error, 1)
+	go func() {
+		a.Logger.Info("HTTP server starting", logger.Fields{"port": 8080})
+		listenErr <- app.Listen(":8080")
+	}()
+
+	// Block until the server fails or a termination signal arrives
+	signalCtx, stop := signal.NotifyContext(a.ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	select {
+	case err := <-listenErr:
+		if err != nil {
+			a.Logger.Fatal("Failed to start server", logger.Fields{"error": err.Error()})
+		}
+	case <-signalCtx.Done():
+		a.Logger.Info("Shutdown signal received, stopping server...")
+	}
+
+	timeout := a.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = DefaultShutdownTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := a.Shutdown(ctx); err != nil {
+		a.Logger.Error("Graceful shutdown failed", logger.Fields{"error": err.Error()})
+		return
 	}
+	a.Logger.Info("Server stopped gracefully")
+}
+
+// -----------------------------------------------------------
+// 9) Shutdown() - Graceful shutdown
+// -----------------------------------------------------------
+
+// Shutdown stops the HTTP server, waiting for in-flight requests until ctx
+// expires, then cancels the root context and releases background workers,
+// WebSocket connections, container-managed resources (caches) and the
+// database connection. It is safe to call more than once.
+func (a *App) Shutdown(ctx context.Context) error {
+	a.shutdownOnce.Do(func() {
+		record := func(component string, err error) {
+			if err == nil {
+				return
+			}
+			a.Logger.Error("Shutdown error", logger.Fields{"component": component, "error": err.Error()})
+			if a.shutdownErr == nil {
+				a.shutdownErr = fmt.Errorf("%s: %w", component, err)
+			}
+		}
+
+		if a.server != nil {
+			record("http", a.server.ShutdownWithContext(ctx))
+		}
+
+		// Stop everything derived from the root context
+		a.cancel()
+
+		if a.Dashboard != nil {
+			record("dashboard", a.Dashboard.Close())
+		}
+		if a.Collector != nil {
+			record("metrics", a.Collector.Close())
+		}
+		if a.Outbox != nil {
+			record("outbox", a.Outbox.Close())
+		}
+		if a.Webhooks != nil {
+			record("webhooks", a.Webhooks.Close())
+		}
+		if a.WSHub != nil {
+			a.WSHub.Close()
+		}
+
+		record("container", a.Container.Close())
+
+		if config.DB != nil {
+			record("database", config.DB.Close())
+		}
+	})
+
+	return a.shutdownErr
 }
@@ -0,0 +1,90 @@
+package core
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// testCloser records whether the container closed it
+type testCloser struct {
+	closed bool
+}
+
+func (c *testCloser) Close() error {
+	c.closed = true
+	return nil
+}
+
+// waitForGoroutines waits until no more than want goroutines are running
+func waitForGoroutines(t *testing.T, want int) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > want {
+		if time.Now().After(deadline) {
+			buf := make([]byte, 1<<16)
+			t.Fatalf("%d goroutines still running, want at most %d:\n%s",
+				runtime.NumGoroutine(), want, buf[:runtime.Stack(buf, true)])
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestShutdownStopsBackgroundGoroutines(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+
+	app := NewApp()
+	closer := &testCloser{}
+	app.Container.Provide(func() *testCloser { return closer }, Singleton)
+	Resolve[*testCloser](app.Container)
+
+	// A worker derived from the root context, as modules start them
+	workerDone := make(chan struct{})
+	go func() {
+		defer close(workerDone)
+		<-app.Context().Done()
+	}()
+
+	if runtime.NumGoroutine() <= baseline {
+		t.Fatal("NewApp started no background goroutines")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := app.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	select {
+	case <-workerDone:
+	case <-time.After(time.Second):
+		t.Fatal("root context was not cancelled")
+	}
+	if !closer.closed {
+		t.Fatal("container singleton was not closed")
+	}
+	waitForGoroutines(t, baseline)
+
+	// Shutting down again is a no-op
+	if err := app.Shutdown(ctx); err != nil {
+		t.Fatalf("second Shutdown: %v", err)
+	}
+}
+
+func TestShutdownSkipsUnresolvedSingletons(t *testing.T) {
+	app := NewApp()
+	created := false
+	app.Container.Provide(func() *testCloser {
+		created = true
+		return &testCloser{}
+	}, Singleton)
+
+	if err := app.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if created {
+		t.Fatal("Shutdown instantiated a singleton only to close it")
+	}
+}
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"io"
 	"reflect"
 	"sync"
 )
@@ -81,3 +82,27 @@ func Resolve[T any](c *Container) T {
 
 	return zero
 }
+
+// Close closes every instantiated singleton that implements io.Closer
+// (caches, connection pools, ...) and returns the first error encountered
+func (c *Container) Close() error {
+	c.mu.Lock()
+	var closers []io.Closer
+	for _, provider := range c.providers {
+		if provider.Type != Singleton || provider.Instance == nil {
+			continue
+		}
+		if closer, ok := provider.Instance.(io.Closer); ok {
+			closers = append(closers, closer)
+		}
+	}
+	c.mu.Unlock()
+
+	var firstErr error
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -1,10 +1,15 @@
 package core
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
+	"time"
+
+	"neonexcore/pkg/api"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -16,13 +21,32 @@ type Module interface {
 	RegisterServices(c *Container)
 }
 
+// HealthReporter is implemented by modules that can report their own health.
+// Modules without it are reported healthy while loaded.
+type HealthReporter interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// MiddlewareProvider is implemented by modules that contribute middleware to
+// every module route. It is installed before any module routes are loaded.
+type MiddlewareProvider interface {
+	Middleware(c *Container) []fiber.Handler
+}
+
 type ModuleRegistry struct {
-	Modules []Module
+	Modules       []Module
+	ModulesDir    string        // Directory scanned by AutoDiscover and Watch
+	WatchDebounce time.Duration // Quiet period before Watch reloads a module
+
+	reloader ModuleReloader
+	mu       sync.Mutex
 }
 
 func NewModuleRegistry() *ModuleRegistry {
 	return &ModuleRegistry{
-		Modules: make([]Module, 0),
+		Modules:       make([]Module, 0),
+		ModulesDir:    "modules",
+		WatchDebounce: DefaultWatchDebounce,
 	}
 }
 
@@ -38,14 +62,44 @@ func (r *ModuleRegistry) Load() {
 	}
 }
 
+// LoadMiddleware installs middleware from modules implementing
+// MiddlewareProvider on the router
+func (r *ModuleRegistry) LoadMiddleware(app fiber.Router, c *Container) {
+	for _, m := range r.Modules {
+		if provider, ok := m.(MiddlewareProvider); ok {
+			for _, handler := range provider.Middleware(c) {
+				app.Use(handler)
+			}
+		}
+	}
+}
+
 func (r *ModuleRegistry) LoadRoutes(app fiber.Router, c *Container) {
 	for _, m := range r.Modules {
 		m.Routes(app, c)
 	}
 }
 
+// RegisterHealthChecks registers a "module:<name>" health check for every
+// registered module
+func (r *ModuleRegistry) RegisterHealthChecks(hc *api.HealthChecker) {
+	for _, m := range r.Modules {
+		name := "module:" + m.Name()
+		if reporter, ok := m.(HealthReporter); ok {
+			hc.RegisterErrorCheck(name, reporter.HealthCheck)
+			continue
+		}
+		hc.RegisterCheck(name, func() api.CheckResult {
+			return api.CheckResult{
+				Status:  api.HealthStatusHealthy,
+				Message: "Module loaded",
+			}
+		})
+	}
+}
+
 func (r *ModuleRegistry) AutoDiscover() {
-	entries, err := os.ReadDir("./modules")
+	entries, err := os.ReadDir(r.ModulesDir)
 	if err != nil {
 		fmt.Println("Cannot read modules folder:", err)
 		return
@@ -57,7 +111,7 @@ func (r *ModuleRegistry) AutoDiscover() {
 		}
 
 		moduleFolder := e.Name()
-		metaFile := filepath.Join("modules", moduleFolder, "module.json")
+		metaFile := filepath.Join(r.ModulesDir, moduleFolder, "module.json")
 
 		raw, err := os.ReadFile(metaFile)
 		if err != nil {
@@ -0,0 +1,188 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultWatchDebounce is how long Watch waits for file events to settle
+const DefaultWatchDebounce = 500 * time.Millisecond
+
+// ModuleReloader re-runs a module's lifecycle after its files change.
+// Implementations should skip modules that aren't active.
+type ModuleReloader interface {
+	ReloadModule(ctx context.Context, name string) error
+}
+
+// SetReloader sets the lifecycle hook Watch uses to reload changed modules
+func (r *ModuleRegistry) SetReloader(reloader ModuleReloader) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reloader = reloader
+}
+
+// Watch watches the modules directory and reloads a module when its files
+// change, until ctx is cancelled. Rapid events are debounced per module and
+// other modules are left untouched. Intended for development only.
+func (r *ModuleRegistry) Watch(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	if err := addWatchDirs(watcher, r.ModulesDir); err != nil {
+		return err
+	}
+
+	debounce := r.WatchDebounce
+	if debounce <= 0 {
+		debounce = DefaultWatchDebounce
+	}
+
+	var (
+		mu     sync.Mutex
+		timers = make(map[string]*time.Timer)
+	)
+	defer func() {
+		mu.Lock()
+		for _, t := range timers {
+			t.Stop()
+		}
+		mu.Unlock()
+	}()
+
+	fmt.Println("👀 Watching modules for changes:", r.ModulesDir)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+
+			// Track directories created after the watch started
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					addWatchDirs(watcher, event.Name)
+				}
+			}
+
+			folder := r.moduleFolder(event.Name)
+			if folder == "" {
+				continue
+			}
+
+			mu.Lock()
+			if t, exists := timers[folder]; exists {
+				t.Reset(debounce)
+			} else {
+				timers[folder] = time.AfterFunc(debounce, func() {
+					mu.Lock()
+					delete(timers, folder)
+					mu.Unlock()
+					r.reloadFolder(ctx, folder)
+				})
+			}
+			mu.Unlock()
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			fmt.Println("Module watcher error:", err)
+		}
+	}
+}
+
+// moduleFolder returns the module folder a changed path belongs to
+func (r *ModuleRegistry) moduleFolder(path string) string {
+	rel, err := filepath.Rel(r.ModulesDir, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	if len(parts) < 2 {
+		// A file directly in the modules dir, or the module folder itself
+		if info, err := os.Stat(path); err != nil || !info.IsDir() {
+			return ""
+		}
+	}
+	return parts[0]
+}
+
+// reloadFolder reloads the module stored in folder
+func (r *ModuleRegistry) reloadFolder(ctx context.Context, folder string) {
+	if ctx.Err() != nil {
+		return
+	}
+
+	raw, err := os.ReadFile(filepath.Join(r.ModulesDir, folder, "module.json"))
+	if err != nil {
+		return
+	}
+
+	var meta struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(raw, &meta); err != nil || meta.Name == "" {
+		fmt.Printf("Invalid metadata for module '%s', skipping reload\n", folder)
+		return
+	}
+
+	if err := r.Reload(ctx, meta.Name); err != nil {
+		fmt.Printf("Failed to reload module '%s': %v\n", meta.Name, err)
+	}
+}
+
+// Reload re-runs the lifecycle of a single module. With a reloader set it
+// deactivates and re-activates the module; otherwise a loaded module is
+// re-initialized. Modules that aren't loaded are ignored.
+func (r *ModuleRegistry) Reload(ctx context.Context, name string) error {
+	r.mu.Lock()
+	reloader := r.reloader
+	r.mu.Unlock()
+
+	if reloader != nil {
+		fmt.Println("♻️  Reloading module:", name)
+		return reloader.ReloadModule(ctx, name)
+	}
+
+	for _, m := range r.Modules {
+		if m.Name() == name {
+			fmt.Println("♻️  Re-initializing module:", name)
+			m.Init()
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// addWatchDirs adds root and every directory below it to the watcher
+func addWatchDirs(watcher *fsnotify.Watcher, root string) error {
+	return filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if err := watcher.Add(path); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", path, err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,90 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingReloader records the modules it was asked to reload
+type recordingReloader struct {
+	mu       sync.Mutex
+	reloaded []string
+}
+
+func (r *recordingReloader) ReloadModule(_ context.Context, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reloaded = append(r.reloaded, name)
+	return nil
+}
+
+func (r *recordingReloader) calls() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.reloaded...)
+}
+
+// writeTestModule creates a module folder holding module.json
+func writeTestModule(t *testing.T, modulesDir, folder, name string) {
+	t.Helper()
+
+	dir := filepath.Join(modulesDir, folder)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("create %s: %v", dir, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "module.json"), []byte(`{"name":"`+name+`"}`), 0o644); err != nil {
+		t.Fatalf("write module.json: %v", err)
+	}
+}
+
+func TestWatchReloadsChangedModule(t *testing.T) {
+	modulesDir := t.TempDir()
+	writeTestModule(t, modulesDir, "blog", "blog")
+	writeTestModule(t, modulesDir, "shop", "shop")
+
+	registry := NewModuleRegistry()
+	registry.ModulesDir = modulesDir
+	registry.WatchDebounce = 50 * time.Millisecond
+	reloader := &recordingReloader{}
+	registry.SetReloader(reloader)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	watchDone := make(chan error, 1)
+	go func() { watchDone <- registry.Watch(ctx) }()
+	defer func() {
+		cancel()
+		if err := <-watchDone; err != nil {
+			t.Errorf("Watch: %v", err)
+		}
+	}()
+
+	// Give the watcher time to register the directories
+	time.Sleep(100 * time.Millisecond)
+
+	// A burst of saves is one change
+	source := filepath.Join(modulesDir, "blog", "handler.go")
+	for i := 0; i < 5; i++ {
+		if err := os.WriteFile(source, []byte("package blog\n"), 0o644); err != nil {
+			t.Fatalf("write %s: %v", source, err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(reloader.calls()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the changed module was not reloaded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Wait out another debounce period to catch extra reloads
+	time.Sleep(150 * time.Millisecond)
+	if calls := reloader.calls(); len(calls) != 1 || calls[0] != "blog" {
+		t.Fatalf("reloaded %v, want only blog, once", calls)
+	}
+}
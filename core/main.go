@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
 
@@ -11,12 +12,16 @@ import (
 	"neonexcore/modules/user"
 	"neonexcore/pkg/api"
 	"neonexcore/pkg/database"
+	"neonexcore/pkg/flags"
 	"neonexcore/pkg/logger"
 	"neonexcore/pkg/module"
 	"neonexcore/pkg/rbac"
 )
 
 func main() {
+	fresh := flag.Bool("fresh", false, "Re-run all database seeders, including ones that already ran")
+	flag.Parse()
+
 	fmt.Println("Neonex Core v0.1 starting...")
 
 	// Register module factories
@@ -46,9 +51,11 @@ func main() {
 		&module.Module{},
 		&module.ModuleDependency{},
 		&module.ModuleMigration{},
+		&database.SeederRun{},
 		&admin.AuditLog{},
 		&admin.SystemSettings{},
 		&admin.BackupInfo{},
+		&flags.Flag{},
 	)
 
 	// Run auto-migration
@@ -56,28 +63,28 @@ func main() {
 		log.Fatalf("Failed to run migrations: %v", err)
 	}
 
-	// Seed RBAC data (roles and permissions)
+	// Seed database; each seeder runs once unless started with --fresh
 	ctx := context.Background()
 	rbacManager := rbac.NewManager(config.DB.GetDB())
-	
-	app.Logger.Info("Seeding default roles...")
-	if err := rbacManager.SeedDefaultRoles(ctx); err != nil {
-		log.Printf("Warning: Failed to seed roles: %v", err)
-	}
-
-	app.Logger.Info("Seeding user permissions...")
-	if err := seedUserPermissions(ctx, rbacManager); err != nil {
-		log.Printf("Warning: Failed to seed permissions: %v", err)
-	}
 
-	// Seed database (optional)
 	seeder := database.NewSeederManager(config.DB.GetDB())
+	seeder.Register(rbac.NewRoleSeeder(rbacManager))
 	seeder.Register(user.NewUserSeeder(config.DB.GetDB()))
 	seeder.Register(admin.NewAdminSeeder(config.DB.GetDB()))
-	if err := seeder.Run(context.Background()); err != nil {
+	runSeeders := seeder.Run
+	if *fresh {
+		runSeeders = seeder.RunFresh
+	}
+	if err := runSeeders(ctx); err != nil {
 		log.Printf("Warning: Seeding failed: %v", err)
 	}
 
+	// Seed user permissions (needs the default roles)
+	app.Logger.Info("Seeding user permissions...")
+	if err := seedUserPermissions(ctx, rbacManager); err != nil {
+		log.Printf("Warning: Failed to seed permissions: %v", err)
+	}
+
 	// Load modules
 	app.Registry.AutoDiscover()
 	app.Boot()
@@ -26,3 +26,16 @@ func (m *AdminModule) RegisterServices(c *core.Container) {
 func (m *AdminModule) Routes(router fiber.Router, c *core.Container) {
 	SetupRoutes(router, c)
 }
+
+// Middleware audits mutating requests on the configured route prefixes
+func (m *AdminModule) Middleware(c *core.Container) []fiber.Handler {
+	auditConfig := config.LoadAuditConfig()
+	if !auditConfig.Enabled {
+		return nil
+	}
+
+	writer := c.GetSingleton("admin.audit_writer").(*AuditWriter)
+	middlewareConfig := DefaultAuditMiddlewareConfig()
+	middlewareConfig.Prefixes = auditConfig.Prefixes
+	return []fiber.Handler{AuditMiddleware(writer, middlewareConfig)}
+}
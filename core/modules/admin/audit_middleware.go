@@ -0,0 +1,189 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"neonexcore/pkg/metrics"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// DefaultAuditBodyLimit caps the request body snapshot stored in an audit log
+const DefaultAuditBodyLimit = 4096
+
+// redactedValue replaces sensitive values in audited request bodies
+const redactedValue = "[REDACTED]"
+
+// AuditMiddlewareConfig configures AuditMiddleware
+type AuditMiddlewareConfig struct {
+	Prefixes        []string // Route prefixes to audit; empty audits every path
+	SensitiveFields []string // Body keys containing any of these (case-insensitive) are redacted
+	BodyLimit       int      // Maximum snapshot size in bytes; larger bodies are noted but not stored
+}
+
+// DefaultAuditMiddlewareConfig returns the default audit middleware config
+func DefaultAuditMiddlewareConfig() AuditMiddlewareConfig {
+	return AuditMiddlewareConfig{
+		Prefixes: []string{"/api/v1"},
+		SensitiveFields: []string{
+			"password", "secret", "token", "api_key", "apikey",
+			"private_key", "mnemonic", "signature", "authorization",
+		},
+		BodyLimit: DefaultAuditBodyLimit,
+	}
+}
+
+// AuditMiddleware records an audit log for every non-GET request under the
+// configured prefixes. It runs after the handler so the entry carries the
+// response status; password-like body fields are redacted before storing.
+// Entries are handed to writer, so requests don't wait for them to be stored.
+func AuditMiddleware(writer *AuditWriter, config AuditMiddlewareConfig) fiber.Handler {
+	if config.BodyLimit <= 0 {
+		config.BodyLimit = DefaultAuditBodyLimit
+	}
+
+	return func(c *fiber.Ctx) error {
+		method := c.Method()
+		if method == fiber.MethodGet || method == fiber.MethodHead || method == fiber.MethodOptions {
+			return c.Next()
+		}
+
+		prefix, ok := matchAuditPrefix(c.Path(), config.Prefixes)
+		if !ok {
+			return c.Next()
+		}
+
+		// Snapshot the body before the handler can consume it
+		body := sanitizeAuditBody(c.Body(), config)
+
+		err := c.Next()
+
+		status := metrics.ResponseStatus(c, err)
+		resource := auditResource(c.Route().Path, prefix)
+
+		log := &AuditLog{
+			Action:      fmt.Sprintf("%s.%s", resource, auditVerb(method)),
+			Resource:    resource,
+			ResourceID:  c.Params("id"),
+			Description: fmt.Sprintf("%s %s -> %d", method, c.Path(), status),
+			IPAddress:   c.IP(),
+			UserAgent:   c.Get(fiber.HeaderUserAgent),
+			Status:      "success",
+			CreatedAt:   time.Now(),
+		}
+		if userID, ok := c.Locals("user_id").(uint); ok {
+			log.UserID = userID
+		}
+		if email, ok := c.Locals("email").(string); ok {
+			log.Username = email
+		}
+		if status >= fiber.StatusBadRequest {
+			log.Status = "failed"
+			if err != nil {
+				log.ErrorMsg = err.Error()
+			}
+		}
+
+		metadata, _ := json.Marshal(map[string]interface{}{
+			"method": method,
+			"path":   c.Path(),
+			"route":  c.Route().Path,
+			"status": status,
+			"body":   body,
+		})
+		log.Metadata = string(metadata)
+
+		writer.Write(log)
+
+		return err
+	}
+}
+
+// matchAuditPrefix returns the configured prefix that path falls under
+func matchAuditPrefix(path string, prefixes []string) (string, bool) {
+	if len(prefixes) == 0 {
+		return "", true
+	}
+	for _, prefix := range prefixes {
+		prefix = strings.TrimSuffix(prefix, "/")
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return prefix, true
+		}
+	}
+	return "", false
+}
+
+// auditResource infers the resource from the first static segment of the
+// matched route after the prefix, e.g. "/api/v1/users/:id" -> "users"
+func auditResource(route, prefix string) string {
+	route = strings.TrimPrefix(route, prefix)
+	for _, segment := range strings.Split(route, "/") {
+		if segment != "" && !strings.HasPrefix(segment, ":") && !strings.HasPrefix(segment, "*") {
+			return segment
+		}
+	}
+	return "unknown"
+}
+
+// auditVerb maps an HTTP method to an audit action verb
+func auditVerb(method string) string {
+	switch method {
+	case fiber.MethodPost:
+		return "create"
+	case fiber.MethodPut, fiber.MethodPatch:
+		return "update"
+	case fiber.MethodDelete:
+		return "delete"
+	default:
+		return strings.ToLower(method)
+	}
+}
+
+// sanitizeAuditBody returns a JSON body with sensitive fields redacted.
+// Non-JSON and oversized bodies are summarized instead of stored.
+func sanitizeAuditBody(raw []byte, config AuditMiddlewareConfig) interface{} {
+	if len(raw) == 0 {
+		return nil
+	}
+	if len(raw) > config.BodyLimit {
+		return fmt.Sprintf("[%d bytes omitted]", len(raw))
+	}
+
+	var body interface{}
+	if err := json.Unmarshal(raw, &body); err != nil {
+		return fmt.Sprintf("[%d bytes non-JSON body omitted]", len(raw))
+	}
+	return redactAuditValue(body, config.SensitiveFields)
+}
+
+// redactAuditValue replaces sensitive keys at any depth
+func redactAuditValue(value interface{}, sensitive []string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if isSensitiveField(key, sensitive) {
+				v[key] = redactedValue
+			} else {
+				v[key] = redactAuditValue(item, sensitive)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactAuditValue(item, sensitive)
+		}
+	}
+	return value
+}
+
+func isSensitiveField(key string, sensitive []string) bool {
+	key = strings.ToLower(key)
+	for _, field := range sensitive {
+		if strings.Contains(key, strings.ToLower(field)) {
+			return true
+		}
+	}
+	return false
+}
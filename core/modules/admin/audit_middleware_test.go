@@ -0,0 +1,164 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func newTestAuditWriter(t *testing.T) (*AuditWriter, *gorm.DB) {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "audit.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(&AuditLog{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	writer := NewAuditWriter(NewRepository(db), AuditWriterConfig{FlushInterval: 10 * time.Millisecond})
+	t.Cleanup(func() { writer.Close() })
+	return writer, db
+}
+
+func newAuditTestApp(writer *AuditWriter) *fiber.App {
+	app := fiber.New()
+	app.Use(AuditMiddleware(writer, DefaultAuditMiddlewareConfig()))
+
+	app.Post("/api/v1/users", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusCreated)
+	})
+	app.Get("/api/v1/users", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+	app.Put("/api/v1/users/:id", func(c *fiber.Ctx) error {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid user")
+	})
+	app.Post("/health", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+	return app
+}
+
+func sendAuditTestRequest(t *testing.T, app *fiber.App, method, path, body string) {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	if _, err := app.Test(req); err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+}
+
+func TestAuditMiddlewareLogsPost(t *testing.T) {
+	writer, db := newTestAuditWriter(t)
+	app := newAuditTestApp(writer)
+
+	sendAuditTestRequest(t, app, fiber.MethodPost, "/api/v1/users",
+		`{"email":"jane@example.com","password":"hunter2","profile":{"api_key":"k-123","name":"Jane"}}`)
+	writer.Close()
+
+	var logs []AuditLog
+	if err := db.Find(&logs).Error; err != nil {
+		t.Fatalf("read audit logs: %v", err)
+	}
+	if len(logs) != 1 {
+		t.Fatalf("got %d audit logs, want 1", len(logs))
+	}
+
+	entry := logs[0]
+	if entry.Action != "users.create" || entry.Resource != "users" || entry.Status != "success" {
+		t.Fatalf("audit log = %+v, want a successful users.create entry", entry)
+	}
+
+	var metadata struct {
+		Method string                 `json:"method"`
+		Status int                    `json:"status"`
+		Body   map[string]interface{} `json:"body"`
+	}
+	if err := json.Unmarshal([]byte(entry.Metadata), &metadata); err != nil {
+		t.Fatalf("decode metadata: %v", err)
+	}
+	if metadata.Method != fiber.MethodPost || metadata.Status != fiber.StatusCreated {
+		t.Fatalf("metadata = %+v, want POST with status 201", metadata)
+	}
+	if metadata.Body["email"] != "jane@example.com" {
+		t.Errorf("email = %v, want it kept", metadata.Body["email"])
+	}
+	if metadata.Body["password"] != redactedValue {
+		t.Errorf("password = %v, want it redacted", metadata.Body["password"])
+	}
+	profile, _ := metadata.Body["profile"].(map[string]interface{})
+	if profile["api_key"] != redactedValue || profile["name"] != "Jane" {
+		t.Errorf("profile = %v, want api_key redacted and name kept", profile)
+	}
+	if strings.Contains(entry.Metadata, "hunter2") || strings.Contains(entry.Metadata, "k-123") {
+		t.Errorf("metadata %s contains a sensitive value", entry.Metadata)
+	}
+}
+
+func TestAuditMiddlewareSkipsReadsAndOtherPrefixes(t *testing.T) {
+	writer, db := newTestAuditWriter(t)
+	app := newAuditTestApp(writer)
+
+	sendAuditTestRequest(t, app, fiber.MethodGet, "/api/v1/users", "")
+	sendAuditTestRequest(t, app, fiber.MethodPost, "/health", "")
+	writer.Close()
+
+	var count int64
+	if err := db.Model(&AuditLog{}).Count(&count).Error; err != nil {
+		t.Fatalf("count audit logs: %v", err)
+	}
+	if count != 0 {
+		t.Fatalf("got %d audit logs, want 0", count)
+	}
+}
+
+func TestAuditMiddlewareLogsFailures(t *testing.T) {
+	writer, db := newTestAuditWriter(t)
+	app := newAuditTestApp(writer)
+
+	sendAuditTestRequest(t, app, fiber.MethodPut, "/api/v1/users/7", `{}`)
+	writer.Close()
+
+	var entry AuditLog
+	if err := db.First(&entry).Error; err != nil {
+		t.Fatalf("read audit log: %v", err)
+	}
+	if entry.Action != "users.update" || entry.ResourceID != "7" || entry.Status != "failed" || entry.ErrorMsg != "invalid user" {
+		t.Fatalf("audit log = %+v, want a failed users.update of 7", entry)
+	}
+}
+
+func TestAuditWriterWritesQueuedEntriesOnClose(t *testing.T) {
+	writer, db := newTestAuditWriter(t)
+
+	const count = 250
+	for i := 0; i < count; i++ {
+		if !writer.Write(&AuditLog{Action: fmt.Sprintf("test.%d", i)}) {
+			t.Fatalf("entry %d was dropped", i)
+		}
+	}
+	writer.Close()
+
+	if writer.Write(&AuditLog{Action: "test.closed"}) {
+		t.Fatal("Write after Close queued the entry")
+	}
+
+	var stored int64
+	if err := db.Model(&AuditLog{}).Count(&stored).Error; err != nil {
+		t.Fatalf("count audit logs: %v", err)
+	}
+	if stored != count {
+		t.Fatalf("stored %d audit logs, want %d", stored, count)
+	}
+}
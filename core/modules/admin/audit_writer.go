@@ -0,0 +1,140 @@
+package admin
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// AuditWriterConfig configures AuditWriter
+type AuditWriterConfig struct {
+	QueueSize     int           // Entries waiting to be written; further entries are dropped
+	BatchSize     int           // Maximum entries inserted per statement
+	FlushInterval time.Duration // Longest an entry waits for its batch to fill
+}
+
+// DefaultAuditWriterConfig returns the default audit writer config
+func DefaultAuditWriterConfig() AuditWriterConfig {
+	return AuditWriterConfig{
+		QueueSize:     1000,
+		BatchSize:     100,
+		FlushInterval: time.Second,
+	}
+}
+
+// AuditWriter stores audit logs in batches from a background worker, so
+// requests never wait on the database. Entries queued when the queue is full
+// are dropped and reported in the log. Close writes everything queued.
+type AuditWriter struct {
+	repo   *Repository
+	config AuditWriterConfig
+
+	mu      sync.RWMutex
+	closed  bool
+	entries chan *AuditLog
+	done    chan struct{}
+
+	dropped atomic.Uint64
+}
+
+// NewAuditWriter creates an audit writer and starts its worker
+func NewAuditWriter(repo *Repository, config AuditWriterConfig) *AuditWriter {
+	defaults := DefaultAuditWriterConfig()
+	if config.QueueSize <= 0 {
+		config.QueueSize = defaults.QueueSize
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaults.BatchSize
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = defaults.FlushInterval
+	}
+
+	w := &AuditWriter{
+		repo:    repo,
+		config:  config,
+		entries: make(chan *AuditLog, config.QueueSize),
+		done:    make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// Write queues an entry without blocking and reports whether it was queued
+func (w *AuditWriter) Write(entry *AuditLog) bool {
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	if entry.Status == "" {
+		entry.Status = "success"
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		w.dropped.Add(1)
+		return false
+	}
+
+	select {
+	case w.entries <- entry:
+		return true
+	default:
+		w.dropped.Add(1)
+		return false
+	}
+}
+
+// Close stops accepting entries and returns once the queued ones are written
+func (w *AuditWriter) Close() error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.entries)
+	}
+	w.mu.Unlock()
+
+	<-w.done
+	return nil
+}
+
+// run writes queued entries until the writer is closed
+func (w *AuditWriter) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*AuditLog, 0, w.config.BatchSize)
+	flush := func() {
+		if dropped := w.dropped.Swap(0); dropped > 0 {
+			log.Printf("Dropped %d audit logs: queue full", dropped)
+		}
+		if len(batch) == 0 {
+			return
+		}
+		if err := w.repo.CreateAuditLogs(context.Background(), batch); err != nil {
+			log.Printf("Failed to write %d audit logs: %v", len(batch), err)
+		}
+		batch = make([]*AuditLog, 0, w.config.BatchSize)
+	}
+
+	for {
+		select {
+		case entry, ok := <-w.entries:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, entry)
+			if len(batch) >= w.config.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package admin
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"neonexcore/pkg/errors"
+
+	"gorm.io/gorm"
+)
+
+// DefaultBackupDir is used when BACKUP_DIR is not set
+const DefaultBackupDir = "storage/backups"
+
+// backupTable is excluded from dumps so restoring never rewrites the backup history
+const backupTable = "backup_infos"
+
+// backupDir returns the configured backup directory
+func backupDir() string {
+	if dir := os.Getenv("BACKUP_DIR"); dir != "" {
+		return dir
+	}
+	return DefaultBackupDir
+}
+
+// CreateBackup records a pending backup and dumps all tables to a gzipped
+// JSON-lines file in the background. The returned BackupInfo is pending;
+// poll GetBackup until it moves to completed or failed.
+func (s *Service) CreateBackup(ctx context.Context, createdBy uint) (*BackupInfo, error) {
+	if !s.startJob() {
+		return nil, errors.New(errors.ErrCodeInternal, "Backups are unavailable while shutting down", http.StatusServiceUnavailable)
+	}
+	started := false
+	defer func() {
+		if !started {
+			s.jobs.Done()
+		}
+	}()
+
+	dir := backupDir()
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, errors.NewInternal("Failed to create backup directory").WithError(err)
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, errors.NewInternal("Failed to name backup").WithError(err)
+	}
+
+	now := time.Now()
+	info := &BackupInfo{
+		Filename:  fmt.Sprintf("backup-%s-%s.jsonl.gz", now.Format("20060102-150405"), hex.EncodeToString(suffix)),
+		Type:      "full",
+		Status:    BackupStatusPending,
+		StartedAt: now,
+		CreatedBy: createdBy,
+	}
+	if err := s.repo.CreateBackupInfo(ctx, info); err != nil {
+		return nil, errors.NewInternal("Failed to record backup").WithError(err)
+	}
+
+	// The dump outlives the request, so it runs under the service's
+	// context, which Close cancels, and on its own copy
+	job := *info
+	started = true
+	go s.runBackup(&job, filepath.Join(dir, info.Filename))
+
+	return info, nil
+}
+
+// runBackup writes the dump of a pending backup and records the outcome.
+// A backup interrupted by Close is recorded as failed and its partial
+// file removed.
+func (s *Service) runBackup(info *BackupInfo, path string) {
+	defer s.jobs.Done()
+
+	size, checksum, err := s.writeBackup(s.jobsCtx, path)
+	info.CompletedAt = time.Now()
+	if err != nil {
+		info.Status = BackupStatusFailed
+		info.ErrorMsg = err.Error()
+		if s.jobsCtx.Err() != nil {
+			info.ErrorMsg = "backup interrupted by shutdown"
+		}
+		os.Remove(path)
+	} else {
+		info.Status = BackupStatusCompleted
+		info.Size = size
+		info.Checksum = checksum
+	}
+
+	// The outcome is recorded even when the job was cancelled
+	if err := s.repo.UpdateBackupInfo(context.WithoutCancel(s.jobsCtx), info); err != nil {
+		log.Printf("Failed to record outcome of backup %d: %v", info.ID, err)
+	}
+}
+
+// writeBackup writes the dump to path and returns its size and sha256 checksum
+func (s *Service) writeBackup(ctx context.Context, path string) (int64, string, error) {
+	// O_EXCL: never overwrite another backup's file
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o640)
+	if err != nil {
+		return 0, "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	gz := gzip.NewWriter(io.MultiWriter(file, hash))
+
+	if err := s.repo.ExportTables(ctx, gz, backupTable); err != nil {
+		gz.Close()
+		return 0, "", err
+	}
+	if err := gz.Close(); err != nil {
+		return 0, "", err
+	}
+
+	stat, err := file.Stat()
+	if err != nil {
+		return 0, "", err
+	}
+
+	return stat.Size(), hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// GetBackups lists recorded backups, newest first
+func (s *Service) GetBackups(ctx context.Context) ([]BackupInfo, error) {
+	backups, err := s.repo.GetBackups(ctx)
+	if err != nil {
+		return nil, errors.NewInternal("Failed to retrieve backups").WithError(err)
+	}
+	return backups, nil
+}
+
+// GetBackup returns a recorded backup, e.g. to poll a pending one
+func (s *Service) GetBackup(ctx context.Context, id uint) (*BackupInfo, error) {
+	info, err := s.repo.GetBackupInfo(ctx, id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFound("Backup not found")
+		}
+		return nil, errors.NewInternal("Failed to retrieve backup").WithError(err)
+	}
+	return info, nil
+}
+
+// RestoreBackup restores the database from a completed backup after verifying its checksum
+func (s *Service) RestoreBackup(ctx context.Context, id uint) error {
+	info, err := s.repo.GetBackupInfo(ctx, id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.NewNotFound("Backup not found")
+		}
+		return errors.NewInternal("Failed to retrieve backup").WithError(err)
+	}
+
+	if info.Status != BackupStatusCompleted {
+		return errors.NewBadRequest("Only completed backups can be restored")
+	}
+
+	path := filepath.Join(backupDir(), info.Filename)
+	checksum, err := fileChecksum(path)
+	if err != nil {
+		return errors.NewInternal("Failed to read backup file").WithError(err)
+	}
+	if checksum != info.Checksum {
+		return errors.NewConflict("Backup file checksum mismatch")
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return errors.NewInternal("Failed to open backup file").WithError(err)
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return errors.NewInternal("Failed to decompress backup").WithError(err)
+	}
+	defer gz.Close()
+
+	if err := s.repo.ImportTables(ctx, gz); err != nil {
+		return errors.NewInternal("Restore failed").WithError(err)
+	}
+
+	// Settings were rewritten underneath the cache
+	if err := s.RefreshSettingsCache(ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// fileChecksum returns the hex sha256 of a file
+func fileChecksum(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,388 @@
+package admin
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// backupRecord is one line of a backup stream. The stream starts with a
+// manifest record listing the tables in restore order, then each table
+// starts with a header record (Row is nil) followed by its rows.
+type backupRecord struct {
+	Tables []string               `json:"tables,omitempty"`
+	Table  string                 `json:"table,omitempty"`
+	Row    map[string]interface{} `json:"row,omitempty"`
+	// Types names the columns of Row whose values JSON can't carry as is:
+	// "time" values are RFC 3339 strings and "bytes" values base64 strings
+	Types map[string]string `json:"types,omitempty"`
+}
+
+const (
+	backupTypeTime  = "time"
+	backupTypeBytes = "bytes"
+)
+
+// ExportTables streams every table except the excluded ones as JSON lines.
+// Tables are written parents first, so rows can be restored in stream order
+// without violating foreign keys. Each table starts with a header record so
+// empty tables are restored too.
+func (r *Repository) ExportTables(ctx context.Context, w io.Writer, exclude ...string) error {
+	db := r.db.WithContext(ctx)
+
+	all, err := db.Migrator().GetTables()
+	if err != nil {
+		return fmt.Errorf("failed to list tables: %w", err)
+	}
+
+	skip := make(map[string]bool, len(exclude))
+	for _, t := range exclude {
+		skip[t] = true
+	}
+
+	var tables []string
+	for _, table := range all {
+		if !skip[table] {
+			tables = append(tables, table)
+		}
+	}
+
+	tables, err = sortTablesByDependency(db, tables)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(backupRecord{Tables: tables}); err != nil {
+		return err
+	}
+
+	for _, table := range tables {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if err := encoder.Encode(backupRecord{Table: table}); err != nil {
+			return err
+		}
+
+		if err := exportTable(db, encoder, table); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// exportTable writes the rows of a table
+func exportTable(db *gorm.DB, encoder *json.Encoder, table string) error {
+	rows, err := db.Table(table).Rows()
+	if err != nil {
+		return fmt.Errorf("failed to read table %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return fmt.Errorf("failed to read table %s: %w", table, err)
+	}
+
+	// Scan into plain interfaces to get the driver's own values: int64,
+	// float64, bool, []byte, string, time.Time or nil
+	values := make([]interface{}, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return fmt.Errorf("failed to scan table %s: %w", table, err)
+		}
+		row := make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			row[column] = values[i]
+		}
+		if err := encoder.Encode(encodeBackupRow(table, row)); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// encodeBackupRow builds the record of a row, marking the values JSON would
+// otherwise turn into plain strings
+func encodeBackupRow(table string, row map[string]interface{}) backupRecord {
+	record := backupRecord{Table: table, Row: row}
+	for column, value := range row {
+		var kind string
+		switch v := value.(type) {
+		case time.Time:
+			kind = backupTypeTime
+			row[column] = v.Format(time.RFC3339Nano)
+		case []byte:
+			kind = backupTypeBytes
+			row[column] = base64.StdEncoding.EncodeToString(v)
+		default:
+			continue
+		}
+		if record.Types == nil {
+			record.Types = make(map[string]string)
+		}
+		record.Types[column] = kind
+	}
+	return record
+}
+
+// decodeBackupRow restores the Go values of a row read with UseNumber:
+// integers come back as int64 rather than float64, and the columns listed in
+// the record's Types as time.Time and []byte
+func decodeBackupRow(record backupRecord) (map[string]interface{}, error) {
+	row := record.Row
+	for column, value := range row {
+		switch v := value.(type) {
+		case json.Number:
+			if n, err := v.Int64(); err == nil {
+				row[column] = n
+			} else if f, err := v.Float64(); err == nil {
+				row[column] = f
+			} else {
+				row[column] = v.String()
+			}
+		case string:
+			switch record.Types[column] {
+			case backupTypeTime:
+				t, err := time.Parse(time.RFC3339Nano, v)
+				if err != nil {
+					return nil, fmt.Errorf("column %s: %w", column, err)
+				}
+				row[column] = t
+			case backupTypeBytes:
+				b, err := base64.StdEncoding.DecodeString(v)
+				if err != nil {
+					return nil, fmt.Errorf("column %s: %w", column, err)
+				}
+				row[column] = b
+			}
+		}
+	}
+	return row, nil
+}
+
+// ImportTables replaces table contents with the records of a backup stream
+// produced by ExportTables. Everything runs in a single transaction.
+func (r *Repository) ImportTables(ctx context.Context, reader io.Reader) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		scanner := bufio.NewScanner(reader)
+		scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+
+		var table string
+		var restored []string
+		var batch []map[string]interface{}
+		cleared := false
+
+		flush := func() error {
+			if len(batch) == 0 {
+				return nil
+			}
+			err := tx.Table(table).CreateInBatches(batch, 100).Error
+			batch = nil
+			return err
+		}
+
+		for scanner.Scan() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			decoder := json.NewDecoder(bytes.NewReader(scanner.Bytes()))
+			decoder.UseNumber()
+
+			var record backupRecord
+			if err := decoder.Decode(&record); err != nil {
+				return fmt.Errorf("corrupt backup record: %w", err)
+			}
+
+			// The manifest lists every table parents first: clear them
+			// children first so no foreign key is left dangling
+			if record.Tables != nil {
+				for i := len(record.Tables) - 1; i >= 0; i-- {
+					if err := clearTable(tx, record.Tables[i]); err != nil {
+						return err
+					}
+				}
+				cleared = true
+				continue
+			}
+
+			// A header record starts a new table. Backups made before
+			// manifests were written clear each table as it comes.
+			if record.Row == nil {
+				if err := flush(); err != nil {
+					return fmt.Errorf("failed to restore table %s: %w", table, err)
+				}
+				table = record.Table
+				restored = append(restored, table)
+				if !cleared {
+					if err := clearTable(tx, table); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+
+			row, err := decodeBackupRow(record)
+			if err != nil {
+				return fmt.Errorf("corrupt backup record for table %s: %w", table, err)
+			}
+
+			batch = append(batch, row)
+			if len(batch) >= 100 {
+				if err := flush(); err != nil {
+					return fmt.Errorf("failed to restore table %s: %w", table, err)
+				}
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+
+		if err := flush(); err != nil {
+			return fmt.Errorf("failed to restore table %s: %w", table, err)
+		}
+
+		return resetSequences(tx, restored)
+	})
+}
+
+// clearTable deletes every row of a table
+func clearTable(tx *gorm.DB, table string) error {
+	if err := tx.Exec("DELETE FROM " + tx.Statement.Quote(table)).Error; err != nil {
+		return fmt.Errorf("failed to clear table %s: %w", table, err)
+	}
+	return nil
+}
+
+// resetSequences moves the id sequences of restored tables past the restored
+// rows on PostgreSQL, where inserting explicit ids leaves them behind. Other
+// databases track auto-increment values from the rows themselves.
+func resetSequences(tx *gorm.DB, tables []string) error {
+	if tx.Dialector.Name() != "postgres" {
+		return nil
+	}
+
+	for _, table := range tables {
+		if !tx.Migrator().HasColumn(table, "id") {
+			continue
+		}
+
+		var sequence *string
+		if err := tx.Raw("SELECT pg_get_serial_sequence(?, 'id')", tx.Statement.Quote(table)).Scan(&sequence).Error; err != nil {
+			return fmt.Errorf("failed to find id sequence of %s: %w", table, err)
+		}
+		if sequence == nil {
+			continue
+		}
+
+		quoted := tx.Statement.Quote(table)
+		err := tx.Exec("SELECT setval(?, COALESCE(MAX(id), 1), MAX(id) IS NOT NULL) FROM "+quoted, *sequence).Error
+		if err != nil {
+			return fmt.Errorf("failed to reset id sequence of %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// sortTablesByDependency orders tables so each comes after the tables its
+// foreign keys reference. Tables in a reference cycle keep their relative
+// order at the end.
+func sortTablesByDependency(db *gorm.DB, tables []string) ([]string, error) {
+	included := make(map[string]bool, len(tables))
+	for _, table := range tables {
+		included[table] = true
+	}
+
+	parents := make(map[string]map[string]bool, len(tables))
+	for _, table := range tables {
+		refs, err := foreignKeyReferences(db, table)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read foreign keys of %s: %w", table, err)
+		}
+		for _, ref := range refs {
+			if ref == table || !included[ref] {
+				continue
+			}
+			if parents[table] == nil {
+				parents[table] = make(map[string]bool)
+			}
+			parents[table][ref] = true
+		}
+	}
+
+	remaining := append([]string(nil), tables...)
+	sort.Strings(remaining)
+
+	ordered := make([]string, 0, len(tables))
+	done := make(map[string]bool, len(tables))
+	for len(remaining) > 0 {
+		var next []string
+		for _, table := range remaining {
+			ready := true
+			for parent := range parents[table] {
+				if !done[parent] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				ordered = append(ordered, table)
+			} else {
+				next = append(next, table)
+			}
+		}
+
+		if len(next) == len(remaining) {
+			return append(ordered, next...), nil
+		}
+		for _, table := range ordered {
+			done[table] = true
+		}
+		remaining = next
+	}
+
+	return ordered, nil
+}
+
+// foreignKeyReferences lists the tables the foreign keys of a table
+// reference. Dialects it doesn't know report none.
+func foreignKeyReferences(db *gorm.DB, table string) ([]string, error) {
+	var query string
+	switch db.Dialector.Name() {
+	case "postgres":
+		query = `SELECT DISTINCT ccu.table_name
+			FROM information_schema.table_constraints tc
+			JOIN information_schema.constraint_column_usage ccu
+				ON ccu.constraint_name = tc.constraint_name AND ccu.constraint_schema = tc.constraint_schema
+			WHERE tc.constraint_type = 'FOREIGN KEY' AND tc.table_schema = CURRENT_SCHEMA() AND tc.table_name = ?`
+	case "mysql":
+		query = `SELECT DISTINCT referenced_table_name
+			FROM information_schema.key_column_usage
+			WHERE table_schema = DATABASE() AND table_name = ? AND referenced_table_name IS NOT NULL`
+	case "sqlite":
+		query = `SELECT DISTINCT "table" FROM pragma_foreign_key_list(?)`
+	default:
+		return nil, nil
+	}
+
+	var refs []string
+	err := db.Raw(query, table).Scan(&refs).Error
+	return refs, err
+}
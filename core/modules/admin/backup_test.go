@@ -0,0 +1,71 @@
+package admin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// blockReads makes the first read on db wait until its context is done,
+// closing started once it is waiting
+func blockReads(t *testing.T, db *gorm.DB) <-chan struct{} {
+	t.Helper()
+
+	started := make(chan struct{})
+	var once sync.Once
+	err := db.Callback().Row().Before("gorm:row").Register("test:block_reads", func(tx *gorm.DB) {
+		once.Do(func() {
+			close(started)
+			<-tx.Statement.Context.Done()
+		})
+	})
+	if err != nil {
+		t.Fatalf("register row callback: %v", err)
+	}
+	return started
+}
+
+func TestCloseInterruptsRunningBackup(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("BACKUP_DIR", dir)
+
+	service, db := newTestService(t)
+	if err := db.AutoMigrate(&BackupInfo{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	started := blockReads(t, db)
+
+	info, err := service.CreateBackup(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("CreateBackup: %v", err)
+	}
+	<-started
+	path := filepath.Join(dir, info.Filename)
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("backup file while running: %v", err)
+	}
+
+	if err := service.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// Close waited for the job to record its outcome
+	got, err := service.GetBackup(context.Background(), info.ID)
+	if err != nil {
+		t.Fatalf("GetBackup: %v", err)
+	}
+	if got.Status != BackupStatusFailed || got.ErrorMsg == "" || got.CompletedAt.IsZero() {
+		t.Fatalf("backup = %+v, want failed with an error", got)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("partial backup file left behind: %v", err)
+	}
+
+	if _, err := service.CreateBackup(context.Background(), 1); err == nil {
+		t.Fatal("started a backup after Close")
+	}
+}
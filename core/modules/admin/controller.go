@@ -1,10 +1,19 @@
 package admin
 
 import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
 	"strconv"
+	"strings"
 	"time"
 
 	"neonexcore/pkg/api"
+	"neonexcore/pkg/errors"
+	"neonexcore/pkg/logger"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -103,7 +112,7 @@ func (c *Controller) GetModuleStats(ctx *fiber.Ctx) error {
 // @Failure 500 {object} api.Response
 // @Router /admin/health [get]
 func (c *Controller) GetSystemHealth(ctx *fiber.Ctx) error {
-	health := c.service.GetSystemHealth()
+	health := c.service.GetSystemHealth(ctx.Context())
 	return api.Success(ctx, health)
 }
 
@@ -118,13 +127,178 @@ func (c *Controller) GetSystemHealth(ctx *fiber.Ctx) error {
 // @Param user_id query int false "Filter by user ID"
 // @Param action query string false "Filter by action"
 // @Param resource query string false "Filter by resource"
+// @Param start_date query string false "Filter from date (YYYY-MM-DD or RFC3339)"
+// @Param end_date query string false "Filter until date (YYYY-MM-DD or RFC3339)"
+// @Param filter query string false "Filters as filter[field][op]=value on action, resource, resource_id, status, username, ip_address and description"
 // @Success 200 {object} api.Response{data=[]AuditLog}
 // @Failure 500 {object} api.Response
+// @Failure 400 {object} api.Response
 // @Router /admin/audit-logs [get]
 func (c *Controller) GetAuditLogs(ctx *fiber.Ctx) error {
-	pagination := api.GetPagination(ctx)
-	
-	// Build filters
+	pagination, err := api.ParsePagination(ctx, api.PaginationConfig{DefaultLimit: 20})
+	if err != nil {
+		return err
+	}
+
+	filters, err := auditLogFilters(ctx)
+	if err != nil {
+		return err
+	}
+
+	logs, total, err := c.service.GetAuditLogs(ctx.Context(), pagination.Page, pagination.Limit, filters)
+	if err != nil {
+		return api.InternalError(ctx, err.Error())
+	}
+
+	meta := api.CalculateMeta(pagination.Page, pagination.Limit, int(total))
+	return api.Paginated(ctx, logs, meta)
+}
+
+// ExportAuditLogs downloads all matching audit logs as CSV or JSON
+// @Summary Export audit logs
+// @Description Stream all audit logs matching the filters as a downloadable file
+// @Tags Admin
+// @Security BearerAuth
+// @Produce text/csv,json
+// @Param format query string false "Export format (csv or json)" default(csv)
+// @Param user_id query int false "Filter by user ID"
+// @Param action query string false "Filter by action"
+// @Param resource query string false "Filter by resource"
+// @Param start_date query string false "Filter from date (YYYY-MM-DD or RFC3339)"
+// @Param end_date query string false "Filter until date (YYYY-MM-DD or RFC3339)"
+// @Param filter query string false "Filters as filter[field][op]=value on action, resource, resource_id, status, username, ip_address and description"
+// @Success 200 {file} file
+// @Failure 400 {object} api.Response
+// @Router /admin/audit-logs/export [get]
+func (c *Controller) ExportAuditLogs(ctx *fiber.Ctx) error {
+	format := ctx.Query("format", "csv")
+	if format != "csv" && format != "json" {
+		return api.BadRequest(ctx, "Unsupported export format, use csv or json", nil)
+	}
+
+	filters, err := auditLogFilters(ctx)
+	if err != nil {
+		return err
+	}
+
+	filename := fmt.Sprintf("audit-logs-%s.%s", time.Now().Format("20060102-150405"), format)
+	ctx.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+	if format == "csv" {
+		ctx.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	} else {
+		ctx.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+	}
+
+	// The export runs after the handler returns, so it cannot use the
+	// request context. The response is closed once the client stops reading.
+	reader, writer := io.Pipe()
+	go func() {
+		w := bufio.NewWriter(writer)
+		var err error
+		if format == "csv" {
+			err = writeAuditLogsCSV(context.Background(), c.service, filters, w)
+		} else {
+			err = writeAuditLogsJSON(context.Background(), c.service, filters, w)
+		}
+		if err == nil {
+			err = w.Flush()
+		}
+		// On error the connection is closed before the final chunk, so
+		// clients see a truncated response rather than a complete export
+		writer.CloseWithError(err)
+	}()
+	ctx.Context().SetBodyStream(reader, -1)
+
+	return nil
+}
+
+// auditLogCSVHeader is the header row of CSV exports
+var auditLogCSVHeader = []string{
+	"id", "created_at", "user_id", "username", "action", "resource", "resource_id",
+	"description", "ip_address", "user_agent", "status", "error_message", "metadata",
+}
+
+// writeAuditLogsCSV streams logs as CSV, flushing periodically
+func writeAuditLogsCSV(ctx context.Context, service *Service, filters map[string]interface{}, w *bufio.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(auditLogCSVHeader); err != nil {
+		return err
+	}
+
+	count := 0
+	err := service.ExportAuditLogs(ctx, filters, func(log *AuditLog) error {
+		count++
+		if count%500 == 0 {
+			writer.Flush()
+			if err := writer.Error(); err != nil {
+				return err
+			}
+		}
+		return writer.Write([]string{
+			strconv.FormatUint(uint64(log.ID), 10),
+			log.CreatedAt.Format(time.RFC3339),
+			strconv.FormatUint(uint64(log.UserID), 10),
+			log.Username,
+			log.Action,
+			log.Resource,
+			log.ResourceID,
+			log.Description,
+			log.IPAddress,
+			log.UserAgent,
+			log.Status,
+			log.ErrorMsg,
+			log.Metadata,
+		})
+	})
+
+	writer.Flush()
+	if err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+// writeAuditLogsJSON streams logs as a JSON array without buffering the whole result
+func writeAuditLogsJSON(ctx context.Context, service *Service, filters map[string]interface{}, w *bufio.Writer) error {
+	if _, err := w.WriteString("["); err != nil {
+		return err
+	}
+	first := true
+	err := service.ExportAuditLogs(ctx, filters, func(log *AuditLog) error {
+		if !first {
+			if _, err := w.WriteString(","); err != nil {
+				return err
+			}
+		}
+		first = false
+
+		data, err := json.Marshal(log)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	_, err = w.WriteString("]")
+	return err
+}
+
+// auditLogFilterFields declares the fields audit logs may filter on with filter[field][op]=value
+var auditLogFilterFields = api.FilterFields{
+	"action":      {Operators: []api.FilterOperator{api.FilterEq, api.FilterIn}},
+	"resource":    {Operators: []api.FilterOperator{api.FilterEq, api.FilterIn}},
+	"resource_id": {Operators: []api.FilterOperator{api.FilterEq}},
+	"status":      {Operators: []api.FilterOperator{api.FilterEq, api.FilterIn}},
+	"username":    {Operators: []api.FilterOperator{api.FilterEq, api.FilterLike}},
+	"ip_address":  {Operators: []api.FilterOperator{api.FilterEq}},
+	"description": {Operators: []api.FilterOperator{api.FilterLike}},
+}
+
+// auditLogFilters builds audit log filters from query params
+func auditLogFilters(ctx *fiber.Ctx) (map[string]interface{}, error) {
 	filters := make(map[string]interface{})
 	if userID := ctx.QueryInt("user_id", 0); userID > 0 {
 		filters["user_id"] = uint(userID)
@@ -135,14 +309,41 @@ func (c *Controller) GetAuditLogs(ctx *fiber.Ctx) error {
 	if resource := ctx.Query("resource"); resource != "" {
 		filters["resource"] = resource
 	}
+	if start := ctx.Query("start_date"); start != "" {
+		t, err := parseDateParam(start)
+		if err != nil {
+			return nil, errors.NewBadRequest("invalid start_date")
+		}
+		filters["start_date"] = t
+	}
+	if end := ctx.Query("end_date"); end != "" {
+		t, err := parseDateParam(end)
+		if err != nil {
+			return nil, errors.NewBadRequest("invalid end_date")
+		}
+		// A bare date includes the whole day
+		if len(end) == len("2006-01-02") {
+			t = t.Add(24*time.Hour - time.Nanosecond)
+		}
+		filters["end_date"] = t
+	}
 
-	logs, total, err := c.service.GetAuditLogs(ctx.Context(), pagination.Page, pagination.Limit, filters)
+	dsl, err := api.ParseFilters(ctx, auditLogFilterFields)
 	if err != nil {
-		return api.InternalError(ctx, err.Error())
+		return nil, err
+	}
+	if len(dsl) > 0 {
+		filters["filter"] = dsl
 	}
+	return filters, nil
+}
 
-	meta := api.CalculateMeta(pagination.Page, pagination.Limit, int(total))
-	return api.Paginated(ctx, logs, meta)
+// parseDateParam accepts YYYY-MM-DD or RFC3339
+func parseDateParam(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
 }
 
 // GetActivitySummary retrieves activity summary
@@ -197,7 +398,7 @@ func (c *Controller) GetSettings(ctx *fiber.Ctx) error {
 
 // GetSetting retrieves a specific setting
 // @Summary Get a setting
-// @Description Get a specific system setting by key
+// @Description Get a specific system setting by key. The ETag header carries the setting's version.
 // @Tags Admin
 // @Security BearerAuth
 // @Produce json
@@ -214,6 +415,7 @@ func (c *Controller) GetSetting(ctx *fiber.Ctx) error {
 		return api.NotFound(ctx, "Setting not found")
 	}
 
+	api.SetVersionETag(ctx, setting.Version)
 	return api.Success(ctx, setting)
 }
 
@@ -245,7 +447,7 @@ func (c *Controller) CreateSetting(ctx *fiber.Ctx) error {
 	}
 
 	if err := c.service.CreateSetting(ctx.Context(), &setting); err != nil {
-		return api.InternalError(ctx, err.Error())
+		return err
 	}
 
 	return api.Created(ctx, setting)
@@ -253,28 +455,36 @@ func (c *Controller) CreateSetting(ctx *fiber.Ctx) error {
 
 // UpdateSetting updates an existing setting
 // @Summary Update a setting
-// @Description Update an existing system setting
+// @Description Update an existing system setting. Send the version from GET as If-Match (or as "version" in the body) to fail with 409 if the setting changed meanwhile.
 // @Tags Admin
 // @Security BearerAuth
 // @Accept json
 // @Produce json
 // @Param key path string true "Setting key"
+// @Param If-Match header string false "ETag of the version being updated"
 // @Param setting body map[string]string true "Setting value"
 // @Success 200 {object} api.Response{data=SystemSettings}
 // @Failure 400 {object} api.Response
 // @Failure 404 {object} api.Response
+// @Failure 409 {object} api.Response
 // @Failure 500 {object} api.Response
 // @Router /admin/settings/{key} [put]
 func (c *Controller) UpdateSetting(ctx *fiber.Ctx) error {
 	key := ctx.Params("key")
 	
 	var body struct {
-		Value string `json:"value"`
+		Value   string `json:"value"`
+		Version *uint  `json:"version"`
 	}
 	if err := ctx.BodyParser(&body); err != nil {
 		return api.BadRequest(ctx, "Invalid request body")
 	}
 
+	version, conditional, err := api.ExpectedVersion(ctx, body.Version)
+	if err != nil {
+		return err
+	}
+
 	// Get current user ID
 	var userID uint
 	if uid := ctx.Locals("user_id"); uid != nil {
@@ -283,12 +493,20 @@ func (c *Controller) UpdateSetting(ctx *fiber.Ctx) error {
 		}
 	}
 
-	if err := c.service.UpdateSetting(ctx.Context(), key, body.Value, userID); err != nil {
-		return api.InternalError(ctx, err.Error())
+	if conditional {
+		err = c.service.UpdateSettingIfVersion(ctx.Context(), key, body.Value, userID, version)
+	} else {
+		err = c.service.UpdateSetting(ctx.Context(), key, body.Value, userID)
+	}
+	if err != nil {
+		return err
 	}
 
 	// Return updated setting
 	setting, _ := c.service.GetSetting(ctx.Context(), key)
+	if setting != nil {
+		api.SetVersionETag(ctx, setting.Version)
+	}
 	return api.Success(ctx, setting)
 }
 
@@ -313,6 +531,160 @@ func (c *Controller) DeleteSetting(ctx *fiber.Ctx) error {
 	return api.NoContent(ctx)
 }
 
+// GetLogLevels lists the runtime log level overrides
+// @Summary Get log level overrides
+// @Description List log level overrides by logger name; "root" applies to all loggers
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} api.Response{data=map[string]string}
+// @Router /admin/logging/levels [get]
+func (c *Controller) GetLogLevels(ctx *fiber.Ctx) error {
+	levels := make(map[string]string)
+	for name, level := range logger.LevelOverrides() {
+		levels[name] = strings.ToLower(level.String())
+	}
+
+	return api.Success(ctx, levels)
+}
+
+// SetLogLevel overrides a logger's level at runtime
+// @Summary Set a log level
+// @Description Override the level of a logger and its descendants (e.g. "http", "module"), or of all loggers with "root". Overrides are not persisted.
+// @Tags Admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param name path string true "Logger name"
+// @Param level body map[string]string true "Level: debug, info, warn, error or fatal"
+// @Success 200 {object} api.Response{data=map[string]string}
+// @Failure 400 {object} api.Response
+// @Router /admin/logging/levels/{name} [put]
+func (c *Controller) SetLogLevel(ctx *fiber.Ctx) error {
+	name := ctx.Params("name")
+
+	var body struct {
+		Level string `json:"level"`
+	}
+	if err := ctx.BodyParser(&body); err != nil {
+		return errors.NewBadRequest("Invalid request body")
+	}
+
+	level, err := logger.ParseLevel(body.Level)
+	if err != nil {
+		return errors.NewBadRequest(err.Error())
+	}
+
+	logger.SetLevelFor(name, level)
+	return api.Success(ctx, map[string]string{
+		"logger": name,
+		"level":  strings.ToLower(level.String()),
+	})
+}
+
+// ClearLogLevel removes a logger's level override
+// @Summary Clear a log level
+// @Description Remove the level override of a logger so it falls back to its parent's or its configured level
+// @Tags Admin
+// @Security BearerAuth
+// @Param name path string true "Logger name"
+// @Success 204 "No Content"
+// @Router /admin/logging/levels/{name} [delete]
+func (c *Controller) ClearLogLevel(ctx *fiber.Ctx) error {
+	logger.ClearLevelFor(ctx.Params("name"))
+	return api.NoContent(ctx)
+}
+
+// CreateBackup starts a full database backup
+// @Summary Create a backup
+// @Description Start dumping the database to the backup directory; poll the returned backup until it completes
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Success 202 {object} api.Response{data=BackupInfo}
+// @Failure 500 {object} api.Response
+// @Router /admin/backups [post]
+func (c *Controller) CreateBackup(ctx *fiber.Ctx) error {
+	userID, _ := getUserInfo(ctx)
+
+	backup, err := c.service.CreateBackup(ctx.UserContext(), userID)
+	if err != nil {
+		return err
+	}
+
+	return api.Accepted(ctx, "Backup started", backup)
+}
+
+// GetBackup returns a recorded backup
+// @Summary Get a backup
+// @Description Get a recorded backup, e.g. to poll the status of a pending one
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Backup ID"
+// @Success 200 {object} api.Response{data=BackupInfo}
+// @Failure 400 {object} api.Response
+// @Failure 404 {object} api.Response
+// @Failure 500 {object} api.Response
+// @Router /admin/backups/{id} [get]
+func (c *Controller) GetBackup(ctx *fiber.Ctx) error {
+	id, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	if err != nil {
+		return api.BadRequest(ctx, "Invalid backup ID", nil)
+	}
+
+	backup, err := c.service.GetBackup(ctx.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	return api.Success(ctx, backup)
+}
+
+// GetBackups lists recorded backups
+// @Summary List backups
+// @Description List recorded database backups, newest first
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} api.Response{data=[]BackupInfo}
+// @Failure 500 {object} api.Response
+// @Router /admin/backups [get]
+func (c *Controller) GetBackups(ctx *fiber.Ctx) error {
+	backups, err := c.service.GetBackups(ctx.UserContext())
+	if err != nil {
+		return err
+	}
+
+	return api.Success(ctx, backups)
+}
+
+// RestoreBackup restores the database from a backup
+// @Summary Restore a backup
+// @Description Replace database contents with a completed backup
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Backup ID"
+// @Success 200 {object} api.Response
+// @Failure 400 {object} api.Response
+// @Failure 404 {object} api.Response
+// @Failure 409 {object} api.Response
+// @Failure 500 {object} api.Response
+// @Router /admin/backups/{id}/restore [post]
+func (c *Controller) RestoreBackup(ctx *fiber.Ctx) error {
+	id, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	if err != nil {
+		return api.BadRequest(ctx, "Invalid backup ID", nil)
+	}
+
+	if err := c.service.RestoreBackup(ctx.UserContext(), uint(id)); err != nil {
+		return err
+	}
+
+	return api.SuccessWithMessage(ctx, "Backup restored successfully", nil)
+}
+
 // Helper to get user info from context
 func getUserInfo(ctx *fiber.Ctx) (uint, string) {
 	var userID uint
@@ -0,0 +1,290 @@
+package admin
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"neonexcore/pkg/errors"
+	"neonexcore/pkg/logger"
+
+	"github.com/glebarez/sqlite"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func newTestService(t *testing.T) (*Service, *gorm.DB) {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "admin.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(&AuditLog{}, &SystemSettings{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return NewService(NewRepository(db)), db
+}
+
+func newExportTestApp(t *testing.T) (*fiber.App, *gorm.DB) {
+	t.Helper()
+
+	service, db := newTestService(t)
+	ctrl := NewController(service)
+
+	app := fiber.New(fiber.Config{ErrorHandler: errors.ErrorHandler(logger.Default())})
+	app.Get("/audit-logs/export", ctrl.ExportAuditLogs)
+	return app, db
+}
+
+func seedAuditLogs(t *testing.T, db *gorm.DB, logs ...AuditLog) {
+	t.Helper()
+
+	for i := range logs {
+		if err := db.Create(&logs[i]).Error; err != nil {
+			t.Fatalf("create audit log: %v", err)
+		}
+	}
+}
+
+// exportAuditLogs downloads an export and returns the status and body
+func exportAuditLogs(t *testing.T, app *fiber.App, query string) (int, string) {
+	t.Helper()
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/audit-logs/export"+query, nil), -1)
+	if err != nil {
+		t.Fatalf("export %s: %v", query, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read export %s: %v", query, err)
+	}
+	return resp.StatusCode, string(body)
+}
+
+func exportCSVRows(t *testing.T, app *fiber.App, query string) [][]string {
+	t.Helper()
+
+	status, body := exportAuditLogs(t, app, query)
+	if status != fiber.StatusOK {
+		t.Fatalf("export %s returned %d: %s", query, status, body)
+	}
+	rows, err := csv.NewReader(strings.NewReader(body)).ReadAll()
+	if err != nil {
+		t.Fatalf("parse csv %q: %v", body, err)
+	}
+	return rows
+}
+
+func TestExportAuditLogsCSV(t *testing.T) {
+	app, db := newExportTestApp(t)
+	seedAuditLogs(t, db,
+		AuditLog{UserID: 1, Username: "jane", Action: "create", Resource: "users", Description: "said \"hi\", then left", Status: "success"},
+		AuditLog{UserID: 2, Username: "john", Action: "delete", Resource: "posts", Status: "failed"},
+	)
+
+	rows := exportCSVRows(t, app, "")
+	if len(rows) != 3 {
+		t.Fatalf("export has %d rows, want a header and 2 logs", len(rows))
+	}
+	for i, column := range auditLogCSVHeader {
+		if rows[0][i] != column {
+			t.Fatalf("header = %v, want %v", rows[0], auditLogCSVHeader)
+		}
+	}
+	// Values with quotes and commas survive the round trip
+	if got := rows[1][7]; got != "said \"hi\", then left" {
+		t.Fatalf("description = %q", got)
+	}
+}
+
+func TestExportAuditLogsFilters(t *testing.T) {
+	app, db := newExportTestApp(t)
+	old := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+	seedAuditLogs(t, db,
+		AuditLog{UserID: 1, Action: "create", Resource: "users", Status: "success", CreatedAt: old},
+		AuditLog{UserID: 1, Action: "delete", Resource: "users", Status: "success"},
+		AuditLog{UserID: 2, Action: "delete", Resource: "posts", Status: "failed"},
+	)
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"?user_id=1", 2},
+		{"?action=delete", 2},
+		{"?user_id=1&action=delete", 1},
+		{"?filter[status][eq]=failed", 1},
+		{"?end_date=2024-01-10", 1},
+		{"?start_date=2024-01-11", 2},
+		{"?action=missing", 0},
+	}
+	for _, tt := range tests {
+		// Rows after the header
+		if got := len(exportCSVRows(t, app, tt.query)) - 1; got != tt.want {
+			t.Errorf("export%s has %d logs, want %d", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestExportAuditLogsJSON(t *testing.T) {
+	app, db := newExportTestApp(t)
+	seedAuditLogs(t, db,
+		AuditLog{UserID: 1, Action: "create", Resource: "users"},
+		AuditLog{UserID: 2, Action: "delete", Resource: "posts"},
+	)
+
+	status, body := exportAuditLogs(t, app, "?format=json&action=delete")
+	if status != fiber.StatusOK {
+		t.Fatalf("export returned %d: %s", status, body)
+	}
+	var logs []AuditLog
+	if err := json.Unmarshal([]byte(body), &logs); err != nil {
+		t.Fatalf("decode %q: %v", body, err)
+	}
+	if len(logs) != 1 || logs[0].Action != "delete" {
+		t.Fatalf("export = %+v, want the delete log", logs)
+	}
+}
+
+func TestExportAuditLogsRejectsInvalidParams(t *testing.T) {
+	app, _ := newExportTestApp(t)
+
+	for _, query := range []string{"?format=xml", "?start_date=yesterday"} {
+		if status, _ := exportAuditLogs(t, app, query); status != fiber.StatusBadRequest {
+			t.Errorf("export%s returned %d, want 400", query, status)
+		}
+	}
+}
+
+func TestUpdateSettingIsConditionalOnVersion(t *testing.T) {
+	service, _ := newTestService(t)
+	createTestSetting(t, service, "site.name", "Neonex")
+	ctrl := NewController(service)
+
+	app := fiber.New(fiber.Config{ErrorHandler: errors.ErrorHandler(logger.Default())})
+	app.Get("/settings/:key", ctrl.GetSetting)
+	app.Put("/settings/:key", ctrl.UpdateSetting)
+
+	send := func(t *testing.T, method, body string, headers ...string) *http.Response {
+		t.Helper()
+		var reader io.Reader
+		if body != "" {
+			reader = strings.NewReader(body)
+		}
+		req := httptest.NewRequest(method, "/settings/site.name", reader)
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatalf("%s: %v", method, err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	etag := send(t, fiber.MethodGet, "").Header.Get(fiber.HeaderETag)
+	if etag != `"1"` {
+		t.Fatalf("GET ETag = %q, want \"1\"", etag)
+	}
+
+	resp := send(t, fiber.MethodPut, `{"value":"Neonex Core"}`, fiber.HeaderIfMatch, etag)
+	if resp.StatusCode != fiber.StatusOK || resp.Header.Get(fiber.HeaderETag) != `"2"` {
+		t.Fatalf("conditional update: status %d, ETag %q; want 200 and \"2\"", resp.StatusCode, resp.Header.Get(fiber.HeaderETag))
+	}
+
+	// Writers still holding version 1 are turned away
+	if resp := send(t, fiber.MethodPut, `{"value":"Other"}`, fiber.HeaderIfMatch, etag); resp.StatusCode != fiber.StatusConflict {
+		t.Fatalf("stale If-Match returned %d, want 409", resp.StatusCode)
+	}
+	if resp := send(t, fiber.MethodPut, `{"value":"Other","version":1}`); resp.StatusCode != fiber.StatusConflict {
+		t.Fatalf("stale body version returned %d, want 409", resp.StatusCode)
+	}
+	assertSettingValue(t, service, "site.name", "Neonex Core")
+
+	// Unconditional updates still go through
+	if resp := send(t, fiber.MethodPut, `{"value":"Neonex 2"}`); resp.StatusCode != fiber.StatusOK || resp.Header.Get(fiber.HeaderETag) != `"3"` {
+		t.Fatalf("unconditional update: status %d, ETag %q", resp.StatusCode, resp.Header.Get(fiber.HeaderETag))
+	}
+}
+
+func TestUpdateSettingIfVersionLosesRace(t *testing.T) {
+	service, db := newTestService(t)
+	createTestSetting(t, service, "site.name", "Neonex")
+	assertSettingValue(t, service, "site.name", "Neonex")
+
+	// Another writer bumps the version after the service read it
+	db.Callback().Update().Before("gorm:update").Register("test:concurrent_write", func(tx *gorm.DB) {
+		tx.Session(&gorm.Session{NewDB: true}).Exec("UPDATE system_settings SET version = version + 1 WHERE key = ?", "site.name")
+	})
+
+	err := service.UpdateSettingIfVersion(context.Background(), "site.name", "Mine", 0, 1)
+	if appErr, ok := err.(*errors.AppError); !ok || appErr.StatusCode != fiber.StatusConflict {
+		t.Fatalf("UpdateSettingIfVersion = %v, want a conflict", err)
+	}
+}
+
+func TestLogLevelEndpoints(t *testing.T) {
+	t.Cleanup(func() { logger.ClearLevelFor("module") })
+	service, _ := newTestService(t)
+	ctrl := NewController(service)
+
+	app := fiber.New(fiber.Config{ErrorHandler: errors.ErrorHandler(logger.Default())})
+	app.Get("/logging/levels", ctrl.GetLogLevels)
+	app.Put("/logging/levels/:name", ctrl.SetLogLevel)
+	app.Delete("/logging/levels/:name", ctrl.ClearLogLevel)
+
+	send := func(t *testing.T, method, path, body string) (int, map[string]string) {
+		t.Helper()
+		var reader io.Reader
+		if body != "" {
+			reader = strings.NewReader(body)
+		}
+		req := httptest.NewRequest(method, path, reader)
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+		defer resp.Body.Close()
+
+		var envelope struct {
+			Data map[string]string `json:"data"`
+		}
+		json.NewDecoder(resp.Body).Decode(&envelope)
+		return resp.StatusCode, envelope.Data
+	}
+
+	status, data := send(t, fiber.MethodPut, "/logging/levels/module", `{"level":"WARN"}`)
+	if status != fiber.StatusOK || data["logger"] != "module" || data["level"] != "warn" {
+		t.Fatalf("set level: status %d, data %v", status, data)
+	}
+	if level := logger.LevelOverrides()["module"]; level != logger.WarnLevel {
+		t.Fatalf("module override = %v, want warn", level)
+	}
+	if _, data := send(t, fiber.MethodGet, "/logging/levels", ""); data["module"] != "warn" {
+		t.Fatalf("levels = %v, want module at warn", data)
+	}
+
+	if status, _ := send(t, fiber.MethodPut, "/logging/levels/module", `{"level":"loud"}`); status != fiber.StatusBadRequest {
+		t.Fatalf("unknown level returned %d, want 400", status)
+	}
+
+	if status, _ := send(t, fiber.MethodDelete, "/logging/levels/module", ""); status != fiber.StatusNoContent {
+		t.Fatalf("clear level returned %d, want 204", status)
+	}
+	if _, ok := logger.LevelOverrides()["module"]; ok {
+		t.Fatal("override kept after clearing it")
+	}
+}
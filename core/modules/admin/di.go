@@ -12,10 +12,20 @@ func RegisterDependencies(container *core.Container, db *gorm.DB) {
 		return NewRepository(db)
 	})
 
-	// Register Service as Singleton
+	// Register Service as Singleton; the container closes it on shutdown,
+	// cancelling running backups
 	container.RegisterSingleton("admin.service", func() interface{} {
 		repo := container.GetSingleton("admin.repository").(*Repository)
-		return NewService(repo)
+		service := NewService(repo)
+		RegisterEventListeners(service)
+		return service
+	})
+
+	// Register the audit writer as Singleton; the container closes it on
+	// shutdown, writing any queued entries
+	container.RegisterSingleton("admin.audit_writer", func() interface{} {
+		repo := container.GetSingleton("admin.repository").(*Repository)
+		return NewAuditWriter(repo, DefaultAuditWriterConfig())
 	})
 
 	// Register Controller as Singleton
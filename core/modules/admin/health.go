@@ -0,0 +1,184 @@
+package admin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"neonexcore/pkg/metrics"
+)
+
+// DefaultDataDir is used when DATA_DIR is not set
+const DefaultDataDir = "storage"
+
+// dataDir returns the directory whose filesystem is reported as disk usage
+func dataDir() string {
+	if dir := os.Getenv("DATA_DIR"); dir != "" {
+		return dir
+	}
+	return DefaultDataDir
+}
+
+// existingDir returns dir or its nearest existing parent, so disk usage can
+// be reported before the data directory has been created
+func existingDir(dir string) string {
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+	}
+}
+
+// SystemStats is a raw resource sample that system health is derived from
+type SystemStats struct {
+	MemoryAllocBytes uint64
+	MemorySysBytes   uint64
+	NumGC            uint32
+	Goroutines       int
+	CPU              metrics.CPUStats
+	Disk             metrics.DiskStats
+	DiskErr          error
+}
+
+// SystemStatsFunc returns the current resource sample
+type SystemStatsFunc func() SystemStats
+
+// readSystemStats samples the running process and the data directory's disk
+func readSystemStats() SystemStats {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	disk, diskErr := metrics.DiskUsage(existingDir(dataDir()))
+
+	return SystemStats{
+		MemoryAllocBytes: m.Alloc,
+		MemorySysBytes:   m.Sys,
+		NumGC:            m.NumGC,
+		Goroutines:       runtime.NumGoroutine(),
+		CPU:              metrics.CPU(),
+		Disk:             disk,
+		DiskErr:          diskErr,
+	}
+}
+
+// HealthThresholds are the limits above which a dimension degrades health
+type HealthThresholds struct {
+	MemoryDegradedMB    float64
+	MemoryCriticalMB    float64
+	GoroutinesDegraded  int
+	GoroutinesCritical  int
+	CPUDegradedPercent  float64
+	CPUCriticalPercent  float64
+	DiskDegradedPercent float64
+	DiskCriticalPercent float64
+}
+
+// DefaultHealthThresholds returns the default health thresholds
+func DefaultHealthThresholds() HealthThresholds {
+	return HealthThresholds{
+		MemoryDegradedMB:    1000,
+		MemoryCriticalMB:    2000,
+		GoroutinesDegraded:  1000,
+		GoroutinesCritical:  10000,
+		CPUDegradedPercent:  80,
+		CPUCriticalPercent:  95,
+		DiskDegradedPercent: 85,
+		DiskCriticalPercent: 95,
+	}
+}
+
+// Settings that override the default health thresholds
+const (
+	SettingHealthMemoryDegradedMB    = "health.memory_degraded_mb"
+	SettingHealthMemoryCriticalMB    = "health.memory_critical_mb"
+	SettingHealthGoroutinesDegraded  = "health.goroutines_degraded"
+	SettingHealthGoroutinesCritical  = "health.goroutines_critical"
+	SettingHealthCPUDegradedPercent  = "health.cpu_degraded_percent"
+	SettingHealthCPUCriticalPercent  = "health.cpu_critical_percent"
+	SettingHealthDiskDegradedPercent = "health.disk_degraded_percent"
+	SettingHealthDiskCriticalPercent = "health.disk_critical_percent"
+)
+
+// healthThresholds reads the health thresholds from settings. Missing,
+// unparsable or non-positive values use the defaults, as does any pair whose
+// degraded limit is above its critical limit.
+func (s *Service) healthThresholds(ctx context.Context) HealthThresholds {
+	defaults := DefaultHealthThresholds()
+	t := defaults
+
+	t.MemoryDegradedMB, t.MemoryCriticalMB = s.thresholdPair(ctx,
+		SettingHealthMemoryDegradedMB, SettingHealthMemoryCriticalMB,
+		defaults.MemoryDegradedMB, defaults.MemoryCriticalMB)
+
+	degraded, critical := s.thresholdPair(ctx,
+		SettingHealthGoroutinesDegraded, SettingHealthGoroutinesCritical,
+		float64(defaults.GoroutinesDegraded), float64(defaults.GoroutinesCritical))
+	t.GoroutinesDegraded, t.GoroutinesCritical = int(degraded), int(critical)
+
+	t.CPUDegradedPercent, t.CPUCriticalPercent = s.thresholdPair(ctx,
+		SettingHealthCPUDegradedPercent, SettingHealthCPUCriticalPercent,
+		defaults.CPUDegradedPercent, defaults.CPUCriticalPercent)
+
+	t.DiskDegradedPercent, t.DiskCriticalPercent = s.thresholdPair(ctx,
+		SettingHealthDiskDegradedPercent, SettingHealthDiskCriticalPercent,
+		defaults.DiskDegradedPercent, defaults.DiskCriticalPercent)
+
+	return t
+}
+
+// thresholdPair reads a degraded/critical setting pair
+func (s *Service) thresholdPair(ctx context.Context, degradedKey, criticalKey string, degradedDefault, criticalDefault float64) (float64, float64) {
+	degraded := s.thresholdSetting(ctx, degradedKey, degradedDefault)
+	critical := s.thresholdSetting(ctx, criticalKey, criticalDefault)
+	if degraded > critical {
+		return degradedDefault, criticalDefault
+	}
+	return degraded, critical
+}
+
+// thresholdSetting reads a positive number from a setting
+func (s *Service) thresholdSetting(ctx context.Context, key string, defaultValue float64) float64 {
+	setting, err := s.GetSetting(ctx, key)
+	if err != nil {
+		return defaultValue
+	}
+
+	value, err := strconv.ParseFloat(strings.TrimSpace(setting.Value), 64)
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+	return value
+}
+
+// healthStatus returns "healthy", "degraded" or "critical" for a health
+// report. Disk usage only counts when it could be read.
+func (t HealthThresholds) healthStatus(health *SystemHealth, diskKnown bool) string {
+	diskUsage := health.DiskUsagePercent
+	if !diskKnown {
+		diskUsage = 0
+	}
+
+	if health.MemoryUsageMB > t.MemoryCriticalMB ||
+		health.GoroutineCount > t.GoroutinesCritical ||
+		health.CPUUsage > t.CPUCriticalPercent ||
+		diskUsage > t.DiskCriticalPercent {
+		return "critical"
+	}
+
+	if health.MemoryUsageMB > t.MemoryDegradedMB ||
+		health.GoroutineCount > t.GoroutinesDegraded ||
+		health.CPUUsage > t.CPUDegradedPercent ||
+		diskUsage > t.DiskDegradedPercent {
+		return "degraded"
+	}
+
+	return "healthy"
+}
@@ -0,0 +1,144 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"neonexcore/pkg/metrics"
+)
+
+// healthyStats is a resource sample below every default threshold
+func healthyStats() SystemStats {
+	return SystemStats{
+		MemoryAllocBytes: 200 << 20,
+		MemorySysBytes:   300 << 20,
+		Goroutines:       50,
+		CPU:              metrics.CPUStats{ProcessPercent: 20, PerCore: []float64{15, 25}},
+		Disk: metrics.DiskStats{
+			Path:        "/data",
+			TotalBytes:  100 << 30,
+			UsedBytes:   40 << 30,
+			FreeBytes:   60 << 30,
+			UsedPercent: 40,
+		},
+	}
+}
+
+func TestSystemHealthStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*SystemStats)
+		want   string
+	}{
+		{"healthy", func(*SystemStats) {}, "healthy"},
+		{"cpu degraded", func(s *SystemStats) { s.CPU.ProcessPercent = 85 }, "degraded"},
+		{"cpu critical", func(s *SystemStats) { s.CPU.ProcessPercent = 97 }, "critical"},
+		{"disk degraded", func(s *SystemStats) { s.Disk.UsedPercent = 90 }, "degraded"},
+		{"disk critical", func(s *SystemStats) { s.Disk.UsedPercent = 98 }, "critical"},
+		{"memory degraded", func(s *SystemStats) { s.MemoryAllocBytes = 1500 << 20 }, "degraded"},
+		{"goroutines critical", func(s *SystemStats) { s.Goroutines = 20000 }, "critical"},
+		{"worst dimension wins", func(s *SystemStats) {
+			s.CPU.ProcessPercent = 85
+			s.Disk.UsedPercent = 98
+		}, "critical"},
+		// An unreadable disk doesn't count against health
+		{"disk unknown", func(s *SystemStats) {
+			s.Disk = metrics.DiskStats{UsedPercent: 99}
+			s.DiskErr = errors.New("statfs: permission denied")
+		}, "healthy"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, _ := newTestService(t)
+			stats := healthyStats()
+			tt.modify(&stats)
+			service.SetSystemStatsSource(func() SystemStats { return stats })
+
+			if health := service.GetSystemHealth(context.Background()); health.Status != tt.want {
+				t.Fatalf("status = %s, want %s (health %+v)", health.Status, tt.want, health)
+			}
+		})
+	}
+}
+
+func TestSystemHealthReportsCPUAndDisk(t *testing.T) {
+	service, _ := newTestService(t)
+	service.SetSystemStatsSource(healthyStats)
+
+	health := service.GetSystemHealth(context.Background())
+	if health.CPUUsage != 20 || health.DiskUsagePercent != 40 || health.MemoryUsageMB != 200 {
+		t.Fatalf("health = %+v", health)
+	}
+	if perCore, _ := health.Details["cpu_per_core_percent"].([]float64); len(perCore) != 2 || perCore[1] != 25 {
+		t.Fatalf("cpu_per_core_percent = %v", health.Details["cpu_per_core_percent"])
+	}
+	want := map[string]interface{}{
+		"disk_path":     "/data",
+		"disk_total_mb": float64(100 << 10),
+		"disk_used_mb":  float64(40 << 10),
+		"disk_free_mb":  float64(60 << 10),
+	}
+	for key, value := range want {
+		if health.Details[key] != value {
+			t.Fatalf("%s = %v, want %v", key, health.Details[key], value)
+		}
+	}
+
+	service.SetSystemStatsSource(func() SystemStats {
+		stats := healthyStats()
+		stats.DiskErr = errors.New("statfs: permission denied")
+		return stats
+	})
+	health = service.GetSystemHealth(context.Background())
+	if health.Details["disk_error"] != "statfs: permission denied" || health.Details["disk_path"] != nil {
+		t.Fatalf("details = %v, want only the disk error", health.Details)
+	}
+}
+
+func TestSystemHealthThresholdsFromSettings(t *testing.T) {
+	service, _ := newTestService(t)
+	service.SetSystemStatsSource(healthyStats)
+	ctx := context.Background()
+
+	// 200MB of memory is healthy by default
+	createTestSetting(t, service, SettingHealthMemoryDegradedMB, "100")
+	createTestSetting(t, service, SettingHealthMemoryCriticalMB, "500")
+	if status := service.GetSystemHealth(ctx).Status; status != "degraded" {
+		t.Fatalf("status = %s, want degraded with memory_degraded_mb=100", status)
+	}
+
+	// Changes apply to the next report
+	if err := service.UpdateSetting(ctx, SettingHealthMemoryCriticalMB, "150", 1); err != nil {
+		t.Fatalf("UpdateSetting: %v", err)
+	}
+	if status := service.GetSystemHealth(ctx).Status; status != "critical" {
+		t.Fatalf("status = %s, want critical with memory_critical_mb=150", status)
+	}
+
+	createTestSetting(t, service, SettingHealthGoroutinesDegraded, "40")
+	createTestSetting(t, service, SettingHealthDiskCriticalPercent, "30")
+	thresholds := service.healthThresholds(ctx)
+	if thresholds.GoroutinesDegraded != 40 || thresholds.GoroutinesCritical != 10000 {
+		t.Fatalf("thresholds = %+v, want goroutines_degraded=40", thresholds)
+	}
+	// The default disk degraded 85% is above the critical 30%, so the pair
+	// is ignored
+	if thresholds.DiskDegradedPercent != 85 || thresholds.DiskCriticalPercent != 95 || thresholds.CPUDegradedPercent != 80 {
+		t.Fatalf("thresholds = %+v, want the defaults for unset and inconsistent pairs", thresholds)
+	}
+}
+
+func TestSystemHealthThresholdsIgnoreInvalidSettings(t *testing.T) {
+	for _, value := range []string{"", "abc", "0", "-5", "1e400"} {
+		t.Run(value, func(t *testing.T) {
+			service, _ := newTestService(t)
+			createTestSetting(t, service, SettingHealthCPUDegradedPercent, value)
+			createTestSetting(t, service, SettingHealthGoroutinesCritical, value)
+
+			if got := service.healthThresholds(context.Background()); got != DefaultHealthThresholds() {
+				t.Fatalf("thresholds = %+v, want the defaults", got)
+			}
+		})
+	}
+}
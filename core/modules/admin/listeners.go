@@ -0,0 +1,27 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"neonexcore/pkg/events"
+)
+
+// RegisterEventListeners records security-relevant events in the audit log
+func RegisterEventListeners(service *Service) {
+	events.Subscribe(events.EventUserLockedOut, func(ctx context.Context, event events.UserLockedOutEvent) error {
+		metadata, _ := json.Marshal(event)
+
+		return service.LogActivity(ctx, &AuditLog{
+			Username:    event.Email,
+			Action:      "auth.lockout",
+			Resource:    "user",
+			ResourceID:  event.Email,
+			Description: fmt.Sprintf("Account %s locked after repeated failed logins", event.Email),
+			IPAddress:   event.IPAddress,
+			Status:      "failed",
+			Metadata:    string(metadata),
+		})
+	})
+}
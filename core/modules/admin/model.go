@@ -101,18 +101,31 @@ type SystemSettings struct {
 	Category          string    `json:"category" gorm:"index"`
 	Description       string    `json:"description"`
 	IsPublic          bool      `json:"is_public"` // Can be accessed without admin rights
+	AllowedValues     []string  `json:"allowed_values,omitempty" gorm:"serializer:json"` // Optional whitelist of values
+	Min               *float64  `json:"min,omitempty"` // Optional lower bound for int settings
+	Max               *float64  `json:"max,omitempty"` // Optional upper bound for int settings
 	UpdatedBy         uint      `json:"updated_by"`
+	Version           uint      `json:"version" gorm:"not null;default:1"` // Incremented on every update, used as the ETag
 	CreatedAt         time.Time `json:"created_at"`
 	UpdatedAt         time.Time `json:"updated_at"`
 }
 
+// Backup statuses
+const (
+	BackupStatusPending   = "pending"
+	BackupStatusCompleted = "completed"
+	BackupStatusFailed    = "failed"
+)
+
 // BackupInfo represents backup information
 type BackupInfo struct {
 	ID          uint      `json:"id" gorm:"primarykey"`
 	Filename    string    `json:"filename"`
 	Size        int64     `json:"size"`
-	Type        string    `json:"type"` // full, incremental
-	Status      string    `json:"status"` // success, failed, in_progress
+	Checksum    string    `json:"checksum"` // sha256 of the backup file
+	Type        string    `json:"type"`     // full, incremental
+	Status      string    `json:"status"`   // pending, completed, failed
+	ErrorMsg    string    `json:"error_message,omitempty"`
 	StartedAt   time.Time `json:"started_at"`
 	CompletedAt time.Time `json:"completed_at"`
 	CreatedBy   uint      `json:"created_by"`
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"neonexcore/modules/user"
+	"neonexcore/pkg/api"
 	"neonexcore/pkg/module"
 	"neonexcore/pkg/rbac"
 
@@ -173,14 +174,54 @@ func (r *Repository) CreateAuditLog(ctx context.Context, log *AuditLog) error {
 	return r.db.WithContext(ctx).Create(log).Error
 }
 
+// CreateAuditLogs creates several audit log entries in one statement
+func (r *Repository) CreateAuditLogs(ctx context.Context, logs []*AuditLog) error {
+	return r.db.WithContext(ctx).Create(&logs).Error
+}
+
 // GetAuditLogs retrieves audit logs with pagination
 func (r *Repository) GetAuditLogs(ctx context.Context, page, limit int, filters map[string]interface{}) ([]AuditLog, int64, error) {
 	var logs []AuditLog
 	var total int64
 
-	query := r.db.WithContext(ctx).Model(&AuditLog{})
+	query := applyAuditFilters(r.db.WithContext(ctx).Model(&AuditLog{}), filters)
+
+	// Count total
+	query.Count(&total)
+
+	// Get paginated results
+	offset := (page - 1) * limit
+	err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&logs).Error
+
+	return logs, total, err
+}
+
+// StreamAuditLogs calls fn for every audit log matching the filters, oldest first,
+// reading rows one at a time so large histories are never held in memory
+func (r *Repository) StreamAuditLogs(ctx context.Context, filters map[string]interface{}, fn func(*AuditLog) error) error {
+	query := applyAuditFilters(r.db.WithContext(ctx).Model(&AuditLog{}), filters)
 
-	// Apply filters
+	rows, err := query.Order("created_at ASC").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var log AuditLog
+		if err := r.db.ScanRows(rows, &log); err != nil {
+			return err
+		}
+		if err := fn(&log); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// applyAuditFilters applies the supported audit log filters to a query
+func applyAuditFilters(query *gorm.DB, filters map[string]interface{}) *gorm.DB {
 	if userID, ok := filters["user_id"].(uint); ok {
 		query = query.Where("user_id = ?", userID)
 	}
@@ -196,15 +237,10 @@ func (r *Repository) GetAuditLogs(ctx context.Context, page, limit int, filters
 	if endDate, ok := filters["end_date"].(time.Time); ok {
 		query = query.Where("created_at <= ?", endDate)
 	}
-
-	// Count total
-	query.Count(&total)
-
-	// Get paginated results
-	offset := (page - 1) * limit
-	err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&logs).Error
-
-	return logs, total, err
+	if filter, ok := filters["filter"].(api.Filters); ok {
+		query = filter.Apply(query)
+	}
+	return query
 }
 
 // GetActivitySummary retrieves activity summary
@@ -298,9 +334,52 @@ func (r *Repository) UpdateSetting(ctx context.Context, key, value string, updat
 			"value":      value,
 			"updated_by": updatedBy,
 			"updated_at": time.Now(),
+			"version":    gorm.Expr("version + 1"),
 		}).Error
 }
 
+// UpdateSettingIfVersion updates a setting only if its version still matches,
+// reporting whether it did
+func (r *Repository) UpdateSettingIfVersion(ctx context.Context, key, value string, updatedBy, version uint) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&SystemSettings{}).
+		Where("key = ? AND version = ?", key, version).
+		Updates(map[string]interface{}{
+			"value":      value,
+			"updated_by": updatedBy,
+			"updated_at": time.Now(),
+			"version":    gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
 func (r *Repository) DeleteSetting(ctx context.Context, key string) error {
 	return r.db.WithContext(ctx).Where("key = ?", key).Delete(&SystemSettings{}).Error
 }
+
+// Backup operations
+func (r *Repository) CreateBackupInfo(ctx context.Context, info *BackupInfo) error {
+	return r.db.WithContext(ctx).Create(info).Error
+}
+
+func (r *Repository) UpdateBackupInfo(ctx context.Context, info *BackupInfo) error {
+	return r.db.WithContext(ctx).Save(info).Error
+}
+
+func (r *Repository) GetBackupInfo(ctx context.Context, id uint) (*BackupInfo, error) {
+	var info BackupInfo
+	err := r.db.WithContext(ctx).First(&info, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+func (r *Repository) GetBackups(ctx context.Context) ([]BackupInfo, error) {
+	var backups []BackupInfo
+	err := r.db.WithContext(ctx).Order("started_at DESC").Find(&backups).Error
+	return backups, err
+}
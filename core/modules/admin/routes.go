@@ -49,11 +49,24 @@ func SetupRoutes(router fiber.Router, container *core.Container) {
 		rbac.RequirePermission(rbacManager, "admin.logs.view"),
 		controller.GetAuditLogs,
 	)
+	admin.Get("/audit-logs/export",
+		rbac.RequirePermission(rbacManager, "admin.logs.view"),
+		controller.ExportAuditLogs,
+	)
 	admin.Get("/activity", 
 		rbac.RequirePermission(rbacManager, "admin.logs.view"),
 		controller.GetActivitySummary,
 	)
 
+	// Backup routes (require admin.backups.manage permission)
+	backupsGroup := admin.Group("/backups")
+	backupsGroup.Use(rbac.RequirePermission(rbacManager, "admin.backups.manage"))
+
+	backupsGroup.Get("/", controller.GetBackups)
+	backupsGroup.Post("/", controller.CreateBackup)
+	backupsGroup.Get("/:id", controller.GetBackup)
+	backupsGroup.Post("/:id/restore", controller.RestoreBackup)
+
 	// Settings routes (require admin.settings.manage permission)
 	settingsGroup := admin.Group("/settings")
 	settingsGroup.Use(rbac.RequirePermission(rbacManager, "admin.settings.manage"))
@@ -63,4 +76,12 @@ func SetupRoutes(router fiber.Router, container *core.Container) {
 	settingsGroup.Post("/", controller.CreateSetting)
 	settingsGroup.Put("/:key", controller.UpdateSetting)
 	settingsGroup.Delete("/:key", controller.DeleteSetting)
+
+	// Runtime log levels (require admin.settings.manage permission)
+	loggingGroup := admin.Group("/logging")
+	loggingGroup.Use(rbac.RequirePermission(rbacManager, "admin.settings.manage"))
+
+	loggingGroup.Get("/levels", controller.GetLogLevels)
+	loggingGroup.Put("/levels/:name", controller.SetLogLevel)
+	loggingGroup.Delete("/levels/:name", controller.ClearLogLevel)
 }
@@ -22,6 +22,12 @@ func (s *AdminSeeder) Name() string {
 	return "AdminSeeder"
 }
 
+// Dependencies runs the role seeder first; admin permissions are granted to
+// the super-admin role
+func (s *AdminSeeder) Dependencies() []string {
+	return []string{rbac.RoleSeederName}
+}
+
 func (s *AdminSeeder) Run(ctx context.Context) error {
 	fmt.Println("Seeding admin data...")
 
@@ -92,6 +98,13 @@ func (s *AdminSeeder) seedPermissions(ctx context.Context) error {
 			Module:      "admin",
 			Category:    "admin",
 		},
+		{
+			Name:        "Manage Backups",
+			Slug:        "admin.backups.manage",
+			Description: "Create, list and restore database backups",
+			Module:      "admin",
+			Category:    "admin",
+		},
 	}
 
 	for _, perm := range permissions {
@@ -189,6 +202,70 @@ func (s *AdminSeeder) seedSettings(ctx context.Context) error {
 			Description: "Number of days to keep audit logs",
 			IsPublic:    false,
 		},
+		{
+			Key:         "health.memory_degraded_mb",
+			Value:       "1000",
+			Type:        "int",
+			Category:    "health",
+			Description: "Heap usage in MB above which system health is degraded",
+			IsPublic:    false,
+		},
+		{
+			Key:         "health.memory_critical_mb",
+			Value:       "2000",
+			Type:        "int",
+			Category:    "health",
+			Description: "Heap usage in MB above which system health is critical",
+			IsPublic:    false,
+		},
+		{
+			Key:         "health.goroutines_degraded",
+			Value:       "1000",
+			Type:        "int",
+			Category:    "health",
+			Description: "Goroutine count above which system health is degraded",
+			IsPublic:    false,
+		},
+		{
+			Key:         "health.goroutines_critical",
+			Value:       "10000",
+			Type:        "int",
+			Category:    "health",
+			Description: "Goroutine count above which system health is critical",
+			IsPublic:    false,
+		},
+		{
+			Key:         "health.cpu_degraded_percent",
+			Value:       "80",
+			Type:        "int",
+			Category:    "health",
+			Description: "Process CPU percent above which system health is degraded",
+			IsPublic:    false,
+		},
+		{
+			Key:         "health.cpu_critical_percent",
+			Value:       "95",
+			Type:        "int",
+			Category:    "health",
+			Description: "Process CPU percent above which system health is critical",
+			IsPublic:    false,
+		},
+		{
+			Key:         "health.disk_degraded_percent",
+			Value:       "85",
+			Type:        "int",
+			Category:    "health",
+			Description: "Data disk usage percent above which system health is degraded",
+			IsPublic:    false,
+		},
+		{
+			Key:         "health.disk_critical_percent",
+			Value:       "95",
+			Type:        "int",
+			Category:    "health",
+			Description: "Data disk usage percent above which system health is critical",
+			IsPublic:    false,
+		},
 	}
 
 	for _, setting := range settings {
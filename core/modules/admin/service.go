@@ -3,8 +3,8 @@ package admin
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"runtime"
+	"sync"
 	"time"
 
 	"neonexcore/pkg/errors"
@@ -15,13 +15,86 @@ import (
 type Service struct {
 	repo      *Repository
 	startTime time.Time
+
+	// settingsCache is a read-through cache of settings by key. Entries
+	// expire after settingsTTL so changes made by other instances show up;
+	// settingsGen counts invalidations, so a read that raced one doesn't
+	// cache what it read.
+	settingsMu    sync.RWMutex
+	settingsCache map[string]cachedSetting
+	settingsGen   uint64
+	settingsTTL   time.Duration
+
+	// systemStats samples resource usage for health reports
+	systemStats SystemStatsFunc
+
+	// Background jobs such as backups run under jobsCtx, which Close
+	// cancels; jobsMu orders adding a job with Close waiting on jobs
+	jobsMu     sync.Mutex
+	jobsCtx    context.Context
+	cancelJobs context.CancelFunc
+	jobs       sync.WaitGroup
 }
 
 func NewService(repo *Repository) *Service {
+	jobsCtx, cancelJobs := context.WithCancel(context.Background())
 	return &Service{
-		repo:      repo,
-		startTime: time.Now(),
+		repo:          repo,
+		startTime:     time.Now(),
+		settingsCache: make(map[string]cachedSetting),
+		settingsTTL:   DefaultSettingsCacheTTL,
+		systemStats:   readSystemStats,
+		jobsCtx:       jobsCtx,
+		cancelJobs:    cancelJobs,
+	}
+}
+
+// Close cancels running background jobs and returns once they have
+// recorded their outcome. No new jobs start afterwards.
+func (s *Service) Close() error {
+	s.jobsMu.Lock()
+	s.cancelJobs()
+	s.jobsMu.Unlock()
+
+	s.jobs.Wait()
+	return nil
+}
+
+// startJob reserves a slot for a background job, reporting false once the
+// service is closed. The caller must call s.jobs.Done when the job ends.
+func (s *Service) startJob() bool {
+	s.jobsMu.Lock()
+	defer s.jobsMu.Unlock()
+
+	if s.jobsCtx.Err() != nil {
+		return false
 	}
+	s.jobs.Add(1)
+	return true
+}
+
+// DefaultSettingsCacheTTL is how long settings are served from the cache
+const DefaultSettingsCacheTTL = time.Minute
+
+// cachedSetting is a settings cache entry
+type cachedSetting struct {
+	setting   SystemSettings
+	expiresAt time.Time
+}
+
+// SetSettingsCacheTTL sets how long settings are served from the cache
+// before being read again; 0 or less disables the cache
+func (s *Service) SetSettingsCacheTTL(ttl time.Duration) {
+	s.settingsMu.Lock()
+	defer s.settingsMu.Unlock()
+	s.settingsTTL = ttl
+	s.settingsCache = make(map[string]cachedSetting)
+	s.settingsGen++
+}
+
+// SetSystemStatsSource replaces the resource sampler used by GetSystemHealth
+func (s *Service) SetSystemStatsSource(fn SystemStatsFunc) {
+	s.systemStats = fn
 }
 
 // GetDashboard retrieves complete dashboard data
@@ -35,7 +108,7 @@ func (s *Service) GetDashboard(ctx context.Context) (map[string]interface{}, err
 	stats.SystemUptime = time.Since(s.startTime).Seconds()
 
 	// Get system health
-	health := s.GetSystemHealth()
+	health := s.GetSystemHealth(ctx)
 
 	// Get recent activity
 	activity, err := s.repo.GetActivitySummary(ctx, 7) // Last 7 days
@@ -66,38 +139,46 @@ func (s *Service) GetStats(ctx context.Context) (map[string]interface{}, error)
 	return map[string]interface{}{
 		"users":   userStats,
 		"modules": moduleStats,
-		"system":  s.GetSystemHealth(),
+		"system":  s.GetSystemHealth(ctx),
 	}, nil
 }
 
-// GetSystemHealth retrieves current system health metrics
-func (s *Service) GetSystemHealth() *SystemHealth {
-	var m runtime.MemStats
-	runtime.ReadMemStats(&m)
+// GetSystemHealth retrieves current system health metrics. Status thresholds
+// are read from the health.* settings on every call.
+func (s *Service) GetSystemHealth(ctx context.Context) *SystemHealth {
+	stats := s.systemStats()
 
 	health := &SystemHealth{
-		Status:         "healthy",
-		DatabaseStatus: "connected",
-		MemoryUsageMB:  float64(m.Alloc) / 1024 / 1024,
-		GoroutineCount: runtime.NumGoroutine(),
-		UptimeSeconds:  time.Since(s.startTime).Seconds(),
-		Details:        make(map[string]interface{}),
+		Status:           "healthy",
+		DatabaseStatus:   "connected",
+		MemoryUsageMB:    float64(stats.MemoryAllocBytes) / 1024 / 1024,
+		GoroutineCount:   stats.Goroutines,
+		CPUUsage:         stats.CPU.ProcessPercent,
+		DiskUsagePercent: stats.Disk.UsedPercent,
+		UptimeSeconds:    time.Since(s.startTime).Seconds(),
+		Details:          make(map[string]interface{}),
 	}
 
 	// Add detailed memory stats
-	health.Details["sys_mb"] = float64(m.Sys) / 1024 / 1024
-	health.Details["num_gc"] = m.NumGC
+	health.Details["sys_mb"] = float64(stats.MemorySysBytes) / 1024 / 1024
+	health.Details["num_gc"] = stats.NumGC
 	health.Details["go_version"] = runtime.Version()
 	health.Details["num_cpu"] = runtime.NumCPU()
 
-	// Determine overall health status
-	if health.MemoryUsageMB > 1000 || health.GoroutineCount > 1000 {
-		health.Status = "degraded"
-	}
-	if health.MemoryUsageMB > 2000 || health.GoroutineCount > 10000 {
-		health.Status = "critical"
+	// Add CPU and disk stats
+	health.Details["cpu_per_core_percent"] = stats.CPU.PerCore
+	if stats.DiskErr != nil {
+		health.Details["disk_error"] = stats.DiskErr.Error()
+	} else {
+		health.Details["disk_path"] = stats.Disk.Path
+		health.Details["disk_total_mb"] = float64(stats.Disk.TotalBytes) / 1024 / 1024
+		health.Details["disk_used_mb"] = float64(stats.Disk.UsedBytes) / 1024 / 1024
+		health.Details["disk_free_mb"] = float64(stats.Disk.FreeBytes) / 1024 / 1024
 	}
 
+	// Determine overall health status
+	health.Status = s.healthThresholds(ctx).healthStatus(health, stats.DiskErr == nil)
+
 	return health
 }
 
@@ -125,6 +206,11 @@ func (s *Service) GetAuditLogs(ctx context.Context, page, limit int, filters map
 	return s.repo.GetAuditLogs(ctx, page, limit, filters)
 }
 
+// ExportAuditLogs streams all audit logs matching the filters to fn
+func (s *Service) ExportAuditLogs(ctx context.Context, filters map[string]interface{}, fn func(*AuditLog) error) error {
+	return s.repo.StreamAuditLogs(ctx, filters, fn)
+}
+
 // GetActivitySummary retrieves activity summary for specified days
 func (s *Service) GetActivitySummary(ctx context.Context, days int) (*ActivitySummary, error) {
 	if days < 1 {
@@ -139,6 +225,11 @@ func (s *Service) GetActivitySummary(ctx context.Context, days int) (*ActivitySu
 
 // Settings management
 func (s *Service) GetSetting(ctx context.Context, key string) (*SystemSettings, error) {
+	if setting, ok := s.cachedSetting(key); ok {
+		return setting, nil
+	}
+
+	gen := s.settingsGeneration()
 	setting, err := s.repo.GetSetting(ctx, key)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -146,6 +237,7 @@ func (s *Service) GetSetting(ctx context.Context, key string) (*SystemSettings,
 		}
 		return nil, errors.NewAppError(errors.ErrCodeInternalError, "Failed to retrieve setting", err)
 	}
+	s.cacheSettings(gen, *setting)
 	return setting, nil
 }
 
@@ -158,10 +250,12 @@ func (s *Service) GetSettingsByCategory(ctx context.Context, category string) ([
 }
 
 func (s *Service) GetAllSettings(ctx context.Context, includePrivate bool) ([]SystemSettings, error) {
+	gen := s.settingsGeneration()
 	settings, err := s.repo.GetAllSettings(ctx)
 	if err != nil {
 		return nil, errors.NewAppError(errors.ErrCodeInternalError, "Failed to retrieve settings", err)
 	}
+	s.cacheSettings(gen, settings...)
 
 	// Filter out private settings if requested
 	if !includePrivate {
@@ -184,16 +278,32 @@ func (s *Service) CreateSetting(ctx context.Context, setting *SystemSettings) er
 		return errors.NewAppError(errors.ErrCodeConflict, "Setting already exists", nil)
 	}
 
+	if err := ValidateSettingValue(setting, setting.Value); err != nil {
+		return err
+	}
+
+	setting.Version = 1
 	if err := s.repo.CreateSetting(ctx, setting); err != nil {
 		return errors.NewAppError(errors.ErrCodeInternalError, "Failed to create setting", err)
 	}
+	s.invalidateSetting(setting.Key)
 
 	return nil
 }
 
 func (s *Service) UpdateSetting(ctx context.Context, key, value string, updatedBy uint) error {
+	return s.updateSetting(ctx, key, value, updatedBy, nil)
+}
+
+// UpdateSettingIfVersion updates a setting only if it is still at the given
+// version, returning a Conflict error if someone else updated it first
+func (s *Service) UpdateSettingIfVersion(ctx context.Context, key, value string, updatedBy, version uint) error {
+	return s.updateSetting(ctx, key, value, updatedBy, &version)
+}
+
+func (s *Service) updateSetting(ctx context.Context, key, value string, updatedBy uint, version *uint) error {
 	// Verify setting exists
-	_, err := s.repo.GetSetting(ctx, key)
+	setting, err := s.repo.GetSetting(ctx, key)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return errors.NewAppError(errors.ErrCodeNotFound, "Setting not found", err)
@@ -201,9 +311,28 @@ func (s *Service) UpdateSetting(ctx context.Context, key, value string, updatedB
 		return errors.NewAppError(errors.ErrCodeInternalError, "Failed to retrieve setting", err)
 	}
 
-	if err := s.repo.UpdateSetting(ctx, key, value, updatedBy); err != nil {
+	if version != nil && setting.Version != *version {
+		return errors.NewConflict("Setting was modified by someone else; reload it and try again")
+	}
+
+	if err := ValidateSettingValue(setting, value); err != nil {
+		return err
+	}
+
+	if version == nil {
+		err = s.repo.UpdateSetting(ctx, key, value, updatedBy)
+	} else {
+		var updated bool
+		updated, err = s.repo.UpdateSettingIfVersion(ctx, key, value, updatedBy, *version)
+		if err == nil && !updated {
+			s.invalidateSetting(key)
+			return errors.NewConflict("Setting was modified by someone else; reload it and try again")
+		}
+	}
+	if err != nil {
 		return errors.NewAppError(errors.ErrCodeInternalError, "Failed to update setting", err)
 	}
+	s.invalidateSetting(key)
 
 	return nil
 }
@@ -212,9 +341,74 @@ func (s *Service) DeleteSetting(ctx context.Context, key string) error {
 	if err := s.repo.DeleteSetting(ctx, key); err != nil {
 		return errors.NewAppError(errors.ErrCodeInternalError, "Failed to delete setting", err)
 	}
+	s.invalidateSetting(key)
 	return nil
 }
 
+// RefreshSettingsCache drops all cached settings and reloads them from the
+// database. Call it after changing settings outside the service (bulk imports,
+// restores, other instances).
+func (s *Service) RefreshSettingsCache(ctx context.Context) error {
+	// Drop everything first, so reads racing the reload can't cache old values
+	s.settingsMu.Lock()
+	s.settingsCache = make(map[string]cachedSetting)
+	s.settingsGen++
+	gen := s.settingsGen
+	s.settingsMu.Unlock()
+
+	settings, err := s.repo.GetAllSettings(ctx)
+	if err != nil {
+		return errors.NewInternal("Failed to retrieve settings").WithError(err)
+	}
+
+	s.cacheSettings(gen, settings...)
+	return nil
+}
+
+// cachedSetting returns a copy of a cached setting that hasn't expired
+func (s *Service) cachedSetting(key string) (*SystemSettings, bool) {
+	s.settingsMu.RLock()
+	defer s.settingsMu.RUnlock()
+
+	entry, ok := s.settingsCache[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	setting := entry.setting
+	return &setting, true
+}
+
+// settingsGeneration returns the invalidation count to pass to
+// cacheSettings along with what is read after the call
+func (s *Service) settingsGeneration() uint64 {
+	s.settingsMu.RLock()
+	defer s.settingsMu.RUnlock()
+	return s.settingsGen
+}
+
+// cacheSettings caches settings read at generation gen, unless a setting
+// was invalidated since
+func (s *Service) cacheSettings(gen uint64, settings ...SystemSettings) {
+	s.settingsMu.Lock()
+	defer s.settingsMu.Unlock()
+
+	if gen != s.settingsGen || s.settingsTTL <= 0 {
+		return
+	}
+
+	expiresAt := time.Now().Add(s.settingsTTL)
+	for _, setting := range settings {
+		s.settingsCache[setting.Key] = cachedSetting{setting: setting, expiresAt: expiresAt}
+	}
+}
+
+func (s *Service) invalidateSetting(key string) {
+	s.settingsMu.Lock()
+	delete(s.settingsCache, key)
+	s.settingsGen++
+	s.settingsMu.Unlock()
+}
+
 // GetSettingValue retrieves and parses setting value by type
 func (s *Service) GetSettingValue(ctx context.Context, key string) (interface{}, error) {
 	setting, err := s.GetSetting(ctx, key)
@@ -269,26 +463,3 @@ func (s *Service) SetSettingValue(ctx context.Context, key string, value interfa
 
 	return s.UpdateSetting(ctx, key, stringValue, updatedBy)
 }
-
-// Helper to log admin actions
-func (s *Service) LogAdminAction(ctx context.Context, userID uint, username, action, resource, resourceID string, status string, metadata interface{}) error {
-	metadataStr := ""
-	if metadata != nil {
-		bytes, _ := json.Marshal(metadata)
-		metadataStr = string(bytes)
-	}
-
-	log := &AuditLog{
-		UserID:      userID,
-		Username:    username,
-		Action:      action,
-		Resource:    resource,
-		ResourceID:  fmt.Sprintf("%v", resourceID),
-		Description: fmt.Sprintf("%s %s %s", username, action, resource),
-		Status:      status,
-		Metadata:    metadataStr,
-		CreatedAt:   time.Now(),
-	}
-
-	return s.LogActivity(ctx, log)
-}
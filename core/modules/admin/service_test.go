@@ -0,0 +1,123 @@
+package admin
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// countQueries counts the SELECTs run on db from now on
+func countQueries(t *testing.T, db *gorm.DB) *int64 {
+	t.Helper()
+
+	var count int64
+	err := db.Callback().Query().After("gorm:query").Register("test:count_queries", func(*gorm.DB) {
+		atomic.AddInt64(&count, 1)
+	})
+	if err != nil {
+		t.Fatalf("register query callback: %v", err)
+	}
+	return &count
+}
+
+func createTestSetting(t *testing.T, service *Service, key, value string) {
+	t.Helper()
+
+	if err := service.CreateSetting(context.Background(), &SystemSettings{Key: key, Type: SettingTypeString, Value: value}); err != nil {
+		t.Fatalf("CreateSetting %s: %v", key, err)
+	}
+}
+
+func assertSettingValue(t *testing.T, service *Service, key string, want interface{}) {
+	t.Helper()
+
+	value, err := service.GetSettingValue(context.Background(), key)
+	if err != nil {
+		t.Fatalf("GetSettingValue %s: %v", key, err)
+	}
+	if value != want {
+		t.Fatalf("%s = %v, want %v", key, value, want)
+	}
+}
+
+func TestSettingsCacheServesRepeatedReads(t *testing.T) {
+	service, db := newTestService(t)
+	createTestSetting(t, service, "site.name", "Neonex")
+	queries := countQueries(t, db)
+
+	for i := 0; i < 5; i++ {
+		assertSettingValue(t, service, "site.name", "Neonex")
+	}
+	if n := atomic.LoadInt64(queries); n != 1 {
+		t.Fatalf("5 reads ran %d queries, want 1", n)
+	}
+}
+
+func TestSettingsCacheReflectsChanges(t *testing.T) {
+	service, _ := newTestService(t)
+	ctx := context.Background()
+	createTestSetting(t, service, "site.name", "Neonex")
+	assertSettingValue(t, service, "site.name", "Neonex")
+
+	if err := service.UpdateSetting(ctx, "site.name", "Neonex Core", 1); err != nil {
+		t.Fatalf("UpdateSetting: %v", err)
+	}
+	assertSettingValue(t, service, "site.name", "Neonex Core")
+
+	if err := service.DeleteSetting(ctx, "site.name"); err != nil {
+		t.Fatalf("DeleteSetting: %v", err)
+	}
+	if _, err := service.GetSetting(ctx, "site.name"); err == nil {
+		t.Fatal("a deleted setting is still served from the cache")
+	}
+}
+
+func TestGetAllSettingsWarmsCache(t *testing.T) {
+	service, db := newTestService(t)
+	createTestSetting(t, service, "site.name", "Neonex")
+	createTestSetting(t, service, "site.theme", "dark")
+
+	if _, err := service.GetAllSettings(context.Background(), true); err != nil {
+		t.Fatalf("GetAllSettings: %v", err)
+	}
+	queries := countQueries(t, db)
+
+	assertSettingValue(t, service, "site.name", "Neonex")
+	assertSettingValue(t, service, "site.theme", "dark")
+	if n := atomic.LoadInt64(queries); n != 0 {
+		t.Fatalf("reads after warming the cache ran %d queries", n)
+	}
+}
+
+func TestRefreshSettingsCachePicksUpExternalChanges(t *testing.T) {
+	service, db := newTestService(t)
+	createTestSetting(t, service, "site.name", "Neonex")
+	assertSettingValue(t, service, "site.name", "Neonex")
+
+	// Changed behind the service's back, e.g. by a restore
+	if err := db.Model(&SystemSettings{}).Where("key = ?", "site.name").Update("value", "Restored").Error; err != nil {
+		t.Fatalf("update setting: %v", err)
+	}
+	assertSettingValue(t, service, "site.name", "Neonex")
+
+	if err := service.RefreshSettingsCache(context.Background()); err != nil {
+		t.Fatalf("RefreshSettingsCache: %v", err)
+	}
+	assertSettingValue(t, service, "site.name", "Restored")
+}
+
+func TestSettingsCacheExpires(t *testing.T) {
+	service, db := newTestService(t)
+	service.SetSettingsCacheTTL(50 * time.Millisecond)
+	createTestSetting(t, service, "site.name", "Neonex")
+	assertSettingValue(t, service, "site.name", "Neonex")
+
+	if err := db.Model(&SystemSettings{}).Where("key = ?", "site.name").Update("value", "Changed").Error; err != nil {
+		t.Fatalf("update setting: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	assertSettingValue(t, service, "site.name", "Changed")
+}
@@ -0,0 +1,67 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"neonexcore/pkg/errors"
+)
+
+// Setting value types
+const (
+	SettingTypeString = "string"
+	SettingTypeInt    = "int"
+	SettingTypeBool   = "bool"
+	SettingTypeJSON   = "json"
+)
+
+// ValidateSettingValue checks that value matches the setting's declared type and,
+// when configured, its allowed values and numeric bounds. Values are parsed the
+// same way GetSettingValue reads them; an empty type is treated as string.
+func ValidateSettingValue(setting *SystemSettings, value string) error {
+	invalid := func(reason string) error {
+		return errors.NewBadRequest(fmt.Sprintf("Invalid value for setting %q", setting.Key)).WithDetails(map[string]interface{}{
+			"key":    setting.Key,
+			"type":   setting.Type,
+			"reason": reason,
+		})
+	}
+
+	switch setting.Type {
+	case "", SettingTypeString:
+	case SettingTypeInt:
+		var n int64
+		if err := json.Unmarshal([]byte(value), &n); err != nil {
+			return invalid("value must be an integer")
+		}
+		if setting.Min != nil && float64(n) < *setting.Min {
+			return invalid(fmt.Sprintf("value must be at least %v", *setting.Min))
+		}
+		if setting.Max != nil && float64(n) > *setting.Max {
+			return invalid(fmt.Sprintf("value must be at most %v", *setting.Max))
+		}
+	case SettingTypeBool:
+		var b bool
+		if err := json.Unmarshal([]byte(value), &b); err != nil {
+			return invalid("value must be true or false")
+		}
+	case SettingTypeJSON:
+		if !json.Valid([]byte(value)) {
+			return invalid("value must be valid JSON")
+		}
+	default:
+		return invalid(fmt.Sprintf("unknown setting type %q", setting.Type))
+	}
+
+	if len(setting.AllowedValues) > 0 {
+		for _, allowed := range setting.AllowedValues {
+			if value == allowed {
+				return nil
+			}
+		}
+		return invalid(fmt.Sprintf("value must be one of: %s", strings.Join(setting.AllowedValues, ", ")))
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package admin
+
+import (
+	"context"
+	"testing"
+
+	"neonexcore/pkg/errors"
+)
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
+func TestValidateSettingValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		setting SystemSettings
+		value   string
+		valid   bool
+	}{
+		{"string", SystemSettings{Type: SettingTypeString}, "anything", true},
+		{"untyped", SystemSettings{}, "anything", true},
+		{"int", SystemSettings{Type: SettingTypeInt}, "42", true},
+		{"negative int", SystemSettings{Type: SettingTypeInt}, "-3", true},
+		{"int with text", SystemSettings{Type: SettingTypeInt}, "not-a-number", false},
+		{"int with fraction", SystemSettings{Type: SettingTypeInt}, "1.5", false},
+		{"int at bounds", SystemSettings{Type: SettingTypeInt, Min: floatPtr(1), Max: floatPtr(10)}, "10", true},
+		{"int below min", SystemSettings{Type: SettingTypeInt, Min: floatPtr(1)}, "0", false},
+		{"int above max", SystemSettings{Type: SettingTypeInt, Max: floatPtr(10)}, "11", false},
+		{"bool true", SystemSettings{Type: SettingTypeBool}, "true", true},
+		{"bool false", SystemSettings{Type: SettingTypeBool}, "false", true},
+		{"bool with text", SystemSettings{Type: SettingTypeBool}, "yes", false},
+		{"json object", SystemSettings{Type: SettingTypeJSON}, `{"a":[1,2]}`, true},
+		{"json string", SystemSettings{Type: SettingTypeJSON}, `"text"`, true},
+		{"json malformed", SystemSettings{Type: SettingTypeJSON}, `{"a":`, false},
+		{"allowed value", SystemSettings{Type: SettingTypeString, AllowedValues: []string{"light", "dark"}}, "dark", true},
+		{"disallowed value", SystemSettings{Type: SettingTypeString, AllowedValues: []string{"light", "dark"}}, "blue", false},
+		{"unknown type", SystemSettings{Type: "float"}, "1.5", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setting.Key = "test.setting"
+			err := ValidateSettingValue(&tt.setting, tt.value)
+			if tt.valid {
+				if err != nil {
+					t.Fatalf("rejected %q: %v", tt.value, err)
+				}
+				return
+			}
+			appErr, ok := errors.GetAppError(err)
+			if !ok || appErr.StatusCode != 400 {
+				t.Fatalf("ValidateSettingValue(%q) = %v, want a bad request", tt.value, err)
+			}
+		})
+	}
+}
+
+func TestCreateAndUpdateSettingValidateValue(t *testing.T) {
+	service, _ := newTestService(t)
+	ctx := context.Background()
+
+	if err := service.CreateSetting(ctx, &SystemSettings{Key: "uploads.max", Type: SettingTypeInt, Value: "many"}); err == nil {
+		t.Fatal("CreateSetting accepted a non-integer value")
+	}
+	if _, err := service.GetSetting(ctx, "uploads.max"); err == nil {
+		t.Fatal("a rejected setting was persisted")
+	}
+
+	if err := service.CreateSetting(ctx, &SystemSettings{Key: "uploads.max", Type: SettingTypeInt, Value: "10", Max: floatPtr(100)}); err != nil {
+		t.Fatalf("CreateSetting: %v", err)
+	}
+	for _, value := range []string{"ten", "101"} {
+		if err := service.UpdateSetting(ctx, "uploads.max", value, 1); err == nil {
+			t.Errorf("UpdateSetting accepted %q", value)
+		}
+	}
+	if err := service.SetSettingValue(ctx, "uploads.max", 50, 1); err != nil {
+		t.Fatalf("SetSettingValue: %v", err)
+	}
+
+	value, err := service.GetSettingValue(ctx, "uploads.max")
+	if err != nil {
+		t.Fatalf("GetSettingValue: %v", err)
+	}
+	if value != 50 {
+		t.Fatalf("uploads.max = %v, want 50", value)
+	}
+}
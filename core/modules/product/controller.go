@@ -3,6 +3,8 @@ package product
 import (
 	"strconv"
 
+	"neonexcore/pkg/api"
+
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -14,8 +16,20 @@ func NewController(service *Service) *Controller {
 	return &Controller{service: service}
 }
 
+// filterFields declares the fields GetAll may filter on with filter[field][op]=value
+var filterFields = api.FilterFields{
+	"name":       {Operators: []api.FilterOperator{api.FilterEq, api.FilterLike}},
+	"is_active":  {Operators: []api.FilterOperator{api.FilterEq}, Parse: func(v string) (interface{}, error) { return strconv.ParseBool(v) }},
+	"created_at": {Operators: []api.FilterOperator{api.FilterGte, api.FilterLte}},
+}
+
 func (c *Controller) GetAll(ctx *fiber.Ctx) error {
-	entities, err := c.service.GetAll(ctx.Context())
+	filters, err := api.ParseFilters(ctx, filterFields)
+	if err != nil {
+		return err
+	}
+
+	entities, err := c.service.GetAll(ctx.Context(), filters)
 	if err != nil {
 		return ctx.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -0,0 +1,56 @@
+package product
+
+import (
+	"encoding/json"
+	"testing"
+
+	"neonexcore/pkg/api"
+)
+
+func TestProductOpenAPISchema(t *testing.T) {
+	sg := api.NewSwaggerGenerator(api.SwaggerInfo{Title: "Test", Version: "1.0"})
+	sg.AddSchemaFromStruct("Product", Product{})
+
+	data, err := sg.GenerateJSON()
+	if err != nil {
+		t.Fatalf("GenerateJSON: %v", err)
+	}
+	var spec struct {
+		Components struct {
+			Schemas map[string]struct {
+				Type       string                            `json:"type"`
+				Properties map[string]map[string]interface{} `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		t.Fatalf("decode spec: %v", err)
+	}
+
+	schema := spec.Components.Schemas["Product"]
+	if schema.Type != "object" {
+		t.Fatalf("Product schema type = %q, want object", schema.Type)
+	}
+
+	// gorm.Model is flattened like encoding/json does
+	want := map[string]interface{}{
+		"ID":          "integer",
+		"CreatedAt":   "string",
+		"UpdatedAt":   "string",
+		"DeletedAt":   "string",
+		"name":        "string",
+		"description": "string",
+		"is_active":   "boolean",
+	}
+	if len(schema.Properties) != len(want) {
+		t.Fatalf("properties = %v, want %v", schema.Properties, want)
+	}
+	for name, typ := range want {
+		if got := schema.Properties[name]["type"]; got != typ {
+			t.Errorf("%s has type %q, want %q", name, got, typ)
+		}
+	}
+	if format := schema.Properties["CreatedAt"]["format"]; format != "date-time" {
+		t.Errorf("CreatedAt has format %q, want date-time", format)
+	}
+}
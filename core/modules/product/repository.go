@@ -1,6 +1,7 @@
 package product
 
 import (
+	"neonexcore/pkg/api"
 	"neonexcore/pkg/database"
 
 	"gorm.io/gorm"
@@ -31,3 +32,10 @@ func (r *Repository) FindActive() ([]Product, error) {
 	err := r.DB.Where("is_active = ?", true).Find(&entities).Error
 	return entities, err
 }
+
+// FindFiltered returns the products matching the filters
+func (r *Repository) FindFiltered(filters api.Filters) ([]Product, error) {
+	var entities []Product
+	err := filters.Apply(r.DB).Find(&entities).Error
+	return entities, err
+}
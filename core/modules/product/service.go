@@ -3,6 +3,8 @@ package product
 import (
 	"context"
 	"fmt"
+
+	"neonexcore/pkg/api"
 )
 
 type Service struct {
@@ -13,8 +15,8 @@ func NewService(repo *Repository) *Service {
 	return &Service{repo: repo}
 }
 
-func (s *Service) GetAll(ctx context.Context) ([]Product, error) {
-	return s.repo.FindAll()
+func (s *Service) GetAll(ctx context.Context, filters api.Filters) ([]Product, error) {
+	return s.repo.FindFiltered(filters)
 }
 
 func (s *Service) GetByID(ctx context.Context, id uint) (*Product, error) {
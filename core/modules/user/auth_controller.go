@@ -35,7 +35,7 @@ func (ctrl *AuthController) Login(c *fiber.Ctx) error {
 	ctx := context.Background()
 	
 	// Authenticate user
-	result, err := ctrl.authService.Login(ctx, req.Email, req.Password)
+	result, err := ctrl.authService.Login(ctx, req.Email, req.Password, c.IP())
 	if err != nil {
 		return err
 	}
@@ -67,12 +67,13 @@ func (ctrl *AuthController) Register(c *fiber.Ctx) error {
 
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 		"success": true,
-		"message": "Registration successful",
+		"message": "Registration successful, please verify your email",
 		"data": fiber.Map{
-			"id":       user.ID,
-			"name":     user.Name,
-			"email":    user.Email,
-			"username": user.Username,
+			"id":                user.ID,
+			"name":              user.Name,
+			"email":             user.Email,
+			"username":          user.Username,
+			"is_email_verified": user.IsEmailVerified,
 		},
 	})
 }
@@ -105,9 +106,25 @@ func (ctrl *AuthController) RefreshToken(c *fiber.Ctx) error {
 // Logout handles user logout
 // POST /api/v1/auth/logout
 func (ctrl *AuthController) Logout(c *fiber.Ctx) error {
-	// In JWT, logout is typically handled client-side by removing the token
-	// Here we can add token to blacklist if needed (future enhancement)
-	
+	type LogoutRequest struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	userID, _ := auth.GetUserID(c)
+	token, ok := auth.GetToken(c)
+	if !ok {
+		return errors.NewUnauthorized("User not authenticated")
+	}
+
+	// Refresh token is optional, ignore empty or malformed bodies
+	var req LogoutRequest
+	_ = c.BodyParser(&req)
+
+	ctx := context.Background()
+	if err := ctrl.authService.Logout(ctx, userID, token, req.RefreshToken); err != nil {
+		return err
+	}
+
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"success": true,
 		"message": "Logout successful",
@@ -158,36 +175,20 @@ func (ctrl *AuthController) UpdateProfile(c *fiber.Ctx) error {
 	}
 
 	ctx := context.Background()
-	user, err := ctrl.authService.userRepo.FindByID(ctx, userID)
-	if err != nil || user == nil {
-		return errors.NewNotFound("User not found")
-	}
-
-	// Update fields if provided
-	if req.Name != "" {
-		user.Name = req.Name
-	}
-	if req.Email != "" {
-		// Check if email is already taken by another user
-		existing, _ := ctrl.authService.userRepo.FindByEmail(ctx, req.Email)
-		if existing != nil && existing.ID != userID {
-			return errors.NewConflict("Email already in use")
-		}
-		user.Email = req.Email
-	}
-
-	if err := ctrl.authService.userRepo.Update(ctx, user); err != nil {
-		return errors.NewInternal("Failed to update profile")
+	user, err := ctrl.authService.UpdateProfile(ctx, userID, req.Name, req.Email)
+	if err != nil {
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"success": true,
 		"message": "Profile updated successfully",
 		"data": fiber.Map{
-			"id":       user.ID,
-			"name":     user.Name,
-			"email":    user.Email,
-			"username": user.Username,
+			"id":                user.ID,
+			"name":              user.Name,
+			"email":             user.Email,
+			"username":          user.Username,
+			"is_email_verified": user.IsEmailVerified,
 		},
 	})
 }
@@ -253,30 +254,14 @@ func (ctrl *AuthController) ForgotPassword(c *fiber.Ctx) error {
 	}
 
 	ctx := context.Background()
-	user, err := ctrl.authService.userRepo.FindByEmail(ctx, req.Email)
-	if err != nil || user == nil {
-		// Don't reveal if email exists or not (security)
-		return c.Status(fiber.StatusOK).JSON(fiber.Map{
-			"success": true,
-			"message": "If the email exists, a password reset link has been sent",
-		})
-	}
-
-	// Generate reset token
-	resetToken, err := auth.GenerateResetToken()
-	if err != nil {
-		return errors.NewInternal("Failed to generate reset token")
+	if err := ctrl.authService.ForgotPassword(ctx, req.Email); err != nil {
+		return err
 	}
 
-	// TODO: Save reset token to database and send email
-	// For now, just return success (will implement email in notification system)
-
+	// Don't reveal if email exists or not (security)
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"success": true,
 		"message": "If the email exists, a password reset link has been sent",
-		"debug": fiber.Map{
-			"reset_token": resetToken, // Remove in production
-		},
 	})
 }
 
@@ -293,12 +278,14 @@ func (ctrl *AuthController) ResetPassword(c *fiber.Ctx) error {
 		return err
 	}
 
-	// TODO: Implement token validation and password reset
-	// For now, return not implemented
+	ctx := context.Background()
+	if err := ctrl.authService.ResetPassword(ctx, req.Token, req.NewPassword); err != nil {
+		return err
+	}
 
-	return c.Status(fiber.StatusNotImplemented).JSON(fiber.Map{
-		"success": false,
-		"message": "Password reset not yet implemented",
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Password reset successfully",
 	})
 }
 
@@ -310,11 +297,43 @@ func (ctrl *AuthController) VerifyEmail(c *fiber.Ctx) error {
 		return errors.NewBadRequest("Token is required")
 	}
 
-	// TODO: Implement email verification
-	// For now, return not implemented
+	ctx := context.Background()
+	user, err := ctrl.authService.VerifyEmail(ctx, token)
+	if err != nil {
+		return err
+	}
 
-	return c.Status(fiber.StatusNotImplemented).JSON(fiber.Map{
-		"success": false,
-		"message": "Email verification not yet implemented",
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Email verified successfully",
+		"data": fiber.Map{
+			"id":                user.ID,
+			"email":             user.Email,
+			"is_email_verified": user.IsEmailVerified,
+			"email_verified_at": user.EmailVerifiedAt,
+		},
+	})
+}
+
+// ResendVerification issues a new email verification token
+// POST /api/v1/auth/resend-verification
+func (ctrl *AuthController) ResendVerification(c *fiber.Ctx) error {
+	type ResendVerificationRequest struct {
+		Email string `json:"email" validate:"required,email"`
+	}
+
+	var req ResendVerificationRequest
+	if err := validation.ValidateBody(c, &req); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	if err := ctrl.authService.ResendVerification(ctx, req.Email); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "If the email exists and is unverified, a verification link has been sent",
 	})
 }
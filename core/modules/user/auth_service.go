@@ -2,44 +2,100 @@ package user
 
 import (
 	"context"
+	stderrors "errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
 	"time"
 
+	"neonexcore/internal/config"
 	"neonexcore/pkg/auth"
+	"neonexcore/pkg/database"
 	"neonexcore/pkg/errors"
 	"neonexcore/pkg/events"
+	"neonexcore/pkg/notify"
 	"neonexcore/pkg/rbac"
 	"neonexcore/pkg/validation"
+
+	"gorm.io/gorm"
 )
 
+// EmailVerificationExpiry is how long an email verification token stays valid
+const EmailVerificationExpiry = 24 * time.Hour
+
+// PasswordResetExpiry is how long a password reset token stays valid
+const PasswordResetExpiry = time.Hour
+
+// emailSendTimeout bounds the delivery of a single account email
+const emailSendTimeout = 30 * time.Second
+
 // AuthService handles authentication logic
 type AuthService struct {
 	userRepo    *UserRepository
+	txManager   *database.TxManager
 	jwtManager  *auth.JWTManager
 	hasher      *auth.PasswordHasher
 	rbacManager *rbac.Manager
+	limiter     *auth.LoginLimiter
+	mailer      notify.Mailer
+	emails      *notify.Renderer
+	mailConfig  *config.MailConfig
+
+	deletedPolicy string
 }
 
 // NewAuthService creates a new auth service
 func NewAuthService(
 	userRepo *UserRepository,
+	txManager *database.TxManager,
 	jwtManager *auth.JWTManager,
 	hasher *auth.PasswordHasher,
 	rbacManager *rbac.Manager,
+	limiter *auth.LoginLimiter,
 ) *AuthService {
 	return &AuthService{
 		userRepo:    userRepo,
+		txManager:   txManager,
 		jwtManager:  jwtManager,
 		hasher:      hasher,
 		rbacManager: rbacManager,
+		limiter:     limiter,
+
+		deletedPolicy: config.DeletedUserReject,
 	}
 }
 
+// SetDeletedUserPolicy sets what Register does when the email or username
+// belongs to a soft-deleted user: config.DeletedUserReject or
+// config.DeletedUserRestore
+func (s *AuthService) SetDeletedUserPolicy(policy string) {
+	s.deletedPolicy = policy
+}
+
+// SetMailer enables verification and password reset emails. Without a
+// mailer they are not sent.
+func (s *AuthService) SetMailer(mailer notify.Mailer, emails *notify.Renderer, mailConfig *config.MailConfig) {
+	s.mailer = mailer
+	s.emails = emails
+	s.mailConfig = mailConfig
+}
+
 // Login authenticates a user
-func (s *AuthService) Login(ctx context.Context, email, password string) (map[string]interface{}, error) {
+func (s *AuthService) Login(ctx context.Context, email, password, ip string) (map[string]interface{}, error) {
+	// Failures count per email from each IP, so nobody can lock a user out
+	// from elsewhere, and per IP across emails to slow password spraying
+	identifiers := []string{"email:" + strings.ToLower(email) + "|ip:" + ip, "ip:" + ip}
+
+	// Reject while locked out
+	if remaining, locked := s.limiter.Locked(ctx, identifiers...); locked {
+		return nil, lockedError(remaining)
+	}
+
 	// Find user
 	user, err := s.userRepo.FindByEmail(ctx, email)
 	if err != nil || user == nil {
-		return nil, errors.New(errors.ErrCodeInvalidCredentials, "Invalid email or password", 401)
+		return nil, s.loginFailed(ctx, email, ip, identifiers)
 	}
 
 	// Check if user is active
@@ -47,10 +103,21 @@ func (s *AuthService) Login(ctx context.Context, email, password string) (map[st
 		return nil, errors.New(errors.ErrCodeAccountDisabled, "Account is disabled", 403)
 	}
 
-	// Verify password
-	if err := s.hasher.Verify(password, user.Password); err != nil {
-		return nil, errors.New(errors.ErrCodeInvalidCredentials, "Invalid email or password", 401)
+	// Verify password, upgrading hashes made with an old cost or no pepper
+	newHash, err := s.hasher.VerifyAndRehash(password, user.Password)
+	if err != nil {
+		return nil, s.loginFailed(ctx, email, ip, identifiers)
 	}
+	if newHash != "" {
+		// The login still succeeds with the old hash; the upgrade is retried
+		// on the next login
+		user.Password = newHash
+		if err := s.userRepo.UpdateColumns(ctx, user, map[string]interface{}{"password": newHash}); err != nil {
+			log.Printf("Failed to upgrade password hash of user %d: %v", user.ID, err)
+		}
+	}
+
+	s.limiter.Reset(ctx, identifiers...)
 
 	// Get user roles and permissions
 	roles, _ := s.rbacManager.GetUserRoles(ctx, user.ID)
@@ -87,14 +154,14 @@ func (s *AuthService) Login(ctx context.Context, email, password string) (map[st
 	// Update last login
 	now := time.Now()
 	user.LastLoginAt = &now
-	s.userRepo.Update(ctx, user)
+	s.userRepo.UpdateColumns(ctx, user, map[string]interface{}{"last_login_at": now})
 
 	// Dispatch login event
 	events.DispatchAsync(ctx, events.Event{
 		Name: events.EventUserLoggedIn,
-		Data: map[string]interface{}{
-			"user_id": user.ID,
-			"email":   user.Email,
+		Data: events.UserLoggedInEvent{
+			UserID: user.ID,
+			Email:  user.Email,
 		},
 	})
 
@@ -113,8 +180,43 @@ func (s *AuthService) Login(ctx context.Context, email, password string) (map[st
 	}, nil
 }
 
+// loginFailed records a failed attempt and returns the error to report
+func (s *AuthService) loginFailed(ctx context.Context, email, ip string, identifiers []string) error {
+	locked, _ := s.limiter.RecordFailure(ctx, identifiers...)
+	if !locked {
+		return errors.New(errors.ErrCodeInvalidCredentials, "Invalid email or password", 401)
+	}
+
+	duration := s.limiter.Config().LockoutDuration
+	events.DispatchAsync(ctx, events.Event{
+		Name: events.EventUserLockedOut,
+		Data: events.UserLockedOutEvent{
+			Email:       email,
+			IPAddress:   ip,
+			LockedUntil: time.Now().Add(duration),
+		},
+	})
+
+	return lockedError(duration)
+}
+
+// lockedError builds the "too many attempts" error for the remaining lock time
+func lockedError(remaining time.Duration) error {
+	retryAfter := remaining.Round(time.Second)
+	return errors.New(
+		errors.ErrCodeAccountLocked,
+		fmt.Sprintf("Too many failed login attempts, try again in %s", retryAfter),
+		429,
+	).WithDetails(map[string]interface{}{
+		"retry_after": int(retryAfter.Seconds()),
+	})
+}
+
 // Register creates a new user account
 func (s *AuthService) Register(ctx context.Context, req *validation.RegisterRequest) (*User, error) {
+	// Existence checks must see the latest writes
+	ctx = database.UsePrimary(ctx)
+
 	// Check if email exists
 	existing, _ := s.userRepo.FindByEmail(ctx, req.Email)
 	if existing != nil {
@@ -140,44 +242,441 @@ func (s *AuthService) Register(ctx context.Context, req *validation.RegisterRequ
 		Username: req.Username,
 		Password: hashedPassword,
 		IsActive: true,
+		Active:   true,
+	}
+
+	verificationToken, err := s.setVerificationToken(user)
+	if err != nil {
+		return nil, errors.NewInternal("Failed to generate verification token")
 	}
 
-	if err := s.userRepo.Create(ctx, user); err != nil {
-		return nil, errors.NewInternal("Failed to create user")
+	// A soft-deleted user may still hold the email or username
+	restoreID, err := deletedUserToRestore(ctx, s.userRepo, s.deletedPolicy, user)
+	if err != nil {
+		return nil, createUserError(err)
 	}
 
-	// Assign default user role
-	role, _ := s.rbacManager.GetRoleBySlug(ctx, "user")
-	if role != nil {
-		s.rbacManager.AssignRole(ctx, user.ID, role.ID)
+	role, err := s.rbacManager.GetRoleBySlug(ctx, "user")
+	if err != nil && !stderrors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.NewInternal("Failed to load default role")
 	}
 
-	// Dispatch user created event
-	events.DispatchAsync(ctx, events.Event{
-		Name: events.EventUserCreated,
-		Data: map[string]interface{}{
-			"user_id": user.ID,
-			"email":   user.Email,
-		},
+	// Save the user with its events and default role, so the account only
+	// exists once all of it is
+	err = s.txManager.WithTransaction(ctx, func(tx *gorm.DB) error {
+		repo := s.userRepo.WithTx(tx)
+		txCtx := database.ContextWithTx(ctx, tx)
+
+		if restoreID == 0 {
+			if err := repo.Create(ctx, user); err != nil {
+				return err
+			}
+			err = events.Enqueue(tx, events.Event{
+				Name: events.EventUserCreated,
+				Data: events.UserCreatedEvent{
+					UserID: user.ID,
+					Email:  user.Email,
+				},
+			})
+		} else {
+			if err := repo.RestoreAs(ctx, restoreID, user); err != nil {
+				return err
+			}
+			err = events.Enqueue(tx, events.Event{
+				Name: events.EventUserRestored,
+				Data: events.UserRestoredEvent{
+					UserID: user.ID,
+					Email:  user.Email,
+				},
+			})
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := enqueueVerificationRequest(tx, user); err != nil {
+			return err
+		}
+
+		// A restored user starts without its former access or sessions
+		if restoreID != 0 {
+			if err := s.rbacManager.RevokeAll(txCtx, user.ID); err != nil {
+				return err
+			}
+		}
+		if role != nil {
+			if err := s.rbacManager.AssignRole(txCtx, user.ID, role.ID); err != nil {
+				return err
+			}
+		}
+		if restoreID != 0 {
+			return s.jwtManager.RevokeUserTokens(ctx, user.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, createUserError(err)
+	}
+
+	s.sendVerificationEmail(user, verificationToken)
+
+	return user, nil
+}
+
+// VerifyEmail marks the user owning the token as verified and invalidates the token
+func (s *AuthService) VerifyEmail(ctx context.Context, token string) (*User, error) {
+	user, err := s.userRepo.FindByVerificationToken(ctx, auth.HashToken(token))
+	if err != nil || user == nil {
+		return nil, errors.NewBadRequest("Invalid or expired verification token")
+	}
+
+	if user.VerificationExpiry == nil || time.Now().After(*user.VerificationExpiry) {
+		return nil, errors.NewBadRequest("Invalid or expired verification token")
+	}
+
+	now := time.Now()
+	user.IsEmailVerified = true
+	user.EmailVerifiedAt = &now
+	user.VerificationToken = nil
+	user.VerificationExpiry = nil
+
+	err = s.txManager.WithTransaction(ctx, func(tx *gorm.DB) error {
+		err := s.userRepo.WithTx(tx).UpdateColumns(ctx, user, map[string]interface{}{
+			"is_email_verified":   true,
+			"email_verified_at":   now,
+			"verification_token":  nil,
+			"verification_expiry": nil,
+		})
+		if err != nil {
+			return err
+		}
+
+		return events.Enqueue(tx, events.Event{
+			Name: events.EventUserEmailVerified,
+			Data: events.UserEmailVerifiedEvent{
+				UserID: user.ID,
+				Email:  user.Email,
+			},
+		})
 	})
+	if err != nil {
+		return nil, errors.NewInternal("Failed to verify email")
+	}
 
 	return user, nil
 }
 
-// RefreshToken refreshes access token
+// ResendVerification emails a fresh verification token to an unverified user.
+// It does nothing for unknown or verified emails so callers do not reveal
+// whether the email exists.
+func (s *AuthService) ResendVerification(ctx context.Context, email string) error {
+	user, err := s.userRepo.FindByEmail(ctx, email)
+	if err != nil || user == nil || user.IsEmailVerified {
+		return nil
+	}
+
+	token, err := s.setVerificationToken(user)
+	if err != nil {
+		return errors.NewInternal("Failed to generate verification token")
+	}
+
+	err = s.txManager.WithTransaction(ctx, func(tx *gorm.DB) error {
+		err := s.userRepo.WithTx(tx).UpdateColumns(ctx, user, map[string]interface{}{
+			"verification_token":  user.VerificationToken,
+			"verification_expiry": user.VerificationExpiry,
+		})
+		if err != nil {
+			return err
+		}
+		return enqueueVerificationRequest(tx, user)
+	})
+	if err != nil {
+		return errors.NewInternal("Failed to save verification token")
+	}
+
+	s.sendVerificationEmail(user, token)
+
+	return nil
+}
+
+// ForgotPassword emails a password reset token to the user. It does nothing
+// for unknown emails so callers do not reveal whether the email exists.
+func (s *AuthService) ForgotPassword(ctx context.Context, email string) error {
+	user, err := s.userRepo.FindByEmail(ctx, email)
+	if err != nil || user == nil {
+		return nil
+	}
+
+	token, err := auth.GenerateResetToken()
+	if err != nil {
+		return errors.NewInternal("Failed to generate reset token")
+	}
+
+	// Only the hash is stored; the token itself is only in the email
+	tokenHash := auth.HashToken(token)
+	expiry := time.Now().Add(PasswordResetExpiry)
+	user.PasswordResetToken = &tokenHash
+	user.PasswordResetExpiry = &expiry
+	err = s.userRepo.UpdateColumns(ctx, user, map[string]interface{}{
+		"password_reset_token":  tokenHash,
+		"password_reset_expiry": expiry,
+	})
+	if err != nil {
+		return errors.NewInternal("Failed to save reset token")
+	}
+
+	s.sendTokenEmail(user, notify.TemplatePasswordReset, token, PasswordResetExpiry)
+
+	return nil
+}
+
+// ResetPassword sets a new password for the user owning a password reset
+// token. The token can be used once, and the user's existing sessions end.
+func (s *AuthService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	user, err := s.userRepo.FindByPasswordResetToken(ctx, auth.HashToken(token))
+	if err != nil || user == nil {
+		return errors.NewBadRequest("Invalid or expired reset token")
+	}
+
+	if user.PasswordResetExpiry == nil || time.Now().After(*user.PasswordResetExpiry) {
+		return errors.NewBadRequest("Invalid or expired reset token")
+	}
+
+	hashedPassword, err := s.hasher.Hash(newPassword)
+	if err != nil {
+		return errors.NewInternal("Failed to hash password")
+	}
+
+	user.Password = hashedPassword
+	user.PasswordResetToken = nil
+	user.PasswordResetExpiry = nil
+
+	err = s.txManager.WithTransaction(ctx, func(tx *gorm.DB) error {
+		// The version check makes a token used twice at once succeed only once
+		updated, err := s.userRepo.WithTx(tx).UpdateIfVersion(ctx, user, user.Version)
+		if err != nil {
+			return err
+		}
+		if !updated {
+			return errors.NewBadRequest("Invalid or expired reset token")
+		}
+
+		err = events.Enqueue(tx, events.Event{
+			Name: events.EventUserPasswordReset,
+			Data: events.UserPasswordResetEvent{
+				UserID: user.ID,
+				Email:  user.Email,
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		return s.jwtManager.RevokeUserTokens(ctx, user.ID)
+	})
+	if err != nil {
+		if appErr, ok := errors.GetAppError(err); ok {
+			return appErr
+		}
+		return errors.NewInternal("Failed to reset password")
+	}
+
+	return nil
+}
+
+// setVerificationToken assigns a new verification token and expiry to the
+// user and returns the token. Like reset tokens, only the hash is stored; the
+// token itself is only in the email.
+func (s *AuthService) setVerificationToken(user *User) (string, error) {
+	token, err := auth.GenerateResetToken()
+	if err != nil {
+		return "", err
+	}
+
+	tokenHash := auth.HashToken(token)
+	expiry := time.Now().Add(EmailVerificationExpiry)
+	user.VerificationToken = &tokenHash
+	user.VerificationExpiry = &expiry
+	return token, nil
+}
+
+// enqueueVerificationRequest notifies listeners through the outbox of tx
+// that a verification email should be sent
+func enqueueVerificationRequest(tx *gorm.DB, user *User) error {
+	return events.Enqueue(tx, events.Event{
+		Name: events.EventUserVerifyRequest,
+		Data: events.UserVerifyRequestEvent{
+			UserID: user.ID,
+			Email:  user.Email,
+		},
+	})
+}
+
+// sendVerificationEmail emails a verification token to the user
+func (s *AuthService) sendVerificationEmail(user *User, token string) {
+	s.sendTokenEmail(user, notify.TemplateEmailVerification, token, EmailVerificationExpiry)
+}
+
+// sendTokenEmail renders a token email and sends it in the background, so a
+// slow or unreachable mail server neither delays nor fails the request.
+// Nothing is sent until SetMailer was called.
+func (s *AuthService) sendTokenEmail(user *User, template, token string, expiresIn time.Duration) {
+	if s.mailer == nil || s.mailConfig == nil {
+		return
+	}
+
+	// The link URLs contain a {token} placeholder
+	linkFormat := s.mailConfig.VerifyURL
+	if template == notify.TemplatePasswordReset {
+		linkFormat = s.mailConfig.ResetURL
+	}
+
+	msg, err := s.emails.Render(template, notify.TokenEmailData{
+		AppName:   s.mailConfig.AppName,
+		Name:      user.Name,
+		Link:      strings.ReplaceAll(linkFormat, "{token}", url.QueryEscape(token)),
+		Token:     token,
+		ExpiresIn: formatExpiry(expiresIn),
+	}, user.Email)
+	if err != nil {
+		fmt.Printf("⚠️  Failed to render %s email: %v\n", template, err)
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), emailSendTimeout)
+		defer cancel()
+		if err := s.mailer.Send(ctx, msg); err != nil {
+			fmt.Printf("⚠️  Failed to send %s email to %s: %v\n", template, msg.To[0], err)
+		}
+	}()
+}
+
+// formatExpiry formats a token lifetime for emails, e.g. "24 hours"
+func formatExpiry(d time.Duration) string {
+	if d >= time.Hour && d%time.Hour == 0 {
+		if hours := int(d / time.Hour); hours != 1 {
+			return fmt.Sprintf("%d hours", hours)
+		}
+		return "1 hour"
+	}
+	return fmt.Sprintf("%d minutes", int(d/time.Minute))
+}
+
+// RefreshToken refreshes access token and rotates the refresh token
 func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (map[string]interface{}, error) {
-	accessToken, err := s.jwtManager.RefreshAccessToken(refreshToken)
+	accessToken, newRefreshToken, err := s.jwtManager.RotateRefreshToken(ctx, refreshToken)
 	if err != nil {
 		return nil, errors.NewUnauthorized("Invalid refresh token")
 	}
 
 	return map[string]interface{}{
-		"access_token": accessToken,
-		"token_type":   "Bearer",
-		"expires_in":   900,
+		"access_token":  accessToken,
+		"refresh_token": newRefreshToken,
+		"token_type":    "Bearer",
+		"expires_in":    900,
 	}, nil
 }
 
+// Logout revokes the access token and, when provided, the refresh token. The
+// refresh token must belong to the user logging out.
+func (s *AuthService) Logout(ctx context.Context, userID uint, accessToken, refreshToken string) error {
+	if refreshToken != "" {
+		claims, err := s.jwtManager.ValidateToken(refreshToken)
+		switch {
+		case err == nil:
+			if claims.UserID != userID {
+				return errors.NewForbidden("Refresh token belongs to another user")
+			}
+		case stderrors.Is(err, auth.ErrExpiredToken), stderrors.Is(err, auth.ErrRevokedToken):
+			// Nothing left to revoke
+			refreshToken = ""
+		default:
+			return errors.NewBadRequest("Invalid refresh token")
+		}
+	}
+
+	if err := s.jwtManager.RevokeToken(ctx, accessToken); err != nil {
+		return errors.NewInternal("Failed to revoke access token")
+	}
+
+	if refreshToken != "" {
+		if err := s.jwtManager.RevokeToken(ctx, refreshToken); err != nil {
+			return errors.NewInternal("Failed to revoke refresh token")
+		}
+	}
+
+	events.DispatchAsync(ctx, events.Event{
+		Name: events.EventUserLoggedOut,
+		Data: events.UserLoggedOutEvent{
+			UserID: userID,
+		},
+	})
+
+	return nil
+}
+
+// UpdateProfile updates a user's own name and email. Empty values are left
+// unchanged. A new email must be verified again, so it resets the verified
+// state and sends a fresh verification email. The version is bumped so
+// concurrent admin edits conflict, and user.updated is published through the
+// outbox.
+func (s *AuthService) UpdateProfile(ctx context.Context, userID uint, name, email string) (*User, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil || user == nil {
+		return nil, errors.NewNotFound("User not found")
+	}
+
+	if name != "" {
+		user.Name = name
+	}
+	emailChanged := false
+	var verificationToken string
+	if email != "" && email != user.Email {
+		// Check if email is already taken by another user
+		existing, _ := s.userRepo.FindByEmail(ctx, email)
+		if existing != nil && existing.ID != userID {
+			return nil, errors.NewConflict("Email already in use")
+		}
+		user.Email = email
+		emailChanged = true
+
+		user.IsEmailVerified = false
+		user.EmailVerifiedAt = nil
+		verificationToken, err = s.setVerificationToken(user)
+		if err != nil {
+			return nil, errors.NewInternal("Failed to generate verification token")
+		}
+	}
+
+	updated := false
+	err = s.txManager.WithTransaction(ctx, func(tx *gorm.DB) error {
+		ok, err := s.userRepo.WithTx(tx).UpdateIfVersion(ctx, user, user.Version)
+		if err != nil || !ok {
+			return err
+		}
+		updated = true
+		if emailChanged {
+			if err := enqueueVerificationRequest(tx, user); err != nil {
+				return err
+			}
+		}
+		return enqueueUserUpdated(tx, user)
+	})
+	if err != nil {
+		return nil, errors.NewInternal("Failed to update profile")
+	}
+	if !updated {
+		return nil, errors.NewConflict("Profile was modified concurrently; try again")
+	}
+
+	if emailChanged {
+		s.sendVerificationEmail(user, verificationToken)
+	}
+
+	return user, nil
+}
+
 // ChangePassword changes user password
 func (s *AuthService) ChangePassword(ctx context.Context, userID uint, currentPassword, newPassword string) error {
 	user, err := s.userRepo.FindByID(ctx, userID)
@@ -197,7 +696,7 @@ func (s *AuthService) ChangePassword(ctx context.Context, userID uint, currentPa
 	}
 
 	user.Password = hashedPassword
-	return s.userRepo.Update(ctx, user)
+	return s.userRepo.UpdateColumns(ctx, user, map[string]interface{}{"password": hashedPassword})
 }
 
 // GenerateAPIKey generates API key for user
@@ -213,7 +712,7 @@ func (s *AuthService) GenerateAPIKey(ctx context.Context, userID uint) (string,
 	}
 
 	user.APIKey = &apiKey
-	if err := s.userRepo.Update(ctx, user); err != nil {
+	if err := s.userRepo.UpdateColumns(ctx, user, map[string]interface{}{"api_key": apiKey}); err != nil {
 		return "", errors.NewInternal("Failed to save API key")
 	}
 
@@ -0,0 +1,523 @@
+package user
+
+import (
+	"context"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"neonexcore/internal/config"
+	"neonexcore/pkg/auth"
+	"neonexcore/pkg/cache"
+	"neonexcore/pkg/database"
+	"neonexcore/pkg/errors"
+	"neonexcore/pkg/events"
+	"neonexcore/pkg/notify"
+	"neonexcore/pkg/rbac"
+	"neonexcore/pkg/validation"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "user.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	err = db.AutoMigrate(
+		&User{},
+		&rbac.Role{}, &rbac.Permission{}, &rbac.UserRole{}, &rbac.UserPermission{},
+		&events.OutboxMessage{},
+	)
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+func newTestAuthService(t *testing.T) (*AuthService, *gorm.DB) {
+	t.Helper()
+
+	db := newTestDB(t)
+	mc := cache.NewMemoryCache(cache.DefaultMemoryCacheConfig())
+	t.Cleanup(func() { mc.Close() })
+
+	jwtManager := auth.NewJWTManager(&auth.JWTConfig{SecretKey: "test-secret"})
+	jwtManager.SetBlacklist(auth.NewTokenBlacklist(mc))
+
+	service := NewAuthService(
+		NewUserRepository(db),
+		database.NewTxManager(db),
+		jwtManager,
+		auth.NewPasswordHasher(auth.MinCost),
+		rbac.NewManager(db),
+		auth.NewLoginLimiter(mc, auth.LockoutConfig{MaxAttempts: 3, LockoutDuration: time.Minute}),
+	)
+	return service, db
+}
+
+func registerTestUser(t *testing.T, service *AuthService, email string) *User {
+	t.Helper()
+
+	user, err := service.Register(context.Background(), &validation.RegisterRequest{
+		Name:     "Jane",
+		Email:    email,
+		Username: "jane",
+		Password: "correct-horse",
+	})
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	return user
+}
+
+// reloadUser reads a user back from the database
+func reloadUser(t *testing.T, db *gorm.DB, id uint) *User {
+	t.Helper()
+
+	var user User
+	if err := db.First(&user, id).Error; err != nil {
+		t.Fatalf("load user %d: %v", id, err)
+	}
+	return &user
+}
+
+func TestVerifyEmail(t *testing.T) {
+	service, db := newTestAuthService(t)
+	ctx := context.Background()
+
+	mailer := withCaptureMailer(service)
+
+	user := registerTestUser(t, service, "jane@example.com")
+	if user.IsEmailVerified || user.VerificationToken == nil {
+		t.Fatalf("registered user = %+v, want an unverified user with a token", user)
+	}
+	token := verificationToken(t, mailer, 1)
+
+	// Neither the user row nor the outbox holds the emailed token
+	if stored := reloadUser(t, db, user.ID); stored.VerificationToken == nil || *stored.VerificationToken == token {
+		t.Fatalf("stored verification token %v, want the token's hash", stored.VerificationToken)
+	}
+	var payloads []string
+	db.Model(&events.OutboxMessage{}).Pluck("payload", &payloads)
+	for _, payload := range payloads {
+		if strings.Contains(payload, token) {
+			t.Fatalf("outbox payload %s contains the verification token", payload)
+		}
+	}
+	if _, err := service.VerifyEmail(ctx, *user.VerificationToken); err == nil {
+		t.Fatal("VerifyEmail accepted the stored hash as a token")
+	}
+
+	if _, err := service.VerifyEmail(ctx, token); err != nil {
+		t.Fatalf("VerifyEmail: %v", err)
+	}
+
+	stored := reloadUser(t, db, user.ID)
+	if !stored.IsEmailVerified || stored.EmailVerifiedAt == nil {
+		t.Fatalf("user after verifying = %+v, want verified", stored)
+	}
+	if stored.VerificationToken != nil {
+		t.Fatal("verification token was kept after use")
+	}
+
+	if _, err := service.VerifyEmail(ctx, token); err == nil {
+		t.Fatal("VerifyEmail accepted a used token")
+	}
+}
+
+func TestVerifyEmailRejectsInvalidAndExpiredTokens(t *testing.T) {
+	service, db := newTestAuthService(t)
+	ctx := context.Background()
+
+	if _, err := service.VerifyEmail(ctx, "unknown-token"); err == nil {
+		t.Fatal("VerifyEmail accepted an unknown token")
+	}
+
+	mailer := withCaptureMailer(service)
+	user := registerTestUser(t, service, "jane@example.com")
+	expired := time.Now().Add(-time.Minute)
+	if err := db.Model(&User{}).Where("id = ?", user.ID).Update("verification_expiry", expired).Error; err != nil {
+		t.Fatalf("expire token: %v", err)
+	}
+
+	if _, err := service.VerifyEmail(ctx, verificationToken(t, mailer, 1)); err == nil {
+		t.Fatal("VerifyEmail accepted an expired token")
+	}
+	if reloadUser(t, db, user.ID).IsEmailVerified {
+		t.Fatal("an expired token verified the user")
+	}
+}
+
+func TestLogoutRevokesOnlyOwnRefreshToken(t *testing.T) {
+	service, _ := newTestAuthService(t)
+	ctx := context.Background()
+
+	accessToken, err := service.jwtManager.GenerateAccessToken(1, "jane@example.com", "user", nil)
+	if err != nil {
+		t.Fatalf("GenerateAccessToken: %v", err)
+	}
+	ownRefresh, _ := service.jwtManager.GenerateRefreshToken(1, "jane@example.com")
+	otherRefresh, _ := service.jwtManager.GenerateRefreshToken(2, "john@example.com")
+
+	if err := service.Logout(ctx, 1, accessToken, otherRefresh); err == nil {
+		t.Fatal("Logout accepted another user's refresh token")
+	}
+	if _, err := service.jwtManager.ValidateToken(otherRefresh); err != nil {
+		t.Fatalf("another user's refresh token was revoked: %v", err)
+	}
+	if _, err := service.jwtManager.ValidateToken(accessToken); err != nil {
+		t.Fatalf("rejected logout revoked the access token: %v", err)
+	}
+
+	if err := service.Logout(ctx, 1, accessToken, ownRefresh); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+	for name, token := range map[string]string{"access": accessToken, "refresh": ownRefresh} {
+		if _, err := service.jwtManager.ValidateToken(token); err == nil {
+			t.Errorf("%s token is still valid after logout", name)
+		}
+	}
+}
+
+func TestLoginLocksOutAfterFailedAttempts(t *testing.T) {
+	service, _ := newTestAuthService(t)
+	ctx := context.Background()
+	registerTestUser(t, service, "jane@example.com")
+
+	// A success in between resets the count
+	for i := 0; i < 2; i++ {
+		if _, err := service.Login(ctx, "jane@example.com", "wrong", "10.0.0.1"); err == nil {
+			t.Fatal("Login accepted a wrong password")
+		}
+	}
+	if _, err := service.Login(ctx, "jane@example.com", "correct-horse", "10.0.0.1"); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		service.Login(ctx, "jane@example.com", "wrong", "10.0.0.1")
+	}
+	_, err := service.Login(ctx, "jane@example.com", "correct-horse", "10.0.0.1")
+	appErr, ok := err.(*errors.AppError)
+	if !ok || appErr.Code != errors.ErrCodeAccountLocked {
+		t.Fatalf("Login while locked: %v, want an account locked error", err)
+	}
+}
+
+func TestLoginUpgradesPasswordHash(t *testing.T) {
+	service, db := newTestAuthService(t)
+	ctx := context.Background()
+	user := registerTestUser(t, service, "jane@example.com")
+	oldHash := reloadUser(t, db, user.ID).Password
+
+	// The operator raises the cost and adds a pepper
+	hasher := auth.NewPasswordHasher(auth.MinCost + 1)
+	hasher.SetPepper("pepper")
+	service.hasher = hasher
+
+	if _, err := service.Login(ctx, "jane@example.com", "wrong", "10.0.0.1"); err == nil {
+		t.Fatal("Login accepted a wrong password")
+	}
+	if reloadUser(t, db, user.ID).Password != oldHash {
+		t.Fatal("a failed login changed the password hash")
+	}
+
+	if _, err := service.Login(ctx, "jane@example.com", "correct-horse", "10.0.0.1"); err != nil {
+		t.Fatalf("Login with the old hash: %v", err)
+	}
+	upgraded := reloadUser(t, db, user.ID).Password
+	if upgraded == oldHash || hasher.NeedsRehash(upgraded) {
+		t.Fatalf("hash wasn't upgraded to cost %d", hasher.Cost())
+	}
+	if newHash, err := hasher.VerifyAndRehash("correct-horse", upgraded); err != nil || newHash != "" {
+		t.Fatalf("upgraded hash isn't peppered: rehash %q, err %v", newHash, err)
+	}
+
+	// Later logins use the upgraded hash as is
+	if _, err := service.Login(ctx, "jane@example.com", "correct-horse", "10.0.0.1"); err != nil {
+		t.Fatalf("Login with the upgraded hash: %v", err)
+	}
+	if reloadUser(t, db, user.ID).Password != upgraded {
+		t.Fatal("a current hash was rehashed")
+	}
+}
+
+func TestUpdateProfileEmailChangeRequiresVerification(t *testing.T) {
+	service, db := newTestAuthService(t)
+	ctx := context.Background()
+
+	mailer := withCaptureMailer(service)
+
+	user := registerTestUser(t, service, "jane@example.com")
+	if _, err := service.VerifyEmail(ctx, verificationToken(t, mailer, 1)); err != nil {
+		t.Fatalf("VerifyEmail: %v", err)
+	}
+
+	// A name change keeps the verified email
+	if _, err := service.UpdateProfile(ctx, user.ID, "Jane Doe", ""); err != nil {
+		t.Fatalf("UpdateProfile: %v", err)
+	}
+	if !reloadUser(t, db, user.ID).IsEmailVerified {
+		t.Fatal("changing the name reset email verification")
+	}
+
+	if _, err := service.UpdateProfile(ctx, user.ID, "", "jane.doe@example.com"); err != nil {
+		t.Fatalf("UpdateProfile: %v", err)
+	}
+	stored := reloadUser(t, db, user.ID)
+	if stored.IsEmailVerified || stored.EmailVerifiedAt != nil {
+		t.Fatalf("user after changing email = %+v, want unverified", stored)
+	}
+	if stored.VerificationToken == nil {
+		t.Fatal("no verification token was issued for the new email")
+	}
+
+	var requests int64
+	db.Model(&events.OutboxMessage{}).Where("event_name = ?", events.EventUserVerifyRequest).Count(&requests)
+	if requests != 2 {
+		t.Fatalf("%d verification requests were published, want 2 (register and email change)", requests)
+	}
+
+	if _, err := service.VerifyEmail(ctx, verificationToken(t, mailer, 2)); err != nil {
+		t.Fatalf("VerifyEmail for the new email: %v", err)
+	}
+}
+
+// withCaptureMailer makes the service send account emails to a capturing mailer
+func withCaptureMailer(service *AuthService) *notify.CaptureMailer {
+	mailer := notify.NewCaptureMailer()
+	service.SetMailer(mailer, notify.NewRenderer(), &config.MailConfig{
+		AppName:   "Neonex",
+		VerifyURL: "https://app.example.com/verify/{token}",
+		ResetURL:  "https://app.example.com/reset-password?token={token}",
+	})
+	return mailer
+}
+
+// waitForEmails waits until the mailer captured n messages; emails are sent
+// in the background
+func waitForEmails(t *testing.T, mailer *notify.CaptureMailer, n int) []notify.Message {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		messages := mailer.Messages()
+		if len(messages) >= n {
+			return messages
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d emails sent, want %d", len(messages), n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// linkToken extracts the token from an email's link
+func linkToken(t *testing.T, msg notify.Message, prefix string) string {
+	t.Helper()
+
+	for _, line := range strings.Split(msg.Text, "\n") {
+		if strings.HasPrefix(line, prefix) {
+			token, err := url.QueryUnescape(strings.TrimPrefix(line, prefix))
+			if err != nil {
+				t.Fatalf("unescape token: %v", err)
+			}
+			return token
+		}
+	}
+	t.Fatalf("no %s link in %q", prefix, msg.Text)
+	return ""
+}
+
+// verificationToken returns the token of the nth email the mailer captured,
+// a verification email
+func verificationToken(t *testing.T, mailer *notify.CaptureMailer, n int) string {
+	t.Helper()
+
+	return linkToken(t, waitForEmails(t, mailer, n)[n-1], "https://app.example.com/verify/")
+}
+
+func TestRegisterEmailsVerificationLink(t *testing.T) {
+	service, _ := newTestAuthService(t)
+	mailer := withCaptureMailer(service)
+
+	user := registerTestUser(t, service, "jane@example.com")
+
+	msg := waitForEmails(t, mailer, 1)[0]
+	if len(msg.To) != 1 || msg.To[0] != "jane@example.com" || msg.Subject != "Verify your Neonex email address" {
+		t.Fatalf("email to %v with subject %q", msg.To, msg.Subject)
+	}
+	token := linkToken(t, msg, "https://app.example.com/verify/")
+	if auth.HashToken(token) != *user.VerificationToken {
+		t.Fatalf("emailed token %q, want the one whose hash is stored", token)
+	}
+	if !strings.Contains(msg.Text, "expires in 24 hours") {
+		t.Fatalf("email text %q, want the expiry", msg.Text)
+	}
+}
+
+func TestForgotPasswordEmailsResetLink(t *testing.T) {
+	service, db := newTestAuthService(t)
+	ctx := context.Background()
+	user := registerTestUser(t, service, "jane@example.com")
+	mailer := withCaptureMailer(service)
+
+	// Unknown emails are silently ignored
+	if err := service.ForgotPassword(ctx, "nobody@example.com"); err != nil {
+		t.Fatalf("ForgotPassword for an unknown email: %v", err)
+	}
+	if err := service.ForgotPassword(ctx, "jane@example.com"); err != nil {
+		t.Fatalf("ForgotPassword: %v", err)
+	}
+
+	msg := waitForEmails(t, mailer, 1)[0]
+	if len(msg.To) != 1 || msg.To[0] != "jane@example.com" || msg.Subject != "Reset your Neonex password" {
+		t.Fatalf("email to %v with subject %q", msg.To, msg.Subject)
+	}
+	token := linkToken(t, msg, "https://app.example.com/reset-password?token=")
+
+	// Only a hash of the token is stored
+	stored := reloadUser(t, db, user.ID)
+	if stored.PasswordResetToken == nil || *stored.PasswordResetToken == token {
+		t.Fatalf("stored reset token %v, want the token's hash", stored.PasswordResetToken)
+	}
+
+	if err := service.ResetPassword(ctx, token, "new-password-1"); err != nil {
+		t.Fatalf("ResetPassword: %v", err)
+	}
+	if _, err := service.Login(ctx, "jane@example.com", "new-password-1", "10.0.0.1"); err != nil {
+		t.Fatalf("Login with the new password: %v", err)
+	}
+	if err := service.ResetPassword(ctx, token, "new-password-2"); err == nil {
+		t.Fatal("ResetPassword accepted a used token")
+	}
+	if err := service.ResetPassword(ctx, *stored.PasswordResetToken, "new-password-2"); err == nil {
+		t.Fatal("ResetPassword accepted the stored hash as a token")
+	}
+}
+
+func TestResetPasswordRejectsExpiredToken(t *testing.T) {
+	service, db := newTestAuthService(t)
+	ctx := context.Background()
+	user := registerTestUser(t, service, "jane@example.com")
+	mailer := withCaptureMailer(service)
+
+	if err := service.ForgotPassword(ctx, "jane@example.com"); err != nil {
+		t.Fatalf("ForgotPassword: %v", err)
+	}
+	token := linkToken(t, waitForEmails(t, mailer, 1)[0], "https://app.example.com/reset-password?token=")
+	db.Model(&User{}).Where("id = ?", user.ID).Update("password_reset_expiry", time.Now().Add(-time.Minute))
+
+	if err := service.ResetPassword(ctx, token, "new-password-1"); err == nil {
+		t.Fatal("ResetPassword accepted an expired token")
+	}
+}
+
+func TestAccountEmailsSkippedWithoutMailConfig(t *testing.T) {
+	service, db := newTestAuthService(t)
+	ctx := context.Background()
+	mailer := notify.NewCaptureMailer()
+	service.SetMailer(mailer, notify.NewRenderer(), nil)
+
+	user := registerTestUser(t, service, "jane@example.com")
+	if err := service.ForgotPassword(ctx, "jane@example.com"); err != nil {
+		t.Fatalf("ForgotPassword: %v", err)
+	}
+
+	// The reset is still recorded, but nothing is sent
+	if reloadUser(t, db, user.ID).PasswordResetToken == nil {
+		t.Fatal("no reset token was stored")
+	}
+	time.Sleep(50 * time.Millisecond)
+	if messages := mailer.Messages(); len(messages) != 0 {
+		t.Fatalf("%d emails sent without a mail config", len(messages))
+	}
+}
+
+func TestRegisterWithDeletedUsersEmail(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   string
+		email    string
+		username string
+		restored bool
+	}{
+		{"reject same email", config.DeletedUserReject, "jane@example.com", "janet", false},
+		{"reject same username", config.DeletedUserReject, "janet@example.com", "jane", false},
+		{"restore same email", config.DeletedUserRestore, "jane@example.com", "janet", true},
+		// Only the owner of the email may take the deleted account over
+		{"restore same username", config.DeletedUserRestore, "janet@example.com", "jane", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, db := newTestAuthService(t)
+			service.SetDeletedUserPolicy(tt.policy)
+			ctx := context.Background()
+
+			userRole := &rbac.Role{Name: "User", Slug: "user"}
+			adminRole := &rbac.Role{Name: "Admin", Slug: "admin"}
+			for _, role := range []*rbac.Role{userRole, adminRole} {
+				if err := service.rbacManager.CreateRole(ctx, role); err != nil {
+					t.Fatalf("CreateRole %s: %v", role.Slug, err)
+				}
+			}
+
+			deleted := registerTestUser(t, service, "jane@example.com")
+			if err := service.rbacManager.AssignRole(ctx, deleted.ID, adminRole.ID); err != nil {
+				t.Fatalf("AssignRole: %v", err)
+			}
+			token, _ := service.jwtManager.GenerateAccessToken(deleted.ID, deleted.Email, "admin", nil)
+			if err := db.Delete(&User{}, deleted.ID).Error; err != nil {
+				t.Fatalf("delete user: %v", err)
+			}
+
+			user, err := service.Register(ctx, &validation.RegisterRequest{
+				Name:     "Janet",
+				Email:    tt.email,
+				Username: tt.username,
+				Password: "another-horse",
+			})
+
+			if !tt.restored {
+				appErr, ok := errors.GetAppError(err)
+				if !ok || appErr.Code != errors.ErrCodeAccountDeleted || appErr.StatusCode != 409 {
+					t.Fatalf("Register error = %v, want a 409 ACCOUNT_DELETED", err)
+				}
+				var trashed User
+				if err := db.Unscoped().First(&trashed, deleted.ID).Error; err != nil || !trashed.DeletedAt.Valid {
+					t.Fatal("deleted user was restored")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Register: %v", err)
+			}
+			if user.ID != deleted.ID {
+				t.Fatalf("registered user %d, want deleted user %d restored", user.ID, deleted.ID)
+			}
+			stored := reloadUser(t, db, deleted.ID)
+			if stored.Name != "Janet" || stored.Username != "janet" || stored.Password == deleted.Password || stored.Version <= deleted.Version {
+				t.Fatalf("restored user = %+v, want the new details", stored)
+			}
+
+			// The restored user starts over with the default role and no sessions
+			roles, err := service.rbacManager.GetUserRoles(ctx, deleted.ID)
+			if err != nil || len(roles) != 1 || roles[0].Slug != "user" {
+				t.Fatalf("restored user's roles = %v, %v; want only user", roles, err)
+			}
+			if _, err := service.jwtManager.ValidateToken(token); err == nil {
+				t.Fatal("the deleted user's token is still valid")
+			}
+			if got := outboxEvents(t, db); !strings.Contains(strings.Join(got, ","), events.EventUserRestored) {
+				t.Fatalf("outbox = %v, want user.restored", got)
+			}
+		})
+	}
+}
@@ -1,12 +1,17 @@
 package user
 
 import (
+	"context"
+	"fmt"
 	"time"
 
 	"neonexcore/internal/config"
 	"neonexcore/internal/core"
 	"neonexcore/pkg/auth"
+	"neonexcore/pkg/cache"
 	"neonexcore/pkg/database"
+	"neonexcore/pkg/flags"
+	"neonexcore/pkg/notify"
 	"neonexcore/pkg/rbac"
 )
 
@@ -19,22 +24,58 @@ func (m *UserModule) RegisterServices(c *core.Container) {
 		return database.NewTxManager(db)
 	}, core.Singleton)
 
+	// ==================== Cache ====================
+
+	// Register shared cache (in-memory, swap for Redis in multi-instance deployments)
+	c.Provide(func() cache.Cache {
+		return cache.NewMemoryCache(cache.DefaultMemoryCacheConfig())
+	}, core.Singleton)
+
 	// ==================== Authentication & Security ====================
-	
+
+	// Register Token Blacklist
+	c.Provide(func() *auth.TokenBlacklist {
+		return auth.NewTokenBlacklist(core.Resolve[cache.Cache](c))
+	}, core.Singleton)
+
 	// Register JWT Manager
 	c.Provide(func() *auth.JWTManager {
-		return auth.NewJWTManager(&auth.JWTConfig{
+		jwtManager := auth.NewJWTManager(&auth.JWTConfig{
 			SecretKey:     "your-secret-key-change-in-production", // TODO: Move to config
 			AccessExpiry:  15 * time.Minute,
 			RefreshExpiry: 7 * 24 * time.Hour,
 			Issuer:        "NeonexCore",
 			Algorithm:     "HS256",
 		})
+		jwtManager.SetBlacklist(core.Resolve[*auth.TokenBlacklist](c))
+		return jwtManager
+	}, core.Singleton)
+
+	// Register Login Limiter (brute-force lockout)
+	c.Provide(func() *auth.LoginLimiter {
+		lockoutConfig := config.LoadLockoutConfig()
+		return auth.NewLoginLimiter(core.Resolve[cache.Cache](c), auth.LockoutConfig{
+			MaxAttempts:     lockoutConfig.MaxAttempts,
+			Window:          lockoutConfig.Window,
+			LockoutDuration: lockoutConfig.Duration,
+		})
 	}, core.Singleton)
 
 	// Register Password Hasher
 	c.Provide(func() *auth.PasswordHasher {
-		return auth.NewPasswordHasher(12) // bcrypt cost
+		return newPasswordHasher()
+	}, core.Singleton)
+
+	// ==================== Notifications ====================
+
+	// Register Mailer (SMTP when MAIL_DRIVER=smtp, otherwise mail is discarded)
+	c.Provide(func() notify.Mailer {
+		return newMailer(config.LoadMailConfig())
+	}, core.Singleton)
+
+	// Register Email Template Renderer
+	c.Provide(func() *notify.Renderer {
+		return notify.NewRenderer()
 	}, core.Singleton)
 
 	// ==================== RBAC ====================
@@ -42,7 +83,24 @@ func (m *UserModule) RegisterServices(c *core.Container) {
 	// Register RBAC Manager
 	c.Provide(func() *rbac.Manager {
 		db := config.DB.GetDB()
-		return rbac.NewManager(db)
+		manager := rbac.NewManager(db)
+		manager.SetCache(core.Resolve[cache.Cache](c), rbac.DefaultCacheTTL)
+		return manager
+	}, core.Singleton)
+
+	// Register RBAC Controller
+	c.Provide(func() *rbac.Controller {
+		return rbac.NewController(core.Resolve[*rbac.Manager](c))
+	}, core.Transient)
+
+	// ==================== Feature Flags ====================
+
+	// Register Feature Flag Service
+	c.Provide(func() *flags.Service {
+		db := config.DB.GetDB()
+		service := flags.NewService(db, core.Resolve[*rbac.Manager](c))
+		service.SetCache(core.Resolve[cache.Cache](c), flags.DefaultCacheTTL)
+		return service
 	}, core.Singleton)
 
 	// ==================== Repositories ====================
@@ -50,7 +108,13 @@ func (m *UserModule) RegisterServices(c *core.Container) {
 	// Register User Repository
 	c.Provide(func() *UserRepository {
 		db := config.DB.GetDB()
-		return NewUserRepository(db)
+		repo := NewUserRepository(db)
+		if config.LoadUserSearchConfig().TrigramIndex {
+			if err := repo.EnsureSearchIndexes(context.Background()); err != nil {
+				fmt.Printf("⚠️  Failed to create user search indexes: %v\n", err)
+			}
+		}
+		return repo
 	}, core.Singleton)
 
 	// ==================== Services ====================
@@ -59,16 +123,23 @@ func (m *UserModule) RegisterServices(c *core.Container) {
 	c.Provide(func() *UserService {
 		repo := core.Resolve[*UserRepository](c)
 		txManager := core.Resolve[*database.TxManager](c)
-		return NewUserService(repo, txManager)
+		service := NewUserService(repo, txManager)
+		service.SetDeletedUserPolicy(config.LoadUserAccountConfig().DeletedUserPolicy)
+		return service
 	}, core.Singleton)
 
 	// Register Auth Service
 	c.Provide(func() *AuthService {
 		userRepo := core.Resolve[*UserRepository](c)
+		txManager := core.Resolve[*database.TxManager](c)
 		jwtManager := core.Resolve[*auth.JWTManager](c)
 		hasher := core.Resolve[*auth.PasswordHasher](c)
 		rbacManager := core.Resolve[*rbac.Manager](c)
-		return NewAuthService(userRepo, jwtManager, hasher, rbacManager)
+		limiter := core.Resolve[*auth.LoginLimiter](c)
+		service := NewAuthService(userRepo, txManager, jwtManager, hasher, rbacManager, limiter)
+		service.SetMailer(core.Resolve[notify.Mailer](c), core.Resolve[*notify.Renderer](c), config.LoadMailConfig())
+		service.SetDeletedUserPolicy(config.LoadUserAccountConfig().DeletedUserPolicy)
+		return service
 	}, core.Singleton)
 
 	// ==================== Controllers ====================
@@ -83,6 +154,35 @@ func (m *UserModule) RegisterServices(c *core.Container) {
 	c.Provide(func() *UserController {
 		service := core.Resolve[*UserService](c)
 		rbacManager := core.Resolve[*rbac.Manager](c)
-		return NewUserController(service, rbacManager)
+		hasher := core.Resolve[*auth.PasswordHasher](c)
+		jwtManager := core.Resolve[*auth.JWTManager](c)
+		controller := NewUserController(service, rbacManager, hasher, jwtManager)
+		controller.SetSearch(core.Resolve[cache.Cache](c), config.LoadUserSearchConfig())
+		return controller
 	}, core.Transient)
 }
+
+// newPasswordHasher creates a hasher with the configured bcrypt cost and pepper
+func newPasswordHasher() *auth.PasswordHasher {
+	passwordConfig := config.LoadPasswordConfig()
+	hasher := auth.NewPasswordHasher(passwordConfig.BcryptCost)
+	hasher.SetPepper(passwordConfig.Pepper)
+	return hasher
+}
+
+// newMailer creates the mailer selected by the mail config
+func newMailer(mailConfig *config.MailConfig) notify.Mailer {
+	if mailConfig.Driver != "smtp" {
+		return notify.NoopMailer{}
+	}
+
+	return notify.NewSMTPMailer(&notify.SMTPConfig{
+		Host:     mailConfig.Host,
+		Port:     mailConfig.Port,
+		Username: mailConfig.Username,
+		Password: mailConfig.Password,
+		From:     mailConfig.From,
+		TLS:      mailConfig.Encryption,
+		Timeout:  10 * time.Second,
+	})
+}
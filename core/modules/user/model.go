@@ -24,9 +24,12 @@ type User struct {
 	IsEmailVerified     bool           `gorm:"default:false" json:"is_email_verified"`
 	EmailVerifiedAt     *time.Time     `json:"email_verified_at,omitempty"`
 	LastLoginAt         *time.Time     `json:"last_login_at,omitempty"`
-	PasswordResetToken  *string        `gorm:"size:255" json:"-"`
+	PasswordResetToken  *string        `gorm:"size:255;index" json:"-"` // SHA-256 of the emailed token
 	PasswordResetExpiry *time.Time     `json:"-"`
+	VerificationToken   *string        `gorm:"size:255;index" json:"-"`
+	VerificationExpiry  *time.Time     `json:"-"`
 	APIKey              *string        `gorm:"size:255;uniqueIndex" json:"-"`
+	Version             uint           `gorm:"not null;default:1" json:"version"` // Incremented by versioned updates, used as the ETag
 
 	// Relations
 	Roles       []rbac.UserRole       `gorm:"foreignKey:UserID" json:"roles,omitempty"`
@@ -0,0 +1,66 @@
+package user
+
+import (
+	"encoding/json"
+	"testing"
+
+	"neonexcore/pkg/api"
+)
+
+func TestUserOpenAPISchema(t *testing.T) {
+	sg := api.NewSwaggerGenerator(api.SwaggerInfo{Title: "Test", Version: "1.0"})
+	sg.AddSchemaFromStruct("User", &User{})
+
+	data, err := sg.GenerateJSON()
+	if err != nil {
+		t.Fatalf("GenerateJSON: %v", err)
+	}
+	var spec struct {
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]map[string]interface{} `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		t.Fatalf("decode spec: %v", err)
+	}
+	properties := spec.Components.Schemas["User"].Properties
+
+	// Fields hidden from JSON stay out of the schema
+	for _, hidden := range []string{"Password", "password", "DeletedAt", "APIKey", "VerificationToken"} {
+		if _, exists := properties[hidden]; exists {
+			t.Errorf("hidden field %s is in the schema", hidden)
+		}
+	}
+
+	tests := []struct {
+		name, typ, format string
+	}{
+		{"id", "integer", "int64"},
+		{"email", "string", ""},
+		{"age", "integer", "int64"},
+		{"is_active", "boolean", ""},
+		{"created_at", "string", "date-time"},
+		{"email_verified_at", "string", "date-time"},
+	}
+	for _, tt := range tests {
+		property := properties[tt.name]
+		if property["type"] != tt.typ || (tt.format != "" && property["format"] != tt.format) {
+			t.Errorf("%s = %v, want type %s format %s", tt.name, property, tt.typ, tt.format)
+		}
+	}
+	if nullable := properties["last_login_at"]["nullable"]; nullable != true {
+		t.Errorf("last_login_at is not nullable: %v", properties["last_login_at"])
+	}
+
+	// Relations are registered as their own schemas
+	roles := properties["roles"]
+	items, _ := roles["items"].(map[string]interface{})
+	if roles["type"] != "array" || items["$ref"] != "#/components/schemas/UserRole" {
+		t.Fatalf("roles = %v, want an array of UserRole references", roles)
+	}
+	if _, exists := spec.Components.Schemas["UserRole"]; !exists {
+		t.Fatal("UserRole schema is not registered")
+	}
+}
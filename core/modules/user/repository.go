@@ -6,6 +6,7 @@ import (
 	"neonexcore/pkg/database"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // UserRepository handles user data operations
@@ -20,11 +21,53 @@ func NewUserRepository(db *gorm.DB) *UserRepository {
 	}
 }
 
+// WithTx returns a user repository with a transaction
+func (r *UserRepository) WithTx(tx *gorm.DB) *UserRepository {
+	return &UserRepository{
+		BaseRepository: r.BaseRepository.WithTx(tx),
+	}
+}
+
 // FindByEmail finds a user by email
 func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*User, error) {
 	return r.FindOne(ctx, "email = ?", email)
 }
 
+// UpdateIfVersion saves a user only if its stored version still matches,
+// incrementing the version, and reports whether it did. Associations are not
+// saved.
+func (r *UserRepository) UpdateIfVersion(ctx context.Context, user *User, version uint) (bool, error) {
+	user.Version = version + 1
+	result := r.GetDB().WithContext(ctx).
+		Model(user).
+		Where("version = ?", version).
+		Select("*").
+		Omit("id", "created_at", clause.Associations).
+		Updates(user)
+	if result.Error != nil || result.RowsAffected == 0 {
+		user.Version = version
+		return false, result.Error
+	}
+	return true, nil
+}
+
+// UpdateColumns updates only the given columns of a user and increments its
+// version, so a versioned update based on an earlier read conflicts instead
+// of writing back the old values. user.Version is refreshed from the row.
+func (r *UserRepository) UpdateColumns(ctx context.Context, user *User, columns map[string]interface{}) error {
+	values := make(map[string]interface{}, len(columns)+1)
+	for column, value := range columns {
+		values[column] = value
+	}
+	values["version"] = gorm.Expr("version + 1")
+
+	db := r.GetDB().WithContext(ctx)
+	if err := db.Model(&User{}).Where("id = ?", user.ID).Updates(values).Error; err != nil {
+		return err
+	}
+	return db.Model(&User{}).Where("id = ?", user.ID).Select("version").Scan(&user.Version).Error
+}
+
 // FindActiveUsers finds all active users
 func (r *UserRepository) FindActiveUsers(ctx context.Context) ([]*User, error) {
 	return r.FindByCondition(ctx, "active = ?", true)
@@ -40,3 +83,103 @@ func (r *UserRepository) SearchUsers(ctx context.Context, keyword string) ([]*Us
 	pattern := "%" + keyword + "%"
 	return r.FindByCondition(ctx, "name LIKE ? OR email LIKE ?", pattern, pattern)
 }
+
+// PaginateTrashed returns soft-deleted users, most recently deleted first
+func (r *UserRepository) PaginateTrashed(ctx context.Context, page, pageSize int) ([]*User, int64, error) {
+	var users []*User
+	var total int64
+
+	query := r.ReadReplica(ctx).Unscoped().Model(&User{}).Where("deleted_at IS NOT NULL")
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("deleted_at DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&users).Error
+	return users, total, err
+}
+
+// FindTrashedByID finds a soft-deleted user by ID
+func (r *UserRepository) FindTrashedByID(ctx context.Context, id uint) (*User, error) {
+	var user User
+	err := r.GetDB().WithContext(ctx).Unscoped().Where("deleted_at IS NOT NULL").First(&user, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// FindActiveConflict finds an active user other than excludeID that holds
+// the given email or username
+func (r *UserRepository) FindActiveConflict(ctx context.Context, email, username string, excludeID uint) (*User, error) {
+	query := r.GetDB().WithContext(ctx).Where("id <> ?", excludeID)
+	if username != "" {
+		query = query.Where("email = ? OR username = ?", email, username)
+	} else {
+		query = query.Where("email = ?", email)
+	}
+
+	var user User
+	err := query.First(&user).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// FindTrashedConflicts finds the soft-deleted users holding the given email
+// or username
+func (r *UserRepository) FindTrashedConflicts(ctx context.Context, email, username string) ([]*User, error) {
+	query := r.GetDB().WithContext(ctx).Unscoped().Where("deleted_at IS NOT NULL")
+	if username != "" {
+		query = query.Where("email = ? OR username = ?", email, username)
+	} else {
+		query = query.Where("email = ?", email)
+	}
+
+	var users []*User
+	err := query.Find(&users).Error
+	return users, err
+}
+
+// RestoreAs restores the soft-deleted user with the given ID, replacing its
+// data with user's. user takes over the ID, creation time and version.
+// Associations are not saved.
+func (r *UserRepository) RestoreAs(ctx context.Context, id uint, user *User) error {
+	var trashed User
+	err := r.GetDB().WithContext(ctx).Unscoped().Where("deleted_at IS NOT NULL").First(&trashed, id).Error
+	if err != nil {
+		return err
+	}
+
+	user.ID = trashed.ID
+	user.CreatedAt = trashed.CreatedAt
+	user.DeletedAt = gorm.DeletedAt{}
+	user.Version = trashed.Version + 1
+	return r.GetDB().WithContext(ctx).
+		Unscoped().
+		Model(user).
+		Select("*").
+		Omit("id", "created_at", clause.Associations).
+		Updates(user).Error
+}
+
+// Restore clears a user's soft delete
+func (r *UserRepository) Restore(ctx context.Context, id uint) error {
+	return r.GetDB().WithContext(ctx).Unscoped().Model(&User{}).Where("id = ?", id).Update("deleted_at", nil).Error
+}
+
+// CountActiveByIDs counts the active users among the given IDs
+func (r *UserRepository) CountActiveByIDs(ctx context.Context, ids []uint) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	var count int64
+	err := r.GetDB().WithContext(ctx).Model(&User{}).
+		Where("id IN ? AND is_active = ?", ids, true).
+		Count(&count).Error
+	return count, err
+}
@@ -2,8 +2,11 @@ package user
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"neonexcore/pkg/database"
+	"neonexcore/pkg/rbac"
 
 	"gorm.io/gorm"
 )
@@ -33,11 +36,77 @@ func (r *UserRepository) FindByAPIKey(ctx context.Context, apiKey string) (*User
 	return r.FindOne(ctx, "api_key = ?", apiKey)
 }
 
+// FindByVerificationToken finds a user by the hash of an email verification token
+func (r *UserRepository) FindByVerificationToken(ctx context.Context, tokenHash string) (*User, error) {
+	return r.FindOne(ctx, "verification_token = ?", tokenHash)
+}
+
+// FindByPasswordResetToken finds a user by the hash of a password reset token
+func (r *UserRepository) FindByPasswordResetToken(ctx context.Context, tokenHash string) (*User, error) {
+	return r.FindOne(ctx, "password_reset_token = ?", tokenHash)
+}
+
+// WithRole returns a scope restricting users to those holding the given role slug
+func (r *UserRepository) WithRole(slug string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		subQuery := r.GetDB().Model(&rbac.UserRole{}).
+			Select("user_roles.user_id").
+			Joins("JOIN roles ON roles.id = user_roles.role_id AND roles.deleted_at IS NULL").
+			Where("roles.slug = ?", slug)
+		return db.Where("users.id IN (?)", subQuery)
+	}
+}
+
 // Search searches users by name or email
 func (r *UserRepository) Search(ctx context.Context, query string) ([]*User, error) {
 	return r.FindByCondition(ctx, "name LIKE ? OR email LIKE ?", "%"+query+"%", "%"+query+"%")
 }
 
+// SearchLimited searches users by name or email, returning at most limit
+// matches ordered by name. Wildcards in the query are matched literally.
+// On PostgreSQL the match is case-insensitive and can use the trigram
+// indexes from EnsureSearchIndexes.
+func (r *UserRepository) SearchLimited(ctx context.Context, query string, limit int) ([]*User, error) {
+	db := r.ReadReplica(ctx)
+
+	operator := "LIKE"
+	if db.Dialector.Name() == "postgres" {
+		operator = "ILIKE"
+	}
+
+	pattern := "%" + searchEscaper.Replace(query) + "%"
+	condition := fmt.Sprintf("name %[1]s ? ESCAPE '!' OR email %[1]s ? ESCAPE '!'", operator)
+
+	var users []*User
+	err := db.Where(condition, pattern, pattern).Order("name ASC").Limit(limit).Find(&users).Error
+	return users, err
+}
+
+// searchEscaper escapes LIKE wildcards with the '!' escape character
+var searchEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// EnsureSearchIndexes creates pg_trgm GIN indexes so SearchLimited's
+// substring matches don't scan the whole users table. It is a no-op on
+// databases other than PostgreSQL.
+func (r *UserRepository) EnsureSearchIndexes(ctx context.Context) error {
+	db := r.GetDB().WithContext(ctx)
+	if db.Dialector.Name() != "postgres" {
+		return nil
+	}
+
+	statements := []string{
+		"CREATE EXTENSION IF NOT EXISTS pg_trgm",
+		"CREATE INDEX IF NOT EXISTS idx_users_name_trgm ON users USING gin (name gin_trgm_ops)",
+		"CREATE INDEX IF NOT EXISTS idx_users_email_trgm ON users USING gin (email gin_trgm_ops)",
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // GetActiveUsers gets all active users
 func (r *UserRepository) GetActiveUsers(ctx context.Context) ([]*User, error) {
 	return r.FindByCondition(ctx, "is_active = ?", true)
@@ -1,8 +1,13 @@
 package user
 
 import (
+	"strconv"
+	"time"
+
 	"neonexcore/internal/core"
+	"neonexcore/pkg/api"
 	"neonexcore/pkg/auth"
+	"neonexcore/pkg/cache"
 	"neonexcore/pkg/rbac"
 
 	"github.com/gofiber/fiber/v2"
@@ -16,20 +21,38 @@ func (m *UserModule) Routes(app *fiber.App, c *core.Container) {
 	// Resolve middleware dependencies
 	jwtManager := core.Resolve[*auth.JWTManager](c)
 	rbacManager := core.Resolve[*rbac.Manager](c)
+	sharedCache := core.Resolve[cache.Cache](c)
+
+	// Per-IP limits on unauthenticated endpoints that are attractive to abuse;
+	// each auth endpoint has its own budget
+	authLimiter := api.RateLimitMiddleware(api.RateLimitConfig{
+		MaxRequests:    10,
+		WindowDuration: time.Minute,
+		KeyGenerator:   api.RouteIPKey,
+		Store:          sharedCache,
+		Prefix:         "auth",
+	})
+	searchLimiter := api.RateLimitMiddleware(api.RateLimitConfig{
+		MaxRequests:    30,
+		WindowDuration: time.Minute,
+		Store:          sharedCache,
+		Prefix:         "search",
+	})
 
 	// API v1 group
-	api := app.Group("/api/v1")
+	v1 := app.Group("/api/v1")
 
 	// ==================== Authentication Routes (Public) ====================
-	authGroup := api.Group("/auth")
+	authGroup := v1.Group("/auth")
 	{
 		// Public auth endpoints
-		authGroup.Post("/login", authCtrl.Login)
-		authGroup.Post("/register", authCtrl.Register)
-		authGroup.Post("/refresh", authCtrl.RefreshToken)
-		authGroup.Post("/forgot-password", authCtrl.ForgotPassword)
-		authGroup.Post("/reset-password", authCtrl.ResetPassword)
-		authGroup.Get("/verify-email/:token", authCtrl.VerifyEmail)
+		authGroup.Post("/login", authLimiter, authCtrl.Login)
+		authGroup.Post("/register", authLimiter, authCtrl.Register)
+		authGroup.Post("/refresh", authLimiter, authCtrl.RefreshToken)
+		authGroup.Post("/forgot-password", authLimiter, authCtrl.ForgotPassword)
+		authGroup.Post("/reset-password", authLimiter, authCtrl.ResetPassword)
+		authGroup.Get("/verify-email/:token", authLimiter, authCtrl.VerifyEmail)
+		authGroup.Post("/resend-verification", authLimiter, authCtrl.ResendVerification)
 
 		// Protected auth endpoints (require authentication)
 		authProtected := authGroup.Group("", auth.AuthMiddleware(jwtManager))
@@ -41,10 +64,10 @@ func (m *UserModule) Routes(app *fiber.App, c *core.Container) {
 	}
 
 	// ==================== User Management Routes ====================
-	usersGroup := api.Group("/users")
+	usersGroup := v1.Group("/users")
 	{
 		// Public/Optional auth endpoints
-		usersGroup.Get("/search", userCtrl.Search)
+		usersGroup.Get("/search", searchLimiter, userCtrl.Search)
 
 		// Protected endpoints (require authentication)
 		usersProtected := usersGroup.Group("", auth.AuthMiddleware(jwtManager))
@@ -54,6 +77,16 @@ func (m *UserModule) Routes(app *fiber.App, c *core.Container) {
 				rbac.RequirePermission(rbacManager, "users.read"),
 				userCtrl.GetAll,
 			)
+			// Soft-deleted users (require 'users.delete' permission); before /:id so "trash" isn't taken as an ID
+			usersProtected.Get("/trash",
+				rbac.RequirePermission(rbacManager, "users.delete"),
+				userCtrl.Trash,
+			)
+			usersProtected.Post("/:id/restore",
+				rbac.RequirePermission(rbacManager, "users.delete"),
+				userCtrl.Restore,
+			)
+
 			usersProtected.Get("/:id", 
 				rbac.RequirePermission(rbacManager, "users.read"),
 				userCtrl.GetByID,
@@ -65,9 +98,9 @@ func (m *UserModule) Routes(app *fiber.App, c *core.Container) {
 				userCtrl.Create,
 			)
 
-			// Update operations (require 'users.update' permission)
-			usersProtected.Put("/:id", 
-				rbac.RequirePermission(rbacManager, "users.update"),
+			// Update operations (require 'users.update', or 'users.update.own' for your own account)
+			usersProtected.Put("/:id",
+				rbac.RequirePermissionOrOwner(rbacManager, "users.update", userIDParam),
 				userCtrl.Update,
 			)
 
@@ -86,6 +119,10 @@ func (m *UserModule) Routes(app *fiber.App, c *core.Container) {
 				rbac.RequirePermission(rbacManager, "users.manage-roles"),
 				userCtrl.AssignRole,
 			)
+			usersProtected.Put("/:id/roles",
+				rbac.RequirePermission(rbacManager, "users.manage-roles"),
+				userCtrl.SyncRoles,
+			)
 			usersProtected.Delete("/:id/roles/:roleId",
 				rbac.RequirePermission(rbacManager, "users.manage-roles"),
 				userCtrl.RemoveRole,
@@ -96,9 +133,25 @@ func (m *UserModule) Routes(app *fiber.App, c *core.Container) {
 				rbac.RequirePermission(rbacManager, "users.manage-permissions"),
 				userCtrl.GetUserPermissions,
 			)
+			usersProtected.Post("/:id/permissions",
+				rbac.RequirePermission(rbacManager, "users.manage-permissions"),
+				userCtrl.AssignPermissions,
+			)
+			usersProtected.Put("/:id/permissions",
+				rbac.RequirePermission(rbacManager, "users.manage-permissions"),
+				userCtrl.SyncPermissions,
+			)
 		}
 	}
 
+	// ==================== RBAC Catalog Routes ====================
+	// Roles and permissions for role-editing screens (require 'users.manage-roles' permission)
+	rbacCtrl := core.Resolve[*rbac.Controller](c)
+	rbacCtrl.RegisterRoutes(v1.Group("/rbac",
+		auth.AuthMiddleware(jwtManager),
+		rbac.RequirePermission(rbacManager, "users.manage-roles"),
+	))
+
 	// ==================== Legacy Routes (backward compatibility) ====================
 	// Keep old /user routes for backward compatibility
 	legacyGroup := app.Group("/user")
@@ -115,3 +168,9 @@ func (m *UserModule) Routes(app *fiber.App, c *core.Container) {
 		}
 	}
 }
+
+// userIDParam resolves the owner of a /users/:id resource, which is the user itself
+func userIDParam(c *fiber.Ctx) (uint, error) {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	return uint(id), err
+}
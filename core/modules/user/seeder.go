@@ -4,8 +4,6 @@ import (
 	"context"
 	"fmt"
 
-	"neonexcore/pkg/auth"
-
 	"gorm.io/gorm"
 )
 
@@ -35,7 +33,7 @@ func (s *UserSeeder) Run(ctx context.Context) error {
 		return nil
 	}
 
-	hasher := auth.NewPasswordHasher()
+	hasher := newPasswordHasher()
 
 	// Hash passwords
 	adminPass, _ := hasher.Hash("admin123")
@@ -2,25 +2,39 @@ package user
 
 import (
 	"context"
+	stderrors "errors"
 	"fmt"
+	"net/http"
 
+	"neonexcore/internal/config"
 	"neonexcore/pkg/database"
+	"neonexcore/pkg/errors"
+	"neonexcore/pkg/events"
 
 	"gorm.io/gorm"
 )
 
 type UserService struct {
-	repo      *UserRepository
-	txManager *database.TxManager
+	repo          *UserRepository
+	txManager     *database.TxManager
+	deletedPolicy string
 }
 
 func NewUserService(repo *UserRepository, txManager *database.TxManager) *UserService {
 	return &UserService{
-		repo:      repo,
-		txManager: txManager,
+		repo:          repo,
+		txManager:     txManager,
+		deletedPolicy: config.DeletedUserReject,
 	}
 }
 
+// SetDeletedUserPolicy sets what CreateOrRestoreUser does when the email or
+// username belongs to a soft-deleted user: config.DeletedUserReject or
+// config.DeletedUserRestore
+func (s *UserService) SetDeletedUserPolicy(policy string) {
+	s.deletedPolicy = policy
+}
+
 // GetAllUsers retrieves all users
 func (s *UserService) GetAllUsers(ctx context.Context) ([]*User, error) {
 	return s.repo.FindAll(ctx)
@@ -31,19 +45,156 @@ func (s *UserService) GetUser(ctx context.Context, id uint) (*User, error) {
 	return s.repo.FindByID(ctx, id)
 }
 
-// CreateUser creates a new user
+// CreateUser creates a new user. The user.created event is written to the
+// outbox in the same transaction, so it's only published if the user is.
 func (s *UserService) CreateUser(ctx context.Context, user *User) error {
-	return s.repo.Create(ctx, user)
+	return s.txManager.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := s.repo.WithTx(tx).Create(ctx, user); err != nil {
+			return err
+		}
+
+		return events.Enqueue(tx, events.Event{
+			Name: events.EventUserCreated,
+			Data: events.UserCreatedEvent{
+				UserID: user.ID,
+				Email:  user.Email,
+			},
+		})
+	})
+}
+
+// CreateOrRestoreUser creates a user like CreateUser, applying the deleted
+// user policy when its email or username belongs to a soft-deleted user. It
+// reports whether the deleted user was restored in place of a new one;
+// user.restored is published instead of user.created then.
+//
+// onRestore, when not nil, runs in the restoring transaction, with a context
+// carrying it (see database.ContextWithTx), so resetting the restored user's
+// access commits or rolls back with the restore.
+func (s *UserService) CreateOrRestoreUser(ctx context.Context, user *User, onRestore func(ctx context.Context) error) (bool, error) {
+	restoreID, err := deletedUserToRestore(ctx, s.repo, s.deletedPolicy, user)
+	if err != nil {
+		return false, err
+	}
+	if restoreID == 0 {
+		return false, s.CreateUser(ctx, user)
+	}
+
+	err = s.txManager.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := s.repo.WithTx(tx).RestoreAs(ctx, restoreID, user); err != nil {
+			return err
+		}
+
+		err := events.Enqueue(tx, events.Event{
+			Name: events.EventUserRestored,
+			Data: events.UserRestoredEvent{
+				UserID: user.ID,
+				Email:  user.Email,
+			},
+		})
+		if err != nil || onRestore == nil {
+			return err
+		}
+		return onRestore(database.ContextWithTx(ctx, tx))
+	})
+	return err == nil, err
 }
 
-// UpdateUser updates a user
+// UpdateUser updates a user and publishes user.updated through the outbox.
+// The stored version is incremented whatever user.Version is; use
+// UpdateUserIfVersion to detect concurrent changes.
 func (s *UserService) UpdateUser(ctx context.Context, user *User) error {
-	return s.repo.Update(ctx, user)
+	return s.txManager.WithTransaction(ctx, func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+
+		current, err := repo.FindByID(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		if current == nil {
+			return gorm.ErrRecordNotFound
+		}
+		updated, err := repo.UpdateIfVersion(ctx, user, current.Version)
+		if err != nil {
+			return err
+		}
+		if !updated {
+			return fmt.Errorf("user %d was modified concurrently", user.ID)
+		}
+
+		return enqueueUserUpdated(tx, user)
+	})
 }
 
-// DeleteUser deletes a user
+// UpdateUserIfVersion updates a user only if its stored version still
+// matches, and reports whether it did. user.updated is published through the
+// outbox when it does.
+func (s *UserService) UpdateUserIfVersion(ctx context.Context, user *User, version uint) (bool, error) {
+	updated := false
+	err := s.txManager.WithTransaction(ctx, func(tx *gorm.DB) error {
+		ok, err := s.repo.WithTx(tx).UpdateIfVersion(ctx, user, version)
+		if err != nil || !ok {
+			return err
+		}
+
+		if err := enqueueUserUpdated(tx, user); err != nil {
+			return err
+		}
+		updated = true
+		return nil
+	})
+	if err != nil {
+		user.Version = version
+		return false, err
+	}
+	return updated, nil
+}
+
+// DeleteUser soft deletes a user and publishes user.deleted through the outbox
 func (s *UserService) DeleteUser(ctx context.Context, id uint) error {
-	return s.repo.Delete(ctx, id)
+	return s.txManager.WithTransaction(ctx, func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+
+		user, err := repo.FindByID(ctx, id)
+		if err != nil || user == nil {
+			return err
+		}
+		if err := repo.Delete(ctx, id); err != nil {
+			return err
+		}
+
+		return events.Enqueue(tx, events.Event{
+			Name: events.EventUserDeleted,
+			Data: events.UserDeletedEvent{
+				UserID: user.ID,
+				Email:  user.Email,
+			},
+		})
+	})
+}
+
+// RestoreUser clears a user's soft delete and publishes user.restored
+// through the outbox
+func (s *UserService) RestoreUser(ctx context.Context, user *User) error {
+	err := s.txManager.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := s.repo.WithTx(tx).Restore(ctx, user.ID); err != nil {
+			return err
+		}
+
+		return events.Enqueue(tx, events.Event{
+			Name: events.EventUserRestored,
+			Data: events.UserRestoredEvent{
+				UserID: user.ID,
+				Email:  user.Email,
+			},
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	user.DeletedAt = gorm.DeletedAt{}
+	return nil
 }
 
 // GetUserByEmail retrieves a user by email
@@ -70,3 +221,47 @@ func (s *UserService) CreateUserWithTransaction(ctx context.Context, users []*Us
 		return nil
 	})
 }
+
+func enqueueUserUpdated(tx *gorm.DB, user *User) error {
+	return events.Enqueue(tx, events.Event{
+		Name: events.EventUserUpdated,
+		Data: events.UserUpdatedEvent{
+			UserID: user.ID,
+			Email:  user.Email,
+		},
+	})
+}
+
+// deletedUserToRestore applies a deleted user policy to a new user whose
+// email or username may belong to soft-deleted users. It returns the ID of
+// the deleted user to restore in its place, 0 if there is none, or an
+// ACCOUNT_DELETED error. Only a deleted user with the same email is restored.
+func deletedUserToRestore(ctx context.Context, repo *UserRepository, policy string, user *User) (uint, error) {
+	trashed, err := repo.FindTrashedConflicts(ctx, user.Email, user.Username)
+	if err != nil {
+		return 0, err
+	}
+	if len(trashed) == 0 {
+		return 0, nil
+	}
+
+	if policy == config.DeletedUserRestore && len(trashed) == 1 && trashed[0].Email == user.Email {
+		return trashed[0].ID, nil
+	}
+	return 0, errors.New(errors.ErrCodeAccountDeleted,
+		"This email or username belongs to a deleted account; ask an administrator to restore it",
+		http.StatusConflict)
+}
+
+// createUserError maps an error from creating or restoring a user to an
+// AppError. Unique violations that slipped past the existence checks, e.g.
+// from concurrent requests, become a conflict.
+func createUserError(err error) error {
+	if appErr, ok := errors.GetAppError(err); ok {
+		return appErr
+	}
+	if stderrors.Is(err, gorm.ErrDuplicatedKey) {
+		return errors.NewConflict("Email or username already exists")
+	}
+	return errors.NewInternal("Failed to create user").WithError(err)
+}
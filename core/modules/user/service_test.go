@@ -0,0 +1,145 @@
+package user
+
+import (
+	"context"
+	stderrors "errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"neonexcore/internal/config"
+	"neonexcore/pkg/database"
+	"neonexcore/pkg/errors"
+	"neonexcore/pkg/events"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTestUserService(t *testing.T) (*UserService, *gorm.DB) {
+	t.Helper()
+
+	db := newTestDB(t)
+	return NewUserService(NewUserRepository(db), database.NewTxManager(db)), db
+}
+
+// outboxEvents returns the names of the events in the outbox
+func outboxEvents(t *testing.T, db *gorm.DB) []string {
+	t.Helper()
+
+	var names []string
+	if err := db.Model(&events.OutboxMessage{}).Order("id").Pluck("event_name", &names).Error; err != nil {
+		t.Fatalf("load outbox: %v", err)
+	}
+	return names
+}
+
+func TestUserEventsFollowTheTransaction(t *testing.T) {
+	service, db := newTestUserService(t)
+	ctx := context.Background()
+
+	jane := &User{Name: "Jane", Email: "jane@example.com", Username: "jane", Password: "x"}
+	if err := service.CreateUser(ctx, jane); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	// A failed insert rolls its event back with it
+	duplicate := &User{Name: "Jane", Email: "jane@example.com", Username: "jane2", Password: "x"}
+	if err := service.CreateUser(ctx, duplicate); err == nil {
+		t.Fatal("created a user with a taken email")
+	}
+	if got := outboxEvents(t, db); len(got) != 1 || got[0] != events.EventUserCreated {
+		t.Fatalf("outbox = %v, want one user.created", got)
+	}
+
+	jane.Name = "Jane Doe"
+	if err := service.UpdateUser(ctx, jane); err != nil {
+		t.Fatalf("UpdateUser: %v", err)
+	}
+	if updated, err := service.UpdateUserIfVersion(ctx, jane, 1); err != nil || updated {
+		t.Fatalf("stale UpdateUserIfVersion = %v, %v", updated, err)
+	}
+	if err := service.DeleteUser(ctx, jane.ID); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+
+	want := strings.Join([]string{events.EventUserCreated, events.EventUserUpdated, events.EventUserDeleted}, ",")
+	if got := strings.Join(outboxEvents(t, db), ","); got != want {
+		t.Fatalf("outbox = %s, want %s", got, want)
+	}
+}
+
+func TestRestoreRollsBackWithItsEvent(t *testing.T) {
+	service, db := newTestUserService(t)
+	service.SetDeletedUserPolicy(config.DeletedUserRestore)
+	ctx := context.Background()
+
+	jane := &User{Name: "Jane", Email: "jane@example.com", Username: "jane", Password: "x"}
+	if err := service.CreateUser(ctx, jane); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if err := service.DeleteUser(ctx, jane.ID); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+
+	// Resetting the restored user's access fails, undoing the restore
+	again := &User{Name: "Jane", Email: "jane@example.com", Username: "jane", Password: "y"}
+	restored, err := service.CreateOrRestoreUser(ctx, again, func(context.Context) error {
+		return stderrors.New("revoke failed")
+	})
+	if err == nil || restored {
+		t.Fatalf("CreateOrRestoreUser = %v, %v; want the hook's error", restored, err)
+	}
+	if stored, _ := service.repo.FindByEmail(ctx, "jane@example.com"); stored != nil {
+		t.Fatal("the failed restore was kept")
+	}
+	for _, name := range outboxEvents(t, db) {
+		if name == events.EventUserRestored {
+			t.Fatal("the failed restore published user.restored")
+		}
+	}
+
+	restored, err = service.CreateOrRestoreUser(ctx, again, nil)
+	if err != nil || !restored {
+		t.Fatalf("CreateOrRestoreUser = %v, %v", restored, err)
+	}
+	if got := outboxEvents(t, db); got[len(got)-1] != events.EventUserRestored {
+		t.Fatalf("outbox = %v, want user.restored last", got)
+	}
+}
+
+func TestCreateUserErrorTranslatesUniqueViolations(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "user.db")), &gorm.Config{TranslateError: true})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(&User{}, &events.OutboxMessage{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	service := NewUserService(NewUserRepository(db), database.NewTxManager(db))
+	ctx := context.Background()
+
+	if err := service.CreateUser(ctx, &User{Name: "Jane", Email: "jane@example.com", Username: "jane", Password: "x"}); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	// As if a concurrent request won the race past the existence checks
+	err = service.CreateUser(ctx, &User{Name: "Janet", Email: "jane@example.com", Username: "janet", Password: "x"})
+	if err == nil {
+		t.Fatal("created a user with a taken email")
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		code errors.ErrorCode
+	}{
+		{"unique violation", err, errors.ErrCodeConflict},
+		{"app error", errors.NewNotFound("User not found"), errors.ErrCodeNotFound},
+		{"other", stderrors.New("disk full"), errors.ErrCodeInternal},
+	}
+	for _, tt := range tests {
+		appErr, ok := errors.GetAppError(createUserError(tt.err))
+		if !ok || appErr.Code != tt.code {
+			t.Errorf("%s: createUserError = %v, want %s", tt.name, appErr, tt.code)
+		}
+	}
+}
@@ -2,45 +2,104 @@ package user
 
 import (
 	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
 	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 
+	"neonexcore/internal/config"
+	"neonexcore/pkg/api"
 	"neonexcore/pkg/auth"
+	"neonexcore/pkg/cache"
+	"neonexcore/pkg/database"
 	"neonexcore/pkg/errors"
-	"neonexcore/pkg/events"
 	"neonexcore/pkg/rbac"
+	"neonexcore/pkg/validation"
 
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
 )
 
 // UserController handles user CRUD operations
 type UserController struct {
 	service     *UserService
 	rbacManager *rbac.Manager
+	hasher      *auth.PasswordHasher
+	jwtManager  *auth.JWTManager
+
+	// searchCache holds recent search results; nil disables caching
+	searchCache  cache.Cache
+	searchConfig *config.UserSearchConfig
 }
 
 // NewUserController creates a new user controller
-func NewUserController(service *UserService, rbacManager *rbac.Manager) *UserController {
+func NewUserController(service *UserService, rbacManager *rbac.Manager, hasher *auth.PasswordHasher, jwtManager *auth.JWTManager) *UserController {
 	return &UserController{
 		service:     service,
 		rbacManager: rbacManager,
+		hasher:      hasher,
+		jwtManager:  jwtManager,
+		searchConfig: &config.UserSearchConfig{
+			MinLength:  2,
+			MaxResults: 50,
+		},
 	}
 }
 
-// GetAll returns all users with pagination
-// GET /api/v1/users?page=1&limit=10
-func (ctrl *UserController) GetAll(c *fiber.Ctx) error {
-	page, _ := strconv.Atoi(c.Query("page", "1"))
-	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+// SetSearch configures search limits and the cache used to serve repeated
+// identical queries
+func (ctrl *UserController) SetSearch(searchCache cache.Cache, searchConfig *config.UserSearchConfig) {
+	ctrl.searchCache = searchCache
+	ctrl.searchConfig = searchConfig
+}
 
-	if page < 1 {
-		page = 1
+// sortableColumns whitelists the columns GetAll may sort by
+var sortableColumns = map[string]bool{
+	"id":            true,
+	"name":          true,
+	"email":         true,
+	"username":      true,
+	"created_at":    true,
+	"updated_at":    true,
+	"last_login_at": true,
+}
+
+// filterFields declares the fields GetAll may filter on with filter[field][op]=value
+var filterFields = api.FilterFields{
+	"name":              {Operators: []api.FilterOperator{api.FilterEq, api.FilterLike}},
+	"email":             {Operators: []api.FilterOperator{api.FilterEq, api.FilterLike, api.FilterIn}},
+	"username":          {Operators: []api.FilterOperator{api.FilterEq, api.FilterLike, api.FilterIn}},
+	"is_active":         {Operators: []api.FilterOperator{api.FilterEq}, Parse: parseBoolFilter},
+	"is_email_verified": {Operators: []api.FilterOperator{api.FilterEq}, Parse: parseBoolFilter},
+	"created_at":        {Operators: []api.FilterOperator{api.FilterGte, api.FilterLte}, Parse: parseTimeFilter},
+	"last_login_at":     {Operators: []api.FilterOperator{api.FilterGte, api.FilterLte}, Parse: parseTimeFilter},
+}
+
+// GetAll returns all users with pagination, sorting and filtering
+// GET /api/v1/users?page=1&limit=10&sort=created_at:desc&is_active=true&role=admin&filter[email][like]=example.com
+func (ctrl *UserController) GetAll(c *fiber.Ctx) error {
+	pagination, err := api.ParsePagination(c)
+	if err != nil {
+		return err
 	}
-	if limit < 1 || limit > 100 {
-		limit = 10
+	page, limit := pagination.Page, pagination.Limit
+
+	opts, err := ctrl.parseListOptions(c)
+	if err != nil {
+		return err
 	}
 
 	ctx := context.Background()
-	users, total, err := ctrl.service.repo.Paginate(ctx, page, limit)
+
+	// cursor= (empty for the first page) switches to keyset pagination
+	if api.HasCursor(c) {
+		return ctrl.getAllByCursor(ctx, c, limit, opts)
+	}
+
+	users, total, err := ctrl.service.repo.Paginate(ctx, page, limit, opts)
 	if err != nil {
 		return errors.NewInternal("Failed to fetch users")
 	}
@@ -57,6 +116,237 @@ func (ctrl *UserController) GetAll(c *fiber.Ctx) error {
 	})
 }
 
+// cursorColumns whitelists the indexed columns GetAll may page by in cursor mode
+var cursorColumns = map[string]bool{
+	"id":         true,
+	"created_at": true,
+}
+
+// getAllByCursor returns a page of users using keyset pagination
+// GET /api/v1/users?cursor=&limit=10&sort=created_at:desc
+func (ctrl *UserController) getAllByCursor(ctx context.Context, c *fiber.Ctx, limit int, opts database.QueryOptions) error {
+	keyset := api.KeysetOptions{
+		Column: "id",
+		Limit:  limit,
+		Cursor: c.Query("cursor"),
+	}
+
+	if len(opts.Sort) > 1 {
+		return errors.NewBadRequest("Cursor pagination supports a single sort column")
+	}
+	if len(opts.Sort) == 1 {
+		if !cursorColumns[opts.Sort[0].Column] {
+			return errors.NewBadRequest("Cursor pagination only supports sorting by id or created_at")
+		}
+		keyset.Column = opts.Sort[0].Column
+		keyset.Desc = opts.Sort[0].Desc
+	}
+
+	// Ordering is owned by the keyset query
+	opts.Sort = nil
+
+	users, page, err := api.KeysetPaginate(opts.Apply(ctrl.service.repo.Query(ctx)), keyset, func(u *User) (interface{}, uint) {
+		if keyset.Column == "created_at" {
+			return u.CreatedAt, u.ID
+		}
+		return u.ID, u.ID
+	})
+	if err != nil {
+		if _, ok := err.(*errors.AppError); ok {
+			return err
+		}
+		return errors.NewInternal("Failed to fetch users")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    users,
+		"meta": fiber.Map{
+			"limit":       limit,
+			"next_cursor": page.NextCursor,
+			"has_more":    page.HasMore,
+		},
+	})
+}
+
+// parseListOptions converts sort and filter query params into structured query options
+func (ctrl *UserController) parseListOptions(c *fiber.Ctx) (database.QueryOptions, error) {
+	opts := database.QueryOptions{
+		Filters: make(map[string]interface{}),
+	}
+
+	// sort=created_at:desc,name:asc
+	if sort := c.Query("sort"); sort != "" {
+		for _, part := range strings.Split(sort, ",") {
+			column, direction, _ := strings.Cut(strings.TrimSpace(part), ":")
+			if !sortableColumns[column] {
+				return opts, errors.NewBadRequest("Unknown sort column: " + column)
+			}
+
+			switch strings.ToLower(direction) {
+			case "", "asc":
+				opts.Sort = append(opts.Sort, database.SortOption{Column: column})
+			case "desc":
+				opts.Sort = append(opts.Sort, database.SortOption{Column: column, Desc: true})
+			default:
+				return opts, errors.NewBadRequest("Invalid sort direction: " + direction)
+			}
+		}
+	}
+
+	for _, column := range []string{"is_active", "is_email_verified"} {
+		value := c.Query(column)
+		if value == "" {
+			continue
+		}
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return opts, errors.NewBadRequest("Invalid boolean for " + column)
+		}
+		opts.Filters[column] = parsed
+	}
+
+	if role := c.Query("role"); role != "" {
+		opts.Scopes = append(opts.Scopes, ctrl.service.repo.WithRole(role))
+	}
+
+	filters, err := api.ParseFilters(c, filterFields)
+	if err != nil {
+		return opts, err
+	}
+	if len(filters) > 0 {
+		opts.Scopes = append(opts.Scopes, filters.Scope())
+	}
+
+	return opts, nil
+}
+
+// parseBoolFilter converts a filter value to a bool
+func parseBoolFilter(value string) (interface{}, error) {
+	return strconv.ParseBool(value)
+}
+
+// parseTimeFilter converts a YYYY-MM-DD or RFC3339 filter value to a time
+func parseTimeFilter(value string) (interface{}, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+// guardLastSuperAdmin runs change in a transaction, refusing it if userID is
+// the last active super-admin: deleting, deactivating or demoting them would
+// lock everyone out of admin functions. The super-admin role stays locked
+// until the transaction ends, so concurrent guarded changes run one after
+// another and each checks the outcome of the others.
+func (ctrl *UserController) guardLastSuperAdmin(ctx context.Context, userID uint, change func(ctx context.Context) error) error {
+	return ctrl.service.txManager.WithTransaction(ctx, func(tx *gorm.DB) error {
+		txCtx := database.ContextWithTx(ctx, tx)
+
+		if err := ctrl.rbacManager.LockRole(txCtx, rbac.SuperAdminRole); err != nil {
+			return errors.NewInternal("Failed to check super-admin role")
+		}
+		if err := ctrl.ensureNotLastSuperAdmin(txCtx, tx, userID); err != nil {
+			return err
+		}
+
+		return change(txCtx)
+	})
+}
+
+// ensureNotLastSuperAdmin refuses to delete, deactivate or demote the last
+// active super-admin. Call it through guardLastSuperAdmin.
+func (ctrl *UserController) ensureNotLastSuperAdmin(ctx context.Context, tx *gorm.DB, userID uint) error {
+	holders, err := ctrl.rbacManager.RoleHolderIDs(ctx, rbac.SuperAdminRole)
+	if err != nil {
+		return errors.NewInternal("Failed to check super-admin role")
+	}
+
+	others := make([]uint, 0, len(holders))
+	isHolder := false
+	for _, id := range holders {
+		if id == userID {
+			isHolder = true
+		} else {
+			others = append(others, id)
+		}
+	}
+	if !isHolder {
+		return nil
+	}
+
+	remaining, err := ctrl.service.repo.WithTx(tx).CountActiveByIDs(ctx, others)
+	if err != nil {
+		return errors.NewInternal("Failed to check super-admin role")
+	}
+	if remaining == 0 {
+		return errors.NewConflict("Cannot remove the last super-admin; assign the role to another active user first")
+	}
+	return nil
+}
+
+// Trash returns soft-deleted users with pagination
+// GET /api/v1/users/trash?page=1&limit=10
+func (ctrl *UserController) Trash(c *fiber.Ctx) error {
+	pagination, err := api.ParsePagination(c)
+	if err != nil {
+		return err
+	}
+	page, limit := pagination.Page, pagination.Limit
+
+	ctx := context.Background()
+	users, total, err := ctrl.service.repo.PaginateTrashed(ctx, page, limit)
+	if err != nil {
+		return errors.NewInternal("Failed to fetch deleted users")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    users,
+		"meta": fiber.Map{
+			"page":        page,
+			"limit":       limit,
+			"total":       total,
+			"total_pages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
+
+// Restore restores a soft-deleted user
+// POST /api/v1/users/:id/restore
+func (ctrl *UserController) Restore(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return errors.NewBadRequest("Invalid user ID")
+	}
+
+	// Read what's about to be changed from the primary, not a lagging replica
+	ctx := database.UsePrimary(context.Background())
+	user, err := ctrl.service.repo.FindTrashedByID(ctx, uint(id))
+	if err != nil || user == nil {
+		return errors.NewNotFound("Deleted user not found")
+	}
+
+	// The email or username may have been reused since the deletion
+	conflict, err := ctrl.service.repo.FindActiveConflict(ctx, user.Email, user.Username, user.ID)
+	if err != nil {
+		return errors.NewInternal("Failed to check for conflicting users")
+	}
+	if conflict != nil {
+		return errors.NewConflict("Email or username is already in use by another user")
+	}
+
+	if err := ctrl.service.RestoreUser(ctx, user); err != nil {
+		return errors.NewInternal("Failed to restore user")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "User restored successfully",
+		"data":    user,
+	})
+}
+
 // GetByID returns a user by ID
 // GET /api/v1/users/:id
 func (ctrl *UserController) GetByID(c *fiber.Ctx) error {
@@ -74,6 +364,7 @@ func (ctrl *UserController) GetByID(c *fiber.Ctx) error {
 	// Get user roles
 	roles, _ := ctrl.rbacManager.GetUserRoles(ctx, user.ID)
 	
+	api.SetVersionETag(c, user.Version)
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"success": true,
 		"data": fiber.Map{
@@ -87,6 +378,7 @@ func (ctrl *UserController) GetByID(c *fiber.Ctx) error {
 			"last_login_at":     user.LastLoginAt,
 			"created_at":        user.CreatedAt,
 			"updated_at":        user.UpdatedAt,
+			"version":           user.Version,
 			"roles":             roles,
 		},
 	})
@@ -110,7 +402,7 @@ func (ctrl *UserController) Create(c *fiber.Ctx) error {
 	}
 
 	// Validate
-	validator := &validation.Validator{}
+	validator := validation.NewValidator()
 	if errs := validator.Validate(&req); errs != nil {
 		details := make(map[string]interface{})
 		for field, msg := range errs {
@@ -119,7 +411,8 @@ func (ctrl *UserController) Create(c *fiber.Ctx) error {
 		return errors.NewValidationError("Validation failed", details)
 	}
 
-	ctx := context.Background()
+	// Check for existing users on the primary, not a lagging replica
+	ctx := withActor(database.UsePrimary(context.Background()), c)
 
 	// Check if email exists
 	existing, _ := ctrl.service.repo.FindByEmail(ctx, req.Email)
@@ -134,8 +427,7 @@ func (ctrl *UserController) Create(c *fiber.Ctx) error {
 	}
 
 	// Hash password
-	hasher := auth.NewPasswordHasher(12)
-	hashedPassword, err := hasher.Hash(req.Password)
+	hashedPassword, err := ctrl.hasher.Hash(req.Password)
 	if err != nil {
 		return errors.NewInternal("Failed to hash password")
 	}
@@ -151,22 +443,25 @@ func (ctrl *UserController) Create(c *fiber.Ctx) error {
 		Active:   req.IsActive,
 	}
 
-	if err := ctrl.service.repo.Create(ctx, user); err != nil {
-		return errors.NewInternal("Failed to create user")
+	// The restored user starts without its former access or sessions
+	restored, err := ctrl.service.CreateOrRestoreUser(ctx, user, func(txCtx context.Context) error {
+		if err := ctrl.rbacManager.RevokeAll(txCtx, user.ID); err != nil {
+			return err
+		}
+		return ctrl.jwtManager.RevokeUserTokens(ctx, user.ID)
+	})
+	if err != nil {
+		return createUserError(err)
 	}
 
-	// Dispatch event
-	events.DispatchAsync(ctx, events.Event{
-		Name: events.EventUserCreated,
-		Data: map[string]interface{}{
-			"user_id": user.ID,
-			"email":   user.Email,
-		},
-	})
+	message := "User created successfully"
+	if restored {
+		message = "Deleted user restored with the new details"
+	}
 
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 		"success": true,
-		"message": "User created successfully",
+		"message": message,
 		"data": fiber.Map{
 			"id":       user.ID,
 			"name":     user.Name,
@@ -176,7 +471,9 @@ func (ctrl *UserController) Create(c *fiber.Ctx) error {
 	})
 }
 
-// Update updates a user
+// Update updates a user. Sending the version from GET as If-Match (or as
+// "version" in the body) makes the update fail with 409 if the user changed
+// meanwhile.
 // PUT /api/v1/users/:id
 func (ctrl *UserController) Update(c *fiber.Ctx) error {
 	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
@@ -189,6 +486,7 @@ func (ctrl *UserController) Update(c *fiber.Ctx) error {
 		Email    string `json:"email" validate:"omitempty,email"`
 		Age      int    `json:"age" validate:"omitempty,gte=0,lte=150"`
 		IsActive *bool  `json:"is_active"`
+		Version  *uint  `json:"version"`
 	}
 
 	var req UpdateUserRequest
@@ -196,11 +494,41 @@ func (ctrl *UserController) Update(c *fiber.Ctx) error {
 		return errors.NewBadRequest("Invalid request body")
 	}
 
-	ctx := context.Background()
+	// Updates are conditional when the client sends the version it read
+	version, conditional, err := api.ExpectedVersion(c, req.Version)
+	if err != nil {
+		return err
+	}
+
+	// Read what's about to be changed from the primary, not a lagging replica
+	ctx := database.UsePrimary(context.Background())
 	user, err := ctrl.service.repo.FindByID(ctx, uint(id))
 	if err != nil || user == nil {
 		return errors.NewNotFound("User not found")
 	}
+	if !conditional {
+		version = user.Version
+	}
+	if user.Version != version {
+		return errors.NewConflict("User was modified by someone else; reload it and try again")
+	}
+
+	// Holders of only users.update.own edit their own name and age; changing
+	// the email (which must be verified again, see PUT /auth/profile) or the
+	// account status needs users.update
+	if currentUserID, ok := auth.GetUserID(c); ok {
+		emailChange := req.Email != "" && req.Email != user.Email
+		statusChange := req.IsActive != nil && *req.IsActive != user.IsActive
+		if emailChange || statusChange {
+			global, err := ctrl.rbacManager.HasPermission(ctx, currentUserID, "users.update")
+			if err != nil {
+				return errors.NewInternal("Failed to check permissions")
+			}
+			if !global {
+				return errors.NewForbidden("Changing the email or account status requires the users.update permission")
+			}
+		}
+	}
 
 	// Update fields if provided
 	if req.Name != "" {
@@ -217,24 +545,33 @@ func (ctrl *UserController) Update(c *fiber.Ctx) error {
 	if req.Age > 0 {
 		user.Age = req.Age
 	}
+	deactivating := false
 	if req.IsActive != nil {
+		deactivating = !*req.IsActive && user.IsActive
 		user.IsActive = *req.IsActive
 		user.Active = *req.IsActive
 	}
 
-	if err := ctrl.service.repo.Update(ctx, user); err != nil {
-		return errors.NewInternal("Failed to update user")
+	save := func(ctx context.Context) error {
+		updated, err := ctrl.service.UpdateUserIfVersion(ctx, user, version)
+		if err != nil {
+			return errors.NewInternal("Failed to update user")
+		}
+		if !updated {
+			return errors.NewConflict("User was modified by someone else; reload it and try again")
+		}
+		return nil
+	}
+	if deactivating {
+		err = ctrl.guardLastSuperAdmin(ctx, user.ID, save)
+	} else {
+		err = save(ctx)
+	}
+	if err != nil {
+		return err
 	}
 
-	// Dispatch event
-	events.DispatchAsync(ctx, events.Event{
-		Name: events.EventUserUpdated,
-		Data: map[string]interface{}{
-			"user_id": user.ID,
-			"email":   user.Email,
-		},
-	})
-
+	api.SetVersionETag(c, user.Version)
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"success": true,
 		"message": "User updated successfully",
@@ -244,6 +581,7 @@ func (ctrl *UserController) Update(c *fiber.Ctx) error {
 			"email":     user.Email,
 			"username":  user.Username,
 			"is_active": user.IsActive,
+			"version":   user.Version,
 		},
 	})
 }
@@ -262,24 +600,22 @@ func (ctrl *UserController) Delete(c *fiber.Ctx) error {
 		return errors.NewBadRequest("Cannot delete your own account")
 	}
 
-	ctx := context.Background()
+	// Read what's about to be changed from the primary, not a lagging replica
+	ctx := database.UsePrimary(context.Background())
 	user, err := ctrl.service.repo.FindByID(ctx, uint(id))
 	if err != nil || user == nil {
 		return errors.NewNotFound("User not found")
 	}
 
-	if err := ctrl.service.repo.Delete(ctx, uint(id)); err != nil {
-		return errors.NewInternal("Failed to delete user")
-	}
-
-	// Dispatch event
-	events.DispatchAsync(ctx, events.Event{
-		Name: events.EventUserDeleted,
-		Data: map[string]interface{}{
-			"user_id": user.ID,
-			"email":   user.Email,
-		},
+	err = ctrl.guardLastSuperAdmin(ctx, user.ID, func(ctx context.Context) error {
+		if err := ctrl.service.DeleteUser(ctx, user.ID); err != nil {
+			return errors.NewInternal("Failed to delete user")
+		}
+		return nil
 	})
+	if err != nil {
+		return err
+	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"success": true,
@@ -290,28 +626,92 @@ func (ctrl *UserController) Delete(c *fiber.Ctx) error {
 // Search searches users by name or email
 // GET /api/v1/users/search?q=john
 func (ctrl *UserController) Search(c *fiber.Ctx) error {
-	query := c.Query("q")
+	query := strings.TrimSpace(c.Query("q"))
 	if query == "" {
 		return errors.NewBadRequest("Search query is required")
 	}
 
-	ctx := context.Background()
-	users, err := ctrl.service.repo.Search(ctx, query)
+	searchConfig := ctrl.searchConfig
+	if utf8.RuneCountInString(query) < searchConfig.MinLength {
+		return errors.NewBadRequest(fmt.Sprintf("Search query must be at least %d characters", searchConfig.MinLength))
+	}
+
+	pagination, err := api.ParsePagination(c, api.PaginationConfig{
+		DefaultLimit: searchConfig.MaxResults,
+		MaxLimit:     searchConfig.MaxResults,
+	})
 	if err != nil {
-		return errors.NewInternal("Failed to search users")
+		return err
+	}
+	limit := pagination.Limit
+
+	ctx := context.Background()
+	cacheKey := fmt.Sprintf("users:search:%d:%s", limit, strings.ToLower(query))
+
+	users, cached := ctrl.cachedSearch(ctx, cacheKey)
+	if !cached {
+		users, err = ctrl.service.repo.SearchLimited(ctx, query, limit)
+		if err != nil {
+			return errors.NewInternal("Failed to search users")
+		}
+		ctrl.cacheSearch(ctx, cacheKey, users)
 	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"success": true,
 		"data":    users,
 		"meta": fiber.Map{
-			"query": query,
-			"count": len(users),
+			"query":  query,
+			"count":  len(users),
+			"limit":  limit,
+			"cached": cached,
 		},
 	})
 }
 
-// AssignRole assigns a role to a user
+// cachedSearch returns cached search results, if any
+func (ctrl *UserController) cachedSearch(ctx context.Context, key string) ([]*User, bool) {
+	if ctrl.searchCache == nil || ctrl.searchConfig.CacheTTL <= 0 {
+		return nil, false
+	}
+
+	value, err := ctrl.searchCache.Get(ctx, key)
+	if err != nil {
+		return nil, false
+	}
+
+	var raw []byte
+	switch v := value.(type) {
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return nil, false
+	}
+
+	var users []*User
+	if err := json.Unmarshal(raw, &users); err != nil {
+		return nil, false
+	}
+	return users, true
+}
+
+// cacheSearch stores search results as JSON so any cache backend can hold them
+func (ctrl *UserController) cacheSearch(ctx context.Context, key string, users []*User) {
+	if ctrl.searchCache == nil || ctrl.searchConfig.CacheTTL <= 0 {
+		return
+	}
+
+	encoded, err := json.Marshal(users)
+	if err != nil {
+		return
+	}
+	ctrl.searchCache.Set(ctx, key, string(encoded), ctrl.searchConfig.CacheTTL)
+}
+
+// AssignRole assigns one role ("role_id") or several ("role_ids") to a user.
+// Roles the user already holds are left as they are.
 // POST /api/v1/users/:id/roles
 func (ctrl *UserController) AssignRole(c *fiber.Ctx) error {
 	userID, err := strconv.ParseUint(c.Params("id"), 10, 32)
@@ -320,15 +720,16 @@ func (ctrl *UserController) AssignRole(c *fiber.Ctx) error {
 	}
 
 	type AssignRoleRequest struct {
-		RoleID uint `json:"role_id" validate:"required"`
+		RoleID  uint   `json:"role_id"`
+		RoleIDs []uint `json:"role_ids" validate:"max=100,dive,required"`
 	}
 
 	var req AssignRoleRequest
-	if err := c.BodyParser(&req); err != nil {
-		return errors.NewBadRequest("Invalid request body")
+	if err := validation.ValidateBody(c, &req); err != nil {
+		return err
 	}
 
-	ctx := context.Background()
+	ctx := withActor(context.Background(), c)
 	
 	// Check if user exists
 	user, err := ctrl.service.repo.FindByID(ctx, uint(userID))
@@ -336,9 +737,18 @@ func (ctrl *UserController) AssignRole(c *fiber.Ctx) error {
 		return errors.NewNotFound("User not found")
 	}
 
-	// Assign role
-	if err := ctrl.rbacManager.AssignRole(ctx, uint(userID), req.RoleID); err != nil {
-		return errors.NewInternal("Failed to assign role")
+	if req.RoleID == 0 && len(req.RoleIDs) == 0 {
+		return errors.NewBadRequest("role_id or role_ids is required")
+	}
+
+	roleIDs := req.RoleIDs
+	if req.RoleID != 0 {
+		roleIDs = append(roleIDs, req.RoleID)
+	}
+
+	// Assign roles
+	if err := ctrl.rbacManager.AssignRoles(ctx, uint(userID), roleIDs); err != nil {
+		return assignmentError(err, "Failed to assign role")
 	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
@@ -347,6 +757,54 @@ func (ctrl *UserController) AssignRole(c *fiber.Ctx) error {
 	})
 }
 
+// SyncRoles replaces a user's roles with the given set
+// PUT /api/v1/users/:id/roles
+func (ctrl *UserController) SyncRoles(c *fiber.Ctx) error {
+	userID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return errors.NewBadRequest("Invalid user ID")
+	}
+
+	type SyncRolesRequest struct {
+		RoleIDs []uint `json:"role_ids" validate:"max=100,dive,required"`
+	}
+
+	var req SyncRolesRequest
+	if err := validation.ValidateBody(c, &req); err != nil {
+		return err
+	}
+
+	ctx := withActor(context.Background(), c)
+
+	user, err := ctrl.service.repo.FindByID(ctx, uint(userID))
+	if err != nil || user == nil {
+		return errors.NewNotFound("User not found")
+	}
+
+	sync := func(ctx context.Context) error {
+		if err := ctrl.rbacManager.SyncUserRoles(ctx, uint(userID), req.RoleIDs); err != nil {
+			return assignmentError(err, "Failed to sync roles")
+		}
+		return nil
+	}
+
+	// Dropping super-admin is subject to the same rule as removing it
+	superAdmin, err := ctrl.rbacManager.GetRoleBySlug(ctx, rbac.SuperAdminRole)
+	if err == nil && !containsID(req.RoleIDs, superAdmin.ID) {
+		err = ctrl.guardLastSuperAdmin(ctx, uint(userID), sync)
+	} else {
+		err = sync(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Roles synced successfully",
+	})
+}
+
 // RemoveRole removes a role from a user
 // DELETE /api/v1/users/:id/roles/:roleId
 func (ctrl *UserController) RemoveRole(c *fiber.Ctx) error {
@@ -360,9 +818,22 @@ func (ctrl *UserController) RemoveRole(c *fiber.Ctx) error {
 		return errors.NewBadRequest("Invalid role ID")
 	}
 
-	ctx := context.Background()
-	if err := ctrl.rbacManager.RemoveRole(ctx, uint(userID), uint(roleID)); err != nil {
-		return errors.NewInternal("Failed to remove role")
+	ctx := withActor(context.Background(), c)
+	remove := func(ctx context.Context) error {
+		if err := ctrl.rbacManager.RemoveRole(ctx, uint(userID), uint(roleID)); err != nil {
+			return errors.NewInternal("Failed to remove role")
+		}
+		return nil
+	}
+
+	superAdmin, err := ctrl.rbacManager.GetRoleBySlug(ctx, rbac.SuperAdminRole)
+	if err == nil && superAdmin.ID == uint(roleID) {
+		err = ctrl.guardLastSuperAdmin(ctx, uint(userID), remove)
+	} else {
+		err = remove(ctx)
+	}
+	if err != nil {
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
@@ -391,6 +862,76 @@ func (ctrl *UserController) GetUserRoles(c *fiber.Ctx) error {
 	})
 }
 
+// AssignPermissions assigns permissions directly to a user. Permissions the
+// user already holds directly are left as they are.
+// POST /api/v1/users/:id/permissions
+func (ctrl *UserController) AssignPermissions(c *fiber.Ctx) error {
+	userID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return errors.NewBadRequest("Invalid user ID")
+	}
+
+	type AssignPermissionsRequest struct {
+		PermissionIDs []uint `json:"permission_ids" validate:"required,min=1,max=100,dive,required"`
+	}
+
+	var req AssignPermissionsRequest
+	if err := validation.ValidateBody(c, &req); err != nil {
+		return err
+	}
+
+	ctx := withActor(context.Background(), c)
+
+	user, err := ctrl.service.repo.FindByID(ctx, uint(userID))
+	if err != nil || user == nil {
+		return errors.NewNotFound("User not found")
+	}
+
+	if err := ctrl.rbacManager.AssignPermissions(ctx, uint(userID), req.PermissionIDs); err != nil {
+		return assignmentError(err, "Failed to assign permissions")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Permissions assigned successfully",
+	})
+}
+
+// SyncPermissions replaces a user's direct permissions with the given set.
+// Permissions granted through roles are unaffected.
+// PUT /api/v1/users/:id/permissions
+func (ctrl *UserController) SyncPermissions(c *fiber.Ctx) error {
+	userID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return errors.NewBadRequest("Invalid user ID")
+	}
+
+	type SyncPermissionsRequest struct {
+		PermissionIDs []uint `json:"permission_ids" validate:"max=100,dive,required"`
+	}
+
+	var req SyncPermissionsRequest
+	if err := validation.ValidateBody(c, &req); err != nil {
+		return err
+	}
+
+	ctx := withActor(context.Background(), c)
+
+	user, err := ctrl.service.repo.FindByID(ctx, uint(userID))
+	if err != nil || user == nil {
+		return errors.NewNotFound("User not found")
+	}
+
+	if err := ctrl.rbacManager.SyncUserPermissions(ctx, uint(userID), req.PermissionIDs); err != nil {
+		return assignmentError(err, "Failed to sync permissions")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Permissions synced successfully",
+	})
+}
+
 // GetUserPermissions gets all permissions for a user
 // GET /api/v1/users/:id/permissions
 func (ctrl *UserController) GetUserPermissions(c *fiber.Ctx) error {
@@ -410,3 +951,34 @@ func (ctrl *UserController) GetUserPermissions(c *fiber.Ctx) error {
 		"data":    permissions,
 	})
 }
+
+// assignmentError converts a role or permission assignment error to an AppError
+func assignmentError(err error, message string) error {
+	switch {
+	case stderrors.Is(err, rbac.ErrRoleNotFound):
+		return errors.NewNotFound("Role not found")
+	case stderrors.Is(err, rbac.ErrPermissionNotFound):
+		return errors.NewNotFound("Permission not found")
+	default:
+		return errors.NewInternal(message)
+	}
+}
+
+// containsID reports whether ids contains id
+func containsID(ids []uint, id uint) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// withActor marks ctx with the authenticated user as the actor of the RBAC
+// changes made with it
+func withActor(ctx context.Context, c *fiber.Ctx) context.Context {
+	if userID, ok := auth.GetUserID(c); ok {
+		return rbac.WithActor(ctx, userID)
+	}
+	return ctx
+}
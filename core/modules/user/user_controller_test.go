@@ -0,0 +1,610 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"neonexcore/internal/config"
+	"neonexcore/pkg/auth"
+	"neonexcore/pkg/cache"
+	"neonexcore/pkg/database"
+	"neonexcore/pkg/errors"
+	"neonexcore/pkg/events"
+	"neonexcore/pkg/logger"
+	"neonexcore/pkg/rbac"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func newTestUserController(t *testing.T) (*UserController, *gorm.DB) {
+	t.Helper()
+
+	db := newTestDB(t)
+	jwtManager := auth.NewJWTManager(&auth.JWTConfig{SecretKey: "test-secret"})
+	ctrl := NewUserController(
+		NewUserService(NewUserRepository(db), database.NewTxManager(db)),
+		rbac.NewManager(db),
+		auth.NewPasswordHasher(auth.MinCost),
+		jwtManager,
+	)
+	return ctrl, db
+}
+
+// newTestUserApp mounts the user routes without authentication
+func newTestUserApp(ctrl *UserController) *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: errors.ErrorHandler(logger.Default())})
+	app.Get("/users", ctrl.GetAll)
+	app.Get("/users/search", ctrl.Search)
+	app.Get("/users/trash", ctrl.Trash)
+	app.Post("/users/:id/restore", ctrl.Restore)
+	app.Get("/users/:id", ctrl.GetByID)
+	app.Post("/users", ctrl.Create)
+	app.Put("/users/:id", ctrl.Update)
+	app.Delete("/users/:id", ctrl.Delete)
+	app.Put("/users/:id/roles", ctrl.SyncRoles)
+	app.Delete("/users/:id/roles/:roleId", ctrl.RemoveRole)
+	return app
+}
+
+// testResponse is a decoded response of the test app
+type testResponse struct {
+	Status int
+	Header map[string]string
+	Body   struct {
+		Success bool                   `json:"success"`
+		Message string                 `json:"message"`
+		Data    json.RawMessage        `json:"data"`
+		Meta    map[string]interface{} `json:"meta"`
+	}
+}
+
+func doRequest(t *testing.T, app *fiber.App, method, path, body string, headers ...string) testResponse {
+	t.Helper()
+
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	result := testResponse{Status: resp.StatusCode, Header: map[string]string{}}
+	for key := range resp.Header {
+		result.Header[key] = resp.Header.Get(key)
+	}
+	raw, _ := io.ReadAll(resp.Body)
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &result.Body); err != nil {
+			t.Fatalf("%s %s: decode %q: %v", method, path, raw, err)
+		}
+	}
+	return result
+}
+
+// userNames returns the names of the users in a list response
+func userNames(t *testing.T, resp testResponse) []string {
+	t.Helper()
+
+	var users []User
+	if err := json.Unmarshal(resp.Body.Data, &users); err != nil {
+		t.Fatalf("decode users: %v", err)
+	}
+	names := make([]string, len(users))
+	for i, u := range users {
+		names[i] = u.Name
+	}
+	return names
+}
+
+func seedUsers(t *testing.T, db *gorm.DB, names ...string) []*User {
+	t.Helper()
+
+	users := make([]*User, len(names))
+	for i, name := range names {
+		lower := strings.ToLower(name)
+		users[i] = &User{Name: name, Email: lower + "@example.com", Username: lower, Password: "x", IsActive: true}
+		if err := db.Create(users[i]).Error; err != nil {
+			t.Fatalf("create user %s: %v", name, err)
+		}
+	}
+	return users
+}
+
+func TestGetAllSortsByColumnAndDirection(t *testing.T) {
+	ctrl, db := newTestUserController(t)
+	app := newTestUserApp(ctrl)
+	seedUsers(t, db, "Bob", "Alice", "Carol")
+
+	resp := doRequest(t, app, fiber.MethodGet, "/users?sort=name:asc", "")
+	if got := strings.Join(userNames(t, resp), ","); got != "Alice,Bob,Carol" {
+		t.Fatalf("sort=name:asc returned %s", got)
+	}
+
+	resp = doRequest(t, app, fiber.MethodGet, "/users?sort=name:desc", "")
+	if got := strings.Join(userNames(t, resp), ","); got != "Carol,Bob,Alice" {
+		t.Fatalf("sort=name:desc returned %s", got)
+	}
+
+	resp = doRequest(t, app, fiber.MethodGet, "/users?sort=id:desc", "")
+	if got := strings.Join(userNames(t, resp), ","); got != "Carol,Alice,Bob" {
+		t.Fatalf("sort=id:desc returned %s", got)
+	}
+}
+
+func TestGetAllRejectsUnknownSortColumn(t *testing.T) {
+	ctrl, _ := newTestUserController(t)
+	app := newTestUserApp(ctrl)
+
+	for _, sort := range []string{"password", "name:sideways"} {
+		if resp := doRequest(t, app, fiber.MethodGet, "/users?sort="+sort, ""); resp.Status != fiber.StatusBadRequest {
+			t.Errorf("sort=%s returned %d, want 400", sort, resp.Status)
+		}
+	}
+}
+
+func TestGetAllFiltersByRole(t *testing.T) {
+	ctrl, db := newTestUserController(t)
+	app := newTestUserApp(ctrl)
+	users := seedUsers(t, db, "Alice", "Bob", "Carol")
+
+	role := &rbac.Role{Name: "Admin", Slug: "admin"}
+	if err := ctrl.rbacManager.CreateRole(context.Background(), role); err != nil {
+		t.Fatalf("CreateRole: %v", err)
+	}
+	for _, u := range []*User{users[0], users[2]} {
+		if err := ctrl.rbacManager.AssignRole(context.Background(), u.ID, role.ID); err != nil {
+			t.Fatalf("AssignRole: %v", err)
+		}
+	}
+
+	resp := doRequest(t, app, fiber.MethodGet, "/users?role=admin&sort=name", "")
+	if got := strings.Join(userNames(t, resp), ","); got != "Alice,Carol" {
+		t.Fatalf("role=admin returned %s", got)
+	}
+	if total := resp.Body.Meta["total"]; total != float64(2) {
+		t.Fatalf("total = %v, want 2", total)
+	}
+
+	resp = doRequest(t, app, fiber.MethodGet, "/users?role=missing", "")
+	if names := userNames(t, resp); len(names) != 0 {
+		t.Fatalf("role=missing returned %v", names)
+	}
+}
+
+func TestTrashListsSoftDeletedUsers(t *testing.T) {
+	ctrl, db := newTestUserController(t)
+	app := newTestUserApp(ctrl)
+	users := seedUsers(t, db, "Alice", "Bob", "Carol")
+
+	for _, u := range []*User{users[0], users[2]} {
+		if resp := doRequest(t, app, fiber.MethodDelete, fmt.Sprintf("/users/%d", u.ID), ""); resp.Status != fiber.StatusOK {
+			t.Fatalf("delete %s returned %d", u.Name, resp.Status)
+		}
+	}
+
+	resp := doRequest(t, app, fiber.MethodGet, "/users/trash", "")
+	if resp.Status != fiber.StatusOK {
+		t.Fatalf("trash returned %d", resp.Status)
+	}
+	names := userNames(t, resp)
+	sort.Strings(names)
+	if got := strings.Join(names, ","); got != "Alice,Carol" {
+		t.Fatalf("trash returned %s, want the deleted users", got)
+	}
+	if total := resp.Body.Meta["total"]; total != float64(2) {
+		t.Fatalf("total = %v, want 2", total)
+	}
+
+	resp = doRequest(t, app, fiber.MethodGet, "/users/trash?page=2&limit=1", "")
+	if names := userNames(t, resp); len(names) != 1 || resp.Body.Meta["total_pages"] != float64(2) {
+		t.Fatalf("page 2 returned %v, meta %v", names, resp.Body.Meta)
+	}
+
+	// Active users stay out of the trash and deleted ones out of the list
+	resp = doRequest(t, app, fiber.MethodGet, "/users", "")
+	if got := strings.Join(userNames(t, resp), ","); got != "Bob" {
+		t.Fatalf("users returned %s, want only Bob", got)
+	}
+}
+
+func TestRestoreUser(t *testing.T) {
+	ctrl, db := newTestUserController(t)
+	app := newTestUserApp(ctrl)
+	users := seedUsers(t, db, "Alice", "Bob")
+	alice := users[0]
+
+	doRequest(t, app, fiber.MethodDelete, fmt.Sprintf("/users/%d", alice.ID), "")
+	resp := doRequest(t, app, fiber.MethodPost, fmt.Sprintf("/users/%d/restore", alice.ID), "")
+	if resp.Status != fiber.StatusOK {
+		t.Fatalf("restore returned %d: %s", resp.Status, resp.Body.Message)
+	}
+	if restored := reloadUser(t, db, alice.ID); restored.DeletedAt.Valid || restored.Email != "alice@example.com" {
+		t.Fatalf("restored user = %+v", restored)
+	}
+
+	var published int64
+	db.Model(&events.OutboxMessage{}).Where("event_name = ?", events.EventUserRestored).Count(&published)
+	if published != 1 {
+		t.Fatalf("%d user.restored events, want 1", published)
+	}
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+	}{
+		{"already active", fmt.Sprintf("/users/%d/restore", alice.ID), fiber.StatusNotFound},
+		{"never deleted", fmt.Sprintf("/users/%d/restore", users[1].ID), fiber.StatusNotFound},
+		{"missing", "/users/999/restore", fiber.StatusNotFound},
+		{"invalid id", "/users/abc/restore", fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if resp := doRequest(t, app, fiber.MethodPost, tt.path, ""); resp.Status != tt.status {
+			t.Errorf("%s: restore returned %d, want %d", tt.name, resp.Status, tt.status)
+		}
+	}
+}
+
+func TestRestoreRejectsTakenEmailOrUsername(t *testing.T) {
+	ctrl, db := newTestUserController(t)
+	app := newTestUserApp(ctrl)
+	// Databases with partial unique indexes let a new user take a deleted
+	// user's email or username
+	for _, field := range []string{"Email", "Username"} {
+		if err := db.Migrator().DropIndex(&User{}, field); err != nil {
+			t.Fatalf("drop %s index: %v", field, err)
+		}
+	}
+	users := seedUsers(t, db, "Alice", "Bob")
+	for _, u := range users {
+		doRequest(t, app, fiber.MethodDelete, fmt.Sprintf("/users/%d", u.ID), "")
+	}
+
+	newer := []*User{
+		{Name: "Alicia", Email: "alice@example.com", Username: "alicia", Password: "x", IsActive: true},
+		{Name: "Robert", Email: "robert@example.com", Username: "bob", Password: "x", IsActive: true},
+	}
+	for i, u := range newer {
+		if err := db.Create(u).Error; err != nil {
+			t.Fatalf("create %s: %v", u.Name, err)
+		}
+
+		resp := doRequest(t, app, fiber.MethodPost, fmt.Sprintf("/users/%d/restore", users[i].ID), "")
+		if resp.Status != fiber.StatusConflict {
+			t.Fatalf("restoring %s returned %d, want 409", users[i].Name, resp.Status)
+		}
+		var trashed User
+		if err := db.Unscoped().First(&trashed, users[i].ID).Error; err != nil || !trashed.DeletedAt.Valid {
+			t.Fatalf("%s was restored despite the conflict", users[i].Name)
+		}
+	}
+}
+
+// grantSuperAdmin creates the super-admin role and assigns it to users
+func grantSuperAdmin(t *testing.T, ctrl *UserController, users ...*User) *rbac.Role {
+	t.Helper()
+
+	role := &rbac.Role{Name: "Super Admin", Slug: rbac.SuperAdminRole}
+	if err := ctrl.rbacManager.CreateRole(context.Background(), role); err != nil {
+		t.Fatalf("CreateRole: %v", err)
+	}
+	for _, u := range users {
+		if err := ctrl.rbacManager.AssignRole(context.Background(), u.ID, role.ID); err != nil {
+			t.Fatalf("AssignRole: %v", err)
+		}
+	}
+	return role
+}
+
+func TestLastSuperAdminGuard(t *testing.T) {
+	actions := []struct {
+		name    string
+		request func(user *User, role *rbac.Role) (method, path, body string)
+		applied func(t *testing.T, db *gorm.DB, ctrl *UserController, user *User) bool
+	}{
+		{
+			"delete",
+			func(user *User, _ *rbac.Role) (string, string, string) {
+				return fiber.MethodDelete, fmt.Sprintf("/users/%d", user.ID), ""
+			},
+			func(_ *testing.T, db *gorm.DB, _ *UserController, user *User) bool {
+				return db.First(&User{}, user.ID).Error != nil
+			},
+		},
+		{
+			"deactivate",
+			func(user *User, _ *rbac.Role) (string, string, string) {
+				return fiber.MethodPut, fmt.Sprintf("/users/%d", user.ID), `{"is_active":false}`
+			},
+			func(t *testing.T, db *gorm.DB, _ *UserController, user *User) bool {
+				return !reloadUser(t, db, user.ID).IsActive
+			},
+		},
+		{
+			"remove role",
+			func(user *User, role *rbac.Role) (string, string, string) {
+				return fiber.MethodDelete, fmt.Sprintf("/users/%d/roles/%d", user.ID, role.ID), ""
+			},
+			func(_ *testing.T, _ *gorm.DB, ctrl *UserController, user *User) bool {
+				has, _ := ctrl.rbacManager.HasRole(context.Background(), user.ID, rbac.SuperAdminRole)
+				return !has
+			},
+		},
+		{
+			"sync roles",
+			func(user *User, _ *rbac.Role) (string, string, string) {
+				return fiber.MethodPut, fmt.Sprintf("/users/%d/roles", user.ID), `{"role_ids":[]}`
+			},
+			func(_ *testing.T, _ *gorm.DB, ctrl *UserController, user *User) bool {
+				has, _ := ctrl.rbacManager.HasRole(context.Background(), user.ID, rbac.SuperAdminRole)
+				return !has
+			},
+		},
+	}
+	setups := []struct {
+		name    string
+		holders int
+		active  bool
+		status  int
+	}{
+		{"only super-admin", 1, true, fiber.StatusConflict},
+		{"other super-admin inactive", 2, false, fiber.StatusConflict},
+		{"one of two", 2, true, fiber.StatusOK},
+	}
+	for _, action := range actions {
+		for _, setup := range setups {
+			t.Run(action.name+"/"+setup.name, func(t *testing.T) {
+				ctrl, db := newTestUserController(t)
+				app := newTestUserApp(ctrl)
+				users := seedUsers(t, db, "Alice", "Bob")
+				role := grantSuperAdmin(t, ctrl, users[:setup.holders]...)
+				if !setup.active {
+					db.Model(users[1]).Update("is_active", false)
+				}
+
+				method, path, body := action.request(users[0], role)
+				resp := doRequest(t, app, method, path, body)
+				if resp.Status != setup.status {
+					t.Fatalf("%s returned %d, want %d: %s", action.name, resp.Status, setup.status, resp.Body.Message)
+				}
+				if applied := action.applied(t, db, ctrl, users[0]); applied != (setup.status == fiber.StatusOK) {
+					t.Fatalf("change applied = %v after status %d", applied, resp.Status)
+				}
+			})
+		}
+	}
+}
+
+func TestSearchRejectsShortQueries(t *testing.T) {
+	ctrl, db := newTestUserController(t)
+	app := newTestUserApp(ctrl)
+	seedUsers(t, db, "Alice", "Albert")
+
+	for _, q := range []string{"", "a", "%20a%20", "%20%20%20"} {
+		if resp := doRequest(t, app, fiber.MethodGet, "/users/search?q="+q, ""); resp.Status != fiber.StatusBadRequest {
+			t.Errorf("q=%q returned %d, want 400", q, resp.Status)
+		}
+	}
+
+	resp := doRequest(t, app, fiber.MethodGet, "/users/search?q=al", "")
+	if got := strings.Join(userNames(t, resp), ","); got != "Albert,Alice" {
+		t.Fatalf("q=al returned %s", got)
+	}
+	// Wildcards are matched literally
+	if resp := doRequest(t, app, fiber.MethodGet, "/users/search?q=%25%25", ""); len(userNames(t, resp)) != 0 {
+		t.Fatalf("q=%%%% returned %v", userNames(t, resp))
+	}
+}
+
+func TestSearchCapsResults(t *testing.T) {
+	ctrl, db := newTestUserController(t)
+	app := newTestUserApp(ctrl)
+	ctrl.SetSearch(nil, &config.UserSearchConfig{MinLength: 2, MaxResults: 3})
+	seedUsers(t, db, "Ann1", "Ann2", "Ann3", "Ann4", "Ann5")
+
+	resp := doRequest(t, app, fiber.MethodGet, "/users/search?q=ann", "")
+	if got := strings.Join(userNames(t, resp), ","); got != "Ann1,Ann2,Ann3" {
+		t.Fatalf("q=ann returned %s, want the first 3", got)
+	}
+	resp = doRequest(t, app, fiber.MethodGet, "/users/search?q=ann&limit=2", "")
+	if names := userNames(t, resp); len(names) != 2 {
+		t.Fatalf("limit=2 returned %v", names)
+	}
+	if resp := doRequest(t, app, fiber.MethodGet, "/users/search?q=ann&limit=100", ""); resp.Status != fiber.StatusBadRequest {
+		t.Fatalf("limit=100 returned %d, want 400", resp.Status)
+	}
+}
+
+func TestSearchCachesRepeatedQueries(t *testing.T) {
+	ctrl, db := newTestUserController(t)
+	app := newTestUserApp(ctrl)
+	mc := cache.NewMemoryCache(cache.DefaultMemoryCacheConfig())
+	t.Cleanup(func() { mc.Close() })
+	ctrl.SetSearch(mc, &config.UserSearchConfig{MinLength: 2, MaxResults: 50, CacheTTL: time.Minute})
+	seedUsers(t, db, "Alice")
+
+	resp := doRequest(t, app, fiber.MethodGet, "/users/search?q=ali", "")
+	if resp.Body.Meta["cached"] != false {
+		t.Fatalf("first search meta = %v, want it uncached", resp.Body.Meta)
+	}
+
+	// Repeats within the TTL don't reach the database, whatever the case
+	seedUsers(t, db, "Alicia")
+	resp = doRequest(t, app, fiber.MethodGet, "/users/search?q=ALI", "")
+	if resp.Body.Meta["cached"] != true {
+		t.Fatalf("repeated search meta = %v, want it cached", resp.Body.Meta)
+	}
+	if got := strings.Join(userNames(t, resp), ","); got != "Alice" {
+		t.Fatalf("cached search returned %s", got)
+	}
+
+	// A different limit is a different query
+	resp = doRequest(t, app, fiber.MethodGet, "/users/search?q=ali&limit=10", "")
+	if resp.Body.Meta["cached"] != false || len(userNames(t, resp)) != 2 {
+		t.Fatalf("search with limit=10 returned %v, meta %v", userNames(t, resp), resp.Body.Meta)
+	}
+}
+
+func TestUpdateIsConditionalOnVersion(t *testing.T) {
+	ctrl, db := newTestUserController(t)
+	app := newTestUserApp(ctrl)
+	alice := seedUsers(t, db, "Alice")[0]
+	path := fmt.Sprintf("/users/%d", alice.ID)
+
+	resp := doRequest(t, app, fiber.MethodGet, path, "")
+	etag := resp.Header["Etag"]
+	if etag != `"1"` {
+		t.Fatalf("GET ETag = %q, want \"1\"", etag)
+	}
+
+	resp = doRequest(t, app, fiber.MethodPut, path, `{"name":"Alice Smith"}`, fiber.HeaderIfMatch, etag)
+	if resp.Status != fiber.StatusOK || resp.Header["Etag"] != `"2"` {
+		t.Fatalf("conditional update: status %d, ETag %q; want 200 and \"2\"", resp.Status, resp.Header["Etag"])
+	}
+
+	// Another admin still holding version 1 is turned away
+	for _, tt := range []struct {
+		name    string
+		body    string
+		headers []string
+	}{
+		{"If-Match", `{"name":"Alice Jones"}`, []string{fiber.HeaderIfMatch, etag}},
+		{"weak If-Match", `{"name":"Alice Jones"}`, []string{fiber.HeaderIfMatch, "W/" + etag}},
+		{"body version", `{"name":"Alice Jones","version":1}`, nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := doRequest(t, app, fiber.MethodPut, path, tt.body, tt.headers...)
+			if resp.Status != fiber.StatusConflict {
+				t.Fatalf("stale update returned %d, want 409", resp.Status)
+			}
+		})
+	}
+
+	var stored User
+	db.First(&stored, alice.ID)
+	if stored.Name != "Alice Smith" || stored.Version != 2 {
+		t.Fatalf("stored user %q at version %d, want Alice Smith at 2", stored.Name, stored.Version)
+	}
+
+	// Unconditional updates still go through and bump the version
+	resp = doRequest(t, app, fiber.MethodPut, path, `{"age":30}`)
+	if resp.Status != fiber.StatusOK || resp.Header["Etag"] != `"3"` {
+		t.Fatalf("unconditional update: status %d, ETag %q", resp.Status, resp.Header["Etag"])
+	}
+	if resp := doRequest(t, app, fiber.MethodPut, path, `{"age":31}`, fiber.HeaderIfMatch, "3"); resp.Status != fiber.StatusBadRequest {
+		t.Fatalf("malformed If-Match returned %d, want 400", resp.Status)
+	}
+}
+
+func TestCreateWithDeletedUsersEmail(t *testing.T) {
+	body := `{"name":"Alicia","email":"alice@example.com","username":"alicia","password":"correct-horse","is_active":true}`
+
+	ctrl, db := newTestUserController(t)
+	app := newTestUserApp(ctrl)
+	alice := seedUsers(t, db, "Alice")[0]
+	doRequest(t, app, fiber.MethodDelete, fmt.Sprintf("/users/%d", alice.ID), "")
+
+	resp := doRequest(t, app, fiber.MethodPost, "/users", body)
+	if resp.Status != fiber.StatusConflict {
+		t.Fatalf("create returned %d, want 409", resp.Status)
+	}
+
+	ctrl.service.SetDeletedUserPolicy(config.DeletedUserRestore)
+	resp = doRequest(t, app, fiber.MethodPost, "/users", body)
+	if resp.Status != fiber.StatusCreated || resp.Body.Message != "Deleted user restored with the new details" {
+		t.Fatalf("create returned %d: %s", resp.Status, resp.Body.Message)
+	}
+	if restored := reloadUser(t, db, alice.ID); restored.Name != "Alicia" || restored.Username != "alicia" {
+		t.Fatalf("restored user = %+v, want the new details", restored)
+	}
+}
+
+func TestOwnerUpdateIsLimitedToProfileFields(t *testing.T) {
+	ctrl, db := newTestUserController(t)
+	ctx := context.Background()
+	users := seedUsers(t, db, "Alice", "Bob")
+	alice, bob := users[0], users[1]
+	db.Model(alice).Update("is_email_verified", true)
+
+	// Alice may update her own account, Bob any account
+	grant := func(user *User, slug string) {
+		role := &rbac.Role{Name: slug, Slug: slug}
+		permission := &rbac.Permission{Name: slug, Slug: slug}
+		if err := ctrl.rbacManager.CreateRole(ctx, role); err != nil {
+			t.Fatalf("CreateRole: %v", err)
+		}
+		if err := ctrl.rbacManager.CreatePermission(ctx, permission); err != nil {
+			t.Fatalf("CreatePermission: %v", err)
+		}
+		ctrl.rbacManager.AttachPermissionToRole(ctx, role.ID, permission.ID)
+		ctrl.rbacManager.AssignRole(ctx, user.ID, role.ID)
+	}
+	grant(alice, "users.update.own")
+	grant(bob, "users.update")
+
+	// The X-User header names the authenticated user
+	app := fiber.New(fiber.Config{ErrorHandler: errors.ErrorHandler(logger.Default())})
+	app.Use(func(c *fiber.Ctx) error {
+		var id uint
+		fmt.Sscan(c.Get("X-User"), &id)
+		c.Locals("user_id", id)
+		return c.Next()
+	})
+	app.Put("/users/:id", rbac.RequirePermissionOrOwner(ctrl.rbacManager, "users.update", func(c *fiber.Ctx) (uint, error) {
+		var id uint
+		_, err := fmt.Sscan(c.Params("id"), &id)
+		return id, err
+	}), ctrl.Update)
+
+	alicePath := fmt.Sprintf("/users/%d", alice.ID)
+	aliceID := fmt.Sprint(alice.ID)
+	tests := []struct {
+		name   string
+		actor  string
+		path   string
+		body   string
+		status int
+	}{
+		{"owner changes name and age", aliceID, alicePath, `{"name":"Alice Smith","age":30}`, fiber.StatusOK},
+		{"owner resends own email", aliceID, alicePath, `{"email":"alice@example.com"}`, fiber.StatusOK},
+		{"owner changes email", aliceID, alicePath, `{"email":"alice@evil.example"}`, fiber.StatusForbidden},
+		{"owner deactivates", aliceID, alicePath, `{"is_active":false}`, fiber.StatusForbidden},
+		{"owner edits another user", aliceID, fmt.Sprintf("/users/%d", bob.ID), `{"name":"Robert"}`, fiber.StatusForbidden},
+	}
+	for _, tt := range tests {
+		if resp := doRequest(t, app, fiber.MethodPut, tt.path, tt.body, "X-User", tt.actor); resp.Status != tt.status {
+			t.Fatalf("%s: status %d, want %d: %s", tt.name, resp.Status, tt.status, resp.Body.Message)
+		}
+	}
+
+	stored := reloadUser(t, db, alice.ID)
+	if stored.Name != "Alice Smith" || stored.Age != 30 || stored.Email != "alice@example.com" || !stored.IsActive || !stored.IsEmailVerified {
+		t.Fatalf("alice = %+v, want only her name and age changed", stored)
+	}
+
+	// users.update still covers the email and account status
+	resp := doRequest(t, app, fiber.MethodPut, alicePath, `{"email":"alice.smith@example.com","is_active":false}`, "X-User", fmt.Sprint(bob.ID))
+	if resp.Status != fiber.StatusOK {
+		t.Fatalf("admin update: status %d, want 200: %s", resp.Status, resp.Body.Message)
+	}
+	if stored := reloadUser(t, db, alice.ID); stored.Email != "alice.smith@example.com" || stored.IsActive {
+		t.Fatalf("alice = %+v, want the admin's changes", stored)
+	}
+}
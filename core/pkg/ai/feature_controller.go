@@ -0,0 +1,98 @@
+package ai
+
+import (
+	"strings"
+
+	"neonexcore/pkg/api"
+	"neonexcore/pkg/errors"
+	"neonexcore/pkg/validation"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// FeatureController serves feature vectors over HTTP for online inference.
+// Vectors come from the feature store cache where possible; features an
+// entity doesn't have are omitted rather than reported as errors.
+type FeatureController struct {
+	store *FeatureStore
+}
+
+// NewFeatureController creates a new feature controller
+func NewFeatureController(store *FeatureStore) *FeatureController {
+	return &FeatureController{
+		store: store,
+	}
+}
+
+// GetFeatureVector handles GET /features/:entityType/:entityId?names=a,b,c
+func (c *FeatureController) GetFeatureVector(ctx *fiber.Ctx) error {
+	entityType := ctx.Params("entityType")
+	entityID := ctx.Params("entityId")
+
+	names := cleanFeatureNames(strings.Split(ctx.Query("names"), ","))
+	if len(names) == 0 {
+		return errors.NewBadRequest("names is required")
+	}
+
+	vectors, err := c.store.GetFeatureVectorsBatch(ctx.UserContext(), entityType, []string{entityID}, names)
+	if err != nil {
+		return errors.NewInternal("Failed to get feature vector").WithError(err)
+	}
+
+	return api.Success(ctx, fiber.Map{
+		"entity_type": entityType,
+		"entity_id":   entityID,
+		"features":    vectors[entityID],
+	})
+}
+
+// GetFeatureVectorsBatch handles POST /features/batch with up to 1000
+// entity IDs
+func (c *FeatureController) GetFeatureVectorsBatch(ctx *fiber.Ctx) error {
+	var req struct {
+		EntityType string   `json:"entity_type" validate:"required"`
+		EntityIDs  []string `json:"entity_ids" validate:"required,min=1,max=1000"`
+		Names      []string `json:"names" validate:"required,min=1"`
+	}
+	if err := validation.ValidateBody(ctx, &req); err != nil {
+		return err
+	}
+
+	names := cleanFeatureNames(req.Names)
+	if len(names) == 0 {
+		return errors.NewBadRequest("names is required")
+	}
+
+	vectors, err := c.store.GetFeatureVectorsBatch(ctx.UserContext(), req.EntityType, req.EntityIDs, names)
+	if err != nil {
+		return errors.NewInternal("Failed to get feature vectors").WithError(err)
+	}
+
+	return api.Success(ctx, fiber.Map{
+		"entity_type": req.EntityType,
+		"vectors":     vectors,
+	})
+}
+
+// RegisterRoutes registers feature serving routes
+func (c *FeatureController) RegisterRoutes(router fiber.Router) {
+	features := router.Group("/features")
+
+	features.Post("/batch", c.GetFeatureVectorsBatch)
+	features.Get("/:entityType/:entityId", c.GetFeatureVector)
+}
+
+// cleanFeatureNames trims feature names and drops blanks and duplicates
+func cleanFeatureNames(list []string) []string {
+	names := make([]string, 0, len(list))
+	seen := make(map[string]bool, len(list))
+	for _, name := range list {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	return names
+}
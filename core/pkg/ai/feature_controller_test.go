@@ -0,0 +1,133 @@
+package ai
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"neonexcore/pkg/errors"
+	"neonexcore/pkg/logger"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func newFeatureTestApp(t *testing.T) (*fiber.App, *FeatureStore) {
+	t.Helper()
+
+	fs, _ := seedBatchFeatures(t, 500)
+	app := fiber.New(fiber.Config{ErrorHandler: errors.ErrorHandler(logger.Default())})
+	NewFeatureController(fs).RegisterRoutes(app)
+	return app, fs
+}
+
+// requestFeatures sends a request and returns the status and the data of
+// the response envelope
+func requestFeatures(t *testing.T, app *fiber.App, method, path, body string) (int, map[string]interface{}) {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+
+	var envelope struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	json.NewDecoder(resp.Body).Decode(&envelope)
+	return resp.StatusCode, envelope.Data
+}
+
+func TestFeatureControllerGetVector(t *testing.T) {
+	app, _ := newFeatureTestApp(t)
+
+	tests := []struct {
+		name string
+		path string
+		want map[string]interface{}
+	}{
+		{"all features", "/features/user/1?names=score,country", map[string]interface{}{"score": float64(100), "country": "NL"}},
+		{"blank and duplicate names", "/features/user/2?names=score,,%20score", map[string]interface{}{"score": float64(2)}},
+		{"partial vector", "/features/user/5?names=score,country,unknown", map[string]interface{}{"score": float64(5)}},
+		{"unknown entity", "/features/user/missing?names=score", map[string]interface{}{}},
+		{"other entity type", "/features/product/1?names=score", map[string]interface{}{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, data := requestFeatures(t, app, fiber.MethodGet, tt.path, "")
+			if status != fiber.StatusOK {
+				t.Fatalf("status %d, want 200", status)
+			}
+			features, _ := data["features"].(map[string]interface{})
+			if features == nil || len(features) != len(tt.want) {
+				t.Fatalf("features = %v, want %v", data["features"], tt.want)
+			}
+			for k, v := range tt.want {
+				if features[k] != v {
+					t.Fatalf("features = %v, want %v", features, tt.want)
+				}
+			}
+		})
+	}
+
+	if status, _ := requestFeatures(t, app, fiber.MethodGet, "/features/user/1?names=,", ""); status != fiber.StatusBadRequest {
+		t.Fatalf("without names: status %d, want 400", status)
+	}
+}
+
+func TestFeatureControllerBatch(t *testing.T) {
+	app, _ := newFeatureTestApp(t)
+
+	body := `{"entity_type":"user","entity_ids":["1","4","5","missing"],"names":["score","country"]}`
+	status, data := requestFeatures(t, app, fiber.MethodPost, "/features/batch", body)
+	if status != fiber.StatusOK {
+		t.Fatalf("status %d, want 200", status)
+	}
+	vectors, _ := data["vectors"].(map[string]interface{})
+	if len(vectors) != 4 {
+		t.Fatalf("vectors = %v, want one per entity", data["vectors"])
+	}
+
+	want := map[string]int{"1": 2, "4": 2, "5": 1, "missing": 0}
+	for id, size := range want {
+		vector, ok := vectors[id].(map[string]interface{})
+		if !ok || len(vector) != size {
+			t.Errorf("vector of %s = %v, want %d features", id, vectors[id], size)
+		}
+	}
+	if vector := vectors["4"].(map[string]interface{}); vector["score"] != float64(4) || vector["country"] != "NL" {
+		t.Fatalf("vector of 4 = %v", vector)
+	}
+
+	invalid := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"no entity IDs", `{"entity_type":"user","entity_ids":[],"names":["score"]}`, fiber.StatusUnprocessableEntity},
+		{"no entity type", `{"entity_ids":["1"],"names":["score"]}`, fiber.StatusUnprocessableEntity},
+		{"blank names", `{"entity_type":"user","entity_ids":["1"],"names":[" "]}`, fiber.StatusBadRequest},
+		{"malformed body", `{"entity_ids":`, fiber.StatusBadRequest},
+	}
+	for _, tt := range invalid {
+		if status, _ := requestFeatures(t, app, fiber.MethodPost, "/features/batch", tt.body); status != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.name, status, tt.want)
+		}
+	}
+}
+
+func TestFeatureControllerServesFromCache(t *testing.T) {
+	app, fs := newFeatureTestApp(t)
+	queries := countFeatureQueries(t, fs.db)
+
+	for i := 0; i < 3; i++ {
+		if status, _ := requestFeatures(t, app, fiber.MethodGet, "/features/user/1?names=score,country", ""); status != fiber.StatusOK {
+			t.Fatalf("status %d, want 200", status)
+		}
+	}
+	if *queries != 1 {
+		t.Fatalf("%d queries for repeated requests, want 1", *queries)
+	}
+}
@@ -3,7 +3,10 @@ package ai
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"strings"
 	"sync"
 	"time"
 
@@ -13,22 +16,57 @@ import (
 // FeatureStore stores and manages ML features
 type FeatureStore struct {
 	db         *gorm.DB
-	cache      map[string]*Feature
+	config     FeatureStoreConfig
+	cache      map[string]*featureCacheEntry
 	cacheTTL   time.Duration
 	mu         sync.RWMutex
 }
 
-// Feature represents a machine learning feature
+// featureCacheEntry is a cached feature and when it was cached
+type featureCacheEntry struct {
+	feature  *Feature
+	cachedAt time.Time
+}
+
+// DefaultHistoryRetention is how long superseded feature versions are kept
+// by default
+const DefaultHistoryRetention = 30 * 24 * time.Hour
+
+// FeatureStoreConfig configures the feature store
+type FeatureStoreConfig struct {
+	// KeepHistory stores every computation as a new version instead of
+	// overwriting, which enables point-in-time lookups
+	KeepHistory bool
+	// HistoryRetention prunes superseded versions older than this (0 keeps them forever)
+	HistoryRetention time.Duration
+	// CacheTTL bounds how long a feature is served from memory before being refetched
+	CacheTTL time.Duration
+	// BatchSize is the number of entities fetched per query by GetFeatureVectorsBatch
+	BatchSize int
+}
+
+// DefaultFeatureStoreConfig returns the default feature store configuration
+func DefaultFeatureStoreConfig() *FeatureStoreConfig {
+	return &FeatureStoreConfig{
+		KeepHistory:      true,
+		HistoryRetention: DefaultHistoryRetention,
+		CacheTTL:         5 * time.Minute,
+		BatchSize:        500,
+	}
+}
+
+// Feature represents a machine learning feature. With history enabled each
+// computation is a separate row identified by ID and Version.
 type Feature struct {
 	ID          string                 `json:"id" gorm:"primaryKey"`
 	Name        string                 `json:"name" gorm:"index"`
 	EntityType  string                 `json:"entity_type"` // user, product, etc.
 	EntityID    string                 `json:"entity_id" gorm:"index"`
-	Values      map[string]interface{} `json:"values" gorm:"type:jsonb"`
-	Version     int                    `json:"version"`
-	ComputedAt  time.Time              `json:"computed_at"`
+	Values      map[string]interface{} `json:"values" gorm:"type:jsonb;serializer:json"`
+	Version     int                    `json:"version" gorm:"primaryKey;autoIncrement:false"`
+	ComputedAt  time.Time              `json:"computed_at" gorm:"index"`
 	ExpiresAt   *time.Time             `json:"expires_at,omitempty"`
-	Metadata    map[string]string      `json:"metadata" gorm:"type:jsonb"`
+	Metadata    map[string]string      `json:"metadata" gorm:"type:jsonb;serializer:json"`
 	CreatedAt   time.Time              `json:"created_at"`
 	UpdatedAt   time.Time              `json:"updated_at"`
 }
@@ -38,23 +76,37 @@ type FeatureGroup struct {
 	ID          string            `json:"id" gorm:"primaryKey"`
 	Name        string            `json:"name" gorm:"uniqueIndex"`
 	Description string            `json:"description"`
-	Features    []string          `json:"features" gorm:"type:jsonb"`
+	Features    []string          `json:"features" gorm:"type:jsonb;serializer:json"`
 	EntityType  string            `json:"entity_type"`
 	Version     int               `json:"version"`
-	Metadata    map[string]string `json:"metadata" gorm:"type:jsonb"`
+	Metadata    map[string]string `json:"metadata" gorm:"type:jsonb;serializer:json"`
 	CreatedAt   time.Time         `json:"created_at"`
 	UpdatedAt   time.Time         `json:"updated_at"`
 }
 
-// NewFeatureStore creates a new feature store
-func NewFeatureStore(db *gorm.DB) *FeatureStore {
+// NewFeatureStore creates a new feature store. A nil config uses DefaultFeatureStoreConfig.
+func NewFeatureStore(db *gorm.DB, config *FeatureStoreConfig) *FeatureStore {
+	if config == nil {
+		config = DefaultFeatureStoreConfig()
+	}
+	if config.CacheTTL <= 0 {
+		config.CacheTTL = DefaultFeatureStoreConfig().CacheTTL
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultFeatureStoreConfig().BatchSize
+	}
+
 	store := &FeatureStore{
 		db:       db,
-		cache:    make(map[string]*Feature),
-		cacheTTL: 5 * time.Minute,
+		config:   *config,
+		cache:    make(map[string]*featureCacheEntry),
+		cacheTTL: config.CacheTTL,
 	}
 
-	// Auto-migrate
+	// Auto-migrate; tables from before versioning need their key rebuilt first
+	if err := migrateFeatureVersions(db); err != nil {
+		log.Printf("feature store: failed to migrate features to versioned keys: %v", err)
+	}
 	db.AutoMigrate(&Feature{}, &FeatureGroup{})
 
 	// Start cleanup goroutine
@@ -63,89 +115,309 @@ func NewFeatureStore(db *gorm.DB) *FeatureStore {
 	return store
 }
 
-// SetFeature sets a feature value
+// SetFeature stores a new version of a feature. The version is one past the
+// latest stored version for the feature ID.
 func (fs *FeatureStore) SetFeature(ctx context.Context, feature *Feature) error {
+	if err := fs.saveFeature(fs.db.WithContext(ctx), feature); err != nil {
+		return fmt.Errorf("failed to save feature: %w", err)
+	}
+
+	fs.cacheFeatures(feature)
+
+	return nil
+}
+
+// maxVersionAttempts bounds how often saveFeature retries a version taken by
+// a concurrent writer
+const maxVersionAttempts = 5
+
+// saveFeature assigns the ID and next version and inserts the row. Without
+// KeepHistory older versions are removed so only the latest row remains.
+// Writers racing for the same version collide on the (id, version) key; the
+// loser retries with the next one.
+func (fs *FeatureStore) saveFeature(db *gorm.DB, feature *Feature) error {
 	if feature.ID == "" {
 		feature.ID = fmt.Sprintf("%s:%s:%s", feature.EntityType, feature.EntityID, feature.Name)
 	}
 
 	feature.ComputedAt = time.Now()
 
-	// Save to database
-	if err := fs.db.WithContext(ctx).Save(feature).Error; err != nil {
-		return fmt.Errorf("failed to save feature: %w", err)
+	for attempt := 1; ; attempt++ {
+		// Nested in a batch's transaction this is a savepoint, so a collision
+		// doesn't abort the batch
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var latest int
+			if err := tx.Model(&Feature{}).
+				Where("id = ?", feature.ID).
+				Select("COALESCE(MAX(version), 0)").
+				Scan(&latest).Error; err != nil {
+				return err
+			}
+			feature.Version = latest + 1
+
+			if !fs.config.KeepHistory {
+				if err := tx.Where("id = ?", feature.ID).Delete(&Feature{}).Error; err != nil {
+					return err
+				}
+			}
+
+			return tx.Create(feature).Error
+		})
+		if !errors.Is(err, gorm.ErrDuplicatedKey) || attempt == maxVersionAttempts {
+			return err
+		}
 	}
+}
 
-	// Update cache
-	fs.mu.Lock()
-	fs.cache[feature.ID] = feature
-	fs.mu.Unlock()
+// migrateFeatureVersions rebuilds a features table keyed by ID alone, as
+// created before features were versioned, with the (id, version) primary
+// key. AutoMigrate can't change a primary key, so the rows are copied into a
+// freshly created table.
+func migrateFeatureVersions(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable(&Feature{}) {
+		return nil
+	}
 
-	return nil
+	columns, err := migrator.ColumnTypes(&Feature{})
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, column := range columns {
+		if strings.EqualFold(column.Name(), "version") {
+			if primary, ok := column.PrimaryKey(); ok && primary {
+				return nil
+			}
+		}
+		names = append(names, column.Name())
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("CREATE TABLE features_unversioned AS SELECT * FROM features").Error; err != nil {
+			return err
+		}
+		if err := tx.Migrator().DropTable(&Feature{}); err != nil {
+			return err
+		}
+		if err := tx.Migrator().CreateTable(&Feature{}); err != nil {
+			return err
+		}
+
+		// Rows without a version become version 1
+		quoted := make([]string, len(names))
+		selects := make([]string, len(names))
+		for i, name := range names {
+			quoted[i] = tx.Statement.Quote(name)
+			selects[i] = quoted[i]
+			if strings.EqualFold(name, "version") {
+				selects[i] = fmt.Sprintf("CASE WHEN %[1]s > 0 THEN %[1]s ELSE 1 END", quoted[i])
+			}
+		}
+		copyRows := fmt.Sprintf("INSERT INTO features (%s) SELECT %s FROM features_unversioned",
+			strings.Join(quoted, ", "), strings.Join(selects, ", "))
+		if err := tx.Exec(copyRows).Error; err != nil {
+			return err
+		}
+
+		return tx.Migrator().DropTable("features_unversioned")
+	})
 }
 
-// GetFeature gets a feature by ID
+// GetFeature gets the latest version of a feature by ID
 func (fs *FeatureStore) GetFeature(ctx context.Context, featureID string) (*Feature, error) {
 	// Check cache first
 	fs.mu.RLock()
-	if cached, exists := fs.cache[featureID]; exists {
-		if cached.ExpiresAt == nil || time.Now().Before(*cached.ExpiresAt) {
-			fs.mu.RUnlock()
-			return cached, nil
-		}
-	}
+	entry, exists := fs.cache[featureID]
 	fs.mu.RUnlock()
+	if exists && fs.cacheValid(entry, time.Now()) {
+		return entry.feature, nil
+	}
 
-	// Get from database
+	// Get latest version from database
 	var feature Feature
-	if err := fs.db.WithContext(ctx).Where("id = ?", featureID).First(&feature).Error; err != nil {
+	if err := fs.db.WithContext(ctx).Where("id = ?", featureID).Order("version DESC").First(&feature).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, fmt.Errorf("feature not found: %s", featureID)
 		}
 		return nil, err
 	}
 
-	// Update cache
+	fs.cacheFeatures(&feature)
+
+	return &feature, nil
+}
+
+// cacheFeatures stores features in the cache, stamped with the current time
+func (fs *FeatureStore) cacheFeatures(features ...*Feature) {
+	now := time.Now()
+
 	fs.mu.Lock()
-	fs.cache[featureID] = &feature
-	fs.mu.Unlock()
+	defer fs.mu.Unlock()
+	for _, feature := range features {
+		fs.cache[feature.ID] = &featureCacheEntry{feature: feature, cachedAt: now}
+	}
+}
 
+// cacheValid reports whether a cache entry is within the cache TTL and the feature has not expired
+func (fs *FeatureStore) cacheValid(entry *featureCacheEntry, now time.Time) bool {
+	if now.Sub(entry.cachedAt) >= fs.cacheTTL {
+		return false
+	}
+	return entry.feature.ExpiresAt == nil || now.Before(*entry.feature.ExpiresAt)
+}
+
+// GetFeatureVersion gets a specific version of a feature
+func (fs *FeatureStore) GetFeatureVersion(ctx context.Context, featureID string, version int) (*Feature, error) {
+	var feature Feature
+	if err := fs.db.WithContext(ctx).Where("id = ? AND version = ?", featureID, version).First(&feature).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("feature not found: %s (version %d)", featureID, version)
+		}
+		return nil, err
+	}
 	return &feature, nil
 }
 
-// GetFeaturesByEntity gets all features for an entity
+// GetFeaturesByEntity gets the latest version of every feature of an entity
 func (fs *FeatureStore) GetFeaturesByEntity(ctx context.Context, entityType, entityID string) ([]*Feature, error) {
 	var features []*Feature
 	if err := fs.db.WithContext(ctx).
 		Where("entity_type = ? AND entity_id = ?", entityType, entityID).
+		Where("version = (SELECT MAX(latest.version) FROM features latest WHERE latest.id = features.id)").
 		Find(&features).Error; err != nil {
 		return nil, err
 	}
 	return features, nil
 }
 
-// GetFeatureVector gets feature vector for entity
+// GetFeatureVector gets feature vector for entity using the latest feature values
 func (fs *FeatureStore) GetFeatureVector(ctx context.Context, entityType, entityID string, featureNames []string) (map[string]interface{}, error) {
-	features, err := fs.GetFeaturesByEntity(ctx, entityType, entityID)
-	if err != nil {
+	return fs.featureVector(ctx, entityType, entityID, featureNames, nil)
+}
+
+// GetFeatureVectorAsOf gets the feature vector as it was at asOf: for each name,
+// the most recent version computed at or before asOf. Features with no value
+// at that time are omitted. Requires KeepHistory for anything but the latest values.
+func (fs *FeatureStore) GetFeatureVectorAsOf(ctx context.Context, entityType, entityID string, featureNames []string, asOf time.Time) (map[string]interface{}, error) {
+	return fs.featureVector(ctx, entityType, entityID, featureNames, &asOf)
+}
+
+// featureVector builds a vector from the newest versions, optionally bounded by asOf
+func (fs *FeatureStore) featureVector(ctx context.Context, entityType, entityID string, featureNames []string, asOf *time.Time) (map[string]interface{}, error) {
+	query := fs.db.WithContext(ctx).
+		Where("entity_type = ? AND entity_id = ?", entityType, entityID).
+		Where("name IN ?", featureNames)
+	if asOf != nil {
+		query = query.Where("computed_at <= ?", *asOf)
+	}
+
+	var features []*Feature
+	if err := query.Order("computed_at DESC, version DESC").Find(&features).Error; err != nil {
 		return nil, err
 	}
 
-	vector := make(map[string]interface{})
-	featureMap := make(map[string]*Feature)
+	return buildVector(latestByName(features), featureNames), nil
+}
+
+// GetFeatureVectorsBatch gets the latest feature vectors of many entities,
+// keyed by entity ID. Entities whose features are all cached are served from
+// the cache; the rest are fetched with one query per BatchSize entities and
+// cached. Entities with none of the features get an empty vector.
+func (fs *FeatureStore) GetFeatureVectorsBatch(ctx context.Context, entityType string, entityIDs []string, featureNames []string) (map[string]map[string]interface{}, error) {
+	vectors := make(map[string]map[string]interface{}, len(entityIDs))
+
+	now := time.Now()
+	var uncached []string
+	for _, entityID := range entityIDs {
+		if _, seen := vectors[entityID]; seen {
+			continue
+		}
+		if latest, ok := fs.cachedLatest(entityType, entityID, featureNames, now); ok {
+			vectors[entityID] = buildVector(latest, featureNames)
+			continue
+		}
+		vectors[entityID] = nil
+		uncached = append(uncached, entityID)
+	}
+
+	for start := 0; start < len(uncached); start += fs.config.BatchSize {
+		end := start + fs.config.BatchSize
+		if end > len(uncached) {
+			end = len(uncached)
+		}
+		chunk := uncached[start:end]
+
+		var features []*Feature
+		if err := fs.db.WithContext(ctx).
+			Where("entity_type = ? AND entity_id IN ?", entityType, chunk).
+			Where("name IN ?", featureNames).
+			Order("computed_at DESC, version DESC").
+			Find(&features).Error; err != nil {
+			return nil, err
+		}
+
+		byEntity := make(map[string][]*Feature, len(chunk))
+		for _, f := range features {
+			byEntity[f.EntityID] = append(byEntity[f.EntityID], f)
+		}
+
+		for _, entityID := range chunk {
+			latest := latestByName(byEntity[entityID])
+			vectors[entityID] = buildVector(latest, featureNames)
+
+			cached := make([]*Feature, 0, len(latest))
+			for _, f := range latest {
+				cached = append(cached, f)
+			}
+			fs.cacheFeatures(cached...)
+		}
+	}
+
+	return vectors, nil
+}
+
+// cachedLatest returns an entity's cached features by name, if every name is
+// cached under its default feature ID
+func (fs *FeatureStore) cachedLatest(entityType, entityID string, featureNames []string, now time.Time) (map[string]*Feature, bool) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	latest := make(map[string]*Feature, len(featureNames))
+	for _, name := range featureNames {
+		entry, exists := fs.cache[fmt.Sprintf("%s:%s:%s", entityType, entityID, name)]
+		if !exists || !fs.cacheValid(entry, now) {
+			return nil, false
+		}
+		latest[name] = entry.feature
+	}
+	return latest, true
+}
+
+// latestByName picks the newest feature per name from features ordered
+// newest first
+func latestByName(features []*Feature) map[string]*Feature {
+	latest := make(map[string]*Feature)
 	for _, f := range features {
-		featureMap[f.Name] = f
+		// Rows are newest first; keep the first seen per name
+		if _, seen := latest[f.Name]; !seen {
+			latest[f.Name] = f
+		}
 	}
+	return latest
+}
 
+// buildVector merges the values of the named features, in name order
+func buildVector(latest map[string]*Feature, featureNames []string) map[string]interface{} {
+	vector := make(map[string]interface{})
 	for _, name := range featureNames {
-		if feature, exists := featureMap[name]; exists {
+		if feature, exists := latest[name]; exists {
 			for k, v := range feature.Values {
 				vector[k] = v
 			}
 		}
 	}
-
-	return vector, nil
+	return vector
 }
 
 // CreateFeatureGroup creates a feature group
@@ -185,23 +457,29 @@ func (fs *FeatureStore) BatchSetFeatures(ctx context.Context, features []*Featur
 	}()
 
 	for _, feature := range features {
-		if feature.ID == "" {
-			feature.ID = fmt.Sprintf("%s:%s:%s", feature.EntityType, feature.EntityID, feature.Name)
-		}
-		feature.ComputedAt = time.Now()
-
-		if err := tx.Save(feature).Error; err != nil {
+		if err := fs.saveFeature(tx, feature); err != nil {
 			tx.Rollback()
 			return err
 		}
+	}
 
-		// Update cache
-		fs.mu.Lock()
-		fs.cache[feature.ID] = feature
-		fs.mu.Unlock()
+	if err := tx.Commit().Error; err != nil {
+		return err
 	}
 
-	return tx.Commit().Error
+	// Only cache once the versions are committed
+	fs.cacheFeatures(features...)
+
+	return nil
+}
+
+// PruneFeatureHistory deletes superseded versions computed before cutoff.
+// The latest version of every feature is always kept.
+func (fs *FeatureStore) PruneFeatureHistory(ctx context.Context, cutoff time.Time) error {
+	return fs.db.WithContext(ctx).
+		Where("computed_at < ?", cutoff).
+		Where("version < (SELECT MAX(latest.version) FROM features latest WHERE latest.id = features.id)").
+		Delete(&Feature{}).Error
 }
 
 // DeleteExpiredFeatures deletes expired features
@@ -227,7 +505,6 @@ func (fs *FeatureStore) ComputeFeatures(ctx context.Context, entityType, entityI
 			Values: map[string]interface{}{
 				name: value,
 			},
-			Version: 1,
 		}
 		features = append(features, feature)
 	}
@@ -257,19 +534,27 @@ func (fs *FeatureStore) ImportFeatures(ctx context.Context, data []byte) error {
 	return fs.BatchSetFeatures(ctx, features)
 }
 
-// cleanupLoop periodically cleans up expired features
+// cleanupLoop periodically cleans up expired features. The cache is swept every
+// CacheTTL; database cleanup still runs hourly.
 func (fs *FeatureStore) cleanupLoop() {
-	ticker := time.NewTicker(1 * time.Hour)
+	ticker := time.NewTicker(fs.cacheTTL)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		ctx := context.Background()
-		fs.DeleteExpiredFeatures(ctx)
+	lastDBCleanup := time.Now()
+	for now := range ticker.C {
+		if now.Sub(lastDBCleanup) >= time.Hour {
+			ctx := context.Background()
+			fs.DeleteExpiredFeatures(ctx)
+			if fs.config.KeepHistory && fs.config.HistoryRetention > 0 {
+				fs.PruneFeatureHistory(ctx, now.Add(-fs.config.HistoryRetention))
+			}
+			lastDBCleanup = now
+		}
 
 		// Clean cache
 		fs.mu.Lock()
-		for id, feature := range fs.cache {
-			if feature.ExpiresAt != nil && time.Now().After(*feature.ExpiresAt) {
+		for id, entry := range fs.cache {
+			if !fs.cacheValid(entry, now) {
 				delete(fs.cache, id)
 			}
 		}
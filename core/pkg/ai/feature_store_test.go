@@ -0,0 +1,368 @@
+package ai
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTestFeatureStore(t *testing.T, config *FeatureStoreConfig) (*FeatureStore, *gorm.DB) {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "features.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	return NewFeatureStore(db, config), db
+}
+
+// setTestFeature stores a new value of a user feature and returns it
+func setTestFeature(t *testing.T, fs *FeatureStore, entityID, name string, value interface{}) *Feature {
+	t.Helper()
+
+	feature := &Feature{
+		Name:       name,
+		EntityType: "user",
+		EntityID:   entityID,
+		Values:     map[string]interface{}{name: value},
+	}
+	if err := fs.SetFeature(context.Background(), feature); err != nil {
+		t.Fatalf("SetFeature %s: %v", name, err)
+	}
+	// Keep computation times apart
+	time.Sleep(5 * time.Millisecond)
+	return feature
+}
+
+func TestGetFeatureVectorAsOf(t *testing.T) {
+	fs, _ := newTestFeatureStore(t, nil)
+	ctx := context.Background()
+
+	beforeAll := time.Now()
+	time.Sleep(5 * time.Millisecond)
+	setTestFeature(t, fs, "1", "score", float64(10))
+	setTestFeature(t, fs, "1", "country", "NL")
+	afterFirst := time.Now()
+	setTestFeature(t, fs, "1", "score", float64(20))
+	afterSecond := time.Now()
+	setTestFeature(t, fs, "1", "score", float64(30))
+
+	names := []string{"score", "country"}
+	tests := []struct {
+		name  string
+		asOf  time.Time
+		score interface{}
+	}{
+		{"after the first value", afterFirst, float64(10)},
+		{"after the second value", afterSecond, float64(20)},
+		{"now", time.Now(), float64(30)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vector, err := fs.GetFeatureVectorAsOf(ctx, "user", "1", names, tt.asOf)
+			if err != nil {
+				t.Fatalf("GetFeatureVectorAsOf: %v", err)
+			}
+			if vector["score"] != tt.score || vector["country"] != "NL" {
+				t.Fatalf("vector = %v, want score %v and country NL", vector, tt.score)
+			}
+		})
+	}
+
+	// Nothing was computed yet
+	vector, err := fs.GetFeatureVectorAsOf(ctx, "user", "1", names, beforeAll)
+	if err != nil {
+		t.Fatalf("GetFeatureVectorAsOf: %v", err)
+	}
+	if len(vector) != 0 {
+		t.Fatalf("vector before any computation = %v, want empty", vector)
+	}
+
+	latest, err := fs.GetFeatureVector(ctx, "user", "1", names)
+	if err != nil {
+		t.Fatalf("GetFeatureVector: %v", err)
+	}
+	if latest["score"] != float64(30) {
+		t.Fatalf("latest vector = %v, want score 30", latest)
+	}
+}
+
+func TestGetFeatureVectorAsOfWithoutHistory(t *testing.T) {
+	config := DefaultFeatureStoreConfig()
+	config.KeepHistory = false
+	fs, _ := newTestFeatureStore(t, config)
+
+	setTestFeature(t, fs, "1", "score", float64(10))
+	afterFirst := time.Now()
+	setTestFeature(t, fs, "1", "score", float64(20))
+
+	// The first value was overwritten, so there is nothing to return
+	vector, err := fs.GetFeatureVectorAsOf(context.Background(), "user", "1", []string{"score"}, afterFirst)
+	if err != nil {
+		t.Fatalf("GetFeatureVectorAsOf: %v", err)
+	}
+	if len(vector) != 0 {
+		t.Fatalf("vector = %v, want empty without history", vector)
+	}
+}
+
+func TestPruneFeatureHistoryKeepsLatest(t *testing.T) {
+	fs, db := newTestFeatureStore(t, nil)
+
+	setTestFeature(t, fs, "1", "score", float64(10))
+	setTestFeature(t, fs, "1", "score", float64(20))
+	setTestFeature(t, fs, "1", "score", float64(30))
+
+	if err := fs.PruneFeatureHistory(context.Background(), time.Now()); err != nil {
+		t.Fatalf("PruneFeatureHistory: %v", err)
+	}
+
+	var versions []int
+	db.Model(&Feature{}).Order("version").Pluck("version", &versions)
+	if len(versions) != 1 || versions[0] != 3 {
+		t.Fatalf("versions after pruning = %v, want only the latest", versions)
+	}
+}
+
+func TestSetFeatureIncrementsVersion(t *testing.T) {
+	fs, db := newTestFeatureStore(t, nil)
+	ctx := context.Background()
+
+	for i, value := range []float64{10, 20, 30} {
+		feature := setTestFeature(t, fs, "1", "score", value)
+		if feature.Version != i+1 {
+			t.Fatalf("value %d stored as version %d, want %d", i+1, feature.Version, i+1)
+		}
+	}
+
+	var rows int64
+	db.Model(&Feature{}).Where("id = ?", "user:1:score").Count(&rows)
+	if rows != 3 {
+		t.Fatalf("%d rows stored, want every version kept", rows)
+	}
+
+	latest, err := fs.GetFeature(ctx, "user:1:score")
+	if err != nil {
+		t.Fatalf("GetFeature: %v", err)
+	}
+	if latest.Version != 3 || latest.Values["score"] != float64(30) {
+		t.Fatalf("GetFeature = version %d %v, want version 3", latest.Version, latest.Values)
+	}
+
+	first, err := fs.GetFeatureVersion(ctx, "user:1:score", 1)
+	if err != nil {
+		t.Fatalf("GetFeatureVersion: %v", err)
+	}
+	if first.Values["score"] != float64(10) {
+		t.Fatalf("version 1 = %v, want 10", first.Values)
+	}
+
+	// Other features are versioned separately
+	if other := setTestFeature(t, fs, "2", "score", float64(1)); other.Version != 1 {
+		t.Fatalf("another entity's first value stored as version %d", other.Version)
+	}
+}
+
+func TestSetFeatureWithoutHistoryKeepsLatestRow(t *testing.T) {
+	config := DefaultFeatureStoreConfig()
+	config.KeepHistory = false
+	fs, db := newTestFeatureStore(t, config)
+
+	setTestFeature(t, fs, "1", "score", float64(10))
+	if feature := setTestFeature(t, fs, "1", "score", float64(20)); feature.Version != 2 {
+		t.Fatalf("second value stored as version %d, want 2", feature.Version)
+	}
+
+	var rows int64
+	db.Model(&Feature{}).Where("id = ?", "user:1:score").Count(&rows)
+	if rows != 1 {
+		t.Fatalf("%d rows stored, want only the latest", rows)
+	}
+}
+
+func TestBatchSetFeaturesVersionsEachFeature(t *testing.T) {
+	fs, _ := newTestFeatureStore(t, nil)
+	ctx := context.Background()
+	setTestFeature(t, fs, "1", "score", float64(10))
+
+	batch := []*Feature{
+		{Name: "score", EntityType: "user", EntityID: "1", Values: map[string]interface{}{"score": float64(20)}},
+		{Name: "country", EntityType: "user", EntityID: "1", Values: map[string]interface{}{"country": "NL"}},
+	}
+	if err := fs.BatchSetFeatures(ctx, batch); err != nil {
+		t.Fatalf("BatchSetFeatures: %v", err)
+	}
+	if batch[0].Version != 2 || batch[1].Version != 1 {
+		t.Fatalf("batch versions = %d, %d; want 2, 1", batch[0].Version, batch[1].Version)
+	}
+}
+
+func TestGetFeatureRefetchesAfterCacheTTL(t *testing.T) {
+	config := DefaultFeatureStoreConfig()
+	config.CacheTTL = 50 * time.Millisecond
+	fs, db := newTestFeatureStore(t, config)
+	ctx := context.Background()
+
+	// No ExpiresAt, so only the cache TTL bounds how long it is served
+	setTestFeature(t, fs, "1", "score", float64(10))
+	if err := db.Model(&Feature{}).Where("id = ?", "user:1:score").
+		Update("values", `{"score":20}`).Error; err != nil {
+		t.Fatalf("update feature: %v", err)
+	}
+
+	cached, err := fs.GetFeature(ctx, "user:1:score")
+	if err != nil {
+		t.Fatalf("GetFeature: %v", err)
+	}
+	if cached.Values["score"] != float64(10) {
+		t.Fatalf("GetFeature within the TTL = %v, want the cached 10", cached.Values)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	refetched, err := fs.GetFeature(ctx, "user:1:score")
+	if err != nil {
+		t.Fatalf("GetFeature: %v", err)
+	}
+	if refetched.Values["score"] != float64(20) {
+		t.Fatalf("GetFeature after the TTL = %v, want 20 from the database", refetched.Values)
+	}
+}
+
+func TestCleanupLoopEvictsStaleCacheEntries(t *testing.T) {
+	config := DefaultFeatureStoreConfig()
+	config.CacheTTL = 20 * time.Millisecond
+	fs, _ := newTestFeatureStore(t, config)
+
+	setTestFeature(t, fs, "1", "score", float64(10))
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		stats, err := fs.GetStats(context.Background())
+		if err != nil {
+			t.Fatalf("GetStats: %v", err)
+		}
+		if stats["cache_size"] == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("cache still holds %v entries after the TTL", stats["cache_size"])
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// countFeatureQueries counts the queries made on the features table
+func countFeatureQueries(t *testing.T, db *gorm.DB) *int {
+	t.Helper()
+
+	var queries int
+	err := db.Callback().Query().Before("gorm:query").Register("test:count_feature_queries", func(tx *gorm.DB) {
+		if tx.Statement.Table == "features" {
+			queries++
+		}
+	})
+	if err != nil {
+		t.Fatalf("register query callback: %v", err)
+	}
+	return &queries
+}
+
+// seedBatchFeatures stores score for users 1-5 and country for users 1-4,
+// with a second score for user 1, and returns a store with an empty cache
+func seedBatchFeatures(t *testing.T, batchSize int) (*FeatureStore, *gorm.DB) {
+	t.Helper()
+
+	config := DefaultFeatureStoreConfig()
+	config.BatchSize = batchSize
+	fs, db := newTestFeatureStore(t, config)
+	for i, id := range []string{"1", "2", "3", "4", "5"} {
+		setTestFeature(t, fs, id, "score", float64(i+1))
+		if id != "5" {
+			setTestFeature(t, fs, id, "country", "NL")
+		}
+	}
+	setTestFeature(t, fs, "1", "score", float64(100))
+
+	return NewFeatureStore(db, config), db
+}
+
+func TestGetFeatureVectorsBatchMatchesSingle(t *testing.T) {
+	fs, db := seedBatchFeatures(t, 2)
+	ctx := context.Background()
+	queries := countFeatureQueries(t, db)
+
+	names := []string{"score", "country"}
+	ids := []string{"1", "2", "3", "4", "5", "missing", "1"}
+	vectors, err := fs.GetFeatureVectorsBatch(ctx, "user", ids, names)
+	if err != nil {
+		t.Fatalf("GetFeatureVectorsBatch: %v", err)
+	}
+
+	// Six distinct entities in chunks of two
+	if *queries != 3 {
+		t.Fatalf("batch made %d queries, want one per chunk (3)", *queries)
+	}
+	if len(vectors) != 6 {
+		t.Fatalf("got %d vectors, want one per distinct entity", len(vectors))
+	}
+
+	for _, id := range ids {
+		single, err := fs.GetFeatureVector(ctx, "user", id, names)
+		if err != nil {
+			t.Fatalf("GetFeatureVector %s: %v", id, err)
+		}
+		batch := vectors[id]
+		if batch == nil || len(batch) != len(single) {
+			t.Fatalf("user %s: batch vector %v, single %v", id, batch, single)
+		}
+		for k, v := range single {
+			if batch[k] != v {
+				t.Fatalf("user %s: batch vector %v, single %v", id, batch, single)
+			}
+		}
+	}
+	if vectors["1"]["score"] != float64(100) || len(vectors["5"]) != 1 || len(vectors["missing"]) != 0 {
+		t.Fatalf("vectors = %v, want the latest score, a partial vector for 5 and an empty one for missing", vectors)
+	}
+}
+
+func TestGetFeatureVectorsBatchUsesCache(t *testing.T) {
+	fs, db := seedBatchFeatures(t, 500)
+	ctx := context.Background()
+	names := []string{"score", "country"}
+	ids := []string{"1", "2", "3", "4", "5"}
+
+	if _, err := fs.GetFeatureVectorsBatch(ctx, "user", ids, names); err != nil {
+		t.Fatalf("GetFeatureVectorsBatch: %v", err)
+	}
+
+	if err := db.Model(&Feature{}).Where("entity_id = ? AND name = ?", "2", "score").
+		Update("values", `{"score":20}`).Error; err != nil {
+		t.Fatalf("update feature: %v", err)
+	}
+	queries := countFeatureQueries(t, db)
+
+	// Users 1-4 have every feature cached; only 5 is fetched again
+	vectors, err := fs.GetFeatureVectorsBatch(ctx, "user", ids, names)
+	if err != nil {
+		t.Fatalf("GetFeatureVectorsBatch: %v", err)
+	}
+	if *queries != 1 {
+		t.Fatalf("batch made %d queries, want 1 for the partly cached entity", *queries)
+	}
+	if vectors["2"]["score"] != float64(2) || vectors["5"]["score"] != float64(5) {
+		t.Fatalf("vectors = %v, want the cached score for 2", vectors)
+	}
+
+	// Fully cached batches don't query at all
+	if _, err := fs.GetFeatureVectorsBatch(ctx, "user", ids[:4], names); err != nil {
+		t.Fatalf("GetFeatureVectorsBatch: %v", err)
+	}
+	if *queries != 1 {
+		t.Fatalf("cached batch made %d more queries", *queries-1)
+	}
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"neonexcore/pkg/errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+// BodyLimitConfig limits request bodies and the shape of JSON payloads
+type BodyLimitConfig struct {
+	MaxBodySize    int            // Bytes; larger bodies get 413. 0 disables the check
+	MaxJSONDepth   int            // Deepest object/array nesting; 0 disables the check
+	MaxJSONArray   int            // Most elements in a single JSON array; 0 disables the check
+	RouteBodySizes map[string]int // MaxBodySize overrides by path prefix; the longest prefix wins
+}
+
+// DefaultBodyLimitConfig returns the default body limits
+func DefaultBodyLimitConfig() BodyLimitConfig {
+	return BodyLimitConfig{
+		MaxBodySize:  1 << 20, // 1 MB
+		MaxJSONDepth: 32,
+		MaxJSONArray: 10000,
+	}
+}
+
+// RequestConfig raises the server's body read limit for routes with a
+// larger override, leaving every other route at the server's limit. Set it
+// as the server's HeaderReceived hook, e.g. app.Server().HeaderReceived, so
+// large bodies are only buffered for the routes that accept them.
+func (cfg BodyLimitConfig) RequestConfig(header *fasthttp.RequestHeader) fasthttp.RequestConfig {
+	path, _, _ := strings.Cut(string(header.RequestURI()), "?")
+	limit, matched := cfg.routeBodySize(path)
+	if !matched || limit <= 0 {
+		return fasthttp.RequestConfig{}
+	}
+	return fasthttp.RequestConfig{MaxRequestBodySize: limit}
+}
+
+// bodySizeFor returns the body size limit of a path
+func (cfg BodyLimitConfig) bodySizeFor(path string) int {
+	if limit, matched := cfg.routeBodySize(path); matched {
+		return limit
+	}
+	return cfg.MaxBodySize
+}
+
+// routeBodySize returns the override of the longest prefix matching path
+func (cfg BodyLimitConfig) routeBodySize(path string) (int, bool) {
+	limit := 0
+	matched := -1
+	for prefix, size := range cfg.RouteBodySizes {
+		if strings.HasPrefix(path, prefix) && len(prefix) > matched {
+			limit = size
+			matched = len(prefix)
+		}
+	}
+	return limit, matched >= 0
+}
+
+// BodyLimitMiddleware rejects bodies larger than the route's limit with 413
+// and JSON bodies nested too deeply or with too large arrays with 400,
+// before any handler parses them. Malformed JSON is left to the handler.
+func BodyLimitMiddleware(config ...BodyLimitConfig) fiber.Handler {
+	cfg := DefaultBodyLimitConfig()
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+
+	return func(c *fiber.Ctx) error {
+		limit := cfg.bodySizeFor(c.Path())
+		if limit > 0 {
+			if c.Request().Header.ContentLength() > limit || len(c.Request().Body()) > limit {
+				return bodyTooLarge(limit)
+			}
+		}
+
+		if !isJSON(c.Get(fiber.HeaderContentType)) {
+			return c.Next()
+		}
+
+		// Body decompresses Content-Encoding, so check the decoded size too
+		body := c.Body()
+		if limit > 0 && len(body) > limit {
+			return bodyTooLarge(limit)
+		}
+		if len(body) == 0 {
+			return c.Next()
+		}
+
+		if err := checkJSONShape(body, cfg.MaxJSONDepth, cfg.MaxJSONArray); err != nil {
+			return err
+		}
+		return c.Next()
+	}
+}
+
+func bodyTooLarge(limit int) *errors.AppError {
+	return errors.NewPayloadTooLarge(fmt.Sprintf("Request body exceeds %d bytes", limit))
+}
+
+// isJSON reports whether a content type is JSON, the way BodyParser decides
+func isJSON(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	return strings.HasSuffix(strings.ToLower(strings.TrimSpace(mediaType)), "json")
+}
+
+// checkJSONShape walks the JSON tokens without building values and fails
+// once the nesting or an array grows past its limit
+func checkJSONShape(body []byte, maxDepth, maxArray int) error {
+	type level struct {
+		array    bool
+		elements int
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	var stack []level
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return nil // Let the handler report the syntax error
+		}
+
+		delim, isDelim := tok.(json.Delim)
+
+		// Every value directly inside an array is an element
+		if n := len(stack); n > 0 && stack[n-1].array && (!isDelim || delim == '{' || delim == '[') {
+			stack[n-1].elements++
+			if maxArray > 0 && stack[n-1].elements > maxArray {
+				return errors.NewBadRequest(fmt.Sprintf("JSON arrays may have at most %d elements", maxArray))
+			}
+		}
+
+		if !isDelim {
+			continue
+		}
+		switch delim {
+		case '{', '[':
+			stack = append(stack, level{array: delim == '['})
+			if maxDepth > 0 && len(stack) > maxDepth {
+				return errors.NewBadRequest(fmt.Sprintf("JSON may be nested at most %d levels deep", maxDepth))
+			}
+		case '}', ']':
+			stack = stack[:len(stack)-1]
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"neonexcore/pkg/errors"
+	"neonexcore/pkg/logger"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+func newBodyLimitTestApp(config BodyLimitConfig) *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: errors.ErrorHandler(logger.Default())})
+	app.Use(BodyLimitMiddleware(config))
+	app.Post("/*", noContent)
+	return app
+}
+
+func TestBodyLimitMiddleware(t *testing.T) {
+	config := BodyLimitConfig{
+		MaxBodySize:    1024,
+		MaxJSONDepth:   4,
+		MaxJSONArray:   3,
+		RouteBodySizes: map[string]int{"/uploads": 4096, "/uploads/small": 16},
+	}
+
+	tests := []struct {
+		name        string
+		path        string
+		contentType string
+		body        string
+		want        int
+	}{
+		{"small body", "/items", fiber.MIMEApplicationJSON, `{"name":"widget"}`, fiber.StatusNoContent},
+		{"oversized body", "/items", fiber.MIMETextPlain, strings.Repeat("a", 1025), fiber.StatusRequestEntityTooLarge},
+		{"route override", "/uploads/file", fiber.MIMEOctetStream, strings.Repeat("a", 4096), fiber.StatusNoContent},
+		{"over the route override", "/uploads/file", fiber.MIMEOctetStream, strings.Repeat("a", 4097), fiber.StatusRequestEntityTooLarge},
+		{"longest prefix wins", "/uploads/small", fiber.MIMEOctetStream, strings.Repeat("a", 17), fiber.StatusRequestEntityTooLarge},
+		{"nested at the limit", "/items", fiber.MIMEApplicationJSON, `{"a":{"b":[{"c":1}]}}`, fiber.StatusNoContent},
+		{"nested too deeply", "/items", fiber.MIMEApplicationJSON, `{"a":{"b":[{"c":[1]}]}}`, fiber.StatusBadRequest},
+		{"deeply nested arrays", "/items", "application/vnd.api+json", strings.Repeat("[", 100) + strings.Repeat("]", 100), fiber.StatusBadRequest},
+		{"array at the limit", "/items", fiber.MIMEApplicationJSON, `[1,{"a":2},[3]]`, fiber.StatusNoContent},
+		{"array too large", "/items", fiber.MIMEApplicationJSON, `{"ids":[1,2,3,4]}`, fiber.StatusBadRequest},
+		{"nested array too large", "/items", fiber.MIMEApplicationJSON, `[[1,2,3,4]]`, fiber.StatusBadRequest},
+		// Only JSON bodies are inspected; syntax errors are left to the handler
+		{"not JSON", "/items", fiber.MIMETextPlain, strings.Repeat("[", 100), fiber.StatusNoContent},
+		{"malformed JSON", "/items", fiber.MIMEApplicationJSON, `{"a":`, fiber.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newBodyLimitTestApp(config)
+			req := httptest.NewRequest(fiber.MethodPost, tt.path, strings.NewReader(tt.body))
+			req.Header.Set(fiber.HeaderContentType, tt.contentType)
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("POST %s: %v", tt.path, err)
+			}
+			if resp.StatusCode != tt.want {
+				t.Fatalf("status %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
+
+func TestBodyLimitMiddlewareChecksDecodedSize(t *testing.T) {
+	app := newBodyLimitTestApp(BodyLimitConfig{MaxBodySize: 1024})
+
+	// Compresses to far less than the limit
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	zw.Write([]byte(`{"name":"` + strings.Repeat("a", 4096) + `"}`))
+	zw.Close()
+	if compressed.Len() >= 1024 {
+		t.Fatalf("compressed body is %d bytes", compressed.Len())
+	}
+
+	req := httptest.NewRequest(fiber.MethodPost, "/items", &compressed)
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	req.Header.Set(fiber.HeaderContentEncoding, "gzip")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("POST /items: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusRequestEntityTooLarge {
+		t.Fatalf("status %d, want 413", resp.StatusCode)
+	}
+}
+
+func TestBodyLimitRequestConfig(t *testing.T) {
+	config := BodyLimitConfig{MaxBodySize: 1024, RouteBodySizes: map[string]int{"/uploads": 4096, "/uploads/off": 0}}
+
+	tests := []struct {
+		uri  string
+		want int
+	}{
+		{"/uploads/file", 4096},
+		{"/uploads/file?name=a", 4096},
+		{"/items", 0},
+		{"/items?next=/uploads", 0},
+		{"/uploads/off", 0},
+	}
+	for _, tt := range tests {
+		var header fasthttp.RequestHeader
+		header.SetRequestURI(tt.uri)
+		if got := config.RequestConfig(&header).MaxRequestBodySize; got != tt.want {
+			t.Errorf("RequestConfig(%s).MaxRequestBodySize = %d, want %d", tt.uri, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+// Response encodings supported by CompressionMiddleware
+const (
+	EncodingBrotli  = "br"
+	EncodingGzip    = "gzip"
+	EncodingDeflate = "deflate"
+)
+
+// CompressionConfig configures response compression
+type CompressionConfig struct {
+	MinSize   int      // Smallest body in bytes worth compressing
+	Encodings []string // Offered encodings, most preferred first
+}
+
+// DefaultCompressionConfig returns the default compression config
+func DefaultCompressionConfig() CompressionConfig {
+	return CompressionConfig{
+		MinSize:   1024,
+		Encodings: []string{EncodingBrotli, EncodingGzip, EncodingDeflate},
+	}
+}
+
+// CompressionMiddleware compresses responses with the best encoding the
+// client accepts. Only responses of compressible content types at least
+// MinSize bytes long are compressed; responses that already have a
+// Content-Encoding are left alone.
+func CompressionMiddleware(config ...CompressionConfig) fiber.Handler {
+	cfg := DefaultCompressionConfig()
+	if len(config) > 0 {
+		cfg = config[0]
+		if len(cfg.Encodings) == 0 {
+			cfg.Encodings = DefaultCompressionConfig().Encodings
+		}
+	}
+
+	return func(c *fiber.Ctx) error {
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		resp := c.Response()
+		if !isCompressible(string(resp.Header.ContentType())) {
+			return nil
+		}
+
+		// The representation depends on Accept-Encoding even when this
+		// response isn't compressed, so caches must key on it
+		c.Vary(fiber.HeaderAcceptEncoding)
+
+		status := resp.StatusCode()
+		if c.Method() == fiber.MethodHead || status < 200 || status == fiber.StatusNoContent ||
+			status == fiber.StatusPartialContent || status == fiber.StatusNotModified {
+			return nil
+		}
+		if len(resp.Header.Peek(fiber.HeaderContentEncoding)) > 0 || resp.IsBodyStream() {
+			return nil
+		}
+
+		body := resp.Body()
+		if len(body) < cfg.MinSize {
+			return nil
+		}
+
+		encoding := negotiateEncoding(c.Get(fiber.HeaderAcceptEncoding), cfg.Encodings)
+		if encoding == "" {
+			return nil
+		}
+
+		var compressed []byte
+		switch encoding {
+		case EncodingBrotli:
+			compressed = fasthttp.AppendBrotliBytesLevel(nil, body, fasthttp.CompressBrotliDefaultCompression)
+		case EncodingGzip:
+			compressed = fasthttp.AppendGzipBytesLevel(nil, body, fasthttp.CompressDefaultCompression)
+		case EncodingDeflate:
+			compressed = fasthttp.AppendDeflateBytesLevel(nil, body, fasthttp.CompressDefaultCompression)
+		}
+		if len(compressed) == 0 || len(compressed) >= len(body) {
+			return nil
+		}
+
+		resp.SetBodyRaw(compressed)
+		resp.Header.Set(fiber.HeaderContentEncoding, encoding)
+
+		// The compressed bytes differ from what a strong ETag describes
+		if etag := string(resp.Header.Peek(fiber.HeaderETag)); etag != "" && !strings.HasPrefix(etag, "W/") {
+			resp.Header.Set(fiber.HeaderETag, "W/"+etag)
+		}
+		return nil
+	}
+}
+
+// compressibleTypes are the content types worth compressing, besides text/*
+var compressibleTypes = []string{
+	"application/json",
+	"application/javascript",
+	"application/xml",
+	"application/x-ndjson",
+	"application/graphql-response+json",
+	"image/svg+xml",
+}
+
+// isCompressible reports whether a content type is worth compressing
+func isCompressible(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+
+	if strings.HasPrefix(mediaType, "text/") ||
+		strings.HasSuffix(mediaType, "+json") || strings.HasSuffix(mediaType, "+xml") {
+		return true
+	}
+	return containsString(compressibleTypes, mediaType)
+}
+
+// negotiateEncoding picks the offered encoding with the highest q-value in
+// an Accept-Encoding header, preferring earlier offers on ties. It returns
+// "" when the client accepts none of them.
+func negotiateEncoding(header string, offers []string) string {
+	if header == "" {
+		return ""
+	}
+
+	accepted := make(map[string]float64)
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if ok && strings.EqualFold(key, "q") {
+				if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		accepted[name] = q
+	}
+
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		q, ok := accepted[offer]
+		if !ok {
+			q, ok = accepted["*"]
+		}
+		if ok && q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
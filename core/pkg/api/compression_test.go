@@ -0,0 +1,168 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// largeJSON is a compressible JSON document well over the default MinSize
+var largeJSON = `{"items":[` + strings.TrimSuffix(strings.Repeat(`{"name":"widget","price":10},`, 200), ",") + `]}`
+
+func newCompressionTestApp() *fiber.App {
+	app := fiber.New()
+	app.Use(CompressionMiddleware())
+	app.Get("/large", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderETag, `"v1"`)
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		return c.SendString(largeJSON)
+	})
+	app.Get("/small", func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"ok": true})
+	})
+	app.Get("/image", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, "image/png")
+		return c.Send(bytes.Repeat([]byte{0}, 4096))
+	})
+	app.Get("/encoded", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		c.Set(fiber.HeaderContentEncoding, EncodingGzip)
+		return c.Send(gzipBytes([]byte(largeJSON)))
+	})
+	return app
+}
+
+func gzipBytes(data []byte) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(data)
+	zw.Close()
+	return buf.Bytes()
+}
+
+func getCompressed(t *testing.T, app *fiber.App, path, acceptEncoding string) *http.Response {
+	t.Helper()
+
+	req := httptest.NewRequest(fiber.MethodGet, path, nil)
+	if acceptEncoding != "" {
+		req.Header.Set(fiber.HeaderAcceptEncoding, acceptEncoding)
+	}
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("GET %s: %v", path, err)
+	}
+	return resp
+}
+
+// decodeBody returns a response body with its Content-Encoding undone
+func decodeBody(t *testing.T, resp *http.Response) string {
+	t.Helper()
+
+	var r io.Reader = resp.Body
+	switch resp.Header.Get(fiber.HeaderContentEncoding) {
+	case EncodingGzip:
+		zr, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			t.Fatalf("gzip reader: %v", err)
+		}
+		r = zr
+	case EncodingDeflate:
+		// HTTP deflate is zlib-wrapped
+		zr, err := zlib.NewReader(resp.Body)
+		if err != nil {
+			t.Fatalf("zlib reader: %v", err)
+		}
+		r = zr
+	}
+	body, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+	return string(body)
+}
+
+func TestCompressionMiddleware(t *testing.T) {
+	tests := []struct {
+		name           string
+		path           string
+		acceptEncoding string
+		want           string
+	}{
+		{"large JSON gzip", "/large", "gzip", EncodingGzip},
+		{"large JSON deflate", "/large", "deflate", EncodingDeflate},
+		{"large JSON brotli preferred", "/large", "gzip, deflate, br", EncodingBrotli},
+		{"q-values", "/large", "br;q=0.5, gzip;q=0.8", EncodingGzip},
+		{"refused encoding", "/large", "gzip;q=0", ""},
+		{"no Accept-Encoding", "/large", "", ""},
+		{"small JSON", "/small", "gzip", ""},
+		{"incompressible type", "/image", "gzip", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := getCompressed(t, newCompressionTestApp(), tt.path, tt.acceptEncoding)
+			if got := resp.Header.Get(fiber.HeaderContentEncoding); got != tt.want {
+				t.Fatalf("Content-Encoding = %q, want %q", got, tt.want)
+			}
+			if tt.path != "/image" && resp.Header.Get(fiber.HeaderVary) != fiber.HeaderAcceptEncoding {
+				t.Fatalf("Vary = %q, want Accept-Encoding", resp.Header.Get(fiber.HeaderVary))
+			}
+			if tt.path == "/large" && tt.want != EncodingBrotli {
+				if body := decodeBody(t, resp); body != largeJSON {
+					t.Fatalf("decoded body is %d bytes, want the original %d", len(body), len(largeJSON))
+				}
+			}
+		})
+	}
+}
+
+func TestCompressionMiddlewareWeakensETag(t *testing.T) {
+	app := newCompressionTestApp()
+
+	if etag := getCompressed(t, app, "/large", "gzip").Header.Get(fiber.HeaderETag); etag != `W/"v1"` {
+		t.Fatalf("compressed ETag = %s, want it weakened", etag)
+	}
+	if etag := getCompressed(t, app, "/large", "").Header.Get(fiber.HeaderETag); etag != `"v1"` {
+		t.Fatalf("uncompressed ETag = %s, want it unchanged", etag)
+	}
+}
+
+func TestCompressionMiddlewareSkipsEncodedResponses(t *testing.T) {
+	resp := getCompressed(t, newCompressionTestApp(), "/encoded", "gzip, deflate")
+	if got := resp.Header.Get(fiber.HeaderContentEncoding); got != EncodingGzip {
+		t.Fatalf("Content-Encoding = %q, want gzip", got)
+	}
+	// Decoding once gives back the JSON, so it wasn't compressed twice
+	if body := decodeBody(t, resp); body != largeJSON {
+		t.Fatal("already compressed response was compressed again")
+	}
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	offers := DefaultCompressionConfig().Encodings
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", ""},
+		{"identity", ""},
+		{"gzip", EncodingGzip},
+		{"GZIP", EncodingGzip},
+		{"deflate, gzip", EncodingGzip},
+		{"*", EncodingBrotli},
+		{"*;q=0.1, deflate", EncodingDeflate},
+		{"br;q=0, *", EncodingGzip},
+		{"gzip;q=0.5, deflate;q=0.5", EncodingGzip},
+	}
+	for _, tt := range tests {
+		if got := negotiateEncoding(tt.header, offers); got != tt.want {
+			t.Errorf("negotiateEncoding(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"neonexcore/pkg/errors"
+)
+
+// KeysetOptions configures keyset (cursor) pagination. Column must be an
+// indexed column from a caller-side whitelist; rows are ordered by Column
+// and then by id so that equal column values still page deterministically.
+type KeysetOptions struct {
+	Column string // Cursor column, e.g. "id" or "created_at"
+	Desc   bool
+	Limit  int
+	Cursor string // Opaque cursor from the previous page's next_cursor
+}
+
+// KeysetPage describes where the next page starts
+type KeysetPage struct {
+	NextCursor string
+	HasMore    bool
+}
+
+// KeyFunc returns a row's cursor column value and id
+type KeyFunc[T any] func(row *T) (value interface{}, id uint)
+
+// cursorPayload is the decoded form of an opaque cursor
+type cursorPayload struct {
+	Kind  string          `json:"k"`
+	Value json.RawMessage `json:"v"`
+	ID    uint            `json:"id"`
+}
+
+// HasCursor reports whether the request opted into cursor pagination by
+// sending a cursor query parameter (empty for the first page)
+func HasCursor(c *fiber.Ctx) bool {
+	return c.Context().QueryArgs().Has("cursor")
+}
+
+// KeysetPaginate returns one page of rows after the cursor. Unlike OFFSET
+// pagination its cost doesn't grow with depth, and rows inserted
+// concurrently are never skipped or repeated.
+func KeysetPaginate[T any](db *gorm.DB, opts KeysetOptions, key KeyFunc[T]) ([]*T, KeysetPage, error) {
+	if opts.Column == "" {
+		opts.Column = "id"
+	}
+	if opts.Limit < 1 {
+		opts.Limit = 10
+	}
+
+	column := clause.Column{Table: clause.CurrentTable, Name: opts.Column}
+	idColumn := clause.Column{Table: clause.CurrentTable, Name: "id"}
+
+	query := db
+	if opts.Cursor != "" {
+		value, id, err := decodeCursor(opts.Cursor)
+		if err != nil {
+			return nil, KeysetPage{}, err
+		}
+
+		op := ">"
+		if opts.Desc {
+			op = "<"
+		}
+		if opts.Column == "id" {
+			query = query.Where(fmt.Sprintf("? %s ?", op), idColumn, id)
+		} else {
+			query = query.Where(
+				fmt.Sprintf("? %s ? OR (? = ? AND ? %s ?)", op, op),
+				column, value, column, value, idColumn, id,
+			)
+		}
+	}
+
+	query = query.Order(clause.OrderByColumn{Column: column, Desc: opts.Desc})
+	if opts.Column != "id" {
+		query = query.Order(clause.OrderByColumn{Column: idColumn, Desc: opts.Desc})
+	}
+
+	// Fetch one extra row to learn whether another page exists
+	var rows []*T
+	if err := query.Limit(opts.Limit + 1).Find(&rows).Error; err != nil {
+		return nil, KeysetPage{}, err
+	}
+
+	page := KeysetPage{}
+	if len(rows) > opts.Limit {
+		rows = rows[:opts.Limit]
+		page.HasMore = true
+
+		value, id := key(rows[len(rows)-1])
+		cursor, err := encodeCursor(value, id)
+		if err != nil {
+			return nil, KeysetPage{}, err
+		}
+		page.NextCursor = cursor
+	}
+
+	return rows, page, nil
+}
+
+// encodeCursor builds an opaque cursor from a column value and id. Times
+// keep their type so they compare correctly on every database driver.
+func encodeCursor(value interface{}, id uint) (string, error) {
+	payload := cursorPayload{ID: id}
+
+	var raw interface{}
+	switch v := value.(type) {
+	case time.Time:
+		payload.Kind = "time"
+		raw = v.UTC().Format(time.RFC3339Nano)
+	case string:
+		payload.Kind = "string"
+		raw = v
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		payload.Kind = "int"
+		raw = v
+	default:
+		return "", fmt.Errorf("unsupported cursor value type %T", value)
+	}
+
+	encodedValue, err := json.Marshal(raw)
+	if err != nil {
+		return "", err
+	}
+	payload.Value = encodedValue
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor parses a cursor produced by encodeCursor
+func decodeCursor(cursor string) (interface{}, uint, error) {
+	invalid := errors.NewBadRequest("Invalid cursor")
+
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, 0, invalid
+	}
+
+	var payload cursorPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, 0, invalid
+	}
+
+	switch payload.Kind {
+	case "time":
+		var s string
+		if err := json.Unmarshal(payload.Value, &s); err != nil {
+			return nil, 0, invalid
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return nil, 0, invalid
+		}
+		return t, payload.ID, nil
+	case "string":
+		var s string
+		if err := json.Unmarshal(payload.Value, &s); err != nil {
+			return nil, 0, invalid
+		}
+		return s, payload.ID, nil
+	case "int":
+		var n int64
+		if err := json.Unmarshal(payload.Value, &n); err != nil {
+			return nil, 0, invalid
+		}
+		return n, payload.ID, nil
+	default:
+		return nil, 0, invalid
+	}
+}
+
+// CursorPaginated sends a keyset-paginated response
+func CursorPaginated(c *fiber.Ctx, data interface{}, limit int, page KeysetPage) error {
+	hasMore := page.HasMore
+	return c.JSON(Response{
+		Success: true,
+		Data:    data,
+		Meta: &Meta{
+			Limit:      limit,
+			NextCursor: page.NextCursor,
+			HasMore:    &hasMore,
+		},
+		Timestamp: time.Now().Unix(),
+	})
+}
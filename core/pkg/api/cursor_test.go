@@ -0,0 +1,200 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"neonexcore/pkg/errors"
+
+	"github.com/glebarez/sqlite"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type pageItem struct {
+	ID        uint
+	Name      string
+	CreatedAt time.Time
+}
+
+func pageItemKey(item *pageItem) (interface{}, uint) { return item.CreatedAt, item.ID }
+
+// newPageItemDB seeds count items; every three share a created_at so the
+// id tiebreak is exercised
+func newPageItemDB(t *testing.T, count int) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "pages.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(&pageItem{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < count; i++ {
+		item := &pageItem{Name: fmt.Sprintf("item-%d", i), CreatedAt: base.Add(time.Duration(i/3) * time.Minute)}
+		if err := db.Create(item).Error; err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+	return db
+}
+
+// keysetPages walks every page with the given options
+func keysetPages(t *testing.T, db *gorm.DB, opts KeysetOptions, key KeyFunc[pageItem]) [][]uint {
+	t.Helper()
+
+	var pages [][]uint
+	for {
+		rows, page, err := KeysetPaginate(db.Model(&pageItem{}), opts, key)
+		if err != nil {
+			t.Fatalf("KeysetPaginate: %v", err)
+		}
+		pages = append(pages, pageItemIDs(rows))
+		if !page.HasMore {
+			if page.NextCursor != "" {
+				t.Fatalf("last page has next cursor %q", page.NextCursor)
+			}
+			return pages
+		}
+		opts.Cursor = page.NextCursor
+	}
+}
+
+// offsetPages walks every page with OFFSET/LIMIT in the same order
+func offsetPages(t *testing.T, db *gorm.DB, order string, limit int) [][]uint {
+	t.Helper()
+
+	var pages [][]uint
+	for offset := 0; ; offset += limit {
+		var rows []*pageItem
+		if err := db.Order(order).Offset(offset).Limit(limit).Find(&rows).Error; err != nil {
+			t.Fatalf("offset page: %v", err)
+		}
+		if len(rows) == 0 {
+			return pages
+		}
+		pages = append(pages, pageItemIDs(rows))
+	}
+}
+
+func pageItemIDs(rows []*pageItem) []uint {
+	ids := make([]uint, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID
+	}
+	return ids
+}
+
+func TestKeysetPaginateMatchesOffset(t *testing.T) {
+	db := newPageItemDB(t, 25)
+
+	tests := []struct {
+		name  string
+		opts  KeysetOptions
+		key   KeyFunc[pageItem]
+		order string
+	}{
+		{"id ascending", KeysetOptions{Limit: 10}, func(item *pageItem) (interface{}, uint) { return item.ID, item.ID }, "id"},
+		{"id descending", KeysetOptions{Limit: 7, Desc: true}, func(item *pageItem) (interface{}, uint) { return item.ID, item.ID }, "id desc"},
+		{"created_at ascending", KeysetOptions{Column: "created_at", Limit: 4}, pageItemKey, "created_at, id"},
+		{"created_at descending", KeysetOptions{Column: "created_at", Limit: 4, Desc: true}, pageItemKey, "created_at desc, id desc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := fmt.Sprint(offsetPages(t, db, tt.order, tt.opts.Limit))
+			if got := fmt.Sprint(keysetPages(t, db, tt.opts, tt.key)); got != want {
+				t.Fatalf("cursor pages = %s, want the offset pages %s", got, want)
+			}
+		})
+	}
+}
+
+func TestKeysetPaginateStableUnderInserts(t *testing.T) {
+	db := newPageItemDB(t, 10)
+	opts := KeysetOptions{Column: "created_at", Limit: 5, Desc: true}
+
+	var offsetFirst []*pageItem
+	if err := db.Order("created_at desc, id desc").Limit(5).Find(&offsetFirst).Error; err != nil {
+		t.Fatalf("offset page: %v", err)
+	}
+	cursorFirst, page, err := KeysetPaginate(db.Model(&pageItem{}), opts, pageItemKey)
+	if err != nil {
+		t.Fatalf("KeysetPaginate: %v", err)
+	}
+
+	// New rows arrive between the first and second page
+	for i := 0; i < 2; i++ {
+		if err := db.Create(&pageItem{Name: "new", CreatedAt: time.Now().UTC()}).Error; err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+	}
+
+	var offsetSecond []*pageItem
+	if err := db.Order("created_at desc, id desc").Offset(5).Limit(5).Find(&offsetSecond).Error; err != nil {
+		t.Fatalf("offset page: %v", err)
+	}
+	if offsetSecond[0].ID != offsetFirst[3].ID {
+		t.Fatalf("offset second page starts at %d, want the shifted row %d repeated", offsetSecond[0].ID, offsetFirst[3].ID)
+	}
+
+	opts.Cursor = page.NextCursor
+	cursorSecond, _, err := KeysetPaginate(db.Model(&pageItem{}), opts, pageItemKey)
+	if err != nil {
+		t.Fatalf("KeysetPaginate: %v", err)
+	}
+	got := fmt.Sprint(append(pageItemIDs(cursorFirst), pageItemIDs(cursorSecond)...))
+	if got != "[10 9 8 7 6 5 4 3 2 1]" {
+		t.Fatalf("cursor pages = %s, want every original row once", got)
+	}
+}
+
+func TestKeysetPaginateInvalidCursor(t *testing.T) {
+	db := newPageItemDB(t, 1)
+
+	for _, cursor := range []string{"not base64!", "bm90IGpzb24", "eyJrIjoiYm9vbCIsInYiOnRydWUsImlkIjoxfQ"} {
+		_, _, err := KeysetPaginate(db.Model(&pageItem{}), KeysetOptions{Cursor: cursor}, pageItemKey)
+		appErr, ok := errors.GetAppError(err)
+		if !ok || appErr.StatusCode != fiber.StatusBadRequest {
+			t.Fatalf("cursor %q: error = %v, want a 400", cursor, err)
+		}
+	}
+}
+
+func TestCursorPaginatedMeta(t *testing.T) {
+	db := newPageItemDB(t, 3)
+
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		opts := KeysetOptions{Limit: 2, Cursor: c.Query("cursor")}
+		rows, page, err := KeysetPaginate(db.Model(&pageItem{}), opts, pageItemKey)
+		if err != nil {
+			return err
+		}
+		if !HasCursor(c) {
+			return fiber.ErrBadRequest
+		}
+		return CursorPaginated(c, rows, opts.Limit, page)
+	})
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/?cursor=", nil))
+	if err != nil {
+		t.Fatalf("GET /: %v", err)
+	}
+	var body struct {
+		Success bool                     `json:"success"`
+		Data    []map[string]interface{} `json:"data"`
+		Meta    map[string]interface{}   `json:"meta"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if !body.Success || len(body.Data) != 2 || body.Meta["has_more"] != true || body.Meta["next_cursor"] == "" {
+		t.Fatalf("body = %+v, want two rows, has_more and next_cursor", body)
+	}
+}
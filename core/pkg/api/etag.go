@@ -0,0 +1,56 @@
+package api
+
+import (
+	"strconv"
+	"strings"
+
+	"neonexcore/pkg/errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// VersionETag formats a record version as an ETag, e.g. 3 -> "3"
+func VersionETag(version uint) string {
+	return `"` + strconv.FormatUint(uint64(version), 10) + `"`
+}
+
+// SetVersionETag sets the ETag header for a versioned record, so clients can
+// send it back in If-Match to make their update conditional
+func SetVersionETag(c *fiber.Ctx, version uint) {
+	c.Set(fiber.HeaderETag, VersionETag(version))
+}
+
+// IfMatchVersion returns the record version an update is conditional on,
+// from the If-Match header. ok is false when the request has no If-Match or
+// uses "*". Weak ETags (W/"3") are accepted.
+func IfMatchVersion(c *fiber.Ctx) (version uint, ok bool, err error) {
+	header := strings.TrimSpace(c.Get(fiber.HeaderIfMatch))
+	if header == "" || header == "*" {
+		return 0, false, nil
+	}
+
+	tag := strings.TrimPrefix(header, "W/")
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, false, errors.NewBadRequest("Invalid If-Match header")
+	}
+
+	parsed, err := strconv.ParseUint(tag[1:len(tag)-1], 10, 0)
+	if err != nil {
+		return 0, false, errors.NewBadRequest("Invalid If-Match header")
+	}
+	return uint(parsed), true, nil
+}
+
+// ExpectedVersion returns the version a conditional update expects: the
+// If-Match header, or else the version sent in the request body. ok is false
+// for unconditional updates.
+func ExpectedVersion(c *fiber.Ctx, bodyVersion *uint) (version uint, ok bool, err error) {
+	version, ok, err = IfMatchVersion(c)
+	if err != nil || ok {
+		return version, ok, err
+	}
+	if bodyVersion != nil {
+		return *bodyVersion, true, nil
+	}
+	return 0, false, nil
+}
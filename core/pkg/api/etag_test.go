@@ -0,0 +1,65 @@
+package api
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestExpectedVersion(t *testing.T) {
+	bodyVersion := uint(4)
+	tests := []struct {
+		name        string
+		ifMatch     string
+		body        *uint
+		want        uint
+		conditional bool
+		wantErr     bool
+	}{
+		{"unconditional", "", nil, 0, false, false},
+		{"any version", "*", nil, 0, false, false},
+		{"strong ETag", `"3"`, nil, 3, true, false},
+		{"weak ETag", `W/"3"`, nil, 3, true, false},
+		{"header wins over body", `"3"`, &bodyVersion, 3, true, false},
+		{"body version", "", &bodyVersion, 4, true, false},
+		{"unquoted", "3", nil, 0, false, true},
+		{"not a number", `"abc"`, nil, 0, false, true},
+		{"negative", `"-1"`, nil, 0, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			var (
+				version     uint
+				conditional bool
+				err         error
+			)
+			app.Put("/", func(c *fiber.Ctx) error {
+				version, conditional, err = ExpectedVersion(c, tt.body)
+				return nil
+			})
+
+			req := httptest.NewRequest(fiber.MethodPut, "/", nil)
+			if tt.ifMatch != "" {
+				req.Header.Set(fiber.HeaderIfMatch, tt.ifMatch)
+			}
+			if _, testErr := app.Test(req); testErr != nil {
+				t.Fatalf("request: %v", testErr)
+			}
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if version != tt.want || conditional != tt.conditional {
+				t.Fatalf("ExpectedVersion = %d, %v; want %d, %v", version, conditional, tt.want, tt.conditional)
+			}
+		})
+	}
+}
+
+func TestVersionETag(t *testing.T) {
+	if got := VersionETag(12); got != `"12"` {
+		t.Fatalf("VersionETag(12) = %s", got)
+	}
+}
@@ -0,0 +1,182 @@
+package api
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"neonexcore/pkg/errors"
+)
+
+// FilterOperator is a comparison supported by filter query params
+type FilterOperator string
+
+const (
+	FilterEq   FilterOperator = "eq"
+	FilterGte  FilterOperator = "gte"
+	FilterLte  FilterOperator = "lte"
+	FilterLike FilterOperator = "like"
+	FilterIn   FilterOperator = "in"
+)
+
+// FilterField declares a filterable field of a list endpoint
+type FilterField struct {
+	Column    string                                  // Database column; defaults to the param name
+	Operators []FilterOperator                        // Allowed operators; defaults to all
+	Parse     func(value string) (interface{}, error) // Optional value conversion, e.g. to bool or time
+}
+
+// FilterFields maps filter param names to their fields. Only declared
+// fields can be filtered on.
+type FilterFields map[string]FilterField
+
+// Filter is a single parsed filter condition
+type Filter struct {
+	Column   string
+	Operator FilterOperator
+	Value    interface{} // []interface{} for FilterIn
+}
+
+// Filters is a list of parsed filter conditions
+type Filters []Filter
+
+// ParseFilters parses filter[field]=value and filter[field][op]=value query
+// params against the allowed fields, e.g.
+//
+//	?filter[status]=active&filter[price][gte]=10&filter[role][in]=admin,editor
+//
+// Unknown fields and operators are rejected with a bad request error.
+func ParseFilters(c *fiber.Ctx, fields FilterFields) (Filters, error) {
+	var params []string
+	values := make(map[string]string)
+	c.Context().QueryArgs().VisitAll(func(key, value []byte) {
+		k := string(key)
+		if strings.HasPrefix(k, "filter[") {
+			params = append(params, k)
+			values[k] = string(value)
+		}
+	})
+	// Query arg order is client-controlled; keep the generated SQL stable
+	sort.Strings(params)
+
+	filters := make(Filters, 0, len(params))
+	for _, param := range params {
+		name, operator, ok := parseFilterParam(param)
+		if !ok {
+			return nil, errors.NewBadRequest("Invalid filter parameter: " + param)
+		}
+
+		field, ok := fields[name]
+		if !ok {
+			return nil, errors.NewBadRequest("Unknown filter field: " + name)
+		}
+		if !field.allows(operator) {
+			return nil, errors.NewBadRequest("Unsupported filter operator " + string(operator) + " for " + name)
+		}
+
+		filter := Filter{Column: field.Column, Operator: operator}
+		if filter.Column == "" {
+			filter.Column = name
+		}
+
+		if operator == FilterIn {
+			var list []interface{}
+			for _, item := range strings.Split(values[param], ",") {
+				value, err := field.parse(strings.TrimSpace(item))
+				if err != nil {
+					return nil, errors.NewBadRequest("Invalid filter value for " + name)
+				}
+				list = append(list, value)
+			}
+			filter.Value = list
+		} else {
+			value, err := field.parse(values[param])
+			if err != nil {
+				return nil, errors.NewBadRequest("Invalid filter value for " + name)
+			}
+			filter.Value = value
+		}
+
+		filters = append(filters, filter)
+	}
+
+	return filters, nil
+}
+
+// parseFilterParam splits filter[name] or filter[name][op]
+func parseFilterParam(param string) (string, FilterOperator, bool) {
+	rest := strings.TrimPrefix(param, "filter[")
+	name, rest, ok := strings.Cut(rest, "]")
+	if !ok || name == "" {
+		return "", "", false
+	}
+	if rest == "" {
+		return name, FilterEq, true
+	}
+	if !strings.HasPrefix(rest, "[") || !strings.HasSuffix(rest, "]") {
+		return "", "", false
+	}
+	operator := FilterOperator(strings.ToLower(rest[1 : len(rest)-1]))
+	switch operator {
+	case FilterEq, FilterGte, FilterLte, FilterLike, FilterIn:
+		return name, operator, true
+	}
+	return "", "", false
+}
+
+func (f FilterField) allows(operator FilterOperator) bool {
+	if len(f.Operators) == 0 {
+		return true
+	}
+	for _, op := range f.Operators {
+		if op == operator {
+			return true
+		}
+	}
+	return false
+}
+
+func (f FilterField) parse(value string) (interface{}, error) {
+	if f.Parse == nil {
+		return value, nil
+	}
+	return f.Parse(value)
+}
+
+// Apply adds the filter conditions to a query. Columns are quoted and
+// values are always bound as parameters.
+func (f Filters) Apply(db *gorm.DB) *gorm.DB {
+	for _, filter := range f {
+		column := clause.Column{Table: clause.CurrentTable, Name: filter.Column}
+		switch filter.Operator {
+		case FilterEq:
+			db = db.Where(clause.Eq{Column: column, Value: filter.Value})
+		case FilterGte:
+			db = db.Where(clause.Gte{Column: column, Value: filter.Value})
+		case FilterLte:
+			db = db.Where(clause.Lte{Column: column, Value: filter.Value})
+		case FilterIn:
+			values, _ := filter.Value.([]interface{})
+			db = db.Where(clause.IN{Column: column, Values: values})
+		case FilterLike:
+			db = db.Where(clause.Expr{
+				SQL:  "? LIKE ? ESCAPE '!'",
+				Vars: []interface{}{column, "%" + escapeLike(filter.Value) + "%"},
+			})
+		}
+	}
+	return db
+}
+
+// Scope returns the filters as a GORM scope, e.g. for database.QueryOptions
+func (f Filters) Scope() func(*gorm.DB) *gorm.DB {
+	return f.Apply
+}
+
+// escapeLike escapes LIKE wildcards so like filters match literally
+func escapeLike(value interface{}) string {
+	s, _ := value.(string)
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(s)
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"neonexcore/pkg/errors"
+
+	"github.com/glebarez/sqlite"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type filterProduct struct {
+	ID       uint
+	Name     string
+	Status   string
+	Price    int
+	Internal string
+}
+
+var filterProductFields = FilterFields{
+	"name":   {Operators: []FilterOperator{FilterEq, FilterLike}},
+	"status": {Operators: []FilterOperator{FilterEq, FilterIn}},
+	"price": {
+		Parse: func(value string) (interface{}, error) { return strconv.Atoi(value) },
+	},
+	"state": {Column: "status"},
+}
+
+func newFilterProductDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "filter.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(&filterProduct{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	products := []filterProduct{
+		{Name: "Red shirt", Status: "active", Price: 5},
+		{Name: "Blue shirt", Status: "active", Price: 10},
+		{Name: "Green hat", Status: "draft", Price: 15},
+		{Name: "100% wool hat", Status: "archived", Price: 20},
+	}
+	if err := db.Create(&products).Error; err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	return db
+}
+
+// filterProducts parses query against filterProductFields and returns the
+// ids of the matching products
+func filterProducts(t *testing.T, db *gorm.DB, query string) ([]uint, error) {
+	t.Helper()
+
+	var filters Filters
+	var parseErr error
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		filters, parseErr = ParseFilters(c, filterProductFields)
+		return nil
+	})
+	if _, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/?"+query, nil)); err != nil {
+		t.Fatalf("GET /?%s: %v", query, err)
+	}
+	if parseErr != nil {
+		return nil, parseErr
+	}
+
+	var ids []uint
+	if err := db.Model(&filterProduct{}).Scopes(filters.Scope()).Order("id").Pluck("id", &ids).Error; err != nil {
+		t.Fatalf("query %s: %v", query, err)
+	}
+	return ids, nil
+}
+
+func TestParseFiltersOperators(t *testing.T) {
+	db := newFilterProductDB(t)
+
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"none", "", "[1 2 3 4]"},
+		{"eq", "filter[status]=active", "[1 2]"},
+		{"explicit eq", "filter[status][eq]=draft", "[3]"},
+		{"gte", "filter[price][gte]=10", "[2 3 4]"},
+		{"lte", "filter[price][lte]=10", "[1 2]"},
+		{"range", "filter[price][gte]=10&filter[price][lte]=15", "[2 3]"},
+		{"like", "filter[name][like]=shirt", "[1 2]"},
+		{"like is literal", "filter[name][like]=" + url.QueryEscape("100%"), "[4]"},
+		{"like wildcard", "filter[name][like]=_", "[]"},
+		{"in", "filter[status][in]=draft,+archived", "[3 4]"},
+		{"aliased column", "filter[state]=draft", "[3]"},
+		{"combined", "filter[status]=active&filter[price][gte]=10", "[2]"},
+		{"values are bound", "filter[status]=" + url.QueryEscape("active' OR '1'='1"), "[]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids, err := filterProducts(t, db, tt.query)
+			if err != nil {
+				t.Fatalf("ParseFilters: %v", err)
+			}
+			if got := fmt.Sprint(ids); got != tt.want {
+				t.Fatalf("matched %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFiltersRejectsInvalidFilters(t *testing.T) {
+	db := newFilterProductDB(t)
+
+	tests := []struct {
+		name    string
+		query   string
+		message string
+	}{
+		{"non-whitelisted column", "filter[internal]=secret", "Unknown filter field: internal"},
+		{"injected column", "filter[" + url.QueryEscape("id) OR (1=1") + "]=1", "Unknown filter field"},
+		{"disallowed operator", "filter[status][gte]=a", "Unsupported filter operator gte for status"},
+		{"unknown operator", "filter[price][ne]=5", "Invalid filter parameter"},
+		{"malformed param", "filter[price=5", "Invalid filter parameter"},
+		{"unparsable value", "filter[price][gte]=cheap", "Invalid filter value for price"},
+		{"unparsable list value", "filter[price][in]=5,x", "Invalid filter value for price"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := filterProducts(t, db, tt.query)
+			appErr, ok := errors.GetAppError(err)
+			if !ok || appErr.StatusCode != fiber.StatusBadRequest || !strings.Contains(appErr.Message, tt.message) {
+				t.Fatalf("error = %v, want a 400 containing %q", err, tt.message)
+			}
+		})
+	}
+}
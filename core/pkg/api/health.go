@@ -1,13 +1,25 @@
 package api
 
 import (
+	"context"
+	"fmt"
 	"runtime"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
+	"neonexcore/pkg/cache"
 )
 
+// DefaultHealthCheckTimeout bounds each health check run
+const DefaultHealthCheckTimeout = 5 * time.Second
+
+// DefaultPoolSaturation is the share of the database pool in use at which
+// the database check fails
+const DefaultPoolSaturation = 0.95
+
 // HealthStatus represents the health status of a component
 type HealthStatus string
 
@@ -24,6 +36,7 @@ type HealthCheck struct {
 	Timestamp int64                  `json:"timestamp"`
 	Uptime    float64                `json:"uptime_seconds"`
 	Checks    map[string]CheckResult `json:"checks"`
+	Failing   []string               `json:"failing,omitempty"`
 }
 
 // CheckResult represents the result of a single health check
@@ -33,12 +46,19 @@ type CheckResult struct {
 	Details interface{}  `json:"details,omitempty"`
 }
 
+// CheckFunc is a context-aware health check. It should return promptly
+// once ctx is done.
+type CheckFunc func(ctx context.Context) CheckResult
+
 // HealthChecker performs health checks
 type HealthChecker struct {
 	startTime time.Time
 	version   string
 	db        *gorm.DB
-	checks    map[string]func() CheckResult
+	timeout   time.Duration
+	poolLimit float64 // Share of MaxOpenConnections in use that fails the database check
+	mu        sync.RWMutex
+	checks    map[string]CheckFunc
 }
 
 // NewHealthChecker creates a new health checker
@@ -47,50 +67,191 @@ func NewHealthChecker(version string, db *gorm.DB) *HealthChecker {
 		startTime: time.Now(),
 		version:   version,
 		db:        db,
-		checks:    make(map[string]func() CheckResult),
+		timeout:   DefaultHealthCheckTimeout,
+		poolLimit: DefaultPoolSaturation,
+		checks:    make(map[string]CheckFunc),
 	}
 
 	// Register default checks
-	hc.RegisterCheck("database", hc.checkDatabase)
+	hc.RegisterCheckFunc("database", hc.checkDatabase)
 	hc.RegisterCheck("memory", hc.checkMemory)
 	hc.RegisterCheck("goroutines", hc.checkGoroutines)
 
 	return hc
 }
 
+// SetTimeout sets how long a health check run may take. Checks still
+// running when it expires are reported unhealthy.
+func (hc *HealthChecker) SetTimeout(timeout time.Duration) {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	hc.timeout = timeout
+}
+
+// SetPoolSaturation sets the share of the database pool's open connection
+// limit, between 0 and 1, that may be in use before the database check
+// fails. 0 disables the check. Pools without a limit never fail it.
+func (hc *HealthChecker) SetPoolSaturation(ratio float64) {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	hc.poolLimit = ratio
+}
+
 // RegisterCheck registers a custom health check
 func (hc *HealthChecker) RegisterCheck(name string, check func() CheckResult) {
+	hc.RegisterCheckFunc(name, func(context.Context) CheckResult {
+		return check()
+	})
+}
+
+// RegisterCheckFunc registers a context-aware health check, replacing any
+// check with the same name
+func (hc *HealthChecker) RegisterCheckFunc(name string, check CheckFunc) {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
 	hc.checks[name] = check
 }
 
-// Check performs all health checks
-func (hc *HealthChecker) Check() HealthCheck {
-	checks := make(map[string]CheckResult)
-	overallStatus := HealthStatusHealthy
+// RegisterErrorCheck registers a check that is healthy when fn returns nil
+func (hc *HealthChecker) RegisterErrorCheck(name string, fn func(ctx context.Context) error) {
+	hc.RegisterCheckFunc(name, func(ctx context.Context) CheckResult {
+		if err := fn(ctx); err != nil {
+			return CheckResult{
+				Status:  HealthStatusUnhealthy,
+				Message: err.Error(),
+			}
+		}
+		return CheckResult{Status: HealthStatusHealthy}
+	})
+}
+
+// UnregisterCheck removes a health check
+func (hc *HealthChecker) UnregisterCheck(name string) {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	delete(hc.checks, name)
+}
 
+// Check runs all health checks concurrently within the configured timeout
+func (hc *HealthChecker) Check(ctx context.Context) HealthCheck {
+	hc.mu.RLock()
+	timeout := hc.timeout
+	checks := make(map[string]CheckFunc, len(hc.checks))
 	for name, check := range hc.checks {
-		result := check()
-		checks[name] = result
+		checks[name] = check
+	}
+	hc.mu.RUnlock()
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	type namedResult struct {
+		name   string
+		result CheckResult
+	}
+	// Buffered so checks that outlive the timeout don't block forever
+	results := make(chan namedResult, len(checks))
+	for name, check := range checks {
+		go func(name string, check CheckFunc) {
+			results <- namedResult{name, runCheck(ctx, check)}
+		}(name, check)
+	}
 
+	collected := make(map[string]CheckResult, len(checks))
+	for len(collected) < len(checks) {
+		select {
+		case r := <-results:
+			collected[r.name] = r.result
+		case <-ctx.Done():
+			for name := range checks {
+				if _, ok := collected[name]; !ok {
+					collected[name] = CheckResult{
+						Status:  HealthStatusUnhealthy,
+						Message: "Health check timed out",
+					}
+				}
+			}
+		}
+	}
+
+	overallStatus := HealthStatusHealthy
+	failing := make([]string, 0)
+	for name, result := range collected {
 		// Update overall status
 		if result.Status == HealthStatusUnhealthy {
 			overallStatus = HealthStatusUnhealthy
+			failing = append(failing, name)
 		} else if result.Status == HealthStatusDegraded && overallStatus == HealthStatusHealthy {
 			overallStatus = HealthStatusDegraded
 		}
 	}
+	sort.Strings(failing)
 
 	return HealthCheck{
 		Status:    overallStatus,
 		Version:   hc.version,
 		Timestamp: time.Now().Unix(),
 		Uptime:    time.Since(hc.startTime).Seconds(),
-		Checks:    checks,
+		Checks:    collected,
+		Failing:   failing,
+	}
+}
+
+// runCheck runs a single check, turning a panic into an unhealthy result
+func runCheck(ctx context.Context, check CheckFunc) (result CheckResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = CheckResult{
+				Status:  HealthStatusUnhealthy,
+				Message: fmt.Sprintf("Health check panicked: %v", r),
+			}
+		}
+	}()
+	return check(ctx)
+}
+
+// CacheCheck returns a health check that round-trips a key through c
+func CacheCheck(c cache.Cache) CheckFunc {
+	return func(ctx context.Context) CheckResult {
+		if c == nil {
+			return CheckResult{
+				Status:  HealthStatusUnhealthy,
+				Message: "Cache not configured",
+			}
+		}
+
+		key := "health:ping"
+		if err := c.Set(ctx, key, "pong", time.Minute); err != nil {
+			return CheckResult{
+				Status:  HealthStatusUnhealthy,
+				Message: "Cache write failed",
+				Details: map[string]interface{}{
+					"error": err.Error(),
+				},
+			}
+		}
+		if _, err := c.Get(ctx, key); err != nil {
+			return CheckResult{
+				Status:  HealthStatusUnhealthy,
+				Message: "Cache read failed",
+				Details: map[string]interface{}{
+					"error": err.Error(),
+				},
+			}
+		}
+
+		return CheckResult{
+			Status:  HealthStatusHealthy,
+			Message: "Cache is healthy",
+		}
 	}
 }
 
 // checkDatabase checks database connectivity
-func (hc *HealthChecker) checkDatabase() CheckResult {
+func (hc *HealthChecker) checkDatabase(ctx context.Context) CheckResult {
 	if hc.db == nil {
 		return CheckResult{
 			Status:  HealthStatusUnhealthy,
@@ -106,25 +267,44 @@ func (hc *HealthChecker) checkDatabase() CheckResult {
 		}
 	}
 
-	if err := sqlDB.Ping(); err != nil {
+	// Read the pool before pinging: the ping holds a connection itself and
+	// would block until the timeout on an exhausted pool
+	stats := sqlDB.Stats()
+	details := map[string]interface{}{
+		"open_connections": stats.OpenConnections,
+		"max_open":         stats.MaxOpenConnections,
+		"in_use":           stats.InUse,
+		"idle":             stats.Idle,
+		"wait_count":       stats.WaitCount,
+		"wait_duration_ms": stats.WaitDuration.Milliseconds(),
+	}
+
+	hc.mu.RLock()
+	poolLimit := hc.poolLimit
+	hc.mu.RUnlock()
+
+	if poolLimit > 0 && stats.MaxOpenConnections > 0 &&
+		float64(stats.InUse) >= poolLimit*float64(stats.MaxOpenConnections) {
+		return CheckResult{
+			Status:  HealthStatusUnhealthy,
+			Message: "Database connection pool exhausted",
+			Details: details,
+		}
+	}
+
+	if err := sqlDB.PingContext(ctx); err != nil {
+		details["error"] = err.Error()
 		return CheckResult{
 			Status:  HealthStatusUnhealthy,
 			Message: "Database ping failed",
-			Details: map[string]interface{}{
-				"error": err.Error(),
-			},
+			Details: details,
 		}
 	}
 
-	stats := sqlDB.Stats()
 	return CheckResult{
 		Status:  HealthStatusHealthy,
 		Message: "Database is healthy",
-		Details: map[string]interface{}{
-			"open_connections": stats.OpenConnections,
-			"in_use":           stats.InUse,
-			"idle":             stats.Idle,
-		},
+		Details: details,
 	}
 }
 
@@ -190,7 +370,7 @@ func (hc *HealthChecker) checkGoroutines() CheckResult {
 // HealthCheckHandler creates a health check endpoint handler
 func HealthCheckHandler(checker *HealthChecker) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		health := checker.Check()
+		health := checker.Check(c.UserContext())
 
 		statusCode := fiber.StatusOK
 		if health.Status == HealthStatusUnhealthy {
@@ -203,14 +383,16 @@ func HealthCheckHandler(checker *HealthChecker) fiber.Handler {
 	}
 }
 
-// ReadinessHandler creates a readiness check endpoint (simpler than health)
-func ReadinessHandler(db *gorm.DB) fiber.Handler {
+// ReadinessHandler creates a readiness check endpoint. It runs the same
+// checks as /health but only reports whether the app can take traffic.
+func ReadinessHandler(checker *HealthChecker) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		// Check database connectivity
-		sqlDB, err := db.DB()
-		if err != nil || sqlDB.Ping() != nil {
+		health := checker.Check(c.UserContext())
+
+		if health.Status == HealthStatusUnhealthy {
 			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
-				"ready": false,
+				"ready":   false,
+				"failing": health.Failing,
 			})
 		}
 
@@ -230,8 +412,8 @@ func LivenessHandler() fiber.Handler {
 }
 
 // SetupHealthRoutes sets up health check routes
-func SetupHealthRoutes(app *fiber.App, checker *HealthChecker, db *gorm.DB) {
+func SetupHealthRoutes(app *fiber.App, checker *HealthChecker) {
 	app.Get("/health", HealthCheckHandler(checker))
-	app.Get("/health/ready", ReadinessHandler(db))
+	app.Get("/health/ready", ReadinessHandler(checker))
 	app.Get("/health/live", LivenessHandler())
 }
@@ -0,0 +1,189 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func newTestHealthChecker(t *testing.T) *HealthChecker {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "health.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	return NewHealthChecker("1.0.0", db)
+}
+
+// getHealth requests path from an app serving the health routes and decodes
+// the JSON body into out
+func getHealth(t *testing.T, checker *HealthChecker, path string, out interface{}) int {
+	t.Helper()
+
+	app := fiber.New()
+	SetupHealthRoutes(app, checker)
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, path, nil), -1)
+	if err != nil {
+		t.Fatalf("GET %s: %v", path, err)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		t.Fatalf("decode %s: %v", path, err)
+	}
+	return resp.StatusCode
+}
+
+func TestHealthAllChecksPass(t *testing.T) {
+	checker := newTestHealthChecker(t)
+	checker.RegisterErrorCheck("cache", func(context.Context) error { return nil })
+
+	var health HealthCheck
+	if status := getHealth(t, checker, "/health", &health); status != fiber.StatusOK {
+		t.Fatalf("status = %d, want 200", status)
+	}
+	if health.Status != HealthStatusHealthy || len(health.Failing) != 0 {
+		t.Fatalf("health = %+v, want healthy", health)
+	}
+	for _, name := range []string{"database", "memory", "goroutines", "cache"} {
+		if _, ok := health.Checks[name]; !ok {
+			t.Fatalf("checks = %v, missing %s", health.Checks, name)
+		}
+	}
+
+	var ready map[string]interface{}
+	if status := getHealth(t, checker, "/health/ready", &ready); status != fiber.StatusOK || ready["ready"] != true {
+		t.Fatalf("ready: status %d, body %v; want 200 and ready", status, ready)
+	}
+}
+
+func TestHealthFailingCheckReturns503(t *testing.T) {
+	checker := newTestHealthChecker(t)
+	checker.RegisterErrorCheck("payments", func(context.Context) error {
+		return stderrors.New("gateway unreachable")
+	})
+	checker.RegisterCheck("search", func() CheckResult {
+		panic("index missing")
+	})
+
+	var health HealthCheck
+	if status := getHealth(t, checker, "/health", &health); status != fiber.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", status)
+	}
+	if health.Status != HealthStatusUnhealthy {
+		t.Fatalf("status = %s, want unhealthy", health.Status)
+	}
+	if len(health.Failing) != 2 || health.Failing[0] != "payments" || health.Failing[1] != "search" {
+		t.Fatalf("failing = %v, want [payments search]", health.Failing)
+	}
+	if got := health.Checks["payments"].Message; got != "gateway unreachable" {
+		t.Fatalf("payments message = %q", got)
+	}
+
+	var ready struct {
+		Ready   bool     `json:"ready"`
+		Failing []string `json:"failing"`
+	}
+	if status := getHealth(t, checker, "/health/ready", &ready); status != fiber.StatusServiceUnavailable || ready.Ready || len(ready.Failing) != 2 {
+		t.Fatalf("ready: status %d, body %+v; want 503 listing the failing checks", status, ready)
+	}
+
+	// Liveness doesn't depend on the checks
+	var live map[string]interface{}
+	if status := getHealth(t, checker, "/health/live", &live); status != fiber.StatusOK || live["alive"] != true {
+		t.Fatalf("live: status %d, body %v; want 200 and alive", status, live)
+	}
+}
+
+func TestHealthCheckTimeout(t *testing.T) {
+	checker := newTestHealthChecker(t)
+	checker.SetTimeout(50 * time.Millisecond)
+	checker.RegisterCheckFunc("slow", func(ctx context.Context) CheckResult {
+		<-ctx.Done()
+		time.Sleep(time.Second)
+		return CheckResult{Status: HealthStatusHealthy}
+	})
+
+	start := time.Now()
+	health := checker.Check(context.Background())
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("Check took %s, want it bounded by the timeout", elapsed)
+	}
+	if health.Status != HealthStatusUnhealthy || len(health.Failing) != 1 || health.Failing[0] != "slow" {
+		t.Fatalf("health = %+v, want only the slow check failing", health)
+	}
+	if got := health.Checks["slow"].Message; got != "Health check timed out" {
+		t.Fatalf("slow message = %q", got)
+	}
+}
+
+func TestHealthFailsOnExhaustedPool(t *testing.T) {
+	checker := newTestHealthChecker(t)
+	sqlDB, err := checker.db.DB()
+	if err != nil {
+		t.Fatalf("DB: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(2)
+	ctx := context.Background()
+
+	first, err := sqlDB.Conn(ctx)
+	if err != nil {
+		t.Fatalf("Conn: %v", err)
+	}
+	second, err := sqlDB.Conn(ctx)
+	if err != nil {
+		t.Fatalf("Conn: %v", err)
+	}
+
+	// The check reports the exhausted pool instead of waiting for a connection
+	start := time.Now()
+	result := checker.checkDatabase(ctx)
+	if result.Status != HealthStatusUnhealthy || result.Message != "Database connection pool exhausted" {
+		t.Fatalf("database check = %+v, want the pool exhausted", result)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("check took %s", elapsed)
+	}
+	if details, _ := result.Details.(map[string]interface{}); details["in_use"] != 2 || details["max_open"] != 2 {
+		t.Fatalf("details = %v", result.Details)
+	}
+
+	// One of two connections in use is under the 95% threshold
+	second.Close()
+	if result := checker.checkDatabase(ctx); result.Status != HealthStatusHealthy {
+		t.Fatalf("database check = %+v, want healthy", result)
+	}
+	checker.SetPoolSaturation(0.5)
+	if result := checker.checkDatabase(ctx); result.Status != HealthStatusUnhealthy {
+		t.Fatalf("database check at 50%% = %+v, want unhealthy", result)
+	}
+	first.Close()
+
+	var health HealthCheck
+	if status := getHealth(t, checker, "/health", &health); status != fiber.StatusOK {
+		t.Fatalf("status = %d after the pool drained, want 200", status)
+	}
+}
+
+func TestHealthIgnoresUnlimitedPool(t *testing.T) {
+	checker := newTestHealthChecker(t)
+	sqlDB, _ := checker.db.DB()
+	sqlDB.SetMaxOpenConns(0)
+
+	conn, err := sqlDB.Conn(context.Background())
+	if err != nil {
+		t.Fatalf("Conn: %v", err)
+	}
+	defer conn.Close()
+
+	if result := checker.checkDatabase(context.Background()); result.Status != HealthStatusHealthy {
+		t.Fatalf("database check = %+v, want healthy without a pool limit", result)
+	}
+}
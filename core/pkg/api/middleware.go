@@ -8,7 +8,8 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/cors"
 )
 
-// CORSConfig represents CORS configuration
+// CORSConfig represents CORS configuration. AllowOrigins accepts exact
+// origins, "*" and subdomain wildcards such as "https://*.example.com".
 type CORSConfig struct {
 	AllowOrigins     []string
 	AllowMethods     []string
@@ -36,12 +37,16 @@ func DefaultCORSConfig() CORSConfig {
 			"Accept",
 			"Authorization",
 			"X-Requested-With",
+			"X-Request-ID",
 			"API-Version",
+			"If-Match",
 		},
-		AllowCredentials: true,
+		AllowCredentials: false, // Browsers reject credentials with the "*" origin
 		ExposeHeaders: []string{
 			"Content-Length",
 			"API-Version",
+			"ETag",
+			"X-Request-ID",
 			"X-RateLimit-Limit",
 			"X-RateLimit-Remaining",
 			"X-RateLimit-Reset",
@@ -68,12 +73,16 @@ func ProductionCORSConfig(allowedOrigins []string) CORSConfig {
 			"Accept",
 			"Authorization",
 			"X-Requested-With",
+			"X-Request-ID",
 			"API-Version",
+			"If-Match",
 		},
 		AllowCredentials: true,
 		ExposeHeaders: []string{
 			"Content-Length",
 			"API-Version",
+			"ETag",
+			"X-Request-ID",
 			"X-RateLimit-Limit",
 			"X-RateLimit-Remaining",
 			"X-RateLimit-Reset",
@@ -86,11 +95,17 @@ func ProductionCORSConfig(allowedOrigins []string) CORSConfig {
 func CORSMiddleware(config ...CORSConfig) fiber.Handler {
 	cfg := DefaultCORSConfig()
 	if len(config) > 0 {
-		cfg = config[0]
+		cfg = mergeCORSConfig(cfg, config[0])
+	}
+
+	// Credentials can't be combined with a wildcard origin
+	if cfg.AllowCredentials && containsString(cfg.AllowOrigins, "*") {
+		cfg.AllowCredentials = false
 	}
 
 	return cors.New(cors.Config{
-		AllowOrigins:     joinStrings(cfg.AllowOrigins, ", "),
+		// No spaces: fiber locates subdomain wildcards before trimming
+		AllowOrigins:     joinStrings(cfg.AllowOrigins, ","),
 		AllowMethods:     joinStrings(cfg.AllowMethods, ", "),
 		AllowHeaders:     joinStrings(cfg.AllowHeaders, ", "),
 		AllowCredentials: cfg.AllowCredentials,
@@ -99,6 +114,32 @@ func CORSMiddleware(config ...CORSConfig) fiber.Handler {
 	})
 }
 
+// mergeCORSConfig fills list fields left empty in cfg from defaults
+func mergeCORSConfig(defaults, cfg CORSConfig) CORSConfig {
+	if len(cfg.AllowOrigins) == 0 {
+		cfg.AllowOrigins = defaults.AllowOrigins
+	}
+	if len(cfg.AllowMethods) == 0 {
+		cfg.AllowMethods = defaults.AllowMethods
+	}
+	if len(cfg.AllowHeaders) == 0 {
+		cfg.AllowHeaders = defaults.AllowHeaders
+	}
+	if len(cfg.ExposeHeaders) == 0 {
+		cfg.ExposeHeaders = defaults.ExposeHeaders
+	}
+	return cfg
+}
+
+func containsString(slice []string, value string) bool {
+	for _, s := range slice {
+		if s == value {
+			return true
+		}
+	}
+	return false
+}
+
 // SecurityHeadersMiddleware adds security headers
 func SecurityHeadersMiddleware() fiber.Handler {
 	return func(c *fiber.Ctx) error {
@@ -162,21 +203,6 @@ func LoggerMiddleware() fiber.Handler {
 	}
 }
 
-// CompressionMiddleware enables response compression
-func CompressionMiddleware() fiber.Handler {
-	return func(c *fiber.Ctx) error {
-		// Check if client accepts compression
-		acceptEncoding := c.Get("Accept-Encoding")
-		if acceptEncoding == "" {
-			return c.Next()
-		}
-
-		// Fiber has built-in compression, this is a placeholder
-		// In production, use fiber.Compress() middleware
-		return c.Next()
-	}
-}
-
 // Helper functions
 func joinStrings(slice []string, sep string) string {
 	if len(slice) == 0 {
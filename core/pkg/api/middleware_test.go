@@ -0,0 +1,110 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func newCORSTestApp() *fiber.App {
+	app := fiber.New()
+	app.Use(CORSMiddleware(CORSConfig{
+		AllowOrigins:     []string{"https://app.example.com", "https://*.example.org"},
+		AllowCredentials: true,
+		MaxAge:           600,
+	}))
+	app.Get("/users", noContent)
+	return app
+}
+
+func sendCORS(t *testing.T, app *fiber.App, req *http.Request) *http.Response {
+	t.Helper()
+
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", req.Method, req.URL, err)
+	}
+	return resp
+}
+
+func TestCORSAllowedOrigin(t *testing.T) {
+	app := newCORSTestApp()
+
+	for _, origin := range []string{"https://app.example.com", "https://shop.example.org"} {
+		req := httptest.NewRequest(fiber.MethodGet, "/users", nil)
+		req.Header.Set("Origin", origin)
+		resp := sendCORS(t, app, req)
+
+		if resp.StatusCode != fiber.StatusNoContent {
+			t.Fatalf("%s: status %d, want 204", origin, resp.StatusCode)
+		}
+		if got := resp.Header.Get("Access-Control-Allow-Origin"); got != origin {
+			t.Fatalf("%s: Access-Control-Allow-Origin = %q, want the origin echoed", origin, got)
+		}
+		if got := resp.Header.Get("Access-Control-Allow-Credentials"); got != "true" {
+			t.Fatalf("%s: Access-Control-Allow-Credentials = %q, want true", origin, got)
+		}
+		if resp.Header.Get("Access-Control-Expose-Headers") == "" {
+			t.Fatalf("%s: no exposed headers", origin)
+		}
+	}
+}
+
+func TestCORSDisallowedOrigin(t *testing.T) {
+	app := newCORSTestApp()
+
+	for _, origin := range []string{"https://evil.com", "https://example.org.evil.com", "https://e.evil-xample.org", "http://app.example.com"} {
+		req := httptest.NewRequest(fiber.MethodGet, "/users", nil)
+		req.Header.Set("Origin", origin)
+		resp := sendCORS(t, app, req)
+
+		if got := resp.Header.Get("Access-Control-Allow-Origin"); got != "" {
+			t.Fatalf("%s: Access-Control-Allow-Origin = %q, want none", origin, got)
+		}
+	}
+}
+
+func TestCORSPreflight(t *testing.T) {
+	app := newCORSTestApp()
+
+	req := httptest.NewRequest(fiber.MethodOptions, "/users", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", fiber.MethodPatch)
+	req.Header.Set("Access-Control-Request-Headers", "Authorization, Content-Type")
+	resp := sendCORS(t, app, req)
+
+	if resp.StatusCode != fiber.StatusNoContent {
+		t.Fatalf("status %d, want 204", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+		t.Fatalf("Access-Control-Allow-Origin = %q", got)
+	}
+	if got := resp.Header.Get("Access-Control-Allow-Methods"); got != "GET,POST,PUT,PATCH,DELETE,OPTIONS" {
+		t.Fatalf("Access-Control-Allow-Methods = %q, want the defaults", got)
+	}
+	if got := resp.Header.Get("Access-Control-Allow-Headers"); got == "" {
+		t.Fatal("no Access-Control-Allow-Headers")
+	}
+	if got := resp.Header.Get("Access-Control-Max-Age"); got != "600" {
+		t.Fatalf("Access-Control-Max-Age = %q, want 600", got)
+	}
+}
+
+func TestCORSWildcardDropsCredentials(t *testing.T) {
+	app := fiber.New()
+	app.Use(CORSMiddleware(CORSConfig{AllowOrigins: []string{"*"}, AllowCredentials: true}))
+	app.Get("/users", noContent)
+
+	req := httptest.NewRequest(fiber.MethodGet, "/users", nil)
+	req.Header.Set("Origin", "https://anywhere.dev")
+	resp := sendCORS(t, app, req)
+
+	if got := resp.Header.Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Fatalf("Access-Control-Allow-Origin = %q, want *", got)
+	}
+	if got := resp.Header.Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Fatalf("Access-Control-Allow-Credentials = %q, want none with a wildcard origin", got)
+	}
+}
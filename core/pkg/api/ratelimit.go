@@ -1,10 +1,14 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"time"
 
+	"neonexcore/pkg/cache"
+	"neonexcore/pkg/errors"
+
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -131,6 +135,63 @@ func (rl *RateLimiter) cleanupRoutine() {
 	}
 }
 
+// CacheRateLimiter implements fixed-window rate limiting on a shared cache,
+// so limits hold across instances when backed by RedisCache
+type CacheRateLimiter struct {
+	store          cache.Cache
+	maxRequests    int
+	windowDuration time.Duration
+	prefix         string
+}
+
+// RateLimitResult describes the state of a key after a request was counted
+type RateLimitResult struct {
+	Allowed   bool
+	Remaining int
+	ResetAt   time.Time
+}
+
+// NewCacheRateLimiter creates a rate limiter that stores counters in store
+func NewCacheRateLimiter(store cache.Cache, maxRequests int, windowDuration time.Duration) *CacheRateLimiter {
+	return &CacheRateLimiter{
+		store:          store,
+		maxRequests:    maxRequests,
+		windowDuration: windowDuration,
+		prefix:         "ratelimit:",
+	}
+}
+
+// Take counts a request for key and reports whether it is within the limit
+func (rl *CacheRateLimiter) Take(ctx context.Context, key string) (RateLimitResult, error) {
+	// Windows are aligned to the clock so every instance agrees on boundaries
+	now := time.Now()
+	windowStart := now.Truncate(rl.windowDuration)
+	resetAt := windowStart.Add(rl.windowDuration)
+	counterKey := fmt.Sprintf("%s%s:%d", rl.prefix, key, windowStart.Unix())
+
+	count, err := rl.store.Increment(ctx, counterKey, 1)
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+	if count == 1 {
+		// First hit in this window; let the counter expire with it
+		if err := rl.store.Expire(ctx, counterKey, time.Until(resetAt)+time.Second); err != nil {
+			return RateLimitResult{}, err
+		}
+	}
+
+	remaining := rl.maxRequests - int(count)
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	return RateLimitResult{
+		Allowed:   int(count) <= rl.maxRequests,
+		Remaining: remaining,
+		ResetAt:   resetAt,
+	}, nil
+}
+
 // RateLimitConfig represents rate limit configuration
 type RateLimitConfig struct {
 	MaxRequests    int           // Maximum requests per window
@@ -138,6 +199,8 @@ type RateLimitConfig struct {
 	KeyGenerator   func(*fiber.Ctx) string // Function to generate rate limit key
 	SkipFunc       func(*fiber.Ctx) bool   // Function to skip rate limiting
 	Handler        fiber.Handler           // Custom handler when limit exceeded
+	Store          cache.Cache             // Shared counter store; nil keeps counters in process
+	Prefix         string                  // Namespace of the limiter's counters in Store, so limiters sharing it don't share budgets
 }
 
 // DefaultRateLimitConfig returns default rate limit configuration
@@ -150,11 +213,7 @@ func DefaultRateLimitConfig() RateLimitConfig {
 		},
 		SkipFunc: nil,
 		Handler: func(c *fiber.Ctx) error {
-			return c.Status(fiber.StatusTooManyRequests).JSON(Response{
-				Success: false,
-				Message: "Too many requests. Please try again later.",
-				Timestamp: time.Now().Unix(),
-			})
+			return errors.New(errors.ErrCodeTooManyRequests, "Too many requests. Please try again later.", fiber.StatusTooManyRequests)
 		},
 	}
 }
@@ -166,7 +225,29 @@ func RateLimitMiddleware(config ...RateLimitConfig) fiber.Handler {
 		cfg = config[0]
 	}
 
-	limiter := NewRateLimiter(cfg.MaxRequests, cfg.WindowDuration)
+	// Fill in anything the caller left unset
+	defaults := DefaultRateLimitConfig()
+	if cfg.MaxRequests <= 0 {
+		cfg.MaxRequests = defaults.MaxRequests
+	}
+	if cfg.WindowDuration <= 0 {
+		cfg.WindowDuration = defaults.WindowDuration
+	}
+	if cfg.KeyGenerator == nil {
+		cfg.KeyGenerator = defaults.KeyGenerator
+	}
+	if cfg.Handler == nil {
+		cfg.Handler = defaults.Handler
+	}
+
+	take := localRateLimit(NewRateLimiter(cfg.MaxRequests, cfg.WindowDuration))
+	if cfg.Store != nil {
+		limiter := NewCacheRateLimiter(cfg.Store, cfg.MaxRequests, cfg.WindowDuration)
+		if cfg.Prefix != "" {
+			limiter.prefix = fmt.Sprintf("ratelimit:%s:", cfg.Prefix)
+		}
+		take = limiter.Take
+	}
 
 	return func(c *fiber.Ctx) error {
 		// Skip rate limiting if configured
@@ -177,29 +258,43 @@ func RateLimitMiddleware(config ...RateLimitConfig) fiber.Handler {
 		// Generate key for this request
 		key := cfg.KeyGenerator(c)
 
-		// Check if request is allowed
-		if !limiter.Allow(key) {
-			// Add rate limit headers
-			c.Set("X-RateLimit-Limit", fmt.Sprintf("%d", cfg.MaxRequests))
-			c.Set("X-RateLimit-Remaining", "0")
-			resetTime := limiter.GetResetTime(key)
-			c.Set("X-RateLimit-Reset", fmt.Sprintf("%d", resetTime.Unix()))
-			c.Set("Retry-After", fmt.Sprintf("%d", int(time.Until(resetTime).Seconds())))
-
-			return cfg.Handler(c)
+		result, err := take(c.UserContext(), key)
+		if err != nil {
+			// Fail open: an unavailable store must not take the API down
+			return c.Next()
 		}
 
 		// Add rate limit headers
-		remaining := limiter.GetRemaining(key)
 		c.Set("X-RateLimit-Limit", fmt.Sprintf("%d", cfg.MaxRequests))
-		c.Set("X-RateLimit-Remaining", fmt.Sprintf("%d", remaining))
-		resetTime := limiter.GetResetTime(key)
-		c.Set("X-RateLimit-Reset", fmt.Sprintf("%d", resetTime.Unix()))
+		c.Set("X-RateLimit-Remaining", fmt.Sprintf("%d", result.Remaining))
+		c.Set("X-RateLimit-Reset", fmt.Sprintf("%d", result.ResetAt.Unix()))
+
+		if !result.Allowed {
+			retryAfter := int(time.Until(result.ResetAt).Seconds())
+			if retryAfter < 1 {
+				retryAfter = 1
+			}
+			c.Set("Retry-After", fmt.Sprintf("%d", retryAfter))
+
+			return cfg.Handler(c)
+		}
 
 		return c.Next()
 	}
 }
 
+// localRateLimit adapts the in-process RateLimiter to the Take signature
+func localRateLimit(limiter *RateLimiter) func(context.Context, string) (RateLimitResult, error) {
+	return func(_ context.Context, key string) (RateLimitResult, error) {
+		allowed := limiter.Allow(key)
+		return RateLimitResult{
+			Allowed:   allowed,
+			Remaining: limiter.GetRemaining(key),
+			ResetAt:   limiter.GetResetTime(key),
+		}, nil
+	}
+}
+
 // IPRateLimitMiddleware creates IP-based rate limiting middleware
 func IPRateLimitMiddleware(maxRequests int, window time.Duration) fiber.Handler {
 	return RateLimitMiddleware(RateLimitConfig{
@@ -216,17 +311,28 @@ func UserRateLimitMiddleware(maxRequests int, window time.Duration) fiber.Handle
 	return RateLimitMiddleware(RateLimitConfig{
 		MaxRequests:    maxRequests,
 		WindowDuration: window,
-		KeyGenerator: func(c *fiber.Ctx) string {
-			// Get user ID from context (set by auth middleware)
-			if userID := c.Locals("user_id"); userID != nil {
-				return fmt.Sprintf("user:%v", userID)
-			}
-			// Fallback to IP if user not authenticated
-			return c.IP()
-		},
+		KeyGenerator:   UserOrIPKey,
 	})
 }
 
+// UserOrIPKey keys rate limits by authenticated user ID, falling back to the
+// client IP for anonymous requests
+func UserOrIPKey(c *fiber.Ctx) string {
+	// Get user ID from context (set by auth middleware)
+	if userID := c.Locals("user_id"); userID != nil {
+		return fmt.Sprintf("user:%v", userID)
+	}
+	// Fallback to IP if user not authenticated
+	return c.IP()
+}
+
+// RouteIPKey keys rate limits by client IP and matched route, giving each
+// endpoint its own budget. The route pattern is used rather than the path, so
+// requests can't get a fresh budget by varying path parameters.
+func RouteIPKey(c *fiber.Ctx) string {
+	return fmt.Sprintf("%s:%s %s", c.IP(), c.Method(), c.Route().Path)
+}
+
 // EndpointRateLimitMiddleware creates endpoint-specific rate limiting
 func EndpointRateLimitMiddleware(maxRequests int, window time.Duration) fiber.Handler {
 	return RateLimitMiddleware(RateLimitConfig{
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"neonexcore/pkg/cache"
+	"neonexcore/pkg/errors"
+	"neonexcore/pkg/logger"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func newRateLimitTestApp(config RateLimitConfig) *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: errors.ErrorHandler(logger.Default())})
+	app.Use(RateLimitMiddleware(config))
+	app.Get("/", noContent)
+	return app
+}
+
+func getRateLimited(t *testing.T, app *fiber.App) *http.Response {
+	t.Helper()
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+	if err != nil {
+		t.Fatalf("GET /: %v", err)
+	}
+	return resp
+}
+
+// waitForWindowStart sleeps until a clock-aligned window begins, so a burst
+// of requests lands in a single window
+func waitForWindowStart(window time.Duration) {
+	time.Sleep(time.Until(time.Now().Truncate(window).Add(window)))
+}
+
+// assertThrottledThenRecovers sends limit requests, checks the next one is
+// throttled with the standard headers and error envelope, and that requests
+// are allowed again once the window has passed
+func assertThrottledThenRecovers(t *testing.T, app *fiber.App, limit int, window time.Duration) {
+	t.Helper()
+
+	for i := 1; i <= limit; i++ {
+		resp := getRateLimited(t, app)
+		if resp.StatusCode != fiber.StatusNoContent {
+			t.Fatalf("request %d: status %d, want it allowed", i, resp.StatusCode)
+		}
+		if got := resp.Header.Get("X-RateLimit-Remaining"); got != strconv.Itoa(limit-i) {
+			t.Fatalf("request %d: X-RateLimit-Remaining = %s, want %d", i, got, limit-i)
+		}
+		if got := resp.Header.Get("X-RateLimit-Limit"); got != strconv.Itoa(limit) {
+			t.Fatalf("X-RateLimit-Limit = %s, want %d", got, limit)
+		}
+	}
+
+	resp := getRateLimited(t, app)
+	if resp.StatusCode != fiber.StatusTooManyRequests {
+		t.Fatalf("request %d: status %d, want 429", limit+1, resp.StatusCode)
+	}
+	if resp.Header.Get("Retry-After") == "" || resp.Header.Get("X-RateLimit-Reset") == "" {
+		t.Fatalf("throttled response headers = %v, want Retry-After and X-RateLimit-Reset", resp.Header)
+	}
+	var body errors.ErrorResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if body.Success || body.Code != errors.ErrCodeTooManyRequests {
+		t.Fatalf("body = %+v, want the error envelope", body)
+	}
+
+	time.Sleep(window + 50*time.Millisecond)
+	if resp := getRateLimited(t, app); resp.StatusCode != fiber.StatusNoContent {
+		t.Fatalf("status after the window = %d, want it allowed", resp.StatusCode)
+	}
+}
+
+func TestRateLimitMiddlewareInProcess(t *testing.T) {
+	window := 300 * time.Millisecond
+	app := newRateLimitTestApp(RateLimitConfig{MaxRequests: 3, WindowDuration: window})
+	assertThrottledThenRecovers(t, app, 3, window)
+}
+
+func TestRateLimitMiddlewareCacheStore(t *testing.T) {
+	store := cache.NewMemoryCache(cache.DefaultMemoryCacheConfig())
+	t.Cleanup(func() { store.Close() })
+
+	// Counters are keyed by the window's start second
+	window := time.Second
+	config := RateLimitConfig{MaxRequests: 3, WindowDuration: window, Store: store, Prefix: "test"}
+	// Two instances sharing the store share the budget
+	app := newRateLimitTestApp(config)
+	other := newRateLimitTestApp(config)
+
+	waitForWindowStart(window)
+	getRateLimited(t, other)
+	if resp := getRateLimited(t, app); resp.Header.Get("X-RateLimit-Remaining") != "1" {
+		t.Fatalf("X-RateLimit-Remaining = %s, want the other instance's request counted", resp.Header.Get("X-RateLimit-Remaining"))
+	}
+
+	waitForWindowStart(window)
+	assertThrottledThenRecovers(t, app, 3, window)
+
+	// Limiters with another prefix keep their own counters
+	separate := newRateLimitTestApp(RateLimitConfig{MaxRequests: 3, WindowDuration: window, Store: store, Prefix: "other"})
+	if resp := getRateLimited(t, separate); resp.Header.Get("X-RateLimit-Remaining") != "2" {
+		t.Fatalf("X-RateLimit-Remaining = %s, want a separate budget", resp.Header.Get("X-RateLimit-Remaining"))
+	}
+}
+
+func TestRateLimitMiddlewareKeysByUser(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: errors.ErrorHandler(logger.Default())})
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_id", c.Get("X-User"))
+		return c.Next()
+	})
+	app.Use(RateLimitMiddleware(RateLimitConfig{MaxRequests: 1, WindowDuration: time.Minute, KeyGenerator: UserOrIPKey}))
+	app.Get("/", noContent)
+
+	get := func(user string) int {
+		req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+		req.Header.Set("X-User", user)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("GET /: %v", err)
+		}
+		return resp.StatusCode
+	}
+
+	if get("1") != fiber.StatusNoContent || get("2") != fiber.StatusNoContent {
+		t.Fatal("each user's first request should be allowed")
+	}
+	if status := get("1"); status != fiber.StatusTooManyRequests {
+		t.Fatalf("user 1's second request: status %d, want 429", status)
+	}
+}
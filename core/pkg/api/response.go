@@ -1,8 +1,13 @@
 package api
 
 import (
+	"fmt"
+	"strconv"
+	"sync"
 	"time"
 
+	"neonexcore/pkg/errors"
+
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -26,6 +31,10 @@ type Meta struct {
 	HasPrevPage  bool  `json:"has_prev_page,omitempty"`
 	NextPage     *int  `json:"next_page,omitempty"`
 	PrevPage     *int  `json:"prev_page,omitempty"`
+
+	// Keyset (cursor) pagination
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    *bool  `json:"has_more,omitempty"`
 }
 
 // PaginationParams represents pagination query parameters
@@ -34,25 +43,136 @@ type PaginationParams struct {
 	Limit int `query:"limit" validate:"omitempty,min=1,max=100"`
 }
 
-// GetPagination extracts pagination params from request
+// Default page sizes, used unless changed with SetPaginationDefaults
+const (
+	DefaultPageSize    = 10
+	DefaultMaxPageSize = 100
+)
+
+// PaginationConfig controls how page and limit are parsed. Zero fields of a
+// per-endpoint override fall back to the defaults.
+type PaginationConfig struct {
+	DefaultLimit int  // Limit used when the request has none
+	MaxLimit     int  // Largest limit a request may ask for
+	ClampLimit   bool // Clamp limits above MaxLimit instead of rejecting them with 400
+}
+
+var (
+	paginationMu       sync.RWMutex
+	paginationDefaults = PaginationConfig{
+		DefaultLimit: DefaultPageSize,
+		MaxLimit:     DefaultMaxPageSize,
+	}
+)
+
+// SetPaginationDefaults sets the pagination config used by every endpoint
+// that doesn't override it. Zero sizes keep their current value.
+func SetPaginationDefaults(config PaginationConfig) {
+	paginationMu.Lock()
+	defer paginationMu.Unlock()
+
+	if config.DefaultLimit > 0 {
+		paginationDefaults.DefaultLimit = config.DefaultLimit
+	}
+	if config.MaxLimit > 0 {
+		paginationDefaults.MaxLimit = config.MaxLimit
+	}
+	paginationDefaults.ClampLimit = config.ClampLimit
+}
+
+// PaginationDefaults returns the pagination config used by default
+func PaginationDefaults() PaginationConfig {
+	paginationMu.RLock()
+	defer paginationMu.RUnlock()
+	return paginationDefaults
+}
+
+// resolvePaginationConfig applies an endpoint's override to the defaults
+func resolvePaginationConfig(overrides []PaginationConfig) PaginationConfig {
+	config := PaginationDefaults()
+	for _, override := range overrides {
+		if override.DefaultLimit > 0 {
+			config.DefaultLimit = override.DefaultLimit
+		}
+		if override.MaxLimit > 0 {
+			config.MaxLimit = override.MaxLimit
+		}
+		if override.ClampLimit {
+			config.ClampLimit = true
+		}
+	}
+	if config.DefaultLimit > config.MaxLimit {
+		config.DefaultLimit = config.MaxLimit
+	}
+	return config
+}
+
+// GetPagination extracts pagination params from request. Invalid values fall
+// back to the defaults and limits above the max are clamped; use
+// ParsePagination to reject them instead.
 func GetPagination(c *fiber.Ctx) PaginationParams {
+	config := PaginationDefaults()
+
 	page := c.QueryInt("page", 1)
-	limit := c.QueryInt("limit", 10)
+	limit := c.QueryInt("limit", config.DefaultLimit)
 
 	if page < 1 {
 		page = 1
 	}
 	if limit < 1 {
-		limit = 10
+		limit = config.DefaultLimit
+	}
+	if limit > config.MaxLimit {
+		limit = config.MaxLimit
 	}
-	if limit > 100 {
-		limit = 100
+
+	return PaginationParams{
+		Page:  page,
+		Limit: limit,
+	}
+}
+
+// ParsePagination extracts and validates page and limit from the request.
+// page and limit must be positive integers; a limit above the max is a 400
+// error unless the config clamps it. An endpoint may pass an override of the
+// defaults, e.g. api.PaginationConfig{DefaultLimit: 20}.
+func ParsePagination(c *fiber.Ctx, override ...PaginationConfig) (PaginationParams, error) {
+	config := resolvePaginationConfig(override)
+
+	page, err := positiveQueryInt(c, "page", 1)
+	if err != nil {
+		return PaginationParams{}, err
+	}
+
+	limit, err := positiveQueryInt(c, "limit", config.DefaultLimit)
+	if err != nil {
+		return PaginationParams{}, err
+	}
+	if limit > config.MaxLimit {
+		if !config.ClampLimit {
+			return PaginationParams{}, errors.NewBadRequest(fmt.Sprintf("limit must not exceed %d", config.MaxLimit))
+		}
+		limit = config.MaxLimit
 	}
 
 	return PaginationParams{
 		Page:  page,
 		Limit: limit,
+	}, nil
+}
+
+// positiveQueryInt parses an optional positive integer query parameter
+func positiveQueryInt(c *fiber.Ctx, key string, defaultValue int) (int, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return defaultValue, nil
+	}
+
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 1 {
+		return 0, errors.NewBadRequest(fmt.Sprintf("%s must be a positive integer", key))
 	}
+	return value, nil
 }
 
 // CalculateMeta calculates pagination metadata
@@ -112,6 +232,17 @@ func Created(c *fiber.Ctx, message string, data interface{}) error {
 	})
 }
 
+// Accepted sends a 202 Accepted response, for work that completes after the
+// request
+func Accepted(c *fiber.Ctx, message string, data interface{}) error {
+	return c.Status(fiber.StatusAccepted).JSON(Response{
+		Success:   true,
+		Message:   message,
+		Data:      data,
+		Timestamp: time.Now().Unix(),
+	})
+}
+
 // NoContent sends a 204 No Content response
 func NoContent(c *fiber.Ctx) error {
 	return c.SendStatus(fiber.StatusNoContent)
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"neonexcore/pkg/errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// parsePaginationQuery runs ParsePagination against a request with the given
+// query string
+func parsePaginationQuery(t *testing.T, query string, override ...PaginationConfig) (PaginationParams, error) {
+	t.Helper()
+
+	var params PaginationParams
+	var parseErr error
+
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		params, parseErr = ParsePagination(c, override...)
+		return nil
+	})
+	if _, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/?"+query, nil)); err != nil {
+		t.Fatalf("GET /?%s: %v", query, err)
+	}
+	return params, parseErr
+}
+
+func TestParsePaginationDefaults(t *testing.T) {
+	params, err := parsePaginationQuery(t, "")
+	if err != nil {
+		t.Fatalf("ParsePagination: %v", err)
+	}
+	if params.Page != 1 || params.Limit != DefaultPageSize {
+		t.Fatalf("params = %+v, want page 1 and limit %d", params, DefaultPageSize)
+	}
+
+	params, err = parsePaginationQuery(t, "page=3&limit=25")
+	if err != nil {
+		t.Fatalf("ParsePagination: %v", err)
+	}
+	if params.Page != 3 || params.Limit != 25 {
+		t.Fatalf("params = %+v, want page 3 and limit 25", params)
+	}
+}
+
+func TestParsePaginationOverride(t *testing.T) {
+	override := PaginationConfig{DefaultLimit: 20, MaxLimit: 50}
+
+	params, err := parsePaginationQuery(t, "", override)
+	if err != nil {
+		t.Fatalf("ParsePagination: %v", err)
+	}
+	if params.Limit != 20 {
+		t.Fatalf("limit = %d, want the override's 20", params.Limit)
+	}
+
+	if _, err := parsePaginationQuery(t, "limit=60", override); err == nil {
+		t.Fatal("ParsePagination accepted a limit above the override's max")
+	}
+	if _, err := parsePaginationQuery(t, "limit=60"); err != nil {
+		t.Fatalf("ParsePagination without override rejected limit 60: %v", err)
+	}
+}
+
+func TestParsePaginationOverLimit(t *testing.T) {
+	_, err := parsePaginationQuery(t, "limit=101")
+	appErr, ok := err.(*errors.AppError)
+	if !ok || appErr.StatusCode != fiber.StatusBadRequest {
+		t.Fatalf("err = %v, want a 400", err)
+	}
+
+	params, err := parsePaginationQuery(t, "limit=101", PaginationConfig{ClampLimit: true})
+	if err != nil {
+		t.Fatalf("ParsePagination with ClampLimit: %v", err)
+	}
+	if params.Limit != DefaultMaxPageSize {
+		t.Fatalf("limit = %d, want it clamped to %d", params.Limit, DefaultMaxPageSize)
+	}
+}
+
+func TestParsePaginationRejectsInvalidValues(t *testing.T) {
+	for _, query := range []string{"page=0", "page=-1", "limit=0", "limit=abc"} {
+		if _, err := parsePaginationQuery(t, query); err == nil {
+			t.Errorf("ParsePagination accepted %q", query)
+		}
+	}
+}
+
+func TestGetPaginationClamps(t *testing.T) {
+	var params PaginationParams
+
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		params = GetPagination(c)
+		return nil
+	})
+	if _, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/?page=0&limit=500", nil)); err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+
+	if params.Page != 1 || params.Limit != DefaultMaxPageSize {
+		t.Fatalf("params = %+v, want page 1 and limit %d", params, DefaultMaxPageSize)
+	}
+}
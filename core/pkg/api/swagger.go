@@ -3,8 +3,10 @@ package api
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 
 	"github.com/gofiber/fiber/v2"
+	"gopkg.in/yaml.v3"
 )
 
 // SwaggerInfo holds API documentation metadata
@@ -85,9 +87,17 @@ func (sg *SwaggerGenerator) AddTag(name, description string) {
 	})
 }
 
-// AddPath adds a path to the spec
+// AddPath adds a path to the spec. Methods are merged into an existing path,
+// replacing operations (e.g. auto-registered ones) for the same method.
 func (sg *SwaggerGenerator) AddPath(path string, methods map[string]interface{}) {
-	sg.spec.Paths[path] = methods
+	existing, ok := sg.spec.Paths[path].(map[string]interface{})
+	if !ok {
+		sg.spec.Paths[path] = methods
+		return
+	}
+	for method, operation := range methods {
+		existing[method] = operation
+	}
 }
 
 // AddSchema adds a schema to components
@@ -116,13 +126,59 @@ func (sg *SwaggerGenerator) GenerateJSON() ([]byte, error) {
 	return json.MarshalIndent(sg.spec, "", "  ")
 }
 
-// SetupSwaggerRoutes sets up Swagger UI and JSON routes
+// GenerateYAML generates YAML representation of the spec. The spec is routed
+// through JSON first so field names and omitempty match GenerateJSON exactly.
+func (sg *SwaggerGenerator) GenerateYAML() ([]byte, error) {
+	data, err := json.Marshal(sg.spec)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	return yaml.Marshal(yamlNumbers(doc))
+}
+
+// yamlNumbers converts whole JSON numbers back to integers so they are not
+// rendered in exponent form (1234567890 would otherwise become 1.23456789e+09)
+func yamlNumbers(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for k, item := range value {
+			value[k] = yamlNumbers(item)
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = yamlNumbers(item)
+		}
+	case float64:
+		if value == math.Trunc(value) && math.Abs(value) < 1<<53 {
+			return int64(value)
+		}
+	}
+	return v
+}
+
+// SetupSwaggerRoutes sets up Swagger UI, JSON and YAML routes
 func SetupSwaggerRoutes(app *fiber.App, sg *SwaggerGenerator) {
 	// Serve OpenAPI JSON
 	app.Get("/api/docs/openapi.json", func(c *fiber.Ctx) error {
 		return c.JSON(sg.spec)
 	})
 
+	// Serve OpenAPI YAML
+	app.Get("/api/docs/openapi.yaml", func(c *fiber.Ctx) error {
+		data, err := sg.GenerateYAML()
+		if err != nil {
+			return err
+		}
+		c.Set("Content-Type", "application/yaml")
+		return c.Send(data)
+	})
+
 	// Serve Swagger UI (HTML)
 	app.Get("/api/docs", func(c *fiber.Ctx) error {
 		html := generateSwaggerUIHTML("/api/docs/openapi.json")
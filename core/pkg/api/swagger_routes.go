@@ -0,0 +1,94 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RegisterFiberRoutes adds a minimal operation for every route registered on
+// the app so that all endpoints appear in the spec. Operations that already
+// exist are left untouched, and auto-registered ones can be replaced later
+// with AddPath. Call it after all routes have been mounted.
+func (sg *SwaggerGenerator) RegisterFiberRoutes(app *fiber.App) {
+	routes := app.GetRoutes(true) // skip middleware registered with Use
+
+	hasGet := make(map[string]bool)
+	for _, route := range routes {
+		if route.Method == http.MethodGet {
+			hasGet[route.Path] = true
+		}
+	}
+
+	for _, route := range routes {
+		// Fiber registers HEAD automatically for every GET route
+		if route.Method == http.MethodHead && hasGet[route.Path] {
+			continue
+		}
+		if route.Method == http.MethodConnect || route.Method == http.MethodTrace {
+			continue
+		}
+
+		path, params := openAPIPath(route.Path)
+		method := strings.ToLower(route.Method)
+
+		item, ok := sg.spec.Paths[path].(map[string]interface{})
+		if !ok {
+			item = make(map[string]interface{})
+			sg.spec.Paths[path] = item
+		}
+		if _, exists := item[method]; exists {
+			continue
+		}
+
+		summary := route.Name
+		if summary == "" {
+			summary = route.Method + " " + path
+		}
+
+		operation := map[string]interface{}{
+			"summary": summary,
+			"responses": map[string]interface{}{
+				"default": map[string]interface{}{"description": "Response"},
+			},
+		}
+		if len(params) > 0 {
+			parameters := make([]interface{}, 0, len(params))
+			for _, name := range params {
+				parameters = append(parameters, map[string]interface{}{
+					"name":     name,
+					"in":       "path",
+					"required": true,
+					"schema":   map[string]interface{}{"type": "string"},
+				})
+			}
+			operation["parameters"] = parameters
+		}
+
+		item[method] = operation
+	}
+}
+
+// openAPIPath converts a Fiber route path ("/users/:id", "/files/*") to OpenAPI
+// form ("/users/{id}", "/files/{wildcard}") and returns the parameter names
+func openAPIPath(path string) (string, []string) {
+	segments := strings.Split(path, "/")
+	var params []string
+
+	for i, segment := range segments {
+		var name string
+		switch {
+		case strings.HasPrefix(segment, ":"):
+			name = strings.TrimRight(strings.TrimPrefix(segment, ":"), "?")
+		case segment == "*" || segment == "+":
+			name = "wildcard"
+		default:
+			continue
+		}
+		segments[i] = "{" + name + "}"
+		params = append(params, name)
+	}
+
+	return strings.Join(segments, "/"), params
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func noContent(c *fiber.Ctx) error {
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func TestRegisterFiberRoutes(t *testing.T) {
+	app := fiber.New()
+	app.Use(noContent)
+	app.Get("/users", noContent).Name("List users")
+	app.Post("/users", noContent)
+	app.Get("/users/:id", noContent)
+	app.Delete("/users/:userId/roles/:roleId", noContent)
+	app.Get("/files/*", noContent)
+
+	sg := NewSwaggerGenerator(SwaggerInfo{Title: "Test", Version: "1.0"})
+	sg.AddPath("/users", map[string]interface{}{
+		"post": map[string]interface{}{"summary": "Create a user"},
+	})
+	sg.RegisterFiberRoutes(app)
+
+	operations := make(map[string][]string)
+	for path, item := range sg.GetSpec().Paths {
+		for method := range item.(map[string]interface{}) {
+			operations[path] = append(operations[path], method)
+		}
+	}
+	want := map[string][]string{
+		"/users":                         {"get", "post"},
+		"/users/{id}":                    {"get"},
+		"/users/{userId}/roles/{roleId}": {"delete"},
+		"/files/{wildcard}":              {"get"},
+	}
+	if len(operations) != len(want) {
+		t.Fatalf("paths = %v, want %v", operations, want)
+	}
+	for path, methods := range want {
+		if len(operations[path]) != len(methods) {
+			t.Errorf("%s has operations %v, want %v (HEAD is skipped)", path, operations[path], methods)
+		}
+	}
+
+	users := sg.GetSpec().Paths["/users"].(map[string]interface{})
+	if summary := users["get"].(map[string]interface{})["summary"]; summary != "List users" {
+		t.Errorf("GET /users summary = %v, want the route name", summary)
+	}
+	// Documented operations are kept
+	if summary := users["post"].(map[string]interface{})["summary"]; summary != "Create a user" {
+		t.Errorf("POST /users summary = %v, want the documented one", summary)
+	}
+
+	roles := sg.GetSpec().Paths["/users/{userId}/roles/{roleId}"].(map[string]interface{})
+	var names []string
+	for _, param := range roles["delete"].(map[string]interface{})["parameters"].([]interface{}) {
+		p := param.(map[string]interface{})
+		if p["in"] != "path" || p["required"] != true {
+			t.Errorf("parameter %v is not a required path parameter", p)
+		}
+		names = append(names, p["name"].(string))
+	}
+	if !reflect.DeepEqual(names, []string{"userId", "roleId"}) {
+		t.Errorf("parameters = %v, want [userId roleId]", names)
+	}
+}
+
+func TestRegisterFiberRoutesCanBeEnriched(t *testing.T) {
+	app := fiber.New()
+	app.Get("/users/:id", noContent)
+
+	sg := NewSwaggerGenerator(SwaggerInfo{Title: "Test", Version: "1.0"})
+	sg.RegisterFiberRoutes(app)
+	sg.AddPath("/users/{id}", map[string]interface{}{
+		"get": map[string]interface{}{"summary": "Get a user"},
+	})
+
+	item := sg.GetSpec().Paths["/users/{id}"].(map[string]interface{})
+	if summary := item["get"].(map[string]interface{})["summary"]; summary != "Get a user" {
+		t.Fatalf("summary = %v, want the enriched one", summary)
+	}
+}
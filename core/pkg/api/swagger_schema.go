@@ -0,0 +1,168 @@
+package api
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	nullTimeType = reflect.TypeOf(sql.NullTime{})
+)
+
+// AddSchemaFromStruct registers a component schema generated from a struct.
+// Property names come from json tags, fields tagged validate:"required" are
+// marked required and nested structs are registered as their own schemas and
+// referenced with $ref.
+func (sg *SwaggerGenerator) AddSchemaFromStruct(name string, v interface{}) {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		sg.AddSchema(name, sg.schemaForType(t))
+		return
+	}
+
+	// Register a placeholder first so self-referencing structs terminate
+	sg.AddSchema(name, map[string]interface{}{"type": "object"})
+	sg.AddSchema(name, sg.structSchema(t))
+}
+
+// hasSchema reports whether a component schema is already registered
+func (sg *SwaggerGenerator) hasSchema(name string) bool {
+	schemas, ok := sg.spec.Components["schemas"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	_, exists := schemas[name]
+	return exists
+}
+
+// structSchema builds an object schema from a struct type
+func (sg *SwaggerGenerator) structSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	var required []string
+	sg.collectFields(t, properties, &required)
+
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// collectFields adds a struct's fields to properties, flattening embedded structs
+// the same way encoding/json does
+func (sg *SwaggerGenerator) collectFields(t reflect.Type, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		// Embedded structs are flattened even when their type is unexported
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct && !isTimeType(fieldType) {
+			sg.collectFields(fieldType, properties, required)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		properties[name] = sg.schemaForType(field.Type)
+
+		for _, rule := range strings.Split(field.Tag.Get("validate"), ",") {
+			if rule == "required" {
+				*required = append(*required, name)
+				break
+			}
+		}
+	}
+}
+
+// schemaForType maps a Go type to an OpenAPI schema
+func (sg *SwaggerGenerator) schemaForType(t reflect.Type) map[string]interface{} {
+	if t.Kind() == reflect.Ptr {
+		schema := sg.schemaForType(t.Elem())
+		if _, isRef := schema["$ref"]; !isRef {
+			schema["nullable"] = true
+		}
+		return schema
+	}
+
+	if isTimeType(t) {
+		schema := map[string]interface{}{"type": "string", "format": "date-time"}
+		if t != timeType {
+			schema["nullable"] = true
+		}
+		return schema
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		schema := map[string]interface{}{"type": "integer", "format": "int64"}
+		if t.Kind() == reflect.Uint || t.Kind() == reflect.Uint64 {
+			schema["minimum"] = 0
+		}
+		return schema
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		schema := map[string]interface{}{"type": "integer", "format": "int32"}
+		if t.Kind() == reflect.Uint8 || t.Kind() == reflect.Uint16 || t.Kind() == reflect.Uint32 {
+			schema["minimum"] = 0
+		}
+		return schema
+	case reflect.Float32:
+		return map[string]interface{}{"type": "number", "format": "float"}
+	case reflect.Float64:
+		return map[string]interface{}{"type": "number", "format": "double"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": sg.schemaForType(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": sg.schemaForType(t.Elem())}
+	case reflect.Struct:
+		name := t.Name()
+		if name == "" {
+			// Anonymous structs are inlined
+			return sg.structSchema(t)
+		}
+		if !sg.hasSchema(name) {
+			sg.AddSchema(name, map[string]interface{}{"type": "object"})
+			sg.AddSchema(name, sg.structSchema(t))
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	default:
+		// interface{} and anything else accept any value
+		return map[string]interface{}{}
+	}
+}
+
+// isTimeType reports whether t serializes as a timestamp (time.Time, sql.NullTime
+// and types defined on it such as gorm.DeletedAt)
+func isTimeType(t reflect.Type) bool {
+	return t == timeType || (t.Kind() == reflect.Struct && t.ConvertibleTo(nullTimeType))
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// specSchema returns a component schema as it appears in the generated JSON
+func specSchema(t *testing.T, sg *SwaggerGenerator, name string) map[string]interface{} {
+	t.Helper()
+
+	data, err := sg.GenerateJSON()
+	if err != nil {
+		t.Fatalf("GenerateJSON: %v", err)
+	}
+	var spec struct {
+		Components struct {
+			Schemas map[string]map[string]interface{} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		t.Fatalf("decode spec: %v", err)
+	}
+	schema, ok := spec.Components.Schemas[name]
+	if !ok {
+		t.Fatalf("schema %s is not registered", name)
+	}
+	return schema
+}
+
+type testAddress struct {
+	City string `json:"city" validate:"required"`
+}
+
+type testBase struct {
+	ID        uint      `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type testCustomer struct {
+	testBase
+	Name     string           `json:"name" validate:"required,max=50"`
+	Email    *string          `json:"email,omitempty"`
+	Score    float64          `json:"score"`
+	Tags     []string         `json:"tags"`
+	Labels   map[string]int32 `json:"labels"`
+	Avatar   []byte           `json:"avatar"`
+	Address  testAddress      `json:"address"`
+	Previous []testAddress    `json:"previous"`
+	Secret   string           `json:"-"`
+	Extra    interface{}      `json:"extra"`
+	Referrer *testCustomer    `json:"referrer"`
+	internal string
+}
+
+func TestAddSchemaFromStruct(t *testing.T) {
+	sg := NewSwaggerGenerator(SwaggerInfo{Title: "Test", Version: "1.0"})
+	sg.AddSchemaFromStruct("Customer", &testCustomer{})
+
+	schema := specSchema(t, sg, "Customer")
+	properties := schema["properties"].(map[string]interface{})
+
+	want := map[string]interface{}{
+		"id":         map[string]interface{}{"type": "integer", "format": "int64", "minimum": float64(0)},
+		"created_at": map[string]interface{}{"type": "string", "format": "date-time"},
+		"name":       map[string]interface{}{"type": "string"},
+		"email":      map[string]interface{}{"type": "string", "nullable": true},
+		"score":      map[string]interface{}{"type": "number", "format": "double"},
+		"tags":       map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+		"labels":     map[string]interface{}{"type": "object", "additionalProperties": map[string]interface{}{"type": "integer", "format": "int32"}},
+		"avatar":     map[string]interface{}{"type": "string", "format": "byte"},
+		"address":    map[string]interface{}{"$ref": "#/components/schemas/testAddress"},
+		"previous":   map[string]interface{}{"type": "array", "items": map[string]interface{}{"$ref": "#/components/schemas/testAddress"}},
+		"extra":      map[string]interface{}{},
+		"referrer":   map[string]interface{}{"$ref": "#/components/schemas/testCustomer"},
+	}
+	if !reflect.DeepEqual(properties, want) {
+		t.Fatalf("properties = %v\nwant %v", properties, want)
+	}
+	if required := schema["required"]; !reflect.DeepEqual(required, []interface{}{"name"}) {
+		t.Fatalf("required = %v, want [name]", required)
+	}
+
+	address := specSchema(t, sg, "testAddress")
+	if !reflect.DeepEqual(address["required"], []interface{}{"city"}) {
+		t.Fatalf("nested schema = %v, want city required", address)
+	}
+}
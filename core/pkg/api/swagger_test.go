@@ -0,0 +1,93 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// parseYAMLAsJSON decodes YAML and normalizes it to what encoding/json
+// produces, so it can be compared with a decoded JSON document
+func parseYAMLAsJSON(t *testing.T, data []byte) interface{} {
+	t.Helper()
+
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("parse yaml: %v", err)
+	}
+	normalized, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("convert yaml to json: %v", err)
+	}
+	var result interface{}
+	if err := json.Unmarshal(normalized, &result); err != nil {
+		t.Fatalf("decode normalized yaml: %v", err)
+	}
+	return result
+}
+
+func TestGenerateYAMLMatchesJSON(t *testing.T) {
+	sg := CreateDefaultSwagger()
+	sg.AddSchemaFromStruct("Customer", &testCustomer{})
+	sg.AddPath("/users/{id}", map[string]interface{}{
+		"get": map[string]interface{}{
+			"summary": "Get a user: by ID",
+			"parameters": []interface{}{
+				map[string]interface{}{"name": "id", "in": "path", "required": true, "schema": map[string]interface{}{"type": "integer", "maximum": 1234567890}},
+			},
+			"responses": map[string]interface{}{
+				"200": map[string]interface{}{"description": "yes"},
+			},
+		},
+	})
+
+	jsonData, err := sg.GenerateJSON()
+	if err != nil {
+		t.Fatalf("GenerateJSON: %v", err)
+	}
+	yamlData, err := sg.GenerateYAML()
+	if err != nil {
+		t.Fatalf("GenerateYAML: %v", err)
+	}
+
+	var fromJSON interface{}
+	if err := json.Unmarshal(jsonData, &fromJSON); err != nil {
+		t.Fatalf("decode json: %v", err)
+	}
+	fromYAML := parseYAMLAsJSON(t, yamlData)
+	if !reflect.DeepEqual(fromYAML, fromJSON) {
+		t.Fatalf("yaml spec differs from json spec\nyaml: %s", yamlData)
+	}
+
+	// Quoted keys and values keep their string type
+	var raw map[string]interface{}
+	yaml.Unmarshal(yamlData, &raw)
+	if version, ok := raw["openapi"].(string); !ok || version != "3.0.0" {
+		t.Fatalf("openapi = %#v, want the string 3.0.0", raw["openapi"])
+	}
+}
+
+func TestSwaggerRoutesServeYAML(t *testing.T) {
+	app := fiber.New()
+	SetupSwaggerRoutes(app, CreateDefaultSwagger())
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/api/docs/openapi.yaml", nil))
+	if err != nil {
+		t.Fatalf("GET openapi.yaml: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != fiber.StatusOK || resp.Header.Get(fiber.HeaderContentType) != "application/yaml" {
+		t.Fatalf("GET openapi.yaml = %d %s", resp.StatusCode, resp.Header.Get(fiber.HeaderContentType))
+	}
+	body, _ := io.ReadAll(resp.Body)
+	doc := parseYAMLAsJSON(t, body).(map[string]interface{})
+	if doc["openapi"] != "3.0.0" {
+		t.Fatalf("served spec = %v", doc)
+	}
+}
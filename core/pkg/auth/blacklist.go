@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"neonexcore/pkg/cache"
+)
+
+// TokenBlacklist tracks revoked tokens until they would have expired anyway
+type TokenBlacklist struct {
+	cache  cache.Cache
+	prefix string
+
+	// lock makes Consume a conditional set shared by every instance using
+	// the cache; without one, mu only covers this process
+	lock cache.Lock
+	mu   sync.Mutex
+}
+
+// NewTokenBlacklist creates a new token blacklist backed by the given cache
+func NewTokenBlacklist(c cache.Cache) *TokenBlacklist {
+	b := &TokenBlacklist{
+		cache:  c,
+		prefix: "auth:blacklist:",
+	}
+	if lock, err := cache.NewLock(c); err == nil {
+		b.lock = lock
+	}
+	return b
+}
+
+// Revoke blacklists a token for the remainder of its lifetime
+func (b *TokenBlacklist) Revoke(ctx context.Context, tokenString string, claims *Claims) error {
+	ttl := remainingLifetime(claims)
+
+	// Already expired tokens are rejected by validation, nothing to store
+	if ttl <= 0 {
+		return nil
+	}
+
+	return b.cache.Set(ctx, b.key(tokenString, claims), true, ttl)
+}
+
+// Consume revokes a token and reports whether this call was the one to
+// revoke it. Of concurrent calls for the same token only one returns true, so
+// a single-use token such as a refresh token can be exchanged once.
+func (b *TokenBlacklist) Consume(ctx context.Context, tokenString string, claims *Claims) (bool, error) {
+	ttl := remainingLifetime(claims)
+	if ttl <= 0 {
+		return false, nil
+	}
+
+	key := b.key(tokenString, claims)
+	if b.lock != nil {
+		// The lock is never released: it is the set-if-absent marker and
+		// expires with the token
+		if _, err := b.lock.Acquire(ctx, key, ttl); err != nil {
+			if errors.Is(err, cache.ErrLockHeld) {
+				return false, nil
+			}
+			return false, err
+		}
+	} else {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+	}
+
+	// Tokens revoked by Revoke, e.g. on logout, hold no lock
+	revoked, err := b.cache.Exists(ctx, key)
+	if err != nil || revoked {
+		return false, err
+	}
+	if err := b.cache.Set(ctx, key, true, ttl); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RevokeUser blacklists every token of a user issued up to now. ttl must
+// cover the lifetime of the longest-lived token.
+func (b *TokenBlacklist) RevokeUser(ctx context.Context, userID uint, ttl time.Duration) error {
+	return b.cache.Set(ctx, b.userKey(userID), time.Now().Unix(), ttl)
+}
+
+// IsRevoked checks if a token has been blacklisted, on its own or with all
+// tokens of its user
+func (b *TokenBlacklist) IsRevoked(ctx context.Context, tokenString string, claims *Claims) (bool, error) {
+	revoked, err := b.cache.Exists(ctx, b.key(tokenString, claims))
+	if err != nil || revoked || claims == nil || claims.UserID == 0 {
+		return revoked, err
+	}
+
+	value, err := b.cache.Get(ctx, b.userKey(claims.UserID))
+	if err != nil {
+		// No revocation recorded for the user
+		return false, nil
+	}
+	cutoff, ok := toUnix(value)
+	if !ok {
+		return false, fmt.Errorf("invalid user revocation entry %v", value)
+	}
+	// iat has second precision, so tokens issued within the revoking second
+	// are revoked too
+	return claims.IssuedAt == nil || claims.IssuedAt.Unix() <= cutoff, nil
+}
+
+// key returns the cache key for a token, preferring its jti over a hash of the raw token
+func (b *TokenBlacklist) key(tokenString string, claims *Claims) string {
+	if claims != nil && claims.ID != "" {
+		return b.prefix + claims.ID
+	}
+	sum := sha256.Sum256([]byte(tokenString))
+	return b.prefix + hex.EncodeToString(sum[:])
+}
+
+// userKey returns the cache key holding the revocation cutoff of a user
+func (b *TokenBlacklist) userKey(userID uint) string {
+	return fmt.Sprintf("%suser:%d", b.prefix, userID)
+}
+
+// remainingLifetime returns how long a token stays valid
+func remainingLifetime(claims *Claims) time.Duration {
+	if claims.ExpiresAt == nil {
+		return time.Minute
+	}
+	return time.Until(claims.ExpiresAt.Time)
+}
+
+// toUnix reads a Unix time stored in the cache, which serializing tiers may
+// return as a float64
+func toUnix(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return v, true
+	case int:
+		return int64(v), true
+	case float64:
+		return int64(v), true
+	}
+	return 0, false
+}
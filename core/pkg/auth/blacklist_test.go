@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"neonexcore/pkg/cache"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func newTestJWTManager(t *testing.T) (*JWTManager, *TokenBlacklist) {
+	t.Helper()
+
+	mc := cache.NewMemoryCache(cache.DefaultMemoryCacheConfig())
+	t.Cleanup(func() { mc.Close() })
+
+	blacklist := NewTokenBlacklist(mc)
+	manager := NewJWTManager(&JWTConfig{SecretKey: "test-secret"})
+	manager.SetBlacklist(blacklist)
+	return manager, blacklist
+}
+
+func TestRevokedTokenIsRejected(t *testing.T) {
+	manager, _ := newTestJWTManager(t)
+	ctx := context.Background()
+
+	token, err := manager.GenerateAccessToken(7, "jane@example.com", "user", nil)
+	if err != nil {
+		t.Fatalf("GenerateAccessToken: %v", err)
+	}
+
+	app := fiber.New()
+	app.Get("/", AuthMiddleware(manager), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+	status := func() int {
+		req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("GET /: %v", err)
+		}
+		return resp.StatusCode
+	}
+
+	if code := status(); code != fiber.StatusOK {
+		t.Fatalf("status before revoking = %d, want 200", code)
+	}
+	if err := manager.RevokeToken(ctx, token); err != nil {
+		t.Fatalf("RevokeToken: %v", err)
+	}
+	if _, err := manager.ValidateToken(token); !errors.Is(err, ErrRevokedToken) {
+		t.Fatalf("ValidateToken after revoking: %v, want ErrRevokedToken", err)
+	}
+	if code := status(); code != fiber.StatusUnauthorized {
+		t.Fatalf("status after revoking = %d, want 401", code)
+	}
+}
+
+func TestBlacklistEntryExpiresWithToken(t *testing.T) {
+	_, blacklist := newTestJWTManager(t)
+	ctx := context.Background()
+
+	claims := &Claims{RegisteredClaims: jwt.RegisteredClaims{
+		ID: "short-lived",
+		// NewNumericDate would truncate to seconds
+		ExpiresAt: &jwt.NumericDate{Time: time.Now().Add(50 * time.Millisecond)},
+	}}
+	if err := blacklist.Revoke(ctx, "token", claims); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if revoked, _ := blacklist.IsRevoked(ctx, "token", claims); !revoked {
+		t.Fatal("token is not revoked")
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if revoked, _ := blacklist.IsRevoked(ctx, "token", claims); revoked {
+		t.Fatal("blacklist entry outlived the token")
+	}
+}
+
+func TestRotateRefreshTokenOnce(t *testing.T) {
+	manager, _ := newTestJWTManager(t)
+	ctx := context.Background()
+
+	refreshToken, err := manager.GenerateRefreshToken(7, "jane@example.com")
+	if err != nil {
+		t.Fatalf("GenerateRefreshToken: %v", err)
+	}
+
+	const rotations = 20
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var rotated []string
+	for i := 0; i < rotations; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, newRefreshToken, err := manager.RotateRefreshToken(ctx, refreshToken)
+			if err != nil {
+				if !errors.Is(err, ErrRevokedToken) {
+					t.Errorf("RotateRefreshToken: %v", err)
+				}
+				return
+			}
+			mu.Lock()
+			rotated = append(rotated, newRefreshToken)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if len(rotated) != 1 {
+		t.Fatalf("refresh token was rotated %d times, want once", len(rotated))
+	}
+	if _, err := manager.ValidateToken(refreshToken); !errors.Is(err, ErrRevokedToken) {
+		t.Fatalf("old refresh token: %v, want ErrRevokedToken", err)
+	}
+	if _, _, err := manager.RotateRefreshToken(ctx, rotated[0]); err != nil {
+		t.Fatalf("rotating the new refresh token: %v", err)
+	}
+}
+
+func TestRevokeUserTokens(t *testing.T) {
+	manager, _ := newTestJWTManager(t)
+	ctx := context.Background()
+
+	token, err := manager.GenerateAccessToken(7, "jane@example.com", "user", nil)
+	if err != nil {
+		t.Fatalf("GenerateAccessToken: %v", err)
+	}
+	other, err := manager.GenerateAccessToken(8, "john@example.com", "user", nil)
+	if err != nil {
+		t.Fatalf("GenerateAccessToken: %v", err)
+	}
+
+	if err := manager.RevokeUserTokens(ctx, 7); err != nil {
+		t.Fatalf("RevokeUserTokens: %v", err)
+	}
+	if _, err := manager.ValidateToken(token); !errors.Is(err, ErrRevokedToken) {
+		t.Fatalf("revoked user's token: %v, want ErrRevokedToken", err)
+	}
+	if _, err := manager.ValidateToken(other); err != nil {
+		t.Fatalf("other user's token: %v", err)
+	}
+}
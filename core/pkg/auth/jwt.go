@@ -1,16 +1,19 @@
 package auth
 
 import (
+	"context"
 	"errors"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 var (
 	ErrInvalidToken     = errors.New("invalid token")
 	ErrExpiredToken     = errors.New("token has expired")
 	ErrInvalidSignature = errors.New("invalid signature")
+	ErrRevokedToken     = errors.New("token has been revoked")
 )
 
 // JWTConfig holds JWT configuration
@@ -34,7 +37,8 @@ type Claims struct {
 
 // JWTManager handles JWT operations
 type JWTManager struct {
-	config *JWTConfig
+	config    *JWTConfig
+	blacklist *TokenBlacklist
 }
 
 // NewJWTManager creates a new JWT manager
@@ -51,6 +55,11 @@ func NewJWTManager(config *JWTConfig) *JWTManager {
 	return &JWTManager{config: config}
 }
 
+// SetBlacklist enables token revocation checks during validation
+func (m *JWTManager) SetBlacklist(blacklist *TokenBlacklist) {
+	m.blacklist = blacklist
+}
+
 // GenerateAccessToken generates a new access token
 func (m *JWTManager) GenerateAccessToken(userID uint, email, role string, permissions []string) (string, error) {
 	claims := &Claims{
@@ -64,6 +73,7 @@ func (m *JWTManager) GenerateAccessToken(userID uint, email, role string, permis
 			NotBefore: jwt.NewNumericDate(time.Now()),
 			Issuer:    m.config.Issuer,
 			Subject:   email,
+			ID:        uuid.NewString(),
 		},
 	}
 
@@ -80,6 +90,7 @@ func (m *JWTManager) GenerateRefreshToken(userID uint, email string) (string, er
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(m.config.RefreshExpiry)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Issuer:    m.config.Issuer,
+			ID:        uuid.NewString(),
 		},
 	}
 
@@ -108,9 +119,43 @@ func (m *JWTManager) ValidateToken(tokenString string) (*Claims, error) {
 		return nil, ErrInvalidToken
 	}
 
+	if m.blacklist != nil {
+		revoked, err := m.blacklist.IsRevoked(context.Background(), tokenString, claims)
+		if err != nil || revoked {
+			return nil, ErrRevokedToken
+		}
+	}
+
 	return claims, nil
 }
 
+// RevokeToken blacklists a token until it expires
+func (m *JWTManager) RevokeToken(ctx context.Context, tokenString string) error {
+	if m.blacklist == nil {
+		return nil
+	}
+
+	claims, err := m.ValidateToken(tokenString)
+	if err != nil {
+		// Expired or already revoked tokens need no further action
+		if errors.Is(err, ErrExpiredToken) || errors.Is(err, ErrRevokedToken) {
+			return nil
+		}
+		return err
+	}
+
+	return m.blacklist.Revoke(ctx, tokenString, claims)
+}
+
+// RevokeUserTokens blacklists every access and refresh token issued to a
+// user so far, ending all of their sessions
+func (m *JWTManager) RevokeUserTokens(ctx context.Context, userID uint) error {
+	if m.blacklist == nil {
+		return nil
+	}
+	return m.blacklist.RevokeUser(ctx, userID, m.config.RefreshExpiry)
+}
+
 // RefreshAccessToken creates new access token from refresh token
 func (m *JWTManager) RefreshAccessToken(refreshToken string) (string, error) {
 	claims, err := m.ValidateToken(refreshToken)
@@ -121,3 +166,36 @@ func (m *JWTManager) RefreshAccessToken(refreshToken string) (string, error) {
 	// Generate new access token
 	return m.GenerateAccessToken(claims.UserID, claims.Email, claims.Role, claims.Permissions)
 }
+
+// RotateRefreshToken exchanges a refresh token for a new access/refresh pair
+// and revokes the old refresh token so it cannot be replayed. When the same
+// token is rotated concurrently only one call succeeds; the others get
+// ErrRevokedToken.
+func (m *JWTManager) RotateRefreshToken(ctx context.Context, refreshToken string) (string, string, error) {
+	claims, err := m.ValidateToken(refreshToken)
+	if err != nil {
+		return "", "", err
+	}
+
+	if m.blacklist != nil {
+		consumed, err := m.blacklist.Consume(ctx, refreshToken, claims)
+		if err != nil {
+			return "", "", err
+		}
+		if !consumed {
+			return "", "", ErrRevokedToken
+		}
+	}
+
+	accessToken, err := m.GenerateAccessToken(claims.UserID, claims.Email, claims.Role, claims.Permissions)
+	if err != nil {
+		return "", "", err
+	}
+
+	newRefreshToken, err := m.GenerateRefreshToken(claims.UserID, claims.Email)
+	if err != nil {
+		return "", "", err
+	}
+
+	return accessToken, newRefreshToken, nil
+}
@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"neonexcore/pkg/cache"
+)
+
+// LockoutConfig holds brute-force protection settings
+type LockoutConfig struct {
+	MaxAttempts     int           // Failed attempts allowed within Window before locking
+	Window          time.Duration // Sliding window for counting failures
+	LockoutDuration time.Duration // How long a lock lasts
+}
+
+// DefaultLockoutConfig returns the default lockout configuration
+func DefaultLockoutConfig() LockoutConfig {
+	return LockoutConfig{
+		MaxAttempts:     5,
+		Window:          15 * time.Minute,
+		LockoutDuration: 15 * time.Minute,
+	}
+}
+
+// LoginLimiter tracks failed logins and locks identifiers (e.g. email and IP) after too many failures
+type LoginLimiter struct {
+	cache  cache.Cache
+	config LockoutConfig
+}
+
+// NewLoginLimiter creates a new login limiter backed by the given cache
+func NewLoginLimiter(c cache.Cache, config LockoutConfig) *LoginLimiter {
+	defaults := DefaultLockoutConfig()
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaults.MaxAttempts
+	}
+	if config.Window <= 0 {
+		config.Window = defaults.Window
+	}
+	if config.LockoutDuration <= 0 {
+		config.LockoutDuration = defaults.LockoutDuration
+	}
+	return &LoginLimiter{cache: c, config: config}
+}
+
+// Locked returns the remaining lock time if any of the identifiers is locked
+func (l *LoginLimiter) Locked(ctx context.Context, identifiers ...string) (time.Duration, bool) {
+	var remaining time.Duration
+	for _, id := range identifiers {
+		if id == "" {
+			continue
+		}
+		exists, err := l.cache.Exists(ctx, l.lockKey(id))
+		if err != nil || !exists {
+			continue
+		}
+		ttl, err := l.cache.TTL(ctx, l.lockKey(id))
+		if err != nil {
+			continue
+		}
+		if ttl > remaining {
+			remaining = ttl
+		}
+	}
+	return remaining, remaining > 0
+}
+
+// RecordFailure counts a failed attempt and reports whether it triggered a lock
+func (l *LoginLimiter) RecordFailure(ctx context.Context, identifiers ...string) (bool, error) {
+	locked := false
+	for _, id := range identifiers {
+		if id == "" {
+			continue
+		}
+		attempts, err := l.cache.Increment(ctx, l.attemptsKey(id), 1)
+		if err != nil {
+			return locked, err
+		}
+		// Every failure pushes the window forward
+		if err := l.cache.Expire(ctx, l.attemptsKey(id), l.config.Window); err != nil {
+			return locked, err
+		}
+
+		if attempts >= int64(l.config.MaxAttempts) {
+			if err := l.cache.Set(ctx, l.lockKey(id), true, l.config.LockoutDuration); err != nil {
+				return locked, err
+			}
+			l.cache.Delete(ctx, l.attemptsKey(id))
+			locked = true
+		}
+	}
+	return locked, nil
+}
+
+// Reset clears failure counters after a successful login
+func (l *LoginLimiter) Reset(ctx context.Context, identifiers ...string) error {
+	for _, id := range identifiers {
+		if id == "" {
+			continue
+		}
+		if err := l.cache.Delete(ctx, l.attemptsKey(id)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Config returns the limiter configuration
+func (l *LoginLimiter) Config() LockoutConfig {
+	return l.config
+}
+
+func (l *LoginLimiter) attemptsKey(id string) string {
+	return "auth:attempts:" + id
+}
+
+func (l *LoginLimiter) lockKey(id string) string {
+	return "auth:lockout:" + id
+}
@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"neonexcore/pkg/cache"
+)
+
+func newTestLoginLimiter(t *testing.T, config LockoutConfig) *LoginLimiter {
+	t.Helper()
+
+	mc := cache.NewMemoryCache(cache.DefaultMemoryCacheConfig())
+	t.Cleanup(func() { mc.Close() })
+	return NewLoginLimiter(mc, config)
+}
+
+func recordFailures(t *testing.T, limiter *LoginLimiter, n int, identifiers ...string) bool {
+	t.Helper()
+
+	var locked bool
+	for i := 0; i < n; i++ {
+		var err error
+		if locked, err = limiter.RecordFailure(context.Background(), identifiers...); err != nil {
+			t.Fatalf("RecordFailure: %v", err)
+		}
+	}
+	return locked
+}
+
+func TestLoginLimiterLocksAfterMaxAttempts(t *testing.T) {
+	limiter := newTestLoginLimiter(t, LockoutConfig{MaxAttempts: 3})
+	ctx := context.Background()
+
+	if recordFailures(t, limiter, 2, "email:jane") {
+		t.Fatal("locked before reaching MaxAttempts")
+	}
+	if _, locked := limiter.Locked(ctx, "email:jane"); locked {
+		t.Fatal("Locked before reaching MaxAttempts")
+	}
+
+	if !recordFailures(t, limiter, 1, "email:jane") {
+		t.Fatal("third failure did not lock")
+	}
+	remaining, locked := limiter.Locked(ctx, "ip:other", "email:jane")
+	if !locked || remaining <= 0 || remaining > DefaultLockoutConfig().LockoutDuration {
+		t.Fatalf("Locked = %v, %v; want locked for up to the lockout duration", remaining, locked)
+	}
+	if _, locked := limiter.Locked(ctx, "email:john"); locked {
+		t.Fatal("another identifier is locked")
+	}
+}
+
+func TestLoginLimiterUnlocksAfterLockoutDuration(t *testing.T) {
+	limiter := newTestLoginLimiter(t, LockoutConfig{MaxAttempts: 2, LockoutDuration: 50 * time.Millisecond})
+	ctx := context.Background()
+
+	if !recordFailures(t, limiter, 2, "email:jane") {
+		t.Fatal("failures did not lock")
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	if _, locked := limiter.Locked(ctx, "email:jane"); locked {
+		t.Fatal("still locked after the lockout duration")
+	}
+	// The counter starts over after the lock
+	if recordFailures(t, limiter, 1, "email:jane") {
+		t.Fatal("a single failure after unlocking locked again")
+	}
+}
+
+func TestLoginLimiterResetOnSuccess(t *testing.T) {
+	limiter := newTestLoginLimiter(t, LockoutConfig{MaxAttempts: 3})
+	ctx := context.Background()
+
+	recordFailures(t, limiter, 2, "email:jane")
+	if err := limiter.Reset(ctx, "email:jane"); err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+
+	if recordFailures(t, limiter, 2, "email:jane") {
+		t.Fatal("failures before the reset still counted")
+	}
+}
@@ -44,6 +44,7 @@ func AuthMiddleware(jwtManager *JWTManager) fiber.Handler {
 		c.Locals("role", claims.Role)
 		c.Locals("permissions", claims.Permissions)
 		c.Locals("claims", claims)
+		c.Locals("token", token)
 
 		return c.Next()
 	}
@@ -97,6 +98,12 @@ func GetUserPermissions(c *fiber.Ctx) ([]string, bool) {
 	return permissions, ok
 }
 
+// GetToken gets the raw bearer token from context
+func GetToken(c *fiber.Ctx) (string, bool) {
+	token, ok := c.Locals("token").(string)
+	return token, ok
+}
+
 // GetClaims gets full claims from context
 func GetClaims(c *fiber.Ctx) (*Claims, bool) {
 	claims, ok := c.Locals("claims").(*Claims)
@@ -1,8 +1,11 @@
 package auth
 
 import (
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 
 	"golang.org/x/crypto/bcrypt"
@@ -16,7 +19,8 @@ const (
 
 // PasswordHasher handles password hashing operations
 type PasswordHasher struct {
-	cost int
+	cost   int
+	pepper []byte
 }
 
 // NewPasswordHasher creates a new password hasher
@@ -30,13 +34,42 @@ func NewPasswordHasher(cost int) *PasswordHasher {
 	return &PasswordHasher{cost: cost}
 }
 
+// SetPepper sets an application-wide secret mixed into every password before
+// hashing, so leaked hashes can't be cracked without it. An empty pepper
+// disables peppering. Hashes made before a pepper was set still verify and
+// are reported by VerifyAndRehash for upgrade.
+func (h *PasswordHasher) SetPepper(pepper string) {
+	if pepper == "" {
+		h.pepper = nil
+		return
+	}
+	h.pepper = []byte(pepper)
+}
+
+// Cost returns the bcrypt cost used for new hashes
+func (h *PasswordHasher) Cost() int {
+	return h.cost
+}
+
+// prepare returns the bytes that are bcrypt-hashed for a password. With a
+// pepper this is the base64 HMAC-SHA256 of the password, which also keeps
+// long passwords under bcrypt's 72-byte limit.
+func (h *PasswordHasher) prepare(password string) []byte {
+	if len(h.pepper) == 0 {
+		return []byte(password)
+	}
+	mac := hmac.New(sha256.New, h.pepper)
+	mac.Write([]byte(password))
+	return []byte(base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+}
+
 // Hash hashes a password using bcrypt
 func (h *PasswordHasher) Hash(password string) (string, error) {
 	if password == "" {
 		return "", fmt.Errorf("password cannot be empty")
 	}
 
-	hashedBytes, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	hashedBytes, err := bcrypt.GenerateFromPassword(h.prepare(password), h.cost)
 	if err != nil {
 		return "", fmt.Errorf("failed to hash password: %w", err)
 	}
@@ -46,7 +79,36 @@ func (h *PasswordHasher) Hash(password string) (string, error) {
 
 // Verify verifies a password against a hash
 func (h *PasswordHasher) Verify(password, hash string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	_, err := h.verify(password, hash)
+	return err
+}
+
+// VerifyAndRehash verifies a password and, when the hash uses an old cost or
+// predates the pepper, returns a fresh hash to store in its place. newHash is
+// empty when the stored hash is current.
+func (h *PasswordHasher) VerifyAndRehash(password, hash string) (newHash string, err error) {
+	legacy, err := h.verify(password, hash)
+	if err != nil {
+		return "", err
+	}
+	if !legacy && !h.NeedsRehash(hash) {
+		return "", nil
+	}
+	return h.Hash(password)
+}
+
+// verify compares a password with a hash, falling back to the unpeppered
+// password for hashes created before a pepper was configured
+func (h *PasswordHasher) verify(password, hash string) (legacy bool, err error) {
+	err = bcrypt.CompareHashAndPassword([]byte(hash), h.prepare(password))
+	if err == nil || len(h.pepper) == 0 {
+		return false, err
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil {
+		return true, nil
+	}
+	return false, err
 }
 
 // NeedsRehash checks if password hash needs to be regenerated
@@ -72,6 +134,14 @@ func GenerateResetToken() (string, error) {
 	return GenerateRandomToken(32)
 }
 
+// HashToken returns the SHA-256 digest of a single-use token, for storing
+// tokens such as password reset tokens without keeping them usable from a
+// database dump
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 // GenerateAPIKey generates an API key
 func GenerateAPIKey() (string, error) {
 	return GenerateRandomToken(32)
@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPasswordHasherCost(t *testing.T) {
+	tests := []struct {
+		cost int
+		want int
+	}{
+		{MinCost, MinCost},
+		{11, 11},
+		{4, MinCost},
+		{0, MinCost},
+		{40, MaxCost},
+	}
+	for _, tt := range tests {
+		if got := NewPasswordHasher(tt.cost).Cost(); got != tt.want {
+			t.Errorf("NewPasswordHasher(%d).Cost() = %d, want %d", tt.cost, got, tt.want)
+		}
+	}
+
+	hash, err := NewPasswordHasher(11).Hash("correct-horse")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	if cost, _ := bcrypt.Cost([]byte(hash)); cost != 11 {
+		t.Fatalf("hash cost = %d, want 11", cost)
+	}
+	if _, err := NewPasswordHasher(MinCost).Hash(""); err == nil {
+		t.Fatal("hashed an empty password")
+	}
+}
+
+func TestPasswordHasherPepper(t *testing.T) {
+	hasher := NewPasswordHasher(MinCost)
+	hasher.SetPepper("pepper")
+
+	hash, err := hasher.Hash("correct-horse")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	if err := hasher.Verify("correct-horse", hash); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if err := hasher.Verify("wrong", hash); err == nil {
+		t.Fatal("verified a wrong password")
+	}
+
+	// The hash is useless without the pepper
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte("correct-horse")); err == nil {
+		t.Fatal("hash verifies without the pepper")
+	}
+	other := NewPasswordHasher(MinCost)
+	other.SetPepper("other-pepper")
+	if err := other.Verify("correct-horse", hash); err == nil {
+		t.Fatal("hash verifies with another pepper")
+	}
+
+	// Passwords past bcrypt's 72 bytes still differ once peppered
+	long := strings.Repeat("a", 80)
+	hash, err = hasher.Hash(long + "1")
+	if err != nil {
+		t.Fatalf("Hash long password: %v", err)
+	}
+	if err := hasher.Verify(long+"2", hash); err == nil {
+		t.Fatal("long passwords differing after 72 bytes matched")
+	}
+}
+
+func TestVerifyAndRehash(t *testing.T) {
+	old := NewPasswordHasher(MinCost)
+	oldHash, _ := old.Hash("correct-horse")
+
+	current := NewPasswordHasher(11)
+	current.SetPepper("pepper")
+	currentHash, _ := current.Hash("correct-horse")
+
+	tests := []struct {
+		name   string
+		hash   string
+		rehash bool
+	}{
+		{"old cost and no pepper", oldHash, true},
+		{"current", currentHash, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := current.NeedsRehash(tt.hash); got != (tt.hash == oldHash) {
+				t.Fatalf("NeedsRehash = %v", got)
+			}
+
+			newHash, err := current.VerifyAndRehash("correct-horse", tt.hash)
+			if err != nil {
+				t.Fatalf("VerifyAndRehash: %v", err)
+			}
+			if (newHash != "") != tt.rehash {
+				t.Fatalf("new hash = %q, want rehash %v", newHash, tt.rehash)
+			}
+			if newHash == "" {
+				return
+			}
+			if cost, _ := bcrypt.Cost([]byte(newHash)); cost != 11 {
+				t.Fatalf("new hash cost = %d, want 11", cost)
+			}
+			// The upgraded hash is peppered and current
+			if again, err := current.VerifyAndRehash("correct-horse", newHash); err != nil || again != "" {
+				t.Fatalf("upgraded hash: rehash %q, err %v", again, err)
+			}
+		})
+	}
+
+	if newHash, err := current.VerifyAndRehash("wrong", oldHash); err == nil || newHash != "" {
+		t.Fatalf("wrong password: new hash %q, err %v", newHash, err)
+	}
+	if !current.NeedsRehash("not a bcrypt hash") {
+		t.Fatal("an invalid hash doesn't need a rehash")
+	}
+}
@@ -2,6 +2,7 @@ package cache
 
 import (
 	"context"
+	"strings"
 	"time"
 )
 
@@ -115,3 +116,12 @@ func DefaultConfig() Config {
 		Timeout:    5 * time.Second,
 	}
 }
+
+// patternEscaper escapes the characters Keys patterns treat specially
+var patternEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`, "]", `\]`)
+
+// EscapePattern escapes s for use as a literal part of a Keys pattern, e.g.
+// a key prefix taken from user input
+func EscapePattern(s string) string {
+	return patternEscaper.Replace(s)
+}
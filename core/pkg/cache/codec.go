@@ -0,0 +1,130 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// Codec converts values to and from bytes for cache tiers that store them
+// outside the process, such as Redis
+type Codec interface {
+	// Encode serializes a value
+	Encode(value interface{}) ([]byte, error)
+
+	// Decode deserializes data into target, which must be a pointer. A
+	// *interface{} target receives the codec's natural representation.
+	Decode(data []byte, target interface{}) error
+}
+
+// JSONCodec encodes values as JSON. Decoded into an interface{}, objects
+// come back as map[string]interface{}, arrays as []interface{} and numbers
+// as float64; decode into a concrete type to get it back.
+type JSONCodec struct{}
+
+// Encode implements Codec
+func (JSONCodec) Encode(value interface{}) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+// Decode implements Codec
+func (JSONCodec) Decode(data []byte, target interface{}) error {
+	return json.Unmarshal(data, target)
+}
+
+// GobCodec encodes values with encoding/gob, keeping their concrete type:
+// decoded into an interface{}, a value comes back as the type it was set
+// with. Types other than the basic ones, map[string]interface{} and
+// []interface{} must be registered with gob.Register.
+type GobCodec struct{}
+
+func init() {
+	// Generic containers, e.g. decoded JSON, are common cache values
+	gob.Register(map[string]interface{}{})
+	gob.Register([]interface{}{})
+}
+
+// Encode implements Codec
+func (GobCodec) Encode(value interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	// Encoding through an interface records the concrete type
+	if err := gob.NewEncoder(&buf).Encode(&value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode implements Codec
+func (GobCodec) Decode(data []byte, target interface{}) error {
+	var value interface{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&value); err != nil {
+		return err
+	}
+	return assign(target, value)
+}
+
+// DefaultCodec is the codec used by tiers that aren't given one
+var DefaultCodec Codec = JSONCodec{}
+
+// GetAs retrieves a value as T. Tiers that serialize values may return a
+// generic form such as map[string]interface{} instead of the type that was
+// set; such values are converted to T through JSON.
+func GetAs[T any](ctx context.Context, c Cache, key string) (T, error) {
+	var result T
+
+	value, err := c.Get(ctx, key)
+	if err != nil {
+		return result, err
+	}
+
+	if typed, ok := value.(T); ok {
+		return typed, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err == nil {
+		err = json.Unmarshal(data, &result)
+	}
+	if err != nil {
+		return result, &CacheError{Op: "get", Key: key, Err: fmt.Errorf("cannot convert %T to %T: %w", value, result, err)}
+	}
+	return result, nil
+}
+
+// roundTrip encodes and decodes a value, giving it the form a tier using
+// codec returns it in
+func roundTrip(codec Codec, value interface{}) (interface{}, error) {
+	data, err := codec.Encode(value)
+	if err != nil {
+		return nil, err
+	}
+	var decoded interface{}
+	if err := codec.Decode(data, &decoded); err != nil {
+		return nil, err
+	}
+	return decoded, nil
+}
+
+// assign stores value in the variable target points to
+func assign(target, value interface{}) error {
+	ptr := reflect.ValueOf(target)
+	if ptr.Kind() != reflect.Pointer || ptr.IsNil() {
+		return fmt.Errorf("decode target must be a non-nil pointer, not %T", target)
+	}
+
+	dest := ptr.Elem()
+	if value == nil {
+		dest.Set(reflect.Zero(dest.Type()))
+		return nil
+	}
+
+	v := reflect.ValueOf(value)
+	if !v.Type().AssignableTo(dest.Type()) {
+		return fmt.Errorf("cannot decode %T into %s", value, dest.Type())
+	}
+	dest.Set(v)
+	return nil
+}
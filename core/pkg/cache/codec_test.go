@@ -0,0 +1,202 @@
+package cache
+
+import (
+	"context"
+	"encoding/gob"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testProfile struct {
+	Name   string
+	Age    int
+	Tags   []string
+	Joined time.Time
+}
+
+type unregisteredValue struct {
+	ID int
+}
+
+func init() {
+	gob.Register(testProfile{})
+}
+
+func newTestProfile() testProfile {
+	return testProfile{Name: "Jane", Age: 42, Tags: []string{"admin", "beta"}, Joined: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+func TestCodecRoundTripsStructs(t *testing.T) {
+	codecs := []struct {
+		name  string
+		codec Codec
+	}{
+		{"json", JSONCodec{}},
+		{"gob", GobCodec{}},
+	}
+	for _, tt := range codecs {
+		t.Run(tt.name, func(t *testing.T) {
+			want := newTestProfile()
+			data, err := tt.codec.Encode(want)
+			if err != nil {
+				t.Fatalf("Encode: %v", err)
+			}
+
+			var got testProfile
+			if err := tt.codec.Decode(data, &got); err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("decoded %+v, want %+v", got, want)
+			}
+
+			// Pointers to structs decode to the struct
+			data, err = tt.codec.Encode(&want)
+			if err != nil {
+				t.Fatalf("Encode pointer: %v", err)
+			}
+			got = testProfile{}
+			if err := tt.codec.Decode(data, &got); err != nil || !reflect.DeepEqual(got, want) {
+				t.Fatalf("decoded pointer = %+v, %v; want %+v", got, err, want)
+			}
+		})
+	}
+}
+
+func TestCodecDecodeIntoInterface(t *testing.T) {
+	profile := newTestProfile()
+	generic := map[string]interface{}{"name": "Jane", "tags": []interface{}{"admin"}}
+
+	tests := []struct {
+		name  string
+		codec Codec
+		value interface{}
+		want  interface{}
+	}{
+		{"json struct", JSONCodec{}, profile, map[string]interface{}{
+			"Name": "Jane", "Age": float64(42), "Tags": []interface{}{"admin", "beta"}, "Joined": "2024-03-01T12:00:00Z",
+		}},
+		{"json map", JSONCodec{}, generic, generic},
+		{"json number", JSONCodec{}, 7, float64(7)},
+		{"gob struct", GobCodec{}, profile, profile},
+		{"gob map", GobCodec{}, generic, generic},
+		{"gob number", GobCodec{}, 7, 7},
+		{"gob string", GobCodec{}, "hello", "hello"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := roundTrip(tt.codec, tt.value)
+			if err != nil {
+				t.Fatalf("roundTrip: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("decoded %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGobCodecErrors(t *testing.T) {
+	codec := GobCodec{}
+
+	if _, err := codec.Encode(unregisteredValue{ID: 1}); err == nil {
+		t.Fatal("encoded a type not registered with gob")
+	}
+
+	data, err := codec.Encode(newTestProfile())
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	var wrong string
+	if err := codec.Decode(data, &wrong); err == nil {
+		t.Fatal("decoded a testProfile into a string")
+	}
+	if err := codec.Decode(data, testProfile{}); err == nil {
+		t.Fatal("decoded into a non-pointer")
+	}
+	if err := codec.Decode([]byte("not gob"), new(interface{})); err == nil {
+		t.Fatal("decoded invalid data")
+	}
+}
+
+func TestGetAsConvertsGenericValues(t *testing.T) {
+	ctx := context.Background()
+	mc := NewMemoryCache(DefaultMemoryCacheConfig())
+	defer mc.Close()
+
+	want := newTestProfile()
+	decoded, _ := roundTrip(JSONCodec{}, want)
+	mc.Set(ctx, "generic", decoded, time.Minute)
+	mc.Set(ctx, "typed", want, time.Minute)
+	mc.Set(ctx, "text", "hello", time.Minute)
+
+	for _, key := range []string{"generic", "typed"} {
+		got, err := GetAs[testProfile](ctx, mc, key)
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Fatalf("GetAs %s = %+v, %v; want %+v", key, got, err, want)
+		}
+	}
+	if _, err := GetAs[testProfile](ctx, mc, "text"); err == nil {
+		t.Fatal("converted a string to a testProfile")
+	}
+	if _, err := GetAs[testProfile](ctx, mc, "missing"); err != ErrKeyNotFound {
+		t.Fatalf("GetAs missing = %v, want ErrKeyNotFound", err)
+	}
+}
+
+func TestMultiTierCodecNormalizesValues(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		codec Codec
+		want  func(testProfile) interface{}
+	}{
+		{"json", JSONCodec{}, func(p testProfile) interface{} {
+			v, _ := roundTrip(JSONCodec{}, p)
+			return v
+		}},
+		{"gob", GobCodec{}, func(p testProfile) interface{} { return p }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultMultiTierConfig()
+			config.WriteThru = false
+			config.WriteBack = true
+			config.Codec = tt.codec
+			mtc := NewMultiTierCache(config)
+			l1, l2 := NewMemoryCache(DefaultMemoryCacheConfig()), NewMemoryCache(DefaultMemoryCacheConfig())
+			mtc.AddTier(l1, TierL1)
+			mtc.AddTier(l2, TierL2)
+			defer mtc.Close()
+
+			profile := newTestProfile()
+			if err := mtc.Set(ctx, "profile", profile, time.Minute); err != nil {
+				t.Fatalf("Set: %v", err)
+			}
+			want := tt.want(newTestProfile())
+
+			// The caller's value isn't shared with the cache
+			profile.Tags[0] = "changed"
+			mtc.Flush(ctx)
+
+			// Every tier holds the same form, so a hit in either looks alike
+			for i, tier := range []Cache{l1, l2} {
+				got, err := tier.Get(ctx, "profile")
+				if err != nil || !reflect.DeepEqual(got, want) {
+					t.Fatalf("L%d holds %#v, %v; want %#v", i+1, got, err, want)
+				}
+			}
+			l1.Delete(ctx, "profile")
+			got, err := mtc.Get(ctx, "profile")
+			if err != nil || !reflect.DeepEqual(got, want) {
+				t.Fatalf("Get from L2 = %#v, %v; want %#v", got, err, want)
+			}
+
+			if err := mtc.Set(ctx, "bad", unregisteredValue{}, time.Minute); tt.name == "gob" && err == nil {
+				t.Fatal("set a value the codec can't encode")
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultLockTTL is used when Acquire or Refresh is called without a TTL
+const DefaultLockTTL = 30 * time.Second
+
+// lockKeyPrefix namespaces lock keys so they can't collide with cached values
+const lockKeyPrefix = "lock:"
+
+// Lock errors
+var (
+	ErrLockHeld        = errors.New("lock is held by another owner")
+	ErrLockNotHeld     = errors.New("lock is not held by this token")
+	ErrLockUnsupported = errors.New("cache does not support locking")
+)
+
+// Lock provides mutual exclusion across instances sharing a cache. Every
+// lock expires after its TTL so a crashed holder cannot block others forever;
+// long-running holders extend it with Refresh.
+type Lock interface {
+	// Acquire takes the lock without blocking and returns the owner token,
+	// or ErrLockHeld if someone else holds it
+	Acquire(ctx context.Context, key string, ttl time.Duration) (string, error)
+
+	// Release frees the lock if token still owns it
+	Release(ctx context.Context, key, token string) error
+
+	// Refresh resets the lock TTL if token still owns it
+	Refresh(ctx context.Context, key, token string, ttl time.Duration) error
+}
+
+// NewLock returns a Lock backed by c. Redis locks are shared by every
+// instance using the same server; memory locks only exclude within the
+// current process. A multi-tier cache locks in its last tier.
+func NewLock(c Cache) (Lock, error) {
+	switch backend := c.(type) {
+	case *RedisCache:
+		return &redisLock{client: backend.client}, nil
+	case *MemoryCache:
+		return &memoryLock{cache: backend}, nil
+	case *MultiTierCache:
+		// Lock in the slowest tier, the one instances share
+		backend.mu.RLock()
+		var shared Cache
+		if n := len(backend.tiers); n > 0 {
+			shared = backend.tiers[n-1].cache
+		}
+		backend.mu.RUnlock()
+		if shared == nil {
+			return nil, ErrLockUnsupported
+		}
+		return NewLock(shared)
+	default:
+		return nil, ErrLockUnsupported
+	}
+}
+
+// newLockToken generates a random owner token
+func newLockToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate lock token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func lockTTL(ttl time.Duration) time.Duration {
+	if ttl <= 0 {
+		return DefaultLockTTL
+	}
+	return ttl
+}
+
+// Redis
+
+// releaseScript deletes the lock only if it still holds the caller's token
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// refreshScript extends the lock only if it still holds the caller's token
+var refreshScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+type redisLock struct {
+	client *redis.Client
+}
+
+func (l *redisLock) Acquire(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	token, err := newLockToken()
+	if err != nil {
+		return "", err
+	}
+
+	ok, err := l.client.SetNX(ctx, lockKeyPrefix+key, token, lockTTL(ttl)).Result()
+	if err != nil {
+		return "", &CacheError{Op: "lock", Key: key, Err: err}
+	}
+	if !ok {
+		return "", ErrLockHeld
+	}
+	return token, nil
+}
+
+func (l *redisLock) Release(ctx context.Context, key, token string) error {
+	n, err := releaseScript.Run(ctx, l.client, []string{lockKeyPrefix + key}, token).Int64()
+	if err != nil {
+		return &CacheError{Op: "unlock", Key: key, Err: err}
+	}
+	if n == 0 {
+		return ErrLockNotHeld
+	}
+	return nil
+}
+
+func (l *redisLock) Refresh(ctx context.Context, key, token string, ttl time.Duration) error {
+	n, err := refreshScript.Run(ctx, l.client, []string{lockKeyPrefix + key}, token, lockTTL(ttl).Milliseconds()).Int64()
+	if err != nil {
+		return &CacheError{Op: "refresh", Key: key, Err: err}
+	}
+	if n == 0 {
+		return ErrLockNotHeld
+	}
+	return nil
+}
+
+// Memory
+
+// memoryLockEntry is a lock held in a MemoryCache
+type memoryLockEntry struct {
+	token     string
+	expiresAt time.Time
+}
+
+type memoryLock struct {
+	cache *MemoryCache
+}
+
+// heldBy returns the token currently owning key, or "" if the lock is free.
+// Caller must hold the cache mutex.
+func (l *memoryLock) heldBy(key string) string {
+	entry, found := l.cache.locks[key]
+	if !found {
+		return ""
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(l.cache.locks, key)
+		return ""
+	}
+	return entry.token
+}
+
+func (l *memoryLock) Acquire(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	token, err := newLockToken()
+	if err != nil {
+		return "", err
+	}
+
+	mc := l.cache
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	if mc.closed {
+		return "", ErrClosed
+	}
+	if l.heldBy(key) != "" {
+		return "", ErrLockHeld
+	}
+
+	mc.locks[key] = memoryLockEntry{token: token, expiresAt: time.Now().Add(lockTTL(ttl))}
+	return token, nil
+}
+
+func (l *memoryLock) Release(ctx context.Context, key, token string) error {
+	mc := l.cache
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	if mc.closed {
+		return ErrClosed
+	}
+	if token == "" || l.heldBy(key) != token {
+		return ErrLockNotHeld
+	}
+
+	delete(mc.locks, key)
+	return nil
+}
+
+func (l *memoryLock) Refresh(ctx context.Context, key, token string, ttl time.Duration) error {
+	mc := l.cache
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	if mc.closed {
+		return ErrClosed
+	}
+	if token == "" || l.heldBy(key) != token {
+		return ErrLockNotHeld
+	}
+
+	mc.locks[key] = memoryLockEntry{token: token, expiresAt: time.Now().Add(lockTTL(ttl))}
+	return nil
+}
@@ -0,0 +1,186 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestMemoryLock(t *testing.T, config MemoryCacheConfig) (*MemoryCache, Lock) {
+	t.Helper()
+
+	mc := NewMemoryCache(config)
+	t.Cleanup(func() { mc.Close() })
+
+	lock, err := NewLock(mc)
+	if err != nil {
+		t.Fatalf("NewLock: %v", err)
+	}
+	return mc, lock
+}
+
+func TestMemoryLockContention(t *testing.T) {
+	_, lock := newTestMemoryLock(t, DefaultMemoryCacheConfig())
+	ctx := context.Background()
+
+	const acquirers = 50
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var tokens []string
+	for i := 0; i < acquirers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			token, err := lock.Acquire(ctx, "job", time.Minute)
+			if errors.Is(err, ErrLockHeld) {
+				return
+			}
+			if err != nil {
+				t.Errorf("Acquire: %v", err)
+				return
+			}
+			mu.Lock()
+			tokens = append(tokens, token)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if len(tokens) != 1 {
+		t.Fatalf("%d acquirers won the lock, want 1", len(tokens))
+	}
+}
+
+func TestMemoryLockSharedAcrossLocks(t *testing.T) {
+	mc, first := newTestMemoryLock(t, DefaultMemoryCacheConfig())
+	ctx := context.Background()
+
+	second, err := NewLock(mc)
+	if err != nil {
+		t.Fatalf("NewLock: %v", err)
+	}
+
+	if _, err := first.Acquire(ctx, "job", time.Minute); err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	if _, err := second.Acquire(ctx, "job", time.Minute); !errors.Is(err, ErrLockHeld) {
+		t.Fatalf("Acquire through another Lock = %v, want ErrLockHeld", err)
+	}
+}
+
+func TestMemoryLockExpiry(t *testing.T) {
+	_, lock := newTestMemoryLock(t, DefaultMemoryCacheConfig())
+	ctx := context.Background()
+
+	stale, err := lock.Acquire(ctx, "job", 20*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	if _, err := lock.Acquire(ctx, "job", time.Minute); !errors.Is(err, ErrLockHeld) {
+		t.Fatalf("Acquire before expiry = %v, want ErrLockHeld", err)
+	}
+
+	time.Sleep(40 * time.Millisecond)
+
+	token, err := lock.Acquire(ctx, "job", time.Minute)
+	if err != nil {
+		t.Fatalf("Acquire after expiry: %v", err)
+	}
+	if err := lock.Release(ctx, "job", stale); !errors.Is(err, ErrLockNotHeld) {
+		t.Fatalf("Release with expired token = %v, want ErrLockNotHeld", err)
+	}
+	if err := lock.Release(ctx, "job", token); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+}
+
+func TestMemoryLockRelease(t *testing.T) {
+	_, lock := newTestMemoryLock(t, DefaultMemoryCacheConfig())
+	ctx := context.Background()
+
+	token, err := lock.Acquire(ctx, "job", time.Minute)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+
+	if err := lock.Release(ctx, "job", "not-the-token"); !errors.Is(err, ErrLockNotHeld) {
+		t.Fatalf("Release with wrong token = %v, want ErrLockNotHeld", err)
+	}
+	if _, err := lock.Acquire(ctx, "job", time.Minute); !errors.Is(err, ErrLockHeld) {
+		t.Fatalf("Acquire after failed release = %v, want ErrLockHeld", err)
+	}
+
+	if err := lock.Release(ctx, "job", token); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if err := lock.Release(ctx, "job", token); !errors.Is(err, ErrLockNotHeld) {
+		t.Fatalf("second Release = %v, want ErrLockNotHeld", err)
+	}
+	if _, err := lock.Acquire(ctx, "job", time.Minute); err != nil {
+		t.Fatalf("Acquire after release: %v", err)
+	}
+}
+
+func TestMemoryLockRefresh(t *testing.T) {
+	_, lock := newTestMemoryLock(t, DefaultMemoryCacheConfig())
+	ctx := context.Background()
+
+	token, err := lock.Acquire(ctx, "job", 30*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	if err := lock.Refresh(ctx, "job", token, time.Minute); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	if _, err := lock.Acquire(ctx, "job", time.Minute); !errors.Is(err, ErrLockHeld) {
+		t.Fatalf("Acquire after refresh = %v, want ErrLockHeld", err)
+	}
+	if err := lock.Refresh(ctx, "job", "not-the-token", time.Minute); !errors.Is(err, ErrLockNotHeld) {
+		t.Fatalf("Refresh with wrong token = %v, want ErrLockNotHeld", err)
+	}
+}
+
+func TestMemoryLockSurvivesEvictionAndClear(t *testing.T) {
+	config := DefaultMemoryCacheConfig()
+	config.MaxSize = 2
+	mc, lock := newTestMemoryLock(t, config)
+	ctx := context.Background()
+
+	token, err := lock.Acquire(ctx, "job", time.Minute)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		if err := mc.Set(ctx, fmt.Sprintf("key%d", i), i, time.Minute); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+	}
+	if err := mc.Clear(ctx); err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+
+	if _, err := lock.Acquire(ctx, "job", time.Minute); !errors.Is(err, ErrLockHeld) {
+		t.Fatalf("Acquire after eviction and Clear = %v, want ErrLockHeld", err)
+	}
+	if err := lock.Release(ctx, "job", token); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+}
+
+func TestMemoryLockClosed(t *testing.T) {
+	mc, lock := newTestMemoryLock(t, DefaultMemoryCacheConfig())
+	ctx := context.Background()
+
+	mc.Close()
+
+	if _, err := lock.Acquire(ctx, "job", time.Minute); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Acquire on closed cache = %v, want ErrClosed", err)
+	}
+}
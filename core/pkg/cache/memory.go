@@ -17,6 +17,10 @@ type MemoryCache struct {
 	config    Config
 	closed    bool
 	closeChan chan struct{}
+
+	// locks holds the locks taken through NewLock. They are kept apart from
+	// the cached items so eviction and Clear can never release them.
+	locks map[string]memoryLockEntry
 }
 
 // cacheItem represents an item in the cache
@@ -50,6 +54,7 @@ func NewMemoryCache(config MemoryCacheConfig) *MemoryCache {
 		maxSize:   config.MaxSize,
 		config:    config.Config,
 		closeChan: make(chan struct{}),
+		locks:     make(map[string]memoryLockEntry),
 	}
 	
 	// Start cleanup goroutine
@@ -104,6 +109,12 @@ func (mc *MemoryCache) Set(ctx context.Context, key string, value interface{}, t
 		ttl = mc.config.DefaultTTL
 	}
 	
+	mc.setLocked(key, value, ttl)
+	return nil
+}
+
+// setLocked stores a value; caller must hold mc.mu
+func (mc *MemoryCache) setLocked(key string, value interface{}, ttl time.Duration) {
 	// Calculate expiration time
 	var expiresAt time.Time
 	if ttl > 0 {
@@ -116,7 +127,7 @@ func (mc *MemoryCache) Set(ctx context.Context, key string, value interface{}, t
 		item.value = value
 		item.expiresAt = expiresAt
 		mc.lru.MoveToFront(elem)
-		return nil
+		return
 	}
 	
 	// Add new item
@@ -134,8 +145,6 @@ func (mc *MemoryCache) Set(ctx context.Context, key string, value interface{}, t
 	if mc.lru.Len() > mc.maxSize {
 		mc.evict()
 	}
-	
-	return nil
 }
 
 // Delete removes a value from the cache
@@ -297,6 +306,15 @@ func (mc *MemoryCache) Increment(ctx context.Context, key string, delta int64) (
 	}
 	
 	item := elem.Value.(*cacheItem)
+
+	// Expired counters start over instead of resurrecting the old value
+	if !item.expiresAt.IsZero() && time.Now().After(item.expiresAt) {
+		item.value = delta
+		item.expiresAt = time.Time{}
+		mc.lru.MoveToFront(elem)
+		return delta, nil
+	}
+
 	val, ok := item.value.(int64)
 	if !ok {
 		return 0, &CacheError{Op: "increment", Key: key, Err: ErrNotFound}
@@ -371,6 +389,7 @@ func (mc *MemoryCache) Close() error {
 	close(mc.closeChan)
 	mc.items = nil
 	mc.lru = nil
+	mc.locks = nil
 	
 	return nil
 }
@@ -429,14 +448,41 @@ func (mc *MemoryCache) cleanup() {
 	for _, elem := range toRemove {
 		mc.removeElement(elem)
 	}
+
+	for key, entry := range mc.locks {
+		if now.After(entry.expiresAt) {
+			delete(mc.locks, key)
+		}
+	}
 }
 
-// matchPattern performs simple pattern matching
+// matchPattern matches a key against a glob pattern where * matches any
+// run of characters, ? matches one character and \ escapes the character
+// after it, like Redis KEYS
 func matchPattern(str, pattern string) bool {
-	// Simple implementation - in production use proper glob matching
-	if pattern == "*" {
-		return true
+	s, p := 0, 0
+	star, next := -1, 0
+	for s < len(str) {
+		switch {
+		case p+1 < len(pattern) && pattern[p] == '\\' && pattern[p+1] == str[s]:
+			s++
+			p += 2
+		case p < len(pattern) && pattern[p] != '\\' && pattern[p] != '*' && (pattern[p] == '?' || pattern[p] == str[s]):
+			s++
+			p++
+		case p < len(pattern) && pattern[p] == '*':
+			star, next = p, s
+			p++
+		case star >= 0:
+			// Let the last * absorb one more character
+			next++
+			s, p = next, star+1
+		default:
+			return false
+		}
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
 	}
-	// TODO: Implement proper pattern matching
-	return str == pattern
+	return p == len(pattern)
 }
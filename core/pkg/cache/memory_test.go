@@ -0,0 +1,32 @@
+package cache
+
+import "testing"
+
+func TestMatchPattern(t *testing.T) {
+	tests := []struct {
+		key     string
+		pattern string
+		want    bool
+	}{
+		{"flags:beta:1", "*", true},
+		{"flags:beta:1", "flags:beta:*", true},
+		{"flags:beta:1", "flags:*:1", true},
+		{"flags:beta:12", "flags:beta:?", false},
+		{"flags:beta:1", "flags:beta:?", true},
+		{"flags:beta-2:1", "flags:beta:*", false},
+		{"flags:beta:1", "flags:beta", false},
+		{"flags:a:b:c", "*:c", true},
+		// Escaped characters match literally
+		{"flags:promo*:1", `flags:promo\*:*`, true},
+		{"flags:promo-summer:1", `flags:promo\*:*`, false},
+		{"flags:what?:1", `flags:what\?:*`, true},
+		{"flags:whatx:1", `flags:what\?:*`, false},
+		{`flags:a\b:1`, `flags:a\\b:*`, true},
+		{"flags:a:1", EscapePattern("flags:a") + ":*", true},
+	}
+	for _, tt := range tests {
+		if got := matchPattern(tt.key, tt.pattern); got != tt.want {
+			t.Errorf("matchPattern(%q, %q) = %v, want %v", tt.key, tt.pattern, got, tt.want)
+		}
+	}
+}
@@ -2,7 +2,10 @@ package cache
 
 import (
 	"context"
+	"errors"
+	"hash/fnv"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -17,13 +20,42 @@ const (
 
 // MultiTierCache implements a multi-tier caching strategy
 type MultiTierCache struct {
-	tiers      []cacheWithLevel
-	mu         sync.RWMutex
-	config     Config
-	promoteL1  bool // Promote hits to L1 cache
-	writeThru  bool // Write-through to all tiers
-	writeBack  bool // Write-back strategy
-	stats      Stats
+	tiers       []cacheWithLevel
+	mu          sync.RWMutex
+	config      Config
+	promoteL1   bool          // Promote hits to L1 cache
+	writeThru   bool          // Write-through to all tiers
+	writeBack   bool          // Write-back strategy
+	negativeTTL time.Duration // How long confirmed-missing keys are remembered
+	codec       Codec         // Normalizes values set in the cache, if set
+	stats       Stats
+
+	// Background promotions/write-backs run on a bounded pool of workers,
+	// one per shard; Flush and Close wait for them
+	shards []*writeShard
+	closed bool
+}
+
+// writeShard is a queue of background work run in order by one worker. Work
+// for a key always goes to the same shard, so its write-backs apply in the
+// order they were made.
+type writeShard struct {
+	tasks chan shardTask
+
+	mu      sync.Mutex
+	seq     uint64            // Sequence number of the last queued task
+	queued  map[string]int    // Number of queued tasks per key
+	dropped map[string]uint64 // Tasks of a key up to this sequence are skipped
+}
+
+// shardTask writes items to tiers, or is a barrier closed once the work
+// queued before it is done
+type shardTask struct {
+	tiers   []cacheWithLevel
+	items   map[string]interface{}
+	ttl     time.Duration
+	seq     uint64
+	barrier chan struct{}
 }
 
 type cacheWithLevel struct {
@@ -37,27 +69,89 @@ type MultiTierConfig struct {
 	PromoteL1 bool // Promote cache hits to L1
 	WriteThru bool // Write to all tiers immediately
 	WriteBack bool // Write to lower tiers asynchronously
+
+	// WriteBackWorkers is the number of goroutines performing write-backs
+	// and promotions, and WriteBackQueue how many may wait in total. Keys
+	// are spread over the workers by hash, so writes of a key apply in
+	// order. Once a worker's queue is full, writes to its keys block until
+	// it frees a slot and promotions are skipped. Zero values use the
+	// defaults.
+	WriteBackWorkers int
+	WriteBackQueue   int
+
+	// Codec, when set, gives values the form tiers using it return them
+	// in before they're stored: set the codec of the lower tiers (e.g.
+	// Redis) and Get returns the same form whichever tier has the value,
+	// and write-backs don't share values with the caller.
+	Codec Codec
+
+	// NegativeTTL is how long keys confirmed missing with SetMissing or
+	// GetOrLoad are remembered, so repeated lookups of a key that doesn't
+	// exist stop at the first tier. Keep it short; 0 disables negative caching.
+	NegativeTTL time.Duration
 }
 
+// negativeEntry is the value stored under keys confirmed missing. It's a
+// string so it survives tiers that serialize values.
+const negativeEntry = "\x00cache:missing\x00"
+
+// Default write-back pool size, used when the config leaves it zero
+const (
+	DefaultWriteBackWorkers = 4
+	DefaultWriteBackQueue   = 1000
+)
+
 // DefaultMultiTierConfig returns default configuration
 func DefaultMultiTierConfig() MultiTierConfig {
 	return MultiTierConfig{
-		Config:    DefaultConfig(),
-		PromoteL1: true,
-		WriteThru: true,
-		WriteBack: false,
+		Config:           DefaultConfig(),
+		PromoteL1:        true,
+		WriteThru:        true,
+		WriteBack:        false,
+		WriteBackWorkers: DefaultWriteBackWorkers,
+		WriteBackQueue:   DefaultWriteBackQueue,
 	}
 }
 
-// NewMultiTierCache creates a new multi-tier cache
+// NewMultiTierCache creates a new multi-tier cache and starts its
+// write-back workers; Close stops them
 func NewMultiTierCache(config MultiTierConfig) *MultiTierCache {
-	return &MultiTierCache{
-		tiers:     []cacheWithLevel{},
-		config:    config.Config,
-		promoteL1: config.PromoteL1,
-		writeThru: config.WriteThru,
-		writeBack: config.WriteBack,
+	workers := config.WriteBackWorkers
+	if workers <= 0 {
+		workers = DefaultWriteBackWorkers
 	}
+	queue := config.WriteBackQueue
+	if queue <= 0 {
+		queue = DefaultWriteBackQueue
+	}
+
+	perShard := queue / workers
+	if perShard < 1 {
+		perShard = 1
+	}
+
+	mtc := &MultiTierCache{
+		tiers:       []cacheWithLevel{},
+		config:      config.Config,
+		promoteL1:   config.PromoteL1,
+		writeThru:   config.WriteThru,
+		writeBack:   config.WriteBack,
+		negativeTTL: config.NegativeTTL,
+		codec:       config.Codec,
+		shards:      make([]*writeShard, workers),
+	}
+
+	for i := range mtc.shards {
+		shard := &writeShard{
+			tasks:   make(chan shardTask, perShard),
+			queued:  make(map[string]int),
+			dropped: make(map[string]uint64),
+		}
+		mtc.shards[i] = shard
+		go shard.run()
+	}
+
+	return mtc
 }
 
 // AddTier adds a cache tier
@@ -65,6 +159,10 @@ func (mtc *MultiTierCache) AddTier(cache Cache, level TierLevel) {
 	mtc.mu.Lock()
 	defer mtc.mu.Unlock()
 
+	if mtc.closed {
+		return
+	}
+
 	mtc.tiers = append(mtc.tiers, cacheWithLevel{
 		cache: cache,
 		level: level,
@@ -74,35 +172,45 @@ func (mtc *MultiTierCache) AddTier(cache Cache, level TierLevel) {
 	mtc.sortTiers()
 }
 
-// Get retrieves a value from the cache (tries L1, L2, L3 in order)
+// Get retrieves a value from the cache (tries L1, L2, L3 in order). Keys
+// remembered as missing are reported as ErrKeyNotFound.
 func (mtc *MultiTierCache) Get(ctx context.Context, key string) (interface{}, error) {
-	mtc.mu.RLock()
-	defer mtc.mu.RUnlock()
-
-	for i, tier := range mtc.tiers {
-		value, err := tier.cache.Get(ctx, key)
-		if err == nil {
-			mtc.stats.Hits++
-
-			// Promote to higher tiers
-			if mtc.promoteL1 && i > 0 {
-				go mtc.promoteToHigherTiers(key, value, i)
-			}
-
-			return value, nil
-		}
+	value, _, err := mtc.lookup(ctx, key)
+	return value, err
+}
 
-		// Continue to next tier if not found
-		if err == ErrKeyNotFound {
-			continue
-		}
+// GetOrLoad retrieves a value like Get, calling load on a miss and caching
+// what it returns for ttl. If load returns ErrKeyNotFound the key is
+// remembered as missing for NegativeTTL, so load isn't called for it again
+// until then or until the key is set.
+func (mtc *MultiTierCache) GetOrLoad(ctx context.Context, key string, ttl time.Duration, load func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	value, negative, err := mtc.lookup(ctx, key)
+	if err == nil || negative || !errors.Is(err, ErrKeyNotFound) {
+		return value, err
+	}
 
-		// Return other errors immediately
+	value, err = load(ctx)
+	if errors.Is(err, ErrKeyNotFound) {
+		mtc.SetMissing(ctx, key)
+		return nil, ErrKeyNotFound
+	}
+	if err != nil {
 		return nil, err
 	}
 
-	mtc.stats.Misses++
-	return nil, ErrKeyNotFound
+	// A failure to cache the value doesn't fail the load
+	mtc.Set(ctx, key, value, ttl)
+	return value, nil
+}
+
+// SetMissing remembers a key as missing for NegativeTTL, e.g. after the
+// backing store confirmed it doesn't exist. Setting the key clears it. It
+// does nothing when negative caching is disabled.
+func (mtc *MultiTierCache) SetMissing(ctx context.Context, key string) error {
+	if mtc.negativeTTL <= 0 {
+		return nil
+	}
+	return mtc.Set(ctx, key, negativeEntry, mtc.negativeTTL)
 }
 
 // Set stores a value in all cache tiers
@@ -114,6 +222,11 @@ func (mtc *MultiTierCache) Set(ctx context.Context, key string, value interface{
 		ttl = mtc.config.DefaultTTL
 	}
 
+	value, err := mtc.normalize(key, value)
+	if err != nil {
+		return err
+	}
+
 	if mtc.writeThru {
 		// Write to all tiers synchronously
 		for _, tier := range mtc.tiers {
@@ -131,18 +244,22 @@ func (mtc *MultiTierCache) Set(ctx context.Context, key string, value interface{
 		}
 
 		if mtc.writeBack && len(mtc.tiers) > 1 {
-			go mtc.writeToLowerTiers(key, value, ttl)
+			lower := mtc.snapshotTiers(1, len(mtc.tiers))
+			mtc.background(lower, map[string]interface{}{key: value}, ttl)
 		}
 	}
 
 	return nil
 }
 
-// Delete removes a value from all cache tiers
+// Delete removes a value from all cache tiers, dropping its pending
+// write-backs and promotions so they can't bring it back
 func (mtc *MultiTierCache) Delete(ctx context.Context, key string) error {
 	mtc.mu.RLock()
 	defer mtc.mu.RUnlock()
 
+	mtc.dropPending(key)
+
 	var lastErr error
 	for _, tier := range mtc.tiers {
 		if err := tier.cache.Delete(ctx, key); err != nil {
@@ -164,6 +281,10 @@ func (mtc *MultiTierCache) Exists(ctx context.Context, key string) (bool, error)
 			continue
 		}
 		if exists {
+			if mtc.negativeTTL > 0 {
+				value, err := tier.cache.Get(ctx, key)
+				return err == nil && !isNegative(value), nil
+			}
 			return true, nil
 		}
 	}
@@ -171,11 +292,16 @@ func (mtc *MultiTierCache) Exists(ctx context.Context, key string) (bool, error)
 	return false, nil
 }
 
-// Clear removes all values from all tiers
+// Clear removes all values from all tiers, dropping pending write-backs
+// and promotions
 func (mtc *MultiTierCache) Clear(ctx context.Context) error {
 	mtc.mu.RLock()
 	defer mtc.mu.RUnlock()
 
+	for _, shard := range mtc.shards {
+		shard.dropAll()
+	}
+
 	var lastErr error
 	for _, tier := range mtc.tiers {
 		if err := tier.cache.Clear(ctx); err != nil {
@@ -245,11 +371,8 @@ func (mtc *MultiTierCache) Increment(ctx context.Context, key string, delta int6
 
 	// Propagate to other tiers
 	if len(mtc.tiers) > 1 {
-		go func() {
-			for i := 1; i < len(mtc.tiers); i++ {
-				mtc.tiers[i].cache.Set(context.Background(), key, val, mtc.config.DefaultTTL)
-			}
-		}()
+		lower := mtc.snapshotTiers(1, len(mtc.tiers))
+		mtc.background(lower, map[string]interface{}{key: val}, mtc.config.DefaultTTL)
 	}
 
 	return val, nil
@@ -279,14 +402,26 @@ func (mtc *MultiTierCache) GetMulti(ctx context.Context, keys []string) (map[str
 			continue
 		}
 
-		// Collect found values
+		// Collect found values; keys remembered as missing aren't looked up further
+		found := make(map[string]interface{}, len(values))
+		missing := make(map[string]interface{})
 		for key, value := range values {
+			if isNegative(value) {
+				missing[key] = value
+				continue
+			}
+			found[key] = value
 			result[key] = value
 		}
 
 		// Promote to higher tiers if needed
-		if mtc.promoteL1 && i > 0 && len(values) > 0 {
-			go mtc.promoteMultiToHigherTiers(values, i)
+		if mtc.promoteL1 && i > 0 && len(found) > 0 {
+			higher := mtc.snapshotTiers(0, i)
+			mtc.tryBackground(higher, found, mtc.config.DefaultTTL)
+		}
+		if mtc.promoteL1 && i > 0 && len(missing) > 0 {
+			higher := mtc.snapshotTiers(0, i)
+			mtc.tryBackground(higher, missing, mtc.negativeTTL)
 		}
 
 		// Update remaining keys
@@ -311,6 +446,18 @@ func (mtc *MultiTierCache) SetMulti(ctx context.Context, items map[string]interf
 		ttl = mtc.config.DefaultTTL
 	}
 
+	if mtc.codec != nil {
+		normalized := make(map[string]interface{}, len(items))
+		for key, value := range items {
+			v, err := mtc.normalize(key, value)
+			if err != nil {
+				return err
+			}
+			normalized[key] = v
+		}
+		items = normalized
+	}
+
 	if mtc.writeThru {
 		// Write to all tiers
 		for _, tier := range mtc.tiers {
@@ -328,18 +475,22 @@ func (mtc *MultiTierCache) SetMulti(ctx context.Context, items map[string]interf
 		}
 
 		if mtc.writeBack && len(mtc.tiers) > 1 {
-			go mtc.setMultiToLowerTiers(items, ttl)
+			lower := mtc.snapshotTiers(1, len(mtc.tiers))
+			mtc.background(lower, items, ttl)
 		}
 	}
 
 	return nil
 }
 
-// DeleteMulti removes multiple values
+// DeleteMulti removes multiple values, dropping their pending write-backs
+// and promotions
 func (mtc *MultiTierCache) DeleteMulti(ctx context.Context, keys []string) error {
 	mtc.mu.RLock()
 	defer mtc.mu.RUnlock()
 
+	mtc.dropPending(keys...)
+
 	var lastErr error
 	for _, tier := range mtc.tiers {
 		if err := tier.cache.DeleteMulti(ctx, keys); err != nil {
@@ -356,8 +507,8 @@ func (mtc *MultiTierCache) Stats(ctx context.Context) (*Stats, error) {
 	defer mtc.mu.RUnlock()
 
 	combined := &Stats{
-		Hits:   mtc.stats.Hits,
-		Misses: mtc.stats.Misses,
+		Hits:   atomic.LoadUint64(&mtc.stats.Hits),
+		Misses: atomic.LoadUint64(&mtc.stats.Misses),
 	}
 
 	for _, tier := range mtc.tiers {
@@ -374,24 +525,121 @@ func (mtc *MultiTierCache) Stats(ctx context.Context) (*Stats, error) {
 	return combined, nil
 }
 
-// Close closes all cache tiers
+// Flush waits until the background write-backs and promotions queued
+// before the call are done, so every value set before it has reached the
+// lower tiers. Work queued meanwhile isn't waited for. It returns ctx's
+// error if ctx is done first.
+func (mtc *MultiTierCache) Flush(ctx context.Context) error {
+	mtc.mu.RLock()
+	if mtc.closed {
+		mtc.mu.RUnlock()
+		return nil
+	}
+	barriers, err := mtc.queueBarriers(ctx)
+	mtc.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	return waitBarriers(ctx, barriers)
+}
+
+// Close waits for pending background promotions and write-backs, stops the
+// workers, then closes all cache tiers
 func (mtc *MultiTierCache) Close() error {
 	mtc.mu.Lock()
-	defer mtc.mu.Unlock()
+	if mtc.closed {
+		mtc.mu.Unlock()
+		return nil
+	}
+	mtc.closed = true
+	tiers := mtc.tiers
+	mtc.tiers = nil
+	mtc.mu.Unlock()
+
+	// No new background work can be queued once closed is set
+	ctx := context.Background()
+	barriers, _ := mtc.queueBarriers(ctx)
+	waitBarriers(ctx, barriers)
+	for _, shard := range mtc.shards {
+		close(shard.tasks)
+	}
 
 	var lastErr error
-	for _, tier := range mtc.tiers {
+	for _, tier := range tiers {
 		if err := tier.cache.Close(); err != nil {
 			lastErr = err
 		}
 	}
 
-	mtc.tiers = nil
 	return lastErr
 }
 
 // Helper methods
 
+// lookup retrieves a value from the first tier that has it. negative
+// reports that the key was found remembered as missing.
+func (mtc *MultiTierCache) lookup(ctx context.Context, key string) (value interface{}, negative bool, err error) {
+	mtc.mu.RLock()
+	defer mtc.mu.RUnlock()
+
+	for i, tier := range mtc.tiers {
+		value, err := tier.cache.Get(ctx, key)
+		if err == nil {
+			negative := isNegative(value)
+			if negative {
+				atomic.AddUint64(&mtc.stats.Misses, 1)
+			} else {
+				atomic.AddUint64(&mtc.stats.Hits, 1)
+			}
+
+			// Promote to higher tiers
+			if mtc.promoteL1 && i > 0 {
+				ttl := mtc.config.DefaultTTL
+				if negative {
+					ttl = mtc.negativeTTL
+				}
+				higher := mtc.snapshotTiers(0, i)
+				mtc.tryBackground(higher, map[string]interface{}{key: value}, ttl)
+			}
+
+			if negative {
+				return nil, true, ErrKeyNotFound
+			}
+			return value, false, nil
+		}
+
+		// Continue to next tier if not found
+		if err == ErrKeyNotFound {
+			continue
+		}
+
+		// Return other errors immediately
+		return nil, false, err
+	}
+
+	atomic.AddUint64(&mtc.stats.Misses, 1)
+	return nil, false, ErrKeyNotFound
+}
+
+// normalize round-trips a value through the codec, if there is one
+func (mtc *MultiTierCache) normalize(key string, value interface{}) (interface{}, error) {
+	if mtc.codec == nil {
+		return value, nil
+	}
+	normalized, err := roundTrip(mtc.codec, value)
+	if err != nil {
+		return nil, &CacheError{Op: "set", Key: key, Err: err}
+	}
+	return normalized, nil
+}
+
+// isNegative reports whether a cached value marks its key as missing
+func isNegative(value interface{}) bool {
+	s, ok := value.(string)
+	return ok && s == negativeEntry
+}
+
 func (mtc *MultiTierCache) sortTiers() {
 	// Simple bubble sort by tier level
 	for i := 0; i < len(mtc.tiers); i++ {
@@ -403,30 +651,172 @@ func (mtc *MultiTierCache) sortTiers() {
 	}
 }
 
-func (mtc *MultiTierCache) promoteToHigherTiers(key string, value interface{}, fromTier int) {
-	ctx := context.Background()
-	for i := 0; i < fromTier; i++ {
-		mtc.tiers[i].cache.Set(ctx, key, value, mtc.config.DefaultTTL)
+// snapshotTiers copies tiers[from:to] so background goroutines don't share
+// the slice with AddTier/Close. Caller must hold mu.
+func (mtc *MultiTierCache) snapshotTiers(from, to int) []cacheWithLevel {
+	tiers := make([]cacheWithLevel, to-from)
+	copy(tiers, mtc.tiers[from:to])
+	return tiers
+}
+
+// background queues writing items to tiers, waiting for room in the queue
+// if it's full. Caller must hold mu.
+func (mtc *MultiTierCache) background(tiers []cacheWithLevel, items map[string]interface{}, ttl time.Duration) {
+	if mtc.closed {
+		return
+	}
+	for shard, part := range mtc.splitByShard(items) {
+		shard.tasks <- shard.task(tiers, part, ttl)
 	}
 }
 
-func (mtc *MultiTierCache) promoteMultiToHigherTiers(values map[string]interface{}, fromTier int) {
-	ctx := context.Background()
-	for i := 0; i < fromTier; i++ {
-		mtc.tiers[i].cache.SetMulti(ctx, values, mtc.config.DefaultTTL)
+// tryBackground queues writing items to tiers unless the queue is full.
+// Caller must hold mu.
+func (mtc *MultiTierCache) tryBackground(tiers []cacheWithLevel, items map[string]interface{}, ttl time.Duration) {
+	if mtc.closed {
+		return
+	}
+	for shard, part := range mtc.splitByShard(items) {
+		task := shard.task(tiers, part, ttl)
+		select {
+		case shard.tasks <- task:
+		default:
+			shard.finish(task)
+		}
 	}
 }
 
-func (mtc *MultiTierCache) writeToLowerTiers(key string, value interface{}, ttl time.Duration) {
-	ctx := context.Background()
-	for i := 1; i < len(mtc.tiers); i++ {
-		mtc.tiers[i].cache.Set(ctx, key, value, ttl)
+// shardFor returns the shard a key's background work runs on
+func (mtc *MultiTierCache) shardFor(key string) *writeShard {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return mtc.shards[h.Sum32()%uint32(len(mtc.shards))]
+}
+
+// splitByShard groups items by the shard their keys belong to
+func (mtc *MultiTierCache) splitByShard(items map[string]interface{}) map[*writeShard]map[string]interface{} {
+	parts := make(map[*writeShard]map[string]interface{})
+	for key, value := range items {
+		shard := mtc.shardFor(key)
+		if parts[shard] == nil {
+			parts[shard] = make(map[string]interface{})
+		}
+		parts[shard][key] = value
+	}
+	return parts
+}
+
+// dropPending skips the queued background work of keys. Caller must hold mu.
+func (mtc *MultiTierCache) dropPending(keys ...string) {
+	for _, key := range keys {
+		shard := mtc.shardFor(key)
+		shard.mu.Lock()
+		if shard.queued[key] > 0 {
+			shard.dropped[key] = shard.seq
+		}
+		shard.mu.Unlock()
+	}
+}
+
+// queueBarriers queues a barrier on every shard. Caller must hold mu.
+func (mtc *MultiTierCache) queueBarriers(ctx context.Context) ([]chan struct{}, error) {
+	barriers := make([]chan struct{}, 0, len(mtc.shards))
+	for _, shard := range mtc.shards {
+		barrier := make(chan struct{})
+		select {
+		case shard.tasks <- shardTask{barrier: barrier}:
+			barriers = append(barriers, barrier)
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
 	}
+	return barriers, nil
 }
 
-func (mtc *MultiTierCache) setMultiToLowerTiers(items map[string]interface{}, ttl time.Duration) {
+// waitBarriers waits until every barrier is reached or ctx is done
+func waitBarriers(ctx context.Context, barriers []chan struct{}) error {
+	for _, barrier := range barriers {
+		select {
+		case <-barrier:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// task records a write of items as queued and returns it
+func (ws *writeShard) task(tiers []cacheWithLevel, items map[string]interface{}, ttl time.Duration) shardTask {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	ws.seq++
+	for key := range items {
+		ws.queued[key]++
+	}
+	return shardTask{tiers: tiers, items: items, ttl: ttl, seq: ws.seq}
+}
+
+// finish records a task as no longer queued. Caller must hold ws.mu or be
+// the only user of the task.
+func (ws *writeShard) finish(task shardTask) {
+	if task.barrier != nil {
+		return
+	}
+	for key := range task.items {
+		ws.queued[key]--
+		if ws.queued[key] <= 0 {
+			delete(ws.queued, key)
+			delete(ws.dropped, key)
+		}
+	}
+}
+
+// dropAll skips all queued background work
+func (ws *writeShard) dropAll() {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	for key := range ws.queued {
+		ws.dropped[key] = ws.seq
+	}
+}
+
+// run performs the shard's queued work in order until Close. Writes happen
+// under the shard lock, so a Delete either drops a write or waits for it to
+// finish before deleting.
+func (ws *writeShard) run() {
 	ctx := context.Background()
-	for i := 1; i < len(mtc.tiers); i++ {
-		mtc.tiers[i].cache.SetMulti(ctx, items, ttl)
+	for task := range ws.tasks {
+		if task.barrier != nil {
+			close(task.barrier)
+			continue
+		}
+
+		ws.mu.Lock()
+		items := make(map[string]interface{}, len(task.items))
+		for key, value := range task.items {
+			if cutoff, ok := ws.dropped[key]; !ok || task.seq > cutoff {
+				items[key] = value
+			}
+		}
+		writeItems(ctx, task.tiers, items, task.ttl)
+		ws.finish(task)
+		ws.mu.Unlock()
+	}
+}
+
+// writeItems stores items in every tier
+func writeItems(ctx context.Context, tiers []cacheWithLevel, items map[string]interface{}, ttl time.Duration) {
+	if len(items) == 0 {
+		return
+	}
+	for _, tier := range tiers {
+		if len(items) == 1 {
+			for key, value := range items {
+				tier.cache.Set(ctx, key, value, ttl)
+			}
+			continue
+		}
+		tier.cache.SetMulti(ctx, items, ttl)
 	}
 }
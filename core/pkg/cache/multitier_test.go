@@ -0,0 +1,401 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// closeTrackingCache counts writes made to a tier after it was closed
+type closeTrackingCache struct {
+	Cache
+	closed         atomic.Bool
+	setsAfterClose atomic.Int64
+}
+
+func (c *closeTrackingCache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	if c.closed.Load() {
+		c.setsAfterClose.Add(1)
+	}
+	return c.Cache.Set(ctx, key, value, ttl)
+}
+
+func (c *closeTrackingCache) Close() error {
+	c.closed.Store(true)
+	return c.Cache.Close()
+}
+
+func newTrackedMemoryTier() *closeTrackingCache {
+	return &closeTrackingCache{Cache: NewMemoryCache(DefaultMemoryCacheConfig())}
+}
+
+func TestMultiTierConcurrentGetAddTierClose(t *testing.T) {
+	ctx := context.Background()
+
+	for round := 0; round < 20; round++ {
+		mtc := NewMultiTierCache(DefaultMultiTierConfig())
+		l1, l3 := newTrackedMemoryTier(), newTrackedMemoryTier()
+		mtc.AddTier(l1, TierL1)
+		mtc.AddTier(l3, TierL3)
+
+		// Only L3 has the values, so every Get promotes
+		for i := 0; i < 50; i++ {
+			l3.Set(ctx, fmt.Sprintf("key-%d", i), i, 0)
+		}
+
+		var wg sync.WaitGroup
+		var added []*closeTrackingCache
+		var addedMu sync.Mutex
+		for g := 0; g < 8; g++ {
+			wg.Add(1)
+			go func(g int) {
+				defer wg.Done()
+				for i := 0; i < 50; i++ {
+					mtc.Get(ctx, fmt.Sprintf("key-%d", (i+g)%50))
+				}
+			}(g)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 5; i++ {
+				tier := newTrackedMemoryTier()
+				addedMu.Lock()
+				added = append(added, tier)
+				addedMu.Unlock()
+				mtc.AddTier(tier, TierL2)
+			}
+		}()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := mtc.Close(); err != nil {
+				t.Errorf("Close: %v", err)
+			}
+		}()
+		wg.Wait()
+
+		// Close may have run before some AddTier calls, whose tiers it
+		// doesn't own
+		mtc.Close()
+		for _, tier := range append(added, l1, l3) {
+			if n := tier.setsAfterClose.Load(); n != 0 {
+				t.Fatalf("round %d: %d promotions written after Close", round, n)
+			}
+			if !tier.closed.Load() {
+				tier.Close()
+			}
+		}
+
+		if _, err := mtc.Get(ctx, "key-1"); err != ErrKeyNotFound {
+			t.Fatalf("Get after Close = %v, want ErrKeyNotFound", err)
+		}
+	}
+}
+
+// newNegativeTestCache returns a write-through cache with an L1 and an L2
+// memory tier remembering missing keys for negativeTTL
+func newNegativeTestCache(t *testing.T, negativeTTL time.Duration) (*MultiTierCache, Cache, Cache) {
+	t.Helper()
+
+	config := DefaultMultiTierConfig()
+	config.NegativeTTL = negativeTTL
+	mtc := NewMultiTierCache(config)
+	l1, l2 := NewMemoryCache(DefaultMemoryCacheConfig()), NewMemoryCache(DefaultMemoryCacheConfig())
+	mtc.AddTier(l1, TierL1)
+	mtc.AddTier(l2, TierL2)
+	t.Cleanup(func() { mtc.Close() })
+	return mtc, l1, l2
+}
+
+// countingLoader returns a load function reporting ErrKeyNotFound and the
+// number of times it ran
+func countingLoader() (func(context.Context) (interface{}, error), *int32) {
+	var calls int32
+	return func(context.Context) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		return nil, ErrKeyNotFound
+	}, &calls
+}
+
+func TestGetOrLoadCachesMissingKeys(t *testing.T) {
+	ctx := context.Background()
+	mtc, _, _ := newNegativeTestCache(t, 100*time.Millisecond)
+	load, calls := countingLoader()
+
+	for i := 0; i < 5; i++ {
+		if _, err := mtc.GetOrLoad(ctx, "user:42", time.Minute, load); err != ErrKeyNotFound {
+			t.Fatalf("GetOrLoad #%d = %v, want ErrKeyNotFound", i+1, err)
+		}
+	}
+	if n := atomic.LoadInt32(calls); n != 1 {
+		t.Fatalf("backing store looked up %d times, want once", n)
+	}
+
+	// The sentinel is never returned as a value
+	if _, err := mtc.Get(ctx, "user:42"); err != ErrKeyNotFound {
+		t.Fatalf("Get = %v, want ErrKeyNotFound", err)
+	}
+	if exists, _ := mtc.Exists(ctx, "user:42"); exists {
+		t.Fatal("Exists reported a key remembered as missing")
+	}
+	if values, _ := mtc.GetMulti(ctx, []string{"user:42"}); len(values) != 0 {
+		t.Fatalf("GetMulti = %v, want nothing", values)
+	}
+
+	// Once the negative TTL passes the store is asked again
+	time.Sleep(150 * time.Millisecond)
+	mtc.GetOrLoad(ctx, "user:42", time.Minute, load)
+	if n := atomic.LoadInt32(calls); n != 2 {
+		t.Fatalf("backing store looked up %d times after the negative TTL, want 2", n)
+	}
+}
+
+func TestSetClearsNegativeEntry(t *testing.T) {
+	ctx := context.Background()
+	mtc, l1, l2 := newNegativeTestCache(t, time.Minute)
+	load, calls := countingLoader()
+
+	mtc.GetOrLoad(ctx, "user:42", time.Minute, load)
+	if err := mtc.Set(ctx, "user:42", "Jane", time.Minute); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	value, err := mtc.GetOrLoad(ctx, "user:42", time.Minute, load)
+	if err != nil || value != "Jane" {
+		t.Fatalf("GetOrLoad after Set = %v, %v; want Jane", value, err)
+	}
+	for i, tier := range []Cache{l1, l2} {
+		if value, _ := tier.Get(ctx, "user:42"); value != "Jane" {
+			t.Fatalf("L%d holds %v, want Jane", i+1, value)
+		}
+	}
+	if n := atomic.LoadInt32(calls); n != 1 {
+		t.Fatalf("backing store looked up %d times, want once", n)
+	}
+}
+
+func TestNegativeEntryInLowerTierShortCircuits(t *testing.T) {
+	ctx := context.Background()
+	mtc, l1, l2 := newNegativeTestCache(t, time.Minute)
+	load, calls := countingLoader()
+
+	// Another instance sharing L2 confirmed the key missing
+	l2.Set(ctx, "user:42", negativeEntry, time.Minute)
+
+	if _, err := mtc.GetOrLoad(ctx, "user:42", time.Minute, load); err != ErrKeyNotFound {
+		t.Fatalf("GetOrLoad = %v, want ErrKeyNotFound", err)
+	}
+	if n := atomic.LoadInt32(calls); n != 0 {
+		t.Fatalf("backing store looked up %d times, want never", n)
+	}
+
+	// The negative entry is promoted with its own TTL
+	mtc.Flush(ctx)
+	if value, _ := l1.Get(ctx, "user:42"); !isNegative(value) {
+		t.Fatalf("L1 holds %v, want the negative entry", value)
+	}
+	if ttl, _ := l1.TTL(ctx, "user:42"); ttl > time.Minute {
+		t.Fatalf("promoted negative entry TTL = %s, want at most the negative TTL", ttl)
+	}
+}
+
+func TestNegativeCachingDisabled(t *testing.T) {
+	ctx := context.Background()
+	mtc, l1, _ := newNegativeTestCache(t, 0)
+	load, calls := countingLoader()
+
+	for i := 0; i < 3; i++ {
+		mtc.GetOrLoad(ctx, "user:42", time.Minute, load)
+	}
+	if n := atomic.LoadInt32(calls); n != 3 {
+		t.Fatalf("backing store looked up %d times, want every time", n)
+	}
+	if err := mtc.SetMissing(ctx, "user:42"); err != nil {
+		t.Fatalf("SetMissing: %v", err)
+	}
+	if exists, _ := l1.Exists(ctx, "user:42"); exists {
+		t.Fatal("SetMissing stored an entry with negative caching disabled")
+	}
+}
+
+// recordingCache records the values written to a tier, in order, and
+// counts writes made after it was closed. Writes wait for gate, if set.
+type recordingCache struct {
+	Cache
+	gate chan struct{}
+
+	mu               sync.Mutex
+	writes           map[string][]interface{}
+	closed           bool
+	writesAfterClose int
+}
+
+func newRecordingTier() *recordingCache {
+	return &recordingCache{Cache: NewMemoryCache(DefaultMemoryCacheConfig()), writes: make(map[string][]interface{})}
+}
+
+func (c *recordingCache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	return c.SetMulti(ctx, map[string]interface{}{key: value}, ttl)
+}
+
+func (c *recordingCache) SetMulti(ctx context.Context, items map[string]interface{}, ttl time.Duration) error {
+	if c.gate != nil {
+		<-c.gate
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		c.writesAfterClose++
+	}
+	for key, value := range items {
+		c.writes[key] = append(c.writes[key], value)
+	}
+	return c.Cache.SetMulti(ctx, items, ttl)
+}
+
+func (c *recordingCache) Close() error {
+	c.mu.Lock()
+	c.closed = true
+	c.mu.Unlock()
+	return c.Cache.Close()
+}
+
+// last returns the last value written to key
+func (c *recordingCache) last(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	writes := c.writes[key]
+	if len(writes) == 0 {
+		return nil, false
+	}
+	return writes[len(writes)-1], true
+}
+
+// newWriteBackTestCache returns a write-back cache with a memory L1 and a
+// recording L2, on a deliberately small pool
+func newWriteBackTestCache(l2 *recordingCache) *MultiTierCache {
+	config := DefaultMultiTierConfig()
+	config.WriteThru = false
+	config.WriteBack = true
+	config.WriteBackWorkers = 2
+	config.WriteBackQueue = 8
+	mtc := NewMultiTierCache(config)
+	mtc.AddTier(NewMemoryCache(DefaultMemoryCacheConfig()), TierL1)
+	mtc.AddTier(l2, TierL2)
+	return mtc
+}
+
+func TestWriteBackCloseWaitsForPendingWrites(t *testing.T) {
+	ctx := context.Background()
+	l2 := newRecordingTier()
+	mtc := newWriteBackTestCache(l2)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 10; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				key := fmt.Sprintf("key-%d-%d", g, i)
+				if err := mtc.Set(ctx, key, i, time.Minute); err != nil {
+					t.Errorf("Set %s: %v", key, err)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	if err := mtc.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	for g := 0; g < 10; g++ {
+		for i := 0; i < 100; i++ {
+			key := fmt.Sprintf("key-%d-%d", g, i)
+			if value, ok := l2.last(key); !ok || value != i {
+				t.Fatalf("L2 %s = %v, want %d", key, value, i)
+			}
+		}
+	}
+	if l2.writesAfterClose != 0 {
+		t.Fatalf("%d write-backs after L2 was closed", l2.writesAfterClose)
+	}
+}
+
+func TestWriteBackKeepsOrderOfAKey(t *testing.T) {
+	ctx := context.Background()
+	l2 := newRecordingTier()
+	mtc := newWriteBackTestCache(l2)
+	defer mtc.Close()
+
+	for i := 0; i < 200; i++ {
+		mtc.Set(ctx, "counter", i, time.Minute)
+	}
+	if err := mtc.Flush(ctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	l2.mu.Lock()
+	writes := l2.writes["counter"]
+	l2.mu.Unlock()
+	if len(writes) != 200 {
+		t.Fatalf("%d write-backs of counter, want 200", len(writes))
+	}
+	for i, value := range writes {
+		if value != i {
+			t.Fatalf("write-back %d of counter = %v, want them in order", i, value)
+		}
+	}
+}
+
+func TestFlushRespectsContext(t *testing.T) {
+	ctx := context.Background()
+	l2 := newRecordingTier()
+	l2.gate = make(chan struct{})
+	mtc := newWriteBackTestCache(l2)
+	defer mtc.Close()
+
+	// The write-back of a is stuck in L2
+	mtc.Set(ctx, "a", 1, time.Minute)
+	timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if err := mtc.Flush(timeout); err != context.DeadlineExceeded {
+		t.Fatalf("Flush = %v, want the deadline exceeded", err)
+	}
+
+	close(l2.gate)
+	if err := mtc.Flush(ctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if value, ok := l2.last("a"); !ok || value != 1 {
+		t.Fatalf("L2 a = %v, want 1", value)
+	}
+}
+
+func TestDeleteDropsPendingWriteBacks(t *testing.T) {
+	ctx := context.Background()
+	l2 := newRecordingTier()
+	mtc := newWriteBackTestCache(l2)
+	defer mtc.Close()
+
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		mtc.Set(ctx, key, i, time.Minute)
+		mtc.Delete(ctx, key)
+	}
+	if err := mtc.Flush(ctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	// Each write-back either was dropped or landed before the delete
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		if _, err := l2.Get(ctx, key); err != ErrKeyNotFound {
+			t.Fatalf("L2 %s: %v, want it deleted", key, err)
+		}
+	}
+}
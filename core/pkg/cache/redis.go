@@ -2,7 +2,7 @@ package cache
 
 import (
 	"context"
-	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -12,6 +12,7 @@ import (
 type RedisCache struct {
 	client *redis.Client
 	config Config
+	codec  Codec
 	stats  Stats
 }
 
@@ -27,6 +28,7 @@ type RedisCacheConfig struct {
 	DialTimeout  time.Duration
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
+	Codec        Codec // Serializes values; DefaultCodec if nil
 }
 
 // DefaultRedisCacheConfig returns the default Redis cache configuration
@@ -42,6 +44,7 @@ func DefaultRedisCacheConfig() RedisCacheConfig {
 		DialTimeout:  5 * time.Second,
 		ReadTimeout:  3 * time.Second,
 		WriteTimeout: 3 * time.Second,
+		Codec:        DefaultCodec,
 	}
 }
 
@@ -67,9 +70,15 @@ func NewRedisCache(config RedisCacheConfig) (*RedisCache, error) {
 		return nil, &CacheError{Op: "connect", Err: err}
 	}
 
+	codec := config.Codec
+	if codec == nil {
+		codec = DefaultCodec
+	}
+
 	return &RedisCache{
 		client: client,
 		config: config.Config,
+		codec:  codec,
 	}, nil
 }
 
@@ -86,14 +95,31 @@ func (rc *RedisCache) Get(ctx context.Context, key string) (interface{}, error)
 
 	rc.stats.Hits++
 
-	// Try to unmarshal as JSON
-	var result interface{}
-	if err := json.Unmarshal([]byte(val), &result); err != nil {
-		// Return as string if not JSON
-		return val, nil
+	value, err := rc.decode(val)
+	if err != nil {
+		return nil, &CacheError{Op: "get", Key: key, Err: err}
 	}
+	return value, nil
+}
 
-	return result, nil
+// GetInto retrieves a value, decoding it into target (a pointer) with the
+// codec. Unlike Get, it gives JSON-encoded values back their concrete type.
+func (rc *RedisCache) GetInto(ctx context.Context, key string, target interface{}) error {
+	val, err := rc.client.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		rc.stats.Misses++
+		return ErrKeyNotFound
+	}
+	if err != nil {
+		return &CacheError{Op: "get", Key: key, Err: err}
+	}
+
+	rc.stats.Hits++
+
+	if err := rc.codec.Decode(val, target); err != nil {
+		return &CacheError{Op: "get", Key: key, Err: err}
+	}
+	return nil
 }
 
 // Set stores a value in the cache with TTL
@@ -102,8 +128,7 @@ func (rc *RedisCache) Set(ctx context.Context, key string, value interface{}, tt
 		ttl = rc.config.DefaultTTL
 	}
 
-	// Serialize value to JSON
-	data, err := json.Marshal(value)
+	data, err := rc.codec.Encode(value)
 	if err != nil {
 		return &CacheError{Op: "set", Key: key, Err: err}
 	}
@@ -200,14 +225,12 @@ func (rc *RedisCache) GetMulti(ctx context.Context, keys []string) (map[string]i
 
 	result := make(map[string]interface{})
 	for i, val := range vals {
-		if val != nil {
-			// Try to unmarshal as JSON
-			var v interface{}
-			if err := json.Unmarshal([]byte(val.(string)), &v); err == nil {
-				result[keys[i]] = v
-			} else {
-				result[keys[i]] = val
+		if s, ok := val.(string); ok {
+			value, err := rc.decode(s)
+			if err != nil {
+				return nil, &CacheError{Op: "mget", Key: keys[i], Err: err}
 			}
+			result[keys[i]] = value
 		}
 	}
 
@@ -223,7 +246,7 @@ func (rc *RedisCache) SetMulti(ctx context.Context, items map[string]interface{}
 	pipe := rc.client.Pipeline()
 
 	for key, value := range items {
-		data, err := json.Marshal(value)
+		data, err := rc.codec.Encode(value)
 		if err != nil {
 			return &CacheError{Op: "mset", Key: key, Err: err}
 		}
@@ -285,6 +308,17 @@ func (rc *RedisCache) Close() error {
 	return rc.client.Close()
 }
 
+// decode decodes a stored value with the codec. Values the codec can't read,
+// such as values written by other clients or with another codec, are an
+// error rather than being passed off as strings.
+func (rc *RedisCache) decode(val string) (interface{}, error) {
+	var result interface{}
+	if err := rc.codec.Decode([]byte(val), &result); err != nil {
+		return nil, fmt.Errorf("cannot decode stored value: %w", err)
+	}
+	return result, nil
+}
+
 // Pipeline returns a Redis pipeline for batch operations
 func (rc *RedisCache) Pipeline() redis.Pipeliner {
 	return rc.client.Pipeline()
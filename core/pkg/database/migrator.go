@@ -25,6 +25,12 @@ func (m *Migrator) RegisterModels(models ...interface{}) {
 	m.models = append(m.models, models...)
 }
 
+// PreMigrator is implemented by models that prepare existing data before
+// their table is migrated, e.g. removing duplicates ahead of a new unique index
+type PreMigrator interface {
+	BeforeMigrate(db *gorm.DB) error
+}
+
 // AutoMigrate runs auto migration for all registered models
 func (m *Migrator) AutoMigrate() error {
 	if len(m.models) == 0 {
@@ -35,6 +41,11 @@ func (m *Migrator) AutoMigrate() error {
 	fmt.Printf("🔄 Running auto-migration for %d models...\n", len(m.models))
 
 	for _, model := range m.models {
+		if pre, ok := model.(PreMigrator); ok {
+			if err := pre.BeforeMigrate(m.db); err != nil {
+				return fmt.Errorf("failed to prepare model for migration: %w", err)
+			}
+		}
 		if err := m.db.AutoMigrate(model); err != nil {
 			return fmt.Errorf("failed to migrate model: %w", err)
 		}
@@ -0,0 +1,40 @@
+package database
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SortOption describes a single ORDER BY column
+type SortOption struct {
+	Column string
+	Desc   bool
+}
+
+// QueryOptions holds structured sorting and filtering for list queries.
+// Column names must come from a caller-side whitelist; they are quoted but never parsed.
+type QueryOptions struct {
+	Sort    []SortOption
+	Filters map[string]interface{} // column => value equality filters
+	Scopes  []func(*gorm.DB) *gorm.DB
+}
+
+// Apply applies the options to a query
+func (o QueryOptions) Apply(db *gorm.DB) *gorm.DB {
+	for column, value := range o.Filters {
+		db = db.Where(clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: column}, Value: value})
+	}
+
+	if len(o.Scopes) > 0 {
+		db = db.Scopes(o.Scopes...)
+	}
+
+	for _, s := range o.Sort {
+		db = db.Order(clause.OrderByColumn{
+			Column: clause.Column{Table: clause.CurrentTable, Name: s.Column},
+			Desc:   s.Desc,
+		})
+	}
+
+	return db
+}
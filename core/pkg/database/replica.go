@@ -0,0 +1,96 @@
+package database
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"gorm.io/gorm"
+)
+
+// Resolver routes queries between a primary database and read replicas
+type Resolver interface {
+	Primary() *gorm.DB
+	ReadReplica() *gorm.DB // A replica for a read-only query; the primary when there are none
+}
+
+// ReplicaSet is a Resolver that spreads reads over its replicas round-robin
+type ReplicaSet struct {
+	primary  *gorm.DB
+	replicas []*gorm.DB
+	next     atomic.Uint64
+}
+
+// NewReplicaSet creates a replica set. Without replicas all reads go to the
+// primary.
+func NewReplicaSet(primary *gorm.DB, replicas ...*gorm.DB) *ReplicaSet {
+	return &ReplicaSet{
+		primary:  primary,
+		replicas: replicas,
+	}
+}
+
+// Primary returns the primary database
+func (s *ReplicaSet) Primary() *gorm.DB {
+	return s.primary
+}
+
+// Replicas returns the read replicas
+func (s *ReplicaSet) Replicas() []*gorm.DB {
+	return s.replicas
+}
+
+// ReadReplica returns the next replica in turn
+func (s *ReplicaSet) ReadReplica() *gorm.DB {
+	if len(s.replicas) == 0 {
+		return s.primary
+	}
+	n := s.next.Add(1) - 1
+	return s.replicas[n%uint64(len(s.replicas))]
+}
+
+var (
+	resolverMu sync.RWMutex
+	resolver   Resolver
+)
+
+// SetResolver sets the resolver used by ReadReplica. nil sends every read
+// to the database it was made on.
+func SetResolver(r Resolver) {
+	resolverMu.Lock()
+	defer resolverMu.Unlock()
+	resolver = r
+}
+
+// GetResolver returns the resolver used by ReadReplica
+func GetResolver() Resolver {
+	resolverMu.RLock()
+	defer resolverMu.RUnlock()
+	return resolver
+}
+
+type primaryKey struct{}
+
+// UsePrimary marks ctx so reads made with it go to the primary. Use it where
+// a read must see the latest writes, e.g. before a read-modify-write.
+func UsePrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// usesPrimary reports whether ctx was marked with UsePrimary
+func usesPrimary(ctx context.Context) bool {
+	primary, _ := ctx.Value(primaryKey{}).(bool)
+	return primary
+}
+
+// ReadReplica returns the database for a read-only query on db: a replica
+// when db is the resolver's primary, or db itself when it is a transaction,
+// another database, or ctx was marked with UsePrimary. Replicas may lag
+// behind the primary.
+func ReadReplica(ctx context.Context, db *gorm.DB) *gorm.DB {
+	r := GetResolver()
+	if r == nil || usesPrimary(ctx) || r.Primary() != db {
+		return db.WithContext(ctx)
+	}
+	return r.ReadReplica().WithContext(ctx)
+}
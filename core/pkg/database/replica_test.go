@@ -0,0 +1,159 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+type widget struct {
+	ID   uint
+	Name string
+}
+
+// recordingResolver is a Resolver that counts the reads it routes
+type recordingResolver struct {
+	*ReplicaSet
+	reads int
+}
+
+func (r *recordingResolver) ReadReplica() *gorm.DB {
+	r.reads++
+	return r.ReplicaSet.ReadReplica()
+}
+
+// newWidgetDB opens a database holding a single widget with the given name
+func newWidgetDB(t *testing.T, name string) *gorm.DB {
+	t.Helper()
+
+	db := newTestDB(t)
+	if err := db.AutoMigrate(&widget{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if err := db.Create(&widget{ID: 1, Name: name}).Error; err != nil {
+		t.Fatalf("create widget: %v", err)
+	}
+	return db
+}
+
+// useResolver routes reads on primary through two replicas for the test
+func useResolver(t *testing.T) (*gorm.DB, *recordingResolver) {
+	t.Helper()
+
+	primary := newWidgetDB(t, "primary")
+	resolver := &recordingResolver{ReplicaSet: NewReplicaSet(primary, newWidgetDB(t, "replica-1"), newWidgetDB(t, "replica-2"))}
+	SetResolver(resolver)
+	t.Cleanup(func() { SetResolver(nil) })
+	return primary, resolver
+}
+
+func widgetName(t *testing.T, repo *BaseRepository[widget], ctx context.Context) string {
+	t.Helper()
+
+	found, err := repo.FindByID(ctx, 1)
+	if err != nil || found == nil {
+		t.Fatalf("FindByID = %v, %v", found, err)
+	}
+	return found.Name
+}
+
+func TestReadsGoToReplicasRoundRobin(t *testing.T) {
+	primary, resolver := useResolver(t)
+	repo := NewBaseRepository[widget](primary)
+	ctx := context.Background()
+
+	var names []string
+	for i := 0; i < 4; i++ {
+		names = append(names, widgetName(t, repo, ctx))
+	}
+	want := []string{"replica-1", "replica-2", "replica-1", "replica-2"}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("reads hit %v, want %v", names, want)
+		}
+	}
+	if resolver.reads != 4 {
+		t.Fatalf("resolver routed %d reads, want 4", resolver.reads)
+	}
+
+	if count, err := repo.Count(ctx, "name LIKE ?", "replica-%"); err != nil || count != 1 {
+		t.Fatalf("Count = %d, %v; want it read from a replica", count, err)
+	}
+}
+
+func TestWritesGoToPrimary(t *testing.T) {
+	primary, resolver := useResolver(t)
+	repo := NewBaseRepository[widget](primary)
+	ctx := context.Background()
+
+	if err := repo.Create(ctx, &widget{ID: 2, Name: "new"}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := repo.Update(ctx, &widget{ID: 1, Name: "renamed"}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if resolver.reads != 0 {
+		t.Fatalf("writes asked the resolver for %d replicas", resolver.reads)
+	}
+
+	var names []string
+	primary.Model(&widget{}).Order("id").Pluck("name", &names)
+	if len(names) != 2 || names[0] != "renamed" || names[1] != "new" {
+		t.Fatalf("primary holds %v, want the writes", names)
+	}
+	for _, replica := range resolver.Replicas() {
+		var count int64
+		replica.Model(&widget{}).Where("name IN ?", []string{"renamed", "new"}).Count(&count)
+		if count != 0 {
+			t.Fatal("a write reached a replica")
+		}
+	}
+}
+
+func TestStrongReadsGoToPrimary(t *testing.T) {
+	primary, resolver := useResolver(t)
+	repo := NewBaseRepository[widget](primary)
+	ctx := context.Background()
+
+	if name := widgetName(t, repo, UsePrimary(ctx)); name != "primary" {
+		t.Fatalf("UsePrimary read hit %s", name)
+	}
+
+	// Reads in a transaction see its writes
+	err := NewTxManager(primary).WithTransaction(ctx, func(tx *gorm.DB) error {
+		txRepo := repo.WithTx(tx)
+		if err := txRepo.Update(ctx, &widget{ID: 1, Name: "pending"}); err != nil {
+			return err
+		}
+		if name := widgetName(t, txRepo, ctx); name != "pending" {
+			t.Fatalf("read in the transaction hit %s", name)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithTransaction: %v", err)
+	}
+
+	// Repositories on other databases aren't routed
+	other := NewBaseRepository[widget](newWidgetDB(t, "other"))
+	if name := widgetName(t, other, ctx); name != "other" {
+		t.Fatalf("read on another database hit %s", name)
+	}
+	if resolver.reads != 0 {
+		t.Fatalf("resolver routed %d reads, want none", resolver.reads)
+	}
+}
+
+func TestReplicaSetWithoutReplicas(t *testing.T) {
+	primary := newWidgetDB(t, "primary")
+	set := NewReplicaSet(primary)
+	if set.ReadReplica() != primary {
+		t.Fatal("reads without replicas didn't go to the primary")
+	}
+
+	// Without a resolver reads use the repository's database
+	if name := widgetName(t, NewBaseRepository[widget](primary), context.Background()); name != "primary" {
+		t.Fatalf("read hit %s", name)
+	}
+}
@@ -18,7 +18,7 @@ type Repository[T any] interface {
 	FindByCondition(ctx context.Context, condition interface{}, args ...interface{}) ([]*T, error)
 	FindOne(ctx context.Context, condition interface{}, args ...interface{}) (*T, error)
 	Count(ctx context.Context, condition interface{}, args ...interface{}) (int64, error)
-	Paginate(ctx context.Context, page, pageSize int) ([]*T, int64, error)
+	Paginate(ctx context.Context, page, pageSize int, opts ...QueryOptions) ([]*T, int64, error)
 }
 
 // BaseRepository implements the Repository interface
@@ -36,6 +36,13 @@ func (r *BaseRepository[T]) GetDB() *gorm.DB {
 	return r.db
 }
 
+// ReadReplica returns the database for a read-only query, a read replica
+// unless the repository is in a transaction or ctx was marked with
+// UsePrimary
+func (r *BaseRepository[T]) ReadReplica(ctx context.Context) *gorm.DB {
+	return ReadReplica(ctx, r.db)
+}
+
 // WithTx returns a repository with a transaction
 func (r *BaseRepository[T]) WithTx(tx *gorm.DB) *BaseRepository[T] {
 	return &BaseRepository[T]{db: tx}
@@ -65,7 +72,7 @@ func (r *BaseRepository[T]) Delete(ctx context.Context, id interface{}) error {
 // FindByID finds an entity by ID
 func (r *BaseRepository[T]) FindByID(ctx context.Context, id interface{}) (*T, error) {
 	var entity T
-	err := r.db.WithContext(ctx).First(&entity, id).Error
+	err := r.ReadReplica(ctx).First(&entity, id).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
@@ -78,21 +85,21 @@ func (r *BaseRepository[T]) FindByID(ctx context.Context, id interface{}) (*T, e
 // FindAll finds all entities
 func (r *BaseRepository[T]) FindAll(ctx context.Context) ([]*T, error) {
 	var entities []*T
-	err := r.db.WithContext(ctx).Find(&entities).Error
+	err := r.ReadReplica(ctx).Find(&entities).Error
 	return entities, err
 }
 
 // FindByCondition finds entities by condition
 func (r *BaseRepository[T]) FindByCondition(ctx context.Context, condition interface{}, args ...interface{}) ([]*T, error) {
 	var entities []*T
-	err := r.db.WithContext(ctx).Where(condition, args...).Find(&entities).Error
+	err := r.ReadReplica(ctx).Where(condition, args...).Find(&entities).Error
 	return entities, err
 }
 
 // FindOne finds one entity by condition
 func (r *BaseRepository[T]) FindOne(ctx context.Context, condition interface{}, args ...interface{}) (*T, error) {
 	var entity T
-	err := r.db.WithContext(ctx).Where(condition, args...).First(&entity).Error
+	err := r.ReadReplica(ctx).Where(condition, args...).First(&entity).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
@@ -106,23 +113,28 @@ func (r *BaseRepository[T]) FindOne(ctx context.Context, condition interface{},
 func (r *BaseRepository[T]) Count(ctx context.Context, condition interface{}, args ...interface{}) (int64, error) {
 	var count int64
 	var entity T
-	err := r.db.WithContext(ctx).Model(&entity).Where(condition, args...).Count(&count).Error
+	err := r.ReadReplica(ctx).Model(&entity).Where(condition, args...).Count(&count).Error
 	return count, err
 }
 
-// Paginate returns paginated results
-func (r *BaseRepository[T]) Paginate(ctx context.Context, page, pageSize int) ([]*T, int64, error) {
+// Paginate returns paginated results, optionally sorted and filtered
+func (r *BaseRepository[T]) Paginate(ctx context.Context, page, pageSize int, opts ...QueryOptions) ([]*T, int64, error) {
 	var entities []*T
 	var total int64
 
 	offset := (page - 1) * pageSize
 
 	var entity T
-	if err := r.db.WithContext(ctx).Model(&entity).Count(&total).Error; err != nil {
+	query := r.ReadReplica(ctx).Model(&entity)
+	for _, opt := range opts {
+		query = opt.Apply(query)
+	}
+
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
-	err := r.db.WithContext(ctx).Offset(offset).Limit(pageSize).Find(&entities).Error
+	err := query.Offset(offset).Limit(pageSize).Find(&entities).Error
 	return entities, total, err
 }
 
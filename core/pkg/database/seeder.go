@@ -3,8 +3,11 @@ package database
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Seeder interface for database seeding
@@ -13,6 +16,24 @@ type Seeder interface {
 	Run(ctx context.Context) error
 }
 
+// DependentSeeder is a seeder that must run after other seeders
+type DependentSeeder interface {
+	Seeder
+	Dependencies() []string // Names of the seeders to run first
+}
+
+// SeederRun records that a seeder has run
+type SeederRun struct {
+	ID    uint      `gorm:"primarykey" json:"id"`
+	Name  string    `gorm:"size:255;uniqueIndex;not null" json:"name"`
+	RanAt time.Time `json:"ran_at"`
+}
+
+// TableName returns the seeder run table name
+func (SeederRun) TableName() string {
+	return "seeder_runs"
+}
+
 // SeederManager manages database seeders
 type SeederManager struct {
 	db      *gorm.DB
@@ -32,22 +53,166 @@ func (sm *SeederManager) Register(seeder Seeder) {
 	sm.seeders = append(sm.seeders, seeder)
 }
 
-// Run runs all registered seeders
+// Run runs the registered seeders that haven't run yet, after their
+// dependencies, and records each one that succeeds in seeder_runs
 func (sm *SeederManager) Run(ctx context.Context) error {
+	return sm.run(ctx, false)
+}
+
+// RunFresh runs every registered seeder, including those that already ran
+func (sm *SeederManager) RunFresh(ctx context.Context) error {
+	return sm.run(ctx, true)
+}
+
+func (sm *SeederManager) run(ctx context.Context, fresh bool) error {
 	if len(sm.seeders) == 0 {
 		fmt.Println("⚠️  No seeders registered")
 		return nil
 	}
 
-	fmt.Printf("🌱 Running %d seeders...\n", len(sm.seeders))
+	ordered, err := sm.order()
+	if err != nil {
+		return err
+	}
 
-	for _, seeder := range sm.seeders {
+	if err := sm.db.WithContext(ctx).AutoMigrate(&SeederRun{}); err != nil {
+		return fmt.Errorf("failed to migrate seeder runs: %w", err)
+	}
+
+	pending := ordered
+	if !fresh {
+		ran, err := sm.ranSeeders(ctx)
+		if err != nil {
+			return err
+		}
+
+		pending = make([]Seeder, 0, len(ordered))
+		for _, seeder := range ordered {
+			if !ran[seeder.Name()] {
+				pending = append(pending, seeder)
+			}
+		}
+	}
+
+	if len(pending) == 0 {
+		fmt.Println("✅ All seeders have already run")
+		return nil
+	}
+
+	fmt.Printf("🌱 Running %d seeders...\n", len(pending))
+
+	for _, seeder := range pending {
 		fmt.Printf("Running %s...\n", seeder.Name())
 		if err := seeder.Run(ctx); err != nil {
 			return fmt.Errorf("seeder %s failed: %w", seeder.Name(), err)
 		}
+
+		if err := sm.recordRun(ctx, seeder.Name()); err != nil {
+			return fmt.Errorf("failed to record seeder %s: %w", seeder.Name(), err)
+		}
 	}
 
 	fmt.Println("✅ Database seeding completed")
 	return nil
 }
+
+// order sorts the seeders so each runs after its dependencies. Seeders
+// that don't depend on each other keep their registration order.
+func (sm *SeederManager) order() ([]Seeder, error) {
+	byName := make(map[string]Seeder, len(sm.seeders))
+	for _, seeder := range sm.seeders {
+		if _, exists := byName[seeder.Name()]; exists {
+			return nil, fmt.Errorf("seeder %s is registered twice", seeder.Name())
+		}
+		byName[seeder.Name()] = seeder
+	}
+
+	for _, seeder := range sm.seeders {
+		for _, dep := range seederDependencies(seeder) {
+			if _, ok := byName[dep]; !ok {
+				return nil, fmt.Errorf("seeder %s depends on unknown seeder %s", seeder.Name(), dep)
+			}
+		}
+	}
+
+	ordered := make([]Seeder, 0, len(sm.seeders))
+	placed := make(map[string]bool, len(sm.seeders))
+	for len(ordered) < len(sm.seeders) {
+		progressed := false
+		for _, seeder := range sm.seeders {
+			if placed[seeder.Name()] || !allPlaced(seederDependencies(seeder), placed) {
+				continue
+			}
+			ordered = append(ordered, seeder)
+			placed[seeder.Name()] = true
+			progressed = true
+			break
+		}
+
+		if !progressed {
+			var cycle []string
+			for _, seeder := range sm.seeders {
+				if !placed[seeder.Name()] {
+					cycle = append(cycle, seeder.Name())
+				}
+			}
+			return nil, fmt.Errorf("seeder dependency cycle among %s", strings.Join(cycle, ", "))
+		}
+	}
+
+	return ordered, nil
+}
+
+// ranSeeders returns the names of the seeders that already ran
+func (sm *SeederManager) ranSeeders(ctx context.Context) (map[string]bool, error) {
+	var names []string
+	if err := sm.db.WithContext(ctx).Model(&SeederRun{}).Pluck("name", &names).Error; err != nil {
+		return nil, fmt.Errorf("failed to load seeder runs: %w", err)
+	}
+
+	ran := make(map[string]bool, len(names))
+	for _, name := range names {
+		ran[name] = true
+	}
+	return ran, nil
+}
+
+// recordRun records that a seeder ran, updating the time of a fresh re-run
+func (sm *SeederManager) recordRun(ctx context.Context, name string) error {
+	return RecordSeederRun(sm.db.WithContext(ctx), name)
+}
+
+// HasSeederRun reports whether the seeder with the given name has run.
+// Seeders run outside a SeederManager, such as module seeders, are tracked
+// in seeder_runs too, under names of their own.
+func HasSeederRun(db *gorm.DB, name string) (bool, error) {
+	var count int64
+	err := db.Model(&SeederRun{}).Where("name = ?", name).Count(&count).Error
+	return count > 0, err
+}
+
+// RecordSeederRun records in seeder_runs that the seeder with the given name
+// ran, updating the time of a re-run
+func RecordSeederRun(db *gorm.DB, name string) error {
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"ran_at"}),
+	}).Create(&SeederRun{Name: name, RanAt: time.Now()}).Error
+}
+
+// seederDependencies returns a seeder's dependencies, if it declares any
+func seederDependencies(seeder Seeder) []string {
+	if dependent, ok := seeder.(DependentSeeder); ok {
+		return dependent.Dependencies()
+	}
+	return nil
+}
+
+func allPlaced(names []string, placed map[string]bool) bool {
+	for _, name := range names {
+		if !placed[name] {
+			return false
+		}
+	}
+	return true
+}
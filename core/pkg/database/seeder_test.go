@@ -0,0 +1,194 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "seed.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	return db
+}
+
+// testSeeder records its runs in a shared log
+type testSeeder struct {
+	name string
+	deps []string
+	log  *[]string
+	err  error
+}
+
+func (s *testSeeder) Name() string           { return s.name }
+func (s *testSeeder) Dependencies() []string { return s.deps }
+
+func (s *testSeeder) Run(ctx context.Context) error {
+	*s.log = append(*s.log, s.name)
+	return s.err
+}
+
+// plainSeeder is a seeder without dependencies
+type plainSeeder struct{ *testSeeder }
+
+func (s plainSeeder) Name() string                  { return s.testSeeder.Name() }
+func (s plainSeeder) Run(ctx context.Context) error { return s.testSeeder.Run(ctx) }
+
+func TestSeederManagerOrdersByDependencies(t *testing.T) {
+	var log []string
+	sm := NewSeederManager(newTestDB(t))
+	sm.Register(&testSeeder{name: "admin", deps: []string{"roles", "permissions"}, log: &log})
+	sm.Register(&testSeeder{name: "settings", log: &log})
+	sm.Register(&testSeeder{name: "roles", deps: []string{"permissions"}, log: &log})
+	sm.Register(plainSeeder{&testSeeder{name: "permissions", log: &log}})
+	sm.Register(&testSeeder{name: "demo", log: &log})
+
+	if err := sm.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	// Independent seeders keep their registration order
+	if got := strings.Join(log, ","); got != "settings,permissions,roles,admin,demo" {
+		t.Fatalf("ran %s", got)
+	}
+}
+
+func TestSeederManagerRejectsInvalidDependencies(t *testing.T) {
+	tests := []struct {
+		name    string
+		seeders []*testSeeder
+		message string
+	}{
+		{"unknown", []*testSeeder{{name: "admin", deps: []string{"roles"}}}, "depends on unknown seeder roles"},
+		{"cycle", []*testSeeder{{name: "a", deps: []string{"b"}}, {name: "b", deps: []string{"a"}}, {name: "c"}}, "cycle among a, b"},
+		{"self", []*testSeeder{{name: "a", deps: []string{"a"}}}, "cycle among a"},
+		{"duplicate", []*testSeeder{{name: "a"}, {name: "a"}}, "registered twice"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var log []string
+			sm := NewSeederManager(newTestDB(t))
+			for _, seeder := range tt.seeders {
+				seeder.log = &log
+				sm.Register(seeder)
+			}
+
+			err := sm.Run(context.Background())
+			if err == nil || !strings.Contains(err.Error(), tt.message) {
+				t.Fatalf("Run = %v, want an error containing %q", err, tt.message)
+			}
+			if len(log) != 0 {
+				t.Fatalf("ran %v despite the invalid dependencies", log)
+			}
+		})
+	}
+}
+
+func TestSeederManagerRunsEachSeederOnce(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	var log []string
+
+	sm := NewSeederManager(db)
+	sm.Register(&testSeeder{name: "roles", log: &log})
+	sm.Register(&testSeeder{name: "admin", deps: []string{"roles"}, log: &log})
+	if err := sm.Run(ctx); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if err := sm.Run(ctx); err != nil {
+		t.Fatalf("second Run: %v", err)
+	}
+	if got := strings.Join(log, ","); got != "roles,admin" {
+		t.Fatalf("two runs ran %s, want each seeder once", got)
+	}
+
+	// A later deployment adds a seeder: only it runs, in a new manager too
+	log = nil
+	sm = NewSeederManager(db)
+	sm.Register(&testSeeder{name: "roles", log: &log})
+	sm.Register(&testSeeder{name: "admin", deps: []string{"roles"}, log: &log})
+	sm.Register(&testSeeder{name: "demo", deps: []string{"admin"}, log: &log})
+	if err := sm.Run(ctx); err != nil {
+		t.Fatalf("Run with a new seeder: %v", err)
+	}
+	if got := strings.Join(log, ","); got != "demo" {
+		t.Fatalf("ran %s, want only the new seeder", got)
+	}
+
+	// Fresh mode re-runs everything
+	log = nil
+	if err := sm.RunFresh(ctx); err != nil {
+		t.Fatalf("RunFresh: %v", err)
+	}
+	if got := strings.Join(log, ","); got != "roles,admin,demo" {
+		t.Fatalf("fresh run ran %s", got)
+	}
+
+	var runs int64
+	db.Model(&SeederRun{}).Count(&runs)
+	if runs != 3 {
+		t.Fatalf("%d seeder runs recorded, want 3", runs)
+	}
+}
+
+func TestSeederManagerRetriesFailedSeeders(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	var log []string
+
+	failing := &testSeeder{name: "admin", deps: []string{"roles"}, log: &log, err: errors.New("boom")}
+	sm := NewSeederManager(db)
+	sm.Register(&testSeeder{name: "roles", log: &log})
+	sm.Register(failing)
+	sm.Register(&testSeeder{name: "demo", deps: []string{"admin"}, log: &log})
+
+	if err := sm.Run(ctx); err == nil || !strings.Contains(err.Error(), "seeder admin failed") {
+		t.Fatalf("Run = %v, want the admin seeder's error", err)
+	}
+	if got := strings.Join(log, ","); got != "roles,admin" {
+		t.Fatalf("ran %s, want seeding to stop at the failure", got)
+	}
+
+	// The failed seeder and those after it run next time
+	log = nil
+	failing.err = nil
+	if err := sm.Run(ctx); err != nil {
+		t.Fatalf("Run after fixing the seeder: %v", err)
+	}
+	if got := strings.Join(log, ","); got != "admin,demo" {
+		t.Fatalf("ran %s, want admin and demo", got)
+	}
+}
+
+func TestRecordSeederRun(t *testing.T) {
+	db := newTestDB(t)
+	if err := db.AutoMigrate(&SeederRun{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	if ran, err := HasSeederRun(db, "module:blog/posts"); err != nil || ran {
+		t.Fatalf("HasSeederRun before recording = %v, %v", ran, err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := RecordSeederRun(db, "module:blog/posts"); err != nil {
+			t.Fatalf("RecordSeederRun: %v", err)
+		}
+	}
+	if ran, err := HasSeederRun(db, "module:blog/posts"); err != nil || !ran {
+		t.Fatalf("HasSeederRun after recording = %v, %v", ran, err)
+	}
+
+	var runs int64
+	db.Model(&SeederRun{}).Count(&runs)
+	if runs != 1 {
+		t.Fatalf("%d runs recorded, want 1", runs)
+	}
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"fmt"
+	"sync"
 
 	"gorm.io/gorm"
 )
@@ -17,11 +18,86 @@ func NewTxManager(db *gorm.DB) *TxManager {
 	return &TxManager{db: db}
 }
 
-// WithTransaction executes a function within a transaction
+type txKey struct{}
+
+// ContextWithTx marks ctx as running inside tx, so code that only receives
+// the context, such as seeders, can join the transaction with TxFromContext
+func ContextWithTx(ctx context.Context, tx *gorm.DB) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// TxFromContext returns the transaction set with ContextWithTx, or db if
+// ctx doesn't run in one
+func TxFromContext(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
+
+// afterCommit holds the functions to run once the transactions started by
+// WithTransaction commit, keyed by the transaction's connection, which its
+// savepoints share
+var afterCommit = struct {
+	sync.Mutex
+	hooks map[gorm.ConnPool][]func()
+}{hooks: make(map[gorm.ConnPool][]func())}
+
+// AfterCommit runs fn once the transaction of ctx (see ContextWithTx) has
+// committed, e.g. to drop cache entries only when the change is visible to
+// other connections. fn is dropped if the transaction rolls back. It runs
+// right away if ctx isn't in a transaction started by WithTransaction.
+func AfterCommit(ctx context.Context, fn func()) {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		afterCommit.Lock()
+		hooks, tracked := afterCommit.hooks[tx.Statement.ConnPool]
+		if tracked {
+			afterCommit.hooks[tx.Statement.ConnPool] = append(hooks, fn)
+		}
+		afterCommit.Unlock()
+		if tracked {
+			return
+		}
+	}
+	fn()
+}
+
+// WithTransaction executes a function within a transaction. If ctx runs in
+// one (see ContextWithTx), fn runs in a savepoint of it instead. Functions
+// passed to AfterCommit inside the transaction run once it commits.
 func (tm *TxManager) WithTransaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
-	return tm.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	if _, inTx := ctx.Value(txKey{}).(*gorm.DB); inTx {
+		return TxFromContext(ctx, tm.db).Transaction(func(tx *gorm.DB) error {
+			return fn(tx)
+		})
+	}
+
+	var conn gorm.ConnPool
+	defer func() {
+		afterCommit.Lock()
+		delete(afterCommit.hooks, conn)
+		afterCommit.Unlock()
+	}()
+
+	err := tm.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		conn = tx.Statement.ConnPool
+		afterCommit.Lock()
+		afterCommit.hooks[conn] = nil
+		afterCommit.Unlock()
 		return fn(tx)
 	})
+	if err != nil {
+		return err
+	}
+
+	afterCommit.Lock()
+	hooks := afterCommit.hooks[conn]
+	delete(afterCommit.hooks, conn)
+	afterCommit.Unlock()
+	for _, hook := range hooks {
+		hook()
+	}
+	return nil
 }
 
 // BeginTx starts a new transaction
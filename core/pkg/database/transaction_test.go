@@ -0,0 +1,57 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestAfterCommit(t *testing.T) {
+	tm := NewTxManager(newTestDB(t))
+	ctx := context.Background()
+
+	var ran []string
+	record := func(name string) func() {
+		return func() { ran = append(ran, name) }
+	}
+
+	// Outside a transaction hooks run right away
+	AfterCommit(ctx, record("now"))
+
+	err := tm.WithTransaction(ctx, func(tx *gorm.DB) error {
+		txCtx := ContextWithTx(ctx, tx)
+		AfterCommit(txCtx, record("outer"))
+
+		// A nested transaction's hooks wait for the outermost commit
+		err := tm.WithTransaction(txCtx, func(tx *gorm.DB) error {
+			AfterCommit(ContextWithTx(txCtx, tx), record("nested"))
+			return nil
+		})
+		if len(ran) != 1 {
+			t.Fatalf("hooks ran before the commit: %v", ran)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatalf("WithTransaction: %v", err)
+	}
+	if len(ran) != 3 || ran[1] != "outer" || ran[2] != "nested" {
+		t.Fatalf("ran %v, want now, outer, nested", ran)
+	}
+
+	// A rolled back transaction drops its hooks
+	ran = nil
+	failed := errors.New("failed")
+	err = tm.WithTransaction(ctx, func(tx *gorm.DB) error {
+		AfterCommit(ContextWithTx(ctx, tx), record("rolled back"))
+		return failed
+	})
+	if !errors.Is(err, failed) || len(ran) != 0 {
+		t.Fatalf("WithTransaction = %v, ran %v; want the error and no hooks", err, ran)
+	}
+	if len(afterCommit.hooks) != 0 {
+		t.Fatalf("%d transactions still tracked", len(afterCommit.hooks))
+	}
+}
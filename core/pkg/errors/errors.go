@@ -18,6 +18,7 @@ const (
 	ErrCodeConflict        ErrorCode = "CONFLICT"
 	ErrCodeValidation      ErrorCode = "VALIDATION_ERROR"
 	ErrCodeTooManyRequests ErrorCode = "TOO_MANY_REQUESTS"
+	ErrCodePayloadTooLarge ErrorCode = "PAYLOAD_TOO_LARGE"
 
 	// Authentication errors
 	ErrCodeInvalidCredentials ErrorCode = "INVALID_CREDENTIALS"
@@ -25,6 +26,7 @@ const (
 	ErrCodeTokenInvalid       ErrorCode = "TOKEN_INVALID"
 	ErrCodeAccountLocked      ErrorCode = "ACCOUNT_LOCKED"
 	ErrCodeAccountDisabled    ErrorCode = "ACCOUNT_DISABLED"
+	ErrCodeAccountDeleted     ErrorCode = "ACCOUNT_DELETED"
 
 	// Database errors
 	ErrCodeDatabaseConnection ErrorCode = "DATABASE_CONNECTION"
@@ -110,6 +112,10 @@ func NewInternal(message string) *AppError {
 	return New(ErrCodeInternal, message, http.StatusInternalServerError)
 }
 
+func NewPayloadTooLarge(message string) *AppError {
+	return New(ErrCodePayloadTooLarge, message, http.StatusRequestEntityTooLarge)
+}
+
 func NewValidationError(message string, details map[string]interface{}) *AppError {
 	return New(ErrCodeValidation, message, http.StatusUnprocessableEntity).WithDetails(details)
 }
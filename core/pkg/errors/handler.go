@@ -1,61 +1,63 @@
 package errors
 
 import (
+	stderrors "errors"
 	"net/http"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
 	"neonexcore/pkg/logger"
 )
 
-// ErrorResponse represents error response structure
+// ErrorResponse is the standard error envelope. It matches the shape of
+// api.Response so error and success bodies share the same fields.
 type ErrorResponse struct {
-	Error   string                 `json:"error"`
-	Message string                 `json:"message"`
-	Code    ErrorCode              `json:"code,omitempty"`
-	Details map[string]interface{} `json:"details,omitempty"`
+	Success   bool                   `json:"success"`
+	Message   string                 `json:"message"`
+	Code      ErrorCode              `json:"code,omitempty"`
+	Errors    map[string]interface{} `json:"errors,omitempty"`
+	Timestamp int64                  `json:"timestamp"`
 }
 
-// ErrorHandler creates global error handler middleware
+// ErrorHandler creates the global Fiber error handler. AppErrors keep their
+// status, message and details; Fiber errors keep their status; unique
+// constraint violations become a 409; anything else becomes a 500 with a
+// generic message so internals aren't leaked.
 func ErrorHandler(log logger.Logger) fiber.ErrorHandler {
 	return func(c *fiber.Ctx, err error) error {
-		// Default to 500 Internal Server Error
-		code := fiber.StatusInternalServerError
-		response := ErrorResponse{
-			Error:   "internal_server_error",
-			Message: "An unexpected error occurred",
-		}
-
-		// Check if it's a Fiber error
-		if fiberErr, ok := err.(*fiber.Error); ok {
-			code = fiberErr.Code
-			response.Message = fiberErr.Message
-			response.Error = http.StatusText(code)
-		}
-
-		// Check if it's our AppError
-		if appErr, ok := err.(*AppError); ok {
-			code = appErr.StatusCode
-			response.Code = appErr.Code
-			response.Message = appErr.Message
-			response.Error = string(appErr.Code)
-			response.Details = appErr.Details
+		code, response := errorResponse(err)
 
+		var appErr *AppError
+		var fiberErr *fiber.Error
+		switch {
+		case stderrors.As(err, &appErr):
 			// Log error with details
-			log.Error("Application error", logger.Fields{
+			fields := logger.Fields{
 				"code":        appErr.Code,
 				"message":     appErr.Message,
-				"status_code": appErr.StatusCode,
+				"status_code": code,
 				"path":        c.Path(),
 				"method":      c.Method(),
-			})
-
+			}
 			// Log underlying error if exists
 			if appErr.Err != nil {
-				log.Error("Underlying error", logger.Fields{
-					"error": appErr.Err.Error(),
-				})
+				fields["error"] = appErr.Err.Error()
 			}
-		} else {
+			if code >= fiber.StatusInternalServerError {
+				log.Error("Application error", fields)
+			} else {
+				log.Warn("Application error", fields)
+			}
+		case stderrors.As(err, &fiberErr):
+			// Routing errors (404, 405, ...) are expected; don't log them
+		case stderrors.Is(err, gorm.ErrDuplicatedKey):
+			log.Warn("Duplicate entry", logger.Fields{
+				"error":  err.Error(),
+				"path":   c.Path(),
+				"method": c.Method(),
+			})
+		default:
 			// Log unexpected errors
 			log.Error("Unexpected error", logger.Fields{
 				"error":  err.Error(),
@@ -69,6 +71,70 @@ func ErrorHandler(log logger.Logger) fiber.ErrorHandler {
 	}
 }
 
+// errorResponse maps an error to its status code and envelope
+func errorResponse(err error) (int, ErrorResponse) {
+	response := ErrorResponse{
+		Success:   false,
+		Message:   "An unexpected error occurred",
+		Code:      ErrCodeInternal,
+		Timestamp: time.Now().Unix(),
+	}
+
+	var appErr *AppError
+	if stderrors.As(err, &appErr) {
+		code := appErr.StatusCode
+		if code == 0 {
+			code = fiber.StatusInternalServerError
+		}
+		response.Message = appErr.Message
+		response.Code = appErr.Code
+		response.Errors = appErr.Details
+		return code, response
+	}
+
+	var fiberErr *fiber.Error
+	if stderrors.As(err, &fiberErr) {
+		response.Message = fiberErr.Message
+		response.Code = statusErrorCode(fiberErr.Code)
+		return fiberErr.Code, response
+	}
+
+	// Requires gorm.Config.TranslateError
+	if stderrors.Is(err, gorm.ErrDuplicatedKey) {
+		response.Message = "Resource already exists"
+		response.Code = ErrCodeDuplicateEntry
+		return fiber.StatusConflict, response
+	}
+
+	return fiber.StatusInternalServerError, response
+}
+
+// statusErrorCode returns the ErrorCode that corresponds to an HTTP status
+func statusErrorCode(status int) ErrorCode {
+	switch status {
+	case http.StatusBadRequest:
+		return ErrCodeBadRequest
+	case http.StatusUnauthorized:
+		return ErrCodeUnauthorized
+	case http.StatusForbidden:
+		return ErrCodeForbidden
+	case http.StatusNotFound:
+		return ErrCodeNotFound
+	case http.StatusConflict:
+		return ErrCodeConflict
+	case http.StatusUnprocessableEntity:
+		return ErrCodeValidation
+	case http.StatusRequestEntityTooLarge:
+		return ErrCodePayloadTooLarge
+	case http.StatusTooManyRequests:
+		return ErrCodeTooManyRequests
+	}
+	if status >= http.StatusInternalServerError {
+		return ErrCodeInternal
+	}
+	return ""
+}
+
 // RecoveryMiddleware recovers from panics
 func RecoveryMiddleware(log logger.Logger) fiber.Handler {
 	return func(c *fiber.Ctx) error {
@@ -80,12 +146,8 @@ func RecoveryMiddleware(log logger.Logger) fiber.Handler {
 					"method": c.Method(),
 				})
 
-				err := NewInternal("Internal server error")
-				c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
-					Error:   string(err.Code),
-					Message: err.Message,
-					Code:    err.Code,
-				})
+				code, response := errorResponse(NewInternal("Internal server error"))
+				c.Status(code).JSON(response)
 			}
 		}()
 		return c.Next()
@@ -0,0 +1,120 @@
+package errors
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"neonexcore/pkg/logger"
+)
+
+func TestErrorHandlerRendersEnvelope(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		status  int
+		code    ErrorCode
+		message string
+	}{
+		{"bad request", NewBadRequest("Invalid page"), fiber.StatusBadRequest, ErrCodeBadRequest, "Invalid page"},
+		{"unauthorized", NewUnauthorized("Token expired"), fiber.StatusUnauthorized, ErrCodeUnauthorized, "Token expired"},
+		{"forbidden", NewForbidden("Admins only"), fiber.StatusForbidden, ErrCodeForbidden, "Admins only"},
+		{"not found", NewNotFound("User not found"), fiber.StatusNotFound, ErrCodeNotFound, "User not found"},
+		{"conflict", NewConflict("Email taken"), fiber.StatusConflict, ErrCodeConflict, "Email taken"},
+		{"validation", NewValidationError("Validation failed", nil), fiber.StatusUnprocessableEntity, ErrCodeValidation, "Validation failed"},
+		{"custom code", New(ErrCodeAccountLocked, "Account locked", fiber.StatusForbidden), fiber.StatusForbidden, ErrCodeAccountLocked, "Account locked"},
+		{"wrapped app error", fmt.Errorf("load user: %w", NewNotFound("User not found")), fiber.StatusNotFound, ErrCodeNotFound, "User not found"},
+		{"fiber error", fiber.ErrMethodNotAllowed, fiber.StatusMethodNotAllowed, "", "Method Not Allowed"},
+		{"fiber error with code", fiber.NewError(fiber.StatusTooManyRequests, "Slow down"), fiber.StatusTooManyRequests, ErrCodeTooManyRequests, "Slow down"},
+		{"duplicate key", fmt.Errorf("create user: %w", gorm.ErrDuplicatedKey), fiber.StatusConflict, ErrCodeDuplicateEntry, "Resource already exists"},
+		{"unknown", stderrors.New("dial tcp 10.0.0.5:5432: connection refused"), fiber.StatusInternalServerError, ErrCodeInternal, "An unexpected error occurred"},
+		{"internal with cause", NewInternal("Failed to save").WithError(stderrors.New("disk full")), fiber.StatusInternalServerError, ErrCodeInternal, "Failed to save"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler(logger.Default())})
+			app.Get("/", func(c *fiber.Ctx) error { return tt.err })
+
+			resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+			if err != nil {
+				t.Fatalf("GET /: %v", err)
+			}
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			raw, _ := io.ReadAll(resp.Body)
+			var body ErrorResponse
+			if err := json.Unmarshal(raw, &body); err != nil {
+				t.Fatalf("decode %s: %v", raw, err)
+			}
+			if body.Success || body.Code != tt.code || body.Message != tt.message || body.Timestamp == 0 {
+				t.Fatalf("body = %+v, want code %q and message %q", body, tt.code, tt.message)
+			}
+			// Underlying errors stay in the logs
+			for _, internal := range []string{"10.0.0.5", "disk full", "create user"} {
+				if strings.Contains(string(raw), internal) {
+					t.Fatalf("body %s leaks %q", raw, internal)
+				}
+			}
+		})
+	}
+}
+
+func TestErrorHandlerValidationDetails(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler(logger.Default())})
+	app.Post("/", func(c *fiber.Ctx) error {
+		return NewValidationError("Validation failed", map[string]interface{}{
+			"email": "must be a valid email",
+		})
+	})
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodPost, "/", nil))
+	if err != nil {
+		t.Fatalf("POST /: %v", err)
+	}
+	var body ErrorResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if body.Errors["email"] != "must be a valid email" {
+		t.Fatalf("errors = %v, want the field details", body.Errors)
+	}
+
+	// Unmatched routes use the envelope too
+	resp, err = app.Test(httptest.NewRequest(fiber.MethodGet, "/missing", nil))
+	if err != nil {
+		t.Fatalf("GET /missing: %v", err)
+	}
+	body = ErrorResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusNotFound || body.Code != ErrCodeNotFound {
+		t.Fatalf("status %d, body %+v; want a 404 envelope", resp.StatusCode, body)
+	}
+}
+
+func TestRecoveryMiddlewareRendersEnvelope(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler(logger.Default())})
+	app.Use(RecoveryMiddleware(logger.Default()))
+	app.Get("/", func(c *fiber.Ctx) error { panic("nil map write") })
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+	if err != nil {
+		t.Fatalf("GET /: %v", err)
+	}
+	raw, _ := io.ReadAll(resp.Body)
+	var body ErrorResponse
+	if err := json.Unmarshal(raw, &body); err != nil {
+		t.Fatalf("decode %s: %v", raw, err)
+	}
+	if resp.StatusCode != fiber.StatusInternalServerError || body.Code != ErrCodeInternal || strings.Contains(string(raw), "nil map") {
+		t.Fatalf("status %d, body %s; want a generic 500 envelope", resp.StatusCode, raw)
+	}
+}
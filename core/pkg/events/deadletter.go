@@ -0,0 +1,140 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrDeadLetterNotFound is returned when a dead letter doesn't exist
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+// DeadLetter records an async event whose handler exhausted its retries
+type DeadLetter struct {
+	ID          uint      `gorm:"primarykey" json:"id"`
+	EventName   string    `gorm:"size:255;index" json:"event_name"`
+	HandlerName string    `gorm:"size:255" json:"handler_name"` // Name the handler was registered under
+	Payload     string    `gorm:"type:text" json:"payload"`
+	Error       string    `gorm:"type:text" json:"error"`
+	Attempts    int       `json:"attempts"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TableName returns the dead letter table name
+func (DeadLetter) TableName() string {
+	return "event_dead_letters"
+}
+
+// DeadLetterStore persists dead letters
+type DeadLetterStore interface {
+	Save(ctx context.Context, letter *DeadLetter) error
+	Get(ctx context.Context, id uint) (*DeadLetter, error)
+	Delete(ctx context.Context, id uint) error
+}
+
+// GormDeadLetterStore stores dead letters in the database
+type GormDeadLetterStore struct {
+	db *gorm.DB
+}
+
+// NewGormDeadLetterStore creates a database-backed dead letter store and migrates its table
+func NewGormDeadLetterStore(db *gorm.DB) (*GormDeadLetterStore, error) {
+	if err := db.AutoMigrate(&DeadLetter{}); err != nil {
+		return nil, err
+	}
+	return &GormDeadLetterStore{db: db}, nil
+}
+
+// Save creates or updates a dead letter
+func (s *GormDeadLetterStore) Save(ctx context.Context, letter *DeadLetter) error {
+	return s.db.WithContext(ctx).Save(letter).Error
+}
+
+// Get returns a dead letter by ID
+func (s *GormDeadLetterStore) Get(ctx context.Context, id uint) (*DeadLetter, error) {
+	var letter DeadLetter
+	err := s.db.WithContext(ctx).First(&letter, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrDeadLetterNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &letter, nil
+}
+
+// Delete removes a dead letter
+func (s *GormDeadLetterStore) Delete(ctx context.Context, id uint) error {
+	return s.db.WithContext(ctx).Delete(&DeadLetter{}, id).Error
+}
+
+// deadLetter records a handler that exhausted its retries
+func (d *EventDispatcher) deadLetter(ctx context.Context, event Event, handlerName string, attempts int, err error) {
+	d.mu.RLock()
+	store := d.deadLetters
+	d.mu.RUnlock()
+
+	if store == nil {
+		return
+	}
+
+	payload, marshalErr := json.Marshal(event.Data)
+	if marshalErr != nil {
+		payload = []byte(fmt.Sprintf("%q", fmt.Sprint(event.Data)))
+	}
+
+	store.Save(ctx, &DeadLetter{
+		EventName:   event.Name,
+		HandlerName: handlerName,
+		Payload:     string(payload),
+		Error:       err.Error(),
+		Attempts:    attempts,
+	})
+}
+
+// ReplayDeadLetter runs the failed handler of a dead letter again with the
+// stored payload. The payload is passed as decoded JSON, which typed
+// handlers accept. On success the dead letter is removed; on failure its
+// error and attempt count are updated.
+func (d *EventDispatcher) ReplayDeadLetter(ctx context.Context, id uint) error {
+	d.mu.RLock()
+	store := d.deadLetters
+	d.mu.RUnlock()
+
+	if store == nil {
+		return errors.New("no dead letter store configured")
+	}
+
+	letter, err := store.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	d.mu.RLock()
+	handler := d.findHandler(letter.EventName, letter.HandlerName)
+	d.mu.RUnlock()
+
+	if handler == nil {
+		return fmt.Errorf("handler %q for event %s is not registered", letter.HandlerName, letter.EventName)
+	}
+
+	var data interface{}
+	if err := json.Unmarshal([]byte(letter.Payload), &data); err != nil {
+		return fmt.Errorf("failed to decode dead letter payload: %w", err)
+	}
+
+	event := Event{Name: letter.EventName, Data: data}
+	if err := callHandler(ctx, handler, event); err != nil {
+		letter.Attempts++
+		letter.Error = err.Error()
+		store.Save(ctx, letter)
+		return err
+	}
+
+	return store.Delete(ctx, letter.ID)
+}
@@ -0,0 +1,183 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// newDeadLetterTestDispatcher returns a dispatcher retrying quickly into a
+// database dead letter store
+func newDeadLetterTestDispatcher(t *testing.T) (*EventDispatcher, *GormDeadLetterStore, *gorm.DB) {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "events.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	store, err := NewGormDeadLetterStore(db)
+	if err != nil {
+		t.Fatalf("NewGormDeadLetterStore: %v", err)
+	}
+
+	dispatcher := NewEventDispatcher()
+	dispatcher.SetRetryPolicy(RetryPolicy{MaxAttempts: 3, InitialBackoff: 5 * time.Millisecond, MaxBackoff: 20 * time.Millisecond})
+	dispatcher.SetDeadLetterStore(store)
+	return dispatcher, store, db
+}
+
+// waitForDeadLetters waits until the store holds want dead letters
+func waitForDeadLetters(t *testing.T, db *gorm.DB, want int) []DeadLetter {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		var letters []DeadLetter
+		if err := db.Order("id").Find(&letters).Error; err != nil {
+			t.Fatalf("list dead letters: %v", err)
+		}
+		if len(letters) >= want {
+			return letters
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d dead letters, want %d", len(letters), want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second}
+	for i, delay := range want {
+		if got := policy.backoff(i + 1); got != delay {
+			t.Fatalf("backoff(%d) = %s, want %s", i+1, got, delay)
+		}
+	}
+}
+
+func TestDispatchAsyncRetriesFlakyHandler(t *testing.T) {
+	dispatcher, _, db := newDeadLetterTestDispatcher(t)
+	var attempts atomic.Int32
+	succeeded := make(chan struct{})
+	dispatcher.Register(EventUserCreated, func(context.Context, Event) error {
+		if attempts.Add(1) < 3 {
+			return errors.New("mail server unavailable")
+		}
+		close(succeeded)
+		return nil
+	})
+
+	dispatcher.DispatchAsync(context.Background(), Event{Name: EventUserCreated, Data: UserCreatedEvent{UserID: 1}})
+
+	select {
+	case <-succeeded:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("handler didn't succeed after %d attempts", attempts.Load())
+	}
+	// Give a stray dead letter time to be written
+	time.Sleep(20 * time.Millisecond)
+	var count int64
+	db.Model(&DeadLetter{}).Count(&count)
+	if count != 0 {
+		t.Fatalf("%d dead letters, want none for a handler that recovered", count)
+	}
+}
+
+func TestDispatchAsyncDeadLettersFailingHandler(t *testing.T) {
+	dispatcher, _, db := newDeadLetterTestDispatcher(t)
+	var attempts atomic.Int32
+	dispatcher.RegisterNamed(EventUserCreated, "welcome-email", func(context.Context, Event) error {
+		attempts.Add(1)
+		return errors.New("mailbox full")
+	})
+	dispatcher.RegisterNamed(EventUserCreated, "audit", func(context.Context, Event) error {
+		panic("audit log closed")
+	})
+	var delivered atomic.Bool
+	dispatcher.RegisterNamed(EventUserCreated, "stats", func(context.Context, Event) error {
+		delivered.Store(true)
+		return nil
+	})
+
+	dispatcher.DispatchAsync(context.Background(), Event{
+		Name: EventUserCreated,
+		Data: UserCreatedEvent{UserID: 7, Email: "ada@example.com"},
+	})
+
+	letters := waitForDeadLetters(t, db, 2)
+	if len(letters) != 2 {
+		t.Fatalf("dead letters = %+v, want one per failing handler", letters)
+	}
+	byHandler := map[string]DeadLetter{}
+	for _, letter := range letters {
+		byHandler[letter.HandlerName] = letter
+	}
+
+	email := byHandler["welcome-email"]
+	if email.EventName != EventUserCreated || email.Attempts != 3 || email.Error != "mailbox full" {
+		t.Fatalf("welcome-email dead letter = %+v", email)
+	}
+	if email.Payload != `{"email":"ada@example.com","user_id":7}` {
+		t.Fatalf("payload = %s, want the event data", email.Payload)
+	}
+	if attempts.Load() != 3 {
+		t.Fatalf("%d attempts, want 3", attempts.Load())
+	}
+	if audit := byHandler["audit"]; audit.Error != "handler panicked: audit log closed" {
+		t.Fatalf("audit dead letter = %+v, want the panic recorded", audit)
+	}
+	if !delivered.Load() {
+		t.Fatal("a healthy handler missed the event")
+	}
+}
+
+func TestReplayDeadLetter(t *testing.T) {
+	dispatcher, store, db := newDeadLetterTestDispatcher(t)
+	dispatcher.SetRetryPolicy(RetryPolicy{MaxAttempts: 1})
+	var healthy atomic.Bool
+	var replayed atomic.Value
+	SubscribeTo(dispatcher, EventUserCreated, func(_ context.Context, e UserCreatedEvent) error {
+		if !healthy.Load() {
+			return errors.New("mail server unavailable")
+		}
+		replayed.Store(e)
+		return nil
+	})
+
+	dispatcher.DispatchAsync(context.Background(), Event{
+		Name: EventUserCreated,
+		Data: UserCreatedEvent{UserID: 7, Email: "ada@example.com"},
+	})
+	letter := waitForDeadLetters(t, db, 1)[0]
+
+	// Still failing: the dead letter is kept and updated
+	if err := dispatcher.ReplayDeadLetter(context.Background(), letter.ID); err == nil {
+		t.Fatal("replay succeeded while the handler fails")
+	}
+	updated, err := store.Get(context.Background(), letter.ID)
+	if err != nil || updated.Attempts != 2 {
+		t.Fatalf("dead letter after failed replay = %+v, %v; want 2 attempts", updated, err)
+	}
+
+	healthy.Store(true)
+	if err := dispatcher.ReplayDeadLetter(context.Background(), letter.ID); err != nil {
+		t.Fatalf("ReplayDeadLetter: %v", err)
+	}
+	if got, _ := replayed.Load().(UserCreatedEvent); got != (UserCreatedEvent{UserID: 7, Email: "ada@example.com"}) {
+		t.Fatalf("replayed payload = %+v", got)
+	}
+	if _, err := store.Get(context.Background(), letter.ID); !errors.Is(err, ErrDeadLetterNotFound) {
+		t.Fatalf("Get after replay: %v, want the dead letter removed", err)
+	}
+	if err := dispatcher.ReplayDeadLetter(context.Background(), letter.ID); !errors.Is(err, ErrDeadLetterNotFound) {
+		t.Fatalf("second replay: %v, want ErrDeadLetterNotFound", err)
+	}
+}
@@ -2,7 +2,10 @@ package events
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"reflect"
+	"runtime"
 	"sync"
 )
 
@@ -15,45 +18,177 @@ type Event struct {
 // Handler is a function that handles an event
 type Handler func(ctx context.Context, event Event) error
 
+// namedHandler is a registered handler with the name dead letters refer to
+// it by
+type namedHandler struct {
+	name    string
+	handler Handler
+}
+
+// ErrAborted is matched by errors from handlers that stopped propagation
+var ErrAborted = errors.New("event propagation aborted")
+
+// Abort returns an error that stops an event from reaching the remaining
+// handlers. Veto-style hooks such as "module.uninstalling" use it to block
+// the operation.
+func Abort(reason string) error {
+	return fmt.Errorf("%w: %s", ErrAborted, reason)
+}
+
 // EventDispatcher manages events and handlers
 type EventDispatcher struct {
-	mu       sync.RWMutex
-	handlers map[string][]Handler
+	mu          sync.RWMutex
+	handlers    map[string][]namedHandler
+	retry       RetryPolicy
+	deadLetters DeadLetterStore
 }
 
 // NewEventDispatcher creates a new event dispatcher
 func NewEventDispatcher() *EventDispatcher {
 	return &EventDispatcher{
-		handlers: make(map[string][]Handler),
+		handlers: make(map[string][]namedHandler),
+		retry:    DefaultRetryPolicy(),
 	}
 }
 
-// Register registers a handler for an event
+// SetRetryPolicy sets the retry policy for async handlers
+func (d *EventDispatcher) SetRetryPolicy(policy RetryPolicy) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.retry = policy
+}
+
+// SetDeadLetterStore sets where async events are recorded once a handler
+// exhausts its retries. Without a store such failures are dropped.
+func (d *EventDispatcher) SetDeadLetterStore(store DeadLetterStore) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.deadLetters = store
+}
+
+// Register registers a handler for an event. The handler is named after its
+// function, e.g. "neonexcore/modules/admin.RegisterEventListeners.func1",
+// for dead letters; use RegisterNamed to choose the name.
 func (d *EventDispatcher) Register(eventName string, handler Handler) {
+	d.RegisterNamed(eventName, handlerName(handler), handler)
+}
+
+// RegisterNamed registers a handler for an event under a name that stays the
+// same across restarts, so its dead letters are replayed to it whatever the
+// registration order. A name already taken for the event gets a "#2", "#3",
+// ... suffix.
+func (d *EventDispatcher) RegisterNamed(eventName, name string, handler Handler) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
-	d.handlers[eventName] = append(d.handlers[eventName], handler)
+	unique := name
+	for n := 2; d.findHandler(eventName, unique) != nil; n++ {
+		unique = fmt.Sprintf("%s#%d", name, n)
+	}
+	d.handlers[eventName] = append(d.handlers[eventName], namedHandler{name: unique, handler: handler})
+}
+
+// findHandler returns the named handler of an event, or nil. The caller
+// must hold d.mu.
+func (d *EventDispatcher) findHandler(eventName, name string) Handler {
+	for _, h := range d.handlers[eventName] {
+		if h.name == name {
+			return h.handler
+		}
+	}
+	return nil
+}
+
+// handlerName names a handler after its function
+func handlerName(fn interface{}) string {
+	if f := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()); f != nil {
+		return f.Name()
+	}
+	return "handler"
+}
+
+// Dispatch synchronously dispatches an event to its handlers
+func (d *EventDispatcher) Dispatch(ctx context.Context, name string, data interface{}) error {
+	return d.DispatchEvent(ctx, Event{Name: name, Data: data})
 }
 
-// Dispatch dispatches an event to all registered handlers
-func (d *EventDispatcher) Dispatch(ctx context.Context, event Event) error {
+// DispatchEvent synchronously invokes the event's handlers in registration
+// order. Every handler runs even if an earlier one fails, and all failures
+// are returned joined together. A handler returning an abort error (see
+// Abort) stops propagation; the returned error then matches ErrAborted.
+// Struct payloads reach handlers as maps; see Subscribe for typed handlers.
+func (d *EventDispatcher) DispatchEvent(ctx context.Context, event Event) error {
+	event.Data = mapPayload(event.Data)
+
 	d.mu.RLock()
 	handlers := d.handlers[event.Name]
 	d.mu.RUnlock()
 
-	for _, handler := range handlers {
-		if err := handler(ctx, event); err != nil {
-			return fmt.Errorf("handler failed for event %s: %w", event.Name, err)
+	var errs []error
+	for _, h := range handlers {
+		err := h.handler(ctx, event)
+		if err == nil {
+			continue
+		}
+
+		errs = append(errs, fmt.Errorf("handler failed for event %s: %w", event.Name, err))
+		if errors.Is(err, ErrAborted) {
+			break
 		}
 	}
 
-	return nil
+	return errors.Join(errs...)
+}
+
+// handlerFailure is a handler that failed an event, by registered name
+type handlerFailure struct {
+	name string
+	err  error
 }
 
-// DispatchAsync dispatches event asynchronously
+// dispatchRecovered invokes the event's handlers like DispatchEvent, but
+// recovers handler panics as errors and also returns each failed handler,
+// so background callers can dead-letter them
+func (d *EventDispatcher) dispatchRecovered(ctx context.Context, event Event) ([]handlerFailure, error) {
+	event.Data = mapPayload(event.Data)
+
+	d.mu.RLock()
+	handlers := d.handlers[event.Name]
+	d.mu.RUnlock()
+
+	var failures []handlerFailure
+	var errs []error
+	for _, h := range handlers {
+		err := callHandler(ctx, h.handler, event)
+		if err == nil {
+			continue
+		}
+
+		failures = append(failures, handlerFailure{name: h.name, err: err})
+		errs = append(errs, fmt.Errorf("handler failed for event %s: %w", event.Name, err))
+		if errors.Is(err, ErrAborted) {
+			break
+		}
+	}
+
+	return failures, errors.Join(errs...)
+}
+
+// DispatchAsync dispatches event asynchronously. Each handler runs
+// independently and is retried with backoff on errors or panics; a handler
+// that exhausts its retries is recorded in the dead letter store.
 func (d *EventDispatcher) DispatchAsync(ctx context.Context, event Event) {
-	go d.Dispatch(ctx, event)
+	event.Data = mapPayload(event.Data)
+
+	d.mu.RLock()
+	handlers := d.handlers[event.Name]
+	d.mu.RUnlock()
+
+	// Retries outlive the caller, e.g. an HTTP request
+	ctx = context.WithoutCancel(ctx)
+	for _, h := range handlers {
+		go d.runWithRetry(ctx, h.name, h.handler, event)
+	}
 }
 
 // HasHandlers checks if event has handlers
@@ -69,9 +204,13 @@ const (
 	EventUserCreated       = "user.created"
 	EventUserUpdated       = "user.updated"
 	EventUserDeleted       = "user.deleted"
+	EventUserRestored      = "user.restored"
 	EventUserLoggedIn      = "user.logged_in"
 	EventUserLoggedOut     = "user.logged_out"
 	EventUserPasswordReset = "user.password_reset"
+	EventUserVerifyRequest = "user.verification_requested"
+	EventUserEmailVerified = "user.email_verified"
+	EventUserLockedOut     = "user.locked_out"
 
 	// Module events
 	EventModuleInstalled   = "module.installed"
@@ -80,6 +219,14 @@ const (
 	EventModuleDeactivated = "module.deactivated"
 	EventModuleUpdated     = "module.updated"
 
+	// RBAC events
+	EventRBACRoleAssigned           = "rbac.role_assigned"
+	EventRBACRoleRemoved            = "rbac.role_removed"
+	EventRBACPermissionAssigned     = "rbac.permission_assigned"
+	EventRBACPermissionRemoved      = "rbac.permission_removed"
+	EventRBACRolePermissionAttached = "rbac.role_permission_attached"
+	EventRBACRolePermissionDetached = "rbac.role_permission_detached"
+
 	// System events
 	EventSystemStarted  = "system.started"
 	EventSystemShutdown = "system.shutdown"
@@ -88,17 +235,48 @@ const (
 // Global dispatcher instance
 var defaultDispatcher = NewEventDispatcher()
 
+// DefaultDispatcher returns the global dispatcher, for components that take
+// a dispatcher but should publish to the global listeners
+func DefaultDispatcher() *EventDispatcher {
+	return defaultDispatcher
+}
+
 // Register registers a global event handler
 func Register(eventName string, handler Handler) {
 	defaultDispatcher.Register(eventName, handler)
 }
 
-// Dispatch dispatches a global event
-func Dispatch(ctx context.Context, event Event) error {
-	return defaultDispatcher.Dispatch(ctx, event)
+// RegisterNamed registers a global event handler under a stable name
+func RegisterNamed(eventName, name string, handler Handler) {
+	defaultDispatcher.RegisterNamed(eventName, name, handler)
+}
+
+// Dispatch synchronously dispatches a global event
+func Dispatch(ctx context.Context, name string, data interface{}) error {
+	return defaultDispatcher.Dispatch(ctx, name, data)
+}
+
+// DispatchEvent synchronously dispatches a global event
+func DispatchEvent(ctx context.Context, event Event) error {
+	return defaultDispatcher.DispatchEvent(ctx, event)
 }
 
 // DispatchAsync dispatches a global event asynchronously
 func DispatchAsync(ctx context.Context, event Event) {
 	defaultDispatcher.DispatchAsync(ctx, event)
 }
+
+// SetRetryPolicy sets the retry policy for global async handlers
+func SetRetryPolicy(policy RetryPolicy) {
+	defaultDispatcher.SetRetryPolicy(policy)
+}
+
+// SetDeadLetterStore sets the dead letter store of the global dispatcher
+func SetDeadLetterStore(store DeadLetterStore) {
+	defaultDispatcher.SetDeadLetterStore(store)
+}
+
+// ReplayDeadLetter replays a dead letter on the global dispatcher
+func ReplayDeadLetter(ctx context.Context, id uint) error {
+	return defaultDispatcher.ReplayDeadLetter(ctx, id)
+}
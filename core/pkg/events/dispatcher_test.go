@@ -0,0 +1,91 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// orderRecorder returns a handler that appends label to calls and returns err
+func orderRecorder(calls *[]string, label string, err error) Handler {
+	return func(context.Context, Event) error {
+		*calls = append(*calls, label)
+		return err
+	}
+}
+
+func TestDispatchRunsHandlersInOrder(t *testing.T) {
+	dispatcher := NewEventDispatcher()
+	var calls []string
+	for _, label := range []string{"first", "second", "third"} {
+		dispatcher.Register("order.placed", orderRecorder(&calls, label, nil))
+	}
+	var received Event
+	dispatcher.Register("order.placed", func(_ context.Context, event Event) error {
+		received = event
+		return nil
+	})
+
+	if err := dispatcher.Dispatch(context.Background(), "order.placed", map[string]interface{}{"order_id": 7}); err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+	if got := fmt.Sprint(calls); got != "[first second third]" {
+		t.Fatalf("calls = %s, want registration order", got)
+	}
+	if received.Name != "order.placed" || received.Data.(map[string]interface{})["order_id"] != 7 {
+		t.Fatalf("received %+v", received)
+	}
+
+	// Events without handlers are fine
+	if err := dispatcher.Dispatch(context.Background(), "order.cancelled", nil); err != nil {
+		t.Fatalf("Dispatch without handlers: %v", err)
+	}
+}
+
+func TestDispatchAggregatesErrors(t *testing.T) {
+	dispatcher := NewEventDispatcher()
+	errStock := errors.New("out of stock")
+	errPayment := errors.New("payment declined")
+	var calls []string
+	dispatcher.Register("order.placed", orderRecorder(&calls, "stock", errStock))
+	dispatcher.Register("order.placed", orderRecorder(&calls, "email", nil))
+	dispatcher.Register("order.placed", orderRecorder(&calls, "payment", errPayment))
+
+	err := dispatcher.Dispatch(context.Background(), "order.placed", nil)
+	if !errors.Is(err, errStock) || !errors.Is(err, errPayment) {
+		t.Fatalf("error = %v, want both handler errors", err)
+	}
+	if errors.Is(err, ErrAborted) {
+		t.Fatalf("error = %v, want no abort", err)
+	}
+	if !strings.Contains(err.Error(), "handler failed for event order.placed") {
+		t.Fatalf("error = %v, want the event named", err)
+	}
+	// A failing handler doesn't stop the rest
+	if got := fmt.Sprint(calls); got != "[stock email payment]" {
+		t.Fatalf("calls = %s, want every handler run", got)
+	}
+}
+
+func TestDispatchAbortStopsPropagation(t *testing.T) {
+	dispatcher := NewEventDispatcher()
+	errAudit := errors.New("audit unavailable")
+	var calls []string
+	dispatcher.Register("module.uninstalling", orderRecorder(&calls, "audit", errAudit))
+	dispatcher.Register("module.uninstalling", orderRecorder(&calls, "veto", Abort("module is in use")))
+	dispatcher.Register("module.uninstalling", orderRecorder(&calls, "cleanup", nil))
+
+	err := dispatcher.Dispatch(context.Background(), "module.uninstalling", nil)
+	if !errors.Is(err, ErrAborted) || !strings.Contains(err.Error(), "module is in use") {
+		t.Fatalf("error = %v, want the abort and its reason", err)
+	}
+	// Errors from before the abort are kept
+	if !errors.Is(err, errAudit) {
+		t.Fatalf("error = %v, want the earlier failure too", err)
+	}
+	if got := fmt.Sprint(calls); got != "[audit veto]" {
+		t.Fatalf("calls = %s, want handlers after the veto skipped", got)
+	}
+}
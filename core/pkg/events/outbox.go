@@ -0,0 +1,286 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OutboxMessage is an event written in a database transaction. The outbox
+// relay publishes it once the transaction has committed.
+type OutboxMessage struct {
+	ID           uint       `gorm:"primarykey" json:"id"`
+	EventName    string     `gorm:"size:255" json:"event_name"`
+	Payload      string     `gorm:"type:text" json:"payload"`
+	Attempts     int        `gorm:"not null;default:0" json:"attempts"`
+	LastError    string     `gorm:"type:text" json:"last_error,omitempty"`
+	AvailableAt  *time.Time `gorm:"index" json:"available_at,omitempty"` // Not relayed before; set while claimed or waiting for a retry
+	DispatchedAt *time.Time `gorm:"index" json:"dispatched_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// TableName returns the outbox table name
+func (OutboxMessage) TableName() string {
+	return "outbox"
+}
+
+// Enqueue writes an event to the outbox within tx, so it is committed or
+// rolled back together with the transaction's other writes. Use it instead
+// of DispatchAsync for events about data changed in a transaction.
+func Enqueue(tx *gorm.DB, event Event) error {
+	payload, err := json.Marshal(event.Data)
+	if err != nil {
+		return fmt.Errorf("failed to encode event %s: %w", event.Name, err)
+	}
+
+	return tx.Create(&OutboxMessage{
+		EventName: event.Name,
+		Payload:   string(payload),
+	}).Error
+}
+
+// OutboxConfig configures the outbox relay
+type OutboxConfig struct {
+	PollInterval    time.Duration // How often the outbox is checked for new events
+	BatchSize       int           // Events published per check
+	Retention       time.Duration // How long published events are kept; 0 keeps them
+	ClaimTimeout    time.Duration // How long an event being published is reserved for its relay
+	RetryBackoff    time.Duration // Delay before the first retry of a failed event; doubles per attempt
+	MaxRetryBackoff time.Duration // Upper bound for the retry delay
+	MaxAttempts     int           // Publish attempts before a failing event is given up on
+}
+
+// DefaultOutboxConfig returns the default outbox relay config
+func DefaultOutboxConfig() *OutboxConfig {
+	return &OutboxConfig{
+		PollInterval:    500 * time.Millisecond,
+		BatchSize:       100,
+		Retention:       24 * time.Hour,
+		ClaimTimeout:    time.Minute,
+		RetryBackoff:    5 * time.Second,
+		MaxRetryBackoff: 10 * time.Minute,
+		MaxAttempts:     10,
+	}
+}
+
+// OutboxRelay publishes committed outbox events to a dispatcher's handlers
+// in registration order, like DispatchEvent; a handler panic counts as a
+// failure. Delivery is at-least-once: an event is only marked dispatched
+// once all its handlers succeeded, and is otherwise retried with backoff,
+// running every handler again, so handlers must tolerate seeing an event
+// twice. After MaxAttempts failed attempts the event is marked dispatched
+// with its last error, and each handler that failed the last attempt is
+// recorded in the dispatcher's dead letter store for replay. Each event is
+// claimed for ClaimTimeout while it is published, so several app instances
+// can relay the same outbox; the events of a relay that stopped mid-way are
+// picked up again once their claim expires.
+type OutboxRelay struct {
+	db         *gorm.DB
+	dispatcher *EventDispatcher
+	config     *OutboxConfig
+	lastPurge  time.Time
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewOutboxRelay creates a relay publishing to the dispatcher, or to the
+// global dispatcher when it is nil, and migrates the outbox table
+func NewOutboxRelay(db *gorm.DB, dispatcher *EventDispatcher, config *OutboxConfig) (*OutboxRelay, error) {
+	if err := db.AutoMigrate(&OutboxMessage{}); err != nil {
+		return nil, err
+	}
+	if dispatcher == nil {
+		dispatcher = defaultDispatcher
+	}
+	defaults := DefaultOutboxConfig()
+	if config == nil {
+		config = defaults
+	}
+	if config.ClaimTimeout <= 0 {
+		config.ClaimTimeout = defaults.ClaimTimeout
+	}
+	if config.RetryBackoff <= 0 {
+		config.RetryBackoff = defaults.RetryBackoff
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaults.MaxAttempts
+	}
+
+	return &OutboxRelay{
+		db:         db,
+		dispatcher: dispatcher,
+		config:     config,
+	}, nil
+}
+
+// Start publishes outbox events in the background until Close
+func (r *OutboxRelay) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	r.done = make(chan struct{})
+
+	go func() {
+		defer close(r.done)
+
+		ticker := time.NewTicker(r.config.PollInterval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := r.RelayOnce(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("Outbox relay failed: %v", err)
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Close stops the relay and waits for it to finish
+func (r *OutboxRelay) Close() error {
+	if r.cancel != nil {
+		r.cancel()
+		<-r.done
+	}
+	return nil
+}
+
+// RelayOnce publishes a batch of pending events in the order they were
+// written and returns how many it published. Events whose handlers fail are
+// left pending for a later retry until they run out of attempts.
+func (r *OutboxRelay) RelayOnce(ctx context.Context) (int, error) {
+	var messages []OutboxMessage
+	err := r.db.WithContext(ctx).
+		Where("dispatched_at IS NULL AND (available_at IS NULL OR available_at <= ?)", time.Now()).
+		Order("id").
+		Limit(r.config.BatchSize).
+		Find(&messages).Error
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	for _, msg := range messages {
+		claimed, err := r.claim(ctx, msg.ID)
+		if err != nil {
+			return published, err
+		}
+		if !claimed {
+			continue // Another relay got it
+		}
+
+		// Handlers get the payload as decoded JSON, the same map shape as
+		// direct dispatches
+		var data interface{}
+		if err := json.Unmarshal([]byte(msg.Payload), &data); err != nil {
+			log.Printf("Outbox event %d (%s) has an invalid payload: %v", msg.ID, msg.EventName, err)
+			r.finish(ctx, msg, err)
+			continue
+		}
+
+		event := Event{Name: msg.EventName, Data: data}
+		failures, err := r.dispatcher.dispatchRecovered(ctx, event)
+		if err != nil && !errors.Is(err, ErrAborted) {
+			if msg.Attempts+1 >= r.config.MaxAttempts {
+				log.Printf("Outbox event %d (%s) failed %d times, giving up: %v", msg.ID, msg.EventName, msg.Attempts+1, err)
+				for _, failure := range failures {
+					r.dispatcher.deadLetter(context.WithoutCancel(ctx), event, failure.name, msg.Attempts+1, failure.err)
+				}
+				r.finish(ctx, msg, err)
+				continue
+			}
+
+			log.Printf("Outbox event %d (%s) failed, will retry: %v", msg.ID, msg.EventName, err)
+			r.retryLater(ctx, msg, err)
+			continue
+		}
+
+		r.finish(ctx, msg, nil)
+		published++
+	}
+
+	r.purge(ctx)
+	return published, nil
+}
+
+// claim reserves a pending event for this relay for ClaimTimeout and reports
+// whether it got it
+func (r *OutboxRelay) claim(ctx context.Context, id uint) (bool, error) {
+	now := time.Now()
+	result := r.db.WithContext(ctx).
+		Model(&OutboxMessage{}).
+		Where("id = ? AND dispatched_at IS NULL AND (available_at IS NULL OR available_at <= ?)", id, now).
+		Update("available_at", now.Add(r.config.ClaimTimeout))
+	return result.RowsAffected == 1, result.Error
+}
+
+// finish marks an event dispatched, recording the failed attempt if it was
+// given up on. It runs even if ctx was cancelled after the handlers ran, so
+// a finished event isn't published again.
+func (r *OutboxRelay) finish(ctx context.Context, msg OutboxMessage, cause error) {
+	updates := map[string]interface{}{"dispatched_at": time.Now()}
+	if cause != nil {
+		updates["attempts"] = msg.Attempts + 1
+		updates["last_error"] = cause.Error()
+	}
+
+	err := r.db.WithContext(context.WithoutCancel(ctx)).
+		Model(&OutboxMessage{}).
+		Where("id = ?", msg.ID).
+		Updates(updates).Error
+	if err != nil {
+		log.Printf("Failed to mark outbox event %d dispatched: %v", msg.ID, err)
+	}
+}
+
+// retryLater releases a failed event's claim, making it available again
+// after the retry backoff
+func (r *OutboxRelay) retryLater(ctx context.Context, msg OutboxMessage, cause error) {
+	attempts := msg.Attempts + 1
+	err := r.db.WithContext(context.WithoutCancel(ctx)).
+		Model(&OutboxMessage{}).
+		Where("id = ?", msg.ID).
+		Updates(map[string]interface{}{
+			"attempts":     attempts,
+			"last_error":   cause.Error(),
+			"available_at": time.Now().Add(r.retryBackoff(attempts)),
+		}).Error
+	if err != nil {
+		log.Printf("Failed to reschedule outbox event %d: %v", msg.ID, err)
+	}
+}
+
+// retryBackoff returns the delay before retrying an event that failed the
+// given number of times
+func (r *OutboxRelay) retryBackoff(attempts int) time.Duration {
+	delay := r.config.RetryBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if r.config.MaxRetryBackoff > 0 && delay >= r.config.MaxRetryBackoff {
+			return r.config.MaxRetryBackoff
+		}
+	}
+	return delay
+}
+
+// purge deletes published events older than the retention, at most hourly
+func (r *OutboxRelay) purge(ctx context.Context) {
+	if r.config.Retention <= 0 || time.Since(r.lastPurge) < time.Hour {
+		return
+	}
+	r.lastPurge = time.Now()
+
+	cutoff := time.Now().Add(-r.config.Retention)
+	if err := r.db.WithContext(ctx).Where("dispatched_at < ?", cutoff).Delete(&OutboxMessage{}).Error; err != nil {
+		log.Printf("Failed to purge outbox: %v", err)
+	}
+}
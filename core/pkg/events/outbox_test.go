@@ -0,0 +1,303 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// outboxTestOrder is business data written alongside outbox events
+type outboxTestOrder struct {
+	ID    uint
+	Total int
+}
+
+func newOutboxTestRelay(t *testing.T, config *OutboxConfig) (*OutboxRelay, *EventDispatcher, *gorm.DB) {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "outbox.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(&outboxTestOrder{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	dispatcher := NewEventDispatcher()
+	relay, err := NewOutboxRelay(db, dispatcher, config)
+	if err != nil {
+		t.Fatalf("NewOutboxRelay: %v", err)
+	}
+	return relay, dispatcher, db
+}
+
+// placeOrder writes an order and its event in one transaction, rolling back
+// if rollback is set
+func placeOrder(db *gorm.DB, total int, rollback bool) error {
+	errRollback := errors.New("rollback")
+	err := db.Transaction(func(tx *gorm.DB) error {
+		order := &outboxTestOrder{Total: total}
+		if err := tx.Create(order).Error; err != nil {
+			return err
+		}
+		if err := Enqueue(tx, Event{Name: "order.placed", Data: map[string]interface{}{"order_id": order.ID, "total": total}}); err != nil {
+			return err
+		}
+		if rollback {
+			return errRollback
+		}
+		return nil
+	})
+	if errors.Is(err, errRollback) {
+		return nil
+	}
+	return err
+}
+
+func TestOutboxPublishesOnlyCommittedEvents(t *testing.T) {
+	relay, dispatcher, db := newOutboxTestRelay(t, nil)
+	var totals []float64
+	dispatcher.Register("order.placed", func(_ context.Context, event Event) error {
+		totals = append(totals, event.Data.(map[string]interface{})["total"].(float64))
+		return nil
+	})
+
+	if err := placeOrder(db, 10, true); err != nil {
+		t.Fatalf("rolled back order: %v", err)
+	}
+	if err := placeOrder(db, 20, false); err != nil {
+		t.Fatalf("committed order: %v", err)
+	}
+
+	published, err := relay.RelayOnce(context.Background())
+	if err != nil {
+		t.Fatalf("RelayOnce: %v", err)
+	}
+	if published != 1 || len(totals) != 1 || totals[0] != 20 {
+		t.Fatalf("published %d events with totals %v, want only the committed order", published, totals)
+	}
+
+	// Published events are marked and not published again
+	var msg OutboxMessage
+	db.First(&msg)
+	if msg.DispatchedAt == nil {
+		t.Fatal("published event is not marked dispatched")
+	}
+	if published, _ := relay.RelayOnce(context.Background()); published != 0 || len(totals) != 1 {
+		t.Fatalf("second relay published %d events", published)
+	}
+}
+
+func TestOutboxRetriesFailedHandlers(t *testing.T) {
+	relay, dispatcher, db := newOutboxTestRelay(t, &OutboxConfig{BatchSize: 10, RetryBackoff: 50 * time.Millisecond})
+	calls := 0
+	dispatcher.Register("order.placed", func(context.Context, Event) error {
+		calls++
+		if calls == 1 {
+			return errors.New("mail server down")
+		}
+		return nil
+	})
+	placeOrder(db, 10, false)
+	ctx := context.Background()
+
+	if published, err := relay.RelayOnce(ctx); err != nil || published != 0 {
+		t.Fatalf("RelayOnce with a failing handler = %d, %v", published, err)
+	}
+	var msg OutboxMessage
+	db.First(&msg)
+	if msg.DispatchedAt != nil || msg.Attempts != 1 || !strings.Contains(msg.LastError, "mail server down") {
+		t.Fatalf("failed event = %+v, want it pending after one attempt", msg)
+	}
+
+	// Not retried before the backoff passes
+	if published, _ := relay.RelayOnce(ctx); published != 0 || calls != 1 {
+		t.Fatalf("event retried before its backoff: %d calls", calls)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if published, err := relay.RelayOnce(ctx); err != nil || published != 1 || calls != 2 {
+		t.Fatalf("retry = %d, %v after %d calls", published, err, calls)
+	}
+}
+
+func TestOutboxRecoversPanickingHandlers(t *testing.T) {
+	relay, dispatcher, db := newOutboxTestRelay(t, &OutboxConfig{BatchSize: 10})
+	dispatcher.Register("order.placed", func(context.Context, Event) error {
+		panic("nil map")
+	})
+	reached := false
+	dispatcher.Register("order.placed", func(context.Context, Event) error {
+		reached = true
+		return nil
+	})
+	placeOrder(db, 10, false)
+
+	if published, err := relay.RelayOnce(context.Background()); err != nil || published != 0 {
+		t.Fatalf("RelayOnce with a panicking handler = %d, %v", published, err)
+	}
+	if !reached {
+		t.Fatal("handler after the panicking one did not run")
+	}
+	var msg OutboxMessage
+	db.First(&msg)
+	if msg.DispatchedAt != nil || msg.Attempts != 1 || !strings.Contains(msg.LastError, "panicked: nil map") {
+		t.Fatalf("event = %+v, want it pending after one failed attempt", msg)
+	}
+}
+
+func TestOutboxGivesUpAfterMaxAttempts(t *testing.T) {
+	relay, dispatcher, db := newOutboxTestRelay(t, &OutboxConfig{BatchSize: 10, RetryBackoff: 10 * time.Millisecond, MaxAttempts: 2})
+	store, err := NewGormDeadLetterStore(db)
+	if err != nil {
+		t.Fatalf("NewGormDeadLetterStore: %v", err)
+	}
+	dispatcher.SetDeadLetterStore(store)
+
+	webhookCalls, mailCalls := 0, 0
+	dispatcher.RegisterNamed("order.placed", "webhooks", func(context.Context, Event) error {
+		webhookCalls++
+		return nil
+	})
+	dispatcher.RegisterNamed("order.placed", "mail", func(context.Context, Event) error {
+		mailCalls++
+		return errors.New("mail server down")
+	})
+	placeOrder(db, 10, false)
+	ctx := context.Background()
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		if published, err := relay.RelayOnce(ctx); err != nil || published != 0 {
+			t.Fatalf("attempt %d = %d, %v", attempt, published, err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	var msg OutboxMessage
+	db.First(&msg)
+	if msg.DispatchedAt == nil || msg.Attempts != 2 || !strings.Contains(msg.LastError, "mail server down") {
+		t.Fatalf("event = %+v, want it finished with its error after 2 attempts", msg)
+	}
+	if published, _ := relay.RelayOnce(ctx); published != 0 || webhookCalls != 2 || mailCalls != 2 {
+		t.Fatalf("given up event published again: %d webhook and %d mail calls", webhookCalls, mailCalls)
+	}
+
+	// Only the failing handler is dead-lettered, so a replay doesn't
+	// repeat the webhook
+	var letters []DeadLetter
+	db.Find(&letters)
+	if len(letters) != 1 || letters[0].HandlerName != "mail" || letters[0].Attempts != 2 {
+		t.Fatalf("dead letters = %+v, want one for the mail handler", letters)
+	}
+	dispatcher.ReplayDeadLetter(ctx, letters[0].ID)
+	if webhookCalls != 2 || mailCalls != 3 {
+		t.Fatalf("replay made %d webhook and %d mail calls, want only the mail handler run", webhookCalls, mailCalls)
+	}
+}
+
+func TestOutboxRetryBackoff(t *testing.T) {
+	relay := &OutboxRelay{config: &OutboxConfig{RetryBackoff: time.Second, MaxRetryBackoff: 5 * time.Second}}
+	for attempts, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 10: 5 * time.Second} {
+		if got := relay.retryBackoff(attempts); got != want {
+			t.Errorf("retryBackoff(%d) = %s, want %s", attempts, got, want)
+		}
+	}
+}
+
+func TestOutboxFinishesAbortedAndInvalidEvents(t *testing.T) {
+	relay, dispatcher, db := newOutboxTestRelay(t, nil)
+	calls := 0
+	dispatcher.Register("order.placed", func(context.Context, Event) error {
+		calls++
+		return Abort("duplicate order")
+	})
+	placeOrder(db, 10, false)
+	db.Create(&OutboxMessage{EventName: "order.placed", Payload: "{not json"})
+
+	if published, err := relay.RelayOnce(context.Background()); err != nil || published != 1 {
+		t.Fatalf("RelayOnce = %d, %v; want the aborted event published", published, err)
+	}
+	if calls != 1 {
+		t.Fatalf("handler called %d times, want once", calls)
+	}
+
+	var pending int64
+	db.Model(&OutboxMessage{}).Where("dispatched_at IS NULL").Count(&pending)
+	if pending != 0 {
+		t.Fatalf("%d events left pending, want none", pending)
+	}
+	var invalid OutboxMessage
+	db.Where("payload = ?", "{not json").First(&invalid)
+	if invalid.LastError == "" {
+		t.Fatal("invalid payload was given up on without an error")
+	}
+}
+
+func TestOutboxClaimsEventsPerRelay(t *testing.T) {
+	relay, dispatcher, db := newOutboxTestRelay(t, &OutboxConfig{BatchSize: 10, ClaimTimeout: 50 * time.Millisecond})
+	other, err := NewOutboxRelay(db, dispatcher, relay.config)
+	if err != nil {
+		t.Fatalf("NewOutboxRelay: %v", err)
+	}
+	calls := 0
+	dispatcher.Register("order.placed", func(context.Context, Event) error {
+		calls++
+		return nil
+	})
+	placeOrder(db, 10, false)
+	ctx := context.Background()
+
+	// A relay that claimed the event and stopped keeps others off it until
+	// the claim expires
+	var msg OutboxMessage
+	db.First(&msg)
+	if claimed, err := relay.claim(ctx, msg.ID); err != nil || !claimed {
+		t.Fatalf("claim = %v, %v", claimed, err)
+	}
+	if published, _ := other.RelayOnce(ctx); published != 0 || calls != 0 {
+		t.Fatal("another relay published a claimed event")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if published, _ := other.RelayOnce(ctx); published != 1 || calls != 1 {
+		t.Fatalf("expired claim: published %d, %d calls", published, calls)
+	}
+}
+
+func TestOutboxRelayRunsInBackground(t *testing.T) {
+	relay, dispatcher, db := newOutboxTestRelay(t, &OutboxConfig{PollInterval: 10 * time.Millisecond, BatchSize: 10})
+	var mu sync.Mutex
+	received := 0
+	dispatcher.Register("order.placed", func(context.Context, Event) error {
+		mu.Lock()
+		received++
+		mu.Unlock()
+		return nil
+	})
+
+	relay.Start()
+	defer relay.Close()
+	placeOrder(db, 10, false)
+	placeOrder(db, 20, true)
+	placeOrder(db, 30, false)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		mu.Lock()
+		got := received
+		mu.Unlock()
+		if got == 2 {
+			break
+		}
+		if got > 2 || time.Now().After(deadline) {
+			t.Fatalf("received %d events, want the 2 committed ones", got)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
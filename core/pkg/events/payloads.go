@@ -0,0 +1,73 @@
+package events
+
+import "time"
+
+// UserCreatedEvent is the payload of EventUserCreated
+type UserCreatedEvent struct {
+	UserID uint   `json:"user_id"`
+	Email  string `json:"email"`
+}
+
+// UserUpdatedEvent is the payload of EventUserUpdated
+type UserUpdatedEvent struct {
+	UserID uint   `json:"user_id"`
+	Email  string `json:"email"`
+}
+
+// UserDeletedEvent is the payload of EventUserDeleted
+type UserDeletedEvent struct {
+	UserID uint   `json:"user_id"`
+	Email  string `json:"email"`
+}
+
+// UserRestoredEvent is the payload of EventUserRestored
+type UserRestoredEvent struct {
+	UserID uint   `json:"user_id"`
+	Email  string `json:"email"`
+}
+
+// UserLoggedInEvent is the payload of EventUserLoggedIn
+type UserLoggedInEvent struct {
+	UserID uint   `json:"user_id"`
+	Email  string `json:"email"`
+}
+
+// UserLoggedOutEvent is the payload of EventUserLoggedOut
+type UserLoggedOutEvent struct {
+	UserID uint `json:"user_id"`
+}
+
+// UserVerifyRequestEvent is the payload of EventUserVerifyRequest. It
+// doesn't carry the token, which only the verification email does.
+type UserVerifyRequestEvent struct {
+	UserID uint   `json:"user_id"`
+	Email  string `json:"email"`
+}
+
+// UserPasswordResetEvent is the payload of EventUserPasswordReset
+type UserPasswordResetEvent struct {
+	UserID uint   `json:"user_id"`
+	Email  string `json:"email"`
+}
+
+// UserEmailVerifiedEvent is the payload of EventUserEmailVerified
+type UserEmailVerifiedEvent struct {
+	UserID uint   `json:"user_id"`
+	Email  string `json:"email"`
+}
+
+// UserLockedOutEvent is the payload of EventUserLockedOut
+type UserLockedOutEvent struct {
+	Email       string    `json:"email"`
+	IPAddress   string    `json:"ip_address"`
+	LockedUntil time.Time `json:"locked_until"`
+}
+
+// RBACEvent is the payload of the RBAC events. Only the IDs an event is
+// about are set; ActorID is the user who made the change, or 0 for the system.
+type RBACEvent struct {
+	ActorID      uint `json:"actor_id"`
+	UserID       uint `json:"user_id,omitempty"`
+	RoleID       uint `json:"role_id,omitempty"`
+	PermissionID uint `json:"permission_id,omitempty"`
+}
@@ -0,0 +1,71 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// RetryPolicy configures how async handlers are retried
+type RetryPolicy struct {
+	MaxAttempts    int           // Total attempts including the first; 1 disables retries
+	InitialBackoff time.Duration // Delay before the first retry
+	MaxBackoff     time.Duration // Upper bound for the doubling delay
+}
+
+// DefaultRetryPolicy returns the default async retry policy
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     30 * time.Second,
+	}
+}
+
+// backoff returns the delay before the given retry (1-based)
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	delay := p.InitialBackoff
+	for i := 1; i < retry; i++ {
+		delay *= 2
+		if p.MaxBackoff > 0 && delay >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+	return delay
+}
+
+// callHandler runs a handler, turning a panic into an error
+func callHandler(ctx context.Context, handler Handler, event Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panicked: %v", r)
+		}
+	}()
+	return handler(ctx, event)
+}
+
+// runWithRetry runs one async handler under the retry policy and
+// dead-letters the event if every attempt fails
+func (d *EventDispatcher) runWithRetry(ctx context.Context, name string, handler Handler, event Event) {
+	d.mu.RLock()
+	policy := d.retry
+	d.mu.RUnlock()
+
+	attempts := policy.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(policy.backoff(attempt - 1))
+		}
+
+		if err = callHandler(ctx, handler, event); err == nil {
+			return
+		}
+	}
+
+	d.deadLetter(ctx, event, name, attempts, err)
+}
@@ -0,0 +1,94 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// TypedHandler handles an event whose payload is decoded into T
+type TypedHandler[T any] func(ctx context.Context, payload T) error
+
+// Subscribe registers a global handler that receives the event payload as
+// T, e.g.
+//
+//	events.Subscribe(events.EventUserCreated, func(ctx context.Context, e events.UserCreatedEvent) error {
+//		return sendWelcomeEmail(e.Email)
+//	})
+func Subscribe[T any](eventName string, handler TypedHandler[T]) {
+	SubscribeTo(defaultDispatcher, eventName, handler)
+}
+
+// SubscribeTo registers a typed handler on a specific dispatcher. It is
+// named after handler's function for dead letters.
+func SubscribeTo[T any](d *EventDispatcher, eventName string, handler TypedHandler[T]) {
+	d.RegisterNamed(eventName, handlerName(handler), func(ctx context.Context, event Event) error {
+		payload, err := DecodePayload[T](event)
+		if err != nil {
+			return err
+		}
+		return handler(ctx, payload)
+	})
+}
+
+// DecodePayload returns the event data as T. Handlers receive struct
+// payloads as maps (see mapPayload), which are decoded by their JSON field
+// names; a field T doesn't declare is an error, so a renamed or mismatched
+// payload fails loudly instead of decoding to zero values. T and *T
+// payloads are used directly. Any other payload type is an error.
+func DecodePayload[T any](event Event) (T, error) {
+	var payload T
+
+	switch data := event.Data.(type) {
+	case T:
+		return data, nil
+	case *T:
+		if data != nil {
+			return *data, nil
+		}
+	case map[string]interface{}:
+		encoded, err := json.Marshal(data)
+		if err == nil {
+			decoder := json.NewDecoder(bytes.NewReader(encoded))
+			decoder.DisallowUnknownFields()
+			err = decoder.Decode(&payload)
+		}
+		if err != nil {
+			return payload, fmt.Errorf("event %s: cannot decode payload into %T: %w", event.Name, payload, err)
+		}
+		return payload, nil
+	}
+
+	return payload, fmt.Errorf("event %s: payload of type %T is not %T", event.Name, event.Data, payload)
+}
+
+// mapPayload converts a struct payload, or a pointer to one, into the
+// map[string]interface{} its JSON encoding decodes to. Handlers registered
+// with Register always see the untyped map shape, whether an event was
+// dispatched with a payload struct, a map or relayed from the outbox;
+// Subscribe decodes it back into the struct. Other payloads are returned
+// unchanged.
+func mapPayload(data interface{}) interface{} {
+	v := reflect.ValueOf(data)
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return data
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return data
+	}
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return data
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(encoded, &m); err != nil {
+		return data
+	}
+	return m
+}
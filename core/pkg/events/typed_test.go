@@ -0,0 +1,106 @@
+package events
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSubscribeReceivesTypedPayload(t *testing.T) {
+	dispatcher := NewEventDispatcher()
+	var received []UserCreatedEvent
+	SubscribeTo(dispatcher, EventUserCreated, func(_ context.Context, e UserCreatedEvent) error {
+		received = append(received, e)
+		return nil
+	})
+	var untyped []interface{}
+	dispatcher.Register(EventUserCreated, func(_ context.Context, event Event) error {
+		untyped = append(untyped, event.Data)
+		return nil
+	})
+
+	payloads := []interface{}{
+		UserCreatedEvent{UserID: 1, Email: "ada@example.com"},
+		&UserCreatedEvent{UserID: 2, Email: "bob@example.com"},
+		// As relayed from the outbox after a JSON round trip
+		map[string]interface{}{"user_id": float64(3), "email": "cy@example.com"},
+	}
+	for _, payload := range payloads {
+		if err := dispatcher.Dispatch(context.Background(), EventUserCreated, payload); err != nil {
+			t.Fatalf("Dispatch %T: %v", payload, err)
+		}
+	}
+
+	want := []UserCreatedEvent{{1, "ada@example.com"}, {2, "bob@example.com"}, {3, "cy@example.com"}}
+	if len(received) != len(want) {
+		t.Fatalf("received %+v, want %+v", received, want)
+	}
+	for i := range want {
+		if received[i] != want[i] {
+			t.Fatalf("received %+v, want %+v", received, want)
+		}
+	}
+
+	// Untyped handlers keep seeing maps keyed by the JSON field names
+	for _, data := range untyped {
+		m, ok := data.(map[string]interface{})
+		if !ok || m["email"] == nil || m["user_id"] == nil {
+			t.Fatalf("untyped handler received %#v, want a map", data)
+		}
+	}
+}
+
+func TestSubscribeTimePayload(t *testing.T) {
+	dispatcher := NewEventDispatcher()
+	lockedUntil := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	var received UserLockedOutEvent
+	SubscribeTo(dispatcher, EventUserLockedOut, func(_ context.Context, e UserLockedOutEvent) error {
+		received = e
+		return nil
+	})
+
+	err := dispatcher.Dispatch(context.Background(), EventUserLockedOut, UserLockedOutEvent{
+		Email:       "ada@example.com",
+		IPAddress:   "10.0.0.1",
+		LockedUntil: lockedUntil,
+	})
+	if err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+	if !received.LockedUntil.Equal(lockedUntil) || received.IPAddress != "10.0.0.1" {
+		t.Fatalf("received %+v", received)
+	}
+}
+
+func TestSubscribeMismatchedPayload(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload interface{}
+		message string
+	}{
+		{"wrong type", "ada@example.com", "payload of type string is not events.UserCreatedEvent"},
+		{"wrong struct", RBACEvent{ActorID: 1}, "cannot decode payload into events.UserCreatedEvent"},
+		{"wrong field type", map[string]interface{}{"user_id": "one"}, "cannot decode payload into events.UserCreatedEvent"},
+		{"renamed field", map[string]interface{}{"user_id": 1, "mail": "ada@example.com"}, `unknown field "mail"`},
+		{"nil pointer", (*UserCreatedEvent)(nil), "is not events.UserCreatedEvent"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dispatcher := NewEventDispatcher()
+			called := false
+			SubscribeTo(dispatcher, EventUserCreated, func(context.Context, UserCreatedEvent) error {
+				called = true
+				return nil
+			})
+
+			err := dispatcher.Dispatch(context.Background(), EventUserCreated, tt.payload)
+			if err == nil || !strings.Contains(err.Error(), tt.message) || !strings.Contains(err.Error(), EventUserCreated) {
+				t.Fatalf("error = %v, want it to contain %q and the event name", err, tt.message)
+			}
+			if called {
+				t.Fatal("handler called with a mismatched payload")
+			}
+		})
+	}
+}
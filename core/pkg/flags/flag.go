@@ -0,0 +1,73 @@
+package flags
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Flag is a feature flag. A disabled flag serves DefaultValue to everyone.
+// An enabled flag serves Value to users its rules target and DefaultValue to
+// the rest. Rules are checked in order: DenyUsers, AllowUsers, Roles, then
+// RolloutPercentage.
+type Flag struct {
+	ID                uint      `gorm:"primarykey" json:"id"`
+	Key               string    `gorm:"uniqueIndex;size:100;not null" json:"key"`
+	Description       string    `gorm:"size:255" json:"description"`
+	Enabled           bool      `gorm:"default:false" json:"enabled"`
+	Value             string    `gorm:"type:text" json:"value"`                       // JSON value for targeted users, true if empty
+	DefaultValue      string    `gorm:"type:text" json:"default_value"`               // JSON value for everyone else, false if empty
+	RolloutPercentage int       `gorm:"not null" json:"rollout_percentage"`           // Share of users, 0-100, bucketed by user ID
+	AllowUsers        []uint    `gorm:"serializer:json" json:"allow_users,omitempty"` // Always targeted
+	DenyUsers         []uint    `gorm:"serializer:json" json:"deny_users,omitempty"`  // Never targeted
+	Roles             []string  `gorm:"serializer:json" json:"roles,omitempty"`       // If set, only users with one of these roles are targeted
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for the Flag model
+func (Flag) TableName() string {
+	return "feature_flags"
+}
+
+// Evaluation reasons
+const (
+	ReasonDisabled = "disabled"  // The flag is off
+	ReasonDenied   = "denied"    // The user is on the deny list
+	ReasonAllowed  = "allowed"   // The user is on the allow list
+	ReasonRole     = "role"      // The user lacks the targeted roles
+	ReasonRollout  = "rollout"   // The user's bucket is inside the rollout
+	ReasonExcluded = "excluded"  // The user's bucket is outside the rollout
+	ReasonNotFound = "not_found" // No flag has the key
+)
+
+// Evaluation is the result of evaluating a flag for a user
+type Evaluation struct {
+	Key     string      `json:"key"`
+	Enabled bool        `json:"enabled"` // Whether the user is targeted
+	Value   interface{} `json:"value"`
+	Reason  string      `json:"reason"`
+}
+
+// onValue returns the value served to targeted users
+func (f *Flag) onValue() interface{} {
+	return decodeValue(f.Value, true)
+}
+
+// offValue returns the value served to everyone else
+func (f *Flag) offValue() interface{} {
+	return decodeValue(f.DefaultValue, false)
+}
+
+// decodeValue decodes a JSON flag value. Values that aren't valid JSON are
+// served as plain strings.
+func decodeValue(raw string, fallback interface{}) interface{} {
+	if raw == "" {
+		return fallback
+	}
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(raw), &value); err != nil {
+		return raw
+	}
+	return value
+}
@@ -0,0 +1,249 @@
+package flags
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"neonexcore/pkg/cache"
+
+	"gorm.io/gorm"
+)
+
+// DefaultCacheTTL is how long an evaluation is cached per flag and user
+const DefaultCacheTTL = 10 * time.Second
+
+// RoleChecker reports whether a user has a role; *rbac.Manager satisfies it
+type RoleChecker interface {
+	HasRole(ctx context.Context, userID uint, roleSlug string) (bool, error)
+}
+
+// Service stores feature flags and evaluates them for users
+type Service struct {
+	db       *gorm.DB
+	roles    RoleChecker
+	cache    cache.Cache
+	cacheTTL time.Duration
+}
+
+// NewService creates a new flag service. roles may be nil, in which case
+// flags that target roles never match.
+func NewService(db *gorm.DB, roles RoleChecker) *Service {
+	return &Service{db: db, roles: roles}
+}
+
+// SetCache enables caching of evaluations. A nil cache disables it.
+func (s *Service) SetCache(c cache.Cache, ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+	s.cache = c
+	s.cacheTTL = ttl
+}
+
+// Get returns a flag by key
+func (s *Service) Get(ctx context.Context, key string) (*Flag, error) {
+	var flag Flag
+	if err := s.db.WithContext(ctx).Where("key = ?", key).First(&flag).Error; err != nil {
+		return nil, err
+	}
+	return &flag, nil
+}
+
+// List returns all flags ordered by key
+func (s *Service) List(ctx context.Context) ([]Flag, error) {
+	var flags []Flag
+	err := s.db.WithContext(ctx).Order("key").Find(&flags).Error
+	return flags, err
+}
+
+// Save creates or updates a flag
+func (s *Service) Save(ctx context.Context, flag *Flag) error {
+	if err := validateFlag(flag); err != nil {
+		return err
+	}
+	if err := s.db.WithContext(ctx).Save(flag).Error; err != nil {
+		return err
+	}
+	s.invalidate(ctx, flag.Key)
+	return nil
+}
+
+// Delete deletes a flag
+func (s *Service) Delete(ctx context.Context, key string) error {
+	if err := s.db.WithContext(ctx).Where("key = ?", key).Delete(&Flag{}).Error; err != nil {
+		return err
+	}
+	s.invalidate(ctx, key)
+	return nil
+}
+
+// Evaluate evaluates a flag for a user. userID 0 is an anonymous user, who
+// is only targeted by flags without roles at 100% rollout. A missing flag
+// evaluates to false with reason "not_found".
+func (s *Service) Evaluate(ctx context.Context, key string, userID uint) (*Evaluation, error) {
+	if eval, ok := s.cachedEvaluation(ctx, key, userID); ok {
+		return eval, nil
+	}
+
+	flag, err := s.Get(ctx, key)
+	if err == gorm.ErrRecordNotFound {
+		return &Evaluation{Key: key, Value: false, Reason: ReasonNotFound}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	eval, err := s.evaluate(ctx, flag, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.cacheEvaluation(ctx, userID, eval)
+	return eval, nil
+}
+
+// IsEnabled reports whether a flag targets a user. Errors count as disabled.
+func (s *Service) IsEnabled(ctx context.Context, key string, userID uint) bool {
+	eval, err := s.Evaluate(ctx, key, userID)
+	return err == nil && eval.Enabled
+}
+
+// evaluate applies a flag's rules to a user
+func (s *Service) evaluate(ctx context.Context, flag *Flag, userID uint) (*Evaluation, error) {
+	off := func(reason string) *Evaluation {
+		return &Evaluation{Key: flag.Key, Value: flag.offValue(), Reason: reason}
+	}
+	on := func(reason string) *Evaluation {
+		return &Evaluation{Key: flag.Key, Enabled: true, Value: flag.onValue(), Reason: reason}
+	}
+
+	if !flag.Enabled {
+		return off(ReasonDisabled), nil
+	}
+	if userID != 0 && containsUint(flag.DenyUsers, userID) {
+		return off(ReasonDenied), nil
+	}
+	if userID != 0 && containsUint(flag.AllowUsers, userID) {
+		return on(ReasonAllowed), nil
+	}
+
+	if len(flag.Roles) > 0 {
+		hasRole, err := s.hasAnyRole(ctx, userID, flag.Roles)
+		if err != nil {
+			return nil, err
+		}
+		if !hasRole {
+			return off(ReasonRole), nil
+		}
+	}
+
+	if flag.RolloutPercentage >= 100 {
+		return on(ReasonRollout), nil
+	}
+	if userID != 0 && Bucket(flag.Key, userID) < flag.RolloutPercentage {
+		return on(ReasonRollout), nil
+	}
+	return off(ReasonExcluded), nil
+}
+
+// hasAnyRole reports whether a user has at least one of the roles
+func (s *Service) hasAnyRole(ctx context.Context, userID uint, roles []string) (bool, error) {
+	if s.roles == nil || userID == 0 {
+		return false, nil
+	}
+	for _, role := range roles {
+		ok, err := s.roles.HasRole(ctx, userID, role)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Bucket returns a user's rollout bucket for a flag, from 0 to 99. It is
+// stable for a flag and user, and independent across flags, so raising a
+// flag's percentage only ever adds users.
+func Bucket(key string, userID uint) int {
+	h := fnv.New32a()
+	fmt.Fprintf(h, "%s:%d", key, userID)
+	return int(h.Sum32() % 100)
+}
+
+// validateFlag checks a flag before it is saved
+func validateFlag(flag *Flag) error {
+	if flag.Key == "" {
+		return fmt.Errorf("flag key is required")
+	}
+	if flag.RolloutPercentage < 0 || flag.RolloutPercentage > 100 {
+		return fmt.Errorf("rollout percentage must be between 0 and 100")
+	}
+	return nil
+}
+
+func containsUint(values []uint, value uint) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func evaluationCacheKey(key string, userID uint) string {
+	return fmt.Sprintf("flags:%s:%d", key, userID)
+}
+
+// cachedEvaluation returns a cached evaluation. Evaluations are cached as
+// JSON so any cache backend can hold them.
+func (s *Service) cachedEvaluation(ctx context.Context, key string, userID uint) (*Evaluation, bool) {
+	if s.cache == nil {
+		return nil, false
+	}
+
+	value, err := s.cache.Get(ctx, evaluationCacheKey(key, userID))
+	if err != nil {
+		return nil, false
+	}
+	raw, ok := value.(string)
+	if !ok {
+		return nil, false
+	}
+
+	var eval Evaluation
+	if err := json.Unmarshal([]byte(raw), &eval); err != nil {
+		return nil, false
+	}
+	return &eval, true
+}
+
+// cacheEvaluation caches an evaluation for the cache TTL
+func (s *Service) cacheEvaluation(ctx context.Context, userID uint, eval *Evaluation) {
+	if s.cache == nil {
+		return
+	}
+
+	raw, err := json.Marshal(eval)
+	if err != nil {
+		return
+	}
+	s.cache.Set(ctx, evaluationCacheKey(eval.Key, userID), string(raw), s.cacheTTL)
+}
+
+// invalidate drops the cached evaluations of a flag
+func (s *Service) invalidate(ctx context.Context, key string) {
+	if s.cache == nil {
+		return
+	}
+
+	keys, err := s.cache.Keys(ctx, fmt.Sprintf("flags:%s:*", cache.EscapePattern(key)))
+	if err != nil || len(keys) == 0 {
+		return
+	}
+	s.cache.DeleteMulti(ctx, keys)
+}
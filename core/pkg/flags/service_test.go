@@ -0,0 +1,199 @@
+package flags
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"neonexcore/pkg/cache"
+	"neonexcore/pkg/events"
+	"neonexcore/pkg/rbac"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTestService(t *testing.T) (*Service, *rbac.Manager) {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "flags.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	err = db.AutoMigrate(&Flag{}, &rbac.Role{}, &rbac.Permission{}, &rbac.UserRole{}, &rbac.UserPermission{}, &events.OutboxMessage{})
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	roles := rbac.NewManager(db)
+	return NewService(db, roles), roles
+}
+
+func saveFlag(t *testing.T, s *Service, flag *Flag) {
+	t.Helper()
+
+	if err := s.Save(context.Background(), flag); err != nil {
+		t.Fatalf("Save %s: %v", flag.Key, err)
+	}
+}
+
+func evaluate(t *testing.T, s *Service, key string, userID uint) *Evaluation {
+	t.Helper()
+
+	eval, err := s.Evaluate(context.Background(), key, userID)
+	if err != nil {
+		t.Fatalf("Evaluate %s for %d: %v", key, userID, err)
+	}
+	return eval
+}
+
+func TestRolloutIsStablePerUser(t *testing.T) {
+	s, _ := newTestService(t)
+	saveFlag(t, s, &Flag{Key: "new-checkout", Enabled: true, RolloutPercentage: 30})
+
+	enabled := 0
+	for userID := uint(1); userID <= 1000; userID++ {
+		first := evaluate(t, s, "new-checkout", userID)
+		for i := 0; i < 3; i++ {
+			if again := evaluate(t, s, "new-checkout", userID); again.Enabled != first.Enabled {
+				t.Fatalf("user %d flipped from %v to %v", userID, first.Enabled, again.Enabled)
+			}
+		}
+		if first.Enabled != (Bucket("new-checkout", userID) < 30) {
+			t.Fatalf("user %d in bucket %d: enabled %v at 30%%", userID, Bucket("new-checkout", userID), first.Enabled)
+		}
+		if first.Enabled {
+			enabled++
+		}
+	}
+	if enabled < 250 || enabled > 350 {
+		t.Fatalf("%d of 1000 users enabled at 30%%", enabled)
+	}
+
+	// Raising the percentage only adds users
+	saveFlag(t, s, &Flag{ID: 1, Key: "new-checkout", Enabled: true, RolloutPercentage: 60})
+	for userID := uint(1); userID <= 1000; userID++ {
+		if Bucket("new-checkout", userID) < 30 && !evaluate(t, s, "new-checkout", userID).Enabled {
+			t.Fatalf("user %d dropped out when the rollout grew", userID)
+		}
+	}
+
+	// Buckets are independent across flags
+	same := 0
+	for userID := uint(1); userID <= 1000; userID++ {
+		if Bucket("new-checkout", userID) == Bucket("dark-mode", userID) {
+			same++
+		}
+	}
+	if same > 50 {
+		t.Fatalf("%d of 1000 users share a bucket across flags", same)
+	}
+}
+
+func TestRoleTargeting(t *testing.T) {
+	s, roles := newTestService(t)
+	ctx := context.Background()
+	beta := &rbac.Role{Name: "Beta", Slug: "beta"}
+	if err := roles.CreateRole(ctx, beta); err != nil {
+		t.Fatalf("CreateRole: %v", err)
+	}
+	if err := roles.AssignRole(ctx, 1, beta.ID); err != nil {
+		t.Fatalf("AssignRole: %v", err)
+	}
+	saveFlag(t, s, &Flag{Key: "reports-v2", Enabled: true, RolloutPercentage: 100, Roles: []string{"admin", "beta"}, AllowUsers: []uint{3}, DenyUsers: []uint{1}})
+	saveFlag(t, s, &Flag{Key: "exports", Enabled: true, RolloutPercentage: 100, Roles: []string{"beta"}})
+
+	tests := []struct {
+		key     string
+		userID  uint
+		enabled bool
+		reason  string
+	}{
+		{"exports", 1, true, ReasonRollout},
+		{"exports", 2, false, ReasonRole},
+		{"exports", 0, false, ReasonRole},
+		// Deny and allow lists win over roles
+		{"reports-v2", 1, false, ReasonDenied},
+		{"reports-v2", 3, true, ReasonAllowed},
+		{"reports-v2", 2, false, ReasonRole},
+	}
+	for _, tt := range tests {
+		eval := evaluate(t, s, tt.key, tt.userID)
+		if eval.Enabled != tt.enabled || eval.Reason != tt.reason {
+			t.Errorf("%s for user %d: enabled %v (%s), want %v (%s)", tt.key, tt.userID, eval.Enabled, eval.Reason, tt.enabled, tt.reason)
+		}
+	}
+}
+
+func TestEvaluateDefaults(t *testing.T) {
+	s, _ := newTestService(t)
+	saveFlag(t, s, &Flag{Key: "theme", Enabled: true, RolloutPercentage: 0, Value: `"neon"`, DefaultValue: `"classic"`})
+	saveFlag(t, s, &Flag{Key: "limits", Enabled: false, RolloutPercentage: 100, Value: `{"max":50}`, DefaultValue: `{"max":10}`})
+	saveFlag(t, s, &Flag{Key: "beta-banner", Enabled: true, RolloutPercentage: 100})
+
+	tests := []struct {
+		key    string
+		userID uint
+		value  interface{}
+		reason string
+	}{
+		{"missing", 1, false, ReasonNotFound},
+		{"theme", 1, "classic", ReasonExcluded},
+		{"limits", 1, map[string]interface{}{"max": float64(10)}, ReasonDisabled},
+		{"beta-banner", 1, true, ReasonRollout},
+		// Anonymous users only see flags rolled out to everyone
+		{"beta-banner", 0, true, ReasonRollout},
+	}
+	for _, tt := range tests {
+		eval := evaluate(t, s, tt.key, tt.userID)
+		if !reflect.DeepEqual(eval.Value, tt.value) || eval.Reason != tt.reason {
+			t.Errorf("%s for user %d = %v (%s), want %v (%s)", tt.key, tt.userID, eval.Value, eval.Reason, tt.value, tt.reason)
+		}
+	}
+
+	// A zero rollout is kept rather than replaced by a column default
+	flag, err := s.Get(context.Background(), "theme")
+	if err != nil || flag.RolloutPercentage != 0 {
+		t.Fatalf("theme rollout = %v, %v; want 0", flag, err)
+	}
+	if err := s.Save(context.Background(), &Flag{Key: "bad", RolloutPercentage: 101}); err == nil {
+		t.Fatal("saved a flag with a 101% rollout")
+	}
+}
+
+func TestEvaluationCache(t *testing.T) {
+	s, _ := newTestService(t)
+	mc := cache.NewMemoryCache(cache.DefaultMemoryCacheConfig())
+	t.Cleanup(func() { mc.Close() })
+	s.SetCache(mc, time.Minute)
+
+	// Keys with pattern characters only invalidate their own evaluations
+	saveFlag(t, s, &Flag{Key: "promo*", Enabled: true, RolloutPercentage: 100})
+	saveFlag(t, s, &Flag{Key: "promo-summer", Enabled: true, RolloutPercentage: 100})
+	evaluate(t, s, "promo*", 1)
+	evaluate(t, s, "promo-summer", 1)
+
+	// Changed behind the service's back: the cached evaluation is served
+	s.db.Model(&Flag{}).Where("key = ?", "promo-summer").Update("enabled", false)
+	if !evaluate(t, s, "promo-summer", 1).Enabled {
+		t.Fatal("evaluation was not cached")
+	}
+
+	flag, _ := s.Get(context.Background(), "promo*")
+	flag.Enabled = false
+	saveFlag(t, s, flag)
+	if evaluate(t, s, "promo*", 1).Enabled {
+		t.Fatal("saving a flag didn't invalidate its evaluations")
+	}
+	if !evaluate(t, s, "promo-summer", 1).Enabled {
+		t.Fatal("saving promo* invalidated promo-summer")
+	}
+
+	if err := s.Delete(context.Background(), "promo*"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if eval := evaluate(t, s, "promo*", 1); eval.Reason != ReasonNotFound {
+		t.Fatalf("deleted flag evaluated with reason %s", eval.Reason)
+	}
+}
@@ -3,6 +3,7 @@ package graphql
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 )
@@ -89,6 +90,11 @@ func (e *Executor) Execute(ctx context.Context, query *Query) *Response {
 	// Execute query (simplified)
 	data, err := e.executeFields(ctx, rootType, nil, query.Variables)
 	if err != nil {
+		var argErrs ArgumentErrors
+		if errors.As(err, &argErrs) {
+			response.Errors = append(response.Errors, argErrs...)
+			return response
+		}
 		response.Errors = append(response.Errors, Error{
 			Message: err.Error(),
 		})
@@ -131,8 +137,14 @@ func (e *Executor) executeFields(ctx context.Context, objType *ObjectType, paren
 			continue
 		}
 
+		// Validate and coerce arguments before the resolver sees them
+		args, err := e.schema.ValidateArgs(field, variables)
+		if err != nil {
+			return nil, err
+		}
+
 		// Execute resolver
-		value, err := resolver(ctx, parent, variables)
+		value, err := resolver(ctx, parent, args)
 		if err != nil {
 			return nil, fmt.Errorf("error resolving field %s: %w", field.Name, err)
 		}
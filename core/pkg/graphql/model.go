@@ -0,0 +1,258 @@
+package graphql
+
+import (
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	deletedAtType = reflect.TypeOf(gorm.DeletedAt{})
+)
+
+// FromModel generates a GraphQL type from a GORM model. Unlike FromStruct it
+// understands GORM conventions:
+//
+//   - embedded structs such as gorm.Model are flattened into the type
+//   - gorm.DeletedAt and fields tagged json:"-" or gorm:"-" are skipped
+//   - the primary key and foreign keys (e.g. CategoryID) are IDs
+//   - struct fields become relation fields named after the related type,
+//     e.g. `Category ProductCategory` becomes `category: ProductCategory`
+//   - slices of structs become list relations, e.g. `items: [OrderItem]`
+//   - a belongs-to relation is non-null when its foreign key is a plain
+//     integer and nullable when it is a pointer such as *uint
+//
+// Field names come from json tags, falling back to snake_case. Each field
+// resolves from the parent struct by its Go name. Related models must be
+// added to the schema under their Go type names.
+func FromModel(name string, model interface{}) *ObjectType {
+	t := reflect.TypeOf(model)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	objType := &ObjectType{
+		Name:   name,
+		Fields: []*Field{},
+	}
+
+	fields := modelFields(t)
+	byName := make(map[string]reflect.StructField, len(fields))
+	for _, field := range fields {
+		byName[field.Name] = field
+	}
+
+	// Foreign keys of belongs-to relations, so their nullability follows the key
+	foreignKeys := make(map[string]reflect.StructField)
+	for _, field := range fields {
+		if relatedStruct(field.Type) == nil || isSliceType(field.Type) {
+			continue
+		}
+		if fk, ok := byName[foreignKeyName(field)]; ok {
+			foreignKeys[field.Name] = fk
+		}
+	}
+
+	for _, field := range fields {
+		gqlField := &Field{
+			Name:        modelFieldName(field),
+			Description: field.Tag.Get("graphql"),
+			Resolver:    FieldResolver(field.Name),
+		}
+
+		switch {
+		case isPrimaryKey(field):
+			gqlField.Type = TypeNonNull
+			gqlField.ElementType = string(TypeID)
+
+		case isForeignKey(field, byName):
+			gqlField.Type = TypeID
+			if field.Type.Kind() != reflect.Ptr {
+				gqlField.Type = TypeNonNull
+				gqlField.ElementType = string(TypeID)
+			}
+
+		case relatedStruct(field.Type) != nil:
+			related := relatedStruct(field.Type).Name()
+			if isSliceType(field.Type) {
+				gqlField.Type = TypeList
+				gqlField.ElementType = related
+			} else if fk, ok := foreignKeys[field.Name]; ok && fk.Type.Kind() != reflect.Ptr {
+				gqlField.Type = TypeNonNull
+				gqlField.ElementType = related
+			} else {
+				gqlField.Type = TypeObject
+				gqlField.ElementType = related
+			}
+
+		default:
+			gqlField.Type = modelScalarType(field.Type)
+			if gqlField.Type == TypeList {
+				gqlField.ElementType = string(modelScalarType(field.Type.Elem()))
+			}
+		}
+
+		objType.Fields = append(objType.Fields, gqlField)
+	}
+
+	return objType
+}
+
+// TypeFromModel adds a type generated from a GORM model
+func (b *Builder) TypeFromModel(name string, model interface{}, description ...string) *Builder {
+	objType := FromModel(name, model)
+	if len(description) > 0 {
+		objType.Description = description[0]
+	}
+	b.schema.AddType(objType)
+	return b
+}
+
+// modelFields lists exported fields with embedded structs flattened,
+// skipping soft-delete markers and fields hidden from JSON or GORM
+func modelFields(t reflect.Type) []reflect.StructField {
+	var fields []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if field.Anonymous {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				fields = append(fields, modelFields(embedded)...)
+				continue
+			}
+		}
+
+		if !field.IsExported() || field.Type == deletedAtType {
+			continue
+		}
+		if field.Tag.Get("json") == "-" || field.Tag.Get("gorm") == "-" {
+			continue
+		}
+
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// modelFieldName returns the json name of a field, or its snake_case name
+func modelFieldName(field reflect.StructField) string {
+	if name := strings.Split(field.Tag.Get("json"), ",")[0]; name != "" {
+		return name
+	}
+	return toSnakeCase(field.Name)
+}
+
+// relatedStruct returns the model type a field relates to, or nil for
+// scalar fields. time.Time and other non-model structs are not relations.
+func relatedStruct(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t == timeType || t == deletedAtType {
+		return nil
+	}
+	return t
+}
+
+func isSliceType(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice || t.Kind() == reflect.Array
+}
+
+// isPrimaryKey reports whether a field is the model's primary key
+func isPrimaryKey(field reflect.StructField) bool {
+	return field.Name == "ID" || gormTagHas(field, "primarykey")
+}
+
+// isForeignKey reports whether an integer field is the key of a relation
+// declared on the model, by GORM's default naming (Category -> CategoryID)
+// or an explicit foreignKey tag
+func isForeignKey(field reflect.StructField, byName map[string]reflect.StructField) bool {
+	kind := field.Type.Kind()
+	if kind == reflect.Ptr {
+		kind = field.Type.Elem().Kind()
+	}
+	if kind < reflect.Int || kind > reflect.Uint64 {
+		return false
+	}
+
+	for _, other := range byName {
+		if relatedStruct(other.Type) != nil && !isSliceType(other.Type) && foreignKeyName(other) == field.Name {
+			return true
+		}
+	}
+	return strings.HasSuffix(field.Name, "ID") && len(field.Name) > 2
+}
+
+// foreignKeyName returns the foreign key field of a belongs-to relation
+func foreignKeyName(relation reflect.StructField) string {
+	if fk := gormTagValue(relation, "foreignkey"); fk != "" {
+		return fk
+	}
+	return relation.Name + "ID"
+}
+
+// gormTagValue returns the value of a key:value setting in a gorm tag
+func gormTagValue(field reflect.StructField, key string) string {
+	for _, setting := range strings.Split(field.Tag.Get("gorm"), ";") {
+		parts := strings.SplitN(setting, ":", 2)
+		if len(parts) == 2 && strings.EqualFold(strings.TrimSpace(parts[0]), key) {
+			return strings.TrimSpace(parts[1])
+		}
+	}
+	return ""
+}
+
+// gormTagHas reports whether a gorm tag contains a flag setting
+func gormTagHas(field reflect.StructField, key string) bool {
+	for _, setting := range strings.Split(field.Tag.Get("gorm"), ";") {
+		if strings.EqualFold(strings.TrimSpace(strings.SplitN(setting, ":", 2)[0]), key) {
+			return true
+		}
+	}
+	return false
+}
+
+// modelScalarType maps a Go field type to a GraphQL scalar, with time.Time
+// as an RFC 3339 String
+func modelScalarType(t reflect.Type) FieldType {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return TypeString
+	}
+	if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+		return TypeString
+	}
+	return goTypeToGraphQLType(t)
+}
+
+// toSnakeCase converts a Go field name such as CategoryID to category_id
+func toSnakeCase(name string) string {
+	runes := []rune(name)
+	var sb strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			prevLower := i > 0 && !unicode.IsUpper(runes[i-1])
+			nextLower := i+1 < len(runes) && !unicode.IsUpper(runes[i+1])
+			if i > 0 && (prevLower || (nextLower && unicode.IsUpper(runes[i-1]))) {
+				sb.WriteByte('_')
+			}
+			sb.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
@@ -0,0 +1,142 @@
+package graphql
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type modelTestCategory struct {
+	gorm.Model
+	Name string `json:"name"`
+}
+
+type modelTestBrand struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+type modelTestTag struct {
+	ID    uint   `json:"id"`
+	Label string `json:"label"`
+}
+
+type modelTestProduct struct {
+	gorm.Model
+	Name        string            `json:"name" gorm:"size:255;not null"`
+	Price       float64           `json:"price"`
+	Stock       int               `json:"stock"`
+	IsActive    bool              `json:"is_active"`
+	Keywords    []string          `json:"keywords" gorm:"serializer:json"`
+	Thumbnail   []byte            `json:"thumbnail"`
+	PublishedAt *time.Time        `json:"published_at"`
+	CategoryID  uint              `json:"category_id"`
+	Category    modelTestCategory `json:"category" gorm:"foreignKey:CategoryID"`
+	BrandID     *uint             `json:"brand_id"`
+	Brand       *modelTestBrand   `json:"brand"`
+	SupplierRef uint
+	Supplier    *modelTestBrand `gorm:"foreignKey:SupplierRef"`
+	Tags        []modelTestTag  `json:"tags" gorm:"many2many:product_tags"`
+	CostPrice   float64         `json:"-"`
+	Computed    string          `gorm:"-"`
+	internal    string
+}
+
+// fieldTypes returns each field's GraphQL type string by name
+func fieldTypes(schema *Schema, objType *ObjectType) map[string]string {
+	types := make(map[string]string, len(objType.Fields))
+	for _, field := range objType.Fields {
+		types[field.Name] = schema.getFieldTypeString(field.Type, field.ElementType, false)
+	}
+	return types
+}
+
+func TestFromModelGeneratesRelations(t *testing.T) {
+	schema := NewSchema()
+	product := FromModel("Product", &modelTestProduct{})
+
+	want := map[string]string{
+		"id":           "ID!",
+		"created_at":   "String",
+		"updated_at":   "String",
+		"name":         "String",
+		"price":        "Float",
+		"stock":        "Int",
+		"is_active":    "Boolean",
+		"keywords":     "[String]",
+		"thumbnail":    "String",
+		"published_at": "String",
+		"category_id":  "ID!",
+		"category":     "modelTestCategory!",
+		"brand_id":     "ID",
+		"brand":        "modelTestBrand",
+		"supplier_ref": "ID!",
+		"supplier":     "modelTestBrand!",
+		"tags":         "[modelTestTag]",
+	}
+	got := fieldTypes(schema, product)
+	for name, typ := range want {
+		if got[name] != typ {
+			t.Errorf("%s: %s, want %s", name, got[name], typ)
+		}
+	}
+	// Soft delete markers and hidden fields are left out
+	for name := range got {
+		if _, ok := want[name]; !ok {
+			t.Errorf("unexpected field %s: %s", name, got[name])
+		}
+	}
+}
+
+func TestFromModelResolvesFromStruct(t *testing.T) {
+	product := &modelTestProduct{
+		Name:     "Lamp",
+		Category: modelTestCategory{Name: "Lighting"},
+	}
+	product.ID = 7
+
+	resolved := make(map[string]interface{})
+	for _, field := range FromModel("Product", product).Fields {
+		value, err := field.Resolver(context.Background(), product, nil)
+		if err != nil {
+			t.Fatalf("resolve %s: %v", field.Name, err)
+		}
+		resolved[field.Name] = value
+	}
+
+	if resolved["id"] != uint(7) || resolved["name"] != "Lamp" {
+		t.Fatalf("resolved %v", resolved)
+	}
+	if category, _ := resolved["category"].(modelTestCategory); category.Name != "Lighting" {
+		t.Fatalf("category = %#v", resolved["category"])
+	}
+	if brand, _ := resolved["brand"].(*modelTestBrand); brand != nil {
+		t.Fatalf("brand = %#v, want nil", brand)
+	}
+}
+
+func TestTypeFromModelSDL(t *testing.T) {
+	schema := NewBuilder().
+		TypeFromModel("modelTestCategory", &modelTestCategory{}).
+		TypeFromModel("Product", &modelTestProduct{}, "A product for sale").
+		Build()
+
+	sdl := schema.String()
+	for _, line := range []string{
+		"type Product {",
+		"category: modelTestCategory!",
+		"brand: modelTestBrand",
+		"tags: [modelTestTag]",
+		"type modelTestCategory {",
+	} {
+		if !strings.Contains(sdl, line) {
+			t.Fatalf("SDL is missing %q:\n%s", line, sdl)
+		}
+	}
+	if strings.Contains(sdl, "deleted_at") {
+		t.Fatalf("SDL exposes deleted_at:\n%s", sdl)
+	}
+}
@@ -0,0 +1,304 @@
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ErrCodeBadUserInput is the error extension code for invalid arguments
+const ErrCodeBadUserInput = "BAD_USER_INPUT"
+
+// ArgumentErrors is returned when a field's arguments fail validation
+type ArgumentErrors []Error
+
+func (e ArgumentErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// ValidateArgs checks args against the field's declared arguments before its
+// resolver runs. Required arguments must be present and non-null, missing
+// optional ones get their default, scalars are coerced to their declared type
+// (e.g. JSON numbers to int for Int), enum values must be declared by the enum
+// and input objects are checked field by field. Arguments the field doesn't
+// declare are passed through unchanged. It returns the coerced arguments, or
+// ArgumentErrors describing every violation.
+func (s *Schema) ValidateArgs(field *Field, args map[string]interface{}) (map[string]interface{}, error) {
+	if len(field.Args) == 0 {
+		return args, nil
+	}
+
+	coerced := make(map[string]interface{}, len(args)+len(field.Args))
+	for name, value := range args {
+		coerced[name] = value
+	}
+
+	var errs ArgumentErrors
+	for _, arg := range field.Args {
+		path := []interface{}{field.Name, arg.Name}
+
+		value, present := args[arg.Name]
+		if !present && arg.DefaultValue != nil {
+			value, present = arg.DefaultValue, true
+		}
+
+		if !present || value == nil {
+			if arg.Required || arg.Type == TypeNonNull {
+				errs = append(errs, argumentError(path, "Argument %q of type %s is required", arg.Name, s.getFieldTypeString(arg.Type, arg.ElementType, arg.Required)))
+			}
+			continue
+		}
+
+		result, err := s.coerceInput(arg.Type, arg.ElementType, value)
+		if err != nil {
+			errs = append(errs, argumentError(path, "Argument %q has invalid value: %v", arg.Name, err))
+			continue
+		}
+		coerced[arg.Name] = result
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return coerced, nil
+}
+
+// argumentError builds a GraphQL error for an invalid argument
+func argumentError(path []interface{}, format string, args ...interface{}) Error {
+	return Error{
+		Message:    fmt.Sprintf(format, args...),
+		Path:       path,
+		Extensions: map[string]interface{}{"code": ErrCodeBadUserInput},
+	}
+}
+
+// coerceInput coerces a non-null input value to the given type. elementType
+// names the inner type of lists and non-nulls, or the enum/input type of
+// TypeEnum and TypeObject.
+func (s *Schema) coerceInput(fieldType FieldType, elementType string, value interface{}) (interface{}, error) {
+	switch fieldType {
+	case TypeNonNull:
+		if value == nil {
+			return nil, fmt.Errorf("expected non-null %s", elementType)
+		}
+		return s.coerceNamed(elementType, value)
+	case TypeList:
+		items, ok := value.([]interface{})
+		if !ok {
+			// A single value is coerced to a one-item list
+			items = []interface{}{value}
+		}
+		result := make([]interface{}, len(items))
+		for i, item := range items {
+			if item == nil {
+				if strings.HasSuffix(elementType, "!") {
+					return nil, fmt.Errorf("item %d: expected non-null %s", i, strings.TrimSuffix(elementType, "!"))
+				}
+				continue
+			}
+			coerced, err := s.coerceNamed(elementType, item)
+			if err != nil {
+				return nil, fmt.Errorf("item %d: %w", i, err)
+			}
+			result[i] = coerced
+		}
+		return result, nil
+	case TypeEnum, TypeObject:
+		return s.coerceNamed(elementType, value)
+	default:
+		if elementType != "" {
+			return s.coerceNamed(elementType, value)
+		}
+		return s.coerceNamed(string(fieldType), value)
+	}
+}
+
+// coerceNamed coerces a value to a scalar, enum or input type by name. A
+// trailing "!" marks a non-null type and "[T]" a list of T.
+func (s *Schema) coerceNamed(typeName string, value interface{}) (interface{}, error) {
+	if strings.HasSuffix(typeName, "!") {
+		return s.coerceInput(TypeNonNull, strings.TrimSuffix(typeName, "!"), value)
+	}
+	if strings.HasPrefix(typeName, "[") && strings.HasSuffix(typeName, "]") {
+		return s.coerceInput(TypeList, typeName[1:len(typeName)-1], value)
+	}
+
+	switch FieldType(typeName) {
+	case TypeString:
+		if str, ok := value.(string); ok {
+			return str, nil
+		}
+		return nil, fmt.Errorf("expected String, got %s", describeValue(value))
+	case TypeInt:
+		return coerceInt(value)
+	case TypeFloat:
+		return coerceFloat(value)
+	case TypeBoolean:
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+		return nil, fmt.Errorf("expected Boolean, got %s", describeValue(value))
+	case TypeID:
+		switch v := value.(type) {
+		case string:
+			return v, nil
+		default:
+			n, err := coerceInt(value)
+			if err != nil {
+				return nil, fmt.Errorf("expected ID, got %s", describeValue(value))
+			}
+			return strconv.Itoa(n), nil
+		}
+	}
+
+	if enum, ok := s.Enums[typeName]; ok {
+		name, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected %s enum value, got %s", typeName, describeValue(value))
+		}
+		for _, enumValue := range enum.Values {
+			if enumValue.Name == name {
+				return name, nil
+			}
+		}
+		return nil, fmt.Errorf("%q is not a valid %s value", name, typeName)
+	}
+
+	if input, ok := s.Inputs[typeName]; ok {
+		return s.coerceInputObject(input, value)
+	}
+
+	// Unknown named types are passed through for resolvers to handle
+	return value, nil
+}
+
+// coerceInputObject checks an input object's fields
+func (s *Schema) coerceInputObject(input *InputType, value interface{}) (interface{}, error) {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected %s object, got %s", input.Name, describeValue(value))
+	}
+
+	declared := make(map[string]bool, len(input.Fields))
+	result := make(map[string]interface{}, len(object))
+	for _, field := range input.Fields {
+		declared[field.Name] = true
+
+		fieldValue, present := object[field.Name]
+		if !present && field.DefaultValue != nil {
+			fieldValue, present = field.DefaultValue, true
+		}
+		if !present || fieldValue == nil {
+			if field.Required || field.Type == TypeNonNull {
+				return nil, fmt.Errorf("field %s.%s is required", input.Name, field.Name)
+			}
+			if present {
+				result[field.Name] = nil
+			}
+			continue
+		}
+
+		coerced, err := s.coerceInput(field.Type, field.ElementType, fieldValue)
+		if err != nil {
+			return nil, fmt.Errorf("field %s.%s: %w", input.Name, field.Name, err)
+		}
+		result[field.Name] = coerced
+	}
+
+	for name := range object {
+		if !declared[name] {
+			return nil, fmt.Errorf("field %q is not defined by %s", name, input.Name)
+		}
+	}
+
+	return result, nil
+}
+
+// coerceInt accepts integral numbers within GraphQL's 32-bit Int range
+func coerceInt(value interface{}) (int, error) {
+	f, ok := numberValue(value)
+	if !ok {
+		if n, isNumber := value.(json.Number); isNumber {
+			return 0, fmt.Errorf("expected Int, got %q", n.String())
+		}
+		return 0, fmt.Errorf("expected Int, got %s", describeValue(value))
+	}
+
+	if f != math.Trunc(f) {
+		return 0, fmt.Errorf("expected Int, got non-integer %v", f)
+	}
+	if f < math.MinInt32 || f > math.MaxInt32 {
+		return 0, fmt.Errorf("Int %v is outside the 32-bit range", f)
+	}
+	return int(f), nil
+}
+
+// coerceFloat accepts any number
+func coerceFloat(value interface{}) (float64, error) {
+	f, ok := numberValue(value)
+	if !ok {
+		if n, isNumber := value.(json.Number); isNumber {
+			return 0, fmt.Errorf("expected Float, got %q", n.String())
+		}
+		return 0, fmt.Errorf("expected Float, got %s", describeValue(value))
+	}
+	return f, nil
+}
+
+// numberValue converts a Go or JSON number to float64
+func numberValue(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// describeValue names a value's type for error messages
+func describeValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return fmt.Sprintf("String %q", v)
+	case bool:
+		return "Boolean"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "list"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
@@ -0,0 +1,168 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newValidationTestSchema returns a schema with a products query taking
+// scalar, enum, list and input object arguments
+func newValidationTestSchema() (*Schema, *Field) {
+	schema := NewSchema()
+	schema.AddEnum(&EnumType{
+		Name:   "ProductStatus",
+		Values: []*EnumValue{{Name: "ACTIVE"}, {Name: "ARCHIVED"}},
+	})
+	schema.AddInput(&InputType{
+		Name: "PriceRange",
+		Fields: []*InputField{
+			{Name: "min", Type: TypeFloat, Required: true},
+			{Name: "max", Type: TypeFloat},
+		},
+	})
+
+	field := &Field{
+		Name: "products",
+		Type: TypeList,
+		Args: []*Argument{
+			{Name: "categoryId", Type: TypeID, Required: true},
+			{Name: "search", Type: TypeString},
+			{Name: "limit", Type: TypeInt, DefaultValue: 20},
+			{Name: "status", Type: TypeEnum, ElementType: "ProductStatus"},
+			{Name: "tags", Type: TypeList, ElementType: "String!"},
+			{Name: "price", Type: TypeObject, ElementType: "PriceRange"},
+		},
+	}
+	schema.SetQuery(&ObjectType{Name: "Query", Fields: []*Field{field}})
+	return schema, field
+}
+
+func TestValidateArgsCoercesValues(t *testing.T) {
+	schema, field := newValidationTestSchema()
+
+	args, err := schema.ValidateArgs(field, map[string]interface{}{
+		"categoryId": float64(7),
+		"limit":      json.Number("5"),
+		"status":     "ARCHIVED",
+		"tags":       "sale",
+		"price":      map[string]interface{}{"min": int64(5000000000)},
+		"debug":      true,
+	})
+	if err != nil {
+		t.Fatalf("ValidateArgs: %v", err)
+	}
+
+	want := map[string]interface{}{
+		"categoryId": "7",
+		"limit":      5,
+		"status":     "ARCHIVED",
+		"tags":       []interface{}{"sale"},
+		"price":      map[string]interface{}{"min": float64(5000000000)},
+		// Undeclared arguments are passed through
+		"debug": true,
+	}
+	if !reflect.DeepEqual(args, want) {
+		t.Fatalf("args = %#v, want %#v", args, want)
+	}
+
+	// Defaults fill in missing optional arguments
+	args, err = schema.ValidateArgs(field, map[string]interface{}{"categoryId": "7"})
+	if err != nil || args["limit"] != 20 {
+		t.Fatalf("args = %v, %v; want the default limit", args, err)
+	}
+	if _, ok := args["search"]; ok {
+		t.Fatalf("args = %v, want missing optional arguments left out", args)
+	}
+}
+
+func TestValidateArgsRejectsInvalidValues(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    map[string]interface{}
+		message string
+	}{
+		{"missing required", map[string]interface{}{}, `Argument "categoryId" of type ID! is required`},
+		{"null required", map[string]interface{}{"categoryId": nil}, `Argument "categoryId" of type ID! is required`},
+		{"string for int", map[string]interface{}{"categoryId": "1", "limit": "10"}, `Argument "limit" has invalid value: expected Int, got String "10"`},
+		{"fractional int", map[string]interface{}{"categoryId": "1", "limit": 2.5}, "expected Int, got non-integer 2.5"},
+		{"int out of range", map[string]interface{}{"categoryId": "1", "limit": float64(1 << 40)}, "outside the 32-bit range"},
+		{"number for string", map[string]interface{}{"categoryId": "1", "search": 42}, "expected String, got int"},
+		{"invalid number", map[string]interface{}{"categoryId": "1", "limit": json.Number("1x")}, `expected Int, got "1x"`},
+		{"string for float", map[string]interface{}{"categoryId": "1", "price": map[string]interface{}{"min": "10"}}, `field PriceRange.min: expected Float, got String "10"`},
+		{"bool for id", map[string]interface{}{"categoryId": true}, "expected ID, got Boolean"},
+		{"invalid enum", map[string]interface{}{"categoryId": "1", "status": "DELETED"}, `"DELETED" is not a valid ProductStatus value`},
+		{"null list item", map[string]interface{}{"categoryId": "1", "tags": []interface{}{"a", nil}}, "item 1: expected non-null String"},
+		{"missing input field", map[string]interface{}{"categoryId": "1", "price": map[string]interface{}{"max": 5}}, "field PriceRange.min is required"},
+		{"unknown input field", map[string]interface{}{"categoryId": "1", "price": map[string]interface{}{"min": 1, "avg": 2}}, `field "avg" is not defined by PriceRange`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, field := newValidationTestSchema()
+
+			_, err := schema.ValidateArgs(field, tt.args)
+			var argErrs ArgumentErrors
+			if !errors.As(err, &argErrs) || len(argErrs) != 1 {
+				t.Fatalf("error = %v, want one argument error", err)
+			}
+			if !strings.Contains(argErrs[0].Message, tt.message) {
+				t.Fatalf("message = %q, want it to contain %q", argErrs[0].Message, tt.message)
+			}
+			if argErrs[0].Extensions["code"] != ErrCodeBadUserInput || argErrs[0].Path[0] != "products" {
+				t.Fatalf("error = %+v, want BAD_USER_INPUT at products", argErrs[0])
+			}
+		})
+	}
+}
+
+func TestValidateArgsReportsEveryViolation(t *testing.T) {
+	schema, field := newValidationTestSchema()
+
+	_, err := schema.ValidateArgs(field, map[string]interface{}{"limit": "ten", "status": "DELETED"})
+	var argErrs ArgumentErrors
+	if !errors.As(err, &argErrs) || len(argErrs) != 3 {
+		t.Fatalf("error = %v, want three argument errors", err)
+	}
+	var paths []string
+	for _, e := range argErrs {
+		paths = append(paths, e.Path[1].(string))
+	}
+	if got := strings.Join(paths, ","); got != "categoryId,limit,status" {
+		t.Fatalf("errors at %s, want categoryId, limit and status", got)
+	}
+}
+
+func TestExecutorValidatesBeforeResolving(t *testing.T) {
+	schema, _ := newValidationTestSchema()
+	executor := NewExecutor(schema)
+	var received map[string]interface{}
+	executor.RegisterResolver("Query", "products", func(_ context.Context, _ interface{}, args map[string]interface{}) (interface{}, error) {
+		received = args
+		return []interface{}{}, nil
+	})
+
+	resp := executor.Execute(context.Background(), &Query{
+		Query:     "{ products { id } }",
+		Variables: map[string]interface{}{"categoryId": "1", "status": "DELETED"},
+	})
+	if received != nil {
+		t.Fatalf("resolver ran with invalid arguments %v", received)
+	}
+	if len(resp.Errors) != 1 || resp.Errors[0].Extensions["code"] != ErrCodeBadUserInput || resp.Data != nil {
+		t.Fatalf("response = %+v, want one BAD_USER_INPUT error and no data", resp)
+	}
+
+	resp = executor.Execute(context.Background(), &Query{
+		Query:     "{ products { id } }",
+		Variables: map[string]interface{}{"categoryId": float64(1), "limit": float64(3)},
+	})
+	if len(resp.Errors) != 0 {
+		t.Fatalf("errors = %+v", resp.Errors)
+	}
+	if received["categoryId"] != "1" || received["limit"] != 3 {
+		t.Fatalf("resolver received %v, want coerced arguments", received)
+	}
+}
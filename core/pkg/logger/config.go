@@ -3,13 +3,15 @@ package logger
 import (
 	"io"
 	"os"
+	"strings"
 )
 
 // Config holds logger configuration
 type Config struct {
 	Level        string
-	Format       string // "text" or "json"
-	Output       string // "console", "file", or "both"
+	Levels       map[string]string // Level overrides by logger name, see SetLevelFor
+	Format       string            // "text" or "json"
+	Output       string            // "console", "file", or "both"
 	FilePath     string
 	MaxSize      int64 // In MB
 	MaxBackups   int
@@ -54,6 +56,17 @@ func LoadConfig() Config {
 		config.FilePath = path
 	}
 
+	// LOG_LEVELS is a comma-separated list of name=level, e.g. "http=warn,module=debug"
+	if levels := os.Getenv("LOG_LEVELS"); levels != "" {
+		config.Levels = make(map[string]string)
+		for _, entry := range strings.Split(levels, ",") {
+			name, level, ok := strings.Cut(entry, "=")
+			if ok && strings.TrimSpace(name) != "" {
+				config.Levels[strings.TrimSpace(name)] = strings.TrimSpace(level)
+			}
+		}
+	}
+
 	return config
 }
 
@@ -62,6 +75,9 @@ func Setup(config Config) error {
 	// Set level
 	level := parseLevel(config.Level)
 	SetGlobalLevel(level)
+	for name, level := range config.Levels {
+		SetLevelFor(name, parseLevel(level))
+	}
 
 	// Set formatter
 	var formatter Formatter
@@ -105,20 +121,8 @@ func Setup(config Config) error {
 	return nil
 }
 
-// parseLevel parses string level to LogLevel
+// parseLevel parses string level to LogLevel, defaulting to InfoLevel
 func parseLevel(level string) LogLevel {
-	switch level {
-	case "debug":
-		return DebugLevel
-	case "info":
-		return InfoLevel
-	case "warn", "warning":
-		return WarnLevel
-	case "error":
-		return ErrorLevel
-	case "fatal":
-		return FatalLevel
-	default:
-		return InfoLevel
-	}
+	parsed, _ := ParseLevel(level)
+	return parsed
 }
@@ -0,0 +1,117 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// RootLogger is the name whose level override applies to every logger
+// without a more specific one
+const RootLogger = "root"
+
+var (
+	overridesMu sync.RWMutex
+	overrides   = make(map[string]LogLevel)
+)
+
+// SetLevelFor overrides the level of the named logger and its descendants
+// at runtime, e.g. "module" also covers "module.seeder". Overrides take
+// precedence over levels set with SetLevel; RootLogger covers all loggers.
+func SetLevelFor(name string, level LogLevel) {
+	overridesMu.Lock()
+	defer overridesMu.Unlock()
+	overrides[name] = level
+}
+
+// ClearLevelFor removes the level override of the named logger
+func ClearLevelFor(name string) {
+	overridesMu.Lock()
+	defer overridesMu.Unlock()
+	delete(overrides, name)
+}
+
+// LevelOverrides returns the current level overrides by logger name
+func LevelOverrides() map[string]LogLevel {
+	overridesMu.RLock()
+	defer overridesMu.RUnlock()
+
+	levels := make(map[string]LogLevel, len(overrides))
+	for name, level := range overrides {
+		levels[name] = level
+	}
+	return levels
+}
+
+// overrideFor returns the override of the most specific name covering the
+// logger: the name itself, its parents, then RootLogger
+func overrideFor(name string) (LogLevel, bool) {
+	overridesMu.RLock()
+	defer overridesMu.RUnlock()
+
+	if len(overrides) == 0 {
+		return 0, false
+	}
+	for name != "" {
+		if level, ok := overrides[name]; ok {
+			return level, true
+		}
+		idx := strings.LastIndex(name, ".")
+		if idx < 0 {
+			break
+		}
+		name = name[:idx]
+	}
+	level, ok := overrides[RootLogger]
+	return level, ok
+}
+
+// Named returns a logger with a dotted name, appended to this logger's name
+// if it has one. Entries carry the name in the "logger" field, and its level
+// can be changed at runtime with SetLevelFor.
+func (l *StandardLogger) Named(name string) Logger {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if l.name != "" {
+		name = l.name + "." + name
+	}
+
+	return &StandardLogger{
+		level:     l.level,
+		formatter: l.formatter,
+		writers:   l.writers,
+		fields:    l.fields,
+		ctx:       l.ctx,
+		caller:    l.caller,
+		colorize:  l.colorize,
+		name:      name,
+		sampler:   l.sampler,
+	}
+}
+
+// effectiveLevel returns the minimum level to log. Caller must hold l.mu.
+func (l *StandardLogger) effectiveLevel() LogLevel {
+	if level, ok := overrideFor(l.name); ok {
+		return level
+	}
+	return l.level
+}
+
+// ParseLevel parses a level name such as "debug" or "warn"
+func ParseLevel(level string) (LogLevel, error) {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug":
+		return DebugLevel, nil
+	case "info":
+		return InfoLevel, nil
+	case "warn", "warning":
+		return WarnLevel, nil
+	case "error":
+		return ErrorLevel, nil
+	case "fatal":
+		return FatalLevel, nil
+	default:
+		return InfoLevel, fmt.Errorf("unknown log level: %q", level)
+	}
+}
@@ -0,0 +1,114 @@
+package logger
+
+import (
+	"testing"
+)
+
+// clearLevelOverrides removes every level override when the test ends
+func clearLevelOverrides(t *testing.T) {
+	t.Cleanup(func() {
+		for name := range LevelOverrides() {
+			ClearLevelFor(name)
+		}
+	})
+}
+
+// messages returns the messages of the JSON lines written to the logger
+func messages(t *testing.T, lines []map[string]interface{}) []string {
+	t.Helper()
+
+	msgs := make([]string, len(lines))
+	for i, line := range lines {
+		msgs[i], _ = line["message"].(string)
+	}
+	return msgs
+}
+
+func TestSuppressedLevelsDontEmit(t *testing.T) {
+	logger, buf := newBufferLogger()
+	logger.SetLevel(WarnLevel)
+
+	logger.Debug("debug")
+	logger.Info("info")
+	logger.Warn("warn")
+	logger.Error("error")
+
+	if got := messages(t, logLines(t, buf)); len(got) != 2 || got[0] != "warn" || got[1] != "error" {
+		t.Fatalf("logged %v, want only warn and error", got)
+	}
+}
+
+func TestLevelOverridesByLoggerName(t *testing.T) {
+	clearLevelOverrides(t)
+	base, buf := newBufferLogger()
+	module := base.Named("module")
+	seeder := module.Named("seeder")
+	http := base.Named("http")
+
+	tests := []struct {
+		name      string
+		overrides map[string]LogLevel
+		want      []string
+	}{
+		{"configured level", nil, []string{"base", "module", "seeder", "http"}},
+		// A parent's override covers its descendants
+		{"parent", map[string]LogLevel{"module": WarnLevel}, []string{"base", "http"}},
+		{"most specific wins", map[string]LogLevel{"module": WarnLevel, "module.seeder": DebugLevel}, []string{"base", "seeder", "http"}},
+		{"root", map[string]LogLevel{RootLogger: ErrorLevel, "http": InfoLevel}, []string{"http"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name := range LevelOverrides() {
+				ClearLevelFor(name)
+			}
+			for name, level := range tt.overrides {
+				SetLevelFor(name, level)
+			}
+
+			base.Info("base")
+			module.Info("module")
+			seeder.Info("seeder")
+			http.Info("http")
+
+			got := messages(t, logLines(t, buf))
+			if len(got) != len(tt.want) {
+				t.Fatalf("logged %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("logged %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestNamedLoggerCarriesName(t *testing.T) {
+	logger, buf := newBufferLogger()
+	logger.Named("module").Named("seeder").With(Fields{"seeder": "roles"}).Info("seeded")
+
+	lines := logLines(t, buf)
+	if len(lines) != 1 || lines[0]["logger"] != "module.seeder" || lines[0]["seeder"] != "roles" {
+		t.Fatalf("lines = %v, want the logger name and fields", lines)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    LogLevel
+		wantErr bool
+	}{
+		{"debug", DebugLevel, false},
+		{" WARN ", WarnLevel, false},
+		{"warning", WarnLevel, false},
+		{"error", ErrorLevel, false},
+		{"verbose", InfoLevel, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.input)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ParseLevel(%q) = %v, %v; want %v, error %v", tt.input, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -71,6 +71,8 @@ type Logger interface {
 
 	With(fields Fields) Logger
 	WithContext(ctx context.Context) Logger
+	Named(name string) Logger
+	Sample(key string, n int) Logger
 	SetLevel(level LogLevel)
 	SetFormatter(formatter Formatter)
 	AddWriter(writer io.Writer)
@@ -102,6 +104,8 @@ type StandardLogger struct {
 	ctx       context.Context
 	caller    bool
 	colorize  bool
+	name      string   // Dotted logger name for level overrides, e.g. "module.seeder"
+	sampler   *sampler // Writes only 1-in-N entries when set
 }
 
 // NewLogger creates a new logger instance
@@ -172,6 +176,8 @@ func (l *StandardLogger) With(fields Fields) Logger {
 		ctx:       l.ctx,
 		caller:    l.caller,
 		colorize:  l.colorize,
+		name:      l.name,
+		sampler:   l.sampler,
 	}
 }
 
@@ -188,6 +194,8 @@ func (l *StandardLogger) WithContext(ctx context.Context) Logger {
 		ctx:       ctx,
 		caller:    l.caller,
 		colorize:  l.colorize,
+		name:      l.name,
+		sampler:   l.sampler,
 	}
 }
 
@@ -222,13 +230,29 @@ func (l *StandardLogger) log(level LogLevel, msg string, fieldsSlice ...Fields)
 	l.mu.RLock()
 
 	// Check if we should log this level
-	if level < l.level {
+	if level < l.effectiveLevel() {
 		l.mu.RUnlock()
 		return
 	}
 
-	// Merge fields
+	// Keep only 1-in-N entries of a sampled logger
+	var sampleCount uint64
+	if l.sampler != nil {
+		var keep bool
+		if sampleCount, keep = l.sampler.take(); !keep {
+			l.mu.RUnlock()
+			return
+		}
+	}
+
+	// Merge fields; request correlation from the context comes first so
+	// explicit fields can override it
 	mergedFields := make(Fields)
+	if l.ctx != nil {
+		for k, v := range contextFields(l.ctx) {
+			mergedFields[k] = v
+		}
+	}
 	for k, v := range l.fields {
 		mergedFields[k] = v
 	}
@@ -237,6 +261,13 @@ func (l *StandardLogger) log(level LogLevel, msg string, fieldsSlice ...Fields)
 			mergedFields[k] = v
 		}
 	}
+	if l.name != "" {
+		mergedFields["logger"] = l.name
+	}
+	if l.sampler != nil {
+		mergedFields["sample_rate"] = l.sampler.n
+		mergedFields["sample_count"] = sampleCount
+	}
 
 	// Get caller info
 	file := ""
@@ -283,6 +314,11 @@ func (l *StandardLogger) log(level LogLevel, msg string, fieldsSlice ...Fields)
 // Global logger instance
 var defaultLogger = NewLogger()
 
+// Default returns the global logger configured by Setup
+func Default() Logger {
+	return defaultLogger
+}
+
 // SetGlobalLevel sets the global logger level
 func SetGlobalLevel(level LogLevel) {
 	defaultLogger.SetLevel(level)
@@ -336,3 +372,7 @@ func With(fields Fields) Logger {
 func WithContext(ctx context.Context) Logger {
 	return defaultLogger.WithContext(ctx)
 }
+
+func Named(name string) Logger {
+	return defaultLogger.Named(name)
+}
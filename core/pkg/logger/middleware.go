@@ -6,11 +6,20 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
-// HTTPMiddleware creates a Fiber middleware for request logging
+// HTTPMiddleware creates a Fiber middleware for request logging. Lines are
+// logged by the "http" logger, so e.g. SetLevelFor("http", WarnLevel) keeps
+// only failed requests.
 func HTTPMiddleware(logger Logger) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		start := time.Now()
 
+		// Prefer the request-scoped logger so the line carries the request ID
+		logger := logger
+		if requestLogger, ok := c.Locals("logger").(Logger); ok {
+			logger = requestLogger
+		}
+		logger = logger.Named("http")
+
 		// Process request
 		err := c.Next()
 
@@ -52,18 +61,41 @@ func HTTPMiddleware(logger Logger) fiber.Handler {
 	}
 }
 
-// RequestIDMiddleware adds a request ID to each request
+// RequestIDMiddleware adds a request ID and B3 trace context to each request.
+// An incoming X-Request-ID and B3 headers are honoured, otherwise new ones are
+// generated. The ID is echoed on the response, stored in Locals and the
+// request's user context, and attached to the request-scoped logger.
 func RequestIDMiddleware(logger Logger) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		requestID := c.Get("X-Request-ID")
+		requestID := c.Get(HeaderRequestID)
 		if requestID == "" {
-			requestID = generateRequestID()
-			c.Set("X-Request-ID", requestID)
+			if existing, ok := c.Locals("request_id").(string); ok {
+				requestID = existing
+			} else {
+				requestID = generateRequestID()
+			}
+		}
+		c.Set(HeaderRequestID, requestID)
+
+		// Continue the caller's trace or start a new one
+		trace := NewTraceContext()
+		if traceID := c.Get(HeaderB3TraceID); traceID != "" {
+			trace = TraceContext{
+				TraceID: traceID,
+				SpanID:  c.Get(HeaderB3SpanID),
+				Sampled: c.Get(HeaderB3Sampled),
+			}
+			if trace.SpanID == "" {
+				trace.SpanID = randomHex(8)
+			}
 		}
 
 		// Store request ID in context for later use
+		ctx := ContextWithTrace(ContextWithRequestID(c.UserContext(), requestID), trace)
+		c.SetUserContext(ctx)
 		c.Locals("request_id", requestID)
-		c.Locals("logger", logger.With(Fields{"request_id": requestID}))
+		c.Locals("trace", trace)
+		c.Locals("logger", logger.WithContext(ctx))
 
 		return c.Next()
 	}
@@ -79,14 +111,5 @@ func GetLogger(c *fiber.Ctx) Logger {
 
 // generateRequestID generates a simple request ID
 func generateRequestID() string {
-	return time.Now().Format("20060102150405") + "-" + randString(8)
-}
-
-func randString(n int) string {
-	const letterBytes = "abcdefghijklmnopqrstuvwxyz0123456789"
-	b := make([]byte, n)
-	for i := range b {
-		b[i] = letterBytes[time.Now().UnixNano()%int64(len(letterBytes))]
-	}
-	return string(b)
+	return time.Now().Format("20060102150405") + "-" + randomHex(8)
 }
@@ -0,0 +1,111 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// newBufferLogger returns a logger writing JSON lines to the returned buffer
+func newBufferLogger() (*StandardLogger, *bytes.Buffer) {
+	var buf bytes.Buffer
+	logger := NewLogger()
+	logger.SetFormatter(NewJSONFormatter())
+	logger.writers = []io.Writer{&buf}
+	return logger, &buf
+}
+
+// logLines decodes the JSON lines written to buf
+func logLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+
+	var lines []map[string]interface{}
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		var line map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("decode log line %q: %v", scanner.Text(), err)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// newRequestIDTestApp logs a line from the handler and passes its user
+// context's outbound headers back in the response body
+func newRequestIDTestApp(logger Logger) *fiber.App {
+	app := fiber.New()
+	app.Use(RequestIDMiddleware(logger), HTTPMiddleware(logger))
+	app.Get("/", func(c *fiber.Ctx) error {
+		GetLogger(c).Info("handling request")
+
+		outbound := http.Header{}
+		InjectHeaders(c.UserContext(), outbound)
+		return c.JSON(outbound)
+	})
+	return app
+}
+
+func TestRequestIDMiddlewareCorrelatesLogs(t *testing.T) {
+	logger, buf := newBufferLogger()
+	app := newRequestIDTestApp(logger)
+
+	req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+	req.Header.Set(HeaderRequestID, "req-123")
+	req.Header.Set(HeaderB3TraceID, "trace-abc")
+	req.Header.Set(HeaderB3SpanID, "span-1")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("GET /: %v", err)
+	}
+
+	if got := resp.Header.Get(HeaderRequestID); got != "req-123" {
+		t.Fatalf("response %s = %q, want req-123", HeaderRequestID, got)
+	}
+
+	lines := logLines(t, buf)
+	if len(lines) != 2 {
+		t.Fatalf("logged %d lines, want the handler's and the access log", len(lines))
+	}
+	for _, line := range lines {
+		if line["request_id"] != "req-123" || line["trace_id"] != "trace-abc" {
+			t.Errorf("log line %v, want request_id req-123 and trace_id trace-abc", line)
+		}
+	}
+
+	// Outbound calls continue the trace in a child span
+	var outbound http.Header
+	if err := json.NewDecoder(resp.Body).Decode(&outbound); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if outbound.Get(HeaderRequestID) != "req-123" || outbound.Get(HeaderB3TraceID) != "trace-abc" ||
+		outbound.Get(HeaderB3ParentSpan) != "span-1" || outbound.Get(HeaderB3SpanID) == "span-1" {
+		t.Fatalf("outbound headers = %v, want a child span of span-1 in trace-abc", outbound)
+	}
+}
+
+func TestRequestIDMiddlewareGeneratesID(t *testing.T) {
+	logger, buf := newBufferLogger()
+	app := newRequestIDTestApp(logger)
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+	if err != nil {
+		t.Fatalf("GET /: %v", err)
+	}
+	requestID := resp.Header.Get(HeaderRequestID)
+	if requestID == "" {
+		t.Fatal("no request ID generated")
+	}
+
+	for _, line := range logLines(t, buf) {
+		if line["request_id"] != requestID || line["trace_id"] == nil || line["trace_id"] == "" {
+			t.Errorf("log line %v, want request_id %s and a new trace", line, requestID)
+		}
+	}
+}
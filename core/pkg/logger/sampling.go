@@ -0,0 +1,50 @@
+package logger
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// sampler keeps the first and then every nth entry logged under a key
+type sampler struct {
+	n     uint64
+	count *atomic.Uint64
+}
+
+// samplerCounts holds the entry count of each sample key, shared by every
+// logger sampling under that key
+var samplerCounts sync.Map // key -> *atomic.Uint64
+
+// Sample returns a logger that writes only 1-in-n entries logged with key:
+// the first, the (n+1)th, and so on. Counting is shared by all loggers using
+// the same key, and entries below the level don't count. Written entries
+// carry "sample_rate" and "sample_count", the number of entries seen so
+// far. Use it for lines on hot paths; n <= 1 disables sampling.
+func (l *StandardLogger) Sample(key string, n int) Logger {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	var s *sampler
+	if n > 1 {
+		count, _ := samplerCounts.LoadOrStore(key, new(atomic.Uint64))
+		s = &sampler{n: uint64(n), count: count.(*atomic.Uint64)}
+	}
+
+	return &StandardLogger{
+		level:     l.level,
+		formatter: l.formatter,
+		writers:   l.writers,
+		fields:    l.fields,
+		ctx:       l.ctx,
+		caller:    l.caller,
+		colorize:  l.colorize,
+		name:      l.name,
+		sampler:   s,
+	}
+}
+
+// take counts an entry and reports the count and whether to write it
+func (s *sampler) take() (uint64, bool) {
+	count := s.count.Add(1)
+	return count, (count-1)%s.n == 0
+}
@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestSamplingEmitsOneInN(t *testing.T) {
+	logger, buf := newBufferLogger()
+	sampled := logger.Sample("test.one-in-n", 10)
+
+	for i := 0; i < 1000; i++ {
+		sampled.Info("module check")
+	}
+
+	lines := logLines(t, buf)
+	if len(lines) != 100 {
+		t.Fatalf("logged %d of 1000 entries, want 100", len(lines))
+	}
+	// The first entry is kept, then every 10th
+	for i, line := range lines {
+		if count := line["sample_count"]; count != float64(i*10+1) {
+			t.Fatalf("line %d sample_count = %v, want %d", i, count, i*10+1)
+		}
+		if line["sample_rate"] != float64(10) {
+			t.Fatalf("sample_rate = %v, want 10", line["sample_rate"])
+		}
+	}
+}
+
+func TestSamplingIsSharedByKey(t *testing.T) {
+	logger, buf := newBufferLogger()
+
+	// Loggers sampling under one key share the count, concurrently too
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sampled := logger.Named("module").Sample("test.shared", 5)
+			for i := 0; i < 250; i++ {
+				sampled.Info("module check")
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := len(logLines(t, buf)); got != 200 {
+		t.Fatalf("logged %d of 1000 entries, want 200", got)
+	}
+
+	// Another key has its own count
+	logger.Sample("test.other", 5).Info("first")
+	if got := len(logLines(t, buf)); got != 1 {
+		t.Fatalf("first entry under a new key logged %d lines, want 1", got)
+	}
+}
+
+func TestSamplingSkipsSuppressedLevels(t *testing.T) {
+	logger, buf := newBufferLogger()
+	sampled := logger.Sample("test.levels", 3)
+
+	// Suppressed entries don't count towards the sample
+	sampled.Debug("ignored")
+	sampled.Debug("ignored")
+	sampled.Info("first")
+	if got := messages(t, logLines(t, buf)); len(got) != 1 || got[0] != "first" {
+		t.Fatalf("logged %v, want the first info entry", got)
+	}
+
+	// n <= 1 disables sampling
+	unsampled := logger.Sample("test.disabled", 1)
+	for i := 0; i < 5; i++ {
+		unsampled.Info("every")
+	}
+	if got := len(logLines(t, buf)); got != 5 {
+		t.Fatalf("logged %d entries without sampling, want 5", got)
+	}
+}
@@ -0,0 +1,121 @@
+package logger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// Request correlation headers
+const (
+	HeaderRequestID    = "X-Request-ID"
+	HeaderB3TraceID    = "X-B3-TraceId"
+	HeaderB3SpanID     = "X-B3-SpanId"
+	HeaderB3ParentSpan = "X-B3-ParentSpanId"
+	HeaderB3Sampled    = "X-B3-Sampled"
+)
+
+type contextKey string
+
+const (
+	requestIDKey contextKey = "request_id"
+	traceKey     contextKey = "trace"
+)
+
+// TraceContext carries B3 trace identifiers for the current request
+type TraceContext struct {
+	TraceID      string
+	SpanID       string
+	ParentSpanID string
+	Sampled      string
+}
+
+// NewTraceContext starts a new trace with a root span
+func NewTraceContext() TraceContext {
+	return TraceContext{
+		TraceID: randomHex(16),
+		SpanID:  randomHex(8),
+	}
+}
+
+// Child returns a new span in the same trace whose parent is t
+func (t TraceContext) Child() TraceContext {
+	return TraceContext{
+		TraceID:      t.TraceID,
+		SpanID:       randomHex(8),
+		ParentSpanID: t.SpanID,
+		Sampled:      t.Sampled,
+	}
+}
+
+// ContextWithRequestID returns a copy of ctx carrying the request ID
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestIDFromContext returns the request ID stored in ctx, if any
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
+
+// ContextWithTrace returns a copy of ctx carrying the trace context
+func ContextWithTrace(ctx context.Context, trace TraceContext) context.Context {
+	return context.WithValue(ctx, traceKey, trace)
+}
+
+// TraceFromContext returns the trace context stored in ctx, if any
+func TraceFromContext(ctx context.Context) (TraceContext, bool) {
+	if ctx == nil {
+		return TraceContext{}, false
+	}
+	trace, ok := ctx.Value(traceKey).(TraceContext)
+	return trace, ok
+}
+
+// InjectHeaders sets the request ID and B3 headers for an outbound call made
+// on behalf of ctx. The outbound call gets its own span whose parent is the
+// current request's span.
+func InjectHeaders(ctx context.Context, header http.Header) {
+	if requestID := RequestIDFromContext(ctx); requestID != "" {
+		header.Set(HeaderRequestID, requestID)
+	}
+
+	trace, ok := TraceFromContext(ctx)
+	if !ok {
+		return
+	}
+
+	child := trace.Child()
+	header.Set(HeaderB3TraceID, child.TraceID)
+	header.Set(HeaderB3SpanID, child.SpanID)
+	header.Set(HeaderB3ParentSpan, child.ParentSpanID)
+	if child.Sampled != "" {
+		header.Set(HeaderB3Sampled, child.Sampled)
+	}
+}
+
+// contextFields returns the correlation fields carried by ctx
+func contextFields(ctx context.Context) Fields {
+	fields := Fields{}
+	if requestID := RequestIDFromContext(ctx); requestID != "" {
+		fields["request_id"] = requestID
+	}
+	if trace, ok := TraceFromContext(ctx); ok {
+		fields["trace_id"] = trace.TraceID
+		fields["span_id"] = trace.SpanID
+	}
+	return fields
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
@@ -3,6 +3,8 @@ package metrics
 import (
 	"context"
 	"runtime"
+	"sort"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -38,6 +40,16 @@ type Counter struct {
 	value       atomic.Uint64
 	labels      map[string]string
 	mu          sync.RWMutex
+
+	// history holds periodic samples for Rate, oldest first
+	history     []counterSample
+	historySize int
+}
+
+// counterSample is a counter value at a point in time
+type counterSample struct {
+	at    time.Time
+	value uint64
 }
 
 // Gauge is a metric that can go up and down
@@ -65,7 +77,7 @@ type Histogram struct {
 type Summary struct {
 	name        string
 	description string
-	values      []float64
+	stream      *quantileStream
 	sum         atomic.Uint64
 	count       atomic.Uint64
 	labels      map[string]string
@@ -85,8 +97,20 @@ type Collector struct {
 
 	// Configuration
 	config CollectorConfig
+
+	// Background collection lifecycle
+	ctx     context.Context
+	cancel  context.CancelFunc
+	done    chan struct{}
+	workers sync.WaitGroup // Collection started after NewCollector, e.g. CollectDBStats
 }
 
+// CounterHistoryInterval is how often counters are sampled for Rate
+const CounterHistoryInterval = time.Second
+
+// DefaultRateWindow is the window of the rate reported in counter metadata
+const DefaultRateWindow = time.Minute
+
 // CollectorConfig holds collector configuration
 type CollectorConfig struct {
 	CollectSystemMetrics bool
@@ -94,6 +118,7 @@ type CollectorConfig struct {
 	EnableHistory        bool
 	HistorySize          int
 	DefaultBuckets       []float64
+	SummaryObjectives    map[float64]float64 // Summary target quantile -> allowed rank error
 }
 
 // DefaultCollectorConfig returns default collector configuration
@@ -104,6 +129,7 @@ func DefaultCollectorConfig() CollectorConfig {
 		EnableHistory:         true,
 		HistorySize:           100,
 		DefaultBuckets:        []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+		SummaryObjectives:     DefaultSummaryObjectives(),
 	}
 }
 
@@ -116,33 +142,104 @@ func NewCollector(config CollectorConfig) *Collector {
 		summaries:  make(map[string]*Summary),
 		startTime:  time.Now(),
 		config:     config,
+		done:       make(chan struct{}),
 	}
 
+	ctx, cancel := context.WithCancel(context.Background())
+	c.ctx = ctx
+	c.cancel = cancel
+
+	var wg sync.WaitGroup
+
 	// Start system metrics collection
 	if config.CollectSystemMetrics {
-		go c.collectSystemMetrics(context.Background())
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.collectSystemMetrics(ctx)
+		}()
+	}
+
+	// Start counter history sampling for rates
+	if config.EnableHistory && config.HistorySize > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.collectCounterHistory(ctx)
+		}()
 	}
 
+	go func() {
+		wg.Wait()
+		close(c.done)
+	}()
+
 	return c
 }
 
+// seriesKey identifies a metric series by name and label set, rendered
+// like a Prometheus series: name{k1="v1",k2="v2"} with sorted label names
+func seriesKey(name string, labels map[string]string) string {
+	if len(labels) == 0 {
+		return name
+	}
+
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(name)
+	b.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(k)
+		b.WriteString(`="`)
+		b.WriteString(labels[k])
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// copyLabels copies labels so callers can reuse their map
+func copyLabels(labels map[string]string) map[string]string {
+	if labels == nil {
+		return nil
+	}
+	copied := make(map[string]string, len(labels))
+	for k, v := range labels {
+		copied[k] = v
+	}
+	return copied
+}
+
 // Counter methods
 
-// NewCounter creates a new counter metric
+// NewCounter creates a new counter metric, or returns the existing series
+// with the same name and labels
 func (c *Collector) NewCounter(name, description string, labels map[string]string) *Counter {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	if counter, exists := c.counters[name]; exists {
+	key := seriesKey(name, labels)
+	if counter, exists := c.counters[key]; exists {
 		return counter
 	}
 
 	counter := &Counter{
 		name:        name,
 		description: description,
-		labels:      labels,
+		labels:      copyLabels(labels),
+	}
+	if c.config.EnableHistory {
+		counter.historySize = c.config.HistorySize
 	}
-	c.counters[name] = counter
+	c.counters[key] = counter
 	return counter
 }
 
@@ -166,6 +263,60 @@ func (counter *Counter) Reset() {
 	counter.value.Store(0)
 }
 
+// Rate returns the per-second increase over the trailing window. It needs
+// collector history (EnableHistory) and covers at most HistorySize samples
+// taken every CounterHistoryInterval; without history it returns 0.
+func (counter *Counter) Rate(window time.Duration) float64 {
+	return counter.rateAt(time.Now(), window)
+}
+
+func (counter *Counter) rateAt(now time.Time, window time.Duration) float64 {
+	counter.mu.RLock()
+	defer counter.mu.RUnlock()
+
+	if len(counter.history) == 0 || window <= 0 {
+		return 0
+	}
+
+	// Use the oldest sample inside the window, or the newest one if the
+	// window is shorter than the sampling interval
+	cutoff := now.Add(-window)
+	base := counter.history[len(counter.history)-1]
+	for _, sample := range counter.history {
+		if !sample.at.Before(cutoff) {
+			base = sample
+			break
+		}
+	}
+
+	elapsed := now.Sub(base.at).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+
+	current := counter.Get()
+	if current < base.value {
+		// The counter was reset; count from zero
+		return float64(current) / elapsed
+	}
+	return float64(current-base.value) / elapsed
+}
+
+// record appends a history sample, dropping the oldest beyond historySize
+func (counter *Counter) record(now time.Time) {
+	counter.mu.Lock()
+	defer counter.mu.Unlock()
+
+	if counter.historySize <= 0 {
+		return
+	}
+	if len(counter.history) >= counter.historySize {
+		copy(counter.history, counter.history[1:])
+		counter.history = counter.history[:len(counter.history)-1]
+	}
+	counter.history = append(counter.history, counterSample{at: now, value: counter.Get()})
+}
+
 // Gauge methods
 
 // NewGauge creates a new gauge metric
@@ -173,16 +324,17 @@ func (c *Collector) NewGauge(name, description string, labels map[string]string)
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	if gauge, exists := c.gauges[name]; exists {
+	key := seriesKey(name, labels)
+	if gauge, exists := c.gauges[key]; exists {
 		return gauge
 	}
 
 	gauge := &Gauge{
 		name:        name,
 		description: description,
-		labels:      labels,
+		labels:      copyLabels(labels),
 	}
-	c.gauges[name] = gauge
+	c.gauges[key] = gauge
 	return gauge
 }
 
@@ -223,7 +375,8 @@ func (c *Collector) NewHistogram(name, description string, labels map[string]str
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	if histogram, exists := c.histograms[name]; exists {
+	key := seriesKey(name, labels)
+	if histogram, exists := c.histograms[key]; exists {
 		return histogram
 	}
 
@@ -236,9 +389,9 @@ func (c *Collector) NewHistogram(name, description string, labels map[string]str
 		description: description,
 		buckets:     buckets,
 		counts:      make([]atomic.Uint64, len(buckets)),
-		labels:      labels,
+		labels:      copyLabels(labels),
 	}
-	c.histograms[name] = histogram
+	c.histograms[key] = histogram
 	return histogram
 }
 
@@ -285,17 +438,23 @@ func (c *Collector) NewSummary(name, description string, labels map[string]strin
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	if summary, exists := c.summaries[name]; exists {
+	key := seriesKey(name, labels)
+	if summary, exists := c.summaries[key]; exists {
 		return summary
 	}
 
+	objectives := c.config.SummaryObjectives
+	if len(objectives) == 0 {
+		objectives = DefaultSummaryObjectives()
+	}
+
 	summary := &Summary{
 		name:        name,
 		description: description,
-		values:      make([]float64, 0, c.config.HistorySize),
-		labels:      labels,
+		stream:      newQuantileStream(objectives),
+		labels:      copyLabels(labels),
 	}
-	c.summaries[name] = summary
+	c.summaries[key] = summary
 	return summary
 }
 
@@ -306,12 +465,32 @@ func (summary *Summary) Observe(value float64) {
 
 	summary.sum.Add(uint64(value * 1000))
 	summary.count.Add(1)
+	summary.stream.Insert(value)
+}
+
+// Quantile returns the estimated value at quantile q over all observations,
+// or NaN if nothing has been observed. Accuracy is only guaranteed for the
+// configured objectives.
+func (summary *Summary) Quantile(q float64) float64 {
+	summary.mu.Lock()
+	defer summary.mu.Unlock()
+	return summary.stream.Query(q)
+}
+
+// GetQuantiles returns estimates for every configured objective, keyed by
+// quantile (e.g. "0.99"). It is empty until something has been observed.
+func (summary *Summary) GetQuantiles() map[string]float64 {
+	summary.mu.Lock()
+	defer summary.mu.Unlock()
 
-	// Keep limited history
-	if len(summary.values) >= 100 {
-		summary.values = summary.values[1:]
+	quantiles := make(map[string]float64)
+	if summary.count.Load() == 0 {
+		return quantiles
 	}
-	summary.values = append(summary.values, value)
+	for _, q := range summary.stream.Targets() {
+		quantiles[formatQuantile(q)] = summary.stream.Query(q)
+	}
+	return quantiles
 }
 
 // GetSum returns the sum of all observations
@@ -344,6 +523,7 @@ func (c *Collector) collectSystemMetrics(ctx context.Context) {
 	memoryGauge := c.NewGauge("system_memory_bytes", "Memory usage in bytes", nil)
 	goroutinesGauge := c.NewGauge("system_goroutines", "Number of goroutines", nil)
 	gcPauseGauge := c.NewGauge("system_gc_pause_ns", "GC pause time in nanoseconds", nil)
+	cpuSampler := DefaultCPUSampler()
 
 	for {
 		select {
@@ -358,12 +538,40 @@ func (c *Collector) collectSystemMetrics(ctx context.Context) {
 			goroutinesGauge.Set(int64(runtime.NumGoroutine()))
 			gcPauseGauge.Set(int64(m.PauseNs[(m.NumGC+255)%256]))
 
-			// CPU is harder to measure accurately, set to 0 for now
-			cpuGauge.Set(0)
+			cpuGauge.Set(int64(cpuSampler.Sample().ProcessPercent))
 		}
 	}
 }
 
+// collectCounterHistory samples every counter for Rate
+func (c *Collector) collectCounterHistory(ctx context.Context) {
+	ticker := time.NewTicker(CounterHistoryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			c.mu.RLock()
+			for _, counter := range c.counters {
+				counter.record(now)
+			}
+			c.mu.RUnlock()
+		}
+	}
+}
+
+// counterMetadata returns the rate metadata of a counter, if history is on
+func (c *Collector) counterMetadata(counter *Counter) map[string]interface{} {
+	if !c.config.EnableHistory {
+		return nil
+	}
+	return map[string]interface{}{
+		"rate": counter.Rate(DefaultRateWindow),
+	}
+}
+
 // GetAllMetrics returns all collected metrics
 func (c *Collector) GetAllMetrics() []Metric {
 	c.mu.RLock()
@@ -381,6 +589,7 @@ func (c *Collector) GetAllMetrics() []Metric {
 			Labels:      counter.labels,
 			Timestamp:   now,
 			Description: counter.description,
+			Metadata:    c.counterMetadata(counter),
 		})
 	}
 
@@ -422,8 +631,9 @@ func (c *Collector) GetAllMetrics() []Metric {
 			Timestamp:   now,
 			Description: summary.description,
 			Metadata: map[string]interface{}{
-				"count":   summary.GetCount(),
-				"average": summary.GetAverage(),
+				"count":     summary.GetCount(),
+				"average":   summary.GetAverage(),
+				"quantiles": summary.GetQuantiles(),
 			},
 		})
 	}
@@ -431,7 +641,23 @@ func (c *Collector) GetAllMetrics() []Metric {
 	return metrics
 }
 
-// GetMetric returns a specific metric by name
+// GetSeries returns every series of a metric name, one per label set
+func (c *Collector) GetSeries(name string) []Metric {
+	series := make([]Metric, 0)
+	for _, metric := range c.GetAllMetrics() {
+		if metric.Name == name {
+			series = append(series, metric)
+		}
+	}
+	sort.Slice(series, func(i, j int) bool {
+		return seriesKey(name, series[i].Labels) < seriesKey(name, series[j].Labels)
+	})
+	return series
+}
+
+// GetMetric returns a specific metric series. Labeled series are looked up
+// by their series key, e.g. `http_requests_total{method="GET"}`; use
+// GetSeries to list every label set of a name.
 func (c *Collector) GetMetric(name string) *Metric {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -447,6 +673,7 @@ func (c *Collector) GetMetric(name string) *Metric {
 			Labels:      counter.labels,
 			Timestamp:   now,
 			Description: counter.description,
+			Metadata:    c.counterMetadata(counter),
 		}
 	}
 
@@ -488,8 +715,9 @@ func (c *Collector) GetMetric(name string) *Metric {
 			Timestamp:   now,
 			Description: summary.description,
 			Metadata: map[string]interface{}{
-				"count":   summary.GetCount(),
-				"average": summary.GetAverage(),
+				"count":     summary.GetCount(),
+				"average":   summary.GetAverage(),
+				"quantiles": summary.GetQuantiles(),
 			},
 		}
 	}
@@ -512,8 +740,11 @@ func (c *Collector) Reset() {
 	}
 }
 
-// Close stops the collector
+// Close stops background collection and waits for the collection
+// goroutines to exit. It is safe to call more than once.
 func (c *Collector) Close() error {
-	// Stop system metrics collection
+	c.cancel()
+	<-c.done
+	c.workers.Wait()
 	return nil
 }
@@ -0,0 +1,118 @@
+package metrics
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+// waitForGoroutines waits until no more than want goroutines are running
+func waitForGoroutines(t *testing.T, want int) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > want {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d goroutines still running, want at most %d", runtime.NumGoroutine(), want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCloseStopsBackgroundGoroutines(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+
+	config := DefaultCollectorConfig()
+	config.SystemMetricsInterval = 10 * time.Millisecond
+	dashConfig := DefaultDashboardConfig()
+	dashConfig.BroadcastInterval = 10 * time.Millisecond
+
+	collectors := make([]*Collector, 50)
+	dashboards := make([]*Dashboard, len(collectors))
+	for i := range collectors {
+		collectors[i] = NewCollector(config)
+		dashboards[i] = NewDashboard(collectors[i], nil, dashConfig)
+	}
+	if runtime.NumGoroutine() < baseline+len(collectors)*3 {
+		t.Fatalf("%d goroutines running, want collection and broadcasting started", runtime.NumGoroutine())
+	}
+
+	// Let a few collection and broadcast ticks run
+	time.Sleep(30 * time.Millisecond)
+
+	for i := range collectors {
+		if err := dashboards[i].Close(); err != nil {
+			t.Fatalf("Dashboard.Close: %v", err)
+		}
+		if err := collectors[i].Close(); err != nil {
+			t.Fatalf("Collector.Close: %v", err)
+		}
+	}
+	waitForGoroutines(t, baseline)
+}
+
+func TestCounterRate(t *testing.T) {
+	counter := &Counter{historySize: 30}
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	// 10 requests a second for 60 seconds
+	for i := 0; i <= 60; i++ {
+		counter.record(start.Add(time.Duration(i) * time.Second))
+		counter.Add(10)
+	}
+	now := start.Add(61 * time.Second)
+
+	if len(counter.history) != 30 {
+		t.Fatalf("%d samples kept, want the history size 30", len(counter.history))
+	}
+	tests := []struct {
+		name   string
+		window time.Duration
+	}{
+		{"inside history", 10 * time.Second},
+		{"whole history", 30 * time.Second},
+		// Only 30 seconds are kept; the rate covers those
+		{"longer than history", time.Minute},
+		{"shorter than interval", 500 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		if rate := counter.rateAt(now, tt.window); rate != 10 {
+			t.Errorf("%s: rate = %v, want 10", tt.name, rate)
+		}
+	}
+
+	// After a reset the rate counts from zero
+	counter.Reset()
+	counter.Add(20)
+	if rate := counter.rateAt(now, 10*time.Second); rate != 2 {
+		t.Fatalf("rate after reset = %v, want 2", rate)
+	}
+
+	if rate := (&Counter{}).rateAt(now, time.Minute); rate != 0 {
+		t.Fatalf("rate without history = %v, want 0", rate)
+	}
+}
+
+func TestCounterRateMetadata(t *testing.T) {
+	config := DefaultCollectorConfig()
+	config.CollectSystemMetrics = false
+	collector := NewCollector(config)
+	defer collector.Close()
+
+	counter := collector.NewCounter("http_requests_total", "Requests", nil)
+	counter.record(time.Now().Add(-10 * time.Second))
+	counter.Add(50)
+
+	metric := collector.GetMetric("http_requests_total")
+	if rate, _ := metric.Metadata["rate"].(float64); rate < 4.9 || rate > 5 {
+		t.Fatalf("rate = %v, want about 5 a second", metric.Metadata["rate"])
+	}
+
+	config.EnableHistory = false
+	plain := NewCollector(config)
+	defer plain.Close()
+	plain.NewCounter("http_requests_total", "Requests", nil).Add(50)
+	if metric := plain.GetMetric("http_requests_total"); metric.Metadata != nil {
+		t.Fatalf("metadata = %v without history", metric.Metadata)
+	}
+}
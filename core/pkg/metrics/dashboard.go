@@ -3,7 +3,10 @@ package metrics
 import (
 	"context"
 	"encoding/json"
+	"log"
+	"neonexcore/pkg/webhook"
 	"neonexcore/pkg/websocket"
+	"strings"
 	"sync"
 	"time"
 
@@ -14,11 +17,20 @@ import (
 type Dashboard struct {
 	collector *Collector
 	hub       *websocket.Hub
+	webhooks  *webhook.Dispatcher
 	interval  time.Duration
 	mu        sync.RWMutex
 
 	// Alert configuration
 	alerts []Alert
+
+	// WebSocket backpressure counters mirrored from the hub
+	droppedMessages *Counter
+	slowDisconnects *Counter
+
+	// Broadcast loop lifecycle
+	cancel context.CancelFunc
+	done   chan struct{}
 }
 
 // Alert represents a metric alert
@@ -43,6 +55,12 @@ const (
 	ConditionNotEquals   AlertCondition = "ne"
 )
 
+// AlertTopic is the WebSocket topic fired alerts are published on
+const AlertTopic = "alerts"
+
+// AlertEvent is the webhook event type of fired alerts
+const AlertEvent = "alert.fired"
+
 // DashboardConfig holds dashboard configuration
 type DashboardConfig struct {
 	BroadcastInterval time.Duration
@@ -68,10 +86,27 @@ func NewDashboard(collector *Collector, hub *websocket.Hub, config DashboardConf
 		hub:       hub,
 		interval:  config.BroadcastInterval,
 		alerts:    make([]Alert, 0),
+		done:      make(chan struct{}),
+		droppedMessages: collector.NewCounter(
+			"websocket_dropped_messages_total",
+			"WebSocket messages dropped because a client's send buffer was full",
+			nil,
+		),
+		slowDisconnects: collector.NewCounter(
+			"websocket_slow_disconnects_total",
+			"WebSocket clients disconnected for falling behind",
+			nil,
+		),
 	}
 
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+
 	// Start broadcasting metrics
-	go d.broadcastMetrics(context.Background())
+	go func() {
+		defer close(d.done)
+		d.broadcastMetrics(ctx)
+	}()
 
 	return d
 }
@@ -87,19 +122,11 @@ func (d *Dashboard) broadcastMetrics(ctx context.Context) {
 			return
 		case <-ticker.C:
 			metrics := d.collector.GetAllMetrics()
-			data, err := json.Marshal(map[string]interface{}{
-				"type":      "metrics",
-				"timestamp": time.Now().Unix(),
-				"uptime":    d.collector.GetUptime().Seconds(),
-				"metrics":   metrics,
-			})
-			if err != nil {
-				continue
-			}
 
-			// Broadcast to all connected clients
+			// Send each client the metrics it subscribed to
 			if d.hub != nil {
-				d.hub.BroadcastJSON(data)
+				d.recordHubStats()
+				d.sendMetrics(metrics)
 			}
 
 			// Check alerts
@@ -108,6 +135,60 @@ func (d *Dashboard) broadcastMetrics(ctx context.Context) {
 	}
 }
 
+// sendMetrics sends every connected client the metrics matching its topic
+// subscriptions. Metric names are topics, so a client subscribed to
+// "system_*" only receives system metrics. Clients with the same
+// subscriptions share one encoded message.
+func (d *Dashboard) sendMetrics(metrics []Metric) {
+	timestamp := time.Now().Unix()
+	uptime := d.collector.GetUptime().Seconds()
+	encoded := make(map[string][]byte)
+
+	for _, conn := range d.hub.Connections() {
+		key := strings.Join(conn.Topics(), ",")
+		data, ok := encoded[key]
+		if !ok {
+			subscribed := make([]Metric, 0, len(metrics))
+			for _, metric := range metrics {
+				if conn.IsSubscribed(metric.Name) {
+					subscribed = append(subscribed, metric)
+				}
+			}
+
+			// Alert-only subscribers get no metrics messages
+			if len(subscribed) > 0 {
+				var err error
+				data, err = json.Marshal(map[string]interface{}{
+					"type":      "metrics",
+					"timestamp": timestamp,
+					"uptime":    uptime,
+					"metrics":   subscribed,
+				})
+				if err != nil {
+					return
+				}
+			}
+			encoded[key] = data
+		}
+
+		if data != nil {
+			// Never blocks; slow clients are dropped per the hub's overflow policy
+			d.hub.Send(conn, data)
+		}
+	}
+}
+
+// recordHubStats brings the backpressure counters up to date with the hub
+func (d *Dashboard) recordHubStats() {
+	stats := d.hub.Stats()
+	if dropped := d.droppedMessages.Get(); stats.DroppedMessages > dropped {
+		d.droppedMessages.Add(stats.DroppedMessages - dropped)
+	}
+	if disconnects := d.slowDisconnects.Get(); stats.SlowDisconnects > disconnects {
+		d.slowDisconnects.Add(stats.SlowDisconnects - disconnects)
+	}
+}
+
 // checkAlerts checks if any alerts should be fired
 func (d *Dashboard) checkAlerts(metrics []Metric) {
 	d.mu.RLock()
@@ -166,10 +247,27 @@ func (d *Dashboard) fireAlert(alert *Alert, metric Metric) {
 	}
 
 	if d.hub != nil {
-		d.hub.BroadcastJSON(data)
+		d.hub.BroadcastTopic(AlertTopic, data)
+	}
+
+	if d.webhooks != nil {
+		payload := map[string]interface{}{
+			"alert":  *alert,
+			"metric": metric,
+		}
+		if _, err := d.webhooks.Publish(context.Background(), AlertEvent, payload); err != nil {
+			log.Printf("Failed to publish alert %s to webhooks: %v", alert.Name, err)
+		}
 	}
 }
 
+// SetWebhooks delivers fired alerts to webhooks subscribed to AlertEvent
+func (d *Dashboard) SetWebhooks(webhooks *webhook.Dispatcher) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.webhooks = webhooks
+}
+
 // AddAlert adds a new alert
 func (d *Dashboard) AddAlert(alert Alert) {
 	d.mu.Lock()
@@ -207,6 +305,9 @@ func (d *Dashboard) SetupRoutes(app *fiber.App) {
 	// Get all metrics
 	app.Get("/metrics", d.handleGetMetrics)
 
+	// Prometheus text exposition
+	app.Get("/metrics/prometheus", PrometheusHandler(d.collector))
+
 	// Get specific metric
 	app.Get("/metrics/:name", d.handleGetMetric)
 
@@ -236,6 +337,12 @@ func (d *Dashboard) handleGetMetric(c *fiber.Ctx) error {
 	metric := d.collector.GetMetric(name)
 
 	if metric == nil {
+		if series := d.collector.GetSeries(name); len(series) > 0 {
+			return c.JSON(fiber.Map{
+				"success": true,
+				"series":  series,
+			})
+		}
 		return c.Status(404).JSON(fiber.Map{
 			"success": false,
 			"error":   "Metric not found",
@@ -293,8 +400,11 @@ func (d *Dashboard) handleDeleteAlert(c *fiber.Ctx) error {
 	})
 }
 
-// Close stops the dashboard
+// Close stops broadcasting and waits for the broadcast goroutine to exit.
+// It is safe to call more than once.
 func (d *Dashboard) Close() error {
+	d.cancel()
+	<-d.done
 	return nil
 }
 
@@ -630,7 +740,8 @@ const dashboardHTML = `<!DOCTYPE html>
 
         function connect() {
             const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
-            const wsUrl = protocol + '//' + window.location.host + '/ws';
+            // Forward ?token= from the page URL for authenticated hubs
+            const wsUrl = protocol + '//' + window.location.host + '/ws' + window.location.search;
             
             ws = new WebSocket(wsUrl);
 
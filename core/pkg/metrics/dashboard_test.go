@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	wsclient "github.com/fasthttp/websocket"
+	"github.com/gofiber/fiber/v2"
+	"neonexcore/pkg/websocket"
+)
+
+// dashboardMessage is a message the dashboard sends to clients
+type dashboardMessage struct {
+	Type    string   `json:"type"`
+	Metrics []Metric `json:"metrics"`
+	Alert   *Alert   `json:"alert"`
+}
+
+// newDashboardTestServer serves hub on a local port and returns its
+// WebSocket URL
+func newDashboardTestServer(t *testing.T, hub *websocket.Hub) string {
+	t.Helper()
+
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	websocket.SetupRoutes(app, hub, nil)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go app.Listener(ln)
+	t.Cleanup(func() {
+		hub.Close()
+		app.Shutdown()
+	})
+	return "ws://" + ln.Addr().String() + "/ws"
+}
+
+// dialSubscribed connects a client subscribed to topics, skipping the
+// welcome and confirmation messages
+func dialSubscribed(t *testing.T, url string, topics ...string) *wsclient.Conn {
+	t.Helper()
+
+	conn, _, err := wsclient.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	msg := websocket.NewMessage(websocket.TypeSubscribe, nil)
+	msg.Topics = topics
+	if err := conn.WriteJSON(msg); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		var reply websocket.Message
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		if err := conn.ReadJSON(&reply); err != nil {
+			t.Fatalf("read: %v", err)
+		}
+	}
+	return conn
+}
+
+func readDashboardMessage(t *testing.T, conn *wsclient.Conn) dashboardMessage {
+	t.Helper()
+
+	var msg dashboardMessage
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	return msg
+}
+
+func TestDashboardSendsSubscribedTopics(t *testing.T) {
+	collector := newMiddlewareTestCollector(t)
+	collector.NewGauge("system_goroutines", "Goroutines", nil).Set(12)
+	collector.NewGauge("system_memory_bytes", "Memory", nil).Set(1024)
+	collector.NewCounter("http_requests_total", "Requests", nil).Add(5)
+
+	hub := websocket.NewHub(websocket.DefaultHubConfig())
+	url := newDashboardTestServer(t, hub)
+	system := dialSubscribed(t, url, "system_*")
+	alerts := dialSubscribed(t, url, AlertTopic)
+
+	config := DefaultDashboardConfig()
+	config.BroadcastInterval = 20 * time.Millisecond
+	dashboard := NewDashboard(collector, hub, config)
+	defer dashboard.Close()
+	dashboard.AddAlert(Alert{Name: "busy", Metric: "http_requests_total", Condition: ConditionGreaterThan, Threshold: 1, Enabled: true})
+
+	msg := readDashboardMessage(t, system)
+	if msg.Type != "metrics" || len(msg.Metrics) != 2 {
+		t.Fatalf("message = %+v, want the two system metrics", msg)
+	}
+	for _, metric := range msg.Metrics {
+		if !strings.HasPrefix(metric.Name, "system_") {
+			t.Fatalf("received unsubscribed metric %s", metric.Name)
+		}
+	}
+
+	// Alert subscribers get alerts and no metrics
+	msg = readDashboardMessage(t, alerts)
+	if msg.Type != "alert" || msg.Alert == nil || msg.Alert.Name != "busy" {
+		t.Fatalf("message = %+v, want the busy alert", msg)
+	}
+	for i := 0; i < 3; i++ {
+		if msg := readDashboardMessage(t, system); msg.Type != "metrics" {
+			t.Fatalf("system client received %s, want only metrics", msg.Type)
+		}
+	}
+	alerts.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	var extra dashboardMessage
+	if err := alerts.ReadJSON(&extra); err == nil {
+		t.Fatalf("alert client received %+v", extra)
+	}
+}
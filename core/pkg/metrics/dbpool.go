@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"database/sql"
+	"time"
+)
+
+// DefaultDBStatsInterval is how often CollectDBStats samples a pool
+const DefaultDBStatsInterval = 5 * time.Second
+
+// dbPoolMetrics are the metrics of one connection pool
+type dbPoolMetrics struct {
+	maxOpen      *Gauge
+	open         *Gauge
+	inUse        *Gauge
+	idle         *Gauge
+	waitCount    *Counter
+	waitDuration *Counter
+
+	lastWaitCount    int64
+	lastWaitDuration time.Duration
+}
+
+// CollectDBStats samples a database connection pool every interval until the
+// collector is closed. The metrics are labelled with pool="<name>":
+//
+//	db_pool_max_open_connections, db_pool_open_connections,
+//	db_pool_in_use_connections, db_pool_idle_connections (gauges)
+//	db_pool_wait_count_total, db_pool_wait_duration_ms_total (counters)
+//
+// A growing wait count means requests are queueing for a connection.
+func (c *Collector) CollectDBStats(name string, db *sql.DB, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultDBStatsInterval
+	}
+
+	labels := map[string]string{"pool": name}
+	m := &dbPoolMetrics{
+		maxOpen:      c.NewGauge("db_pool_max_open_connections", "Maximum open database connections; 0 is unlimited", labels),
+		open:         c.NewGauge("db_pool_open_connections", "Open database connections", labels),
+		inUse:        c.NewGauge("db_pool_in_use_connections", "Database connections in use", labels),
+		idle:         c.NewGauge("db_pool_idle_connections", "Idle database connections", labels),
+		waitCount:    c.NewCounter("db_pool_wait_count_total", "Times a query waited for a free database connection", labels),
+		waitDuration: c.NewCounter("db_pool_wait_duration_ms_total", "Time spent waiting for free database connections in milliseconds", labels),
+	}
+	m.sample(db.Stats())
+
+	c.workers.Add(1)
+	go func() {
+		defer c.workers.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-c.ctx.Done():
+				return
+			case <-ticker.C:
+				m.sample(db.Stats())
+			}
+		}
+	}()
+}
+
+// sample records a snapshot of the pool stats. The wait counters are
+// cumulative in sql.DBStats, so only their growth is added.
+func (m *dbPoolMetrics) sample(stats sql.DBStats) {
+	m.maxOpen.Set(int64(stats.MaxOpenConnections))
+	m.open.Set(int64(stats.OpenConnections))
+	m.inUse.Set(int64(stats.InUse))
+	m.idle.Set(int64(stats.Idle))
+
+	if delta := stats.WaitCount - m.lastWaitCount; delta > 0 {
+		m.waitCount.Add(uint64(delta))
+	}
+	if delta := stats.WaitDuration - m.lastWaitDuration; delta > 0 {
+		m.waitDuration.Add(uint64(delta.Milliseconds()))
+	}
+	m.lastWaitCount = stats.WaitCount
+	m.lastWaitDuration = stats.WaitDuration
+}
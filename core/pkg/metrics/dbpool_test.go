@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTestPool(t *testing.T, maxOpen int) *sql.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "pool.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("DB: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(maxOpen)
+	t.Cleanup(func() { sqlDB.Close() })
+	return sqlDB
+}
+
+// poolMetric returns the value of a pool's metric series
+func poolMetric(c *Collector, name, pool string) (float64, bool) {
+	for _, metric := range c.GetSeries(name) {
+		if metric.Labels["pool"] == pool {
+			return metric.Value, true
+		}
+	}
+	return 0, false
+}
+
+// waitForPoolMetric waits until a pool's metric satisfies cond
+func waitForPoolMetric(t *testing.T, c *Collector, name, pool string, cond func(float64) bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		value, ok := poolMetric(c, name, pool)
+		if ok && cond(value) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s{pool=%q} = %v (found %v)", name, pool, value, ok)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCollectDBStats(t *testing.T) {
+	collector := NewCollector(DefaultCollectorConfig())
+	defer collector.Close()
+	ctx := context.Background()
+
+	primary := newTestPool(t, 1)
+	collector.CollectDBStats("primary", primary, 10*time.Millisecond)
+	collector.CollectDBStats("replica_1", newTestPool(t, 4), 10*time.Millisecond)
+
+	// The limit is published right away, per pool
+	if value, _ := poolMetric(collector, "db_pool_max_open_connections", "primary"); value != 1 {
+		t.Fatalf("primary max open = %v, want 1", value)
+	}
+	if value, _ := poolMetric(collector, "db_pool_max_open_connections", "replica_1"); value != 4 {
+		t.Fatalf("replica max open = %v, want 4", value)
+	}
+
+	conn, err := primary.Conn(ctx)
+	if err != nil {
+		t.Fatalf("Conn: %v", err)
+	}
+	waitForPoolMetric(t, collector, "db_pool_in_use_connections", "primary", func(v float64) bool { return v == 1 })
+
+	// A query on the exhausted pool waits for the held connection
+	done := make(chan error, 1)
+	go func() {
+		done <- primary.PingContext(ctx)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	conn.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+
+	waitForPoolMetric(t, collector, "db_pool_wait_count_total", "primary", func(v float64) bool { return v == 1 })
+	waitForPoolMetric(t, collector, "db_pool_wait_duration_ms_total", "primary", func(v float64) bool { return v >= 40 })
+	waitForPoolMetric(t, collector, "db_pool_in_use_connections", "primary", func(v float64) bool { return v == 0 })
+	waitForPoolMetric(t, collector, "db_pool_idle_connections", "primary", func(v float64) bool { return v == 1 })
+
+	// Later samples only add the growth of the cumulative wait counters
+	time.Sleep(50 * time.Millisecond)
+	if value, _ := poolMetric(collector, "db_pool_wait_count_total", "primary"); value != 1 {
+		t.Fatalf("wait count = %v after more samples, want 1", value)
+	}
+	if value, _ := poolMetric(collector, "db_pool_wait_count_total", "replica_1"); value != 0 {
+		t.Fatalf("replica wait count = %v, want 0", value)
+	}
+}
@@ -1,27 +1,22 @@
 package metrics
 
 import (
+	stderrors "errors"
+	"strconv"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"neonexcore/pkg/errors"
 )
 
-// Middleware creates a Fiber middleware for collecting HTTP metrics
+// Middleware creates a Fiber middleware for collecting HTTP metrics.
+// Requests are counted in http_requests_total labeled by method, route and
+// status, and timed in http_request_duration_seconds labeled by method and
+// route. The route label is the matched route pattern (e.g. /users/:id),
+// which keeps label cardinality bounded.
 func Middleware(collector *Collector) fiber.Handler {
 	// Create metrics
-	requestCounter := collector.NewCounter(
-		"http_requests_total",
-		"Total number of HTTP requests",
-		nil,
-	)
-
-	requestDuration := collector.NewHistogram(
-		"http_request_duration_seconds",
-		"HTTP request duration in seconds",
-		nil,
-		[]float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 2, 5},
-	)
-
 	activeRequests := collector.NewGauge(
 		"http_requests_active",
 		"Number of active HTTP requests",
@@ -42,14 +37,7 @@ func Middleware(collector *Collector) fiber.Handler {
 		[]float64{100, 1000, 10000, 100000, 1000000},
 	)
 
-	statusCounter := make(map[int]*Counter)
-	for _, status := range []int{200, 201, 204, 400, 401, 403, 404, 500, 502, 503} {
-		statusCounter[status] = collector.NewCounter(
-			"http_responses_"+string(rune(status)),
-			"Number of HTTP responses with status "+string(rune(status)),
-			map[string]string{"status": string(rune(status))},
-		)
-	}
+	durationBuckets := []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 2, 5}
 
 	return func(c *fiber.Ctx) error {
 		start := time.Now()
@@ -69,23 +57,53 @@ func Middleware(collector *Collector) fiber.Handler {
 		// Calculate duration
 		duration := time.Since(start).Seconds()
 
+		// Copy values out of the context, which Fiber reuses after the handler returns
+		method := utils.CopyString(c.Method())
+		route := utils.CopyString(c.Route().Path)
+		status := strconv.Itoa(ResponseStatus(c, err))
+
 		// Update metrics
-		requestCounter.Inc()
-		requestDuration.Observe(duration)
+		collector.NewCounter(
+			"http_requests_total",
+			"Total number of HTTP requests",
+			map[string]string{"method": method, "route": route, "status": status},
+		).Inc()
+
+		collector.NewHistogram(
+			"http_request_duration_seconds",
+			"HTTP request duration in seconds",
+			map[string]string{"method": method, "route": route},
+			durationBuckets,
+		).Observe(duration)
 
 		// Track response size
 		responseSize.Observe(float64(len(c.Response().Body())))
 
-		// Track status code
-		status := c.Response().StatusCode()
-		if counter, exists := statusCounter[status]; exists {
-			counter.Inc()
-		}
-
 		return err
 	}
 }
 
+// ResponseStatus returns the status code a request will be answered with.
+// Errors returned by handlers are rendered by the app's error handler after
+// middleware runs, so their status is taken from the error itself.
+func ResponseStatus(c *fiber.Ctx, err error) int {
+	if err == nil {
+		return c.Response().StatusCode()
+	}
+
+	var fiberErr *fiber.Error
+	if stderrors.As(err, &fiberErr) {
+		return fiberErr.Code
+	}
+
+	var appErr *errors.AppError
+	if stderrors.As(err, &appErr) {
+		return appErr.StatusCode
+	}
+
+	return fiber.StatusInternalServerError
+}
+
 // MethodMiddleware creates middleware that tracks metrics by HTTP method
 func MethodMiddleware(collector *Collector) fiber.Handler {
 	counters := make(map[string]*Counter)
@@ -111,8 +129,8 @@ func PathMiddleware(collector *Collector, paths []string) fiber.Handler {
 	counters := make(map[string]*Counter)
 	for _, path := range paths {
 		counters[path] = collector.NewCounter(
-			"http_requests_path_"+path,
-			"Number of requests to "+path,
+			"http_requests_path",
+			"Number of requests per tracked path",
 			map[string]string{"path": path},
 		)
 	}
@@ -149,7 +167,7 @@ func ErrorMiddleware(collector *Collector) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		err := c.Next()
 
-		status := c.Response().StatusCode()
+		status := ResponseStatus(c, err)
 
 		if status >= 400 {
 			errorCounter.Inc()
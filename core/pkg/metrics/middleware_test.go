@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func newMiddlewareTestCollector(t *testing.T) *Collector {
+	t.Helper()
+
+	config := DefaultCollectorConfig()
+	config.CollectSystemMetrics = false
+	collector := NewCollector(config)
+	t.Cleanup(func() { collector.Close() })
+	return collector
+}
+
+func TestMiddlewareRecordsRequests(t *testing.T) {
+	collector := newMiddlewareTestCollector(t)
+
+	app := fiber.New()
+	app.Use(Middleware(collector))
+	app.Get("/users/:id", func(c *fiber.Ctx) error {
+		if c.Params("id") == "0" {
+			return fiber.ErrNotFound
+		}
+		return c.SendString("user")
+	})
+	app.Post("/users", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusCreated)
+	})
+
+	requests := []struct{ method, path string }{
+		{fiber.MethodGet, "/users/1"},
+		{fiber.MethodGet, "/users/2"},
+		{fiber.MethodGet, "/users/0"},
+		{fiber.MethodPost, "/users"},
+	}
+	for _, r := range requests {
+		if _, err := app.Test(httptest.NewRequest(r.method, r.path, nil)); err != nil {
+			t.Fatalf("%s %s: %v", r.method, r.path, err)
+		}
+	}
+
+	counts := map[string]float64{
+		`http_requests_total{method="GET",route="/users/:id",status="200"}`: 2,
+		`http_requests_total{method="GET",route="/users/:id",status="404"}`: 1,
+		`http_requests_total{method="POST",route="/users",status="201"}`:    1,
+	}
+	for key, want := range counts {
+		metric := collector.GetMetric(key)
+		if metric == nil || metric.Value != want {
+			t.Fatalf("%s = %+v, want %v", key, metric, want)
+		}
+	}
+	// Raw paths never become labels
+	if series := collector.GetSeries("http_requests_total"); len(series) != len(counts) {
+		t.Fatalf("%d request counter series, want %d", len(series), len(counts))
+	}
+
+	durations := map[string]uint64{
+		`http_request_duration_seconds{method="GET",route="/users/:id"}`: 3,
+		`http_request_duration_seconds{method="POST",route="/users"}`:    1,
+	}
+	for key, want := range durations {
+		metric := collector.GetMetric(key)
+		if metric == nil || metric.Type != TypeHistogram || metric.Metadata["count"] != want {
+			t.Fatalf("%s = %+v, want %d observations", key, metric, want)
+		}
+	}
+
+	if active := collector.GetMetric("http_requests_active"); active == nil || active.Value != 0 {
+		t.Fatalf("http_requests_active = %+v, want 0 after the requests finished", active)
+	}
+}
@@ -0,0 +1,223 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// PrometheusContentType is the content type of the Prometheus text format
+const PrometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// promSeries is one series of a metric family
+type promSeries struct {
+	labels map[string]string
+	write  func(w *bufio.Writer, name string, labels map[string]string)
+}
+
+// promFamily groups the series that share a metric name
+type promFamily struct {
+	name        string
+	description string
+	kind        MetricType
+	series      []promSeries
+}
+
+// WritePrometheus writes every series in the Prometheus text exposition
+// format. Series sharing a name are grouped under one HELP/TYPE header.
+// Characters Prometheus doesn't allow in metric and label names are
+// replaced with underscores.
+func (c *Collector) WritePrometheus(w io.Writer) error {
+	families := make(map[string]*promFamily)
+	family := func(name, description string, kind MetricType) *promFamily {
+		name = sanitizePromName(name, true)
+		f, ok := families[name]
+		if !ok {
+			f = &promFamily{name: name, description: description, kind: kind}
+			families[name] = f
+		}
+		return f
+	}
+
+	c.mu.RLock()
+	for _, counter := range c.counters {
+		counter := counter
+		f := family(counter.name, counter.description, TypeCounter)
+		f.series = append(f.series, promSeries{labels: counter.labels, write: func(w *bufio.Writer, name string, labels map[string]string) {
+			writePromSample(w, name, labels, float64(counter.Get()))
+		}})
+	}
+	for _, gauge := range c.gauges {
+		gauge := gauge
+		f := family(gauge.name, gauge.description, TypeGauge)
+		f.series = append(f.series, promSeries{labels: gauge.labels, write: func(w *bufio.Writer, name string, labels map[string]string) {
+			writePromSample(w, name, labels, float64(gauge.Get()))
+		}})
+	}
+	for _, histogram := range c.histograms {
+		histogram := histogram
+		f := family(histogram.name, histogram.description, TypeHistogram)
+		f.series = append(f.series, promSeries{labels: histogram.labels, write: func(w *bufio.Writer, name string, labels map[string]string) {
+			// Bucket counts are already cumulative
+			for i, bound := range histogram.buckets {
+				writePromSample(w, name+"_bucket", withLabel(labels, "le", formatPromFloat(bound)), float64(histogram.counts[i].Load()))
+			}
+			count := float64(histogram.GetCount())
+			writePromSample(w, name+"_bucket", withLabel(labels, "le", "+Inf"), count)
+			writePromSample(w, name+"_sum", labels, histogram.GetSum())
+			writePromSample(w, name+"_count", labels, count)
+		}})
+	}
+	for _, summary := range c.summaries {
+		summary := summary
+		f := family(summary.name, summary.description, TypeSummary)
+		f.series = append(f.series, promSeries{labels: summary.labels, write: func(w *bufio.Writer, name string, labels map[string]string) {
+			for _, q := range sortedQuantiles(summary.GetQuantiles()) {
+				writePromSample(w, name, withLabel(labels, "quantile", q.key), q.value)
+			}
+			writePromSample(w, name+"_sum", labels, summary.GetSum())
+			writePromSample(w, name+"_count", labels, float64(summary.GetCount()))
+		}})
+	}
+	c.mu.RUnlock()
+
+	names := make([]string, 0, len(families))
+	for name := range families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	bw := bufio.NewWriter(w)
+	for _, name := range names {
+		f := families[name]
+		sort.Slice(f.series, func(i, j int) bool {
+			return seriesKey(name, f.series[i].labels) < seriesKey(name, f.series[j].labels)
+		})
+
+		if f.description != "" {
+			fmt.Fprintf(bw, "# HELP %s %s\n", name, escapePromHelp(f.description))
+		}
+		fmt.Fprintf(bw, "# TYPE %s %s\n", name, f.kind)
+		for _, series := range f.series {
+			series.write(bw, name, series.labels)
+		}
+	}
+	return bw.Flush()
+}
+
+// PrometheusHandler serves the collector in the Prometheus text format
+func PrometheusHandler(collector *Collector) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, PrometheusContentType)
+		return collector.WritePrometheus(c.Response().BodyWriter())
+	}
+}
+
+// writePromSample writes one "name{labels} value" line
+func writePromSample(w *bufio.Writer, name string, labels map[string]string, value float64) {
+	w.WriteString(name)
+	if len(labels) > 0 {
+		keys := make([]string, 0, len(labels))
+		for k := range labels {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		w.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(sanitizePromName(k, false))
+			w.WriteString(`="`)
+			w.WriteString(escapePromLabel(labels[k]))
+			w.WriteByte('"')
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatPromFloat(value))
+	w.WriteByte('\n')
+}
+
+// withLabel returns a copy of labels with one more label set
+func withLabel(labels map[string]string, key, value string) map[string]string {
+	out := make(map[string]string, len(labels)+1)
+	for k, v := range labels {
+		out[k] = v
+	}
+	out[key] = value
+	return out
+}
+
+type quantileValue struct {
+	key   string
+	value float64
+}
+
+// sortedQuantiles orders quantile estimates by quantile
+func sortedQuantiles(quantiles map[string]float64) []quantileValue {
+	out := make([]quantileValue, 0, len(quantiles))
+	for key, value := range quantiles {
+		out = append(out, quantileValue{key: key, value: value})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, _ := strconv.ParseFloat(out[i].key, 64)
+		b, _ := strconv.ParseFloat(out[j].key, 64)
+		return a < b
+	})
+	return out
+}
+
+func formatPromFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	promHelpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	promLabelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapePromHelp(s string) string {
+	return promHelpEscaper.Replace(s)
+}
+
+func escapePromLabel(s string) string {
+	return promLabelEscaper.Replace(s)
+}
+
+// sanitizePromName makes name a valid Prometheus name by replacing invalid
+// characters with underscores. Metric names match [a-zA-Z_:][a-zA-Z0-9_:]*;
+// label names don't allow colons.
+func sanitizePromName(name string, metric bool) string {
+	if name == "" {
+		return "_"
+	}
+
+	b := []byte(name)
+	for i, ch := range b {
+		valid := ch == '_' ||
+			(ch >= 'a' && ch <= 'z') ||
+			(ch >= 'A' && ch <= 'Z') ||
+			(ch >= '0' && ch <= '9' && i > 0) ||
+			(ch == ':' && metric)
+		if !valid {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
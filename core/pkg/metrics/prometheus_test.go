@@ -0,0 +1,133 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// newTestCollector returns a collector without background collection
+func newTestCollector(t *testing.T) *Collector {
+	t.Helper()
+
+	collector := NewCollector(CollectorConfig{})
+	t.Cleanup(func() { collector.Close() })
+	return collector
+}
+
+// prometheusLines returns the lines of the collector's Prometheus output
+func prometheusLines(t *testing.T, collector *Collector) []string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := collector.WritePrometheus(&buf); err != nil {
+		t.Fatalf("WritePrometheus: %v", err)
+	}
+	return strings.Split(strings.TrimSpace(buf.String()), "\n")
+}
+
+func hasLine(lines []string, want string) bool {
+	for _, line := range lines {
+		if line == want {
+			return true
+		}
+	}
+	return false
+}
+
+func TestLabeledSeriesAreIndependent(t *testing.T) {
+	collector := newTestCollector(t)
+
+	get := collector.NewCounter("http_requests_total", "Total requests", map[string]string{"method": "GET"})
+	post := collector.NewCounter("http_requests_total", "Total requests", map[string]string{"method": "POST"})
+	if again := collector.NewCounter("http_requests_total", "Total requests", map[string]string{"method": "GET"}); again != get {
+		t.Fatal("registering the same series twice returned a new counter")
+	}
+
+	get.Inc()
+	get.Inc()
+	post.Inc()
+
+	if get.Get() != 2 || post.Get() != 1 {
+		t.Fatalf("GET = %d, POST = %d; want 2 and 1", get.Get(), post.Get())
+	}
+
+	lines := prometheusLines(t, collector)
+	for _, want := range []string{
+		"# TYPE http_requests_total counter",
+		`http_requests_total{method="GET"} 2`,
+		`http_requests_total{method="POST"} 1`,
+	} {
+		if !hasLine(lines, want) {
+			t.Errorf("output is missing %q:\n%s", want, strings.Join(lines, "\n"))
+		}
+	}
+}
+
+func TestWritePrometheusSanitizesNames(t *testing.T) {
+	collector := newTestCollector(t)
+
+	collector.NewCounter("cache.hits-total", "", map[string]string{"tier.name": "l1"}).Inc()
+	collector.NewGauge("1st:queue depth", "", nil).Set(3)
+
+	lines := prometheusLines(t, collector)
+	for _, want := range []string{
+		"# TYPE cache_hits_total counter",
+		`cache_hits_total{tier_name="l1"} 1`,
+		"_st:queue_depth 3",
+	} {
+		if !hasLine(lines, want) {
+			t.Errorf("output is missing %q:\n%s", want, strings.Join(lines, "\n"))
+		}
+	}
+}
+
+func TestSanitizePromName(t *testing.T) {
+	tests := []struct {
+		name   string
+		metric bool
+		want   string
+	}{
+		{"http_requests_total", true, "http_requests_total"},
+		{"job:rate5m", true, "job:rate5m"},
+		{"job:rate5m", false, "job_rate5m"},
+		{"/api/users", true, "_api_users"},
+		{"9lives", true, "_lives"},
+		{"", true, "_"},
+	}
+
+	for _, tt := range tests {
+		if got := sanitizePromName(tt.name, tt.metric); got != tt.want {
+			t.Errorf("sanitizePromName(%q, %v) = %q, want %q", tt.name, tt.metric, got, tt.want)
+		}
+	}
+}
+
+func TestPathMiddlewareLabelsPath(t *testing.T) {
+	collector := newTestCollector(t)
+
+	app := fiber.New()
+	app.Use(PathMiddleware(collector, []string{"/api/users", "/api/orders"}))
+	app.Get("/*", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	for _, path := range []string{"/api/users", "/api/users", "/api/orders", "/other"} {
+		if _, err := app.Test(httptest.NewRequest(fiber.MethodGet, path, nil)); err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+	}
+
+	lines := prometheusLines(t, collector)
+	for _, want := range []string{
+		`http_requests_path{path="/api/users"} 2`,
+		`http_requests_path{path="/api/orders"} 1`,
+	} {
+		if !hasLine(lines, want) {
+			t.Errorf("output is missing %q:\n%s", want, strings.Join(lines, "\n"))
+		}
+	}
+}
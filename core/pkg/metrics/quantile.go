@@ -0,0 +1,193 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+	"strconv"
+)
+
+// quantileBufferSize is how many observations are batched before being
+// merged into the quantile stream
+const quantileBufferSize = 500
+
+// DefaultSummaryObjectives returns the default target quantiles and their
+// allowed rank error, e.g. 0.99: 0.001 means p99 is accurate to within
+// ranks 0.989-0.991
+func DefaultSummaryObjectives() map[float64]float64 {
+	return map[float64]float64{
+		0.5:  0.05,
+		0.9:  0.01,
+		0.95: 0.005,
+		0.99: 0.001,
+	}
+}
+
+// quantileTarget is a target quantile and its allowed rank error
+type quantileTarget struct {
+	quantile float64
+	epsilon  float64
+}
+
+// quantileSample is a compressed run of observations
+type quantileSample struct {
+	value float64
+	width float64 // Number of observations this sample stands for
+	delta float64 // Uncertainty of the sample's rank
+}
+
+// quantileStream estimates targeted quantiles over an unbounded stream in
+// bounded memory using the CKMS algorithm (Cormode, Korn, Muthukrishnan,
+// Srivastava, "Effective Computation of Biased Quantiles over Data Streams").
+// It is not safe for concurrent use.
+type quantileStream struct {
+	targets []quantileTarget
+	samples []quantileSample
+	buffer  []float64
+	n       float64
+}
+
+// newQuantileStream creates a stream for the given objectives. Objectives
+// outside (0, 1) are ignored.
+func newQuantileStream(objectives map[float64]float64) *quantileStream {
+	targets := make([]quantileTarget, 0, len(objectives))
+	for q, e := range objectives {
+		if q <= 0 || q >= 1 || e <= 0 || e >= 1 {
+			continue
+		}
+		targets = append(targets, quantileTarget{quantile: q, epsilon: e})
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		return targets[i].quantile < targets[j].quantile
+	})
+
+	return &quantileStream{
+		targets: targets,
+		buffer:  make([]float64, 0, quantileBufferSize),
+	}
+}
+
+// Insert adds an observation
+func (s *quantileStream) Insert(value float64) {
+	s.buffer = append(s.buffer, value)
+	if len(s.buffer) == cap(s.buffer) {
+		s.flush()
+	}
+}
+
+// Query returns the estimated value at quantile q, or NaN if the stream is empty
+func (s *quantileStream) Query(q float64) float64 {
+	s.flush()
+	if len(s.samples) == 0 {
+		return math.NaN()
+	}
+
+	rank := math.Ceil(q * s.n)
+	rank += math.Ceil(s.invariant(rank) / 2)
+
+	prev := s.samples[0]
+	var r float64
+	for _, sample := range s.samples[1:] {
+		r += prev.width
+		if r+sample.width+sample.delta > rank {
+			return prev.value
+		}
+		prev = sample
+	}
+	return prev.value
+}
+
+// Targets returns the target quantiles in ascending order
+func (s *quantileStream) Targets() []float64 {
+	quantiles := make([]float64, len(s.targets))
+	for i, target := range s.targets {
+		quantiles[i] = target.quantile
+	}
+	return quantiles
+}
+
+// invariant returns the allowed width+delta of a sample at rank r. It is
+// half the CKMS bound: with the full bound the uncertainty just below or above
+// a target exceeds 2*epsilon*n, and queries can miss by more than epsilon.
+func (s *quantileStream) invariant(r float64) float64 {
+	min := math.MaxFloat64
+	for _, target := range s.targets {
+		var f float64
+		if target.quantile*s.n <= r {
+			f = target.epsilon * r / target.quantile
+		} else {
+			f = target.epsilon * (s.n - r) / (1 - target.quantile)
+		}
+		if f < min {
+			min = f
+		}
+	}
+	return min
+}
+
+// flush merges buffered observations into the samples and compresses them
+func (s *quantileStream) flush() {
+	if len(s.buffer) == 0 {
+		return
+	}
+	sort.Float64s(s.buffer)
+
+	i := 0
+	for _, value := range s.buffer {
+		inserted := false
+		for ; i < len(s.samples); i++ {
+			if s.samples[i].value > value {
+				// The value ranks somewhere between the previous sample and
+				// the next one's highest possible rank
+				delta := s.samples[i].width + s.samples[i].delta - 1
+				s.samples = append(s.samples, quantileSample{})
+				copy(s.samples[i+1:], s.samples[i:])
+				s.samples[i] = quantileSample{value: value, width: 1, delta: delta}
+				i++
+				inserted = true
+				break
+			}
+		}
+		if !inserted {
+			s.samples = append(s.samples, quantileSample{value: value, width: 1})
+			i++
+		}
+		s.n++
+	}
+	s.buffer = s.buffer[:0]
+
+	s.compress()
+}
+
+// compress merges adjacent samples while the error invariant allows
+func (s *quantileStream) compress() {
+	if len(s.samples) < 2 {
+		return
+	}
+
+	last := len(s.samples) - 1
+	x := s.samples[last]
+	xi := last
+	r := s.n - x.width // Observations ranked before x
+
+	for i := last - 1; i >= 0; i-- {
+		c := s.samples[i]
+		r -= c.width
+		// The invariant is concave in rank, so holding at both ends of the
+		// merged sample's ranks means it holds across them
+		limit := math.Min(s.invariant(r), s.invariant(r+c.width+x.width))
+		if c.width+x.width+x.delta <= limit {
+			x.width += c.width
+			s.samples[xi] = x
+			s.samples = append(s.samples[:i], s.samples[i+1:]...)
+			xi--
+		} else {
+			x = c
+			xi = i
+		}
+	}
+}
+
+// formatQuantile formats a quantile as a metadata key, e.g. 0.99 -> "0.99"
+func formatQuantile(q float64) string {
+	return strconv.FormatFloat(q, 'f', -1, 64)
+}
@@ -0,0 +1,117 @@
+package metrics
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// rankError returns how far estimate's rank in the sorted values is from
+// quantile q, as a fraction of the stream
+func rankError(sorted []float64, q, estimate float64) float64 {
+	n := float64(len(sorted))
+	lower := float64(sort.SearchFloat64s(sorted, estimate))
+	upper := float64(sort.Search(len(sorted), func(i int) bool { return sorted[i] > estimate }))
+	target := q * n
+	switch {
+	case target < lower:
+		return (lower - target) / n
+	case target > upper:
+		return (target - upper) / n
+	}
+	return 0
+}
+
+func TestQuantileStreamWithinErrorBound(t *testing.T) {
+	const n = 100000
+	rng := rand.New(rand.NewSource(1))
+
+	distributions := []struct {
+		name string
+		next func(i int) float64
+	}{
+		{"uniform", func(int) float64 { return rng.Float64() * 1000 }},
+		{"normal", func(int) float64 { return rng.NormFloat64()*50 + 200 }},
+		{"exponential", func(int) float64 { return rng.ExpFloat64() * 0.1 }},
+		{"ascending", func(i int) float64 { return float64(i) }},
+		{"descending", func(i int) float64 { return float64(n - i) }},
+		{"few values", func(int) float64 { return float64(rng.Intn(5)) }},
+	}
+	for _, dist := range distributions {
+		t.Run(dist.name, func(t *testing.T) {
+			objectives := DefaultSummaryObjectives()
+			stream := newQuantileStream(objectives)
+			values := make([]float64, n)
+			for i := range values {
+				values[i] = dist.next(i)
+				stream.Insert(values[i])
+			}
+			sort.Float64s(values)
+
+			for q, epsilon := range objectives {
+				estimate := stream.Query(q)
+				if err := rankError(values, q, estimate); err > epsilon {
+					t.Errorf("p%v = %v, rank error %.4f exceeds %v", q*100, estimate, err, epsilon)
+				}
+			}
+			// The whole point: far fewer samples than observations
+			if len(stream.samples) > n/20 {
+				t.Errorf("%d samples kept for %d observations", len(stream.samples), n)
+			}
+		})
+	}
+}
+
+func TestQuantileStreamIgnoresInvalidObjectives(t *testing.T) {
+	stream := newQuantileStream(map[float64]float64{0: 0.1, 1: 0.1, 0.5: 0, 0.9: 2, 0.75: 0.01})
+	if targets := stream.Targets(); len(targets) != 1 || targets[0] != 0.75 {
+		t.Fatalf("targets = %v, want only 0.75", targets)
+	}
+	if !math.IsNaN(stream.Query(0.75)) {
+		t.Fatal("empty stream returned a quantile")
+	}
+}
+
+func TestSummaryQuantilesCoverFullStream(t *testing.T) {
+	config := DefaultCollectorConfig()
+	config.CollectSystemMetrics = false
+	config.SummaryObjectives = map[float64]float64{0.5: 0.01, 0.99: 0.001}
+	collector := NewCollector(config)
+	defer collector.Close()
+
+	summary := collector.NewSummary("request_size_bytes", "Request size", nil)
+	if quantiles := summary.GetQuantiles(); len(quantiles) != 0 {
+		t.Fatalf("quantiles = %v before any observation", quantiles)
+	}
+
+	// Early observations must still count once far more have arrived
+	for i := 1; i <= 10000; i++ {
+		summary.Observe(float64(i))
+	}
+
+	metric := collector.GetMetric("request_size_bytes")
+	quantiles, _ := metric.Metadata["quantiles"].(map[string]float64)
+	if len(quantiles) != 2 {
+		t.Fatalf("quantiles = %v, want the configured objectives", metric.Metadata["quantiles"])
+	}
+	if p50 := quantiles["0.5"]; math.Abs(p50-5000) > 100 {
+		t.Fatalf("p50 = %v, want 5000±100", p50)
+	}
+	if p99 := quantiles["0.99"]; math.Abs(p99-9900) > 10 {
+		t.Fatalf("p99 = %v, want 9900±10", p99)
+	}
+	if p50 := summary.Quantile(0.5); p50 != quantiles["0.5"] {
+		t.Fatalf("Quantile(0.5) = %v, metadata says %v", p50, quantiles["0.5"])
+	}
+
+	var listed bool
+	for _, m := range collector.GetAllMetrics() {
+		if m.Name == "request_size_bytes" {
+			_, listed = m.Metadata["quantiles"].(map[string]float64)
+		}
+	}
+	if !listed {
+		t.Fatal("GetAllMetrics didn't include the summary quantiles")
+	}
+}
@@ -0,0 +1,146 @@
+package metrics
+
+import (
+	"runtime"
+	"sync"
+	"time"
+)
+
+// DefaultCPUSampleInterval is the minimum time between CPU samples taken by CPU
+const DefaultCPUSampleInterval = time.Second
+
+// CPUStats is a CPU usage sample
+type CPUStats struct {
+	ProcessPercent float64   `json:"process_percent"` // Process CPU time over wall time, normalized to 0-100 across all cores
+	PerCore        []float64 `json:"per_core,omitempty"`
+	SampledAt      time.Time `json:"sampled_at"`
+}
+
+// DiskStats is the disk usage of the filesystem holding a path
+type DiskStats struct {
+	Path        string  `json:"path"`
+	TotalBytes  uint64  `json:"total_bytes"`
+	FreeBytes   uint64  `json:"free_bytes"`
+	UsedBytes   uint64  `json:"used_bytes"`
+	UsedPercent float64 `json:"used_percent"`
+}
+
+// CPUSampler measures CPU usage as the delta between consecutive samples
+type CPUSampler struct {
+	mu          sync.Mutex
+	lastWall    time.Time
+	lastProcess time.Duration
+	lastCores   []coreTimes
+	latest      CPUStats
+}
+
+// coreTimes holds cumulative busy and total ticks of one core
+type coreTimes struct {
+	busy  uint64
+	total uint64
+}
+
+// NewCPUSampler creates a CPU sampler primed with an initial reading
+func NewCPUSampler() *CPUSampler {
+	s := &CPUSampler{}
+	s.Sample()
+	return s
+}
+
+// Sample takes a new reading and returns usage since the previous one
+func (s *CPUSampler) Sample() CPUStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	process := processCPUTime()
+	cores := readCoreTimes()
+
+	stats := CPUStats{SampledAt: now}
+
+	if !s.lastWall.IsZero() {
+		if wall := now.Sub(s.lastWall); wall > 0 {
+			used := float64(process-s.lastProcess) / float64(wall) / float64(runtime.NumCPU())
+			stats.ProcessPercent = clampPercent(used * 100)
+		}
+
+		if len(cores) == len(s.lastCores) {
+			stats.PerCore = make([]float64, len(cores))
+			for i, core := range cores {
+				total := core.total - s.lastCores[i].total
+				if total > 0 {
+					busy := core.busy - s.lastCores[i].busy
+					stats.PerCore[i] = clampPercent(float64(busy) / float64(total) * 100)
+				}
+			}
+		}
+	}
+
+	s.lastWall = now
+	s.lastProcess = process
+	s.lastCores = cores
+	s.latest = stats
+
+	return stats
+}
+
+// Latest returns the most recent sample without taking a new one
+func (s *CPUSampler) Latest() CPUStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.latest
+}
+
+// Current returns the latest sample, refreshing it if it is older than maxAge
+func (s *CPUSampler) Current(maxAge time.Duration) CPUStats {
+	if latest := s.Latest(); !latest.SampledAt.IsZero() && time.Since(latest.SampledAt) < maxAge {
+		return latest
+	}
+	return s.Sample()
+}
+
+func clampPercent(v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	if v > 100 {
+		return 100
+	}
+	return v
+}
+
+var (
+	defaultCPUSampler     *CPUSampler
+	defaultCPUSamplerOnce sync.Once
+)
+
+// DefaultCPUSampler returns the process-wide CPU sampler shared by the
+// collector's system metrics and other health reporting
+func DefaultCPUSampler() *CPUSampler {
+	defaultCPUSamplerOnce.Do(func() {
+		defaultCPUSampler = NewCPUSampler()
+	})
+	return defaultCPUSampler
+}
+
+// CPU returns current CPU usage from the shared sampler. Callers sampling
+// more often than DefaultCPUSampleInterval get the cached reading, so
+// concurrent readers don't shrink each other's measurement window.
+func CPU() CPUStats {
+	return DefaultCPUSampler().Current(DefaultCPUSampleInterval)
+}
+
+// DiskUsage returns usage of the filesystem containing path
+func DiskUsage(path string) (DiskStats, error) {
+	stats, err := diskUsage(path)
+	if err != nil {
+		return DiskStats{Path: path}, err
+	}
+
+	stats.Path = path
+	if stats.TotalBytes > 0 {
+		stats.UsedBytes = stats.TotalBytes - stats.FreeBytes
+		stats.UsedPercent = float64(stats.UsedBytes) / float64(stats.TotalBytes) * 100
+	}
+	return stats, nil
+}
@@ -0,0 +1,23 @@
+//go:build !unix
+
+package metrics
+
+import (
+	"errors"
+	"time"
+)
+
+// processCPUTime is not implemented on this platform
+func processCPUTime() time.Duration {
+	return 0
+}
+
+// readCoreTimes is not implemented on this platform
+func readCoreTimes() []coreTimes {
+	return nil
+}
+
+// diskUsage is not implemented on this platform
+func diskUsage(path string) (DiskStats, error) {
+	return DiskStats{}, errors.New("disk usage is not supported on this platform")
+}
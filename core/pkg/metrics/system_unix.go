@@ -0,0 +1,71 @@
+//go:build unix
+
+package metrics
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// processCPUTime returns the user plus system CPU time consumed by the process
+func processCPUTime() time.Duration {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+		return 0
+	}
+	return time.Duration(usage.Utime.Nano() + usage.Stime.Nano())
+}
+
+// readCoreTimes reads per-core tick counters from /proc/stat. It returns nil
+// where /proc is unavailable, which leaves per-core figures empty.
+func readCoreTimes() []coreTimes {
+	file, err := os.Open("/proc/stat")
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var cores []coreTimes
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		// Per-core lines are "cpuN user nice system idle iowait ..."; the
+		// aggregate "cpu" line is skipped
+		if len(fields) < 5 || !strings.HasPrefix(fields[0], "cpu") || fields[0] == "cpu" {
+			continue
+		}
+
+		var times coreTimes
+		for i, field := range fields[1:] {
+			v, err := strconv.ParseUint(field, 10, 64)
+			if err != nil {
+				continue
+			}
+			times.total += v
+			// idle and iowait are the 4th and 5th columns
+			if i != 3 && i != 4 {
+				times.busy += v
+			}
+		}
+		cores = append(cores, times)
+	}
+
+	return cores
+}
+
+// diskUsage returns total and available bytes of the filesystem holding path
+func diskUsage(path string) (DiskStats, error) {
+	var fs syscall.Statfs_t
+	if err := syscall.Statfs(path, &fs); err != nil {
+		return DiskStats{}, err
+	}
+
+	return DiskStats{
+		TotalBytes: uint64(fs.Blocks) * uint64(fs.Bsize),
+		FreeBytes:  uint64(fs.Bavail) * uint64(fs.Bsize),
+	}, nil
+}
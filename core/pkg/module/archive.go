@@ -0,0 +1,252 @@
+package module
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"neonexcore/pkg/errors"
+	"neonexcore/pkg/logger"
+)
+
+// MaxArchiveSize limits the size of module archives accepted for install,
+// and the total size of the files extracted from them
+const MaxArchiveSize = 100 << 20 // 100 MB
+
+// MaxArchiveEntries limits the number of files and folders in a module archive
+const MaxArchiveEntries = 10000
+
+// archiveDownloadTimeout bounds InstallFromURL downloads
+const archiveDownloadTimeout = 2 * time.Minute
+
+// maxArchiveRedirects limits the redirects followed by InstallFromURL
+const maxArchiveRedirects = 5
+
+// archiveClient downloads module archives. It only follows redirects to
+// other https URLs.
+var archiveClient = &http.Client{
+	Timeout: archiveDownloadTimeout,
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= maxArchiveRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxArchiveRedirects)
+		}
+		if req.URL.Scheme != "https" {
+			return fmt.Errorf("redirect to non-https URL %s", req.URL.Redacted())
+		}
+		return nil
+	},
+}
+
+// InstallFromURL downloads a zip archive over https and installs the module
+// it contains. checksum is required and must be the hex SHA-256 of the
+// archive.
+func (m *ModuleManager) InstallFromURL(ctx context.Context, archiveURL string, checksum string) (*Module, error) {
+	u, err := url.Parse(archiveURL)
+	if err != nil || u.Host == "" {
+		return nil, errors.NewBadRequest("Invalid archive URL")
+	}
+	if u.Scheme != "https" {
+		return nil, errors.NewBadRequest("Archive URL must use https")
+	}
+	if strings.TrimSpace(checksum) == "" {
+		return nil, errors.NewBadRequest("Checksum is required to install from a URL")
+	}
+
+	m.logger.Info("Downloading module archive", logger.Fields{"url": u.Redacted()})
+
+	ctx, cancel := context.WithTimeout(ctx, archiveDownloadTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, errors.NewBadRequest(fmt.Sprintf("Invalid archive URL: %v", err))
+	}
+
+	resp, err := archiveClient.Do(req)
+	if err != nil {
+		return nil, errors.NewBadRequest(fmt.Sprintf("Failed to download module archive: %v", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.NewBadRequest(fmt.Sprintf("Failed to download module archive: HTTP %d", resp.StatusCode))
+	}
+
+	return m.InstallFromArchive(ctx, resp.Body, checksum)
+}
+
+// InstallFromArchive extracts a zip archive into the modules directory,
+// validates its module.json and installs it. The archive may hold the module
+// at its root or inside a single top-level folder. Partial extractions are
+// removed on failure.
+func (m *ModuleManager) InstallFromArchive(ctx context.Context, r io.Reader, checksum string) (*Module, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MaxArchiveSize+1))
+	if err != nil {
+		return nil, errors.NewBadRequest(fmt.Sprintf("Failed to read module archive: %v", err))
+	}
+	if len(data) > MaxArchiveSize {
+		return nil, errors.NewBadRequest(fmt.Sprintf("Module archive exceeds %d bytes", MaxArchiveSize))
+	}
+
+	if checksum != "" {
+		sum := sha256.Sum256(data)
+		if !strings.EqualFold(hex.EncodeToString(sum[:]), strings.TrimSpace(checksum)) {
+			return nil, errors.NewBadRequest("Module archive checksum mismatch")
+		}
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, errors.NewBadRequest(fmt.Sprintf("Invalid module archive: %v", err))
+	}
+
+	if err := os.MkdirAll(m.modulesDir, 0755); err != nil {
+		return nil, errors.NewInternal(fmt.Sprintf("Failed to create modules directory: %v", err))
+	}
+
+	// Extract next to the final location so the move is a cheap rename
+	tmpDir, err := os.MkdirTemp(m.modulesDir, ".install-")
+	if err != nil {
+		return nil, errors.NewInternal(fmt.Sprintf("Failed to create extraction directory: %v", err))
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := extractZip(zr, tmpDir); err != nil {
+		return nil, err
+	}
+
+	root, err := archiveModuleRoot(tmpDir)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata, err := m.LoadMetadata(root)
+	if err != nil {
+		return nil, metadataAppError(err)
+	}
+	if !filepath.IsLocal(metadata.Name) || strings.ContainsAny(metadata.Name, `/\`) {
+		return nil, errors.NewBadRequest("Invalid module name")
+	}
+
+	modulePath := filepath.Join(m.modulesDir, metadata.Name)
+	if _, err := os.Stat(modulePath); err == nil {
+		return nil, errors.NewConflict(fmt.Sprintf("Module directory '%s' already exists", metadata.Name))
+	}
+
+	if err := os.Rename(root, modulePath); err != nil {
+		return nil, errors.NewInternal(fmt.Sprintf("Failed to move module into place: %v", err))
+	}
+
+	module, err := m.Install(ctx, modulePath)
+	if err != nil {
+		os.RemoveAll(modulePath)
+		return nil, err
+	}
+
+	return module, nil
+}
+
+// extractZip writes every entry of zr below dest, rejecting entries that
+// would escape it and archives with more than MaxArchiveEntries entries or
+// more than MaxArchiveSize bytes of content
+func extractZip(zr *zip.Reader, dest string) error {
+	if len(zr.File) > MaxArchiveEntries {
+		return errors.NewBadRequest(fmt.Sprintf("Module archive has more than %d entries", MaxArchiveEntries))
+	}
+
+	remaining := int64(MaxArchiveSize)
+	for _, f := range zr.File {
+		name := filepath.FromSlash(f.Name)
+		if strings.Contains(f.Name, `\`) || !filepath.IsLocal(name) {
+			return errors.NewBadRequest(fmt.Sprintf("Illegal path in module archive: %s", f.Name))
+		}
+		if f.Mode()&os.ModeSymlink != 0 {
+			return errors.NewBadRequest(fmt.Sprintf("Symlinks are not allowed in module archives: %s", f.Name))
+		}
+
+		target := filepath.Join(dest, name)
+
+		if f.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return errors.NewInternal(fmt.Sprintf("Failed to extract module archive: %v", err))
+			}
+			continue
+		}
+
+		written, err := extractZipFile(f, target, remaining)
+		if err == errArchiveTooLarge {
+			return errors.NewBadRequest(fmt.Sprintf("Module archive content exceeds %d bytes", MaxArchiveSize))
+		}
+		if err != nil {
+			return errors.NewInternal(fmt.Sprintf("Failed to extract %s: %v", f.Name, err))
+		}
+		remaining -= written
+	}
+
+	return nil
+}
+
+var errArchiveTooLarge = fmt.Errorf("archive content exceeds %d bytes", MaxArchiveSize)
+
+// extractZipFile writes f to target, failing with errArchiveTooLarge if it
+// holds more than limit bytes. The header's sizes aren't trusted; the
+// content itself is counted.
+func extractZipFile(f *zip.File, target string, limit int64) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return 0, err
+	}
+
+	src, err := f.Open()
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return 0, err
+	}
+
+	// Copy one byte past the limit to tell a full file from an oversized one
+	written, err := io.CopyN(dst, src, limit+1)
+	if err != nil && err != io.EOF {
+		dst.Close()
+		return written, err
+	}
+	if written > limit {
+		dst.Close()
+		return written, errArchiveTooLarge
+	}
+	return written, dst.Close()
+}
+
+// archiveModuleRoot finds the directory holding module.json: the extraction
+// root itself or its single top-level folder
+func archiveModuleRoot(dir string) (string, error) {
+	if _, err := os.Stat(filepath.Join(dir, "module.json")); err == nil {
+		return dir, nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", errors.NewInternal(fmt.Sprintf("Failed to read extracted archive: %v", err))
+	}
+	if len(entries) == 1 && entries[0].IsDir() {
+		nested := filepath.Join(dir, entries[0].Name())
+		if _, err := os.Stat(filepath.Join(nested, "module.json")); err == nil {
+			return nested, nil
+		}
+	}
+
+	return "", errors.NewBadRequest("Module archive does not contain module.json")
+}
@@ -0,0 +1,180 @@
+package module
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	stderrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"neonexcore/pkg/errors"
+)
+
+// zipEntry is a file written to a test archive
+type zipEntry struct {
+	name    string
+	content string
+}
+
+func buildZip(t *testing.T, entries ...zipEntry) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, entry := range entries {
+		w, err := zw.Create(entry.name)
+		if err != nil {
+			t.Fatalf("create %s: %v", entry.name, err)
+		}
+		if _, err := w.Write([]byte(entry.content)); err != nil {
+			t.Fatalf("write %s: %v", entry.name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("close zip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func testModuleJSON(name string) string {
+	return `{"name":"` + name + `","display_name":"` + name + `","version":"1.0.0"}`
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// assertAppErrorStatus checks err is an AppError with the given HTTP status
+func assertAppErrorStatus(t *testing.T, err error, status int) {
+	t.Helper()
+
+	var appErr *errors.AppError
+	if !stderrors.As(err, &appErr) || appErr.StatusCode != status {
+		t.Fatalf("error = %v, want an AppError with status %d", err, status)
+	}
+}
+
+// assertModulesDir checks the modules directory holds only the given
+// entries, so nothing was left behind by failed installs
+func assertModulesDir(t *testing.T, manager *ModuleManager, want ...string) {
+	t.Helper()
+
+	entries, err := os.ReadDir(manager.modulesDir)
+	if err != nil {
+		t.Fatalf("read modules dir: %v", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if len(names) != len(want) {
+		t.Fatalf("modules dir holds %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("modules dir holds %v, want %v", names, want)
+		}
+	}
+}
+
+func TestInstallFromArchive(t *testing.T) {
+	manager, _ := newTestManager(t)
+	archive := buildZip(t,
+		zipEntry{"blog/module.json", testModuleJSON("blog")},
+		zipEntry{"blog/handlers/post.go", "package handlers\n"},
+	)
+
+	module, err := manager.InstallFromArchive(context.Background(), bytes.NewReader(archive), sha256Hex(archive))
+	if err != nil {
+		t.Fatalf("InstallFromArchive: %v", err)
+	}
+	if module.Name != "blog" || module.Status != ModuleStatusInstalled {
+		t.Fatalf("module = %+v", module)
+	}
+	if _, err := os.Stat(filepath.Join(manager.modulesDir, "blog", "handlers", "post.go")); err != nil {
+		t.Fatalf("extracted file: %v", err)
+	}
+	assertModulesDir(t, manager, "blog")
+}
+
+func TestInstallFromArchiveRejectsPathTraversal(t *testing.T) {
+	manager, _ := newTestManager(t)
+	archive := buildZip(t,
+		zipEntry{"blog/module.json", testModuleJSON("blog")},
+		zipEntry{"../escaped.txt", "pwned"},
+	)
+
+	_, err := manager.InstallFromArchive(context.Background(), bytes.NewReader(archive), "")
+	assertAppErrorStatus(t, err, http.StatusBadRequest)
+
+	if _, err := os.Stat(filepath.Join(filepath.Dir(manager.modulesDir), "escaped.txt")); !os.IsNotExist(err) {
+		t.Fatal("the ../ entry was written outside the modules directory")
+	}
+	// The partial extraction was removed
+	assertModulesDir(t, manager)
+	if _, err := manager.repo.FindByName(context.Background(), "blog"); err == nil {
+		t.Fatal("the module was installed")
+	}
+}
+
+func TestInstallFromArchiveRejectsInvalidArchives(t *testing.T) {
+	valid := buildZip(t, zipEntry{"module.json", testModuleJSON("blog")})
+
+	tests := []struct {
+		name     string
+		archive  []byte
+		checksum string
+	}{
+		{"checksum mismatch", valid, sha256Hex([]byte("something else"))},
+		{"absolute path", buildZip(t, zipEntry{"/etc/module.json", testModuleJSON("blog")}), ""},
+		{"no module.json", buildZip(t, zipEntry{"blog/readme.md", "hi"}), ""},
+		{"invalid module.json", buildZip(t, zipEntry{"module.json", `{"name":"blog"}`}), ""},
+		{"not a zip", []byte("not a zip"), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager, _ := newTestManager(t)
+
+			_, err := manager.InstallFromArchive(context.Background(), bytes.NewReader(tt.archive), tt.checksum)
+			if err == nil {
+				t.Fatal("the archive was installed")
+			}
+			assertModulesDir(t, manager)
+		})
+	}
+}
+
+func TestInstallFromURL(t *testing.T) {
+	archive := buildZip(t, zipEntry{"module.json", testModuleJSON("blog")})
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(archive)
+	}))
+	defer server.Close()
+
+	client := archiveClient
+	archiveClient = server.Client()
+	defer func() { archiveClient = client }()
+
+	manager, _ := newTestManager(t)
+	ctx := context.Background()
+
+	_, err := manager.InstallFromURL(ctx, "http://"+server.Listener.Addr().String(), sha256Hex(archive))
+	assertAppErrorStatus(t, err, http.StatusBadRequest)
+	_, err = manager.InstallFromURL(ctx, server.URL, "")
+	assertAppErrorStatus(t, err, http.StatusBadRequest)
+
+	module, err := manager.InstallFromURL(ctx, server.URL, sha256Hex(archive))
+	if err != nil {
+		t.Fatalf("InstallFromURL: %v", err)
+	}
+	if module.Name != "blog" {
+		t.Fatalf("installed %s, want blog", module.Name)
+	}
+}
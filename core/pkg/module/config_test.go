@@ -0,0 +1,161 @@
+package module
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"neonexcore/pkg/errors"
+	"neonexcore/pkg/logger"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// testConfigSchema allows a per_page between 1 and 100 and a light or dark
+// theme, and nothing else
+const testConfigSchema = `{
+	"type": "object",
+	"required": ["per_page"],
+	"additionalProperties": false,
+	"properties": {
+		"per_page": {"type": "integer", "minimum": 1, "maximum": 100},
+		"theme": {"type": "string", "enum": ["light", "dark"]}
+	}
+}`
+
+// installConfigModule installs a "blog" module declaring testConfigSchema
+// with the given default config
+func installConfigModule(t *testing.T, manager *ModuleManager, config string) error {
+	t.Helper()
+
+	modulePath := filepath.Join(manager.modulesDir, "blog")
+	if err := os.MkdirAll(modulePath, 0o755); err != nil {
+		t.Fatalf("create module dir: %v", err)
+	}
+	metadata := `{"name":"blog","display_name":"Blog","version":"1.0.0","config":` + config + `,"config_schema":` + testConfigSchema + `}`
+	if err := os.WriteFile(filepath.Join(modulePath, "module.json"), []byte(metadata), 0o644); err != nil {
+		t.Fatalf("write module.json: %v", err)
+	}
+
+	_, err := manager.Install(context.Background(), modulePath)
+	return err
+}
+
+// validationProblems returns the field problems of a validation AppError
+func validationProblems(t *testing.T, err error) map[string]string {
+	t.Helper()
+
+	var appErr *errors.AppError
+	if !stderrors.As(err, &appErr) || appErr.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("error = %v, want a validation error", err)
+	}
+	problems, _ := appErr.Details["errors"].(map[string]string)
+	return problems
+}
+
+func TestUpdateModuleConfig(t *testing.T) {
+	manager, dispatcher := newTestManager(t)
+	ctx := context.Background()
+	if err := installConfigModule(t, manager, `{"per_page":10}`); err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	recorded := recordEvents(dispatcher, EventModuleConfigUpdated)
+
+	if err := manager.UpdateModuleConfig(ctx, "blog", map[string]interface{}{"per_page": 25, "theme": "dark"}); err != nil {
+		t.Fatalf("UpdateModuleConfig: %v", err)
+	}
+	config, err := manager.GetModuleConfig(ctx, "blog")
+	if err != nil || config["per_page"] != float64(25) || config["theme"] != "dark" {
+		t.Fatalf("GetModuleConfig = %v, %v; want the update", config, err)
+	}
+	if got := recorded(); got != "[module.config_updated]" {
+		t.Fatalf("events = %s, want module.config_updated", got)
+	}
+
+	if err := manager.UpdateModuleConfig(ctx, "missing", map[string]interface{}{}); err == nil {
+		t.Fatal("updated the config of a missing module")
+	}
+}
+
+func TestUpdateModuleConfigSchemaViolation(t *testing.T) {
+	manager, dispatcher := newTestManager(t)
+	ctx := context.Background()
+	if err := installConfigModule(t, manager, `{"per_page":10}`); err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	recorded := recordEvents(dispatcher, EventModuleConfigUpdated)
+
+	err := manager.UpdateModuleConfig(ctx, "blog", map[string]interface{}{"per_page": 2.5, "theme": "blue", "extra": true})
+	problems := validationProblems(t, err)
+	for _, field := range []string{"per_page", "theme", "extra"} {
+		if problems[field] == "" {
+			t.Errorf("no problem reported for %s in %v", field, problems)
+		}
+	}
+
+	err = manager.UpdateModuleConfig(ctx, "blog", map[string]interface{}{"per_page": 500})
+	if problem := validationProblems(t, err)["per_page"]; !strings.Contains(problem, "at most 100") {
+		t.Fatalf("per_page problem = %q, want the maximum", problem)
+	}
+	if problems := validationProblems(t, manager.UpdateModuleConfig(ctx, "blog", map[string]interface{}{})); problems["per_page"] == "" {
+		t.Fatalf("problems = %v, want per_page required", problems)
+	}
+
+	// Rejected configs are neither saved nor announced
+	config, _ := manager.GetModuleConfig(ctx, "blog")
+	if config["per_page"] != float64(10) || len(config) != 1 {
+		t.Fatalf("config = %v, want the default", config)
+	}
+	if got := recorded(); got != "[]" {
+		t.Fatalf("events = %s, want none", got)
+	}
+}
+
+func TestInstallValidatesDefaultConfig(t *testing.T) {
+	manager, _ := newTestManager(t)
+
+	problems := validationProblems(t, installConfigModule(t, manager, `{"per_page":0}`))
+	if problems["config.per_page"] == "" {
+		t.Fatalf("problems = %v, want config.per_page", problems)
+	}
+}
+
+func TestModuleConfigEndpoints(t *testing.T) {
+	manager, _ := newTestManager(t)
+	if err := installConfigModule(t, manager, `{"per_page":10}`); err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	app := fiber.New(fiber.Config{ErrorHandler: errors.ErrorHandler(logger.Default())})
+	NewModuleController(manager).RegisterRoutes(app)
+
+	send := func(method, body string) (int, map[string]interface{}) {
+		req := httptest.NewRequest(method, "/modules/blog/config", strings.NewReader(body))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("%s /modules/blog/config: %v", method, err)
+		}
+		var decoded map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&decoded)
+		return resp.StatusCode, decoded
+	}
+
+	if status, _ := send(fiber.MethodPut, `{"per_page":"ten"}`); status != fiber.StatusUnprocessableEntity {
+		t.Fatalf("PUT invalid config: status %d, want 422", status)
+	}
+	if status, _ := send(fiber.MethodPut, `{"per_page":50,"theme":"light"}`); status != fiber.StatusOK {
+		t.Fatalf("PUT valid config: status %d, want 200", status)
+	}
+
+	status, body := send(fiber.MethodGet, "")
+	data, _ := body["data"].(map[string]interface{})
+	if status != fiber.StatusOK || data["per_page"] != float64(50) || data["theme"] != "light" {
+		t.Fatalf("GET config: status %d, body %v; want the saved config", status, body)
+	}
+}
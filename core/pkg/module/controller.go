@@ -1,8 +1,6 @@
 package module
 
 import (
-	"strconv"
-
 	"github.com/gofiber/fiber/v2"
 	"neonexcore/pkg/errors"
 )
@@ -84,6 +82,55 @@ func (c *ModuleController) InstallModule(ctx *fiber.Ctx) error {
 	})
 }
 
+// InstallModuleFromURL handles POST /api/v1/modules/install/url
+func (c *ModuleController) InstallModuleFromURL(ctx *fiber.Ctx) error {
+	var req struct {
+		URL      string `json:"url" validate:"required"`
+		Checksum string `json:"checksum" validate:"required"`
+	}
+
+	if err := ctx.BodyParser(&req); err != nil || req.URL == "" || req.Checksum == "" {
+		return errors.NewBadRequest("Invalid request body")
+	}
+
+	module, err := c.manager.InstallFromURL(ctx.Context(), req.URL, req.Checksum)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "Module installed successfully",
+		"data":    module,
+	})
+}
+
+// InstallModuleFromArchive handles POST /api/v1/modules/install/archive
+// (multipart form with an "archive" zip file and optional "checksum")
+func (c *ModuleController) InstallModuleFromArchive(ctx *fiber.Ctx) error {
+	header, err := ctx.FormFile("archive")
+	if err != nil {
+		return errors.NewBadRequest("Missing module archive")
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return errors.NewBadRequest("Invalid module archive")
+	}
+	defer file.Close()
+
+	module, err := c.manager.InstallFromArchive(ctx.Context(), file, ctx.FormValue("checksum"))
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "Module installed successfully",
+		"data":    module,
+	})
+}
+
 // UninstallModule handles DELETE /api/v1/modules/:name
 func (c *ModuleController) UninstallModule(ctx *fiber.Ctx) error {
 	name := ctx.Params("name")
@@ -127,6 +174,21 @@ func (c *ModuleController) DeactivateModule(ctx *fiber.Ctx) error {
 	})
 }
 
+// SeedModule handles POST /api/v1/modules/:name/seed
+func (c *ModuleController) SeedModule(ctx *fiber.Ctx) error {
+	name := ctx.Params("name")
+	force := ctx.QueryBool("force", false)
+
+	if err := c.manager.Seed(ctx.Context(), name, force); err != nil {
+		return err
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"message": "Module seeded successfully",
+	})
+}
+
 // UpdateModule handles PUT /api/v1/modules/:name
 func (c *ModuleController) UpdateModule(ctx *fiber.Ctx) error {
 	name := ctx.Params("name")
@@ -153,17 +215,11 @@ func (c *ModuleController) UpdateModule(ctx *fiber.Ctx) error {
 func (c *ModuleController) GetModuleConfig(ctx *fiber.Ctx) error {
 	name := ctx.Params("name")
 
-	module, err := c.manager.GetModule(ctx.Context(), name)
+	config, err := c.manager.GetModuleConfig(ctx.Context(), name)
 	if err != nil {
 		return err
 	}
 
-	// Parse config from JSON string
-	config, err := c.manager.repo.ParseConfig(module.Path)
-	if err != nil {
-		return errors.NewInternal("Failed to parse module config")
-	}
-
 	return ctx.JSON(fiber.Map{
 		"success": true,
 		"data":    config,
@@ -174,18 +230,13 @@ func (c *ModuleController) GetModuleConfig(ctx *fiber.Ctx) error {
 func (c *ModuleController) UpdateModuleConfig(ctx *fiber.Ctx) error {
 	name := ctx.Params("name")
 
-	module, err := c.manager.GetModule(ctx.Context(), name)
-	if err != nil {
-		return err
-	}
-
 	var config map[string]interface{}
 	if err := ctx.BodyParser(&config); err != nil {
 		return errors.NewBadRequest("Invalid request body")
 	}
 
-	if err := c.manager.repo.SaveConfig(ctx.Context(), module.ID, config); err != nil {
-		return errors.NewInternal("Failed to save module config")
+	if err := c.manager.UpdateModuleConfig(ctx.Context(), name, config); err != nil {
+		return err
 	}
 
 	return ctx.JSON(fiber.Map{
@@ -233,12 +284,15 @@ func (c *ModuleController) RegisterRoutes(router fiber.Router) {
 	// Single module operations
 	modules.Get("/:name", c.GetModule)
 	modules.Post("/install", c.InstallModule)
+	modules.Post("/install/url", c.InstallModuleFromURL)
+	modules.Post("/install/archive", c.InstallModuleFromArchive)
 	modules.Delete("/:name", c.UninstallModule)
 	modules.Put("/:name", c.UpdateModule)
 
 	// Module status
 	modules.Post("/:name/activate", c.ActivateModule)
 	modules.Post("/:name/deactivate", c.DeactivateModule)
+	modules.Post("/:name/seed", c.SeedModule)
 
 	// Module config
 	modules.Get("/:name/config", c.GetModuleConfig)
@@ -0,0 +1,121 @@
+package module
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"neonexcore/pkg/logger"
+)
+
+// createDependentModule stores a module depending on dependsOn
+func createDependentModule(t *testing.T, manager *ModuleManager, name, dependsOn string, required bool) {
+	t.Helper()
+
+	module := createTestModule(t, manager, name, ModuleStatusInstalled)
+	dependency := &ModuleDependency{ModuleID: module.ID, DependsOnModule: dependsOn, Version: "^1.0.0", Required: required}
+	if err := manager.db.Create(dependency).Error; err != nil {
+		t.Fatalf("create dependency: %v", err)
+	}
+	// Required defaults to true in the database, so store false explicitly
+	if err := manager.db.Model(dependency).Update("required", required).Error; err != nil {
+		t.Fatalf("update dependency: %v", err)
+	}
+}
+
+// captureLogs makes the manager log JSON lines to the returned buffer too
+func captureLogs(manager *ModuleManager) *bytes.Buffer {
+	var buf bytes.Buffer
+	log := logger.NewLogger()
+	log.SetFormatter(logger.NewJSONFormatter())
+	log.AddWriter(&buf)
+	manager.logger = log
+	return &buf
+}
+
+// loggedDependents returns the dependent field of each logged line with msg
+func loggedDependents(t *testing.T, buf *bytes.Buffer, msg string) []string {
+	t.Helper()
+
+	var dependents []string
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		var line map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("decode log line: %v", err)
+		}
+		if line["message"] == msg {
+			dependents = append(dependents, fmt.Sprint(line["dependent"]))
+		}
+	}
+	return dependents
+}
+
+func TestCheckDependentsRequiredBlocks(t *testing.T) {
+	manager, _ := newTestManager(t)
+	createTestModule(t, manager, "auth", ModuleStatusInstalled)
+	createDependentModule(t, manager, "blog", "auth", true)
+	createDependentModule(t, manager, "shop", "auth", true)
+	createDependentModule(t, manager, "stats", "auth", false)
+
+	dependents, err := manager.CheckDependents(context.Background(), "auth")
+	assertAppErrorStatus(t, err, http.StatusBadRequest)
+	if !strings.Contains(err.Error(), "required by blog, shop") {
+		t.Fatalf("error = %v, want the required dependents named", err)
+	}
+	if len(dependents) != 3 {
+		t.Fatalf("dependents = %+v, want all three", dependents)
+	}
+
+	// Uninstall is blocked and the module kept
+	err = manager.Uninstall(context.Background(), "auth", false)
+	assertAppErrorStatus(t, err, http.StatusBadRequest)
+	if _, err := manager.repo.FindByName(context.Background(), "auth"); err != nil {
+		t.Fatalf("module removed despite required dependents: %v", err)
+	}
+}
+
+func TestCheckDependentsOptionalWarns(t *testing.T) {
+	manager, _ := newTestManager(t)
+	createTestModule(t, manager, "auth", ModuleStatusInstalled)
+	createDependentModule(t, manager, "stats", "auth", false)
+	logs := captureLogs(manager)
+
+	dependents, err := manager.CheckDependents(context.Background(), "auth")
+	if err != nil {
+		t.Fatalf("CheckDependents: %v", err)
+	}
+	if len(dependents) != 1 || dependents[0].Name != "stats" || dependents[0].Required {
+		t.Fatalf("dependents = %+v, want optional stats", dependents)
+	}
+	if !bytes.Contains(logs.Bytes(), []byte("Optional dependents will lose a dependency")) {
+		t.Fatal("optional dependents were not logged")
+	}
+
+	if err := manager.Uninstall(context.Background(), "auth", false); err != nil {
+		t.Fatalf("Uninstall with only optional dependents: %v", err)
+	}
+}
+
+func TestUninstallForceOrphansDependents(t *testing.T) {
+	manager, _ := newTestManager(t)
+	createTestModule(t, manager, "auth", ModuleStatusActive)
+	createDependentModule(t, manager, "blog", "auth", true)
+	createDependentModule(t, manager, "stats", "auth", false)
+	logs := captureLogs(manager)
+
+	if err := manager.Uninstall(context.Background(), "auth", true); err != nil {
+		t.Fatalf("forced Uninstall: %v", err)
+	}
+	if _, err := manager.repo.FindByName(context.Background(), "auth"); err == nil {
+		t.Fatal("module still installed")
+	}
+	if got := fmt.Sprint(loggedDependents(t, logs, "Orphaning dependent module")); got != "[blog stats]" {
+		t.Fatalf("orphaned dependents logged = %s, want [blog stats]", got)
+	}
+}
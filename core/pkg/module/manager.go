@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
 	"gorm.io/gorm"
@@ -18,34 +20,39 @@ import (
 
 // Module lifecycle events
 const (
-	EventModuleInstalling   = "module.installing"
-	EventModuleInstalled    = "module.installed"
-	EventModuleUninstalling = "module.uninstalling"
-	EventModuleUninstalled  = "module.uninstalled"
-	EventModuleActivating   = "module.activating"
-	EventModuleActivated    = "module.activated"
-	EventModuleDeactivating = "module.deactivating"
-	EventModuleDeactivated  = "module.deactivated"
-	EventModuleUpdating     = "module.updating"
-	EventModuleUpdated      = "module.updated"
+	EventModuleInstalling    = "module.installing"
+	EventModuleInstalled     = "module.installed"
+	EventModuleUninstalling  = "module.uninstalling"
+	EventModuleUninstalled   = "module.uninstalled"
+	EventModuleActivating    = "module.activating"
+	EventModuleActivated     = "module.activated"
+	EventModuleDeactivating  = "module.deactivating"
+	EventModuleDeactivated   = "module.deactivated"
+	EventModuleUpdating      = "module.updating"
+	EventModuleUpdated       = "module.updated"
+	EventModuleConfigUpdated = "module.config_updated"
 )
 
 // ModuleManager manages module lifecycle operations
 type ModuleManager struct {
 	repo       *ModuleRepository
 	db         *gorm.DB
-	txManager  *database.TransactionManager
+	txManager  *database.TxManager
 	events     *events.EventDispatcher
 	logger     logger.Logger
 	validator  *validation.Validator
 	modulesDir string
+
+	seedersMu     sync.RWMutex
+	seeders       map[string][]database.Seeder
+	strictSeeders bool
 }
 
 // NewModuleManager creates a new module manager
 func NewModuleManager(
 	repo *ModuleRepository,
 	db *gorm.DB,
-	txManager *database.TransactionManager,
+	txManager *database.TxManager,
 	events *events.EventDispatcher,
 	logger logger.Logger,
 	validator *validation.Validator,
@@ -56,9 +63,34 @@ func NewModuleManager(
 		db:         db,
 		txManager:  txManager,
 		events:     events,
-		logger:     logger,
+		logger:     logger.Named("module"),
 		validator:  validator,
 		modulesDir: modulesDir,
+		seeders:    make(map[string][]database.Seeder),
+	}
+}
+
+// dispatchBefore dispatches a lifecycle event that precedes an operation.
+// Listeners can veto the operation by returning an error, e.g. events.Abort.
+func (m *ModuleManager) dispatchBefore(ctx context.Context, name string, data map[string]interface{}) error {
+	if err := m.events.Dispatch(ctx, name, data); err != nil {
+		m.logger.Warn("Module operation blocked by event listener", logger.Fields{
+			"event": name,
+			"error": err.Error(),
+		})
+		return errors.NewConflict(fmt.Sprintf("Blocked by %s listener: %v", name, err)).WithError(err)
+	}
+	return nil
+}
+
+// dispatchAfter dispatches a lifecycle event for a completed operation.
+// The operation can't be undone, so listener errors are only logged.
+func (m *ModuleManager) dispatchAfter(ctx context.Context, name string, data map[string]interface{}) {
+	if err := m.events.Dispatch(ctx, name, data); err != nil {
+		m.logger.Error("Module event listener failed", logger.Fields{
+			"event": name,
+			"error": err.Error(),
+		})
 	}
 }
 
@@ -67,21 +99,16 @@ func (m *ModuleManager) Install(ctx context.Context, modulePath string) (*Module
 	m.logger.Info("Installing module", logger.Fields{"path": modulePath})
 
 	// Dispatch installing event
-	m.events.Dispatch(ctx, EventModuleInstalling, map[string]interface{}{
+	if err := m.dispatchBefore(ctx, EventModuleInstalling, map[string]interface{}{
 		"path": modulePath,
-	})
+	}); err != nil {
+		return nil, err
+	}
 
 	// Load and validate module metadata
 	metadata, err := m.LoadMetadata(modulePath)
 	if err != nil {
-		return nil, errors.NewBadRequest(fmt.Sprintf("Invalid module metadata: %v", err))
-	}
-
-	// Validate metadata
-	if err := m.validator.Validate(metadata); err != nil {
-		return nil, errors.NewValidation("Invalid module metadata", map[string]interface{}{
-			"errors": err,
-		})
+		return nil, metadataAppError(err)
 	}
 
 	// Check if module already exists
@@ -97,24 +124,28 @@ func (m *ModuleManager) Install(ctx context.Context, modulePath string) (*Module
 
 	// Create module in transaction
 	var module *Module
-	err = m.txManager.WithTransaction(ctx, func(txCtx context.Context) error {
+	err = m.txManager.WithTransaction(ctx, func(tx *gorm.DB) error {
+		txCtx := database.ContextWithTx(ctx, tx)
+		repo := m.repo.WithTx(tx)
+
 		// Create module record
 		configJSON, _ := json.Marshal(metadata.Config)
 		module = &Module{
-			Name:        metadata.Name,
-			DisplayName: metadata.DisplayName,
-			Description: metadata.Description,
-			Version:     metadata.Version,
-			Author:      metadata.Author,
-			Homepage:    metadata.Homepage,
-			Status:      ModuleStatusInstalled,
-			Priority:    metadata.Priority,
-			Path:        modulePath,
-			Config:      string(configJSON),
-			InstalledAt: time.Now(),
-		}
-
-		if err := m.repo.Create(txCtx, module); err != nil {
+			Name:         metadata.Name,
+			DisplayName:  metadata.DisplayName,
+			Description:  metadata.Description,
+			Version:      metadata.Version,
+			Author:       metadata.Author,
+			Homepage:     metadata.Homepage,
+			Status:       ModuleStatusInstalled,
+			Priority:     metadata.Priority,
+			Path:         modulePath,
+			Config:       string(configJSON),
+			ConfigSchema: marshalConfigSchema(metadata.ConfigSchema),
+			InstalledAt:  time.Now(),
+		}
+
+		if err := repo.Create(txCtx, module); err != nil {
 			return errors.NewInternal(fmt.Sprintf("Failed to create module record: %v", err))
 		}
 
@@ -126,7 +157,7 @@ func (m *ModuleManager) Install(ctx context.Context, modulePath string) (*Module
 				Version:         dep.Version,
 				Required:        dep.Required,
 			}
-			if err := m.repo.CreateDependency(txCtx, dependency); err != nil {
+			if err := repo.CreateDependency(txCtx, dependency); err != nil {
 				return errors.NewInternal(fmt.Sprintf("Failed to create dependency: %v", err))
 			}
 		}
@@ -140,11 +171,8 @@ func (m *ModuleManager) Install(ctx context.Context, modulePath string) (*Module
 
 		// Run seeders if exists
 		if metadata.Seeders {
-			if err := m.RunSeeders(txCtx, module); err != nil {
-				m.logger.Warn("Failed to run seeders", logger.Fields{
-					"module": module.Name,
-					"error":  err.Error(),
-				})
+			if err := m.RunSeeders(txCtx, module, false); err != nil {
+				return errors.NewInternal(fmt.Sprintf("Failed to run seeders: %v", err))
 			}
 		}
 
@@ -161,7 +189,7 @@ func (m *ModuleManager) Install(ctx context.Context, modulePath string) (*Module
 	})
 
 	// Dispatch installed event
-	m.events.Dispatch(ctx, EventModuleInstalled, map[string]interface{}{
+	m.dispatchAfter(ctx, EventModuleInstalled, map[string]interface{}{
 		"module_id": module.ID,
 		"module":    module.Name,
 		"version":   module.Version,
@@ -183,16 +211,28 @@ func (m *ModuleManager) Uninstall(ctx context.Context, moduleName string, force
 	}
 
 	// Dispatch uninstalling event
-	m.events.Dispatch(ctx, EventModuleUninstalling, map[string]interface{}{
+	if err := m.dispatchBefore(ctx, EventModuleUninstalling, map[string]interface{}{
 		"module_id": module.ID,
 		"module":    module.Name,
-	})
+	}); err != nil {
+		return err
+	}
 
 	// Check if other modules depend on this
-	if !force {
-		if err := m.CheckDependents(ctx, moduleName); err != nil {
-			return err
+	if force {
+		dependents, err := m.repo.FindDependents(ctx, moduleName)
+		if err != nil {
+			return errors.NewInternal(fmt.Sprintf("Failed to check dependents: %v", err))
+		}
+		for _, dep := range dependents {
+			m.logger.Warn("Orphaning dependent module", logger.Fields{
+				"module":    moduleName,
+				"dependent": dep.Name,
+				"required":  dep.Required,
+			})
 		}
+	} else if _, err := m.CheckDependents(ctx, moduleName); err != nil {
+		return err
 	}
 
 	// Deactivate if active
@@ -203,7 +243,10 @@ func (m *ModuleManager) Uninstall(ctx context.Context, moduleName string, force
 	}
 
 	// Uninstall in transaction
-	err = m.txManager.WithTransaction(ctx, func(txCtx context.Context) error {
+	err = m.txManager.WithTransaction(ctx, func(tx *gorm.DB) error {
+		txCtx := database.ContextWithTx(ctx, tx)
+		repo := m.repo.WithTx(tx)
+
 		// Rollback migrations
 		if err := m.RollbackMigrations(txCtx, module); err != nil {
 			m.logger.Warn("Failed to rollback migrations", logger.Fields{
@@ -213,12 +256,12 @@ func (m *ModuleManager) Uninstall(ctx context.Context, moduleName string, force
 		}
 
 		// Delete dependencies
-		if err := m.repo.DeleteDependencies(txCtx, module.ID); err != nil {
+		if err := repo.DeleteDependencies(txCtx, module.ID); err != nil {
 			return errors.NewInternal(fmt.Sprintf("Failed to delete dependencies: %v", err))
 		}
 
-		// Delete module
-		if err := m.repo.Delete(txCtx, module.ID); err != nil {
+		// Delete module permanently so the name can be installed again
+		if err := repo.Purge(txCtx, module.ID); err != nil {
 			return errors.NewInternal(fmt.Sprintf("Failed to delete module: %v", err))
 		}
 
@@ -232,7 +275,7 @@ func (m *ModuleManager) Uninstall(ctx context.Context, moduleName string, force
 	m.logger.Info("Module uninstalled successfully", logger.Fields{"module": moduleName})
 
 	// Dispatch uninstalled event
-	m.events.Dispatch(ctx, EventModuleUninstalled, map[string]interface{}{
+	m.dispatchAfter(ctx, EventModuleUninstalled, map[string]interface{}{
 		"module": moduleName,
 	})
 
@@ -256,10 +299,12 @@ func (m *ModuleManager) Activate(ctx context.Context, moduleName string) error {
 	}
 
 	// Dispatch activating event
-	m.events.Dispatch(ctx, EventModuleActivating, map[string]interface{}{
+	if err := m.dispatchBefore(ctx, EventModuleActivating, map[string]interface{}{
 		"module_id": module.ID,
 		"module":    module.Name,
-	})
+	}); err != nil {
+		return err
+	}
 
 	// Check dependencies are active
 	deps, err := m.repo.GetDependencies(ctx, module.ID)
@@ -284,7 +329,7 @@ func (m *ModuleManager) Activate(ctx context.Context, moduleName string) error {
 	m.logger.Info("Module activated successfully", logger.Fields{"module": moduleName})
 
 	// Dispatch activated event
-	m.events.Dispatch(ctx, EventModuleActivated, map[string]interface{}{
+	m.dispatchAfter(ctx, EventModuleActivated, map[string]interface{}{
 		"module_id": module.ID,
 		"module":    module.Name,
 	})
@@ -309,10 +354,12 @@ func (m *ModuleManager) Deactivate(ctx context.Context, moduleName string) error
 	}
 
 	// Dispatch deactivating event
-	m.events.Dispatch(ctx, EventModuleDeactivating, map[string]interface{}{
+	if err := m.dispatchBefore(ctx, EventModuleDeactivating, map[string]interface{}{
 		"module_id": module.ID,
 		"module":    module.Name,
-	})
+	}); err != nil {
+		return err
+	}
 
 	// Update status
 	if err := m.repo.UpdateStatus(ctx, module.ID, ModuleStatusInactive); err != nil {
@@ -322,7 +369,7 @@ func (m *ModuleManager) Deactivate(ctx context.Context, moduleName string) error
 	m.logger.Info("Module deactivated successfully", logger.Fields{"module": moduleName})
 
 	// Dispatch deactivated event
-	m.events.Dispatch(ctx, EventModuleDeactivated, map[string]interface{}{
+	m.dispatchAfter(ctx, EventModuleDeactivated, map[string]interface{}{
 		"module_id": module.ID,
 		"module":    module.Name,
 	})
@@ -330,6 +377,30 @@ func (m *ModuleManager) Deactivate(ctx context.Context, moduleName string) error
 	return nil
 }
 
+// ReloadModule re-runs the deactivate/activate lifecycle of an active module,
+// e.g. after its files changed during development. Inactive modules are left
+// untouched.
+func (m *ModuleManager) ReloadModule(ctx context.Context, moduleName string) error {
+	module, err := m.repo.FindByName(ctx, moduleName)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		return errors.NewInternal(fmt.Sprintf("Failed to find module: %v", err))
+	}
+
+	if module.Status != ModuleStatusActive {
+		return nil
+	}
+
+	m.logger.Info("Reloading module", logger.Fields{"module": moduleName})
+
+	if err := m.Deactivate(ctx, moduleName); err != nil {
+		return err
+	}
+	return m.Activate(ctx, moduleName)
+}
+
 // Update updates a module to a new version
 func (m *ModuleManager) Update(ctx context.Context, moduleName string, newPath string) error {
 	m.logger.Info("Updating module", logger.Fields{"module": moduleName, "path": newPath})
@@ -345,7 +416,7 @@ func (m *ModuleManager) Update(ctx context.Context, moduleName string, newPath s
 	// Load new metadata
 	metadata, err := m.LoadMetadata(newPath)
 	if err != nil {
-		return errors.NewBadRequest(fmt.Sprintf("Invalid module metadata: %v", err))
+		return metadataAppError(err)
 	}
 
 	if metadata.Name != moduleName {
@@ -353,34 +424,40 @@ func (m *ModuleManager) Update(ctx context.Context, moduleName string, newPath s
 	}
 
 	// Dispatch updating event
-	m.events.Dispatch(ctx, EventModuleUpdating, map[string]interface{}{
+	if err := m.dispatchBefore(ctx, EventModuleUpdating, map[string]interface{}{
 		"module_id":   module.ID,
 		"module":      module.Name,
 		"old_version": module.Version,
 		"new_version": metadata.Version,
-	})
+	}); err != nil {
+		return err
+	}
 
 	// Update in transaction
-	err = m.txManager.WithTransaction(ctx, func(txCtx context.Context) error {
+	err = m.txManager.WithTransaction(ctx, func(tx *gorm.DB) error {
+		txCtx := database.ContextWithTx(ctx, tx)
+		repo := m.repo.WithTx(tx)
+
 		// Update module record
 		configJSON, _ := json.Marshal(metadata.Config)
 		updates := map[string]interface{}{
-			"display_name": metadata.DisplayName,
-			"description":  metadata.Description,
-			"version":      metadata.Version,
-			"author":       metadata.Author,
-			"homepage":     metadata.Homepage,
-			"priority":     metadata.Priority,
-			"path":         newPath,
-			"config":       string(configJSON),
+			"display_name":  metadata.DisplayName,
+			"description":   metadata.Description,
+			"version":       metadata.Version,
+			"author":        metadata.Author,
+			"homepage":      metadata.Homepage,
+			"priority":      metadata.Priority,
+			"path":          newPath,
+			"config":        string(configJSON),
+			"config_schema": marshalConfigSchema(metadata.ConfigSchema),
 		}
 
-		if err := m.repo.Update(txCtx, module.ID, updates); err != nil {
+		if err := repo.UpdateFields(txCtx, module.ID, updates); err != nil {
 			return errors.NewInternal(fmt.Sprintf("Failed to update module: %v", err))
 		}
 
 		// Update dependencies
-		if err := m.repo.DeleteDependencies(txCtx, module.ID); err != nil {
+		if err := repo.DeleteDependencies(txCtx, module.ID); err != nil {
 			return errors.NewInternal(fmt.Sprintf("Failed to delete old dependencies: %v", err))
 		}
 
@@ -391,7 +468,7 @@ func (m *ModuleManager) Update(ctx context.Context, moduleName string, newPath s
 				Version:         dep.Version,
 				Required:        dep.Required,
 			}
-			if err := m.repo.CreateDependency(txCtx, dependency); err != nil {
+			if err := repo.CreateDependency(txCtx, dependency); err != nil {
 				return errors.NewInternal(fmt.Sprintf("Failed to create dependency: %v", err))
 			}
 		}
@@ -417,7 +494,7 @@ func (m *ModuleManager) Update(ctx context.Context, moduleName string, newPath s
 	})
 
 	// Dispatch updated event
-	m.events.Dispatch(ctx, EventModuleUpdated, map[string]interface{}{
+	m.dispatchAfter(ctx, EventModuleUpdated, map[string]interface{}{
 		"module_id":   module.ID,
 		"module":      module.Name,
 		"old_version": module.Version,
@@ -427,7 +504,63 @@ func (m *ModuleManager) Update(ctx context.Context, moduleName string, newPath s
 	return nil
 }
 
-// LoadMetadata loads module.json from module path
+// GetModuleConfig returns a module's stored config
+func (m *ModuleManager) GetModuleConfig(ctx context.Context, moduleName string) (map[string]interface{}, error) {
+	module, err := m.repo.FindByName(ctx, moduleName)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFound("Module not found")
+		}
+		return nil, errors.NewInternal(fmt.Sprintf("Failed to find module: %v", err))
+	}
+
+	config, err := m.repo.ParseConfig(module.Config)
+	if err != nil {
+		return nil, errors.NewInternal("Failed to parse module config")
+	}
+	return config, nil
+}
+
+// UpdateModuleConfig validates a module's config against the schema its
+// module.json declares and saves it
+func (m *ModuleManager) UpdateModuleConfig(ctx context.Context, moduleName string, config map[string]interface{}) error {
+	module, err := m.repo.FindByName(ctx, moduleName)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.NewNotFound("Module not found")
+		}
+		return errors.NewInternal(fmt.Sprintf("Failed to find module: %v", err))
+	}
+
+	var schema map[string]interface{}
+	if module.ConfigSchema != "" {
+		if err := json.Unmarshal([]byte(module.ConfigSchema), &schema); err != nil {
+			return errors.NewInternal(fmt.Sprintf("Failed to parse module config schema: %v", err))
+		}
+	}
+	if problems := validateConfig(schema, config); problems != nil {
+		return errors.NewValidationError("Invalid module config", map[string]interface{}{
+			"errors": problems,
+		})
+	}
+
+	if err := m.repo.SaveConfig(ctx, module.ID, config); err != nil {
+		return errors.NewInternal(fmt.Sprintf("Failed to save module config: %v", err))
+	}
+
+	m.logger.Info("Module config updated", logger.Fields{"module": moduleName})
+
+	m.dispatchAfter(ctx, EventModuleConfigUpdated, map[string]interface{}{
+		"module_id": module.ID,
+		"module":    module.Name,
+		"config":    config,
+	})
+
+	return nil
+}
+
+// LoadMetadata loads and validates module.json from module path. Invalid
+// metadata is returned as a *MetadataError.
 func (m *ModuleManager) LoadMetadata(modulePath string) (*ModuleMetadata, error) {
 	metadataPath := filepath.Join(modulePath, "module.json")
 	data, err := os.ReadFile(metadataPath)
@@ -435,12 +568,7 @@ func (m *ModuleManager) LoadMetadata(modulePath string) (*ModuleMetadata, error)
 		return nil, fmt.Errorf("failed to read module.json: %w", err)
 	}
 
-	var metadata ModuleMetadata
-	if err := json.Unmarshal(data, &metadata); err != nil {
-		return nil, fmt.Errorf("failed to parse module.json: %w", err)
-	}
-
-	return &metadata, nil
+	return ParseMetadata(data, m.validator)
 }
 
 // CheckDependencies checks if all required dependencies are installed and active
@@ -468,22 +596,41 @@ func (m *ModuleManager) CheckDependencies(ctx context.Context, deps []ModuleDepe
 	return nil
 }
 
-// CheckDependents checks if other modules depend on this module
-func (m *ModuleManager) CheckDependents(ctx context.Context, moduleName string) error {
-	var count int64
-	err := m.db.Model(&ModuleDependency{}).
-		Where("depends_on_module = ? AND required = ?", moduleName, true).
-		Count(&count).Error
-
+// CheckDependents returns the modules that depend on this module. It fails
+// when any of them requires it; optional dependents are only logged.
+func (m *ModuleManager) CheckDependents(ctx context.Context, moduleName string) ([]ModuleDependent, error) {
+	dependents, err := m.repo.FindDependents(ctx, moduleName)
 	if err != nil {
-		return errors.NewInternal(fmt.Sprintf("Failed to check dependents: %v", err))
+		return nil, errors.NewInternal(fmt.Sprintf("Failed to check dependents: %v", err))
 	}
 
-	if count > 0 {
-		return errors.NewBadRequest(fmt.Sprintf("Cannot uninstall: %d module(s) depend on this module", count))
+	required := make([]string, 0)
+	optional := make([]string, 0)
+	for _, dep := range dependents {
+		if dep.Required {
+			required = append(required, dep.Name)
+		} else {
+			optional = append(optional, dep.Name)
+		}
 	}
 
-	return nil
+	if len(optional) > 0 {
+		m.logger.Warn("Optional dependents will lose a dependency", logger.Fields{
+			"module":     moduleName,
+			"dependents": optional,
+		})
+	}
+
+	if len(required) > 0 {
+		return dependents, errors.NewBadRequest(fmt.Sprintf(
+			"Cannot uninstall: required by %s", strings.Join(required, ", "),
+		)).WithDetails(map[string]interface{}{
+			"required": required,
+			"optional": optional,
+		})
+	}
+
+	return dependents, nil
 }
 
 // RunMigrations runs module migrations (placeholder)
@@ -500,13 +647,6 @@ func (m *ModuleManager) RollbackMigrations(ctx context.Context, module *Module)
 	return nil
 }
 
-// RunSeeders runs module seeders (placeholder)
-func (m *ModuleManager) RunSeeders(ctx context.Context, module *Module) error {
-	// TODO: Implement seeder runner
-	m.logger.Info("Running seeders", logger.Fields{"module": module.Name})
-	return nil
-}
-
 // GetModule gets module by name
 func (m *ModuleManager) GetModule(ctx context.Context, moduleName string) (*ModuleInfo, error) {
 	module, err := m.repo.FindByName(ctx, moduleName)
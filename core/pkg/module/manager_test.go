@@ -0,0 +1,104 @@
+package module
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"neonexcore/pkg/database"
+	"neonexcore/pkg/events"
+	"neonexcore/pkg/logger"
+	"neonexcore/pkg/validation"
+)
+
+// newTestManager returns a module manager on a fresh database, installing
+// into a temporary modules directory
+func newTestManager(t *testing.T) (*ModuleManager, *events.EventDispatcher) {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "modules.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(&Module{}, &ModuleDependency{}, &ModuleMigration{}, &database.SeederRun{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	dispatcher := events.NewEventDispatcher()
+	manager := NewModuleManager(
+		NewModuleRepository(db),
+		db,
+		database.NewTxManager(db),
+		dispatcher,
+		logger.Default(),
+		validation.NewValidator(),
+		t.TempDir(),
+	)
+	return manager, dispatcher
+}
+
+// createTestModule stores a module record with the given status
+func createTestModule(t *testing.T, manager *ModuleManager, name string, status ModuleStatus) *Module {
+	t.Helper()
+
+	module := &Module{Name: name, DisplayName: name, Version: "1.0.0", Path: "modules/" + name, Status: status}
+	if err := manager.db.Create(module).Error; err != nil {
+		t.Fatalf("create module %s: %v", name, err)
+	}
+	return module
+}
+
+// recordEvents records the names of the given events as they're dispatched
+func recordEvents(dispatcher *events.EventDispatcher, names ...string) func() string {
+	var (
+		mu       sync.Mutex
+		recorded []string
+	)
+	for _, name := range names {
+		dispatcher.Register(name, func(_ context.Context, event events.Event) error {
+			mu.Lock()
+			defer mu.Unlock()
+			recorded = append(recorded, event.Name)
+			return nil
+		})
+	}
+	return func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		return fmt.Sprint(recorded)
+	}
+}
+
+func TestReloadModuleRunsLifecycle(t *testing.T) {
+	manager, dispatcher := newTestManager(t)
+	ctx := context.Background()
+	createTestModule(t, manager, "blog", ModuleStatusActive)
+	createTestModule(t, manager, "shop", ModuleStatusInactive)
+	recorded := recordEvents(dispatcher, EventModuleDeactivating, EventModuleDeactivated, EventModuleActivating, EventModuleActivated)
+
+	if err := manager.ReloadModule(ctx, "blog"); err != nil {
+		t.Fatalf("ReloadModule: %v", err)
+	}
+	want := "[module.deactivating module.deactivated module.activating module.activated]"
+	if got := recorded(); got != want {
+		t.Fatalf("events = %s, want %s", got, want)
+	}
+	blog, _ := manager.repo.FindByName(ctx, "blog")
+	if blog.Status != ModuleStatusActive {
+		t.Fatalf("status after reload = %s, want active", blog.Status)
+	}
+
+	// Inactive and unknown modules are left alone
+	for _, name := range []string{"shop", "missing"} {
+		if err := manager.ReloadModule(ctx, name); err != nil {
+			t.Fatalf("ReloadModule %s: %v", name, err)
+		}
+	}
+	if got := recorded(); got != want {
+		t.Fatalf("events = %s, want no lifecycle for inactive modules", got)
+	}
+}
@@ -0,0 +1,195 @@
+package module
+
+import (
+	"bytes"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"neonexcore/pkg/errors"
+	"neonexcore/pkg/validation"
+)
+
+// MetadataError lists the problems found in a module.json by field path,
+// e.g. "version" or "dependencies[1].name". Problems that aren't tied to a
+// field, such as JSON syntax errors, are keyed by "$".
+type MetadataError struct {
+	Path   string
+	Fields map[string]string
+}
+
+// Error implements error
+func (e *MetadataError) Error() string {
+	fields := make([]string, 0, len(e.Fields))
+	for field := range e.Fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	problems := make([]string, len(fields))
+	for i, field := range fields {
+		problems[i] = fmt.Sprintf("%s: %s", field, e.Fields[field])
+	}
+
+	return fmt.Sprintf("invalid %s: %s", e.Path, strings.Join(problems, "; "))
+}
+
+// ParseMetadata decodes and validates a module.json. Validation problems
+// are returned as a *MetadataError.
+func ParseMetadata(data []byte, validator *validation.Validator) (*ModuleMetadata, error) {
+	var metadata ModuleMetadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return nil, &MetadataError{Path: "module.json", Fields: jsonErrorFields(data, err)}
+	}
+
+	problems := validator.Validate(&metadata)
+	if problems == nil {
+		problems = make(map[string]string)
+	}
+
+	// Dependencies must be distinct and not on the module itself
+	seen := make(map[string]bool, len(metadata.Dependencies))
+	for i, dep := range metadata.Dependencies {
+		if dep.Name == "" {
+			continue
+		}
+		field := fmt.Sprintf("dependencies[%d].name", i)
+		if dep.Name == metadata.Name {
+			problems[field] = "a module cannot depend on itself"
+		} else if seen[dep.Name] {
+			problems[field] = fmt.Sprintf("duplicate dependency '%s'", dep.Name)
+		}
+		seen[dep.Name] = true
+	}
+
+	// The default config must satisfy the config schema
+	for field, problem := range validateConfig(metadata.ConfigSchema, metadata.Config) {
+		if field == "$" {
+			problems["config"] = strings.Replace(problem, "$", "config", 1)
+		} else {
+			problems["config."+field] = problem
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, &MetadataError{Path: "module.json", Fields: problems}
+	}
+	return &metadata, nil
+}
+
+// ValidateMetadataFile validates a module.json, given its path or its
+// module's directory, and returns the parsed metadata. Module authors can
+// run it as a lint step; problems are returned as a *MetadataError.
+func ValidateMetadataFile(path string) (*ModuleMetadata, error) {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = filepath.Join(path, "module.json")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read module.json: %w", err)
+	}
+
+	metadata, err := ParseMetadata(data, validation.NewValidator())
+	var metaErr *MetadataError
+	if stderrors.As(err, &metaErr) {
+		metaErr.Path = path
+	}
+	return metadata, err
+}
+
+// validateConfig validates a module config against its JSON schema and
+// returns the problems by field path. Without a schema any config is valid.
+func validateConfig(schema, config map[string]interface{}) map[string]string {
+	if schema == nil {
+		return nil
+	}
+	if config == nil {
+		config = map[string]interface{}{}
+	}
+
+	// Round-trip through JSON so values have the types a decoder produces
+	data, err := json.Marshal(config)
+	if err != nil {
+		return map[string]string{"$": fmt.Sprintf("config is not valid JSON: %v", err)}
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return map[string]string{"$": fmt.Sprintf("config is not valid JSON: %v", err)}
+	}
+
+	return validation.ValidateSchema(schema, value)
+}
+
+// marshalConfigSchema encodes a config schema for the Module record
+func marshalConfigSchema(schema map[string]interface{}) string {
+	if schema == nil {
+		return ""
+	}
+	data, _ := json.Marshal(schema)
+	return string(data)
+}
+
+// jsonErrorFields describes a json.Unmarshal error by field
+func jsonErrorFields(data []byte, err error) map[string]string {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case stderrors.As(err, &syntaxErr):
+		line, column := offsetPosition(data, syntaxErr.Offset)
+		return map[string]string{
+			"$": fmt.Sprintf("invalid JSON at line %d, column %d: %s", line, column, syntaxErr.Error()),
+		}
+	case stderrors.As(err, &typeErr) && typeErr.Field != "":
+		return map[string]string{
+			typeErr.Field: fmt.Sprintf("%s must be %s, not %s", typeErr.Field, jsonTypeName(typeErr.Type.Kind().String()), typeErr.Value),
+		}
+	default:
+		return map[string]string{"$": err.Error()}
+	}
+}
+
+// jsonTypeName names a Go kind the way module authors know it from JSON
+func jsonTypeName(kind string) string {
+	switch kind {
+	case "string":
+		return "a string"
+	case "bool":
+		return "a boolean"
+	case "slice", "array":
+		return "an array"
+	case "map", "struct":
+		return "an object"
+	default:
+		return "a number"
+	}
+}
+
+// offsetPosition converts a json.SyntaxError offset, which counts the
+// offending byte, into its 1-based line and column
+func offsetPosition(data []byte, offset int64) (int, int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := len(before) - bytes.LastIndexByte(before, '\n') - 1
+	return line, column
+}
+
+// metadataAppError converts a LoadMetadata error to an AppError, with the
+// field problems as validation details
+func metadataAppError(err error) error {
+	var metaErr *MetadataError
+	if stderrors.As(err, &metaErr) {
+		return errors.NewValidationError("Invalid module metadata", map[string]interface{}{
+			"errors": metaErr.Fields,
+		})
+	}
+	return errors.NewBadRequest(fmt.Sprintf("Invalid module metadata: %v", err))
+}
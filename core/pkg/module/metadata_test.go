@@ -0,0 +1,138 @@
+package module
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"neonexcore/pkg/validation"
+)
+
+func TestParseMetadata(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want map[string]string // Problem fields and a fragment of their message
+	}{
+		{
+			name: "valid",
+			json: `{"name":"blog","display_name":"Blog","version":"1.2.0-beta.1",
+				"dependencies":[{"name":"user","version":">= 1.0.0, <2.0.0"},{"name":"media","version":"^2.1 || 3.x"}]}`,
+		},
+		{
+			name: "missing name",
+			json: `{"display_name":"Blog","version":"1.0.0"}`,
+			want: map[string]string{"name": "required"},
+		},
+		{
+			name: "missing display name and version",
+			json: `{"name":"blog"}`,
+			want: map[string]string{"display_name": "required", "version": "required"},
+		},
+		{
+			name: "bad version",
+			json: `{"name":"blog","display_name":"Blog","version":"1.0"}`,
+			want: map[string]string{"version": "semantic version"},
+		},
+		{
+			name: "malformed dependency",
+			json: `{"name":"blog","display_name":"Blog","version":"1.0.0",
+				"dependencies":[{"name":"user","version":"1.0.0"},{"version":">=1.0.0"},{"name":"media","version":"latest"}]}`,
+			want: map[string]string{"dependencies[1].name": "required", "dependencies[2].version": "version constraint"},
+		},
+		{
+			name: "self and duplicate dependencies",
+			json: `{"name":"blog","display_name":"Blog","version":"1.0.0",
+				"dependencies":[{"name":"blog","version":"*"},{"name":"user","version":"*"},{"name":"user","version":"^1.0"}]}`,
+			want: map[string]string{"dependencies[0].name": "itself", "dependencies[2].name": "duplicate dependency 'user'"},
+		},
+		{
+			name: "wrong type",
+			json: `{"name":"blog","display_name":"Blog","version":1}`,
+			want: map[string]string{"version": "version must be a string, not number"},
+		},
+		{
+			name: "syntax error",
+			json: "{\n  \"name\": \"blog\",\n  \"version\": \"1.0.0\"\n  \"display_name\": \"Blog\"\n}",
+			want: map[string]string{"$": "line 4, column 3"},
+		},
+		{
+			name: "syntax error on the first line",
+			json: `{"name" "blog"}`,
+			want: map[string]string{"$": "line 1, column 9"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metadata, err := ParseMetadata([]byte(tt.json), validation.NewValidator())
+			if tt.want == nil {
+				if err != nil || metadata == nil || metadata.Name != "blog" {
+					t.Fatalf("ParseMetadata = %+v, %v; want the blog metadata", metadata, err)
+				}
+				return
+			}
+
+			var metaErr *MetadataError
+			if !stderrors.As(err, &metaErr) {
+				t.Fatalf("ParseMetadata error = %v, want a MetadataError", err)
+			}
+			if len(metaErr.Fields) != len(tt.want) {
+				t.Fatalf("problems = %v, want fields %v", metaErr.Fields, tt.want)
+			}
+			for field, fragment := range tt.want {
+				if !strings.Contains(metaErr.Fields[field], fragment) {
+					t.Errorf("%s problem = %q, want it to mention %q", field, metaErr.Fields[field], fragment)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateMetadataFile(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "blog")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatalf("create module dir: %v", err)
+	}
+	path := filepath.Join(dir, "module.json")
+
+	os.WriteFile(path, []byte(testModuleJSON("blog")), 0644)
+	for _, target := range []string{dir, path} {
+		if metadata, err := ValidateMetadataFile(target); err != nil || metadata.Name != "blog" {
+			t.Fatalf("ValidateMetadataFile(%s) = %+v, %v", target, metadata, err)
+		}
+	}
+
+	// Problems name the file and every offending field
+	os.WriteFile(path, []byte(`{"name":"blog","version":"one"}`), 0644)
+	_, err := ValidateMetadataFile(dir)
+	var metaErr *MetadataError
+	if !stderrors.As(err, &metaErr) || metaErr.Path != path {
+		t.Fatalf("ValidateMetadataFile error = %v, want a MetadataError for %s", err, path)
+	}
+	if msg := err.Error(); !strings.Contains(msg, path) || !strings.Contains(msg, "display_name: ") || !strings.Contains(msg, "version: ") {
+		t.Fatalf("error = %q, want the path and both fields", msg)
+	}
+
+	if _, err := ValidateMetadataFile(t.TempDir()); err == nil || stderrors.As(err, &metaErr) {
+		t.Fatalf("ValidateMetadataFile without a module.json = %v, want a read error", err)
+	}
+}
+
+func TestInstallRejectsInvalidMetadata(t *testing.T) {
+	manager, _ := newTestManager(t)
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "module.json"), []byte(`{"name":"blog","display_name":"Blog","version":"v1"}`), 0644)
+
+	_, err := manager.Install(context.Background(), dir)
+	assertAppErrorStatus(t, err, http.StatusUnprocessableEntity)
+
+	var count int64
+	manager.db.Model(&Module{}).Count(&count)
+	if count != 0 {
+		t.Fatal("module with invalid metadata was installed")
+	}
+}
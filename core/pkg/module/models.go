@@ -19,20 +19,21 @@ const (
 
 // Module represents a module record in database
 type Module struct {
-	ID          uint           `gorm:"primarykey" json:"id"`
-	Name        string         `gorm:"uniqueIndex;not null" json:"name"`
-	DisplayName string         `gorm:"not null" json:"display_name"`
-	Description string         `json:"description"`
-	Version     string         `gorm:"not null" json:"version"`
-	Author      string         `json:"author"`
-	Homepage    string         `json:"homepage"`
-	Status      ModuleStatus   `gorm:"default:'installed'" json:"status"`
-	Priority    int            `gorm:"default:100" json:"priority"`
-	Path        string         `gorm:"not null" json:"path"`
-	Config      string         `gorm:"type:text" json:"config"` // JSON string
-	InstalledAt time.Time      `json:"installed_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+	ID           uint           `gorm:"primarykey" json:"id"`
+	Name         string         `gorm:"uniqueIndex;not null" json:"name"`
+	DisplayName  string         `gorm:"not null" json:"display_name"`
+	Description  string         `json:"description"`
+	Version      string         `gorm:"not null" json:"version"`
+	Author       string         `json:"author"`
+	Homepage     string         `json:"homepage"`
+	Status       ModuleStatus   `gorm:"default:'installed'" json:"status"`
+	Priority     int            `gorm:"default:100" json:"priority"`
+	Path         string         `gorm:"not null" json:"path"`
+	Config       string         `gorm:"type:text" json:"config"`                  // JSON string
+	ConfigSchema string         `gorm:"type:text" json:"config_schema,omitempty"` // JSON schema of Config
+	InstalledAt  time.Time      `json:"installed_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
 // TableName specifies the table name for Module model
@@ -84,17 +85,25 @@ type ModuleMetadata struct {
 	Homepage     string              `json:"homepage,omitempty"`
 	License      string              `json:"license,omitempty"`
 	Priority     int                 `json:"priority"`
-	Dependencies []ModuleDependencyInfo `json:"dependencies,omitempty"`
+	Dependencies []ModuleDependencyInfo `json:"dependencies,omitempty" validate:"dive"`
 	Routes       bool                `json:"routes"`
 	Migrations   bool                `json:"migrations"`
 	Seeders      bool                `json:"seeders"`
 	Config       map[string]interface{} `json:"config,omitempty"`
+	ConfigSchema map[string]interface{} `json:"config_schema,omitempty"` // JSON schema for Config
 }
 
 // ModuleDependencyInfo represents dependency information in module.json
 type ModuleDependencyInfo struct {
 	Name     string `json:"name" validate:"required"`
-	Version  string `json:"version" validate:"required"`
+	Version  string `json:"version" validate:"required,semver_constraint"`
+	Required bool   `json:"required"`
+}
+
+// ModuleDependent represents an installed module that depends on another module
+type ModuleDependent struct {
+	Name     string `json:"name"`
+	Version  string `json:"version"`
 	Required bool   `json:"required"`
 }
 
@@ -22,6 +22,14 @@ func NewModuleRepository(db *gorm.DB) *ModuleRepository {
 	}
 }
 
+// WithTx returns a repository that runs its queries in tx
+func (r *ModuleRepository) WithTx(tx *gorm.DB) *ModuleRepository {
+	return &ModuleRepository{
+		BaseRepository: r.BaseRepository.WithTx(tx),
+		db:             tx,
+	}
+}
+
 // FindByName finds a module by name
 func (r *ModuleRepository) FindByName(ctx context.Context, name string) (*Module, error) {
 	var module Module
@@ -49,6 +57,11 @@ func (r *ModuleRepository) UpdateStatus(ctx context.Context, moduleID uint, stat
 	return r.db.WithContext(ctx).Model(&Module{}).Where("id = ?", moduleID).Update("status", status).Error
 }
 
+// UpdateFields updates the given columns of a module
+func (r *ModuleRepository) UpdateFields(ctx context.Context, moduleID uint, updates map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&Module{}).Where("id = ?", moduleID).Updates(updates).Error
+}
+
 // Search searches modules by name or description
 func (r *ModuleRepository) Search(ctx context.Context, query string) ([]Module, error) {
 	var modules []Module
@@ -115,6 +128,18 @@ func (r *ModuleRepository) GetDependencies(ctx context.Context, moduleID uint) (
 	return deps, err
 }
 
+// FindDependents gets installed modules that depend on the named module
+func (r *ModuleRepository) FindDependents(ctx context.Context, moduleName string) ([]ModuleDependent, error) {
+	var dependents []ModuleDependent
+	err := r.db.WithContext(ctx).Model(&ModuleDependency{}).
+		Select("modules.name AS name, module_dependencies.version AS version, module_dependencies.required AS required").
+		Joins("JOIN modules ON modules.id = module_dependencies.module_id AND modules.deleted_at IS NULL").
+		Where("module_dependencies.depends_on_module = ?", moduleName).
+		Order("modules.name").
+		Scan(&dependents).Error
+	return dependents, err
+}
+
 // CreateDependency creates a module dependency
 func (r *ModuleRepository) CreateDependency(ctx context.Context, dep *ModuleDependency) error {
 	return r.db.WithContext(ctx).Create(dep).Error
@@ -125,6 +150,11 @@ func (r *ModuleRepository) DeleteDependencies(ctx context.Context, moduleID uint
 	return r.db.WithContext(ctx).Where("module_id = ?", moduleID).Delete(&ModuleDependency{}).Error
 }
 
+// Purge permanently deletes a module, freeing its unique name
+func (r *ModuleRepository) Purge(ctx context.Context, moduleID uint) error {
+	return r.db.WithContext(ctx).Unscoped().Delete(&Module{}, moduleID).Error
+}
+
 // GetMigrations gets module migration history
 func (r *ModuleRepository) GetMigrations(ctx context.Context, moduleID uint) ([]ModuleMigration, error) {
 	var migrations []ModuleMigration
@@ -144,6 +174,23 @@ func (r *ModuleRepository) GetLastBatch(ctx context.Context) (int, error) {
 	return batch, err
 }
 
+// HasRunSeeder reports whether a module seeder has already run
+func (r *ModuleRepository) HasRunSeeder(ctx context.Context, moduleName, seeder string) (bool, error) {
+	return database.HasSeederRun(r.db.WithContext(ctx), seederRunName(moduleName, seeder))
+}
+
+// RecordSeederRun records that a module seeder has run
+func (r *ModuleRepository) RecordSeederRun(ctx context.Context, moduleName, seeder string) error {
+	return database.RecordSeederRun(r.db.WithContext(ctx), seederRunName(moduleName, seeder))
+}
+
+// seederRunName returns the name a module seeder's runs are recorded under
+// in seeder_runs. It is keyed by module name so the record survives
+// reinstalls.
+func seederRunName(moduleName, seeder string) string {
+	return "module:" + moduleName + "/" + seeder
+}
+
 // GetModuleWithDependencies gets module with its dependencies
 func (r *ModuleRepository) GetModuleWithDependencies(ctx context.Context, moduleID uint) (*Module, []ModuleDependency, error) {
 	module, err := r.FindByID(ctx, moduleID)
@@ -0,0 +1,120 @@
+package module
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+	"neonexcore/pkg/database"
+	"neonexcore/pkg/errors"
+	"neonexcore/pkg/logger"
+)
+
+// RegisterSeeder registers a seeder for a module. Seeders run in
+// registration order when a module declaring "seeders": true is installed.
+func (m *ModuleManager) RegisterSeeder(moduleName string, seeder database.Seeder) {
+	m.seedersMu.Lock()
+	defer m.seedersMu.Unlock()
+	m.seeders[moduleName] = append(m.seeders[moduleName], seeder)
+}
+
+// SetStrictSeeders makes a failing seeder abort the install instead of
+// only being logged
+func (m *ModuleManager) SetStrictSeeders(strict bool) {
+	m.seedersMu.Lock()
+	defer m.seedersMu.Unlock()
+	m.strictSeeders = strict
+}
+
+// RunSeeders runs the seeders registered for a module. Seeders that already
+// ran for the module are skipped unless force is set. A failed seeder is
+// logged and the rest still run, unless strict mode is enabled.
+//
+// Seeders run in the transaction of ctx (see database.ContextWithTx), or in
+// a new one, each behind a savepoint, so a failed seeder's writes are rolled
+// back without aborting the transaction.
+func (m *ModuleManager) RunSeeders(ctx context.Context, module *Module, force bool) error {
+	m.seedersMu.RLock()
+	seeders := append([]database.Seeder(nil), m.seeders[module.Name]...)
+	strict := m.strictSeeders
+	m.seedersMu.RUnlock()
+
+	if len(seeders) == 0 {
+		m.logger.Info("No seeders registered", logger.Fields{"module": module.Name})
+		return nil
+	}
+
+	tx := database.TxFromContext(ctx, m.db)
+	if _, inTx := tx.Statement.ConnPool.(gorm.TxCommitter); !inTx {
+		return m.txManager.WithTransaction(ctx, func(tx *gorm.DB) error {
+			return m.RunSeeders(database.ContextWithTx(ctx, tx), module, force)
+		})
+	}
+	repo := m.repo.WithTx(tx)
+
+	m.logger.Info("Running seeders", logger.Fields{
+		"module": module.Name,
+		"count":  len(seeders),
+	})
+
+	for i, seeder := range seeders {
+		if !force {
+			ran, err := repo.HasRunSeeder(ctx, module.Name, seeder.Name())
+			if err != nil {
+				return fmt.Errorf("failed to check seeder %s: %w", seeder.Name(), err)
+			}
+			if ran {
+				m.logger.Debug("Skipping seeder that already ran", logger.Fields{
+					"module": module.Name,
+					"seeder": seeder.Name(),
+				})
+				continue
+			}
+		}
+
+		savepoint := fmt.Sprintf("seeder_%d", i)
+		if err := tx.SavePoint(savepoint).Error; err != nil {
+			return fmt.Errorf("failed to create savepoint for seeder %s: %w", seeder.Name(), err)
+		}
+
+		if err := seeder.Run(ctx); err != nil {
+			if strict {
+				return fmt.Errorf("seeder %s failed: %w", seeder.Name(), err)
+			}
+			if rbErr := tx.RollbackTo(savepoint).Error; rbErr != nil {
+				return fmt.Errorf("failed to roll back seeder %s: %w", seeder.Name(), rbErr)
+			}
+			m.logger.Warn("Seeder failed", logger.Fields{
+				"module": module.Name,
+				"seeder": seeder.Name(),
+				"error":  err.Error(),
+			})
+			continue
+		}
+
+		if err := repo.RecordSeederRun(ctx, module.Name, seeder.Name()); err != nil {
+			return fmt.Errorf("failed to record seeder %s: %w", seeder.Name(), err)
+		}
+	}
+
+	return nil
+}
+
+// Seed runs an installed module's seeders. With force, seeders that already
+// ran are run again.
+func (m *ModuleManager) Seed(ctx context.Context, moduleName string, force bool) error {
+	module, err := m.repo.FindByName(ctx, moduleName)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.NewNotFound("Module not found")
+		}
+		return errors.NewInternal(fmt.Sprintf("Failed to find module: %v", err))
+	}
+
+	return m.txManager.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := m.RunSeeders(database.ContextWithTx(ctx, tx), module, force); err != nil {
+			return errors.NewInternal(fmt.Sprintf("Failed to run seeders: %v", err))
+		}
+		return nil
+	})
+}
@@ -0,0 +1,146 @@
+package module
+
+import (
+	"context"
+	stderrors "errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"neonexcore/pkg/database"
+)
+
+// seedItem is a row written by testSeeder
+type seedItem struct {
+	ID     uint
+	Seeder string
+}
+
+// testSeeder writes a seedItem in the seeding transaction, then fails with err
+type testSeeder struct {
+	manager *ModuleManager
+	name    string
+	err     error
+	runs    int
+}
+
+func (s *testSeeder) Name() string { return s.name }
+
+func (s *testSeeder) Run(ctx context.Context) error {
+	s.runs++
+	if err := database.TxFromContext(ctx, s.manager.db).Create(&seedItem{Seeder: s.name}).Error; err != nil {
+		return err
+	}
+	return s.err
+}
+
+// newSeederTestManager returns a manager with the seed_items table and a
+// "blog" module declaring seeders in its modules directory
+func newSeederTestManager(t *testing.T) (*ModuleManager, string) {
+	t.Helper()
+
+	manager, _ := newTestManager(t)
+	if err := manager.db.AutoMigrate(&seedItem{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	modulePath := filepath.Join(manager.modulesDir, "blog")
+	if err := os.MkdirAll(modulePath, 0o755); err != nil {
+		t.Fatalf("create module dir: %v", err)
+	}
+	metadata := `{"name":"blog","display_name":"Blog","version":"1.0.0","seeders":true}`
+	if err := os.WriteFile(filepath.Join(modulePath, "module.json"), []byte(metadata), 0o644); err != nil {
+		t.Fatalf("write module.json: %v", err)
+	}
+	return manager, modulePath
+}
+
+// seededBy returns the seeders whose rows were committed, in order
+func seededBy(t *testing.T, manager *ModuleManager) []string {
+	t.Helper()
+
+	var seeders []string
+	if err := manager.db.Model(&seedItem{}).Order("id").Pluck("seeder", &seeders).Error; err != nil {
+		t.Fatalf("list seed items: %v", err)
+	}
+	return seeders
+}
+
+func TestSeedersRunOnce(t *testing.T) {
+	manager, modulePath := newSeederTestManager(t)
+	ctx := context.Background()
+	seeder := &testSeeder{manager: manager, name: "posts"}
+	manager.RegisterSeeder("blog", seeder)
+
+	if _, err := manager.Install(ctx, modulePath); err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	if seeder.runs != 1 {
+		t.Fatalf("seeder ran %d times on install, want 1", seeder.runs)
+	}
+
+	// Reinstalling doesn't seed again
+	if err := manager.Uninstall(ctx, "blog", false); err != nil {
+		t.Fatalf("Uninstall: %v", err)
+	}
+	if _, err := manager.Install(ctx, modulePath); err != nil {
+		t.Fatalf("reinstall: %v", err)
+	}
+	if err := manager.Seed(ctx, "blog", false); err != nil {
+		t.Fatalf("Seed: %v", err)
+	}
+	if seeder.runs != 1 {
+		t.Fatalf("seeder ran %d times, want it skipped after the first run", seeder.runs)
+	}
+
+	if err := manager.Seed(ctx, "blog", true); err != nil {
+		t.Fatalf("forced Seed: %v", err)
+	}
+	if seeder.runs != 2 || len(seededBy(t, manager)) != 2 {
+		t.Fatalf("seeder ran %d times, want forced seeding to run it again", seeder.runs)
+	}
+}
+
+func TestFailedSeederDoesNotAbortInstall(t *testing.T) {
+	manager, modulePath := newSeederTestManager(t)
+	ctx := context.Background()
+	failing := &testSeeder{manager: manager, name: "broken", err: stderrors.New("bad data")}
+	posts := &testSeeder{manager: manager, name: "posts"}
+	manager.RegisterSeeder("blog", failing)
+	manager.RegisterSeeder("blog", posts)
+
+	if _, err := manager.Install(ctx, modulePath); err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	// The failed seeder's writes were rolled back; the next seeder still ran
+	if got := seededBy(t, manager); len(got) != 1 || got[0] != "posts" {
+		t.Fatalf("committed seed rows from %v, want only posts", got)
+	}
+
+	// A failed seeder isn't recorded, so it's retried
+	if err := manager.Seed(ctx, "blog", false); err != nil {
+		t.Fatalf("Seed: %v", err)
+	}
+	if failing.runs != 2 || posts.runs != 1 {
+		t.Fatalf("runs: broken %d, posts %d; want 2 and 1", failing.runs, posts.runs)
+	}
+}
+
+func TestStrictSeedersAbortInstall(t *testing.T) {
+	manager, modulePath := newSeederTestManager(t)
+	ctx := context.Background()
+	errBadData := stderrors.New("bad data")
+	manager.RegisterSeeder("blog", &testSeeder{manager: manager, name: "posts"})
+	manager.RegisterSeeder("blog", &testSeeder{manager: manager, name: "broken", err: errBadData})
+	manager.SetStrictSeeders(true)
+
+	if _, err := manager.Install(ctx, modulePath); err == nil {
+		t.Fatal("Install succeeded despite a failing seeder in strict mode")
+	}
+	if _, err := manager.repo.FindByName(ctx, "blog"); err == nil {
+		t.Fatal("the module was installed")
+	}
+	if got := seededBy(t, manager); len(got) != 0 {
+		t.Fatalf("committed seed rows from %v, want the whole install rolled back", got)
+	}
+}
@@ -0,0 +1,77 @@
+package notify
+
+import (
+	"context"
+	"sync"
+)
+
+// Message is an email message
+type Message struct {
+	To      []string
+	Subject string
+	Text    string // Plain text body
+	HTML    string // HTML body, sent as an alternative to Text when set
+}
+
+// Mailer sends email
+type Mailer interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// NoopMailer discards every message
+type NoopMailer struct{}
+
+// Send discards the message
+func (NoopMailer) Send(ctx context.Context, msg *Message) error {
+	return nil
+}
+
+// CaptureMailer keeps sent messages in memory instead of delivering them,
+// for tests and local development
+type CaptureMailer struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+// NewCaptureMailer creates a new capturing mailer
+func NewCaptureMailer() *CaptureMailer {
+	return &CaptureMailer{}
+}
+
+// Send records the message
+func (m *CaptureMailer) Send(ctx context.Context, msg *Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	captured := *msg
+	captured.To = append([]string(nil), msg.To...)
+	m.messages = append(m.messages, captured)
+	return nil
+}
+
+// Messages returns the messages sent so far
+func (m *CaptureMailer) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Message(nil), m.messages...)
+}
+
+// Last returns the most recently sent message
+func (m *CaptureMailer) Last() (Message, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.messages) == 0 {
+		return Message{}, false
+	}
+	return m.messages[len(m.messages)-1], true
+}
+
+// Reset forgets all sent messages
+func (m *CaptureMailer) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = nil
+}
@@ -0,0 +1,182 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpTransaction is what a fake SMTP server received
+type smtpTransaction struct {
+	from string
+	to   []string
+	data string
+}
+
+// newFakeSMTPServer accepts one plain SMTP session and returns its port and
+// a channel receiving the transaction
+func newFakeSMTPServer(t *testing.T) (int, <-chan smtpTransaction) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	received := make(chan smtpTransaction, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		tp := textproto.NewConn(conn)
+		var tx smtpTransaction
+		tp.PrintfLine("220 localhost ESMTP")
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+			switch verb {
+			case "EHLO", "HELO":
+				tp.PrintfLine("250 localhost")
+			case "MAIL":
+				tx.from = strings.TrimPrefix(line, "MAIL FROM:")
+				tp.PrintfLine("250 OK")
+			case "RCPT":
+				tx.to = append(tx.to, strings.TrimPrefix(line, "RCPT TO:"))
+				tp.PrintfLine("250 OK")
+			case "DATA":
+				tp.PrintfLine("354 Go ahead")
+				data, err := tp.ReadDotBytes()
+				if err != nil {
+					return
+				}
+				tx.data = string(data)
+				tp.PrintfLine("250 OK")
+			case "QUIT":
+				tp.PrintfLine("221 Bye")
+				received <- tx
+				return
+			default:
+				tp.PrintfLine("502 Not implemented")
+			}
+		}
+	}()
+
+	return ln.Addr().(*net.TCPAddr).Port, received
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	port, received := newFakeSMTPServer(t)
+	mailer := NewSMTPMailer(&SMTPConfig{
+		Host:    "127.0.0.1",
+		Port:    port,
+		From:    "Neonex <no-reply@example.com>",
+		TLS:     TLSNone,
+		Timeout: 5 * time.Second,
+	})
+
+	err := mailer.Send(context.Background(), &Message{
+		To:      []string{"Jane <jane@example.com>", "ops@example.com"},
+		Subject: "Réinitialiser",
+		Text:    "Open https://example.com/reset?token=abc",
+		HTML:    `<a href="https://example.com/reset?token=abc">Reset</a>`,
+	})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	var tx smtpTransaction
+	select {
+	case tx = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the server received no message")
+	}
+	if tx.from != "<no-reply@example.com>" {
+		t.Fatalf("MAIL FROM %s", tx.from)
+	}
+	if strings.Join(tx.to, ",") != "<jane@example.com>,<ops@example.com>" {
+		t.Fatalf("RCPT TO %v", tx.to)
+	}
+
+	msg, err := mail.ReadMessage(bufio.NewReader(strings.NewReader(tx.data)))
+	if err != nil {
+		t.Fatalf("parse message: %v", err)
+	}
+	subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if subject != "Réinitialiser" {
+		t.Fatalf("Subject = %q", subject)
+	}
+	if to := msg.Header.Get("To"); to != `"Jane" <jane@example.com>, <ops@example.com>` {
+		t.Fatalf("To = %q", to)
+	}
+	if !strings.HasSuffix(msg.Header.Get("Message-ID"), "@example.com>") {
+		t.Fatalf("Message-ID = %q", msg.Header.Get("Message-ID"))
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q", msg.Header.Get("Content-Type"))
+	}
+	parts := multipart.NewReader(msg.Body, params["boundary"])
+	var bodies []string
+	for {
+		part, err := parts.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("read part: %v", err)
+		}
+		// NextPart decodes the quoted-printable parts
+		body, _ := io.ReadAll(part)
+		bodies = append(bodies, part.Header.Get("Content-Type")+": "+string(body))
+	}
+	want := []string{
+		"text/plain; charset=utf-8: Open https://example.com/reset?token=abc",
+		`text/html; charset=utf-8: <a href="https://example.com/reset?token=abc">Reset</a>`,
+	}
+	if strings.Join(bodies, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("parts:\n%s\nwant:\n%s", strings.Join(bodies, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestSMTPMailerRejectsInvalidMessages(t *testing.T) {
+	// Nothing listens on the port; invalid messages fail before connecting
+	mailer := NewSMTPMailer(&SMTPConfig{Host: "127.0.0.1", Port: 1, From: "no-reply@example.com", TLS: TLSNone})
+
+	tests := []struct {
+		name string
+		msg  *Message
+	}{
+		{"no recipients", &Message{Subject: "Hi", Text: "x"}},
+		{"invalid recipient", &Message{To: []string{"not an address"}, Subject: "Hi", Text: "x"}},
+		{"header injection", &Message{To: []string{"jane@example.com"}, Subject: "Hi\r\nBcc: evil@example.com", Text: "x"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := mailer.Send(context.Background(), tt.msg)
+			if err == nil || strings.Contains(err.Error(), "smtp connect") {
+				t.Fatalf("Send = %v, want a validation error", err)
+			}
+		})
+	}
+
+	bad := NewSMTPMailer(&SMTPConfig{Host: "127.0.0.1", Port: 1, From: "not an address", TLS: TLSNone})
+	if err := bad.Send(context.Background(), &Message{To: []string{"jane@example.com"}}); err == nil || !strings.Contains(err.Error(), "sender") {
+		t.Fatalf("Send = %v, want an invalid sender error", err)
+	}
+}
@@ -0,0 +1,139 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+)
+
+// Built-in templates
+const (
+	TemplatePasswordReset     = "password_reset"
+	TemplateEmailVerification = "email_verification"
+)
+
+// TokenEmailData is the data of the built-in templates
+type TokenEmailData struct {
+	AppName   string
+	Name      string // Recipient's name
+	Link      string // Link carrying the token
+	Token     string
+	ExpiresIn string // How long the link stays valid, e.g. "1 hour"
+}
+
+// emailTemplate is a parsed template
+type emailTemplate struct {
+	subject *texttemplate.Template
+	text    *texttemplate.Template
+	html    *htmltemplate.Template // Optional
+}
+
+// Renderer renders named email templates into messages. Subjects and text
+// bodies use text/template; HTML bodies use html/template so data is escaped.
+type Renderer struct {
+	mu        sync.RWMutex
+	templates map[string]*emailTemplate
+}
+
+// NewRenderer creates a renderer with the built-in templates
+func NewRenderer() *Renderer {
+	r := &Renderer{templates: make(map[string]*emailTemplate)}
+	for name, tpl := range defaultTemplates {
+		if err := r.Register(name, tpl.subject, tpl.text, tpl.html); err != nil {
+			panic(fmt.Sprintf("notify: invalid built-in template %s: %v", name, err))
+		}
+	}
+	return r
+}
+
+// Register adds a template, replacing any template with the same name. html
+// may be empty for text-only emails.
+func (r *Renderer) Register(name, subject, text, html string) error {
+	tpl := &emailTemplate{}
+
+	var err error
+	if tpl.subject, err = texttemplate.New(name + ".subject").Parse(subject); err != nil {
+		return err
+	}
+	if tpl.text, err = texttemplate.New(name + ".text").Parse(text); err != nil {
+		return err
+	}
+	if html != "" {
+		if tpl.html, err = htmltemplate.New(name + ".html").Parse(html); err != nil {
+			return err
+		}
+	}
+
+	r.mu.Lock()
+	r.templates[name] = tpl
+	r.mu.Unlock()
+	return nil
+}
+
+// Render renders a template into a message addressed to the recipients
+func (r *Renderer) Render(name string, data interface{}, to ...string) (*Message, error) {
+	r.mu.RLock()
+	tpl, ok := r.templates[name]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("email template %q not found", name)
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := tpl.subject.Execute(&subject, data); err != nil {
+		return nil, err
+	}
+	if err := tpl.text.Execute(&text, data); err != nil {
+		return nil, err
+	}
+	if tpl.html != nil {
+		if err := tpl.html.Execute(&html, data); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Message{
+		To:      to,
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
+
+var defaultTemplates = map[string]struct{ subject, text, html string }{
+	TemplatePasswordReset: {
+		subject: `Reset your {{.AppName}} password`,
+		text: `Hi {{.Name}},
+
+We received a request to reset your {{.AppName}} password. Open the link below to choose a new one:
+
+{{.Link}}
+
+The link expires in {{.ExpiresIn}}. If you didn't ask to reset your password, you can ignore this email.
+`,
+		html: `<p>Hi {{.Name}},</p>
+<p>We received a request to reset your {{.AppName}} password. Click the link below to choose a new one:</p>
+<p><a href="{{.Link}}">Reset password</a></p>
+<p>The link expires in {{.ExpiresIn}}. If you didn't ask to reset your password, you can ignore this email.</p>
+`,
+	},
+	TemplateEmailVerification: {
+		subject: `Verify your {{.AppName}} email address`,
+		text: `Hi {{.Name}},
+
+Please confirm your email address by opening the link below:
+
+{{.Link}}
+
+The link expires in {{.ExpiresIn}}. If you didn't create an account, you can ignore this email.
+`,
+		html: `<p>Hi {{.Name}},</p>
+<p>Please confirm your email address by clicking the link below:</p>
+<p><a href="{{.Link}}">Verify email address</a></p>
+<p>The link expires in {{.ExpiresIn}}. If you didn't create an account, you can ignore this email.</p>
+`,
+	},
+}
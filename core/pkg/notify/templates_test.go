@@ -0,0 +1,102 @@
+package notify
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRenderPasswordReset(t *testing.T) {
+	msg, err := NewRenderer().Render(TemplatePasswordReset, TokenEmailData{
+		AppName:   "Neonex",
+		Name:      "<Jane>",
+		Link:      "https://app.example.com/reset-password?token=abc&x=1",
+		Token:     "abc",
+		ExpiresIn: "1 hour",
+	}, "jane@example.com")
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	if len(msg.To) != 1 || msg.To[0] != "jane@example.com" {
+		t.Fatalf("To = %v", msg.To)
+	}
+	if msg.Subject != "Reset your Neonex password" {
+		t.Fatalf("Subject = %q", msg.Subject)
+	}
+	if !strings.Contains(msg.Text, "Hi <Jane>,") || !strings.Contains(msg.Text, "https://app.example.com/reset-password?token=abc&x=1") {
+		t.Fatalf("Text = %q", msg.Text)
+	}
+	if !strings.Contains(msg.Text, "expires in 1 hour") {
+		t.Fatalf("Text = %q, want the expiry", msg.Text)
+	}
+	// The HTML body escapes the data
+	if !strings.Contains(msg.HTML, "Hi &lt;Jane&gt;,") || !strings.Contains(msg.HTML, `href="https://app.example.com/reset-password?token=abc&amp;x=1"`) {
+		t.Fatalf("HTML = %q", msg.HTML)
+	}
+}
+
+func TestRenderEmailVerification(t *testing.T) {
+	msg, err := NewRenderer().Render(TemplateEmailVerification, TokenEmailData{AppName: "Neonex", Name: "Jane", Link: "https://x/verify/abc"}, "jane@example.com")
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if msg.Subject != "Verify your Neonex email address" || !strings.Contains(msg.Text, "https://x/verify/abc") {
+		t.Fatalf("message = %+v", msg)
+	}
+}
+
+func TestRendererRegister(t *testing.T) {
+	r := NewRenderer()
+	if _, err := r.Render("welcome", nil); err == nil {
+		t.Fatal("rendered an unknown template")
+	}
+	if err := r.Register("welcome", "Welcome {{.", "", ""); err == nil {
+		t.Fatal("registered an invalid template")
+	}
+
+	// Text-only templates have no HTML body, and subjects are trimmed
+	if err := r.Register("welcome", " Welcome, {{.}}\n", "Hello {{.}}", ""); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	msg, err := r.Render("welcome", "Jane", "jane@example.com", "ops@example.com")
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if msg.Subject != "Welcome, Jane" || msg.Text != "Hello Jane" || msg.HTML != "" || len(msg.To) != 2 {
+		t.Fatalf("message = %+v", msg)
+	}
+
+	// Replacing a built-in template
+	if err := r.Register(TemplatePasswordReset, "Custom reset", "{{.Link}}", ""); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if msg, _ := r.Render(TemplatePasswordReset, TokenEmailData{Link: "l"}); msg.Subject != "Custom reset" {
+		t.Fatalf("Subject = %q, want the replaced template", msg.Subject)
+	}
+}
+
+func TestCaptureMailer(t *testing.T) {
+	m := NewCaptureMailer()
+	if _, ok := m.Last(); ok {
+		t.Fatal("Last returned a message before any was sent")
+	}
+
+	to := []string{"jane@example.com"}
+	m.Send(context.Background(), &Message{To: to, Subject: "First"})
+	m.Send(context.Background(), &Message{To: to, Subject: "Second"})
+	// Captured messages don't share the sender's slices
+	to[0] = "changed@example.com"
+
+	last, ok := m.Last()
+	if !ok || last.Subject != "Second" || last.To[0] != "jane@example.com" {
+		t.Fatalf("Last = %+v", last)
+	}
+	if got := len(m.Messages()); got != 2 {
+		t.Fatalf("%d messages, want 2", got)
+	}
+	m.Reset()
+	if got := len(m.Messages()); got != 0 {
+		t.Fatalf("%d messages after Reset", got)
+	}
+}
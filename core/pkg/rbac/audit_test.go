@@ -0,0 +1,186 @@
+package rbac
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"neonexcore/pkg/events"
+)
+
+// recordedEvent is an RBAC event taken from the outbox
+type recordedEvent struct {
+	Name string
+	Data events.RBACEvent
+}
+
+// takeEvents returns the events enqueued so far, in order, and clears the
+// outbox
+func takeEvents(t *testing.T, m *Manager) []recordedEvent {
+	t.Helper()
+
+	var messages []events.OutboxMessage
+	if err := m.db.Order("id").Find(&messages).Error; err != nil {
+		t.Fatalf("load outbox: %v", err)
+	}
+	recorded := make([]recordedEvent, len(messages))
+	for i, message := range messages {
+		recorded[i].Name = message.EventName
+		if err := json.Unmarshal([]byte(message.Payload), &recorded[i].Data); err != nil {
+			t.Fatalf("decode %s payload: %v", message.EventName, err)
+		}
+	}
+	m.db.Where("1 = 1").Delete(&events.OutboxMessage{})
+	return recorded
+}
+
+func TestMutationsEmitEvents(t *testing.T) {
+	m := newTestManager(t)
+	ctx := WithActor(context.Background(), 7)
+	editor := createTestRole(t, m, "editor")
+	author := createTestRole(t, m, "author")
+	edit := createTestPermission(t, m, "posts.edit")
+	publish := createTestPermission(t, m, "posts.publish")
+	takeEvents(t, m)
+
+	const user = 1
+	assigned := func(roleID uint) recordedEvent {
+		return recordedEvent{events.EventRBACRoleAssigned, events.RBACEvent{ActorID: 7, UserID: user, RoleID: roleID}}
+	}
+	removed := func(roleID uint) recordedEvent {
+		return recordedEvent{events.EventRBACRoleRemoved, events.RBACEvent{ActorID: 7, UserID: user, RoleID: roleID}}
+	}
+	granted := func(permissionID uint) recordedEvent {
+		return recordedEvent{events.EventRBACPermissionAssigned, events.RBACEvent{ActorID: 7, UserID: user, PermissionID: permissionID}}
+	}
+	revoked := func(permissionID uint) recordedEvent {
+		return recordedEvent{events.EventRBACPermissionRemoved, events.RBACEvent{ActorID: 7, UserID: user, PermissionID: permissionID}}
+	}
+	attached := func(permissionID uint) recordedEvent {
+		return recordedEvent{events.EventRBACRolePermissionAttached, events.RBACEvent{ActorID: 7, RoleID: editor.ID, PermissionID: permissionID}}
+	}
+	detached := func(permissionID uint) recordedEvent {
+		return recordedEvent{events.EventRBACRolePermissionDetached, events.RBACEvent{ActorID: 7, RoleID: editor.ID, PermissionID: permissionID}}
+	}
+
+	// Each step runs against the state the previous steps left
+	tests := []struct {
+		name   string
+		mutate func() error
+		want   []recordedEvent
+	}{
+		{"assign role", func() error { return m.AssignRole(ctx, user, editor.ID) }, []recordedEvent{assigned(editor.ID)}},
+		{"assign held role", func() error { return m.AssignRole(ctx, user, editor.ID) }, nil},
+		{"remove role", func() error { return m.RemoveRole(ctx, user, editor.ID) }, []recordedEvent{removed(editor.ID)}},
+		{"remove missing role", func() error { return m.RemoveRole(ctx, user, editor.ID) }, nil},
+		{"assign roles", func() error { return m.AssignRoles(ctx, user, []uint{editor.ID, author.ID}) }, []recordedEvent{assigned(editor.ID), assigned(author.ID)}},
+		{"sync roles", func() error { return m.SyncUserRoles(ctx, user, []uint{author.ID}) }, []recordedEvent{removed(editor.ID)}},
+		{"assign permission", func() error { return m.AssignPermission(ctx, user, edit.ID) }, []recordedEvent{granted(edit.ID)}},
+		{"remove permission", func() error { return m.RemovePermission(ctx, user, edit.ID) }, []recordedEvent{revoked(edit.ID)}},
+		{"assign permissions", func() error { return m.AssignPermissions(ctx, user, []uint{edit.ID, publish.ID}) }, []recordedEvent{granted(edit.ID), granted(publish.ID)}},
+		{"sync permissions", func() error { return m.SyncUserPermissions(ctx, user, []uint{publish.ID}) }, []recordedEvent{revoked(edit.ID)}},
+		{"revoke all", func() error { return m.RevokeAll(ctx, user) }, []recordedEvent{removed(author.ID), revoked(publish.ID)}},
+		{"attach permission", func() error { return m.AttachPermissionToRole(ctx, editor.ID, edit.ID) }, []recordedEvent{attached(edit.ID)}},
+		{"detach permission", func() error { return m.DetachPermissionFromRole(ctx, editor.ID, edit.ID) }, []recordedEvent{detached(edit.ID)}},
+		{"sync role permissions", func() error { return m.SyncRolePermissions(ctx, editor.ID, []uint{publish.ID}) }, []recordedEvent{attached(publish.ID)}},
+		{"resync role permissions", func() error { return m.SyncRolePermissions(ctx, editor.ID, []uint{edit.ID}) }, []recordedEvent{detached(publish.ID), attached(edit.ID)}},
+	}
+	for _, tt := range tests {
+		if err := tt.mutate(); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		got := takeEvents(t, m)
+		if len(got) != len(tt.want) {
+			t.Fatalf("%s: events = %+v, want %+v", tt.name, got, tt.want)
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Fatalf("%s: events = %+v, want %+v", tt.name, got, tt.want)
+			}
+		}
+	}
+
+	// A failed batch publishes nothing
+	if err := m.AssignRoles(ctx, user, []uint{editor.ID, 999}); err == nil {
+		t.Fatal("assigned a missing role")
+	}
+	if got := takeEvents(t, m); len(got) != 0 {
+		t.Fatalf("failed batch: events = %+v, want none", got)
+	}
+
+	// Deleting a role removes it from each holder
+	if err := m.AssignRole(context.Background(), 2, editor.ID); err != nil {
+		t.Fatalf("AssignRole: %v", err)
+	}
+	takeEvents(t, m)
+	if err := m.DeleteRole(ctx, editor.ID); err != nil {
+		t.Fatalf("DeleteRole: %v", err)
+	}
+	want := recordedEvent{events.EventRBACRoleRemoved, events.RBACEvent{ActorID: 7, UserID: 2, RoleID: editor.ID}}
+	if got := takeEvents(t, m); len(got) != 1 || got[0] != want {
+		t.Fatalf("delete role: events = %+v, want %+v", got, want)
+	}
+}
+
+func TestChangesWithoutActorAreAttributedToSystem(t *testing.T) {
+	m := newTestManager(t)
+	role := createTestRole(t, m, "editor")
+	takeEvents(t, m)
+
+	if err := m.AssignRole(context.Background(), 1, role.ID); err != nil {
+		t.Fatalf("AssignRole: %v", err)
+	}
+	if got := takeEvents(t, m); len(got) != 1 || got[0].Data.ActorID != 0 {
+		t.Fatalf("events = %+v, want one by actor 0", got)
+	}
+	if actor := ActorFromContext(WithActor(context.Background(), 3)); actor != 3 {
+		t.Fatalf("ActorFromContext = %d, want 3", actor)
+	}
+}
+
+func TestChangeHistory(t *testing.T) {
+	m := newTestManager(t)
+	ctx := WithActor(context.Background(), 7)
+	editor := createTestRole(t, m, "editor")
+	author := createTestRole(t, m, "author")
+
+	// Changes before history is enabled aren't recorded
+	if err := m.AssignRole(ctx, 1, author.ID); err != nil {
+		t.Fatalf("AssignRole: %v", err)
+	}
+	if err := m.EnableHistory(); err != nil {
+		t.Fatalf("EnableHistory: %v", err)
+	}
+
+	if err := m.SyncUserRoles(ctx, 1, []uint{editor.ID}); err != nil {
+		t.Fatalf("SyncUserRoles: %v", err)
+	}
+	if err := m.AssignRole(ctx, 2, author.ID); err != nil {
+		t.Fatalf("AssignRole: %v", err)
+	}
+	// A rolled back change leaves no history
+	m.AssignRoles(ctx, 1, []uint{author.ID, 999})
+
+	changes, total, err := m.ListChanges(context.Background(), 1, 0, 0)
+	if err != nil {
+		t.Fatalf("ListChanges: %v", err)
+	}
+	if total != 2 || len(changes) != 2 {
+		t.Fatalf("changes = %+v, want the sync's removal and assignment", changes)
+	}
+	// Newest first
+	if changes[0].Action != events.EventRBACRoleAssigned || changes[0].RoleID != editor.ID ||
+		changes[1].Action != events.EventRBACRoleRemoved || changes[1].RoleID != author.ID {
+		t.Fatalf("changes = %+v, want the assignment of editor after the removal of author", changes)
+	}
+	for _, change := range changes {
+		if change.ActorID != 7 || change.UserID != 1 {
+			t.Fatalf("change = %+v, want actor 7 and user 1", change)
+		}
+	}
+
+	all, total, _ := m.ListChanges(context.Background(), 0, 0, 1)
+	if total != 3 || len(all) != 1 || all[0].UserID != 2 {
+		t.Fatalf("all changes = %+v of %d, want the newest of 3", all, total)
+	}
+}
@@ -0,0 +1,108 @@
+package rbac
+
+import (
+	stderrors "errors"
+	"strconv"
+	"strings"
+
+	"neonexcore/pkg/api"
+	"neonexcore/pkg/errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Controller exposes the role and permission catalog over HTTP, e.g. for
+// role-editing screens. It doesn't authorize requests itself; mount it behind
+// authentication and a permission check.
+type Controller struct {
+	manager *Manager
+}
+
+// NewController creates a new RBAC controller
+func NewController(manager *Manager) *Controller {
+	return &Controller{
+		manager: manager,
+	}
+}
+
+// ListRoles handles GET /roles (paginated, with permission counts)
+func (c *Controller) ListRoles(ctx *fiber.Ctx) error {
+	pagination, err := api.ParsePagination(ctx)
+	if err != nil {
+		return err
+	}
+
+	offset := (pagination.Page - 1) * pagination.Limit
+	roles, total, err := c.manager.ListRoles(ctx.UserContext(), offset, pagination.Limit)
+	if err != nil {
+		return errors.NewInternal("Failed to list roles").WithError(err)
+	}
+
+	return api.Paginated(ctx, roles, pagination.Page, pagination.Limit, total)
+}
+
+// GetRole handles GET /roles/:id
+func (c *Controller) GetRole(ctx *fiber.Ctx) error {
+	id, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	if err != nil {
+		return errors.NewBadRequest("Invalid role ID")
+	}
+
+	role, err := c.manager.GetRole(ctx.UserContext(), uint(id))
+	if stderrors.Is(err, ErrRoleNotFound) {
+		return errors.NewNotFound("Role not found")
+	}
+	if err != nil {
+		return errors.NewInternal("Failed to get role").WithError(err)
+	}
+
+	return api.Success(ctx, role)
+}
+
+// ListPermissions handles GET /permissions
+// (optional module, category and search filters, paginated)
+func (c *Controller) ListPermissions(ctx *fiber.Ctx) error {
+	return c.listPermissions(ctx, PermissionFilter{
+		Module:   ctx.Query("module"),
+		Category: ctx.Query("category"),
+		Search:   strings.TrimSpace(ctx.Query("search")),
+	})
+}
+
+// SearchPermissions handles GET /permissions/search?q= (matches name or
+// slug, paginated)
+func (c *Controller) SearchPermissions(ctx *fiber.Ctx) error {
+	q := strings.TrimSpace(ctx.Query("q"))
+	if q == "" {
+		return errors.NewBadRequest("q is required")
+	}
+
+	return c.listPermissions(ctx, PermissionFilter{Search: q})
+}
+
+// RegisterRoutes registers RBAC catalog routes
+func (c *Controller) RegisterRoutes(router fiber.Router) {
+	roles := router.Group("/roles")
+	roles.Get("/", c.ListRoles)
+	roles.Get("/:id", c.GetRole)
+
+	permissions := router.Group("/permissions")
+	permissions.Get("/", c.ListPermissions)
+	permissions.Get("/search", c.SearchPermissions)
+}
+
+// listPermissions responds with a page of permissions matching the filter
+func (c *Controller) listPermissions(ctx *fiber.Ctx, filter PermissionFilter) error {
+	pagination, err := api.ParsePagination(ctx)
+	if err != nil {
+		return err
+	}
+
+	offset := (pagination.Page - 1) * pagination.Limit
+	permissions, total, err := c.manager.ListPermissions(ctx.UserContext(), filter, offset, pagination.Limit)
+	if err != nil {
+		return errors.NewInternal("Failed to list permissions").WithError(err)
+	}
+
+	return api.Paginated(ctx, permissions, pagination.Page, pagination.Limit, total)
+}
@@ -0,0 +1,167 @@
+package rbac
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"neonexcore/pkg/api"
+	"neonexcore/pkg/errors"
+	"neonexcore/pkg/logger"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// newCatalogTestApp seeds permissions in the posts and users modules, an
+// editor role with two posts permissions and an empty viewer role
+func newCatalogTestApp(t *testing.T) (*fiber.App, *Manager) {
+	t.Helper()
+	ctx := context.Background()
+
+	m := newTestManager(t)
+	permissions := []*Permission{
+		{Name: "Edit posts", Slug: "posts.edit", Module: "posts", Category: "content"},
+		{Name: "Publish posts", Slug: "posts.publish", Module: "posts", Category: "content"},
+		{Name: "Post settings", Slug: "posts.settings", Module: "posts", Category: "settings"},
+		{Name: "Manage users", Slug: "users.manage", Module: "users", Category: "admin"},
+		{Name: "Discount_100%", Slug: "users.discount", Module: "users", Category: "admin"},
+	}
+	for _, permission := range permissions {
+		if err := m.CreatePermission(ctx, permission); err != nil {
+			t.Fatalf("CreatePermission %s: %v", permission.Slug, err)
+		}
+	}
+
+	editor := createTestRole(t, m, "editor")
+	if err := m.SyncRolePermissions(ctx, editor.ID, []uint{permissions[1].ID, permissions[0].ID}); err != nil {
+		t.Fatalf("SyncRolePermissions: %v", err)
+	}
+	createTestRole(t, m, "viewer")
+
+	app := fiber.New(fiber.Config{ErrorHandler: errors.ErrorHandler(logger.Default())})
+	NewController(m).RegisterRoutes(app)
+	return app, m
+}
+
+// getCatalog sends a GET request and decodes the response envelope into data
+func getCatalog(t *testing.T, app *fiber.App, path string, data interface{}) (int, *api.Meta) {
+	t.Helper()
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, path, nil))
+	if err != nil {
+		t.Fatalf("GET %s: %v", path, err)
+	}
+	envelope := struct {
+		Data interface{} `json:"data"`
+		Meta *api.Meta   `json:"meta"`
+	}{Data: data}
+	json.NewDecoder(resp.Body).Decode(&envelope)
+	return resp.StatusCode, envelope.Meta
+}
+
+func TestControllerListRoles(t *testing.T) {
+	app, _ := newCatalogTestApp(t)
+
+	var roles []RoleSummary
+	status, meta := getCatalog(t, app, "/roles", &roles)
+	if status != fiber.StatusOK || meta == nil || meta.Total != 2 || len(roles) != 2 {
+		t.Fatalf("list roles: status %d, meta %+v, roles %+v; want 2 roles", status, meta, roles)
+	}
+	if roles[0].Slug != "editor" || roles[0].PermissionsCount != 2 || roles[1].Slug != "viewer" || roles[1].PermissionsCount != 0 {
+		t.Fatalf("roles = %+v, want editor with 2 permissions and viewer with none", roles)
+	}
+
+	roles = nil
+	_, meta = getCatalog(t, app, "/roles?limit=1&page=2", &roles)
+	if len(roles) != 1 || roles[0].Slug != "viewer" || meta.Total != 2 || meta.TotalPages != 2 {
+		t.Fatalf("second page = %+v, meta %+v; want viewer", roles, meta)
+	}
+}
+
+func TestControllerListPermissions(t *testing.T) {
+	app, _ := newCatalogTestApp(t)
+
+	tests := []struct {
+		query     string
+		wantTotal int64
+		wantSlugs []string
+	}{
+		{"", 5, []string{"posts.edit", "posts.publish", "posts.settings", "users.discount", "users.manage"}},
+		{"?module=posts", 3, []string{"posts.edit", "posts.publish", "posts.settings"}},
+		{"?module=posts&category=content", 2, []string{"posts.edit", "posts.publish"}},
+		{"?module=billing", 0, nil},
+		{"?search=PUBLISH", 1, []string{"posts.publish"}},
+		{"?module=posts&limit=2&page=2", 3, []string{"posts.settings"}},
+	}
+	for _, tt := range tests {
+		var permissions []Permission
+		status, meta := getCatalog(t, app, "/permissions"+tt.query, &permissions)
+		if status != fiber.StatusOK || meta == nil || meta.Total != tt.wantTotal || len(permissions) != len(tt.wantSlugs) {
+			t.Fatalf("list%s: status %d, meta %+v, %d permissions; want %d of %d", tt.query, status, meta, len(permissions), len(tt.wantSlugs), tt.wantTotal)
+		}
+		for i, slug := range tt.wantSlugs {
+			if permissions[i].Slug != slug {
+				t.Fatalf("list%s: permission %d = %s, want %s", tt.query, i, permissions[i].Slug, slug)
+			}
+		}
+	}
+}
+
+func TestControllerSearchPermissions(t *testing.T) {
+	app, _ := newCatalogTestApp(t)
+
+	tests := []struct {
+		query     string
+		wantSlugs []string
+	}{
+		{"users.", []string{"users.discount", "users.manage"}},
+		{"Manage", []string{"users.manage"}},
+		// LIKE wildcards in the query match literally
+		{"_100%25", []string{"users.discount"}},
+		{"%25", []string{"users.discount"}},
+		{"s_e", nil},
+	}
+	for _, tt := range tests {
+		var permissions []Permission
+		status, _ := getCatalog(t, app, "/permissions/search?q="+tt.query, &permissions)
+		if status != fiber.StatusOK || len(permissions) != len(tt.wantSlugs) {
+			t.Fatalf("search %q: status %d, permissions %+v; want %v", tt.query, status, permissions, tt.wantSlugs)
+		}
+		for i, slug := range tt.wantSlugs {
+			if permissions[i].Slug != slug {
+				t.Fatalf("search %q: permission %d = %s, want %s", tt.query, i, permissions[i].Slug, slug)
+			}
+		}
+	}
+
+	if status, _ := getCatalog(t, app, "/permissions/search?q=%20", nil); status != fiber.StatusBadRequest {
+		t.Fatalf("search without q: status %d, want 400", status)
+	}
+}
+
+func TestControllerGetRole(t *testing.T) {
+	app, m := newCatalogTestApp(t)
+	editor, err := m.GetRoleBySlug(context.Background(), "editor")
+	if err != nil {
+		t.Fatalf("GetRoleBySlug: %v", err)
+	}
+
+	var role Role
+	status, _ := getCatalog(t, app, "/roles/"+strconv.FormatUint(uint64(editor.ID), 10), &role)
+	if status != fiber.StatusOK || role.Slug != "editor" {
+		t.Fatalf("get role: status %d, role %+v; want editor", status, role)
+	}
+	// Permissions are preloaded in slug order
+	if len(role.Permissions) != 2 || role.Permissions[0].Slug != "posts.edit" || role.Permissions[1].Slug != "posts.publish" {
+		t.Fatalf("role permissions = %+v, want posts.edit and posts.publish", role.Permissions)
+	}
+
+	if status, _ := getCatalog(t, app, "/roles/999", nil); status != fiber.StatusNotFound {
+		t.Fatalf("get missing role: status %d, want 404", status)
+	}
+	if status, _ := getCatalog(t, app, "/roles/editor", nil); status != fiber.StatusBadRequest {
+		t.Fatalf("get role by slug: status %d, want 400", status)
+	}
+}
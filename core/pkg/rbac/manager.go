@@ -2,14 +2,44 @@ package rbac
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
+
+	"neonexcore/pkg/cache"
+	"neonexcore/pkg/database"
+	"neonexcore/pkg/events"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-// Manager handles RBAC operations
+// SuperAdminRole is the slug of the role with full system access
+const SuperAdminRole = "super-admin"
+
+// DefaultCacheTTL is how long a user's effective roles and permissions are memoized
+const DefaultCacheTTL = time.Minute
+
+// ErrRoleNotFound is returned when an assignment names a role that doesn't exist
+var ErrRoleNotFound = errors.New("role not found")
+
+// ErrPermissionNotFound is returned when an assignment names a permission that doesn't exist
+var ErrPermissionNotFound = errors.New("permission not found")
+
+// Manager handles RBAC operations.
+//
+// Every change to role and permission assignments publishes an "rbac.*"
+// event (see events.RBACEvent) through the outbox, in the transaction making
+// the change, and is recorded in the change history when EnableHistory was
+// called. Assignments themselves are hard-deleted on removal; the events and
+// the history are their audit trail. Calls that change nothing publish
+// nothing.
 type Manager struct {
-	db *gorm.DB
+	db       *gorm.DB
+	cache    cache.Cache
+	cacheTTL time.Duration
+	history  bool
 }
 
 // NewManager creates a new RBAC manager
@@ -17,36 +47,190 @@ func NewManager(db *gorm.DB) *Manager {
 	return &Manager{db: db}
 }
 
-// AssignRole assigns a role to a user
+// SetCache enables memoization of each user's effective roles and permissions.
+// A nil cache disables it and every check goes to the database.
+func (m *Manager) SetCache(c cache.Cache, ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+	m.cache = c
+	m.cacheTTL = ttl
+}
+
+// AssignRole assigns a role to a user. Assigning a role the user already
+// holds is a no-op.
 func (m *Manager) AssignRole(ctx context.Context, userID, roleID uint) error {
-	userRole := &UserRole{
-		UserID: userID,
-		RoleID: roleID,
+	err := database.TxFromContext(ctx, m.db).Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&UserRole{UserID: userID, RoleID: roleID})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return m.recordChanges(ctx, tx, roleChanges(events.EventRBACRoleAssigned, userID, []uint{roleID}))
+	})
+	if err != nil {
+		return err
 	}
-	return m.db.WithContext(ctx).Create(userRole).Error
+	m.invalidateUser(ctx, userID)
+	return nil
 }
 
 // RemoveRole removes a role from a user
 func (m *Manager) RemoveRole(ctx context.Context, userID, roleID uint) error {
-	return m.db.WithContext(ctx).
-		Where("user_id = ? AND role_id = ?", userID, roleID).
-		Delete(&UserRole{}).Error
+	err := database.TxFromContext(ctx, m.db).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("user_id = ? AND role_id = ?", userID, roleID).Delete(&UserRole{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return m.recordChanges(ctx, tx, roleChanges(events.EventRBACRoleRemoved, userID, []uint{roleID}))
+	})
+	if err != nil {
+		return err
+	}
+	m.invalidateUser(ctx, userID)
+	return nil
+}
+
+// RevokeAll removes all of a user's roles and direct permissions
+func (m *Manager) RevokeAll(ctx context.Context, userID uint) error {
+	err := database.TxFromContext(ctx, m.db).Transaction(func(tx *gorm.DB) error {
+		roleIDs, err := removeRoles(tx, userID, nil)
+		if err != nil {
+			return err
+		}
+		permissionIDs, err := removePermissions(tx, userID, nil)
+		if err != nil {
+			return err
+		}
+
+		changes := roleChanges(events.EventRBACRoleRemoved, userID, roleIDs)
+		changes = append(changes, permissionChanges(events.EventRBACPermissionRemoved, userID, permissionIDs)...)
+		return m.recordChanges(ctx, tx, changes)
+	})
+	if err != nil {
+		return err
+	}
+	m.invalidateUser(ctx, userID)
+	return nil
 }
 
-// AssignPermission assigns a permission to a user
+// AssignPermission assigns a permission directly to a user. Assigning a
+// permission the user already holds directly is a no-op.
 func (m *Manager) AssignPermission(ctx context.Context, userID, permissionID uint) error {
-	userPermission := &UserPermission{
-		UserID:       userID,
-		PermissionID: permissionID,
+	err := database.TxFromContext(ctx, m.db).Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&UserPermission{UserID: userID, PermissionID: permissionID})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return m.recordChanges(ctx, tx, permissionChanges(events.EventRBACPermissionAssigned, userID, []uint{permissionID}))
+	})
+	if err != nil {
+		return err
 	}
-	return m.db.WithContext(ctx).Create(userPermission).Error
+	m.invalidateUser(ctx, userID)
+	return nil
 }
 
 // RemovePermission removes a permission from a user
 func (m *Manager) RemovePermission(ctx context.Context, userID, permissionID uint) error {
-	return m.db.WithContext(ctx).
-		Where("user_id = ? AND permission_id = ?", userID, permissionID).
-		Delete(&UserPermission{}).Error
+	err := database.TxFromContext(ctx, m.db).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("user_id = ? AND permission_id = ?", userID, permissionID).Delete(&UserPermission{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return m.recordChanges(ctx, tx, permissionChanges(events.EventRBACPermissionRemoved, userID, []uint{permissionID}))
+	})
+	if err != nil {
+		return err
+	}
+	m.invalidateUser(ctx, userID)
+	return nil
+}
+
+// AssignRoles assigns several roles to a user in a single transaction. Roles
+// the user already holds are skipped; if any role doesn't exist nothing is
+// assigned and ErrRoleNotFound is returned.
+func (m *Manager) AssignRoles(ctx context.Context, userID uint, roleIDs []uint) error {
+	err := database.TxFromContext(ctx, m.db).Transaction(func(tx *gorm.DB) error {
+		added, err := assignRoles(tx, userID, uniqueIDs(roleIDs))
+		if err != nil {
+			return err
+		}
+		return m.recordChanges(ctx, tx, roleChanges(events.EventRBACRoleAssigned, userID, added))
+	})
+	if err != nil {
+		return err
+	}
+	m.invalidateUser(ctx, userID)
+	return nil
+}
+
+// SyncUserRoles replaces a user's roles with the given set in a single
+// transaction. Assignments kept by the sync are left untouched.
+func (m *Manager) SyncUserRoles(ctx context.Context, userID uint, roleIDs []uint) error {
+	defer m.invalidateUser(ctx, userID)
+
+	roleIDs = uniqueIDs(roleIDs)
+	return database.TxFromContext(ctx, m.db).Transaction(func(tx *gorm.DB) error {
+		// Delete roles not in the new set
+		removed, err := removeRoles(tx, userID, roleIDs)
+		if err != nil {
+			return err
+		}
+
+		// Insert the missing ones
+		added, err := assignRoles(tx, userID, roleIDs)
+		if err != nil {
+			return err
+		}
+
+		changes := roleChanges(events.EventRBACRoleRemoved, userID, removed)
+		changes = append(changes, roleChanges(events.EventRBACRoleAssigned, userID, added)...)
+		return m.recordChanges(ctx, tx, changes)
+	})
+}
+
+// AssignPermissions assigns several permissions directly to a user in a
+// single transaction. Permissions the user already holds directly are
+// skipped; if any permission doesn't exist nothing is assigned and
+// ErrPermissionNotFound is returned.
+func (m *Manager) AssignPermissions(ctx context.Context, userID uint, permissionIDs []uint) error {
+	err := database.TxFromContext(ctx, m.db).Transaction(func(tx *gorm.DB) error {
+		added, err := assignPermissions(tx, userID, uniqueIDs(permissionIDs))
+		if err != nil {
+			return err
+		}
+		return m.recordChanges(ctx, tx, permissionChanges(events.EventRBACPermissionAssigned, userID, added))
+	})
+	if err != nil {
+		return err
+	}
+	m.invalidateUser(ctx, userID)
+	return nil
+}
+
+// SyncUserPermissions replaces a user's direct permissions with the given set
+// in a single transaction. Permissions granted through roles are unaffected.
+func (m *Manager) SyncUserPermissions(ctx context.Context, userID uint, permissionIDs []uint) error {
+	defer m.invalidateUser(ctx, userID)
+
+	permissionIDs = uniqueIDs(permissionIDs)
+	return database.TxFromContext(ctx, m.db).Transaction(func(tx *gorm.DB) error {
+		// Delete permissions not in the new set
+		removed, err := removePermissions(tx, userID, permissionIDs)
+		if err != nil {
+			return err
+		}
+
+		// Insert the missing ones
+		added, err := assignPermissions(tx, userID, permissionIDs)
+		if err != nil {
+			return err
+		}
+
+		changes := permissionChanges(events.EventRBACPermissionRemoved, userID, removed)
+		changes = append(changes, permissionChanges(events.EventRBACPermissionAssigned, userID, added)...)
+		return m.recordChanges(ctx, tx, changes)
+	})
 }
 
 // GetUserRoles gets all roles for a user
@@ -56,7 +240,19 @@ func (m *Manager) GetUserRoles(ctx context.Context, userID uint) ([]Role, error)
 		Joins("JOIN user_roles ON user_roles.role_id = roles.id").
 		Where("user_roles.user_id = ?", userID).
 		Find(&roles).Error
-	return roles, err
+	if err != nil {
+		return nil, err
+	}
+
+	if m.cache != nil {
+		slugs := make([]string, 0, len(roles))
+		for _, r := range roles {
+			slugs = append(slugs, r.Slug)
+		}
+		m.cache.Set(ctx, rolesCacheKey(userID), slugs, m.cacheTTL)
+	}
+
+	return roles, nil
 }
 
 // GetUserPermissions gets all permissions for a user (from roles + direct)
@@ -101,11 +297,32 @@ func (m *Manager) GetUserPermissions(ctx context.Context, userID uint) ([]Permis
 		result = append(result, p)
 	}
 
+	if m.cache != nil {
+		slugs := make([]string, 0, len(result))
+		for _, p := range result {
+			slugs = append(slugs, p.Slug)
+		}
+		m.cache.Set(ctx, permissionsCacheKey(userID), slugs, m.cacheTTL)
+	}
+
 	return result, nil
 }
 
 // HasRole checks if user has a specific role
 func (m *Manager) HasRole(ctx context.Context, userID uint, roleSlug string) (bool, error) {
+	if m.cache != nil {
+		slugs, err := m.cachedRoleSlugs(ctx, userID)
+		if err != nil {
+			return false, err
+		}
+		for _, slug := range slugs {
+			if slug == roleSlug {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
 	var count int64
 	err := m.db.WithContext(ctx).
 		Table("user_roles").
@@ -115,16 +332,48 @@ func (m *Manager) HasRole(ctx context.Context, userID uint, roleSlug string) (bo
 	return count > 0, err
 }
 
-// HasPermission checks if user has a specific permission
+// RoleHolderIDs returns the IDs of users that hold a role
+func (m *Manager) RoleHolderIDs(ctx context.Context, roleSlug string) ([]uint, error) {
+	var userIDs []uint
+	err := database.TxFromContext(ctx, m.db).
+		Table("user_roles").
+		Joins("JOIN roles ON roles.id = user_roles.role_id AND roles.deleted_at IS NULL").
+		Where("roles.slug = ?", roleSlug).
+		Distinct().
+		Pluck("user_roles.user_id", &userIDs).Error
+	return userIDs, err
+}
+
+// HasPermission checks if user has a specific permission.
+//
+// Granted slugs may be wildcards: "users.*" covers every permission under the
+// "users." prefix (including nested ones such as "users.roles.assign") and a
+// standalone "*" covers everything. There are no deny rules, so an exact grant,
+// a prefix wildcard or the global wildcard each satisfy the check on their own,
+// whether they come from a role or a direct assignment.
 func (m *Manager) HasPermission(ctx context.Context, userID uint, permissionSlug string) (bool, error) {
+	if m.cache != nil {
+		slugs, err := m.cachedPermissionSlugs(ctx, userID)
+		if err != nil {
+			return false, err
+		}
+		for _, granted := range slugs {
+			if MatchPermission(granted, permissionSlug) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
 	var count int64
+	candidates := permissionCandidates(permissionSlug)
 
 	// Check from roles
 	err := m.db.WithContext(ctx).
 		Table("permissions").
 		Joins("JOIN role_permissions ON role_permissions.permission_id = permissions.id").
 		Joins("JOIN user_roles ON user_roles.role_id = role_permissions.role_id").
-		Where("user_roles.user_id = ? AND permissions.slug = ?", userID, permissionSlug).
+		Where("user_roles.user_id = ? AND permissions.slug IN ?", userID, candidates).
 		Count(&count).Error
 
 	if err != nil {
@@ -139,12 +388,51 @@ func (m *Manager) HasPermission(ctx context.Context, userID uint, permissionSlug
 	err = m.db.WithContext(ctx).
 		Table("user_permissions").
 		Joins("JOIN permissions ON permissions.id = user_permissions.permission_id").
-		Where("user_permissions.user_id = ? AND permissions.slug = ?", userID, permissionSlug).
+		Where("user_permissions.user_id = ? AND permissions.slug IN ?", userID, candidates).
 		Count(&count).Error
 
 	return count > 0, err
 }
 
+// Can checks a permission against a specific resource. Access is granted when
+// the user holds the permission globally (e.g. "users.update"), or holds its
+// ".own" variant (e.g. "users.update.own") and owns the resource.
+func (m *Manager) Can(ctx context.Context, userID uint, permissionSlug string, resourceOwnerID uint) (bool, error) {
+	has, err := m.HasPermission(ctx, userID, permissionSlug)
+	if err != nil || has {
+		return has, err
+	}
+
+	if userID != resourceOwnerID {
+		return false, nil
+	}
+
+	return m.HasPermission(ctx, userID, permissionSlug+".own")
+}
+
+// MatchPermission reports whether a granted slug satisfies a required slug,
+// honouring "prefix.*" and "*" wildcards on the granted side
+func MatchPermission(granted, required string) bool {
+	if granted == required || granted == "*" {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(granted, "*"); ok && strings.HasSuffix(prefix, ".") {
+		return strings.HasPrefix(required, prefix)
+	}
+	return false
+}
+
+// permissionCandidates lists every granted slug that would satisfy the given
+// slug: the slug itself, each parent wildcard and the global wildcard
+func permissionCandidates(slug string) []string {
+	candidates := []string{slug}
+	parts := strings.Split(slug, ".")
+	for i := len(parts) - 1; i > 0; i-- {
+		candidates = append(candidates, strings.Join(parts[:i], ".")+".*")
+	}
+	return append(candidates, "*")
+}
+
 // HasAnyPermission checks if user has any of the given permissions
 func (m *Manager) HasAnyPermission(ctx context.Context, userID uint, permissionSlugs []string) (bool, error) {
 	for _, slug := range permissionSlugs {
@@ -178,6 +466,61 @@ func (m *Manager) CreateRole(ctx context.Context, role *Role) error {
 	return m.db.WithContext(ctx).Create(role).Error
 }
 
+// UpdateRole saves a role's name, slug and description. The cached roles of
+// its holders are dropped, so a new slug takes effect immediately.
+func (m *Manager) UpdateRole(ctx context.Context, role *Role) error {
+	result := database.TxFromContext(ctx, m.db).
+		Model(role).
+		Select("name", "slug", "description").
+		Updates(role)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRoleNotFound
+	}
+	m.invalidateRole(ctx, role.ID)
+	return nil
+}
+
+// DeleteRole deletes a role and removes it from every user holding it. The
+// removals are published like RemoveRole's and the holders' cached roles and
+// permissions are dropped.
+func (m *Manager) DeleteRole(ctx context.Context, roleID uint) error {
+	var userIDs []uint
+	err := database.TxFromContext(ctx, m.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&UserRole{}).Where("role_id = ?", roleID).Pluck("user_id", &userIDs).Error; err != nil {
+			return err
+		}
+
+		result := tx.Delete(&Role{}, roleID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRoleNotFound
+		}
+
+		if err := tx.Where("role_id = ?", roleID).Delete(&UserRole{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM role_permissions WHERE role_id = ?", roleID).Error; err != nil {
+			return err
+		}
+
+		var changes []Change
+		for _, userID := range userIDs {
+			changes = append(changes, roleChanges(events.EventRBACRoleRemoved, userID, []uint{roleID})...)
+		}
+		return m.recordChanges(ctx, tx, changes)
+	})
+	if err != nil {
+		return err
+	}
+	m.invalidateUsers(ctx, userIDs)
+	return nil
+}
+
 // CreatePermission creates a new permission
 func (m *Manager) CreatePermission(ctx context.Context, permission *Permission) error {
 	return m.db.WithContext(ctx).Create(permission).Error
@@ -185,34 +528,68 @@ func (m *Manager) CreatePermission(ctx context.Context, permission *Permission)
 
 // AttachPermissionToRole attaches a permission to a role
 func (m *Manager) AttachPermissionToRole(ctx context.Context, roleID, permissionID uint) error {
-	return m.db.WithContext(ctx).
-		Exec("INSERT INTO role_permissions (role_id, permission_id) VALUES (?, ?)", roleID, permissionID).
-		Error
+	err := database.TxFromContext(ctx, m.db).Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec("INSERT INTO role_permissions (role_id, permission_id) VALUES (?, ?)", roleID, permissionID).Error
+		if err != nil {
+			return err
+		}
+		return m.recordChanges(ctx, tx, rolePermissionChanges(events.EventRBACRolePermissionAttached, roleID, []uint{permissionID}))
+	})
+	if err != nil {
+		return err
+	}
+	m.invalidateRole(ctx, roleID)
+	return nil
 }
 
 // DetachPermissionFromRole detaches a permission from a role
 func (m *Manager) DetachPermissionFromRole(ctx context.Context, roleID, permissionID uint) error {
-	return m.db.WithContext(ctx).
-		Exec("DELETE FROM role_permissions WHERE role_id = ? AND permission_id = ?", roleID, permissionID).
-		Error
+	err := database.TxFromContext(ctx, m.db).Transaction(func(tx *gorm.DB) error {
+		result := tx.Exec("DELETE FROM role_permissions WHERE role_id = ? AND permission_id = ?", roleID, permissionID)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return m.recordChanges(ctx, tx, rolePermissionChanges(events.EventRBACRolePermissionDetached, roleID, []uint{permissionID}))
+	})
+	if err != nil {
+		return err
+	}
+	m.invalidateRole(ctx, roleID)
+	return nil
 }
 
-// SyncRolePermissions syncs permissions for a role
+// SyncRolePermissions syncs permissions for a role. Permissions the role
+// keeps are left attached.
 func (m *Manager) SyncRolePermissions(ctx context.Context, roleID uint, permissionIDs []uint) error {
-	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		// Delete existing
-		if err := tx.Exec("DELETE FROM role_permissions WHERE role_id = ?", roleID).Error; err != nil {
+	defer m.invalidateRole(ctx, roleID)
+
+	permissionIDs = uniqueIDs(permissionIDs)
+	return database.TxFromContext(ctx, m.db).Transaction(func(tx *gorm.DB) error {
+		var attached []uint
+		if err := tx.Table("role_permissions").Where("role_id = ?", roleID).Pluck("permission_id", &attached).Error; err != nil {
 			return err
 		}
 
+		// Delete permissions not in the new set
+		detached := missingIDs(attached, permissionIDs)
+		if len(detached) > 0 {
+			err := tx.Exec("DELETE FROM role_permissions WHERE role_id = ? AND permission_id IN ?", roleID, detached).Error
+			if err != nil {
+				return err
+			}
+		}
+
 		// Insert new
-		for _, permID := range permissionIDs {
+		added := missingIDs(permissionIDs, attached)
+		for _, permID := range added {
 			if err := tx.Exec("INSERT INTO role_permissions (role_id, permission_id) VALUES (?, ?)", roleID, permID).Error; err != nil {
 				return err
 			}
 		}
 
-		return nil
+		changes := rolePermissionChanges(events.EventRBACRolePermissionDetached, roleID, detached)
+		changes = append(changes, rolePermissionChanges(events.EventRBACRolePermissionAttached, roleID, added)...)
+		return m.recordChanges(ctx, tx, changes)
 	})
 }
 
@@ -223,6 +600,91 @@ func (m *Manager) GetPermissionsByModule(ctx context.Context, module string) ([]
 	return permissions, err
 }
 
+// ListRoles lists roles by name with their permission counts
+func (m *Manager) ListRoles(ctx context.Context, offset, limit int) ([]RoleSummary, int64, error) {
+	query := m.db.WithContext(ctx).Model(&Role{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if offset > 0 {
+		query = query.Offset(offset)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	var roles []RoleSummary
+	err := query.
+		Select("roles.*, (?) AS permissions_count", m.db.
+			Table("role_permissions").
+			Select("COUNT(*)").
+			Joins("JOIN permissions ON permissions.id = role_permissions.permission_id AND permissions.deleted_at IS NULL").
+			Where("role_permissions.role_id = roles.id")).
+		Order("roles.name").
+		Find(&roles).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return roles, total, nil
+}
+
+// GetRole gets a role with its permissions. A missing role is reported as
+// ErrRoleNotFound.
+func (m *Manager) GetRole(ctx context.Context, id uint) (*Role, error) {
+	var role Role
+	err := m.db.WithContext(ctx).
+		Preload("Permissions", func(db *gorm.DB) *gorm.DB {
+			return db.Order("permissions.slug")
+		}).
+		First(&role, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRoleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// ListPermissions lists permissions matching the filter, ordered by module
+// and slug
+func (m *Manager) ListPermissions(ctx context.Context, filter PermissionFilter, offset, limit int) ([]Permission, int64, error) {
+	query := m.db.WithContext(ctx).Model(&Permission{})
+	if filter.Module != "" {
+		query = query.Where("module = ?", filter.Module)
+	}
+	if filter.Category != "" {
+		query = query.Where("category = ?", filter.Category)
+	}
+	if filter.Search != "" {
+		pattern := "%" + escapeLike(strings.ToLower(filter.Search)) + "%"
+		query = query.Where("(LOWER(name) LIKE ? ESCAPE '!' OR LOWER(slug) LIKE ? ESCAPE '!')", pattern, pattern)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if offset > 0 {
+		query = query.Offset(offset)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	var permissions []Permission
+	if err := query.Order("module").Order("slug").Find(&permissions).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return permissions, total, nil
+}
+
 // GetRoleBySlug gets a role by slug
 func (m *Manager) GetRoleBySlug(ctx context.Context, slug string) (*Role, error) {
 	var role Role
@@ -236,6 +698,21 @@ func (m *Manager) GetRoleBySlug(ctx context.Context, slug string) (*Role, error)
 	return &role, nil
 }
 
+// LockRole locks a role's row until the transaction of ctx ends (see
+// database.ContextWithTx), so changes that depend on who holds the role run
+// one after another. A role that doesn't exist is not an error.
+func (m *Manager) LockRole(ctx context.Context, slug string) error {
+	var role Role
+	err := database.TxFromContext(ctx, m.db).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("slug = ?", slug).
+		Take(&role).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	return err
+}
+
 // GetPermissionBySlug gets a permission by slug
 func (m *Manager) GetPermissionBySlug(ctx context.Context, slug string) (*Permission, error) {
 	var permission Permission
@@ -246,12 +723,12 @@ func (m *Manager) GetPermissionBySlug(ctx context.Context, slug string) (*Permis
 	return &permission, nil
 }
 
-// SeedDefaultRoles seeds default system roles
+// SeedDefaultRoles creates the default system roles that don't exist yet
 func (m *Manager) SeedDefaultRoles(ctx context.Context) error {
 	roles := []Role{
 		{
 			Name:        "Super Admin",
-			Slug:        "super-admin",
+			Slug:        SuperAdminRole,
 			Description: "Full system access",
 			IsSystem:    true,
 		},
@@ -269,15 +746,276 @@ func (m *Manager) SeedDefaultRoles(ctx context.Context) error {
 		},
 	}
 
-	for _, role := range roles {
-		var existing Role
-		err := m.db.WithContext(ctx).Where("slug = ?", role.Slug).First(&existing).Error
-		if err == gorm.ErrRecordNotFound {
-			if err := m.db.WithContext(ctx).Create(&role).Error; err != nil {
-				return fmt.Errorf("failed to create role %s: %w", role.Slug, err)
-			}
-		}
+	// Roles that already exist, possibly renamed or edited, are left as they
+	// are, so seeding can run any number of times
+	err := m.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&roles).Error
+	if err != nil {
+		return fmt.Errorf("failed to seed default roles: %w", err)
 	}
 
 	return nil
 }
+
+// cachedPermissionSlugs returns the user's effective permission slugs, loading them on a cache miss
+func (m *Manager) cachedPermissionSlugs(ctx context.Context, userID uint) ([]string, error) {
+	if value, err := m.cache.Get(ctx, permissionsCacheKey(userID)); err == nil {
+		if slugs, ok := toStringSlice(value); ok {
+			return slugs, nil
+		}
+	}
+
+	permissions, err := m.GetUserPermissions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	slugs := make([]string, 0, len(permissions))
+	for _, p := range permissions {
+		slugs = append(slugs, p.Slug)
+	}
+	return slugs, nil
+}
+
+// cachedRoleSlugs returns the user's role slugs, loading them on a cache miss
+func (m *Manager) cachedRoleSlugs(ctx context.Context, userID uint) ([]string, error) {
+	if value, err := m.cache.Get(ctx, rolesCacheKey(userID)); err == nil {
+		if slugs, ok := toStringSlice(value); ok {
+			return slugs, nil
+		}
+	}
+
+	roles, err := m.GetUserRoles(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	slugs := make([]string, 0, len(roles))
+	for _, r := range roles {
+		slugs = append(slugs, r.Slug)
+	}
+	return slugs, nil
+}
+
+// invalidateUser drops the cached roles and permissions of a user
+func (m *Manager) invalidateUser(ctx context.Context, userID uint) {
+	m.invalidateUsers(ctx, []uint{userID})
+}
+
+// invalidateUsers drops the cached roles and permissions of the given users.
+// Inside an outer transaction (see database.ContextWithTx) they are dropped
+// once it commits, so lookups in between can't cache the old assignments.
+func (m *Manager) invalidateUsers(ctx context.Context, userIDs []uint) {
+	if m.cache == nil || len(userIDs) == 0 {
+		return
+	}
+
+	keys := make([]string, 0, 2*len(userIDs))
+	for _, id := range userIDs {
+		keys = append(keys, permissionsCacheKey(id), rolesCacheKey(id))
+	}
+	database.AfterCommit(ctx, func() {
+		m.cache.DeleteMulti(ctx, keys)
+	})
+}
+
+// invalidateRole drops the cached roles and permissions of every user
+// holding the role, once the transaction of ctx commits
+func (m *Manager) invalidateRole(ctx context.Context, roleID uint) {
+	if m.cache == nil {
+		return
+	}
+
+	database.AfterCommit(ctx, func() {
+		var userIDs []uint
+		m.db.WithContext(ctx).Model(&UserRole{}).Where("role_id = ?", roleID).Pluck("user_id", &userIDs)
+		m.invalidateUsers(ctx, userIDs)
+	})
+}
+
+// assignRoles inserts the user's missing assignments of the given distinct
+// roles and returns the IDs of the roles it assigned
+func assignRoles(tx *gorm.DB, userID uint, roleIDs []uint) ([]uint, error) {
+	if len(roleIDs) == 0 {
+		return nil, nil
+	}
+
+	var found int64
+	if err := tx.Model(&Role{}).Where("id IN ?", roleIDs).Count(&found).Error; err != nil {
+		return nil, err
+	}
+	if found != int64(len(roleIDs)) {
+		return nil, ErrRoleNotFound
+	}
+
+	var held []uint
+	err := tx.Model(&UserRole{}).
+		Where("user_id = ? AND role_id IN ?", userID, roleIDs).
+		Pluck("role_id", &held).Error
+	if err != nil {
+		return nil, err
+	}
+
+	added := missingIDs(roleIDs, held)
+	if len(added) == 0 {
+		return nil, nil
+	}
+
+	userRoles := make([]UserRole, len(added))
+	for i, id := range added {
+		userRoles[i] = UserRole{UserID: userID, RoleID: id}
+	}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&userRoles).Error; err != nil {
+		return nil, err
+	}
+	return added, nil
+}
+
+// removeRoles deletes the user's role assignments, except those of the
+// roles in keep, and returns the IDs of the roles it removed
+func removeRoles(tx *gorm.DB, userID uint, keep []uint) ([]uint, error) {
+	query := tx.Model(&UserRole{}).Where("user_id = ?", userID)
+	if len(keep) > 0 {
+		query = query.Where("role_id NOT IN ?", keep)
+	}
+
+	var removed []uint
+	if err := query.Pluck("role_id", &removed).Error; err != nil {
+		return nil, err
+	}
+	if len(removed) == 0 {
+		return nil, nil
+	}
+
+	err := tx.Where("user_id = ? AND role_id IN ?", userID, removed).Delete(&UserRole{}).Error
+	if err != nil {
+		return nil, err
+	}
+	return uniqueIDs(removed), nil
+}
+
+// assignPermissions inserts the user's missing direct assignments of the
+// given distinct permissions and returns the IDs of the permissions it
+// assigned
+func assignPermissions(tx *gorm.DB, userID uint, permissionIDs []uint) ([]uint, error) {
+	if len(permissionIDs) == 0 {
+		return nil, nil
+	}
+
+	var found int64
+	if err := tx.Model(&Permission{}).Where("id IN ?", permissionIDs).Count(&found).Error; err != nil {
+		return nil, err
+	}
+	if found != int64(len(permissionIDs)) {
+		return nil, ErrPermissionNotFound
+	}
+
+	var held []uint
+	err := tx.Model(&UserPermission{}).
+		Where("user_id = ? AND permission_id IN ?", userID, permissionIDs).
+		Pluck("permission_id", &held).Error
+	if err != nil {
+		return nil, err
+	}
+
+	added := missingIDs(permissionIDs, held)
+	if len(added) == 0 {
+		return nil, nil
+	}
+
+	userPermissions := make([]UserPermission, len(added))
+	for i, id := range added {
+		userPermissions[i] = UserPermission{UserID: userID, PermissionID: id}
+	}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&userPermissions).Error; err != nil {
+		return nil, err
+	}
+	return added, nil
+}
+
+// removePermissions deletes the user's direct permission assignments,
+// except those of the permissions in keep, and returns the IDs of the
+// permissions it removed
+func removePermissions(tx *gorm.DB, userID uint, keep []uint) ([]uint, error) {
+	query := tx.Model(&UserPermission{}).Where("user_id = ?", userID)
+	if len(keep) > 0 {
+		query = query.Where("permission_id NOT IN ?", keep)
+	}
+
+	var removed []uint
+	if err := query.Pluck("permission_id", &removed).Error; err != nil {
+		return nil, err
+	}
+	if len(removed) == 0 {
+		return nil, nil
+	}
+
+	err := tx.Where("user_id = ? AND permission_id IN ?", userID, removed).Delete(&UserPermission{}).Error
+	if err != nil {
+		return nil, err
+	}
+	return uniqueIDs(removed), nil
+}
+
+// escapeLike escapes LIKE wildcards with the '!' escape character so search
+// terms match literally
+func escapeLike(s string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(s)
+}
+
+// uniqueIDs drops duplicate IDs, keeping the first occurrence
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	result := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+// missingIDs returns the IDs in want that aren't in have
+func missingIDs(want, have []uint) []uint {
+	present := make(map[uint]bool, len(have))
+	for _, id := range have {
+		present[id] = true
+	}
+	var result []uint
+	for _, id := range want {
+		if !present[id] {
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+func permissionsCacheKey(userID uint) string {
+	return fmt.Sprintf("rbac:permissions:%d", userID)
+}
+
+func rolesCacheKey(userID uint) string {
+	return fmt.Sprintf("rbac:roles:%d", userID)
+}
+
+// toStringSlice converts cached values back to a string slice.
+// Serializing caches (e.g. Redis) hand back []interface{} instead of []string.
+func toStringSlice(value interface{}) ([]string, bool) {
+	switch v := value.(type) {
+	case []string:
+		return v, true
+	case []interface{}:
+		result := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			result = append(result, s)
+		}
+		return result, true
+	}
+	return nil, false
+}
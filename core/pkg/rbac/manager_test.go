@@ -0,0 +1,562 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"neonexcore/pkg/cache"
+	"neonexcore/pkg/database"
+	"neonexcore/pkg/events"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTestManager(t *testing.T) *Manager {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "rbac.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(&Role{}, &Permission{}, &UserRole{}, &UserPermission{}, &events.OutboxMessage{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return NewManager(db)
+}
+
+// newCachedTestManager returns a manager memoizing permissions in a memory cache
+func newCachedTestManager(t *testing.T) *Manager {
+	t.Helper()
+
+	mc := cache.NewMemoryCache(cache.DefaultMemoryCacheConfig())
+	t.Cleanup(func() { mc.Close() })
+
+	m := newTestManager(t)
+	m.SetCache(mc, 0)
+	return m
+}
+
+func createTestRole(t *testing.T, m *Manager, slug string, permissionSlugs ...string) *Role {
+	t.Helper()
+	ctx := context.Background()
+
+	role := &Role{Name: slug, Slug: slug}
+	if err := m.CreateRole(ctx, role); err != nil {
+		t.Fatalf("CreateRole %s: %v", slug, err)
+	}
+	for _, permissionSlug := range permissionSlugs {
+		permission := createTestPermission(t, m, permissionSlug)
+		if err := m.AttachPermissionToRole(ctx, role.ID, permission.ID); err != nil {
+			t.Fatalf("AttachPermissionToRole: %v", err)
+		}
+	}
+	return role
+}
+
+func createTestPermission(t *testing.T, m *Manager, slug string) *Permission {
+	t.Helper()
+
+	if existing, err := m.GetPermissionBySlug(context.Background(), slug); err == nil {
+		return existing
+	}
+	permission := &Permission{Name: slug, Slug: slug}
+	if err := m.CreatePermission(context.Background(), permission); err != nil {
+		t.Fatalf("CreatePermission %s: %v", slug, err)
+	}
+	return permission
+}
+
+func assertPermission(t *testing.T, m *Manager, userID uint, slug string, want bool) {
+	t.Helper()
+
+	has, err := m.HasPermission(context.Background(), userID, slug)
+	if err != nil {
+		t.Fatalf("HasPermission %s: %v", slug, err)
+	}
+	if has != want {
+		t.Fatalf("HasPermission(%d, %s) = %v, want %v", userID, slug, has, want)
+	}
+}
+
+func assertRole(t *testing.T, m *Manager, userID uint, slug string, want bool) {
+	t.Helper()
+
+	has, err := m.HasRole(context.Background(), userID, slug)
+	if err != nil {
+		t.Fatalf("HasRole %s: %v", slug, err)
+	}
+	if has != want {
+		t.Fatalf("HasRole(%d, %s) = %v, want %v", userID, slug, has, want)
+	}
+}
+
+func TestCacheIsInvalidatedOnRoleAssignment(t *testing.T) {
+	m := newCachedTestManager(t)
+	ctx := context.Background()
+	editor := createTestRole(t, m, "editor", "posts.update")
+
+	// Cache the empty permission set first
+	assertPermission(t, m, 1, "posts.update", false)
+	assertRole(t, m, 1, "editor", false)
+
+	if err := m.AssignRole(ctx, 1, editor.ID); err != nil {
+		t.Fatalf("AssignRole: %v", err)
+	}
+	assertPermission(t, m, 1, "posts.update", true)
+	assertRole(t, m, 1, "editor", true)
+
+	if err := m.RemoveRole(ctx, 1, editor.ID); err != nil {
+		t.Fatalf("RemoveRole: %v", err)
+	}
+	assertPermission(t, m, 1, "posts.update", false)
+	assertRole(t, m, 1, "editor", false)
+}
+
+func TestCacheIsInvalidatedOnRolePermissionChange(t *testing.T) {
+	m := newCachedTestManager(t)
+	ctx := context.Background()
+	editor := createTestRole(t, m, "editor", "posts.update")
+	publish := createTestPermission(t, m, "posts.publish")
+
+	if err := m.AssignRole(ctx, 1, editor.ID); err != nil {
+		t.Fatalf("AssignRole: %v", err)
+	}
+	assertPermission(t, m, 1, "posts.publish", false)
+
+	if err := m.SyncRolePermissions(ctx, editor.ID, []uint{publish.ID}); err != nil {
+		t.Fatalf("SyncRolePermissions: %v", err)
+	}
+	assertPermission(t, m, 1, "posts.publish", true)
+	assertPermission(t, m, 1, "posts.update", false)
+}
+
+func TestCacheIsInvalidatedOnDirectPermission(t *testing.T) {
+	m := newCachedTestManager(t)
+	ctx := context.Background()
+	publish := createTestPermission(t, m, "posts.publish")
+
+	assertPermission(t, m, 1, "posts.publish", false)
+	if err := m.AssignPermission(ctx, 1, publish.ID); err != nil {
+		t.Fatalf("AssignPermission: %v", err)
+	}
+	assertPermission(t, m, 1, "posts.publish", true)
+}
+
+func TestCacheIsInvalidatedOnRoleSlugChange(t *testing.T) {
+	m := newCachedTestManager(t)
+	ctx := context.Background()
+	editor := createTestRole(t, m, "editor")
+
+	if err := m.AssignRole(ctx, 1, editor.ID); err != nil {
+		t.Fatalf("AssignRole: %v", err)
+	}
+	assertRole(t, m, 1, "editor", true)
+
+	editor.Slug = "writer"
+	if err := m.UpdateRole(ctx, editor); err != nil {
+		t.Fatalf("UpdateRole: %v", err)
+	}
+	assertRole(t, m, 1, "editor", false)
+	assertRole(t, m, 1, "writer", true)
+}
+
+func TestCacheIsInvalidatedOnRoleDelete(t *testing.T) {
+	m := newCachedTestManager(t)
+	ctx := context.Background()
+	editor := createTestRole(t, m, "editor", "posts.update")
+
+	for _, userID := range []uint{1, 2} {
+		if err := m.AssignRole(ctx, userID, editor.ID); err != nil {
+			t.Fatalf("AssignRole: %v", err)
+		}
+		assertPermission(t, m, userID, "posts.update", true)
+		assertRole(t, m, userID, "editor", true)
+	}
+
+	if err := m.DeleteRole(ctx, editor.ID); err != nil {
+		t.Fatalf("DeleteRole: %v", err)
+	}
+	for _, userID := range []uint{1, 2} {
+		assertPermission(t, m, userID, "posts.update", false)
+		assertRole(t, m, userID, "editor", false)
+	}
+
+	if err := m.DeleteRole(ctx, editor.ID); err != ErrRoleNotFound {
+		t.Fatalf("deleting the role again: %v, want ErrRoleNotFound", err)
+	}
+}
+
+func TestMatchPermission(t *testing.T) {
+	tests := []struct {
+		granted, required string
+		want              bool
+	}{
+		{"users.read", "users.read", true},
+		{"users.read", "users.update", false},
+		{"users.*", "users.read", true},
+		{"users.*", "users.roles.assign", true},
+		{"users.*", "users", false},
+		{"users.*", "usersx.read", false},
+		{"*", "anything.at.all", true},
+		{"users*", "users.read", false},
+	}
+
+	for _, tt := range tests {
+		if got := MatchPermission(tt.granted, tt.required); got != tt.want {
+			t.Errorf("MatchPermission(%q, %q) = %v, want %v", tt.granted, tt.required, got, tt.want)
+		}
+	}
+}
+
+func TestHasPermissionWildcards(t *testing.T) {
+	for name, m := range map[string]*Manager{
+		"database": newTestManager(t),
+		"cached":   newCachedTestManager(t),
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			exact := createTestRole(t, m, "reader", "posts.read")
+			prefix := createTestRole(t, m, "user-admin", "users.*")
+			global := createTestRole(t, m, "root", "*")
+
+			for userID, roleID := range map[uint]uint{1: exact.ID, 2: prefix.ID, 3: global.ID} {
+				if err := m.AssignRole(ctx, userID, roleID); err != nil {
+					t.Fatalf("AssignRole: %v", err)
+				}
+			}
+
+			// Exact grant
+			assertPermission(t, m, 1, "posts.read", true)
+			assertPermission(t, m, 1, "posts.update", false)
+
+			// Prefix wildcard, including nested slugs
+			assertPermission(t, m, 2, "users.read", true)
+			assertPermission(t, m, 2, "users.roles.assign", true)
+			assertPermission(t, m, 2, "posts.read", false)
+
+			// Global wildcard
+			assertPermission(t, m, 3, "posts.read", true)
+			assertPermission(t, m, 3, "settings.update", true)
+		})
+	}
+}
+
+func TestHasPermissionWildcardFromDirectGrant(t *testing.T) {
+	m := newTestManager(t)
+	wildcard := createTestPermission(t, m, "reports.*")
+
+	if err := m.AssignPermission(context.Background(), 1, wildcard.ID); err != nil {
+		t.Fatalf("AssignPermission: %v", err)
+	}
+	assertPermission(t, m, 1, "reports.export", true)
+	assertPermission(t, m, 1, "users.read", false)
+}
+
+// newOwnershipTestManager grants user 1 users.update.own and user 2 the
+// global users.update
+func newOwnershipTestManager(t *testing.T) *Manager {
+	t.Helper()
+
+	m := newTestManager(t)
+	member := createTestRole(t, m, "member", "users.update.own")
+	admin := createTestRole(t, m, "admin", "users.update")
+	for userID, roleID := range map[uint]uint{1: member.ID, 2: admin.ID} {
+		if err := m.AssignRole(context.Background(), userID, roleID); err != nil {
+			t.Fatalf("AssignRole: %v", err)
+		}
+	}
+	return m
+}
+
+func TestCan(t *testing.T) {
+	m := newOwnershipTestManager(t)
+
+	tests := []struct {
+		name    string
+		userID  uint
+		ownerID uint
+		want    bool
+	}{
+		{"owner with own permission", 1, 1, true},
+		{"own permission on another user's resource", 1, 7, false},
+		{"global permission on any resource", 2, 7, true},
+		{"global permission on own resource", 2, 2, true},
+		{"no permission on own resource", 3, 3, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := m.Can(context.Background(), tt.userID, "users.update", tt.ownerID)
+			if err != nil {
+				t.Fatalf("Can: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("Can(%d, users.update, %d) = %v, want %v", tt.userID, tt.ownerID, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSeedDefaultRolesIsIdempotent(t *testing.T) {
+	m := newTestManager(t)
+	ctx := context.Background()
+
+	if err := m.SeedDefaultRoles(ctx); err != nil {
+		t.Fatalf("SeedDefaultRoles: %v", err)
+	}
+	admin, err := m.GetRoleBySlug(ctx, "admin")
+	if err != nil {
+		t.Fatalf("GetRoleBySlug: %v", err)
+	}
+	admin.Name = "Administrator"
+	if err := m.db.Save(admin).Error; err != nil {
+		t.Fatalf("rename role: %v", err)
+	}
+
+	if err := m.SeedDefaultRoles(ctx); err != nil {
+		t.Fatalf("second SeedDefaultRoles: %v", err)
+	}
+	var count int64
+	m.db.Model(&Role{}).Count(&count)
+	if count != 3 {
+		t.Fatalf("%d roles after seeding twice, want 3", count)
+	}
+	// Edited roles are left as they are
+	if admin, _ := m.GetRoleBySlug(ctx, "admin"); admin.Name != "Administrator" {
+		t.Fatalf("admin role renamed back to %q", admin.Name)
+	}
+}
+
+func TestRepeatedAssignmentIsNoOp(t *testing.T) {
+	m := newTestManager(t)
+	ctx := context.Background()
+	role := createTestRole(t, m, "editor", "posts.edit")
+	permission := createTestPermission(t, m, "posts.publish")
+
+	for i := 0; i < 3; i++ {
+		if err := m.AssignRole(ctx, 1, role.ID); err != nil {
+			t.Fatalf("AssignRole #%d: %v", i+1, err)
+		}
+		if err := m.AssignPermission(ctx, 1, permission.ID); err != nil {
+			t.Fatalf("AssignPermission #%d: %v", i+1, err)
+		}
+	}
+
+	var roles, permissions int64
+	m.db.Model(&UserRole{}).Where("user_id = ?", 1).Count(&roles)
+	m.db.Model(&UserPermission{}).Where("user_id = ?", 1).Count(&permissions)
+	if roles != 1 || permissions != 1 {
+		t.Fatalf("%d user roles and %d user permissions after repeated assignment, want 1 each", roles, permissions)
+	}
+	assertRole(t, m, 1, "editor", true)
+	assertPermission(t, m, 1, "posts.publish", true)
+
+	// The database refuses duplicates written around the manager
+	if err := m.db.Create(&UserRole{UserID: 1, RoleID: role.ID}).Error; err == nil {
+		t.Fatal("inserted a duplicate user role")
+	}
+}
+
+// legacyUserRole is the user_roles table before assignments were unique
+type legacyUserRole struct {
+	ID        uint
+	UserID    uint
+	RoleID    uint
+	CreatedAt time.Time
+}
+
+func (legacyUserRole) TableName() string {
+	return "user_roles"
+}
+
+func TestMigrationRemovesDuplicateAssignments(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "rbac.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(&legacyUserRole{}); err != nil {
+		t.Fatalf("migrate legacy table: %v", err)
+	}
+	rows := []legacyUserRole{{UserID: 1, RoleID: 1}, {UserID: 1, RoleID: 1}, {UserID: 1, RoleID: 2}, {UserID: 2, RoleID: 1}, {UserID: 1, RoleID: 1}}
+	if err := db.Create(&rows).Error; err != nil {
+		t.Fatalf("insert rows: %v", err)
+	}
+
+	if err := (UserRole{}).BeforeMigrate(db); err != nil {
+		t.Fatalf("BeforeMigrate: %v", err)
+	}
+	if err := db.AutoMigrate(&UserRole{}); err != nil {
+		t.Fatalf("AutoMigrate: %v", err)
+	}
+
+	// The oldest of each duplicate is kept
+	var ids []uint
+	db.Model(&UserRole{}).Order("id").Pluck("id", &ids)
+	if len(ids) != 3 || ids[0] != 1 || ids[1] != 3 || ids[2] != 4 {
+		t.Fatalf("rows left = %v, want 1, 3 and 4", ids)
+	}
+	if !db.Migrator().HasIndex(&UserRole{}, "idx_user_roles_user_role") {
+		t.Fatal("unique index not created")
+	}
+
+	// Once the index exists there is nothing to do
+	if err := (UserRole{}).BeforeMigrate(db); err != nil {
+		t.Fatalf("second BeforeMigrate: %v", err)
+	}
+}
+
+// userRoleIDs returns the IDs of the roles assigned to a user, keyed by the
+// ID of their assignment row
+func userRoleIDs(t *testing.T, m *Manager, userID uint) map[uint]uint {
+	t.Helper()
+
+	var rows []UserRole
+	if err := m.db.Where("user_id = ?", userID).Find(&rows).Error; err != nil {
+		t.Fatalf("load user roles: %v", err)
+	}
+	assigned := make(map[uint]uint, len(rows))
+	for _, row := range rows {
+		assigned[row.ID] = row.RoleID
+	}
+	return assigned
+}
+
+func TestSyncUserRolesReplacesSet(t *testing.T) {
+	m := newCachedTestManager(t)
+	ctx := context.Background()
+	editor := createTestRole(t, m, "editor", "posts.edit")
+	author := createTestRole(t, m, "author", "posts.create")
+	viewer := createTestRole(t, m, "viewer", "posts.view")
+
+	if err := m.AssignRoles(ctx, 1, []uint{editor.ID, author.ID, editor.ID}); err != nil {
+		t.Fatalf("AssignRoles: %v", err)
+	}
+	assertPermission(t, m, 1, "posts.create", true)
+	before := userRoleIDs(t, m, 1)
+	if len(before) != 2 {
+		t.Fatalf("user roles = %v, want editor and author", before)
+	}
+
+	if err := m.SyncUserRoles(ctx, 1, []uint{editor.ID, viewer.ID}); err != nil {
+		t.Fatalf("SyncUserRoles: %v", err)
+	}
+	assertRole(t, m, 1, "editor", true)
+	assertRole(t, m, 1, "viewer", true)
+	assertRole(t, m, 1, "author", false)
+	assertPermission(t, m, 1, "posts.create", false)
+
+	// The kept assignment is the same row, not a delete and re-insert
+	after := userRoleIDs(t, m, 1)
+	for id, roleID := range before {
+		if roleID == editor.ID && after[id] != editor.ID {
+			t.Fatalf("user roles = %v, want editor's row %d kept", after, id)
+		}
+	}
+
+	if err := m.SyncUserRoles(ctx, 1, nil); err != nil {
+		t.Fatalf("SyncUserRoles to nothing: %v", err)
+	}
+	if roles := userRoleIDs(t, m, 1); len(roles) != 0 {
+		t.Fatalf("user roles = %v, want none", roles)
+	}
+}
+
+func TestBatchRoleAssignmentRollsBack(t *testing.T) {
+	m := newCachedTestManager(t)
+	ctx := context.Background()
+	editor := createTestRole(t, m, "editor", "posts.edit")
+	author := createTestRole(t, m, "author", "posts.create")
+	if err := m.AssignRole(ctx, 1, editor.ID); err != nil {
+		t.Fatalf("AssignRole: %v", err)
+	}
+	assertRole(t, m, 1, "editor", true)
+
+	const missing = 999
+	if err := m.AssignRoles(ctx, 1, []uint{author.ID, missing}); !errors.Is(err, ErrRoleNotFound) {
+		t.Fatalf("AssignRoles with a missing role = %v, want ErrRoleNotFound", err)
+	}
+	assertRole(t, m, 1, "author", false)
+
+	// The removal of editor is undone along with the failed insert
+	if err := m.SyncUserRoles(ctx, 1, []uint{author.ID, missing}); !errors.Is(err, ErrRoleNotFound) {
+		t.Fatalf("SyncUserRoles with a missing role = %v, want ErrRoleNotFound", err)
+	}
+	assertRole(t, m, 1, "editor", true)
+	assertRole(t, m, 1, "author", false)
+	if roles := userRoleIDs(t, m, 1); len(roles) != 1 {
+		t.Fatalf("user roles = %v, want only editor", roles)
+	}
+}
+
+func TestBatchPermissionAssignment(t *testing.T) {
+	m := newCachedTestManager(t)
+	ctx := context.Background()
+	edit := createTestPermission(t, m, "posts.edit")
+	publish := createTestPermission(t, m, "posts.publish")
+	remove := createTestPermission(t, m, "posts.delete")
+
+	// Permissions granted through a role survive a sync of direct ones
+	role := createTestRole(t, m, "viewer", "posts.view")
+	if err := m.AssignRole(ctx, 1, role.ID); err != nil {
+		t.Fatalf("AssignRole: %v", err)
+	}
+
+	if err := m.AssignPermissions(ctx, 1, []uint{edit.ID, publish.ID}); err != nil {
+		t.Fatalf("AssignPermissions: %v", err)
+	}
+	assertPermission(t, m, 1, "posts.publish", true)
+
+	if err := m.SyncUserPermissions(ctx, 1, []uint{edit.ID, remove.ID}); err != nil {
+		t.Fatalf("SyncUserPermissions: %v", err)
+	}
+	assertPermission(t, m, 1, "posts.edit", true)
+	assertPermission(t, m, 1, "posts.delete", true)
+	assertPermission(t, m, 1, "posts.publish", false)
+	assertPermission(t, m, 1, "posts.view", true)
+
+	// A missing permission fails the whole batch
+	if err := m.SyncUserPermissions(ctx, 1, []uint{publish.ID, 999}); !errors.Is(err, ErrPermissionNotFound) {
+		t.Fatalf("SyncUserPermissions with a missing permission = %v, want ErrPermissionNotFound", err)
+	}
+	if err := m.AssignPermissions(ctx, 1, []uint{publish.ID, 999}); !errors.Is(err, ErrPermissionNotFound) {
+		t.Fatalf("AssignPermissions with a missing permission = %v, want ErrPermissionNotFound", err)
+	}
+	assertPermission(t, m, 1, "posts.edit", true)
+	assertPermission(t, m, 1, "posts.delete", true)
+	assertPermission(t, m, 1, "posts.publish", false)
+
+	var direct int64
+	m.db.Model(&UserPermission{}).Where("user_id = ?", 1).Count(&direct)
+	if direct != 2 {
+		t.Fatalf("%d direct permissions, want 2", direct)
+	}
+}
+
+func TestCacheIsInvalidatedAfterOuterCommit(t *testing.T) {
+	m := newCachedTestManager(t)
+	ctx := context.Background()
+	editor := createTestRole(t, m, "editor", "posts.update")
+	if err := m.AssignRole(ctx, 1, editor.ID); err != nil {
+		t.Fatalf("AssignRole: %v", err)
+	}
+	assertPermission(t, m, 1, "posts.update", true)
+
+	err := database.NewTxManager(m.db).WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := m.RemoveRole(database.ContextWithTx(ctx, tx), 1, editor.ID); err != nil {
+			return err
+		}
+		// A lookup before the commit still sees, and caches, the old role
+		assertPermission(t, m, 1, "posts.update", true)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithTransaction: %v", err)
+	}
+
+	assertPermission(t, m, 1, "posts.update", false)
+	assertRole(t, m, 1, "editor", false)
+}
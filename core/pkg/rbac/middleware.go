@@ -2,130 +2,81 @@ package rbac
 
 import (
 	"context"
+	"net/http"
+
+	"neonexcore/pkg/errors"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-// RequirePermission creates middleware that checks for required permission
-func RequirePermission(manager *Manager, permission string) fiber.Handler {
+// checkFunc performs an authorization check for the given user
+type checkFunc func(ctx context.Context, userID uint) (bool, error)
+
+// guard builds middleware around a check. Failures are returned as AppErrors so
+// the global error handler renders them with the standard error envelope.
+func guard(check checkFunc, failMessage string) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		userID, ok := c.Locals("user_id").(uint)
 		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error":   "unauthorized",
-				"message": "user not authenticated",
-			})
+			return errors.NewUnauthorized("User not authenticated")
 		}
 
 		ctx := context.Background()
-		hasPermission, err := manager.HasPermission(ctx, userID, permission)
+		allowed, err := check(ctx, userID)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error":   "internal_error",
-				"message": "failed to check permission",
-			})
+			return errors.NewInternal("Failed to check permissions").WithError(err)
 		}
 
-		if !hasPermission {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"error":   "forbidden",
-				"message": "insufficient permissions",
-			})
+		if !allowed {
+			return errors.New(errors.ErrCodeInsufficientPermissions, failMessage, http.StatusForbidden)
 		}
 
 		return c.Next()
 	}
 }
 
+// RequirePermission creates middleware that checks for required permission
+func RequirePermission(manager *Manager, permission string) fiber.Handler {
+	return guard(func(ctx context.Context, userID uint) (bool, error) {
+		return manager.HasPermission(ctx, userID, permission)
+	}, "Insufficient permissions")
+}
+
 // RequireRole creates middleware that checks for required role
 func RequireRole(manager *Manager, role string) fiber.Handler {
-	return func(c *fiber.Ctx) error {
-		userID, ok := c.Locals("user_id").(uint)
-		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error":   "unauthorized",
-				"message": "user not authenticated",
-			})
-		}
-
-		ctx := context.Background()
-		hasRole, err := manager.HasRole(ctx, userID, role)
-		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error":   "internal_error",
-				"message": "failed to check role",
-			})
-		}
-
-		if !hasRole {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"error":   "forbidden",
-				"message": "insufficient role",
-			})
-		}
-
-		return c.Next()
-	}
+	return guard(func(ctx context.Context, userID uint) (bool, error) {
+		return manager.HasRole(ctx, userID, role)
+	}, "Insufficient role")
 }
 
 // RequireAnyPermission checks if user has any of the given permissions
 func RequireAnyPermission(manager *Manager, permissions ...string) fiber.Handler {
-	return func(c *fiber.Ctx) error {
-		userID, ok := c.Locals("user_id").(uint)
-		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error":   "unauthorized",
-				"message": "user not authenticated",
-			})
-		}
-
-		ctx := context.Background()
-		hasAny, err := manager.HasAnyPermission(ctx, userID, permissions)
-		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error":   "internal_error",
-				"message": "failed to check permissions",
-			})
-		}
-
-		if !hasAny {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"error":   "forbidden",
-				"message": "insufficient permissions",
-			})
-		}
-
-		return c.Next()
-	}
+	return guard(func(ctx context.Context, userID uint) (bool, error) {
+		return manager.HasAnyPermission(ctx, userID, permissions)
+	}, "Insufficient permissions")
 }
 
 // RequireAllPermissions checks if user has all of the given permissions
 func RequireAllPermissions(manager *Manager, permissions ...string) fiber.Handler {
-	return func(c *fiber.Ctx) error {
-		userID, ok := c.Locals("user_id").(uint)
-		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error":   "unauthorized",
-				"message": "user not authenticated",
-			})
-		}
+	return guard(func(ctx context.Context, userID uint) (bool, error) {
+		return manager.HasAllPermissions(ctx, userID, permissions)
+	}, "Insufficient permissions")
+}
 
-		ctx := context.Background()
-		hasAll, err := manager.HasAllPermissions(ctx, userID, permissions)
-		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error":   "internal_error",
-				"message": "failed to check permissions",
-			})
-		}
+// OwnerResolver extracts the owner ID of the resource a request targets
+type OwnerResolver func(c *fiber.Ctx) (uint, error)
 
-		if !hasAll {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"error":   "forbidden",
-				"message": "insufficient permissions",
-			})
+// RequirePermissionOrOwner checks a permission that may be scoped to the
+// user's own resources (see Manager.Can)
+func RequirePermissionOrOwner(manager *Manager, permission string, owner OwnerResolver) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ownerID, err := owner(c)
+		if err != nil {
+			return errors.NewBadRequest("Invalid resource")
 		}
 
-		return c.Next()
+		return guard(func(ctx context.Context, userID uint) (bool, error) {
+			return manager.Can(ctx, userID, permission, ownerID)
+		}, "Insufficient permissions")(c)
 	}
 }
@@ -0,0 +1,144 @@
+package rbac
+
+import (
+	"context"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"neonexcore/pkg/errors"
+	"neonexcore/pkg/logger"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// newGuardedApp mounts a handler behind guard on GET /; the X-User header
+// names the authenticated user
+func newGuardedApp(guard fiber.Handler) *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: errors.ErrorHandler(logger.Default())})
+	app.Use(func(c *fiber.Ctx) error {
+		if id, err := strconv.ParseUint(c.Get("X-User"), 10, 64); err == nil {
+			c.Locals("user_id", uint(id))
+		}
+		return c.Next()
+	})
+	app.Get("/", guard, func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+	return app
+}
+
+func guardedStatus(t *testing.T, app *fiber.App, method, path, userID string) int {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, nil)
+	if userID != "" {
+		req.Header.Set("X-User", userID)
+	}
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	return resp.StatusCode
+}
+
+// newMiddlewareTestManager grants user 1 posts.read and posts.update, user 2
+// posts.read only, and user 3 nothing
+func newMiddlewareTestManager(t *testing.T) *Manager {
+	t.Helper()
+
+	m := newTestManager(t)
+	editor := createTestRole(t, m, "editor", "posts.read", "posts.update")
+	reader := createTestRole(t, m, "reader", "posts.read")
+	for userID, roleID := range map[uint]uint{1: editor.ID, 2: reader.ID} {
+		if err := m.AssignRole(context.Background(), userID, roleID); err != nil {
+			t.Fatalf("AssignRole: %v", err)
+		}
+	}
+	return m
+}
+
+func TestRequirePermission(t *testing.T) {
+	m := newMiddlewareTestManager(t)
+	app := newGuardedApp(RequirePermission(m, "posts.update"))
+
+	for userID, want := range map[string]int{
+		"1": fiber.StatusOK,
+		"2": fiber.StatusForbidden,
+		"":  fiber.StatusUnauthorized,
+	} {
+		if got := guardedStatus(t, app, fiber.MethodGet, "/", userID); got != want {
+			t.Errorf("user %q got %d, want %d", userID, got, want)
+		}
+	}
+}
+
+func TestRequireRole(t *testing.T) {
+	m := newMiddlewareTestManager(t)
+	app := newGuardedApp(RequireRole(m, "editor"))
+
+	if got := guardedStatus(t, app, fiber.MethodGet, "/", "1"); got != fiber.StatusOK {
+		t.Errorf("editor got %d, want 200", got)
+	}
+	if got := guardedStatus(t, app, fiber.MethodGet, "/", "2"); got != fiber.StatusForbidden {
+		t.Errorf("reader got %d, want 403", got)
+	}
+}
+
+func TestRequireAnyPermission(t *testing.T) {
+	m := newMiddlewareTestManager(t)
+	app := newGuardedApp(RequireAnyPermission(m, "posts.delete", "posts.read"))
+
+	if got := guardedStatus(t, app, fiber.MethodGet, "/", "2"); got != fiber.StatusOK {
+		t.Errorf("reader got %d, want 200", got)
+	}
+	if got := guardedStatus(t, app, fiber.MethodGet, "/", "3"); got != fiber.StatusForbidden {
+		t.Errorf("user without permissions got %d, want 403", got)
+	}
+}
+
+func TestRequireAllPermissions(t *testing.T) {
+	m := newMiddlewareTestManager(t)
+	app := newGuardedApp(RequireAllPermissions(m, "posts.read", "posts.update"))
+
+	if got := guardedStatus(t, app, fiber.MethodGet, "/", "1"); got != fiber.StatusOK {
+		t.Errorf("editor got %d, want 200", got)
+	}
+	if got := guardedStatus(t, app, fiber.MethodGet, "/", "2"); got != fiber.StatusForbidden {
+		t.Errorf("reader got %d, want 403", got)
+	}
+}
+
+func TestRequirePermissionOrOwner(t *testing.T) {
+	m := newOwnershipTestManager(t)
+
+	app := fiber.New(fiber.Config{ErrorHandler: errors.ErrorHandler(logger.Default())})
+	app.Use(func(c *fiber.Ctx) error {
+		if id, err := strconv.ParseUint(c.Get("X-User"), 10, 64); err == nil {
+			c.Locals("user_id", uint(id))
+		}
+		return c.Next()
+	})
+	owner := func(c *fiber.Ctx) (uint, error) {
+		id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+		return uint(id), err
+	}
+	app.Put("/users/:id", RequirePermissionOrOwner(m, "users.update", owner), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	tests := []struct {
+		path, userID string
+		want         int
+	}{
+		{"/users/1", "1", fiber.StatusOK},
+		{"/users/7", "1", fiber.StatusForbidden},
+		{"/users/7", "2", fiber.StatusOK},
+		{"/users/abc", "2", fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if got := guardedStatus(t, app, fiber.MethodPut, tt.path, tt.userID); got != tt.want {
+			t.Errorf("user %s PUT %s got %d, want %d", tt.userID, tt.path, got, tt.want)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package rbac
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -35,11 +37,25 @@ type Permission struct {
 	Roles []Role `gorm:"many2many:role_permissions;" json:"roles,omitempty"`
 }
 
+// RoleSummary is a role with the number of permissions attached to it
+type RoleSummary struct {
+	Role
+	PermissionsCount int64 `json:"permissions_count"`
+}
+
+// PermissionFilter narrows a permission listing. Empty fields match everything;
+// Search matches a substring of the name or slug.
+type PermissionFilter struct {
+	Module   string
+	Category string
+	Search   string
+}
+
 // UserRole represents user-role relationship
 type UserRole struct {
 	ID        uint      `gorm:"primarykey" json:"id"`
-	UserID    uint      `gorm:"index;not null" json:"user_id"`
-	RoleID    uint      `gorm:"index;not null" json:"role_id"`
+	UserID    uint      `gorm:"index;uniqueIndex:idx_user_roles_user_role;not null" json:"user_id"`
+	RoleID    uint      `gorm:"index;uniqueIndex:idx_user_roles_user_role;not null" json:"role_id"`
 	CreatedAt time.Time `json:"created_at"`
 
 	Role Role `gorm:"foreignKey:RoleID" json:"role,omitempty"`
@@ -48,8 +64,8 @@ type UserRole struct {
 // UserPermission represents direct user permissions
 type UserPermission struct {
 	ID           uint      `gorm:"primarykey" json:"id"`
-	UserID       uint      `gorm:"index;not null" json:"user_id"`
-	PermissionID uint      `gorm:"index;not null" json:"permission_id"`
+	UserID       uint      `gorm:"index;uniqueIndex:idx_user_permissions_user_permission;not null" json:"user_id"`
+	PermissionID uint      `gorm:"index;uniqueIndex:idx_user_permissions_user_permission;not null" json:"permission_id"`
 	CreatedAt    time.Time `json:"created_at"`
 
 	Permission Permission `gorm:"foreignKey:PermissionID" json:"permission,omitempty"`
@@ -74,3 +90,42 @@ func (UserRole) TableName() string {
 func (UserPermission) TableName() string {
 	return "user_permissions"
 }
+
+// BeforeMigrate removes duplicate assignments, keeping the oldest, so the
+// unique index on (user_id, role_id) can be created on existing tables
+func (UserRole) BeforeMigrate(db *gorm.DB) error {
+	return removeDuplicates(db, &UserRole{}, "idx_user_roles_user_role", "user_id", "role_id")
+}
+
+// BeforeMigrate removes duplicate assignments, keeping the oldest, so the
+// unique index on (user_id, permission_id) can be created on existing tables
+func (UserPermission) BeforeMigrate(db *gorm.DB) error {
+	return removeDuplicates(db, &UserPermission{}, "idx_user_permissions_user_permission", "user_id", "permission_id")
+}
+
+// removeDuplicates deletes all but the lowest-ID row of each group of rows
+// sharing the given columns, unless the table is new or already has the
+// unique index
+func removeDuplicates(db *gorm.DB, model interface{}, index string, columns ...string) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable(model) || migrator.HasIndex(model, index) {
+		return nil
+	}
+
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return err
+	}
+	table := db.Statement.Quote(stmt.Schema.Table)
+
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = db.Statement.Quote(column)
+	}
+
+	// The derived table lets MySQL read the table it deletes from
+	return db.Exec(fmt.Sprintf(
+		"DELETE FROM %s WHERE id NOT IN (SELECT id FROM (SELECT MIN(id) AS id FROM %s GROUP BY %s) AS keep)",
+		table, table, strings.Join(quoted, ", "),
+	)).Error
+}
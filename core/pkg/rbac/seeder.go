@@ -0,0 +1,27 @@
+package rbac
+
+import "context"
+
+// RoleSeederName is the name of the default roles seeder, for seeders that
+// depend on the roles existing
+const RoleSeederName = "RoleSeeder"
+
+// RoleSeeder seeds the default roles
+type RoleSeeder struct {
+	manager *Manager
+}
+
+// NewRoleSeeder creates a seeder for the default roles
+func NewRoleSeeder(manager *Manager) *RoleSeeder {
+	return &RoleSeeder{manager: manager}
+}
+
+// Name implements the Seeder interface
+func (s *RoleSeeder) Name() string {
+	return RoleSeederName
+}
+
+// Run implements the Seeder interface
+func (s *RoleSeeder) Run(ctx context.Context) error {
+	return s.manager.SeedDefaultRoles(ctx)
+}
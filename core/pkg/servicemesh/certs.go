@@ -0,0 +1,168 @@
+package servicemesh
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// certReloadDebounce groups the burst of file events a certificate rotation
+// produces (e.g. cert, key and CA rewritten one after another) into one reload
+const certReloadDebounce = 500 * time.Millisecond
+
+// certBundle is one loaded generation of the proxy's certificates
+type certBundle struct {
+	cert   *tls.Certificate
+	caPool *x509.CertPool
+}
+
+// certStore holds the current certificates and swaps them atomically on
+// reload, so handshakes always see a complete cert/key/CA generation
+type certStore struct {
+	certFile string
+	keyFile  string
+	caFile   string
+	current  atomic.Pointer[certBundle]
+	reloadMu sync.Mutex
+}
+
+// newCertStore loads the certificate, key and CA files
+func newCertStore(certFile, keyFile, caFile string) (*certStore, error) {
+	store := &certStore{
+		certFile: certFile,
+		keyFile:  keyFile,
+		caFile:   caFile,
+	}
+	if err := store.Reload(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Reload reads the files again. On error the previous certificates stay in use.
+func (s *certStore) Reload() error {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	caCert, err := os.ReadFile(s.caFile)
+	if err != nil {
+		return fmt.Errorf("failed to read CA cert: %w", err)
+	}
+
+	caPool := x509.NewCertPool()
+	if !caPool.AppendCertsFromPEM(caCert) {
+		return fmt.Errorf("failed to append CA cert")
+	}
+
+	cert, err := tls.LoadX509KeyPair(s.certFile, s.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load client cert: %w", err)
+	}
+
+	s.current.Store(&certBundle{cert: &cert, caPool: caPool})
+	return nil
+}
+
+// bundle returns the current certificates
+func (s *certStore) bundle() *certBundle {
+	return s.current.Load()
+}
+
+// tlsConfig returns a TLS config for both serving and dialing whose
+// certificates and CA pool are looked up per handshake
+func (s *certStore) tlsConfig() *tls.Config {
+	base := &tls.Config{
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return s.bundle().cert, nil
+		},
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return s.bundle().cert, nil
+		},
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		MinVersion: tls.VersionTLS13,
+	}
+
+	// The client CA pool is fixed per config, so hand each incoming
+	// connection a config carrying the current pool
+	base.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		config := base.Clone()
+		config.GetConfigForClient = nil
+		config.ClientCAs = s.bundle().caPool
+		return config, nil
+	}
+
+	return base
+}
+
+// clientConfig returns a TLS config for outgoing requests that trusts the
+// current CA pool
+func (s *certStore) clientConfig(base *tls.Config) *tls.Config {
+	config := base.Clone()
+	config.GetConfigForClient = nil
+	config.RootCAs = s.bundle().caPool
+	return config
+}
+
+// watch reloads the certificates whenever one of their files changes until
+// done is closed. Directories are watched rather than files so that atomic
+// replacements (such as Kubernetes secret symlink swaps) are seen.
+func (s *certStore) watch(done <-chan struct{}) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create cert watcher: %w", err)
+	}
+
+	dirs := make(map[string]bool)
+	for _, file := range []string{s.certFile, s.keyFile, s.caFile} {
+		dir := filepath.Dir(file)
+		if dirs[dir] {
+			continue
+		}
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close()
+			return fmt.Errorf("failed to watch %s: %w", dir, err)
+		}
+		dirs[dir] = true
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		var reload <-chan time.Time
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Has(fsnotify.Write) || event.Has(fsnotify.Create) || event.Has(fsnotify.Rename) || event.Has(fsnotify.Remove) {
+					reload = time.After(certReloadDebounce)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("Cert watcher error: %v", err)
+			case <-reload:
+				reload = nil
+				if err := s.Reload(); err != nil {
+					log.Printf("Failed to reload certificates: %v", err)
+					continue
+				}
+				log.Printf("Reloaded certificates from %s", s.certFile)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return nil
+}
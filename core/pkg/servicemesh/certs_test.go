@@ -0,0 +1,209 @@
+package servicemesh
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCA is a certificate authority for test certificates
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate CA key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create CA cert: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns a PEM certificate and key for 127.0.0.1 usable by both
+// servers and clients
+func (ca *testCA) issue(t *testing.T, serial int64) (certPEM, keyPEM []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "orders"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("create cert: %v", err)
+	}
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// writeCerts issues a certificate with the given serial and writes it, its
+// key and the CA into dir
+func (ca *testCA) writeCerts(t *testing.T, dir string, serial int64) (certFile, keyFile, caFile string) {
+	t.Helper()
+
+	certPEM, keyPEM := ca.issue(t, serial)
+	certFile = filepath.Join(dir, "tls.crt")
+	keyFile = filepath.Join(dir, "tls.key")
+	caFile = filepath.Join(dir, "ca.crt")
+	for file, data := range map[string][]byte{certFile: certPEM, keyFile: keyPEM, caFile: ca.pem} {
+		if err := os.WriteFile(file, data, 0o600); err != nil {
+			t.Fatalf("write %s: %v", file, err)
+		}
+	}
+	return certFile, keyFile, caFile
+}
+
+// serveTLS accepts mTLS connections with config until the test ends
+func serveTLS(t *testing.T, config *tls.Config) string {
+	t.Helper()
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", config)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+// servedSerial connects with a client certificate from ca and returns the
+// serial number of the server's certificate
+func servedSerial(t *testing.T, addr string, ca *testCA) int64 {
+	t.Helper()
+
+	certPEM, keyPEM := ca.issue(t, 100)
+	clientCert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatalf("client key pair: %v", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+
+	conn, err := tls.Dial("tcp", addr, &tls.Config{
+		RootCAs:      pool,
+		Certificates: []tls.Certificate{clientCert},
+		MinVersion:   tls.VersionTLS13,
+	})
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	return conn.ConnectionState().PeerCertificates[0].SerialNumber.Int64()
+}
+
+func TestReloadCertsChangesServedCertificate(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	certFile, keyFile, caFile := ca.writeCerts(t, dir, 1)
+
+	store, err := newCertStore(certFile, keyFile, caFile)
+	if err != nil {
+		t.Fatalf("newCertStore: %v", err)
+	}
+	addr := serveTLS(t, store.tlsConfig())
+	if serial := servedSerial(t, addr, ca); serial != 1 {
+		t.Fatalf("served serial %d, want 1", serial)
+	}
+
+	ca.writeCerts(t, dir, 2)
+	if err := store.Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if serial := servedSerial(t, addr, ca); serial != 2 {
+		t.Fatalf("served serial %d after reload, want 2", serial)
+	}
+
+	// A broken rotation keeps the working certificates
+	if err := os.WriteFile(keyFile, []byte("not a key"), 0o600); err != nil {
+		t.Fatalf("write key: %v", err)
+	}
+	if err := store.Reload(); err == nil {
+		t.Fatal("Reload accepted a broken key")
+	}
+	if serial := servedSerial(t, addr, ca); serial != 2 {
+		t.Fatalf("served serial %d after a failed reload, want 2", serial)
+	}
+}
+
+func TestCertWatcherReloadsOnChange(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	certFile, keyFile, caFile := ca.writeCerts(t, dir, 1)
+
+	store, err := newCertStore(certFile, keyFile, caFile)
+	if err != nil {
+		t.Fatalf("newCertStore: %v", err)
+	}
+	done := make(chan struct{})
+	defer close(done)
+	if err := store.watch(done); err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	addr := serveTLS(t, store.tlsConfig())
+
+	ca.writeCerts(t, dir, 2)
+	deadline := time.Now().Add(5 * time.Second)
+	for servedSerial(t, addr, ca) != 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("the rotated certificate was never served")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestSidecarReloadCertsRequiresMTLS(t *testing.T) {
+	proxy, err := NewSidecarProxy(&SidecarConfig{ServiceName: "orders"})
+	if err != nil {
+		t.Fatalf("NewSidecarProxy: %v", err)
+	}
+	if err := proxy.ReloadCerts(); err == nil {
+		t.Fatal("ReloadCerts succeeded without mTLS")
+	}
+}
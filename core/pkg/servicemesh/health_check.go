@@ -0,0 +1,240 @@
+package servicemesh
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HealthCheckConfig configures active health checking of instances
+type HealthCheckConfig struct {
+	Path               string        // Probed path, e.g. /health
+	Interval           time.Duration // Time between probe rounds
+	Timeout            time.Duration // Timeout of a single probe
+	UnhealthyThreshold int           // Failed probes in a row before marking unhealthy
+	HealthyThreshold   int           // Successful probes in a row before marking healthy
+	TLSConfig          *tls.Config   // Used to probe https instances
+}
+
+// DefaultHealthCheckConfig returns the default health check config
+func DefaultHealthCheckConfig() *HealthCheckConfig {
+	return &HealthCheckConfig{
+		Path:               "/health",
+		Interval:           10 * time.Second,
+		Timeout:            2 * time.Second,
+		UnhealthyThreshold: 3,
+		HealthyThreshold:   2,
+	}
+}
+
+// probeState counts an instance's consecutive probe results
+type probeState struct {
+	successes int
+	failures  int
+}
+
+// healthChecker periodically probes every instance in a registry
+type healthChecker struct {
+	registry *ServiceRegistry
+	config   *HealthCheckConfig
+	client   *http.Client
+	probes   map[string]*probeState
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// StartHealthChecks starts probing every registered instance's health
+// endpoint, marking instances unhealthy after UnhealthyThreshold failed probes
+// and healthy again after HealthyThreshold successful ones. Discover only
+// returns healthy instances. Instances using protocols other than http and
+// https are not probed. Any previous health checking is stopped first.
+func (r *ServiceRegistry) StartHealthChecks(config *HealthCheckConfig) {
+	if config == nil {
+		config = DefaultHealthCheckConfig()
+	}
+
+	r.StopHealthChecks()
+
+	checker := &healthChecker{
+		registry: r,
+		config:   config,
+		client: &http.Client{
+			Timeout: config.Timeout,
+			Transport: &http.Transport{
+				TLSClientConfig: config.TLSConfig,
+			},
+		},
+		probes: make(map[string]*probeState),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+
+	r.mu.Lock()
+	r.healthChecker = checker
+	r.mu.Unlock()
+
+	go checker.run()
+}
+
+// StopHealthChecks stops active health checking and waits for the current
+// probe round to finish
+func (r *ServiceRegistry) StopHealthChecks() {
+	r.mu.Lock()
+	checker := r.healthChecker
+	r.healthChecker = nil
+	r.mu.Unlock()
+
+	if checker != nil {
+		close(checker.stop)
+		<-checker.done
+	}
+}
+
+// run probes all instances every interval until stopped
+func (h *healthChecker) run() {
+	defer close(h.done)
+
+	ticker := time.NewTicker(h.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		h.checkAll()
+
+		select {
+		case <-ticker.C:
+		case <-h.stop:
+			return
+		}
+	}
+}
+
+// probeResult is the outcome of probing one instance
+type probeResult struct {
+	instance *ServiceInstance
+	err      error
+}
+
+// checkAll probes every instance concurrently and applies the results
+func (h *healthChecker) checkAll() {
+	h.registry.mu.RLock()
+	var instances []*ServiceInstance
+	for _, serviceInstances := range h.registry.services {
+		for _, inst := range serviceInstances {
+			if inst.Protocol == "" || inst.Protocol == "http" || inst.Protocol == "https" {
+				instances = append(instances, inst)
+			}
+		}
+	}
+	h.registry.mu.RUnlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-h.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	results := make([]probeResult, len(instances))
+	var wg sync.WaitGroup
+	for i, inst := range instances {
+		wg.Add(1)
+		go func(i int, inst *ServiceInstance) {
+			defer wg.Done()
+			results[i] = probeResult{instance: inst, err: h.probe(ctx, inst)}
+		}(i, inst)
+	}
+	wg.Wait()
+
+	// Results of a round interrupted by Stop are unreliable
+	if ctx.Err() != nil {
+		return
+	}
+
+	seen := make(map[string]bool, len(results))
+	h.registry.mu.Lock()
+	// A sync may have replaced the probed instances with fresh copies
+	current := make(map[string]*ServiceInstance)
+	for _, serviceInstances := range h.registry.services {
+		for _, inst := range serviceInstances {
+			current[instanceKey(inst)] = inst
+		}
+	}
+	for _, result := range results {
+		key := instanceKey(result.instance)
+		inst, ok := current[key]
+		if !ok {
+			continue
+		}
+		seen[key] = true
+		result.instance = inst
+		h.apply(key, result)
+	}
+	h.registry.mu.Unlock()
+
+	// Forget instances that are gone
+	for key := range h.probes {
+		if !seen[key] {
+			delete(h.probes, key)
+		}
+	}
+}
+
+// apply updates an instance's health from a probe result. The caller holds
+// the registry lock.
+func (h *healthChecker) apply(key string, result probeResult) {
+	state, ok := h.probes[key]
+	if !ok {
+		state = &probeState{}
+		h.probes[key] = state
+	}
+
+	inst := result.instance
+	if result.err != nil {
+		state.failures++
+		state.successes = 0
+		if state.failures >= h.config.UnhealthyThreshold && inst.Health != HealthStatusUnhealthy {
+			log.Printf("Instance %s of %s is unhealthy: %v", key, inst.ServiceName, result.err)
+			inst.Health = HealthStatusUnhealthy
+		}
+		return
+	}
+
+	state.successes++
+	state.failures = 0
+	if state.successes >= h.config.HealthyThreshold && inst.Health != HealthStatusHealthy {
+		log.Printf("Instance %s of %s is healthy", key, inst.ServiceName)
+		inst.Health = HealthStatusHealthy
+	}
+}
+
+// probe calls an instance's health endpoint; any 2xx response is healthy
+func (h *healthChecker) probe(ctx context.Context, inst *ServiceInstance) error {
+	protocol := inst.Protocol
+	if protocol == "" {
+		protocol = "http"
+	}
+
+	url := fmt.Sprintf("%s://%s:%d%s", protocol, inst.Host, inst.Port, h.config.Path)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("health check returned %d", resp.StatusCode)
+	}
+	return nil
+}
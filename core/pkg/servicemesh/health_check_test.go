@@ -0,0 +1,158 @@
+package servicemesh
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newHealthBackend starts an instance whose /healthz answers 200 while
+// healthy is set and 503 otherwise
+func newHealthBackend(t *testing.T, server *httptest.Server, healthy *atomic.Bool) *ServiceInstance {
+	t.Helper()
+
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthz" || !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	if server.TLS != nil {
+		server.StartTLS()
+	} else {
+		server.Start()
+	}
+	t.Cleanup(server.Close)
+
+	host, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	portNum, _ := strconv.Atoi(port)
+	protocol := "http"
+	if server.TLS != nil {
+		protocol = "https"
+	}
+	return &ServiceInstance{ServiceName: "orders", Host: host, Port: portNum, Protocol: protocol}
+}
+
+// waitFor polls cond until it holds or the deadline passes
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(3 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func testHealthCheckConfig() *HealthCheckConfig {
+	return &HealthCheckConfig{
+		Path:               "/healthz",
+		Interval:           20 * time.Millisecond,
+		Timeout:            time.Second,
+		UnhealthyThreshold: 2,
+		HealthyThreshold:   2,
+	}
+}
+
+func TestHealthChecksToggleDiscovery(t *testing.T) {
+	tests := []struct {
+		name   string
+		server *httptest.Server
+	}{
+		{"http", httptest.NewUnstartedServer(nil)},
+		{"https", func() *httptest.Server {
+			server := httptest.NewUnstartedServer(nil)
+			server.TLS = &tls.Config{}
+			return server
+		}()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var healthy atomic.Bool
+			healthy.Store(true)
+			inst := newHealthBackend(t, tt.server, &healthy)
+
+			registry := NewServiceRegistry("")
+			registry.Register(inst)
+
+			config := testHealthCheckConfig()
+			if tt.server.TLS != nil {
+				pool := x509.NewCertPool()
+				pool.AddCert(tt.server.Certificate())
+				config.TLSConfig = &tls.Config{RootCAs: pool}
+			}
+			registry.StartHealthChecks(config)
+			defer registry.StopHealthChecks()
+
+			discoverable := func() bool {
+				_, err := registry.Discover("orders")
+				return err == nil
+			}
+
+			healthy.Store(false)
+			waitFor(t, "the instance to be marked unhealthy", func() bool { return !discoverable() })
+			if _, err := registry.Discover("orders"); err == nil || err.Error() != "no healthy instances for service: orders" {
+				t.Fatalf("Discover error = %v", err)
+			}
+
+			healthy.Store(true)
+			waitFor(t, "the instance to recover", discoverable)
+		})
+	}
+}
+
+func TestHealthChecksSkipOtherProtocols(t *testing.T) {
+	registry := NewServiceRegistry("")
+	// Nothing listens here, so a probe would fail
+	registry.Register(&ServiceInstance{ServiceName: "orders", Host: "127.0.0.1", Port: 1, Protocol: "grpc"})
+	registry.StartHealthChecks(testHealthCheckConfig())
+	time.Sleep(100 * time.Millisecond)
+	registry.StopHealthChecks()
+
+	if _, err := registry.Discover("orders"); err != nil {
+		t.Fatalf("gRPC instance was probed: %v", err)
+	}
+}
+
+func TestHealthChecksSurviveControlPlaneSync(t *testing.T) {
+	var healthy atomic.Bool
+	inst := newHealthBackend(t, httptest.NewUnstartedServer(nil), &healthy)
+	inst.InstanceID = "orders-1"
+
+	// The control plane always reports the instance healthy
+	controlPlane := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		synced := *inst
+		synced.Health = HealthStatusHealthy
+		synced.LastHeartbeat = time.Now()
+		json.NewEncoder(w).Encode(map[string][]*ServiceInstance{"orders": {&synced}})
+	}))
+	defer controlPlane.Close()
+
+	registry := NewServiceRegistry(controlPlane.URL)
+	if err := registry.syncAllFromControlPlane(); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	registry.StartHealthChecks(testHealthCheckConfig())
+	defer registry.StopHealthChecks()
+
+	waitFor(t, "the instance to be marked unhealthy", func() bool {
+		_, err := registry.Discover("orders")
+		return err != nil
+	})
+	if err := registry.syncAllFromControlPlane(); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if _, err := registry.Discover("orders"); err == nil {
+		t.Fatal("a control plane sync overrode the probed health")
+	}
+}
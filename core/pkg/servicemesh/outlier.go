@@ -0,0 +1,253 @@
+package servicemesh
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// OutlierDetectionConfig configures passive health checking of instances
+type OutlierDetectionConfig struct {
+	ConsecutiveFailures int           // Failures in a row before an instance is ejected
+	BaseEjectionTime    time.Duration // Ejection time, multiplied by the number of times ejected
+	MaxEjectionTime     time.Duration // Upper bound on the ejection time
+	MaxEjectionPercent  int           // Max share of a service's instances ejected at once
+}
+
+// DefaultOutlierDetectionConfig returns the default outlier detection config
+func DefaultOutlierDetectionConfig() *OutlierDetectionConfig {
+	return &OutlierDetectionConfig{
+		ConsecutiveFailures: 5,
+		BaseEjectionTime:    30 * time.Second,
+		MaxEjectionTime:     5 * time.Minute,
+		MaxEjectionPercent:  50,
+	}
+}
+
+// outlierState tracks the results of one instance
+type outlierState struct {
+	serviceName         string
+	successes           int64
+	failures            int64
+	consecutiveFailures int
+	ejected             bool
+	ejectedAt           time.Time
+	ejectedUntil        time.Time
+	ejections           int
+	probing             bool
+	probeStartedAt      time.Time
+}
+
+// OutlierDetector ejects instances that fail repeatedly, similar to Envoy's
+// outlier detection. An ejected instance is left out of discovery until its
+// ejection time passes; then a single probe request is let through, and the
+// instance is re-admitted if the probe succeeds or ejected again, for longer,
+// if it fails.
+type OutlierDetector struct {
+	config    *OutlierDetectionConfig
+	instances map[string]*outlierState
+	mu        sync.Mutex
+}
+
+// NewOutlierDetector creates a new outlier detector
+func NewOutlierDetector(config *OutlierDetectionConfig) *OutlierDetector {
+	if config == nil {
+		config = DefaultOutlierDetectionConfig()
+	}
+
+	return &OutlierDetector{
+		config:    config,
+		instances: make(map[string]*outlierState),
+	}
+}
+
+// instanceKey identifies an instance across control plane syncs
+func instanceKey(inst *ServiceInstance) string {
+	if inst.InstanceID != "" {
+		return inst.InstanceID
+	}
+	return fmt.Sprintf("%s:%d", inst.Host, inst.Port)
+}
+
+// state returns the state of an instance, creating it if needed
+func (d *OutlierDetector) state(inst *ServiceInstance) *outlierState {
+	key := instanceKey(inst)
+	st, ok := d.instances[key]
+	if !ok {
+		st = &outlierState{serviceName: inst.ServiceName}
+		d.instances[key] = st
+	}
+	return st
+}
+
+// Filter returns the instances that are not ejected. An instance whose
+// ejection time has passed is returned once as a probe; it stays out of
+// rotation until the probe's result is recorded.
+func (d *OutlierDetector) Filter(instances []*ServiceInstance) []*ServiceInstance {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	admitted := make([]*ServiceInstance, 0, len(instances))
+	var probe *ServiceInstance
+	for _, inst := range instances {
+		st, ok := d.instances[instanceKey(inst)]
+		if !ok || !st.ejected {
+			admitted = append(admitted, inst)
+			continue
+		}
+		// A probe whose result never arrived is retried after the base ejection time
+		probeDue := !st.probing || now.Sub(st.probeStartedAt) >= d.config.BaseEjectionTime
+		if probe == nil && probeDue && !now.Before(st.ejectedUntil) {
+			probe = inst
+		}
+	}
+
+	// Route the probe on its own so it is the instance actually picked
+	if probe != nil {
+		st := d.state(probe)
+		st.probing = true
+		st.probeStartedAt = now
+		return []*ServiceInstance{probe}
+	}
+
+	return admitted
+}
+
+// RecordSuccess records a successful request to an instance, re-admitting
+// it if it was being probed
+func (d *OutlierDetector) RecordSuccess(inst *ServiceInstance) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	st := d.state(inst)
+	st.successes++
+	st.consecutiveFailures = 0
+	if st.ejected && st.probing {
+		st.ejected = false
+		st.probing = false
+	}
+}
+
+// RecordFailure records a failed request to an instance and ejects it once
+// it reaches the consecutive failure limit. total is the number of instances
+// the service has, used to enforce MaxEjectionPercent.
+func (d *OutlierDetector) RecordFailure(inst *ServiceInstance, total int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	st := d.state(inst)
+	st.failures++
+	st.consecutiveFailures++
+
+	// A failed probe ejects again straight away
+	if st.ejected {
+		if st.probing {
+			st.probing = false
+			d.eject(st)
+		}
+		return
+	}
+
+	if st.consecutiveFailures < d.config.ConsecutiveFailures {
+		return
+	}
+	if !d.canEject(st.serviceName, total) {
+		return
+	}
+	d.eject(st)
+}
+
+// canEject reports whether one more of a service's instances may be
+// ejected. As with Envoy, one instance may always be ejected.
+func (d *OutlierDetector) canEject(serviceName string, total int) bool {
+	ejected := 0
+	for _, st := range d.instances {
+		if st.serviceName == serviceName && st.ejected {
+			ejected++
+		}
+	}
+	return ejected == 0 || (ejected+1)*100 <= d.config.MaxEjectionPercent*total
+}
+
+// eject removes an instance from rotation, for longer each time
+func (d *OutlierDetector) eject(st *outlierState) {
+	st.ejections++
+	duration := d.config.BaseEjectionTime * time.Duration(st.ejections)
+	if d.config.MaxEjectionTime > 0 && duration > d.config.MaxEjectionTime {
+		duration = d.config.MaxEjectionTime
+	}
+
+	st.ejected = true
+	st.ejectedAt = time.Now()
+	st.ejectedUntil = st.ejectedAt.Add(duration)
+}
+
+// Retain drops the state of every instance not in live, e.g. after instances
+// were deregistered or evicted, so their ejections no longer count against
+// MaxEjectionPercent
+func (d *OutlierDetector) Retain(live []*ServiceInstance) {
+	keep := make(map[string]bool, len(live))
+	for _, inst := range live {
+		keep[instanceKey(inst)] = true
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for key := range d.instances {
+		if !keep[key] {
+			delete(d.instances, key)
+		}
+	}
+}
+
+// IsEjected reports whether an instance is currently out of rotation
+func (d *OutlierDetector) IsEjected(inst *ServiceInstance) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	st, ok := d.instances[instanceKey(inst)]
+	return ok && st.ejected
+}
+
+// GetMetrics returns outlier detection metrics
+func (d *OutlierDetector) GetMetrics() map[string]interface{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	ejected := 0
+	ejectionsTotal := 0
+	instances := make(map[string]interface{}, len(d.instances))
+	for key, st := range d.instances {
+		successRate := 1.0
+		if total := st.successes + st.failures; total > 0 {
+			successRate = float64(st.successes) / float64(total)
+		}
+
+		stats := map[string]interface{}{
+			"service":              st.serviceName,
+			"ejected":              st.ejected,
+			"ejections":            st.ejections,
+			"consecutive_failures": st.consecutiveFailures,
+			"success_rate":         successRate,
+			"requests_success":     st.successes,
+			"requests_failed":      st.failures,
+		}
+		if st.ejected {
+			ejected++
+			stats["ejected_at"] = st.ejectedAt
+			stats["ejected_until"] = st.ejectedUntil
+			stats["probing"] = st.probing
+		}
+
+		ejectionsTotal += st.ejections
+		instances[key] = stats
+	}
+
+	return map[string]interface{}{
+		"ejected_instances": ejected,
+		"ejections_total":   ejectionsTotal,
+		"instances":         instances,
+	}
+}
@@ -0,0 +1,189 @@
+package servicemesh
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestBackend starts an instance answering with the status in status
+func newTestBackend(t *testing.T, status *atomic.Int32) *ServiceInstance {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(status.Load()))
+	}))
+	t.Cleanup(server.Close)
+
+	host, port, _ := net.SplitHostPort(strings.TrimPrefix(server.URL, "http://"))
+	portNum, _ := strconv.Atoi(port)
+	return &ServiceInstance{ServiceName: "orders", Host: host, Port: portNum, Protocol: "http"}
+}
+
+func TestOutlierDetectorEjectsAndReadmits(t *testing.T) {
+	detector := NewOutlierDetector(&OutlierDetectionConfig{
+		ConsecutiveFailures: 3,
+		BaseEjectionTime:    50 * time.Millisecond,
+		MaxEjectionPercent:  100,
+	})
+	bad := &ServiceInstance{ServiceName: "orders", InstanceID: "bad"}
+	good := &ServiceInstance{ServiceName: "orders", InstanceID: "good"}
+	all := []*ServiceInstance{bad, good}
+
+	// A success in between resets the consecutive failures
+	detector.RecordFailure(bad, 2)
+	detector.RecordFailure(bad, 2)
+	detector.RecordSuccess(bad)
+	detector.RecordFailure(bad, 2)
+	detector.RecordFailure(bad, 2)
+	if detector.IsEjected(bad) {
+		t.Fatal("ejected before reaching the consecutive failure limit")
+	}
+	detector.RecordFailure(bad, 2)
+	if !detector.IsEjected(bad) {
+		t.Fatal("not ejected after three consecutive failures")
+	}
+	if got := detector.Filter(all); len(got) != 1 || got[0] != good {
+		t.Fatalf("Filter = %v, want only the good instance", got)
+	}
+
+	// Once the ejection time passes a single probe is let through
+	time.Sleep(60 * time.Millisecond)
+	if got := detector.Filter(all); len(got) != 1 || got[0] != bad {
+		t.Fatalf("Filter = %v, want the bad instance as a probe", got)
+	}
+	if got := detector.Filter(all); len(got) != 1 || got[0] != good {
+		t.Fatalf("Filter = %v, want the probe kept out of rotation", got)
+	}
+
+	// A failed probe ejects again, for twice as long
+	detector.RecordFailure(bad, 2)
+	time.Sleep(60 * time.Millisecond)
+	if got := detector.Filter(all); len(got) != 1 || got[0] != good {
+		t.Fatalf("Filter = %v, want the bad instance still ejected", got)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if got := detector.Filter(all); len(got) != 1 || got[0] != bad {
+		t.Fatalf("Filter = %v, want a second probe", got)
+	}
+
+	// A successful probe re-admits the instance
+	detector.RecordSuccess(bad)
+	if detector.IsEjected(bad) {
+		t.Fatal("still ejected after a successful probe")
+	}
+	if got := detector.Filter(all); len(got) != 2 {
+		t.Fatalf("Filter = %v, want both instances", got)
+	}
+
+	metrics := detector.GetMetrics()
+	if metrics["ejected_instances"] != 0 || metrics["ejections_total"] != 2 {
+		t.Fatalf("metrics = %v, want no ejected instances after two ejections", metrics)
+	}
+	stats := metrics["instances"].(map[string]interface{})["bad"].(map[string]interface{})
+	if stats["requests_failed"] != int64(6) || stats["requests_success"] != int64(2) {
+		t.Fatalf("bad instance stats = %v", stats)
+	}
+}
+
+func TestOutlierDetectorMaxEjectionPercent(t *testing.T) {
+	detector := NewOutlierDetector(&OutlierDetectionConfig{
+		ConsecutiveFailures: 1,
+		BaseEjectionTime:    time.Minute,
+		MaxEjectionPercent:  50,
+	})
+	instances := []*ServiceInstance{
+		{ServiceName: "orders", InstanceID: "a"},
+		{ServiceName: "orders", InstanceID: "b"},
+		{ServiceName: "orders", InstanceID: "c"},
+	}
+	for _, inst := range instances {
+		detector.RecordFailure(inst, len(instances))
+	}
+
+	// Ejecting a second instance of three would exceed 50%
+	if got := detector.GetMetrics()["ejected_instances"]; got != 1 {
+		t.Fatalf("ejected instances = %v, want 1", got)
+	}
+
+	// State of removed instances no longer counts
+	detector.Retain(instances[1:])
+	detector.RecordFailure(instances[1], 2)
+	if !detector.IsEjected(instances[1]) {
+		t.Fatal("instance b not ejected after the ejected instance was removed")
+	}
+}
+
+func TestSidecarEjectsFailingInstance(t *testing.T) {
+	var badStatus, goodStatus atomic.Int32
+	badStatus.Store(http.StatusInternalServerError)
+	goodStatus.Store(http.StatusOK)
+
+	proxy, err := NewSidecarProxy(&SidecarConfig{
+		ServiceName: "gateway",
+		OutlierDetectionCfg: &OutlierDetectionConfig{
+			ConsecutiveFailures: 2,
+			BaseEjectionTime:    100 * time.Millisecond,
+			MaxEjectionPercent:  50,
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewSidecarProxy: %v", err)
+	}
+	bad := newTestBackend(t, &badStatus)
+	good := newTestBackend(t, &goodStatus)
+	proxy.registry.Register(bad)
+	proxy.registry.Register(good)
+
+	send := func(t *testing.T) int {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+		req.Header.Set("X-Target-Service", "orders")
+		resp, err := proxy.app.Test(req, -1)
+		if err != nil {
+			t.Fatalf("request: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// Every request eventually lands on the good instance once the bad one is out
+	for i := 0; i < 50 && !proxy.registry.outliers.IsEjected(bad); i++ {
+		send(t)
+	}
+	if !proxy.registry.outliers.IsEjected(bad) {
+		t.Fatal("failing instance was never ejected")
+	}
+	for i := 0; i < 10; i++ {
+		if status := send(t); status != http.StatusOK {
+			t.Fatalf("request %d to an ejected instance: status %d", i, status)
+		}
+	}
+
+	metrics := proxy.GetMetrics()
+	if metrics["ejected_instances"] != 1 {
+		t.Fatalf("ejected_instances = %v, want 1", metrics["ejected_instances"])
+	}
+	outliers := metrics["outlier_detection"].(map[string]interface{})["instances"].(map[string]interface{})
+	if stats := outliers[bad.InstanceID].(map[string]interface{}); stats["ejected"] != true {
+		t.Fatalf("bad instance stats = %v, want ejected", stats)
+	}
+
+	// After recovering, the probe re-admits the instance
+	badStatus.Store(http.StatusOK)
+	time.Sleep(110 * time.Millisecond)
+	if status := send(t); status != http.StatusOK {
+		t.Fatalf("probe: status %d", status)
+	}
+	if proxy.registry.outliers.IsEjected(bad) {
+		t.Fatal("recovered instance was not re-admitted")
+	}
+	if got := proxy.GetMetrics()["ejected_instances"]; got != 0 {
+		t.Fatalf("ejected_instances = %v after re-admission, want 0", got)
+	}
+}
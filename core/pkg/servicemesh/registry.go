@@ -1,8 +1,10 @@
 package servicemesh
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
 	"sync"
 	"time"
@@ -10,12 +12,25 @@ import (
 
 // ServiceRegistry manages service discovery
 type ServiceRegistry struct {
-	controlPlane string
-	services     map[string][]*ServiceInstance
-	mu           sync.RWMutex
-	lastSync     time.Time
+	controlPlane  string
+	services      map[string][]*ServiceInstance
+	mu            sync.RWMutex
+	lastSync      time.Time
+	outliers      *OutlierDetector
+	healthChecker *healthChecker
+	heartbeatTTL  time.Duration
+	ttlChanged    chan struct{}
 }
 
+const (
+	// DefaultHeartbeatTTL is how long an instance stays registered without a
+	// heartbeat; the sidecar sends one every 10 seconds
+	DefaultHeartbeatTTL = 30 * time.Second
+
+	// registrySyncInterval is how often the registry syncs with the control plane
+	registrySyncInterval = 30 * time.Second
+)
+
 // ServiceInstance represents a service instance
 type ServiceInstance struct {
 	ServiceName string            `json:"service_name"`
@@ -43,6 +58,8 @@ func NewServiceRegistry(controlPlane string) *ServiceRegistry {
 	registry := &ServiceRegistry{
 		controlPlane: controlPlane,
 		services:     make(map[string][]*ServiceInstance),
+		heartbeatTTL: DefaultHeartbeatTTL,
+		ttlChanged:   make(chan struct{}, 1),
 	}
 
 	// Start background sync
@@ -81,6 +98,7 @@ func (r *ServiceRegistry) Deregister(serviceName string) error {
 	defer r.mu.Unlock()
 
 	delete(r.services, serviceName)
+	r.pruneOutliers()
 
 	if r.controlPlane != "" {
 		return r.deregisterFromControlPlane(serviceName)
@@ -110,18 +128,30 @@ func (r *ServiceRegistry) Discover(serviceName string) (*ServiceInstance, error)
 		return nil, fmt.Errorf("no instances found for service: %s", serviceName)
 	}
 
-	// Filter healthy instances
+	// Filter healthy instances; health checks update Health under the lock.
+	// Stale instances are skipped even before they are evicted.
 	healthy := make([]*ServiceInstance, 0)
+	r.mu.RLock()
+	now := time.Now()
 	for _, inst := range instances {
-		if inst.Health == HealthStatusHealthy {
+		if inst.Health == HealthStatusHealthy && !r.isStale(inst, now) {
 			healthy = append(healthy, inst)
 		}
 	}
+	r.mu.RUnlock()
 
 	if len(healthy) == 0 {
 		return nil, fmt.Errorf("no healthy instances for service: %s", serviceName)
 	}
 
+	// Leave out instances ejected by outlier detection
+	if r.outliers != nil {
+		healthy = r.outliers.Filter(healthy)
+		if len(healthy) == 0 {
+			return nil, fmt.Errorf("all instances ejected for service: %s", serviceName)
+		}
+	}
+
 	// Simple round-robin (can be enhanced with load balancing)
 	return healthy[time.Now().UnixNano()%int64(len(healthy))], nil
 }
@@ -170,6 +200,63 @@ func (r *ServiceRegistry) UpdateHealth(serviceName, instanceID string, status He
 	}
 }
 
+// SetOutlierDetection enables passive health checking: instances that fail
+// repeatedly are ejected from Discover results for a while. Results are
+// reported with ReportSuccess and ReportFailure.
+func (r *ServiceRegistry) SetOutlierDetection(config *OutlierDetectionConfig) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.outliers = NewOutlierDetector(config)
+}
+
+// ReportSuccess records a successful request to an instance
+func (r *ServiceRegistry) ReportSuccess(instance *ServiceInstance) {
+	if r.outliers != nil {
+		r.outliers.RecordSuccess(instance)
+	}
+}
+
+// ReportFailure records a failed request to an instance
+func (r *ServiceRegistry) ReportFailure(instance *ServiceInstance) {
+	if r.outliers == nil {
+		return
+	}
+
+	r.mu.RLock()
+	total := len(r.services[instance.ServiceName])
+	r.mu.RUnlock()
+
+	r.outliers.RecordFailure(instance, total)
+}
+
+// OutlierMetrics returns outlier detection metrics, or nil if disabled
+func (r *ServiceRegistry) OutlierMetrics() map[string]interface{} {
+	if r.outliers == nil {
+		return nil
+	}
+	return r.outliers.GetMetrics()
+}
+
+// SetHeartbeatTTL sets how long an instance may go without a heartbeat
+// before it is evicted. Zero disables eviction.
+func (r *ServiceRegistry) SetHeartbeatTTL(ttl time.Duration) {
+	r.mu.Lock()
+	r.heartbeatTTL = ttl
+	r.mu.Unlock()
+
+	// Wake the sync loop so a shorter TTL takes effect straight away
+	select {
+	case r.ttlChanged <- struct{}{}:
+	default:
+	}
+}
+
+// isStale reports whether an instance's heartbeat is older than the TTL. The
+// caller holds the registry lock.
+func (r *ServiceRegistry) isStale(inst *ServiceInstance, now time.Time) bool {
+	return r.heartbeatTTL > 0 && now.Sub(inst.LastHeartbeat) >= r.heartbeatTTL
+}
+
 // ListServices lists all registered services
 func (r *ServiceRegistry) ListServices() []string {
 	r.mu.RLock()
@@ -190,18 +277,40 @@ func (r *ServiceRegistry) GetServiceInstances(serviceName string) []*ServiceInst
 	return r.services[serviceName]
 }
 
-// syncLoop periodically syncs with control plane
+// syncLoop periodically syncs with control plane and evicts instances whose
+// heartbeat has expired
 func (r *ServiceRegistry) syncLoop() {
-	if r.controlPlane == "" {
-		return
+	for {
+		select {
+		case <-time.After(r.syncInterval()):
+		case <-r.ttlChanged:
+			// Recompute the interval for the new TTL
+			continue
+		}
+
+		if r.controlPlane != "" {
+			r.syncAllFromControlPlane()
+		}
+
+		r.mu.RLock()
+		ttl := r.heartbeatTTL
+		r.mu.RUnlock()
+		if ttl > 0 {
+			r.CleanupStaleInstances(ttl)
+		}
 	}
+}
 
-	ticker := time.NewTicker(30 * time.Second)
-	defer ticker.Stop()
+// syncInterval returns the time between sync loop runs, short enough that
+// instances are evicted soon after their heartbeat expires
+func (r *ServiceRegistry) syncInterval() time.Duration {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 
-	for range ticker.C {
-		r.syncAllFromControlPlane()
+	if r.heartbeatTTL > 0 && r.heartbeatTTL/2 < registrySyncInterval {
+		return r.heartbeatTTL / 2
 	}
+	return registrySyncInterval
 }
 
 // registerWithControlPlane registers with control plane
@@ -214,7 +323,7 @@ func (r *ServiceRegistry) registerWithControlPlane(instance *ServiceInstance) er
 	resp, err := http.Post(
 		fmt.Sprintf("%s/api/v1/services/register", r.controlPlane),
 		"application/json",
-		nil,
+		bytes.NewReader(body),
 	)
 	if err != nil {
 		return err
@@ -268,7 +377,9 @@ func (r *ServiceRegistry) syncFromControlPlane(serviceName string) error {
 	}
 
 	r.mu.Lock()
+	r.keepProbedHealth(instances, r.services[serviceName])
 	r.services[serviceName] = instances
+	r.pruneOutliers()
 	r.mu.Unlock()
 
 	return nil
@@ -293,14 +404,43 @@ func (r *ServiceRegistry) syncAllFromControlPlane() error {
 		return err
 	}
 
+	if allServices == nil {
+		allServices = make(map[string][]*ServiceInstance)
+	}
+
 	r.mu.Lock()
+	for serviceName, instances := range allServices {
+		r.keepProbedHealth(instances, r.services[serviceName])
+	}
 	r.services = allServices
 	r.lastSync = time.Now()
+	r.pruneOutliers()
 	r.mu.Unlock()
 
 	return nil
 }
 
+// keepProbedHealth carries the health of known instances over to their
+// synced copies while health checks run, so the local probe verdict isn't
+// replaced by the control plane's on every sync. New instances keep the
+// control plane's health until they are probed. The caller holds the
+// registry lock.
+func (r *ServiceRegistry) keepProbedHealth(synced, known []*ServiceInstance) {
+	if r.healthChecker == nil || len(known) == 0 {
+		return
+	}
+
+	health := make(map[string]HealthStatus, len(known))
+	for _, inst := range known {
+		health[instanceKey(inst)] = inst.Health
+	}
+	for _, inst := range synced {
+		if status, ok := health[instanceKey(inst)]; ok {
+			inst.Health = status
+		}
+	}
+}
+
 // heartbeatToControlPlane sends heartbeat to control plane
 func (r *ServiceRegistry) heartbeatToControlPlane(serviceName string) error {
 	resp, err := http.Post(
@@ -327,8 +467,26 @@ func (r *ServiceRegistry) CleanupStaleInstances(timeout time.Duration) {
 		for _, inst := range instances {
 			if now.Sub(inst.LastHeartbeat) < timeout {
 				healthy = append(healthy, inst)
+				continue
 			}
+			log.Printf("Evicting instance %s of %s: no heartbeat since %s",
+				instanceKey(inst), serviceName, inst.LastHeartbeat.Format(time.RFC3339))
 		}
 		r.services[serviceName] = healthy
 	}
+	r.pruneOutliers()
+}
+
+// pruneOutliers drops the outlier detection state of instances that are no
+// longer registered. The caller holds the registry lock.
+func (r *ServiceRegistry) pruneOutliers() {
+	if r.outliers == nil {
+		return
+	}
+
+	var live []*ServiceInstance
+	for _, instances := range r.services {
+		live = append(live, instances...)
+	}
+	r.outliers.Retain(live)
 }
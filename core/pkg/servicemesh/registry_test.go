@@ -0,0 +1,99 @@
+package servicemesh
+
+import (
+	"testing"
+	"time"
+)
+
+// registerWithHeartbeat registers an instance whose last heartbeat was at
+// the given time
+func registerWithHeartbeat(t *testing.T, registry *ServiceRegistry, id string, heartbeat time.Time) *ServiceInstance {
+	t.Helper()
+
+	inst := &ServiceInstance{ServiceName: "orders", InstanceID: id, Host: "127.0.0.1", Port: 8080, Protocol: "http"}
+	if err := registry.Register(inst); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	registry.mu.Lock()
+	inst.LastHeartbeat = heartbeat
+	registry.mu.Unlock()
+	return inst
+}
+
+func TestDiscoverSkipsStaleInstances(t *testing.T) {
+	registry := NewServiceRegistry("")
+	registerWithHeartbeat(t, registry, "stale", time.Now().Add(-time.Minute))
+
+	if _, err := registry.Discover("orders"); err == nil {
+		t.Fatal("discovered an instance whose heartbeat expired")
+	}
+
+	fresh := registerWithHeartbeat(t, registry, "fresh", time.Now())
+	for i := 0; i < 10; i++ {
+		inst, err := registry.Discover("orders")
+		if err != nil || inst != fresh {
+			t.Fatalf("Discover = %v, %v; want the fresh instance", inst, err)
+		}
+	}
+
+	// A heartbeat brings a stale instance back
+	registry.Heartbeat("orders")
+	if got := len(registry.GetServiceInstances("orders")); got != 2 {
+		t.Fatalf("%d instances, want 2", got)
+	}
+	seen := make(map[string]bool)
+	for i := 0; i < 200 && len(seen) < 2; i++ {
+		inst, err := registry.Discover("orders")
+		if err != nil {
+			t.Fatalf("Discover: %v", err)
+		}
+		seen[inst.InstanceID] = true
+	}
+	if !seen["stale"] {
+		t.Fatal("instance not discoverable after a heartbeat")
+	}
+}
+
+func TestCleanupStaleInstancesEvicts(t *testing.T) {
+	registry := NewServiceRegistry("")
+	registry.SetOutlierDetection(&OutlierDetectionConfig{ConsecutiveFailures: 1, BaseEjectionTime: time.Minute, MaxEjectionPercent: 100})
+	stale := registerWithHeartbeat(t, registry, "stale", time.Now().Add(-time.Minute))
+	registerWithHeartbeat(t, registry, "fresh", time.Now())
+	registry.ReportFailure(stale)
+
+	registry.CleanupStaleInstances(30 * time.Second)
+
+	instances := registry.GetServiceInstances("orders")
+	if len(instances) != 1 || instances[0].InstanceID != "fresh" {
+		t.Fatalf("instances = %v, want only the fresh one", instances)
+	}
+	// The evicted instance's ejection is forgotten
+	if got := registry.OutlierMetrics()["ejected_instances"]; got != 0 {
+		t.Fatalf("ejected_instances = %v after eviction, want 0", got)
+	}
+}
+
+func TestSyncLoopEvictsExpiredInstances(t *testing.T) {
+	registry := NewServiceRegistry("")
+	// Let the sync loop start waiting with the default TTL first
+	time.Sleep(20 * time.Millisecond)
+	registry.SetHeartbeatTTL(100 * time.Millisecond)
+	registerWithHeartbeat(t, registry, "orders-1", time.Now())
+
+	waitFor(t, "the instance to be evicted", func() bool {
+		return len(registry.GetServiceInstances("orders")) == 0
+	})
+	if _, err := registry.Discover("orders"); err == nil {
+		t.Fatal("evicted instance is still discoverable")
+	}
+}
+
+func TestZeroHeartbeatTTLDisablesEviction(t *testing.T) {
+	registry := NewServiceRegistry("")
+	registry.SetHeartbeatTTL(0)
+	registerWithHeartbeat(t, registry, "orders-1", time.Now().Add(-time.Hour))
+
+	if _, err := registry.Discover("orders"); err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+}
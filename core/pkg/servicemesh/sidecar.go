@@ -3,15 +3,15 @@ package servicemesh
 import (
 	"context"
 	"crypto/tls"
-	"crypto/x509"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
-	"os"
 	"sync"
 	"time"
 
+	"neonexcore/pkg/logger"
+
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -25,6 +25,7 @@ type SidecarProxy struct {
 	metrics        *ProxyMetrics
 	registry       *ServiceRegistry
 	tlsConfig      *tls.Config
+	certs          *certStore
 	routingRules   map[string]*RoutingRule
 	circuitBreaker *CircuitBreaker
 	mu             sync.RWMutex
@@ -34,20 +35,22 @@ type SidecarProxy struct {
 
 // SidecarConfig configuration for sidecar proxy
 type SidecarConfig struct {
-	ServiceName       string
-	ServicePort       int
-	ProxyPort         int
-	ControlPlane      string
-	EnableMTLS        bool
-	EnableTracing     bool
-	EnableMetrics     bool
-	EnableRetry       bool
-	MaxRetries        int
-	RetryTimeout      time.Duration
-	CircuitBreakerCfg *CircuitBreakerConfig
-	TLSCertFile       string
-	TLSKeyFile        string
-	TLSCAFile         string
+	ServiceName         string
+	ServicePort         int
+	ProxyPort           int
+	ControlPlane        string
+	EnableMTLS          bool
+	EnableTracing       bool
+	EnableMetrics       bool
+	EnableRetry         bool
+	MaxRetries          int
+	RetryTimeout        time.Duration
+	CircuitBreakerCfg   *CircuitBreakerConfig
+	OutlierDetectionCfg *OutlierDetectionConfig // Per-instance ejection, disabled when nil
+	HealthCheckCfg      *HealthCheckConfig      // Active health checking, disabled when nil
+	TLSCertFile         string
+	TLSKeyFile          string
+	TLSCAFile           string
 }
 
 // ProxyMetrics metrics collected by sidecar
@@ -106,6 +109,11 @@ func NewSidecarProxy(config *SidecarConfig) (*SidecarProxy, error) {
 			return nil, fmt.Errorf("failed to setup mTLS: %w", err)
 		}
 		proxy.tlsConfig = tlsConfig
+
+		// Pick up rotated certificates without a restart
+		if err := proxy.certs.watch(proxy.shutdown); err != nil {
+			log.Printf("Certificate watching disabled: %v", err)
+		}
 	}
 
 	// Initialize circuit breaker
@@ -115,6 +123,9 @@ func NewSidecarProxy(config *SidecarConfig) (*SidecarProxy, error) {
 
 	// Initialize service registry
 	proxy.registry = NewServiceRegistry(config.ControlPlane)
+	if config.OutlierDetectionCfg != nil {
+		proxy.registry.SetOutlierDetection(config.OutlierDetectionCfg)
+	}
 
 	// Setup Fiber app for proxy
 	proxy.app = fiber.New(fiber.Config{
@@ -126,32 +137,27 @@ func NewSidecarProxy(config *SidecarConfig) (*SidecarProxy, error) {
 	return proxy, nil
 }
 
-// setupMTLS configures mutual TLS
+// setupMTLS configures mutual TLS. Certificates are resolved per handshake,
+// so reloading them affects new connections without a restart.
 func (s *SidecarProxy) setupMTLS() (*tls.Config, error) {
-	// Load CA certificate
-	caCert, err := os.ReadFile(s.config.TLSCAFile)
+	certs, err := newCertStore(s.config.TLSCertFile, s.config.TLSKeyFile, s.config.TLSCAFile)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read CA cert: %w", err)
+		return nil, err
 	}
+	s.certs = certs
 
-	caCertPool := x509.NewCertPool()
-	if !caCertPool.AppendCertsFromPEM(caCert) {
-		return nil, fmt.Errorf("failed to append CA cert")
-	}
+	return certs.tlsConfig(), nil
+}
 
-	// Load client certificate
-	cert, err := tls.LoadX509KeyPair(s.config.TLSCertFile, s.config.TLSKeyFile)
-	if err != nil {
-		return nil, fmt.Errorf("failed to load client cert: %w", err)
+// ReloadCerts reloads the mTLS certificate, key and CA files. New connections
+// use the new certificates; established ones are unaffected. If loading fails
+// the current certificates stay in use. Files are also reloaded automatically
+// when they change on disk.
+func (s *SidecarProxy) ReloadCerts() error {
+	if s.certs == nil {
+		return fmt.Errorf("mTLS is not enabled")
 	}
-
-	return &tls.Config{
-		Certificates: []tls.Certificate{cert},
-		RootCAs:      caCertPool,
-		ClientCAs:    caCertPool,
-		ClientAuth:   tls.RequireAndVerifyClientCert,
-		MinVersion:   tls.VersionTLS13,
-	}, nil
+	return s.certs.Reload()
 }
 
 // setupRoutes configures proxy routes
@@ -244,8 +250,10 @@ func (s *SidecarProxy) proxyHandler(c *fiber.Ctx) error {
 
 		resp, lastErr = s.forwardRequest(c, targetURL, rule)
 		if lastErr == nil && resp.StatusCode < 500 {
+			s.registry.ReportSuccess(instance)
 			break
 		}
+		s.registry.ReportFailure(instance)
 	}
 
 	if lastErr != nil {
@@ -296,7 +304,7 @@ func (s *SidecarProxy) forwardRequest(c *fiber.Ctx, targetURL string, rule *Rout
 
 	if s.tlsConfig != nil {
 		client.Transport = &http.Transport{
-			TLSClientConfig: s.tlsConfig,
+			TLSClientConfig: s.certs.clientConfig(s.tlsConfig),
 		}
 	}
 
@@ -311,11 +319,21 @@ func (s *SidecarProxy) forwardRequest(c *fiber.Ctx, targetURL string, rule *Rout
 		req.Header.Set(string(key), string(value))
 	})
 
-	// Add tracing headers if enabled
+	// Add tracing headers if enabled, continuing the caller's trace when present
 	if s.config.EnableTracing {
-		req.Header.Set("X-Request-ID", c.Get("X-Request-ID", generateRequestID()))
-		req.Header.Set("X-B3-TraceId", generateTraceID())
-		req.Header.Set("X-B3-SpanId", generateSpanID())
+		ctx := c.UserContext()
+		if _, ok := logger.TraceFromContext(ctx); !ok {
+			trace := logger.TraceContext{
+				TraceID: c.Get(logger.HeaderB3TraceID, generateTraceID()),
+				SpanID:  c.Get(logger.HeaderB3SpanID, generateSpanID()),
+				Sampled: c.Get(logger.HeaderB3Sampled),
+			}
+			ctx = logger.ContextWithTrace(ctx, trace)
+		}
+		if logger.RequestIDFromContext(ctx) == "" {
+			ctx = logger.ContextWithRequestID(ctx, c.Get(logger.HeaderRequestID, generateRequestID()))
+		}
+		logger.InjectHeaders(ctx, req.Header)
 	}
 
 	// Add service mesh headers
@@ -379,7 +397,7 @@ func (s *SidecarProxy) GetMetrics() map[string]interface{} {
 		avgDuration = total / time.Duration(len(s.metrics.RequestDuration))
 	}
 
-	return map[string]interface{}{
+	metrics := map[string]interface{}{
 		"requests_total":        s.metrics.RequestsTotal,
 		"requests_success":      s.metrics.RequestsSuccess,
 		"requests_failed":       s.metrics.RequestsFailed,
@@ -390,6 +408,13 @@ func (s *SidecarProxy) GetMetrics() map[string]interface{} {
 		"circuit_breaker_open":  s.circuitBreaker != nil && s.circuitBreaker.IsOpen(),
 		"retries_total":         s.metrics.RetriesTotal,
 	}
+
+	if outliers := s.registry.OutlierMetrics(); outliers != nil {
+		metrics["ejected_instances"] = outliers["ejected_instances"]
+		metrics["outlier_detection"] = outliers
+	}
+
+	return metrics
 }
 
 // Start starts the sidecar proxy
@@ -410,7 +435,25 @@ func (s *SidecarProxy) Start() error {
 	// Start heartbeat
 	go s.heartbeat()
 
-	return s.app.Listen(fmt.Sprintf(":%d", s.proxyPort))
+	// Probe discovered instances, over mTLS when enabled
+	if s.config.HealthCheckCfg != nil {
+		healthCfg := *s.config.HealthCheckCfg
+		if healthCfg.TLSConfig == nil && s.certs != nil {
+			healthCfg.TLSConfig = s.certs.clientConfig(s.tlsConfig)
+		}
+		s.registry.StartHealthChecks(&healthCfg)
+	}
+
+	addr := fmt.Sprintf(":%d", s.proxyPort)
+	if s.tlsConfig != nil {
+		ln, err := tls.Listen("tcp", addr, s.tlsConfig)
+		if err != nil {
+			return fmt.Errorf("failed to listen: %w", err)
+		}
+		return s.app.Listener(ln)
+	}
+
+	return s.app.Listen(addr)
 }
 
 // heartbeat sends periodic heartbeats to control plane
@@ -433,6 +476,7 @@ func (s *SidecarProxy) heartbeat() {
 // Stop stops the sidecar proxy
 func (s *SidecarProxy) Stop(ctx context.Context) error {
 	close(s.shutdown)
+	s.registry.StopHealthChecks()
 	
 	// Deregister from control plane
 	if err := s.registry.Deregister(s.serviceName); err != nil {
@@ -2,6 +2,7 @@ package servicemesh
 
 import (
 	"fmt"
+	"math/rand"
 	"sync"
 )
 
@@ -254,7 +255,5 @@ func (tm *TrafficManager) ListPolicies() []string {
 
 // randomInt returns random int between 0 and max (exclusive)
 func (tm *TrafficManager) randomInt(max int) int {
-	// Simple pseudo-random for demonstration
-	// In production, use crypto/rand or math/rand with seed
-	return int(tm.mu.RLock() + tm.mu.RUnlock()) % max
+	return rand.Intn(max)
 }
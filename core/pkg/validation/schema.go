@@ -0,0 +1,198 @@
+package validation
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// ValidateSchema validates a decoded JSON value against a JSON schema.
+// The supported keywords are type, enum, properties, required,
+// additionalProperties, items, minimum, maximum, minLength, maxLength,
+// pattern, minItems and maxItems. Errors are keyed by field path, e.g.
+// "port" or "servers[0].host"; errors on the value itself are keyed by "$".
+func ValidateSchema(schema map[string]interface{}, value interface{}) map[string]string {
+	errors := make(map[string]string)
+	validateSchema(schema, value, "", errors)
+	if len(errors) == 0 {
+		return nil
+	}
+	return errors
+}
+
+// validateSchema validates value against schema, adding errors under path
+func validateSchema(schema map[string]interface{}, value interface{}, path string, errors map[string]string) {
+	field := path
+	if field == "" {
+		field = "$"
+	}
+
+	if types := schemaTypes(schema["type"]); len(types) > 0 {
+		matched := false
+		for _, t := range types {
+			if schemaTypeMatches(t, value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			errors[field] = fmt.Sprintf("%s must be of type %s", field, strings.Join(types, " or "))
+			return
+		}
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok && !schemaEnumContains(enum, value) {
+		errors[field] = fmt.Sprintf("%s must be one of %s", field, schemaEnumString(enum))
+		return
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		validateSchemaObject(schema, v, path, errors)
+	case []interface{}:
+		if n, ok := schemaNumber(schema["minItems"]); ok && float64(len(v)) < n {
+			errors[field] = fmt.Sprintf("%s must contain at least %v items", field, n)
+		}
+		if n, ok := schemaNumber(schema["maxItems"]); ok && float64(len(v)) > n {
+			errors[field] = fmt.Sprintf("%s must contain at most %v items", field, n)
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				validateSchema(items, item, fmt.Sprintf("%s[%d]", path, i), errors)
+			}
+		}
+	case string:
+		length := float64(len([]rune(v)))
+		if n, ok := schemaNumber(schema["minLength"]); ok && length < n {
+			errors[field] = fmt.Sprintf("%s must be at least %v characters", field, n)
+		}
+		if n, ok := schemaNumber(schema["maxLength"]); ok && length > n {
+			errors[field] = fmt.Sprintf("%s must be at most %v characters", field, n)
+		}
+		if pattern, ok := schema["pattern"].(string); ok {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				errors[field] = fmt.Sprintf("%s has an invalid pattern in the schema", field)
+			} else if !re.MatchString(v) {
+				errors[field] = fmt.Sprintf("%s must match pattern %s", field, pattern)
+			}
+		}
+	case float64:
+		if n, ok := schemaNumber(schema["minimum"]); ok && v < n {
+			errors[field] = fmt.Sprintf("%s must be at least %v", field, n)
+		}
+		if n, ok := schemaNumber(schema["maximum"]); ok && v > n {
+			errors[field] = fmt.Sprintf("%s must be at most %v", field, n)
+		}
+	}
+}
+
+// validateSchemaObject validates the properties of an object
+func validateSchemaObject(schema map[string]interface{}, object map[string]interface{}, path string, errors map[string]string) {
+	properties, _ := schema["properties"].(map[string]interface{})
+
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, r := range required {
+			name, _ := r.(string)
+			if _, exists := object[name]; name != "" && !exists {
+				field := schemaPath(path, name)
+				errors[field] = fmt.Sprintf("%s is required", field)
+			}
+		}
+	}
+
+	for name, value := range object {
+		field := schemaPath(path, name)
+		if property, ok := properties[name].(map[string]interface{}); ok {
+			validateSchema(property, value, field, errors)
+		} else if additional, ok := schema["additionalProperties"].(bool); ok && !additional {
+			errors[field] = fmt.Sprintf("%s is not allowed", field)
+		} else if additional, ok := schema["additionalProperties"].(map[string]interface{}); ok {
+			validateSchema(additional, value, field, errors)
+		}
+	}
+}
+
+// schemaPath returns the path of an object property
+func schemaPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// schemaTypes returns the types allowed by a "type" keyword
+func schemaTypes(t interface{}) []string {
+	switch t := t.(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		types := make([]string, 0, len(t))
+		for _, v := range t {
+			if s, ok := v.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+// schemaTypeMatches reports whether value is of a JSON schema type
+func schemaTypeMatches(t string, value interface{}) bool {
+	switch t {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	case "null":
+		return value == nil
+	}
+	return false
+}
+
+// schemaNumber returns a numeric keyword's value
+func schemaNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+// schemaEnumContains reports whether value is one of an enum's values
+func schemaEnumContains(enum []interface{}, value interface{}) bool {
+	for _, v := range enum {
+		if reflect.DeepEqual(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// schemaEnumString lists an enum's values
+func schemaEnumString(enum []interface{}) string {
+	values := make([]string, len(enum))
+	for i, v := range enum {
+		values[i] = fmt.Sprint(v)
+	}
+	return strings.Join(values, ", ")
+}
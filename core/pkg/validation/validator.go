@@ -22,6 +22,7 @@ func NewValidator() *Validator {
 	v.RegisterValidation("slug", validateSlug)
 	v.RegisterValidation("username", validateUsername)
 	v.RegisterValidation("semver", validateSemver)
+	v.RegisterValidation("semver_constraint", validateSemverConstraint)
 	
 	// Use JSON tag names in error messages
 	v.RegisterTagNameFunc(func(fld reflect.StructField) string {
@@ -35,7 +36,8 @@ func NewValidator() *Validator {
 	return &Validator{validate: v}
 }
 
-// Validate validates a struct
+// Validate validates a struct. Errors are keyed by field path, e.g.
+// "email" or "dependencies[0].version" for fields of nested structs.
 func (v *Validator) Validate(data interface{}) map[string]string {
 	err := v.validate.Struct(data)
 	if err == nil {
@@ -44,13 +46,20 @@ func (v *Validator) Validate(data interface{}) map[string]string {
 
 	errors := make(map[string]string)
 	for _, err := range err.(validator.ValidationErrors) {
-		field := err.Field()
-		errors[field] = formatError(err)
+		errors[fieldPath(err)] = formatError(err)
 	}
 
 	return errors
 }
 
+// fieldPath returns the path of a field below the validated struct
+func fieldPath(err validator.FieldError) string {
+	if _, path, ok := strings.Cut(err.Namespace(), "."); ok && path != "" {
+		return path
+	}
+	return err.Field()
+}
+
 // ValidateVar validates a single variable
 func (v *Validator) ValidateVar(field interface{}, tag string) error {
 	return v.validate.Var(field, tag)
@@ -104,6 +113,8 @@ func formatError(err validator.FieldError) string {
 		return fmt.Sprintf("%s must be a valid username (3-20 alphanumeric characters or underscore)", field)
 	case "semver":
 		return fmt.Sprintf("%s must be a valid semantic version (e.g., 1.0.0)", field)
+	case "semver_constraint":
+		return fmt.Sprintf("%s must be a valid version constraint (e.g., >=1.0.0, ^2.1.0 or *)", field)
 	case "uuid":
 		return fmt.Sprintf("%s must be a valid UUID", field)
 	case "uuid4":
@@ -152,6 +163,44 @@ func validateSemver(fl validator.FieldLevel) bool {
 	return match
 }
 
+// semverComparator matches one comparator of a version constraint, e.g.
+// ">=1.0.0", "^2.1", "~1.2.3-beta" or "1.x"
+var semverComparator = regexp.MustCompile(`^(>=|<=|>|<|=|\^|~)?v?(0|[1-9]\d*|x|X|\*)(\.(0|[1-9]\d*|x|X|\*)){0,2}(-[0-9A-Za-z-]+(\.[0-9A-Za-z-]+)*)?$`)
+
+// validateSemverConstraint validates version constraints: "*", or
+// comparators joined by spaces or commas (all must hold) and "||" (any)
+func validateSemverConstraint(fl validator.FieldLevel) bool {
+	constraint := strings.TrimSpace(fl.Field().String())
+	if constraint == "" {
+		return false
+	}
+
+	for _, alternative := range strings.Split(constraint, "||") {
+		comparators := strings.FieldsFunc(alternative, func(r rune) bool {
+			return r == ' ' || r == ','
+		})
+		if len(comparators) == 0 {
+			return false
+		}
+		operator := ""
+		for _, comparator := range comparators {
+			// Allow a space after the operator, e.g. ">= 1.0.0"
+			if strings.Trim(comparator, "<>=^~") == "" {
+				operator += comparator
+				continue
+			}
+			if !semverComparator.MatchString(operator + comparator) {
+				return false
+			}
+			operator = ""
+		}
+		if operator != "" {
+			return false
+		}
+	}
+	return true
+}
+
 // Common validation rules
 type LoginRequest struct {
 	Email    string `json:"email" validate:"required,email"`
@@ -2,6 +2,7 @@ package web3
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -18,9 +19,8 @@ type AuthProvider interface {
 
 // Web3Auth Web3 authentication
 type Web3Auth struct {
-	challenges map[string]*Challenge
-	sessions   map[string]*Session
-	mu         sync.RWMutex
+	store AuthStore
+	mu    sync.RWMutex
 }
 
 // Challenge authentication challenge
@@ -47,14 +47,14 @@ type WalletConnect struct {
 	Address     common.Address
 	ChainID     int
 	ConnectedAt time.Time
+	ExpiresAt   time.Time
 	Metadata    map[string]string
 }
 
-// NewWeb3Auth creates a new Web3 auth
+// NewWeb3Auth creates a new Web3 auth backed by an in-memory store
 func NewWeb3Auth() *Web3Auth {
 	auth := &Web3Auth{
-		challenges: make(map[string]*Challenge),
-		sessions:   make(map[string]*Session),
+		store: NewMemoryAuthStore(),
 	}
 
 	// Start cleanup routine
@@ -63,11 +63,23 @@ func NewWeb3Auth() *Web3Auth {
 	return auth
 }
 
-// GenerateChallenge generates authentication challenge
-func (a *Web3Auth) GenerateChallenge(address common.Address) (*Challenge, error) {
+// SetStore replaces the challenge and session store, e.g. with a GormAuthStore
+// or CacheAuthStore so sessions survive restarts. Call it before serving requests.
+func (a *Web3Auth) SetStore(store AuthStore) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
+	a.store = store
+}
+
+// getStore returns the current store
+func (a *Web3Auth) getStore() AuthStore {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.store
+}
 
+// GenerateChallenge generates authentication challenge
+func (a *Web3Auth) GenerateChallenge(address common.Address) (*Challenge, error) {
 	nonce := fmt.Sprintf("%d", time.Now().UnixNano())
 	message := fmt.Sprintf("Sign this message to authenticate with NeonexCore.\n\nAddress: %s\nNonce: %s\nTimestamp: %s",
 		address.Hex(), nonce, time.Now().Format(time.RFC3339))
@@ -81,7 +93,9 @@ func (a *Web3Auth) GenerateChallenge(address common.Address) (*Challenge, error)
 	}
 
 	key := address.Hex() + ":" + nonce
-	a.challenges[key] = challenge
+	if err := a.getStore().SaveChallenge(context.Background(), key, challenge); err != nil {
+		return nil, fmt.Errorf("failed to store challenge: %w", err)
+	}
 
 	return challenge, nil
 }
@@ -109,19 +123,20 @@ func (a *Web3Auth) VerifySignature(message, signature string, address common.Add
 
 // Authenticate authenticates a user
 func (a *Web3Auth) Authenticate(ctx context.Context, nonce string, signature string, address common.Address) (*Session, error) {
-	a.mu.Lock()
-	defer a.mu.Unlock()
+	store := a.getStore()
 
-	// Get challenge
+	// Take the challenge; it is consumed whether or not verification succeeds
 	key := address.Hex() + ":" + nonce
-	challenge, exists := a.challenges[key]
-	if !exists {
-		return nil, fmt.Errorf("challenge not found")
+	challenge, err := store.TakeChallenge(ctx, key)
+	if err != nil {
+		if errors.Is(err, ErrAuthRecordNotFound) {
+			return nil, fmt.Errorf("challenge not found")
+		}
+		return nil, fmt.Errorf("failed to load challenge: %w", err)
 	}
 
 	// Check expiration
 	if time.Now().After(challenge.ExpiresAt) {
-		delete(a.challenges, key)
 		return nil, fmt.Errorf("challenge expired")
 	}
 
@@ -144,22 +159,21 @@ func (a *Web3Auth) Authenticate(ctx context.Context, nonce string, signature str
 		Metadata:  make(map[string]interface{}),
 	}
 
-	a.sessions[session.ID] = session
-
-	// Remove used challenge
-	delete(a.challenges, key)
+	if err := store.SaveSession(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to store session: %w", err)
+	}
 
 	return session, nil
 }
 
 // GetSession gets a session by ID
 func (a *Web3Auth) GetSession(sessionID string) (*Session, error) {
-	a.mu.RLock()
-	defer a.mu.RUnlock()
-
-	session, exists := a.sessions[sessionID]
-	if !exists {
-		return nil, fmt.Errorf("session not found")
+	session, err := a.getStore().GetSession(context.Background(), sessionID)
+	if err != nil {
+		if errors.Is(err, ErrAuthRecordNotFound) {
+			return nil, fmt.Errorf("session not found")
+		}
+		return nil, fmt.Errorf("failed to load session: %w", err)
 	}
 
 	// Check expiration
@@ -172,41 +186,47 @@ func (a *Web3Auth) GetSession(sessionID string) (*Session, error) {
 
 // RevokeSession revokes a session
 func (a *Web3Auth) RevokeSession(sessionID string) error {
-	a.mu.Lock()
-	defer a.mu.Unlock()
-
-	if _, exists := a.sessions[sessionID]; !exists {
-		return fmt.Errorf("session not found")
+	if err := a.getStore().DeleteSession(context.Background(), sessionID); err != nil {
+		if errors.Is(err, ErrAuthRecordNotFound) {
+			return fmt.Errorf("session not found")
+		}
+		return fmt.Errorf("failed to revoke session: %w", err)
 	}
-
-	delete(a.sessions, sessionID)
 	return nil
 }
 
 // RefreshSession refreshes a session
 func (a *Web3Auth) RefreshSession(sessionID string) (*Session, error) {
-	a.mu.Lock()
-	defer a.mu.Unlock()
+	ctx := context.Background()
+	store := a.getStore()
 
-	session, exists := a.sessions[sessionID]
-	if !exists {
-		return nil, fmt.Errorf("session not found")
+	session, err := store.GetSession(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, ErrAuthRecordNotFound) {
+			return nil, fmt.Errorf("session not found")
+		}
+		return nil, fmt.Errorf("failed to load session: %w", err)
 	}
 
 	// Extend expiration
 	session.ExpiresAt = time.Now().Add(24 * time.Hour)
+	if err := store.SaveSession(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to store session: %w", err)
+	}
 
 	return session, nil
 }
 
 // ListSessions lists all active sessions for an address
 func (a *Web3Auth) ListSessions(address common.Address) []*Session {
-	a.mu.RLock()
-	defer a.mu.RUnlock()
+	stored, err := a.getStore().ListSessions(context.Background(), address)
+	if err != nil {
+		return []*Session{}
+	}
 
-	sessions := make([]*Session, 0)
-	for _, session := range a.sessions {
-		if session.Address == address && time.Now().Before(session.ExpiresAt) {
+	sessions := make([]*Session, 0, len(stored))
+	for _, session := range stored {
+		if time.Now().Before(session.ExpiresAt) {
 			sessions = append(sessions, session)
 		}
 	}
@@ -214,43 +234,45 @@ func (a *Web3Auth) ListSessions(address common.Address) []*Session {
 	return sessions
 }
 
-// cleanupExpired cleans up expired challenges and sessions
+// cleanupExpired purges expired challenges and sessions from the store
 func (a *Web3Auth) cleanupExpired() {
 	ticker := time.NewTicker(1 * time.Minute)
 	defer ticker.Stop()
 
 	for range ticker.C {
-		a.mu.Lock()
-
-		// Clean expired challenges
-		for key, challenge := range a.challenges {
-			if time.Now().After(challenge.ExpiresAt) {
-				delete(a.challenges, key)
-			}
-		}
-
-		// Clean expired sessions
-		for id, session := range a.sessions {
-			if time.Now().After(session.ExpiresAt) {
-				delete(a.sessions, id)
-			}
-		}
-
-		a.mu.Unlock()
+		a.getStore().DeleteExpired(context.Background(), time.Now())
 	}
 }
 
+// DefaultWalletConnectTTL is how long a WalletConnect connection lives
+// without being refreshed
+const DefaultWalletConnectTTL = 24 * time.Hour
+
 // WalletConnectManager manages WalletConnect sessions
 type WalletConnectManager struct {
 	connections map[string]*WalletConnect
+	ttl         time.Duration
 	mu          sync.RWMutex
 }
 
 // NewWalletConnectManager creates a new WalletConnect manager
 func NewWalletConnectManager() *WalletConnectManager {
-	return &WalletConnectManager{
+	manager := &WalletConnectManager{
 		connections: make(map[string]*WalletConnect),
+		ttl:         DefaultWalletConnectTTL,
 	}
+
+	// Start cleanup routine
+	go manager.cleanupExpired()
+
+	return manager
+}
+
+// SetTTL sets the lifetime of connections created or refreshed afterwards
+func (m *WalletConnectManager) SetTTL(ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ttl = ttl
 }
 
 // CreateConnection creates a new WalletConnect connection
@@ -258,11 +280,13 @@ func (m *WalletConnectManager) CreateConnection(address common.Address, chainID
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	now := time.Now()
 	connection := &WalletConnect{
-		SessionID:   fmt.Sprintf("wc_%d", time.Now().UnixNano()),
+		SessionID:   fmt.Sprintf("wc_%d", now.UnixNano()),
 		Address:     address,
 		ChainID:     chainID,
-		ConnectedAt: time.Now(),
+		ConnectedAt: now,
+		ExpiresAt:   now.Add(m.ttl),
 		Metadata:    make(map[string]string),
 	}
 
@@ -271,16 +295,45 @@ func (m *WalletConnectManager) CreateConnection(address common.Address, chainID
 	return connection, nil
 }
 
-// GetConnection gets a connection by session ID
+// GetConnection gets a connection by session ID. Expired connections are
+// removed and reported as expired.
 func (m *WalletConnectManager) GetConnection(sessionID string) (*WalletConnect, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	connection, exists := m.connections[sessionID]
+	if !exists {
+		return nil, fmt.Errorf("connection not found")
+	}
+
+	// Check expiration
+	if time.Now().After(connection.ExpiresAt) {
+		delete(m.connections, sessionID)
+		return nil, fmt.Errorf("connection expired")
+	}
+
+	return connection, nil
+}
+
+// RefreshConnection extends a connection's expiry by the TTL
+func (m *WalletConnectManager) RefreshConnection(sessionID string) (*WalletConnect, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
 	connection, exists := m.connections[sessionID]
 	if !exists {
 		return nil, fmt.Errorf("connection not found")
 	}
 
+	now := time.Now()
+	if now.After(connection.ExpiresAt) {
+		delete(m.connections, sessionID)
+		return nil, fmt.Errorf("connection expired")
+	}
+
+	// Extend expiration
+	connection.ExpiresAt = now.Add(m.ttl)
+
 	return connection, nil
 }
 
@@ -302,9 +355,10 @@ func (m *WalletConnectManager) ListConnections(address common.Address) []*Wallet
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
+	now := time.Now()
 	connections := make([]*WalletConnect, 0)
 	for _, connection := range m.connections {
-		if connection.Address == address {
+		if connection.Address == address && now.Before(connection.ExpiresAt) {
 			connections = append(connections, connection)
 		}
 	}
@@ -312,6 +366,33 @@ func (m *WalletConnectManager) ListConnections(address common.Address) []*Wallet
 	return connections
 }
 
+// PurgeExpired removes connections that expired before now and returns how
+// many were removed
+func (m *WalletConnectManager) PurgeExpired(now time.Time) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	purged := 0
+	for sessionID, connection := range m.connections {
+		if now.After(connection.ExpiresAt) {
+			delete(m.connections, sessionID)
+			purged++
+		}
+	}
+
+	return purged
+}
+
+// cleanupExpired purges expired connections
+func (m *WalletConnectManager) cleanupExpired() {
+	ticker := time.NewTicker(1 * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		m.PurgeExpired(time.Now())
+	}
+}
+
 // MetaMaskAuth MetaMask authentication helper
 type MetaMaskAuth struct {
 	auth *Web3Auth
@@ -0,0 +1,507 @@
+package web3
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"neonexcore/pkg/cache"
+
+	"github.com/ethereum/go-ethereum/common"
+	"gorm.io/gorm"
+)
+
+// ErrAuthRecordNotFound is returned by AuthStore lookups for missing records
+var ErrAuthRecordNotFound = errors.New("auth record not found")
+
+// AuthStore persists Web3Auth challenges and sessions. Implementations must be
+// safe for concurrent use; persistent stores let sessions survive restarts and
+// be shared between instances.
+type AuthStore interface {
+	SaveChallenge(ctx context.Context, key string, challenge *Challenge) error
+	// TakeChallenge returns and removes a challenge so it can only be used once
+	TakeChallenge(ctx context.Context, key string) (*Challenge, error)
+	SaveSession(ctx context.Context, session *Session) error
+	GetSession(ctx context.Context, id string) (*Session, error)
+	DeleteSession(ctx context.Context, id string) error
+	ListSessions(ctx context.Context, address common.Address) ([]*Session, error)
+	// DeleteExpired purges challenges and sessions that expired before now
+	DeleteExpired(ctx context.Context, now time.Time) error
+}
+
+// MemoryAuthStore keeps challenges and sessions in process memory
+type MemoryAuthStore struct {
+	challenges map[string]*Challenge
+	sessions   map[string]*Session
+	mu         sync.RWMutex
+}
+
+// NewMemoryAuthStore creates an in-memory auth store
+func NewMemoryAuthStore() *MemoryAuthStore {
+	return &MemoryAuthStore{
+		challenges: make(map[string]*Challenge),
+		sessions:   make(map[string]*Session),
+	}
+}
+
+// SaveChallenge stores a challenge
+func (s *MemoryAuthStore) SaveChallenge(ctx context.Context, key string, challenge *Challenge) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.challenges[key] = challenge
+	return nil
+}
+
+// TakeChallenge returns and removes a challenge
+func (s *MemoryAuthStore) TakeChallenge(ctx context.Context, key string) (*Challenge, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	challenge, exists := s.challenges[key]
+	if !exists {
+		return nil, ErrAuthRecordNotFound
+	}
+	delete(s.challenges, key)
+	return challenge, nil
+}
+
+// SaveSession stores a session
+func (s *MemoryAuthStore) SaveSession(ctx context.Context, session *Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[session.ID] = session
+	return nil
+}
+
+// GetSession gets a session by ID
+func (s *MemoryAuthStore) GetSession(ctx context.Context, id string) (*Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	session, exists := s.sessions[id]
+	if !exists {
+		return nil, ErrAuthRecordNotFound
+	}
+	return session, nil
+}
+
+// DeleteSession removes a session
+func (s *MemoryAuthStore) DeleteSession(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.sessions[id]; !exists {
+		return ErrAuthRecordNotFound
+	}
+	delete(s.sessions, id)
+	return nil
+}
+
+// ListSessions lists sessions for an address, including expired ones not yet purged
+func (s *MemoryAuthStore) ListSessions(ctx context.Context, address common.Address) ([]*Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sessions := make([]*Session, 0)
+	for _, session := range s.sessions {
+		if session.Address == address {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions, nil
+}
+
+// DeleteExpired purges expired challenges and sessions
+func (s *MemoryAuthStore) DeleteExpired(ctx context.Context, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, challenge := range s.challenges {
+		if now.After(challenge.ExpiresAt) {
+			delete(s.challenges, key)
+		}
+	}
+	for id, session := range s.sessions {
+		if now.After(session.ExpiresAt) {
+			delete(s.sessions, id)
+		}
+	}
+	return nil
+}
+
+// Web3ChallengeRecord is the database row for a challenge
+type Web3ChallengeRecord struct {
+	Key       string    `gorm:"column:challenge_key;primaryKey;size:128"`
+	Payload   string    `gorm:"type:text"`
+	ExpiresAt time.Time `gorm:"index"`
+}
+
+// TableName returns the challenge table name
+func (Web3ChallengeRecord) TableName() string {
+	return "web3_challenges"
+}
+
+// Web3SessionRecord is the database row for a session
+type Web3SessionRecord struct {
+	ID        string    `gorm:"primaryKey;size:64"`
+	Address   string    `gorm:"size:42;index"`
+	Payload   string    `gorm:"type:text"`
+	ExpiresAt time.Time `gorm:"index"`
+}
+
+// TableName returns the session table name
+func (Web3SessionRecord) TableName() string {
+	return "web3_sessions"
+}
+
+// GormAuthStore persists challenges and sessions in the database
+type GormAuthStore struct {
+	db *gorm.DB
+}
+
+// NewGormAuthStore creates a database-backed auth store and migrates its tables
+func NewGormAuthStore(db *gorm.DB) (*GormAuthStore, error) {
+	if err := db.AutoMigrate(&Web3ChallengeRecord{}, &Web3SessionRecord{}); err != nil {
+		return nil, err
+	}
+	return &GormAuthStore{db: db}, nil
+}
+
+// SaveChallenge stores a challenge
+func (s *GormAuthStore) SaveChallenge(ctx context.Context, key string, challenge *Challenge) error {
+	payload, err := json.Marshal(challenge)
+	if err != nil {
+		return err
+	}
+	return s.db.WithContext(ctx).Save(&Web3ChallengeRecord{
+		Key:       key,
+		Payload:   string(payload),
+		ExpiresAt: challenge.ExpiresAt,
+	}).Error
+}
+
+// TakeChallenge returns and removes a challenge. Only the caller whose delete
+// succeeds gets the challenge, so concurrent instances cannot both use it.
+func (s *GormAuthStore) TakeChallenge(ctx context.Context, key string) (*Challenge, error) {
+	var record Web3ChallengeRecord
+	if err := s.db.WithContext(ctx).Where("challenge_key = ?", key).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAuthRecordNotFound
+		}
+		return nil, err
+	}
+
+	result := s.db.WithContext(ctx).Where("challenge_key = ?", key).Delete(&Web3ChallengeRecord{})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrAuthRecordNotFound
+	}
+
+	var challenge Challenge
+	if err := json.Unmarshal([]byte(record.Payload), &challenge); err != nil {
+		return nil, err
+	}
+	return &challenge, nil
+}
+
+// SaveSession stores a session
+func (s *GormAuthStore) SaveSession(ctx context.Context, session *Session) error {
+	payload, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	return s.db.WithContext(ctx).Save(&Web3SessionRecord{
+		ID:        session.ID,
+		Address:   session.Address.Hex(),
+		Payload:   string(payload),
+		ExpiresAt: session.ExpiresAt,
+	}).Error
+}
+
+// GetSession gets a session by ID
+func (s *GormAuthStore) GetSession(ctx context.Context, id string) (*Session, error) {
+	var record Web3SessionRecord
+	if err := s.db.WithContext(ctx).Where("id = ?", id).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAuthRecordNotFound
+		}
+		return nil, err
+	}
+	return decodeSession(record.Payload)
+}
+
+// DeleteSession removes a session
+func (s *GormAuthStore) DeleteSession(ctx context.Context, id string) error {
+	result := s.db.WithContext(ctx).Where("id = ?", id).Delete(&Web3SessionRecord{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrAuthRecordNotFound
+	}
+	return nil
+}
+
+// ListSessions lists sessions for an address
+func (s *GormAuthStore) ListSessions(ctx context.Context, address common.Address) ([]*Session, error) {
+	var records []Web3SessionRecord
+	if err := s.db.WithContext(ctx).Where("address = ?", address.Hex()).Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	sessions := make([]*Session, 0, len(records))
+	for _, record := range records {
+		session, err := decodeSession(record.Payload)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, nil
+}
+
+// DeleteExpired purges expired challenges and sessions
+func (s *GormAuthStore) DeleteExpired(ctx context.Context, now time.Time) error {
+	if err := s.db.WithContext(ctx).Where("expires_at < ?", now).Delete(&Web3ChallengeRecord{}).Error; err != nil {
+		return err
+	}
+	return s.db.WithContext(ctx).Where("expires_at < ?", now).Delete(&Web3SessionRecord{}).Error
+}
+
+// Cache auth store locking: how long a lock is held at most, and how long to
+// wait for another holder to release it
+const (
+	authStoreLockTTL  = 5 * time.Second
+	authStoreLockWait = 5 * time.Second
+)
+
+// CacheAuthStore keeps challenges and sessions in a cache (e.g. Redis) with
+// TTLs matching their expiry, so expired records disappear on their own.
+// Taking a challenge and updating an address's session index run under a
+// cache lock, so concurrent instances can't both use a challenge or lose
+// each other's sessions.
+type CacheAuthStore struct {
+	cache  cache.Cache
+	prefix string
+
+	// lock is nil for caches without lock support; a mutex then only
+	// excludes within this process
+	lock cache.Lock
+	mu   sync.Mutex
+}
+
+// NewCacheAuthStore creates a cache-backed auth store
+func NewCacheAuthStore(c cache.Cache) *CacheAuthStore {
+	lock, _ := cache.NewLock(c)
+	return &CacheAuthStore{cache: c, prefix: "web3:", lock: lock}
+}
+
+// SaveChallenge stores a challenge
+func (s *CacheAuthStore) SaveChallenge(ctx context.Context, key string, challenge *Challenge) error {
+	return s.set(ctx, s.prefix+"challenge:"+key, challenge, time.Until(challenge.ExpiresAt))
+}
+
+// TakeChallenge returns and removes a challenge. The read and delete run
+// under a lock, so only one caller gets the challenge.
+func (s *CacheAuthStore) TakeChallenge(ctx context.Context, key string) (*Challenge, error) {
+	var challenge Challenge
+	err := s.locked(ctx, s.prefix+"challenge:"+key, func() error {
+		if err := s.get(ctx, s.prefix+"challenge:"+key, &challenge); err != nil {
+			return err
+		}
+		return s.cache.Delete(ctx, s.prefix+"challenge:"+key)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &challenge, nil
+}
+
+// SaveSession stores a session and indexes it by address
+func (s *CacheAuthStore) SaveSession(ctx context.Context, session *Session) error {
+	ttl := time.Until(session.ExpiresAt)
+	if err := s.set(ctx, s.sessionKey(session.ID), session, ttl); err != nil {
+		return err
+	}
+
+	return s.locked(ctx, s.indexKey(session.Address), func() error {
+		ids, err := s.sessionIDs(ctx, session.Address)
+		if err != nil {
+			return err
+		}
+		indexed := false
+		for _, id := range ids {
+			if id == session.ID {
+				indexed = true
+				break
+			}
+		}
+		if !indexed {
+			ids = append(ids, session.ID)
+		}
+
+		// The index lives as long as the longest-lived session in it
+		indexTTL := ttl
+		if current, err := s.cache.TTL(ctx, s.indexKey(session.Address)); err == nil && current > indexTTL {
+			indexTTL = current
+		}
+		return s.set(ctx, s.indexKey(session.Address), ids, indexTTL)
+	})
+}
+
+// GetSession gets a session by ID
+func (s *CacheAuthStore) GetSession(ctx context.Context, id string) (*Session, error) {
+	var session Session
+	if err := s.get(ctx, s.sessionKey(id), &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// DeleteSession removes a session
+func (s *CacheAuthStore) DeleteSession(ctx context.Context, id string) error {
+	session, err := s.GetSession(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.cache.Delete(ctx, s.sessionKey(id)); err != nil {
+		return err
+	}
+
+	return s.locked(ctx, s.indexKey(session.Address), func() error {
+		ids, err := s.sessionIDs(ctx, session.Address)
+		if err != nil {
+			return err
+		}
+		remaining := make([]string, 0, len(ids))
+		for _, existing := range ids {
+			if existing != id {
+				remaining = append(remaining, existing)
+			}
+		}
+		if len(remaining) == 0 {
+			return s.cache.Delete(ctx, s.indexKey(session.Address))
+		}
+
+		ttl, err := s.cache.TTL(ctx, s.indexKey(session.Address))
+		if err != nil || ttl <= 0 {
+			ttl = time.Until(session.ExpiresAt)
+		}
+		return s.set(ctx, s.indexKey(session.Address), remaining, ttl)
+	})
+}
+
+// ListSessions lists sessions for an address; index entries whose session has expired are skipped
+func (s *CacheAuthStore) ListSessions(ctx context.Context, address common.Address) ([]*Session, error) {
+	ids, err := s.sessionIDs(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]*Session, 0, len(ids))
+	for _, id := range ids {
+		session, err := s.GetSession(ctx, id)
+		if errors.Is(err, ErrAuthRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, nil
+}
+
+// DeleteExpired is a no-op: cache TTLs expire records
+func (s *CacheAuthStore) DeleteExpired(ctx context.Context, now time.Time) error {
+	return nil
+}
+
+func (s *CacheAuthStore) sessionKey(id string) string {
+	return s.prefix + "session:" + id
+}
+
+func (s *CacheAuthStore) indexKey(address common.Address) string {
+	return s.prefix + "sessions:" + address.Hex()
+}
+
+// locked runs fn while holding the lock on key, waiting for another holder to
+// release it for up to authStoreLockWait
+func (s *CacheAuthStore) locked(ctx context.Context, key string, fn func() error) error {
+	if s.lock == nil {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return fn()
+	}
+
+	deadline := time.Now().Add(authStoreLockWait)
+	for {
+		token, err := s.lock.Acquire(ctx, key, authStoreLockTTL)
+		if err == nil {
+			defer s.lock.Release(context.Background(), key, token)
+			return fn()
+		}
+		if !errors.Is(err, cache.ErrLockHeld) || time.Now().After(deadline) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+// sessionIDs returns the session IDs indexed for an address
+func (s *CacheAuthStore) sessionIDs(ctx context.Context, address common.Address) ([]string, error) {
+	var ids []string
+	if err := s.get(ctx, s.indexKey(address), &ids); err != nil && !errors.Is(err, ErrAuthRecordNotFound) {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// set stores v as a JSON string so memory and Redis caches round-trip the same
+// way. Records whose TTL has already passed are deleted instead.
+func (s *CacheAuthStore) set(ctx context.Context, key string, v interface{}, ttl time.Duration) error {
+	if ttl <= 0 {
+		return s.cache.Delete(ctx, key)
+	}
+
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return s.cache.Set(ctx, key, string(payload), ttl)
+}
+
+// get loads a JSON string stored by set into v
+func (s *CacheAuthStore) get(ctx context.Context, key string, v interface{}) error {
+	value, err := s.cache.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, cache.ErrKeyNotFound) {
+			return ErrAuthRecordNotFound
+		}
+		return err
+	}
+
+	payload, ok := value.(string)
+	if !ok {
+		return ErrAuthRecordNotFound
+	}
+	return json.Unmarshal([]byte(payload), v)
+}
+
+// decodeSession parses a stored session payload
+func decodeSession(payload string) (*Session, error) {
+	var session Session
+	if err := json.Unmarshal([]byte(payload), &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
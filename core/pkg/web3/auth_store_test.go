@@ -0,0 +1,213 @@
+package web3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"neonexcore/pkg/cache"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// authStores returns a fresh instance of every AuthStore implementation
+func authStores(t *testing.T) map[string]AuthStore {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "auth.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	gormStore, err := NewGormAuthStore(db)
+	if err != nil {
+		t.Fatalf("NewGormAuthStore: %v", err)
+	}
+
+	mc := cache.NewMemoryCache(cache.DefaultMemoryCacheConfig())
+	t.Cleanup(func() { mc.Close() })
+
+	return map[string]AuthStore{
+		"memory": NewMemoryAuthStore(),
+		"gorm":   gormStore,
+		"cache":  NewCacheAuthStore(mc),
+	}
+}
+
+func TestAuthStoreChallengeIsTakenOnce(t *testing.T) {
+	for name, store := range authStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			address := common.HexToAddress("0x1")
+			challenge := &Challenge{
+				Address:   address,
+				Message:   "sign me",
+				Nonce:     "nonce",
+				ExpiresAt: time.Now().Add(time.Minute),
+			}
+			if err := store.SaveChallenge(ctx, "key", challenge); err != nil {
+				t.Fatalf("SaveChallenge: %v", err)
+			}
+
+			const takers = 20
+			var wg sync.WaitGroup
+			var mu sync.Mutex
+			var taken []*Challenge
+			for i := 0; i < takers; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					got, err := store.TakeChallenge(ctx, "key")
+					if errors.Is(err, ErrAuthRecordNotFound) {
+						return
+					}
+					if err != nil {
+						t.Errorf("TakeChallenge: %v", err)
+						return
+					}
+					mu.Lock()
+					taken = append(taken, got)
+					mu.Unlock()
+				}()
+			}
+			wg.Wait()
+
+			if len(taken) != 1 {
+				t.Fatalf("challenge taken %d times, want 1", len(taken))
+			}
+			if taken[0].Nonce != "nonce" || taken[0].Address != address {
+				t.Fatalf("took %+v, want the saved challenge", taken[0])
+			}
+		})
+	}
+}
+
+func TestAuthStoreSessions(t *testing.T) {
+	for name, store := range authStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			address := common.HexToAddress("0x2")
+			other := common.HexToAddress("0x3")
+
+			for _, session := range []*Session{
+				{ID: "a", Address: address, ExpiresAt: time.Now().Add(time.Hour)},
+				{ID: "b", Address: address, ExpiresAt: time.Now().Add(time.Hour)},
+				{ID: "c", Address: other, ExpiresAt: time.Now().Add(time.Hour)},
+			} {
+				if err := store.SaveSession(ctx, session); err != nil {
+					t.Fatalf("SaveSession: %v", err)
+				}
+			}
+
+			got, err := store.GetSession(ctx, "a")
+			if err != nil {
+				t.Fatalf("GetSession: %v", err)
+			}
+			if got.Address != address {
+				t.Fatalf("GetSession address = %s, want %s", got.Address.Hex(), address.Hex())
+			}
+
+			sessions, err := store.ListSessions(ctx, address)
+			if err != nil {
+				t.Fatalf("ListSessions: %v", err)
+			}
+			if len(sessions) != 2 {
+				t.Fatalf("ListSessions returned %d sessions, want 2", len(sessions))
+			}
+
+			if err := store.DeleteSession(ctx, "a"); err != nil {
+				t.Fatalf("DeleteSession: %v", err)
+			}
+			if _, err := store.GetSession(ctx, "a"); !errors.Is(err, ErrAuthRecordNotFound) {
+				t.Fatalf("GetSession after delete = %v, want ErrAuthRecordNotFound", err)
+			}
+			if err := store.DeleteSession(ctx, "a"); !errors.Is(err, ErrAuthRecordNotFound) {
+				t.Fatalf("second DeleteSession = %v, want ErrAuthRecordNotFound", err)
+			}
+
+			sessions, err = store.ListSessions(ctx, address)
+			if err != nil {
+				t.Fatalf("ListSessions: %v", err)
+			}
+			if len(sessions) != 1 || sessions[0].ID != "b" {
+				t.Fatalf("ListSessions after delete = %v, want only session b", sessions)
+			}
+		})
+	}
+}
+
+func TestAuthStoreConcurrentSessionsAreAllIndexed(t *testing.T) {
+	for name, store := range authStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			address := common.HexToAddress("0x4")
+
+			const count = 20
+			var wg sync.WaitGroup
+			for i := 0; i < count; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					session := &Session{
+						ID:        fmt.Sprintf("session-%d", i),
+						Address:   address,
+						ExpiresAt: time.Now().Add(time.Hour),
+					}
+					if err := store.SaveSession(ctx, session); err != nil {
+						t.Errorf("SaveSession: %v", err)
+					}
+				}(i)
+			}
+			wg.Wait()
+
+			sessions, err := store.ListSessions(ctx, address)
+			if err != nil {
+				t.Fatalf("ListSessions: %v", err)
+			}
+			if len(sessions) != count {
+				t.Fatalf("ListSessions returned %d sessions, want %d", len(sessions), count)
+			}
+		})
+	}
+}
+
+func TestAuthStoreDeleteExpired(t *testing.T) {
+	for name, store := range authStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			address := common.HexToAddress("0x5")
+
+			expiring := &Session{ID: "expiring", Address: address, ExpiresAt: time.Now().Add(50 * time.Millisecond)}
+			lasting := &Session{ID: "lasting", Address: address, ExpiresAt: time.Now().Add(time.Hour)}
+			for _, session := range []*Session{expiring, lasting} {
+				if err := store.SaveSession(ctx, session); err != nil {
+					t.Fatalf("SaveSession: %v", err)
+				}
+			}
+			challenge := &Challenge{Address: address, ExpiresAt: time.Now().Add(50 * time.Millisecond)}
+			if err := store.SaveChallenge(ctx, "key", challenge); err != nil {
+				t.Fatalf("SaveChallenge: %v", err)
+			}
+
+			time.Sleep(100 * time.Millisecond)
+			if err := store.DeleteExpired(ctx, time.Now()); err != nil {
+				t.Fatalf("DeleteExpired: %v", err)
+			}
+
+			if _, err := store.GetSession(ctx, "expiring"); !errors.Is(err, ErrAuthRecordNotFound) {
+				t.Fatalf("GetSession of expired session = %v, want ErrAuthRecordNotFound", err)
+			}
+			if _, err := store.GetSession(ctx, "lasting"); err != nil {
+				t.Fatalf("GetSession of live session: %v", err)
+			}
+			if _, err := store.TakeChallenge(ctx, "key"); !errors.Is(err, ErrAuthRecordNotFound) {
+				t.Fatalf("TakeChallenge of expired challenge = %v, want ErrAuthRecordNotFound", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package web3
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestWalletConnectExpiredConnectionIsRemoved(t *testing.T) {
+	manager := NewWalletConnectManager()
+	manager.SetTTL(20 * time.Millisecond)
+	address := common.HexToAddress("0x1")
+
+	connection, err := manager.CreateConnection(address, 1)
+	if err != nil {
+		t.Fatalf("CreateConnection: %v", err)
+	}
+	if want := connection.ConnectedAt.Add(20 * time.Millisecond); !connection.ExpiresAt.Equal(want) {
+		t.Fatalf("ExpiresAt = %s, want %s", connection.ExpiresAt, want)
+	}
+	if _, err := manager.GetConnection(connection.SessionID); err != nil {
+		t.Fatalf("GetConnection: %v", err)
+	}
+
+	time.Sleep(40 * time.Millisecond)
+	if got := manager.ListConnections(address); len(got) != 0 {
+		t.Fatalf("listed %d expired connections", len(got))
+	}
+	if _, err := manager.GetConnection(connection.SessionID); err == nil || err.Error() != "connection expired" {
+		t.Fatalf("GetConnection of an expired connection = %v", err)
+	}
+	// The expired connection was evicted
+	if _, err := manager.GetConnection(connection.SessionID); err == nil || err.Error() != "connection not found" {
+		t.Fatalf("GetConnection after eviction = %v", err)
+	}
+}
+
+func TestWalletConnectPurgeExpired(t *testing.T) {
+	manager := NewWalletConnectManager()
+	address := common.HexToAddress("0x1")
+
+	manager.SetTTL(time.Minute)
+	short, _ := manager.CreateConnection(address, 1)
+	manager.SetTTL(time.Hour)
+	long, _ := manager.CreateConnection(address, 1)
+	if short.SessionID == long.SessionID {
+		t.Fatal("connections share a session ID")
+	}
+
+	if purged := manager.PurgeExpired(time.Now()); purged != 0 {
+		t.Fatalf("purged %d live connections", purged)
+	}
+	if purged := manager.PurgeExpired(time.Now().Add(2 * time.Minute)); purged != 1 {
+		t.Fatalf("purged %d connections, want 1", purged)
+	}
+
+	manager.mu.RLock()
+	_, shortKept := manager.connections[short.SessionID]
+	_, longKept := manager.connections[long.SessionID]
+	manager.mu.RUnlock()
+	if shortKept || !longKept {
+		t.Fatalf("short kept %v, long kept %v; want only the long-lived connection", shortKept, longKept)
+	}
+}
+
+func TestWalletConnectRefreshExtendsExpiry(t *testing.T) {
+	manager := NewWalletConnectManager()
+	manager.SetTTL(200 * time.Millisecond)
+
+	connection, _ := manager.CreateConnection(common.HexToAddress("0x1"), 1)
+	expiresAt := connection.ExpiresAt
+
+	time.Sleep(120 * time.Millisecond)
+	refreshed, err := manager.RefreshConnection(connection.SessionID)
+	if err != nil {
+		t.Fatalf("RefreshConnection: %v", err)
+	}
+	if !refreshed.ExpiresAt.After(expiresAt) {
+		t.Fatalf("ExpiresAt = %s, want later than %s", refreshed.ExpiresAt, expiresAt)
+	}
+
+	// Past the original expiry, still within the refreshed one
+	time.Sleep(120 * time.Millisecond)
+	if _, err := manager.GetConnection(connection.SessionID); err != nil {
+		t.Fatalf("GetConnection after refresh: %v", err)
+	}
+
+	time.Sleep(250 * time.Millisecond)
+	if _, err := manager.RefreshConnection(connection.SessionID); err == nil {
+		t.Fatal("refreshed an expired connection")
+	}
+	if _, err := manager.RefreshConnection("wc_missing"); err == nil {
+		t.Fatal("refreshed a missing connection")
+	}
+}
@@ -0,0 +1,167 @@
+package web3
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// callArgs is the transaction object of an eth_call request
+type callArgs struct {
+	From  *common.Address `json:"from"`
+	To    *common.Address `json:"to"`
+	Input hexutil.Bytes   `json:"input"`
+}
+
+// feeHistoryResult is the result of an eth_feeHistory request
+type feeHistoryResult struct {
+	OldestBlock  *hexutil.Big     `json:"oldestBlock"`
+	Reward       [][]*hexutil.Big `json:"reward,omitempty"`
+	BaseFee      []*hexutil.Big   `json:"baseFeePerGas,omitempty"`
+	GasUsedRatio []float64        `json:"gasUsedRatio"`
+}
+
+// filterArgs is the filter object of an eth_getLogs request
+type filterArgs struct {
+	Address   []common.Address `json:"address"`
+	Topics    [][]common.Hash  `json:"topics"`
+	FromBlock *hexutil.Big     `json:"fromBlock"`
+	ToBlock   *hexutil.Big     `json:"toBlock"`
+}
+
+// mockEth serves the eth namespace of an in-process JSON-RPC server standing
+// in for a node. Requests are answered by the handler set for them and
+// counted.
+type mockEth struct {
+	mu       sync.Mutex
+	requests map[string]int
+
+	call        func(to common.Address, input []byte) ([]byte, error)
+	receipt     func(hash common.Hash) *types.Receipt
+	transaction func(hash common.Hash) *types.Transaction
+	blockNumber func() uint64
+	logs        func(query filterArgs) []types.Log
+	feeHistory  func(blockCount uint64, percentiles []float64) *feeHistoryResult
+
+	// baseFee is the latest block's base fee; nil for a pre-London chain
+	baseFee  *big.Int
+	gasPrice int64
+	gasTip   int64
+	sent     []*types.Transaction
+}
+
+func (e *mockEth) record(method string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.requests == nil {
+		e.requests = make(map[string]int)
+	}
+	e.requests[method]++
+}
+
+// count returns how many requests were made to an eth_ method
+func (e *mockEth) count(method string) int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.requests[method]
+}
+
+// Call answers eth_call
+func (e *mockEth) Call(ctx context.Context, args callArgs, block string) (hexutil.Bytes, error) {
+	e.record("eth_call")
+	return e.call(*args.To, args.Input)
+}
+
+// GetTransactionReceipt answers eth_getTransactionReceipt; nil means not mined
+func (e *mockEth) GetTransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	e.record("eth_getTransactionReceipt")
+	return e.receipt(hash), nil
+}
+
+// GetTransactionByHash answers eth_getTransactionByHash; nil means unknown
+func (e *mockEth) GetTransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, error) {
+	e.record("eth_getTransactionByHash")
+	return e.transaction(hash), nil
+}
+
+// BlockNumber answers eth_blockNumber
+func (e *mockEth) BlockNumber(ctx context.Context) (hexutil.Uint64, error) {
+	e.record("eth_blockNumber")
+	return hexutil.Uint64(e.blockNumber()), nil
+}
+
+// GetLogs answers eth_getLogs
+func (e *mockEth) GetLogs(ctx context.Context, query filterArgs) ([]types.Log, error) {
+	e.record("eth_getLogs")
+	return e.logs(query), nil
+}
+
+// GetBlockByNumber answers eth_getBlockByNumber with a header carrying baseFee
+func (e *mockEth) GetBlockByNumber(ctx context.Context, number string, full bool) (*types.Header, error) {
+	e.record("eth_getBlockByNumber")
+	return &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(0), BaseFee: e.baseFee}, nil
+}
+
+// GetTransactionCount answers eth_getTransactionCount
+func (e *mockEth) GetTransactionCount(ctx context.Context, address common.Address, block string) (hexutil.Uint64, error) {
+	e.record("eth_getTransactionCount")
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return hexutil.Uint64(len(e.sent)), nil
+}
+
+// GasPrice answers eth_gasPrice
+func (e *mockEth) GasPrice(ctx context.Context) (*hexutil.Big, error) {
+	e.record("eth_gasPrice")
+	return (*hexutil.Big)(big.NewInt(e.gasPrice)), nil
+}
+
+// MaxPriorityFeePerGas answers eth_maxPriorityFeePerGas
+func (e *mockEth) MaxPriorityFeePerGas(ctx context.Context) (*hexutil.Big, error) {
+	e.record("eth_maxPriorityFeePerGas")
+	return (*hexutil.Big)(big.NewInt(e.gasTip)), nil
+}
+
+// FeeHistory answers eth_feeHistory
+func (e *mockEth) FeeHistory(ctx context.Context, blockCount hexutil.Uint, lastBlock string, percentiles []float64) (*feeHistoryResult, error) {
+	e.record("eth_feeHistory")
+	return e.feeHistory(uint64(blockCount), percentiles), nil
+}
+
+// SendRawTransaction answers eth_sendRawTransaction, keeping the decoded transaction
+func (e *mockEth) SendRawTransaction(ctx context.Context, data hexutil.Bytes) (common.Hash, error) {
+	e.record("eth_sendRawTransaction")
+
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(data); err != nil {
+		return common.Hash{}, err
+	}
+	e.mu.Lock()
+	e.sent = append(e.sent, tx)
+	e.mu.Unlock()
+	return tx.Hash(), nil
+}
+
+// newMockClient returns a client for network config talking to eth
+func newMockClient(t *testing.T, eth *mockEth, config *NetworkConfig) *Web3Client {
+	t.Helper()
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", eth); err != nil {
+		t.Fatalf("register mock eth service: %v", err)
+	}
+	client := ethclient.NewClient(rpc.DialInProc(server))
+	t.Cleanup(func() {
+		client.Close()
+		server.Stop()
+	})
+
+	return &Web3Client{config: config, client: client, chainID: config.ChainID}
+}
@@ -3,11 +3,13 @@ package web3
 import (
 	"context"
 	"crypto/ecdsa"
+	"errors"
 	"fmt"
 	"math/big"
 	"sync"
 	"time"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -39,6 +41,7 @@ type NetworkConfig struct {
 	WSURL      string
 	Explorer   string
 	NativeCoin string
+	Multicall  common.Address // Multicall3 contract used by BatchCall (zero = sequential calls)
 }
 
 // Web3Client blockchain client
@@ -47,6 +50,7 @@ type Web3Client struct {
 	client      *ethclient.Client
 	wsClient    *ethclient.Client
 	chainID     *big.Int
+	gasOracle   *GasOracle
 	mu          sync.RWMutex
 }
 
@@ -66,7 +70,10 @@ type Transaction struct {
 	To          *common.Address
 	Value       *big.Int
 	Gas         uint64
-	GasPrice    *big.Int
+	GasPrice    *big.Int // Legacy gas price, or the fee cap for EIP-1559 transactions
+	GasTipCap   *big.Int // EIP-1559 only
+	GasFeeCap   *big.Int // EIP-1559 only
+	Type        uint8    // types.LegacyTxType or types.DynamicFeeTxType
 	Nonce       uint64
 	Data        []byte
 	Status      TransactionStatus
@@ -83,18 +90,21 @@ type Wallet struct {
 
 // Web3Manager manages Web3 connections
 type Web3Manager struct {
-	clients map[Network]*Web3Client
-	mu      sync.RWMutex
+	clients  map[Network]*Web3Client
+	networks map[Network]*NetworkConfig // Custom networks added with RegisterNetwork
+	mu       sync.RWMutex
 }
 
-// DefaultNetworkConfigs default network configurations
+// DefaultNetworkConfigs default network configurations. Networks without a
+// public RPC endpoint need WEB3_<NETWORK>_RPC set; see LoadNetworkConfig.
 var DefaultNetworkConfigs = map[Network]*NetworkConfig{
 	NetworkEthereum: {
 		Network:    NetworkEthereum,
 		ChainID:    big.NewInt(1),
-		RPCURL:     "https://mainnet.infura.io/v3/YOUR_API_KEY",
+		RPCURL:     "", // Provider-specific; set WEB3_ETHEREUM_RPC
 		Explorer:   "https://etherscan.io",
 		NativeCoin: "ETH",
+		Multicall:  Multicall3Address,
 	},
 	NetworkPolygon: {
 		Network:    NetworkPolygon,
@@ -102,6 +112,7 @@ var DefaultNetworkConfigs = map[Network]*NetworkConfig{
 		RPCURL:     "https://polygon-rpc.com",
 		Explorer:   "https://polygonscan.com",
 		NativeCoin: "MATIC",
+		Multicall:  Multicall3Address,
 	},
 	NetworkBSC: {
 		Network:    NetworkBSC,
@@ -109,32 +120,82 @@ var DefaultNetworkConfigs = map[Network]*NetworkConfig{
 		RPCURL:     "https://bsc-dataseed.binance.org",
 		Explorer:   "https://bscscan.com",
 		NativeCoin: "BNB",
+		Multicall:  Multicall3Address,
 	},
 	NetworkGoerli: {
 		Network:    NetworkGoerli,
 		ChainID:    big.NewInt(5),
-		RPCURL:     "https://goerli.infura.io/v3/YOUR_API_KEY",
+		RPCURL:     "", // Provider-specific; set WEB3_GOERLI_RPC
 		Explorer:   "https://goerli.etherscan.io",
 		NativeCoin: "GoerliETH",
+		Multicall:  Multicall3Address,
 	},
 	NetworkSepolia: {
 		Network:    NetworkSepolia,
 		ChainID:    big.NewInt(11155111),
-		RPCURL:     "https://sepolia.infura.io/v3/YOUR_API_KEY",
+		RPCURL:     "", // Provider-specific; set WEB3_SEPOLIA_RPC
 		Explorer:   "https://sepolia.etherscan.io",
 		NativeCoin: "SepoliaETH",
+		Multicall:  Multicall3Address,
 	},
 }
 
 // NewWeb3Manager creates a new Web3 manager
 func NewWeb3Manager() *Web3Manager {
 	return &Web3Manager{
-		clients: make(map[Network]*Web3Client),
+		clients:  make(map[Network]*Web3Client),
+		networks: make(map[Network]*NetworkConfig),
 	}
 }
 
+// RegisterNetwork adds or replaces a custom network that ConnectNetwork can
+// resolve. Environment overrides still apply on top of it.
+func (m *Web3Manager) RegisterNetwork(config *NetworkConfig) error {
+	if config == nil || config.Network == "" {
+		return errors.New("network name is required")
+	}
+	if config.ChainID == nil || config.ChainID.Sign() <= 0 {
+		return fmt.Errorf("chain id is required for network %s", config.Network)
+	}
+
+	copied := *config
+	copied.ChainID = new(big.Int).Set(config.ChainID)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.networks[config.Network] = &copied
+	return nil
+}
+
+// NetworkConfig resolves the configuration for a network: a registered
+// custom network or the default, with environment overrides applied
+func (m *Web3Manager) NetworkConfig(network Network) (*NetworkConfig, error) {
+	m.mu.RLock()
+	base, exists := m.networks[network]
+	m.mu.RUnlock()
+
+	if !exists {
+		base = DefaultNetworkConfigs[network]
+	}
+
+	return LoadNetworkConfig(network, base)
+}
+
+// ConnectNetwork connects to a network using its resolved configuration
+func (m *Web3Manager) ConnectNetwork(network Network) error {
+	config, err := m.NetworkConfig(network)
+	if err != nil {
+		return err
+	}
+	return m.Connect(config)
+}
+
 // Connect connects to a blockchain network
 func (m *Web3Manager) Connect(config *NetworkConfig) error {
+	if err := validateNetworkConfig(config); err != nil {
+		return err
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -271,26 +332,148 @@ func (c *Web3Client) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
 	return gasPrice, nil
 }
 
-// SendTransaction sends a transaction
+// GasOracle returns the client's fee tier oracle, created on first use
+func (c *Web3Client) GasOracle() *GasOracle {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.gasOracle == nil {
+		c.gasOracle = NewGasOracle(c.client)
+	}
+	return c.gasOracle
+}
+
+// ConfigureGasOracle replaces the client's fee tier oracle with one using config
+func (c *Web3Client) ConfigureGasOracle(config GasOracleConfig) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gasOracle = NewGasOracle(c.client, config)
+}
+
+// TxMode selects the transaction envelope used by SendTransactionWithOptions
+type TxMode string
+
+const (
+	TxModeAuto       TxMode = "auto"        // EIP-1559 when the latest block has a base fee
+	TxModeLegacy     TxMode = "legacy"      // Legacy gas price transaction
+	TxModeDynamicFee TxMode = "dynamic_fee" // EIP-1559 transaction
+)
+
+// TxOptions configures how a transaction is built
+type TxOptions struct {
+	Mode     TxMode
+	GasLimit uint64 // 0 uses the default for the call type
+	// GasTipCap overrides the suggested priority fee (EIP-1559 only)
+	GasTipCap *big.Int
+	// FeeTier takes the fees from the GasOracle tier (EIP-1559 only; legacy
+	// transactions use SuggestGasPrice)
+	FeeTier FeeTier
+}
+
+// SendTransaction sends a transaction, using EIP-1559 fees when the network supports them
 func (c *Web3Client) SendTransaction(ctx context.Context, wallet *Wallet, to common.Address, value *big.Int, data []byte) (*Transaction, error) {
+	return c.SendTransactionWithOptions(ctx, wallet, to, value, data, TxOptions{Mode: TxModeAuto})
+}
+
+// SendTransactionWithOptions sends a transaction with an explicit envelope and gas settings
+func (c *Web3Client) SendTransactionWithOptions(ctx context.Context, wallet *Wallet, to common.Address, value *big.Int, data []byte, opts TxOptions) (*Transaction, error) {
 	nonce, err := c.GetNonce(ctx, wallet.Address)
 	if err != nil {
 		return nil, err
 	}
 
-	gasPrice, err := c.SuggestGasPrice(ctx)
-	if err != nil {
-		return nil, err
+	gasLimit := opts.GasLimit
+	if gasLimit == 0 {
+		gasLimit = uint64(21000)
+		if len(data) > 0 {
+			gasLimit = uint64(100000) // Higher for contract interaction
+		}
 	}
 
-	gasLimit := uint64(21000)
-	if len(data) > 0 {
-		gasLimit = uint64(100000) // Higher for contract interaction
+	mode := opts.Mode
+	var baseFee *big.Int
+	if mode == "" || mode == TxModeAuto || mode == TxModeDynamicFee {
+		header, err := c.client.HeaderByNumber(ctx, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get latest header: %w", err)
+		}
+		baseFee = header.BaseFee
+
+		if mode != TxModeDynamicFee {
+			mode = TxModeLegacy
+			if baseFee != nil {
+				mode = TxModeDynamicFee
+			}
+		} else if baseFee == nil {
+			return nil, fmt.Errorf("network %s does not support EIP-1559 transactions", c.config.Network)
+		}
 	}
 
-	tx := types.NewTransaction(nonce, to, value, gasLimit, gasPrice, data)
+	result := &Transaction{
+		From:      wallet.Address,
+		To:        &to,
+		Value:     value,
+		Gas:       gasLimit,
+		Nonce:     nonce,
+		Data:      data,
+		Status:    TxStatusPending,
+		Timestamp: time.Now(),
+	}
+
+	var tx *types.Transaction
+	if mode == TxModeDynamicFee {
+		tipCap := opts.GasTipCap
+		if opts.FeeTier != "" {
+			suggestion, err := c.GasOracle().Suggest(ctx, opts.FeeTier)
+			if err != nil {
+				return nil, err
+			}
+			baseFee = suggestion.BaseFee
+			if tipCap == nil {
+				tipCap = suggestion.MaxPriorityFee
+			}
+		}
+		if tipCap == nil {
+			tipCap, err = c.client.SuggestGasTipCap(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to suggest gas tip cap: %w", err)
+			}
+		}
+
+		// Leave room for the base fee to double before the tx becomes unincludable
+		feeCap := new(big.Int).Add(new(big.Int).Mul(baseFee, big.NewInt(2)), tipCap)
+
+		tx = types.NewTx(&types.DynamicFeeTx{
+			ChainID:   c.chainID,
+			Nonce:     nonce,
+			GasTipCap: tipCap,
+			GasFeeCap: feeCap,
+			Gas:       gasLimit,
+			To:        &to,
+			Value:     value,
+			Data:      data,
+		})
+		result.GasTipCap = tipCap
+		result.GasFeeCap = feeCap
+		result.GasPrice = feeCap
+	} else {
+		gasPrice, err := c.SuggestGasPrice(ctx)
+		if err != nil {
+			return nil, err
+		}
 
-	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(c.chainID), wallet.PrivateKey)
+		tx = types.NewTx(&types.LegacyTx{
+			Nonce:    nonce,
+			GasPrice: gasPrice,
+			Gas:      gasLimit,
+			To:       &to,
+			Value:    value,
+			Data:     data,
+		})
+		result.GasPrice = gasPrice
+	}
+
+	signedTx, err := types.SignTx(tx, types.LatestSignerForChainID(c.chainID), wallet.PrivateKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign transaction: %w", err)
 	}
@@ -300,18 +483,9 @@ func (c *Web3Client) SendTransaction(ctx context.Context, wallet *Wallet, to com
 		return nil, fmt.Errorf("failed to send transaction: %w", err)
 	}
 
-	return &Transaction{
-		Hash:      signedTx.Hash(),
-		From:      wallet.Address,
-		To:        &to,
-		Value:     value,
-		Gas:       gasLimit,
-		GasPrice:  gasPrice,
-		Nonce:     nonce,
-		Data:      data,
-		Status:    TxStatusPending,
-		Timestamp: time.Now(),
-	}, nil
+	result.Hash = signedTx.Hash()
+	result.Type = signedTx.Type()
+	return result, nil
 }
 
 // GetTransaction gets transaction by hash
@@ -329,6 +503,11 @@ func (c *Web3Client) GetTransaction(ctx context.Context, hash common.Hash) (*Tra
 		GasPrice: tx.GasPrice(),
 		Nonce:    tx.Nonce(),
 		Data:     tx.Data(),
+		Type:     tx.Type(),
+	}
+	if tx.Type() == types.DynamicFeeTxType {
+		transaction.GasTipCap = tx.GasTipCap()
+		transaction.GasFeeCap = tx.GasFeeCap()
 	}
 
 	if isPending {
@@ -351,27 +530,85 @@ func (c *Web3Client) GetTransaction(ctx context.Context, hash common.Hash) (*Tra
 	return transaction, nil
 }
 
-// WaitForTransaction waits for transaction confirmation
+// Errors returned by WaitForTransaction
+var (
+	ErrTransactionDropped  = errors.New("transaction dropped: not found on the node")
+	ErrTransactionReverted = errors.New("transaction reverted")
+	ErrWaitTimeout         = errors.New("timed out waiting for transaction")
+)
+
+// WaitOptions configures WaitForTransactionWithOptions
+type WaitOptions struct {
+	PollInterval time.Duration // Time between receipt polls (default 2s)
+	// MaxNotFoundPolls is how many consecutive polls may find neither a receipt
+	// nor the transaction before it is reported as dropped (default 30)
+	MaxNotFoundPolls int
+}
+
+// DefaultWaitOptions returns the default wait options
+func DefaultWaitOptions() WaitOptions {
+	return WaitOptions{
+		PollInterval:     2 * time.Second,
+		MaxNotFoundPolls: 30,
+	}
+}
+
+// WaitForTransaction waits for transaction confirmation using DefaultWaitOptions
 func (c *Web3Client) WaitForTransaction(ctx context.Context, hash common.Hash, confirmations uint64) (*types.Receipt, error) {
-	ticker := time.NewTicker(2 * time.Second)
+	return c.WaitForTransactionWithOptions(ctx, hash, confirmations, DefaultWaitOptions())
+}
+
+// WaitForTransactionWithOptions waits until the transaction has the requested
+// confirmations. A reverted transaction returns its receipt immediately along
+// with ErrTransactionReverted; a transaction the node no longer knows about
+// returns ErrTransactionDropped; context expiry returns ErrWaitTimeout wrapping
+// the context error.
+func (c *Web3Client) WaitForTransactionWithOptions(ctx context.Context, hash common.Hash, confirmations uint64, opts WaitOptions) (*types.Receipt, error) {
+	defaults := DefaultWaitOptions()
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = defaults.PollInterval
+	}
+	if opts.MaxNotFoundPolls <= 0 {
+		opts.MaxNotFoundPolls = defaults.MaxNotFoundPolls
+	}
+
+	ticker := time.NewTicker(opts.PollInterval)
 	defer ticker.Stop()
 
+	notFound := 0
 	for {
 		select {
 		case <-ctx.Done():
-			return nil, ctx.Err()
+			return nil, fmt.Errorf("%w %s: %w", ErrWaitTimeout, hash.Hex(), ctx.Err())
 		case <-ticker.C:
 			receipt, err := c.client.TransactionReceipt(ctx, hash)
 			if err != nil {
+				if !errors.Is(err, ethereum.NotFound) {
+					continue // transient RPC error, retry
+				}
+
+				// No receipt yet: still pending, or gone from the mempool
+				if _, _, err := c.client.TransactionByHash(ctx, hash); errors.Is(err, ethereum.NotFound) {
+					notFound++
+					if notFound >= opts.MaxNotFoundPolls {
+						return nil, fmt.Errorf("%w: %s after %d polls", ErrTransactionDropped, hash.Hex(), notFound)
+					}
+				} else if err == nil {
+					notFound = 0
+				}
 				continue
 			}
 
+			if receipt.Status == types.ReceiptStatusFailed {
+				return receipt, fmt.Errorf("%w: %s in block %d", ErrTransactionReverted, hash.Hex(), receipt.BlockNumber.Uint64())
+			}
+
 			currentBlock, err := c.client.BlockNumber(ctx)
 			if err != nil {
 				continue
 			}
 
-			if currentBlock-receipt.BlockNumber.Uint64() >= confirmations {
+			if currentBlock >= receipt.BlockNumber.Uint64() && currentBlock-receipt.BlockNumber.Uint64() >= confirmations {
 				return receipt, nil
 			}
 		}
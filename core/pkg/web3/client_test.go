@@ -0,0 +1,215 @@
+package web3
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var testTxHash = common.HexToHash("0xabc")
+
+// testWaitOptions polls fast enough for tests
+var testWaitOptions = WaitOptions{PollInterval: time.Millisecond, MaxNotFoundPolls: 3}
+
+func testReceipt(status uint64, block int64) *types.Receipt {
+	return &types.Receipt{
+		Status:      status,
+		TxHash:      testTxHash,
+		BlockNumber: big.NewInt(block),
+		Logs:        []*types.Log{},
+	}
+}
+
+// testPendingTx returns a signed transaction, as the node returns for a
+// transaction waiting in the mempool
+func testPendingTx(t *testing.T) *types.Transaction {
+	t.Helper()
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	tx, err := types.SignTx(types.NewTx(&types.LegacyTx{Gas: 21000, GasPrice: big.NewInt(1)}), types.HomesteadSigner{}, key)
+	if err != nil {
+		t.Fatalf("sign transaction: %v", err)
+	}
+	return tx
+}
+
+func TestWaitForTransactionConfirmed(t *testing.T) {
+	eth := &mockEth{blockNumber: func() uint64 { return 12 }}
+	// Mined on the third poll
+	polls := 0
+	eth.receipt = func(common.Hash) *types.Receipt {
+		polls++
+		if polls < 3 {
+			return nil
+		}
+		return testReceipt(types.ReceiptStatusSuccessful, 10)
+	}
+	pending := testPendingTx(t)
+	eth.transaction = func(common.Hash) *types.Transaction { return pending }
+	client := newMockClient(t, eth, &NetworkConfig{ChainID: big.NewInt(1)})
+
+	receipt, err := client.WaitForTransactionWithOptions(context.Background(), testTxHash, 2, testWaitOptions)
+	if err != nil {
+		t.Fatalf("WaitForTransaction: %v", err)
+	}
+	if receipt.BlockNumber.Int64() != 10 {
+		t.Fatalf("receipt block = %d, want 10", receipt.BlockNumber.Int64())
+	}
+}
+
+func TestWaitForTransactionReverted(t *testing.T) {
+	eth := &mockEth{
+		receipt:     func(common.Hash) *types.Receipt { return testReceipt(types.ReceiptStatusFailed, 10) },
+		blockNumber: func() uint64 { return 10 },
+	}
+	client := newMockClient(t, eth, &NetworkConfig{ChainID: big.NewInt(1)})
+
+	// Returns at once instead of waiting for confirmations
+	receipt, err := client.WaitForTransactionWithOptions(context.Background(), testTxHash, 100, testWaitOptions)
+	if !errors.Is(err, ErrTransactionReverted) {
+		t.Fatalf("WaitForTransaction = %v, want ErrTransactionReverted", err)
+	}
+	if receipt == nil || receipt.Status != types.ReceiptStatusFailed {
+		t.Fatalf("receipt = %+v, want the failed receipt", receipt)
+	}
+	if n := eth.count("eth_getTransactionReceipt"); n != 1 {
+		t.Fatalf("polled %d times, want 1", n)
+	}
+}
+
+func TestWaitForTransactionDropped(t *testing.T) {
+	eth := &mockEth{
+		receipt:     func(common.Hash) *types.Receipt { return nil },
+		transaction: func(common.Hash) *types.Transaction { return nil },
+	}
+	client := newMockClient(t, eth, &NetworkConfig{ChainID: big.NewInt(1)})
+
+	_, err := client.WaitForTransactionWithOptions(context.Background(), testTxHash, 1, testWaitOptions)
+	if !errors.Is(err, ErrTransactionDropped) {
+		t.Fatalf("WaitForTransaction = %v, want ErrTransactionDropped", err)
+	}
+	if n := eth.count("eth_getTransactionByHash"); n != testWaitOptions.MaxNotFoundPolls {
+		t.Fatalf("looked the transaction up %d times, want %d", n, testWaitOptions.MaxNotFoundPolls)
+	}
+}
+
+func TestWaitForTransactionTimeout(t *testing.T) {
+	pending := testPendingTx(t)
+	eth := &mockEth{
+		receipt:     func(common.Hash) *types.Receipt { return nil },
+		transaction: func(common.Hash) *types.Transaction { return pending },
+	}
+	client := newMockClient(t, eth, &NetworkConfig{ChainID: big.NewInt(1)})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// A pending transaction is never reported as dropped
+	_, err := client.WaitForTransactionWithOptions(ctx, testTxHash, 1, testWaitOptions)
+	if !errors.Is(err, ErrWaitTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("WaitForTransaction = %v, want ErrWaitTimeout wrapping the deadline", err)
+	}
+}
+
+// newTxTestClient returns a client on chain 1337 with the given base fee, a 5
+// wei gas price and a 2 wei suggested tip
+func newTxTestClient(t *testing.T, baseFee *big.Int) (*Web3Client, *mockEth, *Wallet) {
+	t.Helper()
+
+	wallet, err := CreateWallet()
+	if err != nil {
+		t.Fatalf("CreateWallet: %v", err)
+	}
+	eth := &mockEth{baseFee: baseFee, gasPrice: 5, gasTip: 2}
+	return newMockClient(t, eth, &NetworkConfig{Network: "test", ChainID: big.NewInt(1337)}), eth, wallet
+}
+
+// sentTx returns the only transaction sent and checks it is signed by wallet for chain 1337
+func sentTx(t *testing.T, eth *mockEth, wallet *Wallet) *types.Transaction {
+	t.Helper()
+
+	if len(eth.sent) != 1 {
+		t.Fatalf("%d transactions sent, want 1", len(eth.sent))
+	}
+	tx := eth.sent[0]
+	from, err := types.Sender(types.LatestSignerForChainID(big.NewInt(1337)), tx)
+	if err != nil || from != wallet.Address {
+		t.Fatalf("sender = %s, %v; want %s", from.Hex(), err, wallet.Address.Hex())
+	}
+	return tx
+}
+
+func TestSendTransactionTypes(t *testing.T) {
+	to := common.HexToAddress("0x3000000000000000000000000000000000000003")
+
+	tests := []struct {
+		name     string
+		baseFee  *big.Int
+		mode     TxMode
+		wantType uint8
+	}{
+		{"auto on an EIP-1559 chain", big.NewInt(10), TxModeAuto, types.DynamicFeeTxType},
+		{"auto on a legacy chain", nil, TxModeAuto, types.LegacyTxType},
+		{"legacy on an EIP-1559 chain", big.NewInt(10), TxModeLegacy, types.LegacyTxType},
+		{"dynamic fee", big.NewInt(10), TxModeDynamicFee, types.DynamicFeeTxType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, eth, wallet := newTxTestClient(t, tt.baseFee)
+
+			result, err := client.SendTransactionWithOptions(context.Background(), wallet, to, big.NewInt(1), nil, TxOptions{Mode: tt.mode})
+			if err != nil {
+				t.Fatalf("SendTransaction: %v", err)
+			}
+			tx := sentTx(t, eth, wallet)
+			if tx.Type() != tt.wantType || result.Type != tt.wantType {
+				t.Fatalf("sent type %d (reported %d), want %d", tx.Type(), result.Type, tt.wantType)
+			}
+			if tx.ChainId().Int64() != 1337 {
+				t.Fatalf("chain ID = %s, want 1337", tx.ChainId())
+			}
+
+			if tt.wantType == types.DynamicFeeTxType {
+				// Fee cap covers a doubled base fee plus the suggested tip
+				if tx.GasTipCap().Int64() != 2 || tx.GasFeeCap().Int64() != 22 {
+					t.Fatalf("tip %s, fee cap %s; want 2 and 22", tx.GasTipCap(), tx.GasFeeCap())
+				}
+			} else if tx.GasPrice().Int64() != 5 {
+				t.Fatalf("gas price = %s, want 5", tx.GasPrice())
+			}
+		})
+	}
+}
+
+func TestSendTransactionDynamicFeeOptions(t *testing.T) {
+	to := common.HexToAddress("0x3000000000000000000000000000000000000003")
+
+	client, eth, wallet := newTxTestClient(t, big.NewInt(10))
+	_, err := client.SendTransactionWithOptions(context.Background(), wallet, to, big.NewInt(1), nil,
+		TxOptions{Mode: TxModeDynamicFee, GasTipCap: big.NewInt(7), GasLimit: 50000})
+	if err != nil {
+		t.Fatalf("SendTransaction: %v", err)
+	}
+	tx := sentTx(t, eth, wallet)
+	if tx.GasTipCap().Int64() != 7 || tx.GasFeeCap().Int64() != 27 || tx.Gas() != 50000 {
+		t.Fatalf("tip %s, fee cap %s, gas %d; want 7, 27 and 50000", tx.GasTipCap(), tx.GasFeeCap(), tx.Gas())
+	}
+	if eth.count("eth_maxPriorityFeePerGas") != 0 {
+		t.Fatal("the tip was suggested despite the override")
+	}
+
+	// Without a base fee the chain can't take EIP-1559 transactions
+	legacy, _, wallet := newTxTestClient(t, nil)
+	if _, err := legacy.SendTransactionWithOptions(context.Background(), wallet, to, big.NewInt(1), nil, TxOptions{Mode: TxModeDynamicFee}); err == nil {
+		t.Fatal("sent a dynamic fee transaction on a legacy chain")
+	}
+}
@@ -0,0 +1,81 @@
+package web3
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ErrRPCNotConfigured is returned when a network has no usable RPC URL
+var ErrRPCNotConfigured = errors.New("rpc url not configured")
+
+// NetworkEnvPrefix returns the environment variable prefix for a network,
+// e.g. WEB3_ETHEREUM for ethereum and WEB3_BSC_TESTNET for bsc-testnet
+func NetworkEnvPrefix(network Network) string {
+	name := strings.ToUpper(strings.NewReplacer("-", "_", ".", "_", " ", "_").Replace(string(network)))
+	return "WEB3_" + name
+}
+
+// LoadNetworkConfig returns a copy of base with overrides from the
+// environment applied:
+//
+//	WEB3_<NETWORK>_RPC       RPC URL
+//	WEB3_<NETWORK>_WS        WebSocket URL
+//	WEB3_<NETWORK>_CHAIN_ID  Chain ID
+//	WEB3_<NETWORK>_EXPLORER  Block explorer URL
+//	WEB3_<NETWORK>_MULTICALL Multicall3 contract address
+func LoadNetworkConfig(network Network, base *NetworkConfig) (*NetworkConfig, error) {
+	config := &NetworkConfig{Network: network}
+	if base != nil {
+		*config = *base
+		config.Network = network
+		if base.ChainID != nil {
+			config.ChainID = new(big.Int).Set(base.ChainID)
+		}
+	}
+
+	prefix := NetworkEnvPrefix(network)
+
+	if rpcURL := os.Getenv(prefix + "_RPC"); rpcURL != "" {
+		config.RPCURL = rpcURL
+	}
+	if wsURL := os.Getenv(prefix + "_WS"); wsURL != "" {
+		config.WSURL = wsURL
+	}
+	if explorer := os.Getenv(prefix + "_EXPLORER"); explorer != "" {
+		config.Explorer = explorer
+	}
+	if chainID := os.Getenv(prefix + "_CHAIN_ID"); chainID != "" {
+		id, ok := new(big.Int).SetString(chainID, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid %s_CHAIN_ID: %q", prefix, chainID)
+		}
+		config.ChainID = id
+	}
+	if multicall := os.Getenv(prefix + "_MULTICALL"); multicall != "" {
+		if !common.IsHexAddress(multicall) {
+			return nil, fmt.Errorf("invalid %s_MULTICALL: %q", prefix, multicall)
+		}
+		config.Multicall = common.HexToAddress(multicall)
+	}
+
+	return config, nil
+}
+
+// validateNetworkConfig checks a config is complete enough to dial
+func validateNetworkConfig(config *NetworkConfig) error {
+	if config == nil || config.Network == "" {
+		return errors.New("network name is required")
+	}
+	if config.RPCURL == "" || strings.Contains(config.RPCURL, "YOUR_API_KEY") {
+		return fmt.Errorf("%w for network %s: set %s_RPC", ErrRPCNotConfigured, config.Network, NetworkEnvPrefix(config.Network))
+	}
+	if config.ChainID == nil || config.ChainID.Sign() <= 0 {
+		return fmt.Errorf("chain id not configured for network %s: set %s_CHAIN_ID", config.Network, NetworkEnvPrefix(config.Network))
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package web3
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestNetworkConfigEnvOverride(t *testing.T) {
+	t.Setenv("WEB3_ETHEREUM_RPC", "https://eth.example.com/v3/key")
+	t.Setenv("WEB3_ETHEREUM_WS", "wss://eth.example.com/ws/v3/key")
+	t.Setenv("WEB3_ETHEREUM_CHAIN_ID", "5")
+	t.Setenv("WEB3_ETHEREUM_EXPLORER", "https://explorer.example.com")
+
+	manager := NewWeb3Manager()
+	config, err := manager.NetworkConfig(NetworkEthereum)
+	if err != nil {
+		t.Fatalf("NetworkConfig: %v", err)
+	}
+	if config.RPCURL != "https://eth.example.com/v3/key" || config.WSURL != "wss://eth.example.com/ws/v3/key" {
+		t.Fatalf("urls = %s, %s; want the environment's", config.RPCURL, config.WSURL)
+	}
+	if config.ChainID.Int64() != 5 || config.Explorer != "https://explorer.example.com" {
+		t.Fatalf("config = %+v, want the environment's chain id and explorer", config)
+	}
+	// Settings not in the environment fall back to the defaults
+	if config.NativeCoin != "ETH" || config.Multicall != Multicall3Address {
+		t.Fatalf("config = %+v, want the default coin and multicall", config)
+	}
+
+	// The defaults themselves are untouched
+	defaults := DefaultNetworkConfigs[NetworkEthereum]
+	if defaults.RPCURL != "" || defaults.ChainID.Int64() != 1 {
+		t.Fatalf("defaults changed: %+v", defaults)
+	}
+}
+
+func TestNetworkConfigInvalidEnv(t *testing.T) {
+	tests := []struct {
+		key   string
+		value string
+	}{
+		{"WEB3_POLYGON_CHAIN_ID", "mainnet"},
+		{"WEB3_POLYGON_MULTICALL", "0x123"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			t.Setenv(tt.key, tt.value)
+
+			_, err := NewWeb3Manager().NetworkConfig(NetworkPolygon)
+			if err == nil || !strings.Contains(err.Error(), tt.key) {
+				t.Fatalf("error = %v, want it to name %s", err, tt.key)
+			}
+		})
+	}
+}
+
+func TestConnectNetworkWithoutRPC(t *testing.T) {
+	t.Setenv("WEB3_ETHEREUM_RPC", "")
+
+	manager := NewWeb3Manager()
+	err := manager.ConnectNetwork(NetworkEthereum)
+	if !errors.Is(err, ErrRPCNotConfigured) {
+		t.Fatalf("error = %v, want ErrRPCNotConfigured", err)
+	}
+	if !strings.Contains(err.Error(), "WEB3_ETHEREUM_RPC") {
+		t.Fatalf("error = %v, want it to name the variable to set", err)
+	}
+
+	// Placeholder URLs are treated as missing
+	err = manager.Connect(&NetworkConfig{Network: "legacy", ChainID: big.NewInt(1), RPCURL: "https://mainnet.infura.io/v3/YOUR_API_KEY"})
+	if !errors.Is(err, ErrRPCNotConfigured) {
+		t.Fatalf("error = %v, want ErrRPCNotConfigured for a placeholder URL", err)
+	}
+}
+
+func TestRegisterCustomNetwork(t *testing.T) {
+	manager := NewWeb3Manager()
+	custom := Network("base-sepolia")
+
+	if err := manager.RegisterNetwork(&NetworkConfig{Network: custom, RPCURL: "http://127.0.0.1:8545"}); err == nil {
+		t.Fatal("registered a network without a chain id")
+	}
+	if err := manager.ConnectNetwork(custom); err == nil {
+		t.Fatal("connected to an unknown network")
+	}
+
+	config := &NetworkConfig{
+		Network:    custom,
+		ChainID:    big.NewInt(84532),
+		RPCURL:     "http://127.0.0.1:8545",
+		NativeCoin: "ETH",
+	}
+	if err := manager.RegisterNetwork(config); err != nil {
+		t.Fatalf("RegisterNetwork: %v", err)
+	}
+	// The manager keeps its own copy
+	config.ChainID.SetInt64(1)
+	config.RPCURL = ""
+
+	t.Setenv("WEB3_BASE_SEPOLIA_EXPLORER", "https://sepolia.basescan.org")
+	resolved, err := manager.NetworkConfig(custom)
+	if err != nil {
+		t.Fatalf("NetworkConfig: %v", err)
+	}
+	if resolved.ChainID.Int64() != 84532 || resolved.RPCURL != "http://127.0.0.1:8545" {
+		t.Fatalf("resolved = %+v, want the registered settings", resolved)
+	}
+	if resolved.Explorer != "https://sepolia.basescan.org" {
+		t.Fatalf("explorer = %q, want the environment override", resolved.Explorer)
+	}
+
+	if err := manager.ConnectNetwork(custom); err != nil {
+		t.Fatalf("ConnectNetwork: %v", err)
+	}
+	defer manager.Disconnect(custom)
+	client, err := manager.GetClient(custom)
+	if err != nil {
+		t.Fatalf("GetClient: %v", err)
+	}
+	if client.chainID.Int64() != 84532 {
+		t.Fatalf("client chain id = %s, want 84532", client.chainID)
+	}
+}
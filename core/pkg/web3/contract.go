@@ -7,19 +7,30 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 )
 
 // ContractManager manages smart contract interactions
 type ContractManager struct {
-	client    *Web3Client
-	contracts map[common.Address]*Contract
-	mu        sync.RWMutex
+	client       *Web3Client
+	contracts    map[common.Address]*Contract
+	logChunkSize uint64
+	mu           sync.RWMutex
+
+	// noMulticall is set once the network's Multicall address turns out to
+	// have no contract, so later batches go straight to individual calls
+	noMulticall bool
 }
 
+// DefaultLogChunkSize is the block window used per eth_getLogs request; most
+// providers reject larger ranges
+const DefaultLogChunkSize uint64 = 2000
+
 // Contract smart contract wrapper
 type Contract struct {
 	Address  common.Address
@@ -39,9 +50,20 @@ type ContractEvent struct {
 // NewContractManager creates a new contract manager
 func NewContractManager(client *Web3Client) *ContractManager {
 	return &ContractManager{
-		client:    client,
-		contracts: make(map[common.Address]*Contract),
+		client:       client,
+		contracts:    make(map[common.Address]*Contract),
+		logChunkSize: DefaultLogChunkSize,
+	}
+}
+
+// SetLogChunkSize sets the block window used when querying logs
+func (m *ContractManager) SetLogChunkSize(blocks uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if blocks == 0 {
+		blocks = DefaultLogChunkSize
 	}
+	m.logChunkSize = blocks
 }
 
 // LoadContract loads a contract
@@ -94,23 +116,21 @@ func (m *ContractManager) CallMethod(ctx context.Context, contractAddress common
 		return nil, fmt.Errorf("failed to pack method: %w", err)
 	}
 
-	// Call contract
-	msg := map[string]interface{}{
-		"to":   contractAddress,
-		"data": data,
+	// Call contract at the latest block
+	output, err := m.client.client.CallContract(ctx, ethereum.CallMsg{
+		To:   &contractAddress,
+		Data: data,
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call %s: %w", methodName, err)
 	}
 
-	// This would use ethereum.CallMsg in real implementation
-	_ = msg
-
 	// Unpack results
-	method, exists := contract.ABI.Methods[methodName]
-	if !exists {
-		return nil, fmt.Errorf("method not found: %s", methodName)
+	results, err := contract.ABI.Unpack(methodName, output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack %s result: %w", methodName, err)
 	}
 
-	// For this example, return empty results
-	results := make([]interface{}, len(method.Outputs))
 	return results, nil
 }
 
@@ -223,8 +243,13 @@ func (m *ContractManager) WatchEvents(ctx context.Context, contractAddress commo
 	return eventChan, nil
 }
 
-// GetPastEvents gets past contract events
+// GetPastEvents gets past contract events in the inclusive block range
+// [fromBlock, toBlock]. The range is queried in windows of the log chunk size.
 func (m *ContractManager) GetPastEvents(ctx context.Context, contractAddress common.Address, eventName string, fromBlock, toBlock uint64) ([]*ContractEvent, error) {
+	if toBlock < fromBlock {
+		return nil, fmt.Errorf("invalid block range: toBlock %d is before fromBlock %d", toBlock, fromBlock)
+	}
+
 	contract, err := m.GetContract(contractAddress)
 	if err != nil {
 		return nil, err
@@ -236,14 +261,92 @@ func (m *ContractManager) GetPastEvents(ctx context.Context, contractAddress com
 		return nil, fmt.Errorf("event not found: %s", eventName)
 	}
 
-	_ = event // Use event for filtering
+	logs, err := m.filterLogs(ctx, ethereum.FilterQuery{
+		Addresses: []common.Address{contractAddress},
+		Topics:    [][]common.Hash{{event.ID}},
+	}, fromBlock, toBlock)
+	if err != nil {
+		return nil, err
+	}
 
-	// This would use FilterLogs in real implementation
-	events := make([]*ContractEvent, 0)
+	events := make([]*ContractEvent, 0, len(logs))
+	for _, log := range logs {
+		data, err := decodeEventLog(contract.ABI, event, log)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s log in tx %s: %w", eventName, log.TxHash.Hex(), err)
+		}
+
+		events = append(events, &ContractEvent{
+			Name:        eventName,
+			Address:     log.Address,
+			BlockNumber: log.BlockNumber,
+			TxHash:      log.TxHash,
+			Data:        data,
+		})
+	}
 
 	return events, nil
 }
 
+// filterLogs runs the query over [fromBlock, toBlock] in chunks, skipping removed (reorged) logs
+func (m *ContractManager) filterLogs(ctx context.Context, query ethereum.FilterQuery, fromBlock, toBlock uint64) ([]types.Log, error) {
+	m.mu.RLock()
+	chunk := m.logChunkSize
+	m.mu.RUnlock()
+
+	var logs []types.Log
+	for start := fromBlock; start <= toBlock; start += chunk {
+		end := start + chunk - 1
+		if end > toBlock || end < start { // clamp, including overflow
+			end = toBlock
+		}
+
+		query.FromBlock = new(big.Int).SetUint64(start)
+		query.ToBlock = new(big.Int).SetUint64(end)
+
+		chunkLogs, err := m.client.client.FilterLogs(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to filter logs in blocks %d-%d: %w", start, end, err)
+		}
+		for _, log := range chunkLogs {
+			if !log.Removed {
+				logs = append(logs, log)
+			}
+		}
+
+		if end == toBlock {
+			break
+		}
+	}
+
+	return logs, nil
+}
+
+// decodeEventLog decodes indexed topics and non-indexed data into a single map
+func decodeEventLog(contractABI abi.ABI, event abi.Event, log types.Log) (map[string]interface{}, error) {
+	data := make(map[string]interface{})
+
+	if len(log.Data) > 0 {
+		if err := contractABI.UnpackIntoMap(data, event.Name, log.Data); err != nil {
+			return nil, err
+		}
+	}
+
+	var indexed abi.Arguments
+	for _, input := range event.Inputs {
+		if input.Indexed {
+			indexed = append(indexed, input)
+		}
+	}
+	if len(indexed) > 0 && len(log.Topics) > 1 {
+		if err := abi.ParseTopicsIntoMap(data, indexed, log.Topics[1:]); err != nil {
+			return nil, err
+		}
+	}
+
+	return data, nil
+}
+
 // ERC20 standard interface helpers
 
 // ERC20Transfer transfers ERC20 tokens
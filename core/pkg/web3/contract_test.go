@@ -0,0 +1,90 @@
+package web3
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestGetPastEvents(t *testing.T) {
+	txHash := common.HexToHash("0xdef")
+	var windows [][2]int64
+	eth := &mockEth{
+		logs: func(q filterArgs) []types.Log {
+			from, to := q.FromBlock.ToInt().Int64(), q.ToBlock.ToInt().Int64()
+			windows = append(windows, [2]int64{from, to})
+
+			var logs []types.Log
+			for _, block := range []int64{105, 150, 230} {
+				if block >= from && block <= to {
+					log := testTransferLog(testHolder, block, uint64(block))
+					log.TxHash = txHash
+					logs = append(logs, log)
+				}
+			}
+			// Reorged logs are skipped
+			if from <= 150 && 150 <= to {
+				removed := testTransferLog(testHolder, 999, 150)
+				removed.Removed = true
+				logs = append(logs, removed)
+			}
+			return logs
+		},
+	}
+	manager := newTestNFTManager(t, eth, testNFTABI).contractManager
+	manager.SetLogChunkSize(50)
+
+	events, err := manager.GetPastEvents(context.Background(), testNFT, "Transfer", 100, 230)
+	if err != nil {
+		t.Fatalf("GetPastEvents: %v", err)
+	}
+
+	wantWindows := [][2]int64{{100, 149}, {150, 199}, {200, 230}}
+	if len(windows) != len(wantWindows) {
+		t.Fatalf("queried windows %v, want %v", windows, wantWindows)
+	}
+	for i, window := range wantWindows {
+		if windows[i] != window {
+			t.Fatalf("queried windows %v, want %v", windows, wantWindows)
+		}
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want 3", len(events))
+	}
+	for i, block := range []int64{105, 150, 230} {
+		event := events[i]
+		if event.Name != "Transfer" || event.BlockNumber != uint64(block) || event.TxHash != txHash || event.Address != testNFT {
+			t.Errorf("event %d = %+v", i, event)
+		}
+		if event.Data["to"] != testHolder || event.Data["tokenId"].(*big.Int).Int64() != block {
+			t.Errorf("event %d data = %v", i, event.Data)
+		}
+	}
+}
+
+func TestGetPastEventsRejectsInvalidRange(t *testing.T) {
+	eth := &mockEth{logs: func(filterArgs) []types.Log { return nil }}
+	manager := newTestNFTManager(t, eth, testNFTABI).contractManager
+
+	if _, err := manager.GetPastEvents(context.Background(), testNFT, "Transfer", 200, 100); err == nil {
+		t.Fatal("GetPastEvents accepted toBlock before fromBlock")
+	}
+	if _, err := manager.GetPastEvents(context.Background(), testNFT, "Approval", 0, 100); err == nil {
+		t.Fatal("GetPastEvents accepted an event missing from the ABI")
+	}
+	if n := eth.count("eth_getLogs"); n != 0 {
+		t.Fatalf("%d log queries for invalid requests", n)
+	}
+
+	// A single block range is one window
+	if _, err := manager.GetPastEvents(context.Background(), testNFT, "Transfer", 100, 100); err != nil {
+		t.Fatalf("GetPastEvents: %v", err)
+	}
+	if n := eth.count("eth_getLogs"); n != 1 {
+		t.Fatalf("%d log queries for a single block, want 1", n)
+	}
+}
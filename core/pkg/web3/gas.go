@@ -0,0 +1,204 @@
+package web3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+)
+
+// FeeTier is a transaction speed for EIP-1559 fee suggestions
+type FeeTier string
+
+const (
+	FeeTierSlow     FeeTier = "slow"
+	FeeTierStandard FeeTier = "standard"
+	FeeTierFast     FeeTier = "fast"
+)
+
+// ErrNoBaseFee is returned by GasOracle when the network has no EIP-1559
+// base fee
+var ErrNoBaseFee = errors.New("network has no EIP-1559 base fee")
+
+// FeeSuggestion EIP-1559 fees for a tier
+type FeeSuggestion struct {
+	Tier           FeeTier
+	BaseFee        *big.Int // Base fee of the pending block
+	MaxPriorityFee *big.Int // GasTipCap
+	MaxFee         *big.Int // GasFeeCap: twice the base fee plus the tip
+}
+
+// FeeHistoryReader reads eth_feeHistory; *ethclient.Client implements it
+type FeeHistoryReader interface {
+	FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error)
+}
+
+// GasOracleConfig configures a GasOracle
+type GasOracleConfig struct {
+	BlockCount  uint64              // Recent blocks sampled by eth_feeHistory
+	Percentiles map[FeeTier]float64 // Priority fee percentile of each tier
+	CacheTTL    time.Duration       // How long fetched fees are reused
+}
+
+// DefaultGasOracleConfig returns the default gas oracle config
+func DefaultGasOracleConfig() GasOracleConfig {
+	return GasOracleConfig{
+		BlockCount: 20,
+		Percentiles: map[FeeTier]float64{
+			FeeTierSlow:     10,
+			FeeTierStandard: 50,
+			FeeTierFast:     90,
+		},
+		CacheTTL: 10 * time.Second,
+	}
+}
+
+// GasOracle suggests EIP-1559 fees per tier from the base fee and the
+// priority fees paid in recent blocks. Results are cached for CacheTTL, so
+// one oracle should be shared per network.
+type GasOracle struct {
+	reader FeeHistoryReader
+	config GasOracleConfig
+	tiers  []FeeTier // Tiers in the order their percentiles are requested
+
+	mu        sync.Mutex
+	fetchedAt time.Time
+	baseFee   *big.Int
+	tips      map[FeeTier]*big.Int
+}
+
+// NewGasOracle creates a gas oracle
+func NewGasOracle(reader FeeHistoryReader, config ...GasOracleConfig) *GasOracle {
+	cfg := DefaultGasOracleConfig()
+	if len(config) > 0 {
+		cfg = config[0]
+		if cfg.BlockCount == 0 {
+			cfg.BlockCount = DefaultGasOracleConfig().BlockCount
+		}
+		if len(cfg.Percentiles) == 0 {
+			cfg.Percentiles = DefaultGasOracleConfig().Percentiles
+		}
+	}
+
+	// eth_feeHistory wants the percentiles in ascending order
+	tiers := make([]FeeTier, 0, len(cfg.Percentiles))
+	for tier := range cfg.Percentiles {
+		tiers = append(tiers, tier)
+	}
+	sort.Slice(tiers, func(i, j int) bool {
+		return cfg.Percentiles[tiers[i]] < cfg.Percentiles[tiers[j]]
+	})
+
+	return &GasOracle{
+		reader: reader,
+		config: cfg,
+		tiers:  tiers,
+	}
+}
+
+// Suggest returns the fees for a tier
+func (o *GasOracle) Suggest(ctx context.Context, tier FeeTier) (*FeeSuggestion, error) {
+	if _, ok := o.config.Percentiles[tier]; !ok {
+		return nil, fmt.Errorf("unknown fee tier: %s", tier)
+	}
+
+	baseFee, tips, err := o.fees(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tip := new(big.Int).Set(tips[tier])
+	return &FeeSuggestion{
+		Tier:           tier,
+		BaseFee:        new(big.Int).Set(baseFee),
+		MaxPriorityFee: tip,
+		MaxFee:         new(big.Int).Add(new(big.Int).Mul(baseFee, big.NewInt(2)), tip),
+	}, nil
+}
+
+// SuggestAll returns the fees for every tier
+func (o *GasOracle) SuggestAll(ctx context.Context) (map[FeeTier]*FeeSuggestion, error) {
+	suggestions := make(map[FeeTier]*FeeSuggestion, len(o.tiers))
+	for _, tier := range o.tiers {
+		suggestion, err := o.Suggest(ctx, tier)
+		if err != nil {
+			return nil, err
+		}
+		suggestions[tier] = suggestion
+	}
+	return suggestions, nil
+}
+
+// fees returns the pending base fee and the tip of each tier, fetching
+// them when the cache is stale
+func (o *GasOracle) fees(ctx context.Context) (*big.Int, map[FeeTier]*big.Int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.baseFee != nil && time.Since(o.fetchedAt) < o.config.CacheTTL {
+		return o.baseFee, o.tips, nil
+	}
+
+	percentiles := make([]float64, len(o.tiers))
+	for i, tier := range o.tiers {
+		percentiles[i] = o.config.Percentiles[tier]
+	}
+
+	history, err := o.reader.FeeHistory(ctx, o.config.BlockCount, nil, percentiles)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get fee history: %w", err)
+	}
+
+	// The last base fee is the one of the next block
+	if len(history.BaseFee) == 0 {
+		return nil, nil, ErrNoBaseFee
+	}
+	baseFee := history.BaseFee[len(history.BaseFee)-1]
+	if baseFee == nil || baseFee.Sign() == 0 {
+		return nil, nil, ErrNoBaseFee
+	}
+
+	tips := make(map[FeeTier]*big.Int, len(o.tiers))
+	for i, tier := range o.tiers {
+		tips[tier] = medianReward(history, i)
+	}
+
+	// A tier never tips less than a slower one
+	for i := 1; i < len(o.tiers); i++ {
+		if tips[o.tiers[i]].Cmp(tips[o.tiers[i-1]]) < 0 {
+			tips[o.tiers[i]] = tips[o.tiers[i-1]]
+		}
+	}
+
+	o.baseFee = baseFee
+	o.tips = tips
+	o.fetchedAt = time.Now()
+	return baseFee, tips, nil
+}
+
+// medianReward returns the median over blocks of one reward percentile.
+// Empty blocks, which report zero rewards, are skipped.
+func medianReward(history *ethereum.FeeHistory, index int) *big.Int {
+	values := make([]*big.Int, 0, len(history.Reward))
+	for i, block := range history.Reward {
+		if i < len(history.GasUsedRatio) && history.GasUsedRatio[i] == 0 {
+			continue
+		}
+		if index < len(block) && block[index] != nil {
+			values = append(values, block[index])
+		}
+	}
+	if len(values) == 0 {
+		return new(big.Int)
+	}
+
+	sort.Slice(values, func(i, j int) bool {
+		return values[i].Cmp(values[j]) < 0
+	})
+	return new(big.Int).Set(values[len(values)/2])
+}
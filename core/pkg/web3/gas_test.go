@@ -0,0 +1,223 @@
+package web3
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// testFeeHistory has four blocks with distinct 10th, 50th and 90th
+// percentile tips (medians 1, 3 and 8 gwei) and an empty block whose zero
+// rewards must not drag the medians down
+func testFeeHistory() *ethereum.FeeHistory {
+	gwei := func(n int64) *big.Int { return new(big.Int).Mul(big.NewInt(n), big.NewInt(1e9)) }
+	return &ethereum.FeeHistory{
+		OldestBlock: big.NewInt(100),
+		Reward: [][]*big.Int{
+			{gwei(1), gwei(2), gwei(6)},
+			{gwei(0), gwei(0), gwei(0)},
+			{gwei(1), gwei(3), gwei(8)},
+			{gwei(2), gwei(4), gwei(9)},
+			{gwei(1), gwei(3), gwei(8)},
+		},
+		// One more base fee than blocks: the last is the pending block's
+		BaseFee:      []*big.Int{gwei(28), gwei(29), gwei(30), gwei(31), gwei(32), gwei(30)},
+		GasUsedRatio: []float64{0.5, 0, 0.6, 0.9, 0.4},
+	}
+}
+
+// fakeFeeHistory is a FeeHistoryReader returning a fixed history
+type fakeFeeHistory struct {
+	mu          sync.Mutex
+	history     *ethereum.FeeHistory
+	err         error
+	calls       int
+	blockCount  uint64
+	percentiles []float64
+}
+
+func (f *fakeFeeHistory) FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	f.blockCount = blockCount
+	f.percentiles = rewardPercentiles
+	return f.history, f.err
+}
+
+func (f *fakeFeeHistory) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+func TestGasOracleSuggestTiers(t *testing.T) {
+	reader := &fakeFeeHistory{history: testFeeHistory()}
+	oracle := NewGasOracle(reader)
+
+	suggestions, err := oracle.SuggestAll(context.Background())
+	if err != nil {
+		t.Fatalf("SuggestAll: %v", err)
+	}
+
+	tests := []struct {
+		tier    FeeTier
+		tipGwei int64
+	}{
+		{FeeTierSlow, 1},
+		{FeeTierStandard, 3},
+		{FeeTierFast, 8},
+	}
+	baseFee := big.NewInt(30e9)
+	for _, tt := range tests {
+		suggestion := suggestions[tt.tier]
+		if suggestion == nil {
+			t.Fatalf("no %s suggestion", tt.tier)
+		}
+		wantTip := new(big.Int).Mul(big.NewInt(tt.tipGwei), big.NewInt(1e9))
+		wantMaxFee := new(big.Int).Add(new(big.Int).Mul(baseFee, big.NewInt(2)), wantTip)
+		if suggestion.Tier != tt.tier || suggestion.BaseFee.Cmp(baseFee) != 0 ||
+			suggestion.MaxPriorityFee.Cmp(wantTip) != 0 || suggestion.MaxFee.Cmp(wantMaxFee) != 0 {
+			t.Errorf("%s = %+v, want base fee %s, tip %s and max fee %s", tt.tier, suggestion, baseFee, wantTip, wantMaxFee)
+		}
+	}
+
+	// All tiers come from one request with the percentiles in ascending order
+	if reader.count() != 1 {
+		t.Fatalf("fee history fetched %d times, want 1", reader.count())
+	}
+	want := []float64{10, 50, 90}
+	if reader.blockCount != 20 || len(reader.percentiles) != len(want) {
+		t.Fatalf("requested %d blocks at %v, want 20 at %v", reader.blockCount, reader.percentiles, want)
+	}
+	for i := range want {
+		if reader.percentiles[i] != want[i] {
+			t.Fatalf("requested percentiles %v, want %v", reader.percentiles, want)
+		}
+	}
+}
+
+func TestGasOracleCachesFees(t *testing.T) {
+	reader := &fakeFeeHistory{history: testFeeHistory()}
+	oracle := NewGasOracle(reader, GasOracleConfig{CacheTTL: 20 * time.Millisecond})
+	ctx := context.Background()
+
+	for _, tier := range []FeeTier{FeeTierSlow, FeeTierFast, FeeTierStandard} {
+		if _, err := oracle.Suggest(ctx, tier); err != nil {
+			t.Fatalf("Suggest(%s): %v", tier, err)
+		}
+	}
+	if reader.count() != 1 {
+		t.Fatalf("fee history fetched %d times within the TTL, want 1", reader.count())
+	}
+
+	// Suggestions are copies, so callers can't change the cached fees
+	first, _ := oracle.Suggest(ctx, FeeTierFast)
+	first.MaxPriorityFee.SetInt64(0)
+	first.BaseFee.SetInt64(0)
+	if again, _ := oracle.Suggest(ctx, FeeTierFast); again.MaxPriorityFee.Sign() == 0 || again.BaseFee.Sign() == 0 {
+		t.Fatal("changing a suggestion changed the cached fees")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if _, err := oracle.Suggest(ctx, FeeTierSlow); err != nil {
+		t.Fatalf("Suggest: %v", err)
+	}
+	if reader.count() != 2 {
+		t.Fatalf("fee history fetched %d times after the TTL, want 2", reader.count())
+	}
+}
+
+func TestGasOracleTiersAreMonotonic(t *testing.T) {
+	// The faster percentiles paid less in the only block
+	history := &ethereum.FeeHistory{
+		Reward:       [][]*big.Int{{big.NewInt(5), big.NewInt(3), big.NewInt(1)}},
+		BaseFee:      []*big.Int{big.NewInt(10), big.NewInt(10)},
+		GasUsedRatio: []float64{0.5},
+	}
+	suggestions, err := NewGasOracle(&fakeFeeHistory{history: history}).SuggestAll(context.Background())
+	if err != nil {
+		t.Fatalf("SuggestAll: %v", err)
+	}
+	for _, tier := range []FeeTier{FeeTierSlow, FeeTierStandard, FeeTierFast} {
+		if tip := suggestions[tier].MaxPriorityFee.Int64(); tip != 5 {
+			t.Errorf("%s tip = %d, want 5", tier, tip)
+		}
+	}
+}
+
+func TestGasOracleErrors(t *testing.T) {
+	ctx := context.Background()
+
+	oracle := NewGasOracle(&fakeFeeHistory{history: testFeeHistory()})
+	if _, err := oracle.Suggest(ctx, "instant"); err == nil {
+		t.Fatal("Suggest accepted an unknown tier")
+	}
+
+	noBaseFee := []*ethereum.FeeHistory{
+		{Reward: [][]*big.Int{{big.NewInt(1)}}, GasUsedRatio: []float64{0.5}},
+		{BaseFee: []*big.Int{big.NewInt(10), big.NewInt(0)}, GasUsedRatio: []float64{0.5}},
+	}
+	for _, history := range noBaseFee {
+		if _, err := NewGasOracle(&fakeFeeHistory{history: history}).Suggest(ctx, FeeTierFast); !errors.Is(err, ErrNoBaseFee) {
+			t.Errorf("Suggest = %v, want ErrNoBaseFee", err)
+		}
+	}
+
+	failure := errors.New("node unavailable")
+	if _, err := NewGasOracle(&fakeFeeHistory{err: failure}).Suggest(ctx, FeeTierFast); !errors.Is(err, failure) {
+		t.Fatalf("Suggest = %v, want the node error", err)
+	}
+}
+
+func TestSendTransactionFeeTier(t *testing.T) {
+	to := common.HexToAddress("0x3000000000000000000000000000000000000003")
+
+	client, eth, wallet := newTxTestClient(t, big.NewInt(10))
+	eth.feeHistory = func(blockCount uint64, percentiles []float64) *feeHistoryResult {
+		history := testFeeHistory()
+		result := &feeHistoryResult{OldestBlock: (*hexutil.Big)(history.OldestBlock), GasUsedRatio: history.GasUsedRatio}
+		for _, block := range history.Reward {
+			rewards := make([]*hexutil.Big, len(block))
+			for i, reward := range block {
+				rewards[i] = (*hexutil.Big)(reward)
+			}
+			result.Reward = append(result.Reward, rewards)
+		}
+		for _, baseFee := range history.BaseFee {
+			result.BaseFee = append(result.BaseFee, (*hexutil.Big)(baseFee))
+		}
+		return result
+	}
+
+	_, err := client.SendTransactionWithOptions(context.Background(), wallet, to, big.NewInt(1), nil, TxOptions{FeeTier: FeeTierFast})
+	if err != nil {
+		t.Fatalf("SendTransaction: %v", err)
+	}
+	tx := sentTx(t, eth, wallet)
+	// The fast tip on the oracle's pending base fee, not the header's
+	if tx.GasTipCap().Int64() != 8e9 || tx.GasFeeCap().Int64() != 68e9 {
+		t.Fatalf("tip %s, fee cap %s; want 8 and 68 gwei", tx.GasTipCap(), tx.GasFeeCap())
+	}
+	if eth.count("eth_maxPriorityFeePerGas") != 0 {
+		t.Fatal("the tip was suggested by the node despite the tier")
+	}
+
+	// The oracle is shared, so a second transaction reuses the fees
+	if _, err := client.SendTransactionWithOptions(context.Background(), wallet, to, big.NewInt(1), nil, TxOptions{FeeTier: FeeTierSlow}); err != nil {
+		t.Fatalf("SendTransaction: %v", err)
+	}
+	if eth.count("eth_feeHistory") != 1 {
+		t.Fatalf("fee history fetched %d times, want 1", eth.count("eth_feeHistory"))
+	}
+	if tip := eth.sent[1].GasTipCap().Int64(); tip != 1e9 {
+		t.Fatalf("slow tip = %d, want 1 gwei", tip)
+	}
+}
@@ -0,0 +1,209 @@
+package web3
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// DefaultIPFSGateway is used to fetch ipfs:// URIs when no gateway is configured
+const DefaultIPFSGateway = "https://ipfs.io/ipfs/"
+
+// maxMetadataSize bounds how much of a metadata document is read
+const maxMetadataSize = 1 << 20
+
+// blockedPrefixes are non-public ranges not covered by the net.IP checks in
+// isPublicIP
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// errPrivateAddress is returned for token URIs pointing at non-public hosts
+var errPrivateAddress = errors.New("token URI resolves to a non-public address")
+
+// MetadataResolver fetches and parses NFT metadata from token URIs.
+// It supports https://, ipfs:// (through a gateway) and data: URIs. Token
+// URIs are set by contract owners, so https:// URIs may only reach public
+// addresses; the gateway is trusted and may be a local IPFS node.
+type MetadataResolver struct {
+	httpClient    *http.Client // for token URIs
+	gatewayClient *http.Client // for the configured IPFS gateway
+	ipfsGateway   string
+}
+
+// NewMetadataResolver creates a metadata resolver. An empty gateway uses
+// DefaultIPFSGateway and a zero timeout defaults to 10 seconds.
+func NewMetadataResolver(ipfsGateway string, timeout time.Duration) *MetadataResolver {
+	if ipfsGateway == "" {
+		ipfsGateway = DefaultIPFSGateway
+	}
+	if !strings.HasSuffix(ipfsGateway, "/") {
+		ipfsGateway += "/"
+	}
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	dialer := &net.Dialer{Timeout: timeout, Control: publicAddressOnly}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// Through a proxy, the address check would only see the proxy
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &MetadataResolver{
+		httpClient: &http.Client{
+			Timeout:   timeout,
+			Transport: transport,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if req.URL.Scheme != "https" {
+					return fmt.Errorf("metadata redirect to unsupported scheme: %s", req.URL.Scheme)
+				}
+				if len(via) >= 5 {
+					return errors.New("too many metadata redirects")
+				}
+				return nil
+			},
+		},
+		gatewayClient: &http.Client{Timeout: timeout},
+		ipfsGateway:   ipfsGateway,
+	}
+}
+
+// publicAddressOnly refuses connections to loopback, private, link-local and
+// other non-public addresses. It runs after name resolution, so hostnames
+// resolving to such addresses are refused too.
+func publicAddressOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !isPublicIP(ip) {
+		return errPrivateAddress
+	}
+	return nil
+}
+
+// isPublicIP reports whether ip is a globally routable unicast address
+func isPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// GatewayURL rewrites ipfs:// URIs (including ipfs://ipfs/<cid>) to the gateway; other URIs are returned unchanged
+func (r *MetadataResolver) GatewayURL(uri string) string {
+	if !strings.HasPrefix(uri, "ipfs://") {
+		return uri
+	}
+	path := strings.TrimPrefix(uri, "ipfs://")
+	path = strings.TrimPrefix(path, "ipfs/")
+	return r.ipfsGateway + path
+}
+
+// Resolve fetches and parses the metadata a token URI points to
+func (r *MetadataResolver) Resolve(ctx context.Context, uri string) (*NFTMetadata, error) {
+	var data []byte
+	var err error
+
+	switch {
+	case strings.HasPrefix(uri, "data:"):
+		data, err = decodeDataURI(uri)
+	case strings.HasPrefix(uri, "ipfs://"):
+		data, err = r.fetch(ctx, r.gatewayClient, r.GatewayURL(uri))
+	case strings.HasPrefix(uri, "https://"):
+		data, err = r.fetch(ctx, r.httpClient, uri)
+	default:
+		return nil, fmt.Errorf("unsupported token URI scheme: %s", uri)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var metadata NFTMetadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to parse metadata: %w", err)
+	}
+
+	return &metadata, nil
+}
+
+// fetch downloads a metadata document over HTTP, refusing documents larger
+// than maxMetadataSize
+func (r *MetadataResolver) fetch(ctx context.Context, client *http.Client, target string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid metadata URL: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch metadata: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch metadata: %s returned %d", target, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxMetadataSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata: %w", err)
+	}
+	if len(data) > maxMetadataSize {
+		return nil, fmt.Errorf("metadata exceeds %d bytes", maxMetadataSize)
+	}
+	return data, nil
+}
+
+// decodeDataURI returns the payload of a data: URI (base64 or percent-encoded)
+func decodeDataURI(uri string) ([]byte, error) {
+	header, payload, found := strings.Cut(strings.TrimPrefix(uri, "data:"), ",")
+	if !found {
+		return nil, fmt.Errorf("malformed data URI")
+	}
+
+	if strings.HasSuffix(header, ";base64") {
+		data, err := base64.StdEncoding.DecodeString(payload)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64 data URI: %w", err)
+		}
+		return data, nil
+	}
+
+	decoded, err := url.PathUnescape(payload)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data URI: %w", err)
+	}
+	return []byte(decoded), nil
+}
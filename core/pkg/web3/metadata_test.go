@@ -0,0 +1,148 @@
+package web3
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const testMetadataJSON = `{"name":"Token #1","image":"ipfs://image/1","attributes":[{"trait_type":"color","value":"red"}]}`
+
+// newMetadataServer serves testMetadataJSON on /ipfs/<cid>/1.json and
+// /token/1.json and reports the paths it was asked for
+func newMetadataServer(t *testing.T, tls bool) (*httptest.Server, *[]string) {
+	t.Helper()
+
+	var paths []string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		switch r.URL.Path {
+		case "/ipfs/bafycid/1.json", "/token/1.json":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(testMetadataJSON))
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+			w.Write([]byte(testMetadataJSON))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	var server *httptest.Server
+	if tls {
+		server = httptest.NewTLSServer(handler)
+	} else {
+		server = httptest.NewServer(handler)
+	}
+	t.Cleanup(server.Close)
+	return server, &paths
+}
+
+func assertTestMetadata(t *testing.T, metadata *NFTMetadata, err error) {
+	t.Helper()
+
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if metadata.Name != "Token #1" || metadata.Image != "ipfs://image/1" ||
+		len(metadata.Attributes) != 1 || metadata.Attributes[0].TraitType != "color" || metadata.Attributes[0].Value != "red" {
+		t.Fatalf("metadata = %+v", metadata)
+	}
+}
+
+func TestMetadataResolverHTTPS(t *testing.T) {
+	server, _ := newMetadataServer(t, true)
+	resolver := NewMetadataResolver("", time.Second)
+
+	// The test server is on loopback, which token URIs may not reach
+	_, err := resolver.Resolve(context.Background(), server.URL+"/token/1.json")
+	if !errors.Is(err, errPrivateAddress) {
+		t.Fatalf("Resolve of a loopback URI = %v, want errPrivateAddress", err)
+	}
+
+	resolver.httpClient = server.Client()
+	metadata, err := resolver.Resolve(context.Background(), server.URL+"/token/1.json")
+	assertTestMetadata(t, metadata, err)
+
+	if _, err := resolver.Resolve(context.Background(), server.URL+"/missing"); err == nil {
+		t.Fatal("Resolve accepted a 404")
+	}
+	if _, err := resolver.Resolve(context.Background(), "http://example.com/1.json"); err == nil {
+		t.Fatal("Resolve accepted a plain http URI")
+	}
+}
+
+func TestMetadataResolverIPFS(t *testing.T) {
+	server, paths := newMetadataServer(t, false)
+	resolver := NewMetadataResolver(server.URL+"/ipfs", time.Second)
+
+	for _, uri := range []string{"ipfs://bafycid/1.json", "ipfs://ipfs/bafycid/1.json"} {
+		metadata, err := resolver.Resolve(context.Background(), uri)
+		assertTestMetadata(t, metadata, err)
+	}
+	for _, path := range *paths {
+		if path != "/ipfs/bafycid/1.json" {
+			t.Errorf("gateway was asked for %s", path)
+		}
+	}
+}
+
+func TestMetadataResolverDataURI(t *testing.T) {
+	resolver := NewMetadataResolver("", time.Second)
+
+	for name, uri := range map[string]string{
+		"base64":          "data:application/json;base64," + base64.StdEncoding.EncodeToString([]byte(testMetadataJSON)),
+		"percent-encoded": "data:application/json," + url.PathEscape(testMetadataJSON),
+	} {
+		t.Run(name, func(t *testing.T) {
+			metadata, err := resolver.Resolve(context.Background(), uri)
+			assertTestMetadata(t, metadata, err)
+		})
+	}
+
+	if _, err := resolver.Resolve(context.Background(), "data:application/json;base64,!!!"); err == nil {
+		t.Fatal("Resolve accepted invalid base64")
+	}
+}
+
+func TestMetadataResolverTimeout(t *testing.T) {
+	server, _ := newMetadataServer(t, false)
+	resolver := NewMetadataResolver(server.URL, 50*time.Millisecond)
+
+	if _, err := resolver.Resolve(context.Background(), "ipfs://slow"); err == nil {
+		t.Fatal("Resolve waited past the timeout")
+	}
+}
+
+func TestGetNFTWithMetadata(t *testing.T) {
+	uri := "data:application/json;base64," + base64.StdEncoding.EncodeToString([]byte(testMetadataJSON))
+	nft := &mockNFT{
+		owners: map[int64]common.Address{1: testHolder},
+		uris:   map[int64]string{1: uri},
+	}
+	eth := &mockEth{call: func(to common.Address, input []byte) ([]byte, error) { return nft.call(to, input) }}
+	manager := newTestNFTManager(t, eth, testNFTABI)
+
+	token, err := manager.GetNFT(context.Background(), testNFT, big.NewInt(1))
+	if err != nil {
+		t.Fatalf("GetNFT: %v", err)
+	}
+	if token.Metadata != nil {
+		t.Fatal("metadata was fetched without a resolver")
+	}
+
+	manager.SetMetadataResolver(NewMetadataResolver("", time.Second))
+	token, err = manager.GetNFT(context.Background(), testNFT, big.NewInt(1))
+	if err != nil {
+		t.Fatalf("GetNFT: %v", err)
+	}
+	assertTestMetadata(t, token.Metadata, nil)
+}
@@ -0,0 +1,167 @@
+package web3
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Multicall3Address is the address Multicall3 is deployed at on most EVM chains
+var Multicall3Address = common.HexToAddress("0xcA11bde05977b3631167028862bE2a173976CA11")
+
+// multicall3ABI covers the aggregate3 method of Multicall3
+const multicall3ABI = `[{"inputs":[{"components":[{"internalType":"address","name":"target","type":"address"},{"internalType":"bool","name":"allowFailure","type":"bool"},{"internalType":"bytes","name":"callData","type":"bytes"}],"internalType":"struct Multicall3.Call3[]","name":"calls","type":"tuple[]"}],"name":"aggregate3","outputs":[{"components":[{"internalType":"bool","name":"success","type":"bool"},{"internalType":"bytes","name":"returnData","type":"bytes"}],"internalType":"struct Multicall3.Result[]","name":"returnData","type":"tuple[]"}],"stateMutability":"payable","type":"function"}]`
+
+var parsedMulticall3ABI = mustParseABI(multicall3ABI)
+
+// Call is a single contract read in a batch. The target contract must be
+// loaded with LoadContract.
+type Call struct {
+	Target common.Address
+	Method string
+	Args   []interface{}
+}
+
+// CallResult is the decoded outcome of one Call. A failed call sets Err
+// without failing the rest of the batch.
+type CallResult struct {
+	Values []interface{}
+	Err    error
+}
+
+// multicall3Call mirrors the Multicall3.Call3 tuple
+type multicall3Call struct {
+	Target       common.Address
+	AllowFailure bool
+	CallData     []byte
+}
+
+// multicall3Result mirrors the Multicall3.Result tuple
+type multicall3Result struct {
+	Success    bool
+	ReturnData []byte
+}
+
+// BatchCall runs several read calls and returns their results in order.
+// When the network has a Multicall address configured the calls are
+// aggregated into a single eth_call; otherwise, or if no contract is deployed
+// at that address, they run sequentially.
+func (m *ContractManager) BatchCall(ctx context.Context, calls []Call) ([]CallResult, error) {
+	results := make([]CallResult, len(calls))
+	if len(calls) == 0 {
+		return results, nil
+	}
+
+	contracts := make([]*Contract, len(calls))
+	payloads := make([][]byte, len(calls))
+	for i, call := range calls {
+		contract, err := m.GetContract(call.Target)
+		if err != nil {
+			return nil, err
+		}
+		data, err := contract.ABI.Pack(call.Method, call.Args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to pack %s: %w", call.Method, err)
+		}
+		contracts[i] = contract
+		payloads[i] = data
+	}
+
+	m.mu.RLock()
+	noMulticall := m.noMulticall
+	m.mu.RUnlock()
+
+	multicall := m.client.config.Multicall
+	if multicall == (common.Address{}) || noMulticall {
+		return m.callEach(ctx, calls, contracts, payloads), nil
+	}
+
+	aggregate := make([]multicall3Call, len(calls))
+	for i, call := range calls {
+		aggregate[i] = multicall3Call{
+			Target:       call.Target,
+			AllowFailure: true,
+			CallData:     payloads[i],
+		}
+	}
+
+	data, err := parsedMulticall3ABI.Pack("aggregate3", aggregate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack multicall: %w", err)
+	}
+
+	output, err := m.client.client.CallContract(ctx, ethereum.CallMsg{
+		To:   &multicall,
+		Data: data,
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call multicall: %w", err)
+	}
+
+	// Calling an address without code succeeds with no output; Multicall3
+	// always returns at least an empty array
+	if len(output) == 0 {
+		m.mu.Lock()
+		m.noMulticall = true
+		m.mu.Unlock()
+		return m.callEach(ctx, calls, contracts, payloads), nil
+	}
+
+	unpacked, err := parsedMulticall3ABI.Unpack("aggregate3", output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack multicall result: %w", err)
+	}
+
+	aggregated := *abi.ConvertType(unpacked[0], new([]multicall3Result)).(*[]multicall3Result)
+	if len(aggregated) != len(calls) {
+		return nil, fmt.Errorf("multicall returned %d results for %d calls", len(aggregated), len(calls))
+	}
+
+	for i, call := range calls {
+		if !aggregated[i].Success {
+			results[i].Err = fmt.Errorf("call %s reverted", call.Method)
+			continue
+		}
+		results[i] = unpackCallResult(contracts[i], call.Method, aggregated[i].ReturnData)
+	}
+
+	return results, nil
+}
+
+// callEach runs the calls of a batch one eth_call at a time
+func (m *ContractManager) callEach(ctx context.Context, calls []Call, contracts []*Contract, payloads [][]byte) []CallResult {
+	results := make([]CallResult, len(calls))
+	for i, call := range calls {
+		output, err := m.client.client.CallContract(ctx, ethereum.CallMsg{
+			To:   &calls[i].Target,
+			Data: payloads[i],
+		}, nil)
+		if err != nil {
+			results[i].Err = fmt.Errorf("failed to call %s: %w", call.Method, err)
+			continue
+		}
+		results[i] = unpackCallResult(contracts[i], call.Method, output)
+	}
+	return results
+}
+
+// unpackCallResult decodes the return data of a single call
+func unpackCallResult(contract *Contract, method string, output []byte) CallResult {
+	values, err := contract.ABI.Unpack(method, output)
+	if err != nil {
+		return CallResult{Err: fmt.Errorf("failed to unpack %s result: %w", method, err)}
+	}
+	return CallResult{Values: values}
+}
+
+func mustParseABI(abiJSON string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(abiJSON))
+	if err != nil {
+		panic(fmt.Sprintf("web3: invalid built-in ABI: %v", err))
+	}
+	return parsed
+}
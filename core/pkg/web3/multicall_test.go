@@ -0,0 +1,162 @@
+package web3
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const testTokenABI = `[
+	{"inputs":[],"name":"symbol","outputs":[{"name":"","type":"string"}],"stateMutability":"view","type":"function"},
+	{"inputs":[{"name":"account","type":"address"}],"name":"balanceOf","outputs":[{"name":"","type":"uint256"}],"stateMutability":"view","type":"function"},
+	{"inputs":[],"name":"paused","outputs":[{"name":"","type":"bool"}],"stateMutability":"view","type":"function"}
+]`
+
+var (
+	testToken  = common.HexToAddress("0x1000000000000000000000000000000000000001")
+	testHolder = common.HexToAddress("0x2000000000000000000000000000000000000002")
+)
+
+var errReverted = errors.New("execution reverted")
+
+// tokenCall answers a single call to the test token: symbol and balanceOf
+// succeed, paused reverts
+func tokenCall(input []byte) ([]byte, error) {
+	parsed := mustParseABI(testTokenABI)
+	method, err := parsed.MethodById(input)
+	if err != nil {
+		return nil, err
+	}
+
+	switch method.Name {
+	case "symbol":
+		return method.Outputs.Pack("TKN")
+	case "balanceOf":
+		return method.Outputs.Pack(big.NewInt(42))
+	default:
+		return nil, errReverted
+	}
+}
+
+// multicallBackend answers eth_call like a node with Multicall3 deployed at
+// Multicall3Address and the test token
+func multicallBackend() *mockEth {
+	return &mockEth{call: func(to common.Address, input []byte) ([]byte, error) {
+		if to != Multicall3Address {
+			return tokenCall(input)
+		}
+
+		method := parsedMulticall3ABI.Methods["aggregate3"]
+		unpacked, err := method.Inputs.Unpack(input[4:])
+		if err != nil {
+			return nil, err
+		}
+		calls := *abi.ConvertType(unpacked[0], new([]multicall3Call)).(*[]multicall3Call)
+
+		results := make([]multicall3Result, len(calls))
+		for i, call := range calls {
+			output, err := tokenCall(call.CallData)
+			results[i] = multicall3Result{Success: err == nil, ReturnData: output}
+		}
+		return method.Outputs.Pack(results)
+	}}
+}
+
+func newTestContractManager(t *testing.T, eth *mockEth, multicall common.Address) *ContractManager {
+	t.Helper()
+
+	manager := NewContractManager(newMockClient(t, eth, &NetworkConfig{Multicall: multicall}))
+	if _, err := manager.LoadContract(testToken, testTokenABI); err != nil {
+		t.Fatalf("LoadContract: %v", err)
+	}
+	return manager
+}
+
+var testBatch = []Call{
+	{Target: testToken, Method: "symbol"},
+	{Target: testToken, Method: "balanceOf", Args: []interface{}{testHolder}},
+	{Target: testToken, Method: "paused"},
+}
+
+// checkBatchResults asserts the results of testBatch
+func checkBatchResults(t *testing.T, results []CallResult) {
+	t.Helper()
+
+	if len(results) != len(testBatch) {
+		t.Fatalf("got %d results, want %d", len(results), len(testBatch))
+	}
+	if results[0].Err != nil || results[0].Values[0] != "TKN" {
+		t.Errorf("symbol result = %v, %v; want TKN", results[0].Values, results[0].Err)
+	}
+	if results[1].Err != nil || results[1].Values[0].(*big.Int).Cmp(big.NewInt(42)) != 0 {
+		t.Errorf("balanceOf result = %v, %v; want 42", results[1].Values, results[1].Err)
+	}
+	if results[2].Err == nil {
+		t.Errorf("paused result = %v, want an error", results[2].Values)
+	}
+}
+
+func TestBatchCallUsesOneRoundTrip(t *testing.T) {
+	eth := multicallBackend()
+	manager := newTestContractManager(t, eth, Multicall3Address)
+
+	results, err := manager.BatchCall(context.Background(), testBatch)
+	if err != nil {
+		t.Fatalf("BatchCall: %v", err)
+	}
+
+	checkBatchResults(t, results)
+	if n := eth.count("eth_call"); n != 1 {
+		t.Fatalf("BatchCall made %d eth_call requests, want 1", n)
+	}
+}
+
+func TestBatchCallWithoutMulticallCallsEach(t *testing.T) {
+	eth := multicallBackend()
+	manager := newTestContractManager(t, eth, common.Address{})
+
+	results, err := manager.BatchCall(context.Background(), testBatch)
+	if err != nil {
+		t.Fatalf("BatchCall: %v", err)
+	}
+
+	checkBatchResults(t, results)
+	if n := eth.count("eth_call"); n != len(testBatch) {
+		t.Fatalf("BatchCall made %d eth_call requests, want %d", n, len(testBatch))
+	}
+}
+
+func TestBatchCallFallsBackWhenMulticallIsNotDeployed(t *testing.T) {
+	missing := common.HexToAddress("0x3000000000000000000000000000000000000003")
+	eth := &mockEth{call: func(to common.Address, input []byte) ([]byte, error) {
+		if to == missing {
+			// Calls to an address without code succeed with no output
+			return nil, nil
+		}
+		return tokenCall(input)
+	}}
+	manager := newTestContractManager(t, eth, missing)
+
+	results, err := manager.BatchCall(context.Background(), testBatch)
+	if err != nil {
+		t.Fatalf("BatchCall: %v", err)
+	}
+	checkBatchResults(t, results)
+	if n := eth.count("eth_call"); n != 1+len(testBatch) {
+		t.Fatalf("BatchCall made %d eth_call requests, want %d", n, 1+len(testBatch))
+	}
+
+	// The missing contract is remembered
+	results, err = manager.BatchCall(context.Background(), testBatch)
+	if err != nil {
+		t.Fatalf("BatchCall: %v", err)
+	}
+	checkBatchResults(t, results)
+	if n := eth.count("eth_call"); n != 1+2*len(testBatch) {
+		t.Fatalf("second BatchCall made %d eth_call requests, want %d", n-1-len(testBatch), len(testBatch))
+	}
+}
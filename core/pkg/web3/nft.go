@@ -6,16 +6,36 @@ import (
 	"math/big"
 	"sync"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 )
 
 // NFTManager manages NFT operations
 type NFTManager struct {
 	client          *Web3Client
 	contractManager *ContractManager
+	enumeration     NFTEnumeration
+	metadata        *MetadataResolver         // nil disables metadata fetching
+	startBlocks     map[common.Address]uint64 // first block scanned for Transfer logs, per contract
 	mu              sync.RWMutex
 }
 
+// NFTEnumeration selects how GetNFTsByOwner discovers an owner's tokens
+type NFTEnumeration string
+
+const (
+	// NFTEnumerationAuto uses tokenOfOwnerByIndex when the loaded ABI has it, otherwise Transfer logs
+	NFTEnumerationAuto NFTEnumeration = "auto"
+	// NFTEnumerationIndex calls ERC721Enumerable.tokenOfOwnerByIndex
+	NFTEnumerationIndex NFTEnumeration = "index"
+	// NFTEnumerationTransferLogs scans Transfer events to the owner and checks current ownership
+	NFTEnumerationTransferLogs NFTEnumeration = "transfer_logs"
+)
+
+// erc721TransferTopic is keccak256("Transfer(address,address,uint256)")
+var erc721TransferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
 // NFTMetadata NFT metadata structure
 type NFTMetadata struct {
 	Name        string                 `json:"name"`
@@ -38,8 +58,8 @@ type NFT struct {
 	TokenID      *big.Int
 	ContractAddr common.Address
 	Owner        common.Address
-	TokenURI     string
-	Metadata     *NFTMetadata
+	TokenURI     string       // Empty if the contract doesn't report one
+	Metadata     *NFTMetadata // Nil if metadata is disabled or couldn't be fetched
 }
 
 // NewNFTManager creates a new NFT manager
@@ -47,10 +67,38 @@ func NewNFTManager(client *Web3Client, contractManager *ContractManager) *NFTMan
 	return &NFTManager{
 		client:          client,
 		contractManager: contractManager,
+		enumeration:     NFTEnumerationAuto,
+		startBlocks:     make(map[common.Address]uint64),
 	}
 }
 
-// GetNFT gets NFT details
+// SetEnumerationStrategy sets how GetNFTsByOwner discovers tokens
+func (m *NFTManager) SetEnumerationStrategy(strategy NFTEnumeration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.enumeration = strategy
+}
+
+// SetTransferLogStartBlock sets the block the Transfer log enumeration of a
+// contract starts scanning from, typically the block it was deployed in.
+// Without it the scan starts from the genesis block.
+func (m *NFTManager) SetTransferLogStartBlock(contractAddress common.Address, block uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.startBlocks[contractAddress] = block
+}
+
+// SetMetadataResolver enables fetching NFT.Metadata from token URIs in GetNFT
+// and GetNFTsByOwner. Pass nil to disable it again.
+func (m *NFTManager) SetMetadataResolver(resolver *MetadataResolver) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.metadata = resolver
+}
+
+// GetNFT gets NFT details. A token whose URI or metadata can't be read is
+// still returned, without them: both are set by the contract and may be
+// unavailable.
 func (m *NFTManager) GetNFT(ctx context.Context, contractAddress common.Address, tokenID *big.Int) (*NFT, error) {
 	// Get owner
 	owner, err := m.contractManager.ERC721OwnerOf(ctx, contractAddress, tokenID)
@@ -59,13 +107,9 @@ func (m *NFTManager) GetNFT(ctx context.Context, contractAddress common.Address,
 	}
 
 	// Get token URI
-	results, err := m.contractManager.CallMethod(ctx, contractAddress, "tokenURI", tokenID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get tokenURI: %w", err)
-	}
-
 	tokenURI := ""
-	if len(results) > 0 {
+	results, err := m.contractManager.CallMethod(ctx, contractAddress, "tokenURI", tokenID)
+	if err == nil && len(results) > 0 {
 		if uri, ok := results[0].(string); ok {
 			tokenURI = uri
 		}
@@ -78,24 +122,139 @@ func (m *NFTManager) GetNFT(ctx context.Context, contractAddress common.Address,
 		TokenURI:     tokenURI,
 	}
 
+	m.mu.RLock()
+	resolver := m.metadata
+	m.mu.RUnlock()
+
+	if resolver != nil && tokenURI != "" {
+		if metadata, err := resolver.Resolve(ctx, tokenURI); err == nil {
+			nft.Metadata = metadata
+		}
+	}
+
 	return nft, nil
 }
 
-// GetNFTsByOwner gets all NFTs owned by an address
+// GetNFTsByOwner gets all NFTs owned by an address. The contract must be
+// loaded with an ABI covering the calls used by the enumeration strategy.
 func (m *NFTManager) GetNFTsByOwner(ctx context.Context, contractAddress, owner common.Address) ([]*NFT, error) {
-	// Get balance
-	balance, err := m.contractManager.ERC721BalanceOf(ctx, contractAddress, owner)
+	m.mu.RLock()
+	strategy := m.enumeration
+	m.mu.RUnlock()
+
+	if strategy == "" || strategy == NFTEnumerationAuto {
+		strategy = NFTEnumerationTransferLogs
+		if contract, err := m.contractManager.GetContract(contractAddress); err == nil {
+			if _, ok := contract.ABI.Methods["tokenOfOwnerByIndex"]; ok {
+				strategy = NFTEnumerationIndex
+			}
+		}
+	}
+
+	var tokenIDs []*big.Int
+	var err error
+	switch strategy {
+	case NFTEnumerationIndex:
+		tokenIDs, err = m.tokensByIndex(ctx, contractAddress, owner)
+	case NFTEnumerationTransferLogs:
+		tokenIDs, err = m.tokensByTransferLogs(ctx, contractAddress, owner)
+	default:
+		return nil, fmt.Errorf("unknown NFT enumeration strategy: %s", strategy)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	nfts := make([]*NFT, 0, balance.Int64())
+	nfts := make([]*NFT, 0, len(tokenIDs))
+	for _, tokenID := range tokenIDs {
+		nft, err := m.GetNFT(ctx, contractAddress, tokenID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get token %s: %w", tokenID, err)
+		}
+		nfts = append(nfts, nft)
+	}
 
-	// This would iterate through tokens in real implementation
-	// For now, return empty list
 	return nfts, nil
 }
 
+// tokensByIndex enumerates token IDs with tokenOfOwnerByIndex(owner, i) for i in [0, balance)
+func (m *NFTManager) tokensByIndex(ctx context.Context, contractAddress, owner common.Address) ([]*big.Int, error) {
+	balance, err := m.contractManager.ERC721BalanceOf(ctx, contractAddress, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenIDs := make([]*big.Int, 0, balance.Int64())
+	for i := int64(0); i < balance.Int64(); i++ {
+		results, err := m.contractManager.CallMethod(ctx, contractAddress, "tokenOfOwnerByIndex", owner, big.NewInt(i))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get token at index %d: %w", i, err)
+		}
+		if len(results) == 0 {
+			return nil, fmt.Errorf("empty tokenOfOwnerByIndex result at index %d", i)
+		}
+		tokenID, ok := results[0].(*big.Int)
+		if !ok {
+			return nil, fmt.Errorf("unexpected tokenOfOwnerByIndex result type %T", results[0])
+		}
+		tokenIDs = append(tokenIDs, tokenID)
+	}
+
+	return tokenIDs, nil
+}
+
+// tokensByTransferLogs collects token IDs ever transferred to owner and keeps
+// those the owner still holds
+func (m *NFTManager) tokensByTransferLogs(ctx context.Context, contractAddress, owner common.Address) ([]*big.Int, error) {
+	latest, err := m.client.GetBlockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	start := m.startBlocks[contractAddress]
+	m.mu.RUnlock()
+	if start > latest {
+		return []*big.Int{}, nil
+	}
+
+	logs, err := m.contractManager.filterLogs(ctx, ethereum.FilterQuery{
+		Addresses: []common.Address{contractAddress},
+		Topics: [][]common.Hash{
+			{erc721TransferTopic},
+			nil,
+			{common.BytesToHash(owner.Bytes())},
+		},
+	}, start, latest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter transfer logs: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	tokenIDs := make([]*big.Int, 0)
+	for _, log := range logs {
+		// ERC721 indexes the token ID; ERC20 Transfer logs have only 3 topics
+		if len(log.Topics) != 4 {
+			continue
+		}
+		tokenID := log.Topics[3].Big()
+		if seen[tokenID.String()] {
+			continue
+		}
+		seen[tokenID.String()] = true
+
+		currentOwner, err := m.contractManager.ERC721OwnerOf(ctx, contractAddress, tokenID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get owner of token %s: %w", tokenID, err)
+		}
+		if currentOwner == owner {
+			tokenIDs = append(tokenIDs, tokenID)
+		}
+	}
+
+	return tokenIDs, nil
+}
+
 // MintNFT mints a new NFT
 func (m *NFTManager) MintNFT(ctx context.Context, wallet *Wallet, contractAddress, to common.Address, tokenID *big.Int, tokenURI string) (*Transaction, error) {
 	return m.contractManager.SendMethod(ctx, wallet, contractAddress, "mint", big.NewInt(0), to, tokenID, tokenURI)
@@ -140,43 +299,48 @@ func NewTokenManager(client *Web3Client, contractManager *ContractManager) *Toke
 	}
 }
 
-// GetTokenInfo gets token information
+// GetTokenInfo gets token information. The four reads are batched into a
+// single request when the network supports multicall; fields whose call
+// fails are left empty.
 func (m *TokenManager) GetTokenInfo(ctx context.Context, tokenAddress common.Address) (*Token, error) {
 	token := &Token{
 		Address: tokenAddress,
 	}
 
-	// Get name
-	if results, err := m.contractManager.CallMethod(ctx, tokenAddress, "name"); err == nil && len(results) > 0 {
-		if name, ok := results[0].(string); ok {
-			token.Name = name
-		}
+	results, err := m.contractManager.BatchCall(ctx, []Call{
+		{Target: tokenAddress, Method: "name"},
+		{Target: tokenAddress, Method: "symbol"},
+		{Target: tokenAddress, Method: "decimals"},
+		{Target: tokenAddress, Method: "totalSupply"},
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Get symbol
-	if results, err := m.contractManager.CallMethod(ctx, tokenAddress, "symbol"); err == nil && len(results) > 0 {
-		if symbol, ok := results[0].(string); ok {
-			token.Symbol = symbol
-		}
+	if name, ok := firstValue(results[0]).(string); ok {
+		token.Name = name
 	}
-
-	// Get decimals
-	if results, err := m.contractManager.CallMethod(ctx, tokenAddress, "decimals"); err == nil && len(results) > 0 {
-		if decimals, ok := results[0].(uint8); ok {
-			token.Decimals = decimals
-		}
+	if symbol, ok := firstValue(results[1]).(string); ok {
+		token.Symbol = symbol
 	}
-
-	// Get total supply
-	if results, err := m.contractManager.CallMethod(ctx, tokenAddress, "totalSupply"); err == nil && len(results) > 0 {
-		if supply, ok := results[0].(*big.Int); ok {
-			token.TotalSupply = supply
-		}
+	if decimals, ok := firstValue(results[2]).(uint8); ok {
+		token.Decimals = decimals
+	}
+	if supply, ok := firstValue(results[3]).(*big.Int); ok {
+		token.TotalSupply = supply
 	}
 
 	return token, nil
 }
 
+// firstValue returns the first decoded value of a successful call
+func firstValue(result CallResult) interface{} {
+	if result.Err != nil || len(result.Values) == 0 {
+		return nil
+	}
+	return result.Values[0]
+}
+
 // GetBalance gets token balance
 func (m *TokenManager) GetBalance(ctx context.Context, tokenAddress, account common.Address) (*big.Int, error) {
 	return m.contractManager.ERC20BalanceOf(ctx, tokenAddress, account)
@@ -0,0 +1,194 @@
+package web3
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const testNFTABI = `[
+	{"inputs":[{"name":"owner","type":"address"}],"name":"balanceOf","outputs":[{"name":"","type":"uint256"}],"stateMutability":"view","type":"function"},
+	{"inputs":[{"name":"tokenId","type":"uint256"}],"name":"ownerOf","outputs":[{"name":"","type":"address"}],"stateMutability":"view","type":"function"},
+	{"inputs":[{"name":"tokenId","type":"uint256"}],"name":"tokenURI","outputs":[{"name":"","type":"string"}],"stateMutability":"view","type":"function"},
+	{"anonymous":false,"inputs":[{"indexed":true,"name":"from","type":"address"},{"indexed":true,"name":"to","type":"address"},{"indexed":true,"name":"tokenId","type":"uint256"}],"name":"Transfer","type":"event"}
+]`
+
+// testEnumerableNFTABI adds ERC721Enumerable.tokenOfOwnerByIndex to testNFTABI
+var testEnumerableNFTABI = `[
+	{"inputs":[{"name":"owner","type":"address"},{"name":"index","type":"uint256"}],"name":"tokenOfOwnerByIndex","outputs":[{"name":"","type":"uint256"}],"stateMutability":"view","type":"function"},` + testNFTABI[1:]
+
+var (
+	testNFT      = common.HexToAddress("0x4000000000000000000000000000000000000004")
+	testNFTOther = common.HexToAddress("0x5000000000000000000000000000000000000005")
+)
+
+// mockNFT answers calls to an ERC721 contract holding owners by token ID
+type mockNFT struct {
+	owners map[int64]common.Address
+	uris   map[int64]string
+}
+
+// ownedBy returns the tokens of owner in ascending order
+func (n *mockNFT) ownedBy(owner common.Address) []int64 {
+	var tokens []int64
+	for id := int64(0); id < 100; id++ {
+		if n.owners[id] == owner {
+			tokens = append(tokens, id)
+		}
+	}
+	return tokens
+}
+
+func (n *mockNFT) call(to common.Address, input []byte) ([]byte, error) {
+	parsed := mustParseABI(testEnumerableNFTABI)
+	method, err := parsed.MethodById(input)
+	if err != nil {
+		return nil, err
+	}
+	args, err := method.Inputs.Unpack(input[4:])
+	if err != nil {
+		return nil, err
+	}
+
+	switch method.Name {
+	case "balanceOf":
+		return method.Outputs.Pack(big.NewInt(int64(len(n.ownedBy(args[0].(common.Address))))))
+	case "tokenOfOwnerByIndex":
+		tokens := n.ownedBy(args[0].(common.Address))
+		index := args[1].(*big.Int).Int64()
+		if index >= int64(len(tokens)) {
+			return nil, errReverted
+		}
+		return method.Outputs.Pack(big.NewInt(tokens[index]))
+	case "ownerOf":
+		return method.Outputs.Pack(n.owners[args[0].(*big.Int).Int64()])
+	case "tokenURI":
+		return method.Outputs.Pack(n.uris[args[0].(*big.Int).Int64()])
+	}
+	return nil, fmt.Errorf("unexpected call to %s", method.Name)
+}
+
+func newTestNFTManager(t *testing.T, eth *mockEth, abiJSON string) *NFTManager {
+	t.Helper()
+
+	client := newMockClient(t, eth, &NetworkConfig{ChainID: big.NewInt(1)})
+	contracts := NewContractManager(client)
+	if _, err := contracts.LoadContract(testNFT, abiJSON); err != nil {
+		t.Fatalf("LoadContract: %v", err)
+	}
+	return NewNFTManager(client, contracts)
+}
+
+// testTransferLog is a Transfer of tokenID to an address
+func testTransferLog(to common.Address, tokenID int64, block uint64) types.Log {
+	return types.Log{
+		Address: testNFT,
+		Topics: []common.Hash{
+			erc721TransferTopic,
+			common.BytesToHash(testNFTOther.Bytes()),
+			common.BytesToHash(to.Bytes()),
+			common.BigToHash(big.NewInt(tokenID)),
+		},
+		BlockNumber: block,
+	}
+}
+
+func tokenIDs(nfts []*NFT) []int64 {
+	ids := make([]int64, len(nfts))
+	for i, nft := range nfts {
+		ids[i] = nft.TokenID.Int64()
+	}
+	return ids
+}
+
+func TestGetNFTsByOwnerEnumerable(t *testing.T) {
+	nft := &mockNFT{
+		owners: map[int64]common.Address{3: testHolder, 7: testNFTOther, 9: testHolder},
+		uris:   map[int64]string{3: "ipfs://token/3", 9: "ipfs://token/9"},
+	}
+	eth := &mockEth{call: func(to common.Address, input []byte) ([]byte, error) { return nft.call(to, input) }}
+	manager := newTestNFTManager(t, eth, testEnumerableNFTABI)
+
+	nfts, err := manager.GetNFTsByOwner(context.Background(), testNFT, testHolder)
+	if err != nil {
+		t.Fatalf("GetNFTsByOwner: %v", err)
+	}
+	if got := fmt.Sprint(tokenIDs(nfts)); got != "[3 9]" {
+		t.Fatalf("tokens = %s, want [3 9]", got)
+	}
+	for _, n := range nfts {
+		if n.Owner != testHolder || n.ContractAddr != testNFT || n.TokenURI != fmt.Sprintf("ipfs://token/%d", n.TokenID) {
+			t.Errorf("token %s = %+v", n.TokenID, n)
+		}
+		if n.Metadata != nil {
+			t.Errorf("token %s has metadata without a resolver", n.TokenID)
+		}
+	}
+	if eth.count("eth_getLogs") != 0 {
+		t.Fatal("an enumerable contract was scanned for logs")
+	}
+}
+
+func TestGetNFTsByOwnerTransferLogs(t *testing.T) {
+	nft := &mockNFT{owners: map[int64]common.Address{3: testHolder, 7: testNFTOther, 9: testHolder}}
+	var query filterArgs
+	eth := &mockEth{
+		call:        func(to common.Address, input []byte) ([]byte, error) { return nft.call(to, input) },
+		blockNumber: func() uint64 { return 500 },
+		logs: func(q filterArgs) []types.Log {
+			query = q
+			// Token 7 was transferred away again; token 3 arrived twice
+			return []types.Log{
+				testTransferLog(testHolder, 3, 110),
+				testTransferLog(testHolder, 7, 120),
+				testTransferLog(testHolder, 3, 130),
+				testTransferLog(testHolder, 9, 140),
+			}
+		},
+	}
+	manager := newTestNFTManager(t, eth, testNFTABI)
+	manager.SetTransferLogStartBlock(testNFT, 100)
+
+	nfts, err := manager.GetNFTsByOwner(context.Background(), testNFT, testHolder)
+	if err != nil {
+		t.Fatalf("GetNFTsByOwner: %v", err)
+	}
+	if got := fmt.Sprint(tokenIDs(nfts)); got != "[3 9]" {
+		t.Fatalf("tokens = %s, want [3 9]", got)
+	}
+
+	if query.FromBlock.ToInt().Int64() != 100 || query.ToBlock.ToInt().Int64() != 500 {
+		t.Errorf("scanned blocks %s-%s, want 100-500", query.FromBlock.ToInt(), query.ToBlock.ToInt())
+	}
+	if len(query.Topics) != 3 || query.Topics[0][0] != erc721TransferTopic || query.Topics[2][0] != common.BytesToHash(testHolder.Bytes()) {
+		t.Errorf("topics = %v, want Transfer events to the owner", query.Topics)
+	}
+}
+
+func TestGetNFTsByOwnerForcedStrategy(t *testing.T) {
+	nft := &mockNFT{owners: map[int64]common.Address{3: testHolder}}
+	eth := &mockEth{
+		call:        func(to common.Address, input []byte) ([]byte, error) { return nft.call(to, input) },
+		blockNumber: func() uint64 { return 10 },
+		logs:        func(filterArgs) []types.Log { return []types.Log{testTransferLog(testHolder, 3, 5)} },
+	}
+	manager := newTestNFTManager(t, eth, testEnumerableNFTABI)
+	manager.SetEnumerationStrategy(NFTEnumerationTransferLogs)
+
+	nfts, err := manager.GetNFTsByOwner(context.Background(), testNFT, testHolder)
+	if err != nil {
+		t.Fatalf("GetNFTsByOwner: %v", err)
+	}
+	if len(nfts) != 1 || eth.count("eth_getLogs") != 1 {
+		t.Fatalf("got %d tokens with %d log queries, want the transfer log strategy", len(nfts), eth.count("eth_getLogs"))
+	}
+
+	manager.SetEnumerationStrategy("bogus")
+	if _, err := manager.GetNFTsByOwner(context.Background(), testNFT, testHolder); err == nil {
+		t.Fatal("an unknown strategy was accepted")
+	}
+}
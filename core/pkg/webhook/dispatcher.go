@@ -0,0 +1,401 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"neonexcore/pkg/events"
+)
+
+// Request headers of a delivery
+const (
+	HeaderSignature = "X-Webhook-Signature" // "sha256=" + hex HMAC-SHA256 of "<timestamp>.<body>"
+	HeaderTimestamp = "X-Webhook-Timestamp" // Unix seconds the attempt was signed at
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderID        = "X-Webhook-ID" // Same for every attempt, for deduplication
+)
+
+// DefaultTolerance is how far a delivery's timestamp may be from the
+// receiver's clock when verifying it
+const DefaultTolerance = 5 * time.Minute
+
+// Config configures webhook delivery
+type Config struct {
+	Timeout        time.Duration // Timeout of a single attempt
+	MaxAttempts    int           // Total attempts including the first; 1 disables retries
+	InitialBackoff time.Duration // Delay before the first retry
+	MaxBackoff     time.Duration // Upper bound for the doubling delay
+	Retention      time.Duration // How long delivery attempts are kept; 0 keeps them
+}
+
+// DefaultConfig returns the default webhook delivery config
+func DefaultConfig() *Config {
+	return &Config{
+		Timeout:        10 * time.Second,
+		MaxAttempts:    5,
+		InitialBackoff: 1 * time.Second,
+		MaxBackoff:     5 * time.Minute,
+		Retention:      7 * 24 * time.Hour,
+	}
+}
+
+// Payload is the JSON body of a delivery
+type Payload struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// Dispatcher delivers events to subscribed webhooks in the background,
+// retrying failed attempts with backoff and recording every attempt.
+// Recorded attempts older than the configured retention are purged hourly.
+type Dispatcher struct {
+	store  Store
+	config *Config
+	client *http.Client
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	// mu makes starting a delivery and closing atomic, so no delivery is
+	// added to wg once Close is waiting on it
+	mu     sync.Mutex
+	closed bool
+}
+
+// NewDispatcher creates a new webhook dispatcher
+func NewDispatcher(store Store, config *Config) *Dispatcher {
+	if config == nil {
+		config = DefaultConfig()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &Dispatcher{
+		store:  store,
+		config: config,
+		client: &http.Client{Timeout: config.Timeout},
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	if config.Retention > 0 {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			d.purgeLoop()
+		}()
+	}
+
+	return d
+}
+
+// Subscribe creates a subscription. A random secret is generated when the
+// subscription has none; share it with the receiver to verify signatures.
+func (d *Dispatcher) Subscribe(ctx context.Context, sub *Subscription) error {
+	parsed, err := url.Parse(sub.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("invalid webhook URL %q", sub.URL)
+	}
+	if len(sub.Events) == 0 {
+		return fmt.Errorf("webhook subscription needs at least one event type")
+	}
+
+	if sub.Secret == "" {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return err
+		}
+		sub.Secret = hex.EncodeToString(secret)
+	}
+	sub.Active = true
+
+	return d.store.SaveSubscription(ctx, sub)
+}
+
+// Unsubscribe deletes a subscription
+func (d *Dispatcher) Unsubscribe(ctx context.Context, id uint) error {
+	return d.store.DeleteSubscription(ctx, id)
+}
+
+// Subscriptions returns all subscriptions
+func (d *Dispatcher) Subscriptions(ctx context.Context) ([]Subscription, error) {
+	return d.store.ListSubscriptions(ctx)
+}
+
+// Deliveries returns a subscription's most recent delivery attempts
+func (d *Dispatcher) Deliveries(ctx context.Context, subscriptionID uint, limit int) ([]Delivery, error) {
+	return d.store.ListDeliveries(ctx, subscriptionID, limit)
+}
+
+// Publish delivers an event to every active subscription matching its type
+// and returns how many deliveries were started. Deliveries run in the
+// background.
+func (d *Dispatcher) Publish(ctx context.Context, eventType string, data interface{}) (int, error) {
+	if d.isClosed() {
+		return 0, errDispatcherClosed
+	}
+
+	subs, err := d.store.ListSubscriptions(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	started := 0
+	for i := range subs {
+		sub := subs[i]
+		if !sub.Active || !sub.Matches(eventType) {
+			continue
+		}
+
+		payload := Payload{
+			ID:        newEventID(),
+			Type:      eventType,
+			CreatedAt: time.Now().UTC(),
+			Data:      data,
+		}
+		body, err := json.Marshal(payload)
+		if err != nil {
+			return started, err
+		}
+
+		if !d.startDelivery() {
+			return started, errDispatcherClosed
+		}
+		go func() {
+			defer d.wg.Done()
+			d.deliver(&sub, payload, body)
+		}()
+		started++
+	}
+
+	return started, nil
+}
+
+// ForwardEvents publishes the named domain events from the global event
+// dispatcher to webhooks
+func (d *Dispatcher) ForwardEvents(names ...string) {
+	for _, name := range names {
+		events.Register(name, func(ctx context.Context, event events.Event) error {
+			_, err := d.Publish(ctx, event.Name, event.Data)
+			return err
+		})
+	}
+}
+
+// Close stops scheduling retries and purges, and waits for in-flight
+// attempts to finish
+func (d *Dispatcher) Close() error {
+	d.mu.Lock()
+	d.closed = true
+	d.cancel()
+	d.mu.Unlock()
+
+	d.wg.Wait()
+	return nil
+}
+
+// errDispatcherClosed is returned by Publish after Close
+var errDispatcherClosed = fmt.Errorf("webhook dispatcher is closed")
+
+// isClosed reports whether Close has been called
+func (d *Dispatcher) isClosed() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.closed
+}
+
+// startDelivery adds a delivery to wg unless the dispatcher is closed
+func (d *Dispatcher) startDelivery() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return false
+	}
+	d.wg.Add(1)
+	return true
+}
+
+// PurgeDeliveries deletes recorded attempts older than the retention
+func (d *Dispatcher) PurgeDeliveries(ctx context.Context) error {
+	if d.config.Retention <= 0 {
+		return nil
+	}
+	_, err := d.store.DeleteDeliveriesBefore(ctx, time.Now().Add(-d.config.Retention))
+	return err
+}
+
+// purgeLoop purges old delivery attempts now and then hourly until the
+// dispatcher is closed
+func (d *Dispatcher) purgeLoop() {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		if err := d.PurgeDeliveries(d.ctx); err != nil && d.ctx.Err() == nil {
+			log.Printf("Failed to purge webhook deliveries: %v", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-d.ctx.Done():
+			return
+		}
+	}
+}
+
+// deliver sends a payload to a subscription until an attempt succeeds, the
+// failure is permanent or the attempts run out
+func (d *Dispatcher) deliver(sub *Subscription, payload Payload, body []byte) {
+	attempts := d.config.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-time.After(d.backoff(attempt - 1)):
+			case <-d.ctx.Done():
+				return
+			}
+		}
+
+		retry := d.attempt(sub, payload, body, attempt)
+		if !retry {
+			return
+		}
+	}
+
+	log.Printf("Webhook %s delivery to %s failed after %d attempts", payload.ID, sub.URL, attempts)
+}
+
+// attempt makes one delivery attempt, records it and reports whether it
+// should be retried
+func (d *Dispatcher) attempt(sub *Subscription, payload Payload, body []byte, attempt int) bool {
+	delivery := &Delivery{
+		SubscriptionID: sub.ID,
+		EventID:        payload.ID,
+		EventType:      payload.Type,
+		Attempt:        attempt,
+		Payload:        string(body),
+	}
+
+	start := time.Now()
+	status, err := d.send(sub, payload, body)
+	delivery.DurationMs = time.Since(start).Milliseconds()
+	delivery.StatusCode = status
+
+	retry := false
+	switch {
+	case err != nil:
+		delivery.Error = err.Error()
+		retry = true
+	case status >= 200 && status < 300:
+		delivery.Success = true
+	default:
+		delivery.Error = fmt.Sprintf("webhook returned %d", status)
+		// Other client errors won't change on retry
+		retry = status >= 500 || status == http.StatusTooManyRequests || status == http.StatusRequestTimeout
+	}
+
+	if err := d.store.SaveDelivery(context.Background(), delivery); err != nil {
+		log.Printf("Failed to record webhook delivery %s: %v", payload.ID, err)
+	}
+	return retry
+}
+
+// send posts a signed payload and returns the response status
+func (d *Dispatcher) send(sub *Subscription, payload Payload, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(d.ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "NeonexCore-Webhook/1.0")
+	req.Header.Set(HeaderEvent, payload.Type)
+	req.Header.Set(HeaderID, payload.ID)
+
+	// Every attempt is signed with its own timestamp, so retries stay within
+	// the receiver's tolerance
+	timestamp := time.Now().Unix()
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(sub.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// Drain so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return resp.StatusCode, nil
+}
+
+// backoff returns the delay before the given retry (1-based)
+func (d *Dispatcher) backoff(retry int) time.Duration {
+	delay := d.config.InitialBackoff
+	for i := 1; i < retry; i++ {
+		delay *= 2
+		if d.config.MaxBackoff > 0 && delay >= d.config.MaxBackoff {
+			return d.config.MaxBackoff
+		}
+	}
+	return delay
+}
+
+// Sign returns the signature header value of a body sent at timestamp (Unix
+// seconds): "sha256=" followed by the hex HMAC-SHA256 of "<timestamp>.<body>"
+// keyed with the subscription secret
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether the timestamp and signature header values of a
+// delivery match its body, and the timestamp is within tolerance of now (0
+// uses DefaultTolerance). Webhook receivers use it to authenticate
+// deliveries; the tolerance keeps captured deliveries from being replayed
+// later.
+func Verify(secret string, body []byte, timestamp, signature string, tolerance time.Duration) bool {
+	if tolerance <= 0 {
+		tolerance = DefaultTolerance
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	age := time.Since(time.Unix(ts, 0))
+	if age > tolerance || age < -tolerance {
+		return false
+	}
+
+	return hmac.Equal([]byte(Sign(secret, ts, body)), []byte(signature))
+}
+
+// newEventID returns a random delivery ID
+func newEventID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
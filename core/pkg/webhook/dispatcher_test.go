@@ -0,0 +1,313 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTestStore(t *testing.T) (*GormStore, *gorm.DB) {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "webhook.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	store, err := NewGormStore(db)
+	if err != nil {
+		t.Fatalf("NewGormStore: %v", err)
+	}
+	return store, db
+}
+
+// newTestDispatcher returns a dispatcher retrying quickly
+func newTestDispatcher(t *testing.T, store Store) *Dispatcher {
+	t.Helper()
+
+	d := NewDispatcher(store, &Config{
+		Timeout:        time.Second,
+		MaxAttempts:    3,
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     50 * time.Millisecond,
+	})
+	t.Cleanup(func() { d.Close() })
+	return d
+}
+
+// receivedRequest is a delivery seen by a test receiver
+type receivedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// newReceiver starts a webhook receiver answering with the given statuses in
+// turn, then 200
+func newReceiver(t *testing.T, statuses ...int) (*httptest.Server, func() []receivedRequest) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var received []receivedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		mu.Lock()
+		received = append(received, receivedRequest{header: r.Header.Clone(), body: body})
+		status := http.StatusOK
+		if len(received) <= len(statuses) {
+			status = statuses[len(received)-1]
+		}
+		mu.Unlock()
+
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() []receivedRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]receivedRequest(nil), received...)
+	}
+}
+
+func subscribe(t *testing.T, d *Dispatcher, url string) *Subscription {
+	t.Helper()
+
+	sub := &Subscription{URL: url, Events: []string{"order.*"}}
+	if err := d.Subscribe(context.Background(), sub); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	return sub
+}
+
+func publish(t *testing.T, d *Dispatcher, eventType string) int {
+	t.Helper()
+
+	n, err := d.Publish(context.Background(), eventType, map[string]interface{}{"order_id": 7})
+	if err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	return n
+}
+
+// waitForDeliveries waits until count attempts are recorded for a
+// subscription and returns them, newest first
+func waitForDeliveries(t *testing.T, store Store, subscriptionID uint, count int) []Delivery {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		deliveries, err := store.ListDeliveries(context.Background(), subscriptionID, 100)
+		if err != nil {
+			t.Fatalf("ListDeliveries: %v", err)
+		}
+		if len(deliveries) >= count || time.Now().After(deadline) {
+			if len(deliveries) != count {
+				t.Fatalf("recorded %d attempts, want %d", len(deliveries), count)
+			}
+			return deliveries
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestDeliveryIsSigned(t *testing.T) {
+	store, _ := newTestStore(t)
+	d := newTestDispatcher(t, store)
+	server, received := newReceiver(t)
+	sub := subscribe(t, d, server.URL)
+
+	if n := publish(t, d, "order.paid"); n != 1 {
+		t.Fatalf("Publish started %d deliveries, want 1", n)
+	}
+	waitForDeliveries(t, store, sub.ID, 1)
+
+	requests := received()
+	if len(requests) != 1 {
+		t.Fatalf("receiver got %d requests, want 1", len(requests))
+	}
+	req := requests[0]
+
+	timestamp := req.header.Get(HeaderTimestamp)
+	signature := req.header.Get(HeaderSignature)
+	if !Verify(sub.Secret, req.body, timestamp, signature, 0) {
+		t.Fatalf("signature %q with timestamp %q does not verify", signature, timestamp)
+	}
+	if Verify("other-secret", req.body, timestamp, signature, 0) {
+		t.Fatal("signature verifies with another secret")
+	}
+	if Verify(sub.Secret, append(req.body, ' '), timestamp, signature, 0) {
+		t.Fatal("signature verifies for a modified body")
+	}
+
+	ts, _ := strconv.ParseInt(timestamp, 10, 64)
+	if Verify(sub.Secret, req.body, strconv.FormatInt(ts+1, 10), signature, 0) {
+		t.Fatal("signature verifies with another timestamp")
+	}
+	if req.header.Get(HeaderEvent) != "order.paid" || req.header.Get(HeaderID) == "" {
+		t.Fatalf("event headers = %q, %q", req.header.Get(HeaderEvent), req.header.Get(HeaderID))
+	}
+}
+
+func TestVerifyRejectsStaleTimestamps(t *testing.T) {
+	body := []byte(`{"id":"1"}`)
+	old := time.Now().Add(-10 * time.Minute).Unix()
+	signature := Sign("secret", old, body)
+
+	if Verify("secret", body, strconv.FormatInt(old, 10), signature, 0) {
+		t.Fatal("Verify accepted a timestamp older than the default tolerance")
+	}
+	if !Verify("secret", body, strconv.FormatInt(old, 10), signature, time.Hour) {
+		t.Fatal("Verify rejected a timestamp within the tolerance")
+	}
+	if Verify("secret", body, "not-a-number", signature, 0) {
+		t.Fatal("Verify accepted an invalid timestamp")
+	}
+}
+
+func TestSuccessfulDeliveryIsRecorded(t *testing.T) {
+	store, _ := newTestStore(t)
+	d := newTestDispatcher(t, store)
+	server, received := newReceiver(t)
+	sub := subscribe(t, d, server.URL)
+
+	publish(t, d, "order.paid")
+	if n := publish(t, d, "user.created"); n != 0 {
+		t.Fatalf("Publish of an unsubscribed event started %d deliveries", n)
+	}
+
+	deliveries := waitForDeliveries(t, store, sub.ID, 1)
+	if len(received()) != 1 {
+		t.Fatalf("receiver got %d requests, want 1", len(received()))
+	}
+	if len(deliveries) != 1 || !deliveries[0].Success || deliveries[0].StatusCode != http.StatusOK || deliveries[0].Attempt != 1 {
+		t.Fatalf("deliveries = %+v, want one successful first attempt", deliveries)
+	}
+}
+
+func TestServerErrorsAreRetried(t *testing.T) {
+	store, _ := newTestStore(t)
+	d := newTestDispatcher(t, store)
+	server, received := newReceiver(t, http.StatusInternalServerError, http.StatusBadGateway)
+	sub := subscribe(t, d, server.URL)
+
+	publish(t, d, "order.paid")
+	deliveries := waitForDeliveries(t, store, sub.ID, 3)
+
+	requests := received()
+	if len(requests) != 3 {
+		t.Fatalf("receiver got %d requests, want 3", len(requests))
+	}
+	if requests[0].header.Get(HeaderID) != requests[2].header.Get(HeaderID) {
+		t.Fatal("retries carry a different delivery ID")
+	}
+
+	// Newest first
+	if !deliveries[0].Success || deliveries[0].Attempt != 3 {
+		t.Fatalf("last attempt = %+v, want a successful third attempt", deliveries[0])
+	}
+	if deliveries[2].Success || deliveries[2].StatusCode != http.StatusInternalServerError {
+		t.Fatalf("first attempt = %+v, want a failed 500", deliveries[2])
+	}
+}
+
+func TestClientErrorsAreNotRetried(t *testing.T) {
+	store, _ := newTestStore(t)
+	d := newTestDispatcher(t, store)
+	server, received := newReceiver(t, http.StatusBadRequest)
+	sub := subscribe(t, d, server.URL)
+
+	publish(t, d, "order.paid")
+	deliveries := waitForDeliveries(t, store, sub.ID, 1)
+
+	// Give a wrongly scheduled retry time to show up
+	time.Sleep(100 * time.Millisecond)
+	if n := len(received()); n != 1 {
+		t.Fatalf("receiver got %d requests, want 1", n)
+	}
+	if deliveries[0].Success || deliveries[0].StatusCode != http.StatusBadRequest {
+		t.Fatalf("attempt = %+v, want a failed 400", deliveries[0])
+	}
+}
+
+func TestOldDeliveriesArePurged(t *testing.T) {
+	store, db := newTestStore(t)
+
+	old := &Delivery{SubscriptionID: 1, EventID: "old", CreatedAt: time.Now().Add(-48 * time.Hour)}
+	recent := &Delivery{SubscriptionID: 1, EventID: "recent"}
+	for _, delivery := range []*Delivery{old, recent} {
+		if err := db.Create(delivery).Error; err != nil {
+			t.Fatalf("create delivery: %v", err)
+		}
+	}
+
+	d := NewDispatcher(store, &Config{MaxAttempts: 1, Retention: 24 * time.Hour})
+	defer d.Close()
+	if err := d.PurgeDeliveries(context.Background()); err != nil {
+		t.Fatalf("PurgeDeliveries: %v", err)
+	}
+
+	deliveries, err := store.ListDeliveries(context.Background(), 1, 10)
+	if err != nil {
+		t.Fatalf("ListDeliveries: %v", err)
+	}
+	if len(deliveries) != 1 || deliveries[0].EventID != "recent" {
+		t.Fatalf("deliveries after purge = %+v, want only the recent one", deliveries)
+	}
+}
+
+// blockingStore holds ListSubscriptions until release is closed
+type blockingStore struct {
+	Store
+	listing chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingStore) ListSubscriptions(ctx context.Context) ([]Subscription, error) {
+	close(s.listing)
+	<-s.release
+	return s.Store.ListSubscriptions(ctx)
+}
+
+func TestPublishDuringCloseStartsNoDeliveries(t *testing.T) {
+	store, _ := newTestStore(t)
+	server, received := newReceiver(t)
+	blocking := &blockingStore{Store: store, listing: make(chan struct{}), release: make(chan struct{})}
+	d := NewDispatcher(blocking, &Config{Timeout: time.Second, MaxAttempts: 1})
+	subscribe(t, d, server.URL)
+
+	type result struct {
+		started int
+		err     error
+	}
+	done := make(chan result)
+	go func() {
+		n, err := d.Publish(context.Background(), "order.created", nil)
+		done <- result{n, err}
+	}()
+
+	// Close while Publish is past its first closed check
+	<-blocking.listing
+	if err := d.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	close(blocking.release)
+
+	if got := <-done; got.started != 0 || got.err == nil {
+		t.Fatalf("Publish during Close = %d, %v; want no deliveries and an error", got.started, got.err)
+	}
+	if got := received(); len(got) != 0 {
+		t.Fatalf("received %d deliveries after Close", len(got))
+	}
+	if _, err := d.Publish(context.Background(), "order.created", nil); err == nil {
+		t.Fatal("published after Close")
+	}
+}
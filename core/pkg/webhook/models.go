@@ -0,0 +1,56 @@
+package webhook
+
+import (
+	"strings"
+	"time"
+)
+
+// Subscription delivers events of the subscribed types to a URL
+type Subscription struct {
+	ID          uint      `gorm:"primarykey" json:"id"`
+	URL         string    `gorm:"size:2048;not null" json:"url"`
+	Secret      string    `gorm:"size:255;not null" json:"-"`    // HMAC key for the signature header
+	Events      []string  `gorm:"serializer:json" json:"events"` // Event types; "*" matches all, "user.*" a prefix
+	Description string    `gorm:"size:255" json:"description"`
+	Active      bool      `gorm:"default:true" json:"active"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TableName returns the subscription table name
+func (Subscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+// Matches reports whether the subscription wants events of the type
+func (s *Subscription) Matches(eventType string) bool {
+	for _, pattern := range s.Events {
+		if pattern == "*" || pattern == eventType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok && strings.HasPrefix(eventType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Delivery records one attempt to deliver an event to a subscription
+type Delivery struct {
+	ID             uint      `gorm:"primarykey" json:"id"`
+	SubscriptionID uint      `gorm:"index" json:"subscription_id"`
+	EventID        string    `gorm:"size:64;index" json:"event_id"` // Same for every attempt of a delivery
+	EventType      string    `gorm:"size:255" json:"event_type"`
+	Attempt        int       `json:"attempt"`
+	StatusCode     int       `json:"status_code,omitempty"`
+	Error          string    `gorm:"type:text" json:"error,omitempty"`
+	Success        bool      `json:"success"`
+	DurationMs     int64     `json:"duration_ms"`
+	Payload        string    `gorm:"type:text" json:"payload"`
+	CreatedAt      time.Time `gorm:"index" json:"created_at"`
+}
+
+// TableName returns the delivery table name
+func (Delivery) TableName() string {
+	return "webhook_deliveries"
+}
@@ -0,0 +1,72 @@
+package webhook
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Store persists subscriptions and delivery attempts
+type Store interface {
+	SaveSubscription(ctx context.Context, sub *Subscription) error
+	DeleteSubscription(ctx context.Context, id uint) error
+	ListSubscriptions(ctx context.Context) ([]Subscription, error)
+	SaveDelivery(ctx context.Context, delivery *Delivery) error
+	ListDeliveries(ctx context.Context, subscriptionID uint, limit int) ([]Delivery, error)
+	// DeleteDeliveriesBefore deletes attempts recorded before cutoff and
+	// returns how many were deleted
+	DeleteDeliveriesBefore(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// GormStore stores webhooks in the database
+type GormStore struct {
+	db *gorm.DB
+}
+
+// NewGormStore creates a database-backed webhook store and migrates its tables
+func NewGormStore(db *gorm.DB) (*GormStore, error) {
+	if err := db.AutoMigrate(&Subscription{}, &Delivery{}); err != nil {
+		return nil, err
+	}
+	return &GormStore{db: db}, nil
+}
+
+// SaveSubscription creates or updates a subscription
+func (s *GormStore) SaveSubscription(ctx context.Context, sub *Subscription) error {
+	return s.db.WithContext(ctx).Save(sub).Error
+}
+
+// DeleteSubscription deletes a subscription
+func (s *GormStore) DeleteSubscription(ctx context.Context, id uint) error {
+	return s.db.WithContext(ctx).Delete(&Subscription{}, id).Error
+}
+
+// ListSubscriptions returns all subscriptions
+func (s *GormStore) ListSubscriptions(ctx context.Context) ([]Subscription, error) {
+	var subs []Subscription
+	err := s.db.WithContext(ctx).Order("id").Find(&subs).Error
+	return subs, err
+}
+
+// SaveDelivery records a delivery attempt
+func (s *GormStore) SaveDelivery(ctx context.Context, delivery *Delivery) error {
+	return s.db.WithContext(ctx).Create(delivery).Error
+}
+
+// ListDeliveries returns a subscription's most recent delivery attempts
+func (s *GormStore) ListDeliveries(ctx context.Context, subscriptionID uint, limit int) ([]Delivery, error) {
+	var deliveries []Delivery
+	err := s.db.WithContext(ctx).
+		Where("subscription_id = ?", subscriptionID).
+		Order("id DESC").
+		Limit(limit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+// DeleteDeliveriesBefore deletes attempts recorded before cutoff
+func (s *GormStore) DeleteDeliveriesBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	result := s.db.WithContext(ctx).Where("created_at < ?", cutoff).Delete(&Delivery{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,43 @@
+package websocket
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"neonexcore/pkg/auth"
+	"neonexcore/pkg/errors"
+)
+
+// Authenticator authenticates a WebSocket upgrade request and returns the
+// user ID the connection belongs to. Returning an error rejects the upgrade.
+type Authenticator func(c *fiber.Ctx) (uint, error)
+
+// JWTAuthenticator authenticates upgrade requests with an access token from
+// the Authorization header or, since browsers can't set headers on
+// WebSocket requests, the token query parameter.
+func JWTAuthenticator(jwtManager *auth.JWTManager) Authenticator {
+	return func(c *fiber.Ctx) (uint, error) {
+		token := bearerToken(c.Get(fiber.HeaderAuthorization))
+		if token == "" {
+			token = c.Query("token")
+		}
+		if token == "" {
+			return 0, errors.NewUnauthorized("missing access token")
+		}
+
+		claims, err := jwtManager.ValidateToken(token)
+		if err != nil {
+			return 0, errors.NewUnauthorized("invalid access token")
+		}
+		return claims.UserID, nil
+	}
+}
+
+// bearerToken extracts the token from a "Bearer <token>" header value
+func bearerToken(header string) string {
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
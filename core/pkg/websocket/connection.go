@@ -3,7 +3,11 @@ package websocket
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gofiber/contrib/websocket"
@@ -30,13 +34,25 @@ type Connection struct {
 	Metadata  map[string]interface{}
 	CreatedAt time.Time
 	LastPing  time.Time
+	topics    map[string]bool // Subscribed topic patterns; empty means all
 	mu        sync.RWMutex
 	sendCh    chan []byte
 	done      chan struct{}
+	pumpDone  chan struct{} // Closed once the write pump stops using Conn
+
+	writeTimeout time.Duration
+	overflowed   atomic.Bool
 }
 
 // NewConnection creates a new WebSocket connection wrapper
 func NewConnection(id string, userID uint, conn *websocket.Conn) *Connection {
+	return newConnection(id, userID, conn, DefaultSendBufferSize, 0)
+}
+
+// newConnection creates a connection with a bounded send buffer. A
+// non-zero writeTimeout bounds each write so a congested client can't hold
+// its write pump forever.
+func newConnection(id string, userID uint, conn *websocket.Conn, bufferSize int, writeTimeout time.Duration) *Connection {
 	ctx, cancel := context.WithCancel(context.Background())
 	
 	c := &Connection{
@@ -47,10 +63,14 @@ func NewConnection(id string, userID uint, conn *websocket.Conn) *Connection {
 		Context:   ctx,
 		Cancel:    cancel,
 		Metadata:  make(map[string]interface{}),
+		topics:    make(map[string]bool),
 		CreatedAt: time.Now(),
 		LastPing:  time.Now(),
-		sendCh:    make(chan []byte, 256),
+		sendCh:    make(chan []byte, bufferSize),
 		done:      make(chan struct{}),
+		pumpDone:  make(chan struct{}),
+
+		writeTimeout: writeTimeout,
 	}
 	
 	// Start send pump
@@ -90,24 +110,27 @@ func (c *Connection) SendJSON(v interface{}) error {
 // Close closes the connection gracefully
 func (c *Connection) Close() error {
 	c.mu.Lock()
-	defer c.mu.Unlock()
-	
 	if c.Status == StatusClosed || c.Status == StatusClosing {
+		c.mu.Unlock()
 		return nil
 	}
 	
 	c.Status = StatusClosing
 	c.Cancel()
 	close(c.done)
+	c.mu.Unlock()
 	
-	// Send close message
+	// Send close message without holding the lock, so senders see the
+	// connection as closed instead of waiting on a congested client
 	err := c.Conn.WriteControl(
 		websocket.CloseMessage,
 		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
 		time.Now().Add(time.Second),
 	)
 	
+	c.mu.Lock()
 	c.Status = StatusClosed
+	c.mu.Unlock()
 	return err
 }
 
@@ -140,12 +163,83 @@ func (c *Connection) GetMetadata(key string) (interface{}, bool) {
 	return val, ok
 }
 
+// Subscribe subscribes the connection to topics. Topics may be glob
+// patterns such as "system_*". A connection without subscriptions
+// receives every topic.
+func (c *Connection) Subscribe(topics ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	
+	for _, topic := range topics {
+		if _, err := path.Match(topic, ""); err != nil {
+			return fmt.Errorf("invalid topic pattern %q", topic)
+		}
+	}
+	for _, topic := range topics {
+		c.topics[topic] = true
+	}
+	return nil
+}
+
+// Unsubscribe removes topic subscriptions
+func (c *Connection) Unsubscribe(topics ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	
+	for _, topic := range topics {
+		delete(c.topics, topic)
+	}
+}
+
+// Topics returns the subscribed topic patterns in sorted order
+func (c *Connection) Topics() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	
+	topics := make([]string, 0, len(c.topics))
+	for topic := range c.topics {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+// IsSubscribed reports whether the connection should receive a topic
+func (c *Connection) IsSubscribed(topic string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	
+	if len(c.topics) == 0 {
+		return true
+	}
+	for pattern := range c.topics {
+		if matched, _ := path.Match(pattern, topic); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// markOverflowed flags the connection as overflowed, reporting whether
+// this call set the flag
+func (c *Connection) markOverflowed() bool {
+	return c.overflowed.CompareAndSwap(false, true)
+}
+
+// setWriteDeadline bounds the next write when a write timeout is configured
+func (c *Connection) setWriteDeadline() {
+	if c.writeTimeout > 0 {
+		c.Conn.SetWriteDeadline(time.Now().Add(c.writeTimeout))
+	}
+}
+
 // writePump pumps messages from the send channel to the WebSocket connection
 func (c *Connection) writePump() {
 	ticker := time.NewTicker(54 * time.Second)
 	defer func() {
 		ticker.Stop()
 		c.Conn.Close()
+		close(c.pumpDone)
 	}()
 	
 	for {
@@ -156,12 +250,14 @@ func (c *Connection) writePump() {
 				return
 			}
 			
+			c.setWriteDeadline()
 			if err := c.Conn.WriteMessage(websocket.TextMessage, message); err != nil {
 				return
 			}
 			
 		case <-ticker.C:
 			// Send ping
+			c.setWriteDeadline()
 			if err := c.Conn.WriteMessage(websocket.PingMessage, nil); err != nil {
 				return
 			}
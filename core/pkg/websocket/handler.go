@@ -48,16 +48,20 @@ func (h *Handler) HandleConnection(c *websocket.Conn) {
 	}
 	
 	// Create connection
-	conn := NewConnection(connID, userID, c)
+	conn := newConnection(connID, userID, c, h.hub.sendBufferSize, h.hub.writeTimeout)
 	
 	// Register with hub
 	if err := h.hub.Register(conn); err != nil {
 		conn.Close()
+		<-conn.pumpDone
 		return
 	}
 	
 	defer func() {
 		h.hub.Unregister(connID)
+		conn.Close()
+		// The upgrader recycles c once this handler returns
+		<-conn.pumpDone
 		if h.onDisconnect != nil {
 			h.onDisconnect(conn)
 		}
@@ -181,6 +185,34 @@ func (h *Handler) defaultMessageHandler(conn *Connection, msg *Message) error {
 		data, _ := msg.ToJSON()
 		h.hub.SendToUser(msg.To, data)
 		
+	case TypeSubscribe:
+		// Subscribe to topics
+		if len(msg.Topics) == 0 {
+			return fmt.Errorf("topics required")
+		}
+		
+		if err := conn.Subscribe(msg.Topics...); err != nil {
+			return err
+		}
+		
+		return conn.SendJSON(NewMessage(TypeSystem, SubscriptionPayload{
+			Action: "subscribed",
+			Topics: conn.Topics(),
+		}))
+		
+	case TypeUnsubscribe:
+		// Unsubscribe from topics
+		if len(msg.Topics) == 0 {
+			return fmt.Errorf("topics required")
+		}
+		
+		conn.Unsubscribe(msg.Topics...)
+		
+		return conn.SendJSON(NewMessage(TypeSystem, SubscriptionPayload{
+			Action: "unsubscribed",
+			Topics: conn.Topics(),
+		}))
+		
 	case TypeBroadcast:
 		// Broadcast to all connections
 		msg.From = conn.UserID
@@ -220,21 +252,27 @@ func SetupRoutes(app fiber.Router, hub *Hub, messageHandler MessageHandler) {
 	
 	// WebSocket upgrade endpoint
 	app.Use("/ws", func(c *fiber.Ctx) error {
-		if websocket.IsWebSocketUpgrade(c) {
-			return c.Next()
+		if !websocket.IsWebSocketUpgrade(c) {
+			return fiber.ErrUpgradeRequired
+		}
+		if err := hub.Authenticate(c); err != nil {
+			return err
 		}
-		return fiber.ErrUpgradeRequired
+		return c.Next()
 	})
 	
 	app.Get("/ws", handler.Middleware())
 	
 	// Stats endpoint
 	app.Get("/ws/stats", func(c *fiber.Ctx) error {
+		stats := hub.Stats()
 		return c.JSON(fiber.Map{
-			"connections": hub.ConnectionCount(),
-			"users":       hub.UserCount(),
-			"rooms":       hub.RoomCount(),
-			"room_list":   hub.ListRooms(),
+			"connections":      stats.Connections,
+			"users":            stats.Users,
+			"rooms":            hub.RoomCount(),
+			"room_list":        hub.ListRooms(),
+			"dropped_messages": stats.DroppedMessages,
+			"slow_disconnects": stats.SlowDisconnects,
 		})
 	})
 }
@@ -1,9 +1,13 @@
 package websocket
 
 import (
+	"encoding/json"
 	"errors"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/gofiber/fiber/v2"
 )
 
 var (
@@ -26,12 +30,34 @@ type Hub struct {
 	writeTimeout    time.Duration
 	maxMessageSize  int64
 	
+	// Upgrade authentication; nil allows anonymous connections
+	authenticator Authenticator
+	
+	// Backpressure
+	sendBufferSize  int
+	overflowPolicy  OverflowPolicy
+	droppedMessages atomic.Uint64
+	slowDisconnects atomic.Uint64
+	
 	// Cleanup
 	cleanupInterval time.Duration
 	cleanupTicker   *time.Ticker
 	done            chan struct{}
 }
 
+// OverflowPolicy decides what happens to a client whose send buffer is full
+type OverflowPolicy string
+
+const (
+	// OverflowDrop drops the message and keeps the client connected
+	OverflowDrop OverflowPolicy = "drop"
+	// OverflowDisconnect drops the message and disconnects the client
+	OverflowDisconnect OverflowPolicy = "disconnect"
+)
+
+// DefaultSendBufferSize is the number of messages queued per connection
+const DefaultSendBufferSize = 256
+
 // HubConfig configures the Hub
 type HubConfig struct {
 	PingInterval    time.Duration
@@ -39,6 +65,16 @@ type HubConfig struct {
 	WriteTimeout    time.Duration
 	MaxMessageSize  int64
 	CleanupInterval time.Duration
+	SendBufferSize  int            // Messages queued per connection before overflow
+	OverflowPolicy  OverflowPolicy // What to do with clients that fall behind
+}
+
+// HubStats reports hub connection and backpressure counters
+type HubStats struct {
+	Connections     int    `json:"connections"`
+	Users           int    `json:"users"`
+	DroppedMessages uint64 `json:"dropped_messages"`
+	SlowDisconnects uint64 `json:"slow_disconnects"`
 }
 
 // DefaultHubConfig returns default Hub configuration
@@ -49,6 +85,8 @@ func DefaultHubConfig() HubConfig {
 		WriteTimeout:    10 * time.Second,
 		MaxMessageSize:  512 * 1024, // 512 KB
 		CleanupInterval: 30 * time.Second,
+		SendBufferSize:  DefaultSendBufferSize,
+		OverflowPolicy:  OverflowDisconnect,
 	}
 }
 
@@ -63,8 +101,16 @@ func NewHub(config HubConfig) *Hub {
 		writeTimeout:    config.WriteTimeout,
 		maxMessageSize:  config.MaxMessageSize,
 		cleanupInterval: config.CleanupInterval,
+		sendBufferSize:  config.SendBufferSize,
+		overflowPolicy:  config.OverflowPolicy,
 		done:            make(chan struct{}),
 	}
+	if h.sendBufferSize <= 0 {
+		h.sendBufferSize = DefaultSendBufferSize
+	}
+	if h.overflowPolicy == "" {
+		h.overflowPolicy = OverflowDisconnect
+	}
 	
 	// Start cleanup goroutine
 	h.startCleanup()
@@ -72,6 +118,34 @@ func NewHub(config HubConfig) *Hub {
 	return h
 }
 
+// SetAuthenticator sets the authenticator used for upgrade requests.
+// Once set, requests it rejects never become connections.
+func (h *Hub) SetAuthenticator(authenticator Authenticator) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.authenticator = authenticator
+}
+
+// Authenticate authenticates an upgrade request and stores the user ID in
+// the request locals for the handler. Without an authenticator every
+// request is accepted anonymously.
+func (h *Hub) Authenticate(c *fiber.Ctx) error {
+	h.mu.RLock()
+	authenticator := h.authenticator
+	h.mu.RUnlock()
+	
+	if authenticator == nil {
+		return nil
+	}
+	
+	userID, err := authenticator(c)
+	if err != nil {
+		return err
+	}
+	c.Locals("userID", userID)
+	return nil
+}
+
 // Register adds a new connection to the hub
 func (h *Hub) Register(conn *Connection) error {
 	h.mu.Lock()
@@ -94,12 +168,21 @@ func (h *Hub) Register(conn *Connection) error {
 
 // Unregister removes a connection from the hub
 func (h *Hub) Unregister(connID string) {
+	// Close outside the hub lock: writing the close frame to a congested
+	// client can take a second, and broadcasts must not wait for it
+	if conn := h.remove(connID); conn != nil {
+		conn.Close()
+	}
+}
+
+// remove removes a connection from the hub and its rooms, returning it
+func (h *Hub) remove(connID string) *Connection {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 	
 	conn, exists := h.connections[connID]
 	if !exists {
-		return
+		return nil
 	}
 	
 	// Remove from connections
@@ -118,8 +201,7 @@ func (h *Hub) Unregister(connID string) {
 		room.Leave(connID)
 	}
 	
-	// Close connection
-	conn.Close()
+	return conn
 }
 
 // GetConnection retrieves a connection by ID
@@ -147,41 +229,81 @@ func (h *Hub) GetUserConnections(userID uint) []*Connection {
 	return conns
 }
 
+// Send queues a message for a connection without blocking. When the
+// connection's buffer is full the message is dropped and the overflow
+// policy is applied, so one slow client never stalls the sender.
+func (h *Hub) Send(conn *Connection, message []byte) error {
+	err := conn.Send(message)
+	if errors.Is(err, ErrSendBufferFull) {
+		h.droppedMessages.Add(1)
+		if h.overflowPolicy == OverflowDisconnect && conn.markOverflowed() {
+			h.slowDisconnects.Add(1)
+			// Closing writes a close frame; keep it off the caller's path
+			go h.Unregister(conn.ID)
+		}
+	}
+	return err
+}
+
 // Broadcast sends a message to all connections
 func (h *Hub) Broadcast(message []byte) {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 	
 	for _, conn := range h.connections {
-		conn.Send(message)
+		h.Send(conn, message)
 	}
 }
 
 // BroadcastJSON sends a JSON message to all connections
 func (h *Hub) BroadcastJSON(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	h.Broadcast(data)
+	return nil
+}
+
+// BroadcastTopic sends a message to all connections subscribed to a topic
+func (h *Hub) BroadcastTopic(topic string, message []byte) {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 	
 	for _, conn := range h.connections {
-		conn.SendJSON(v)
+		if conn.IsSubscribed(topic) {
+			h.Send(conn, message)
+		}
 	}
-	return nil
+}
+
+// Connections returns a snapshot of all active connections
+func (h *Hub) Connections() []*Connection {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	
+	conns := make([]*Connection, 0, len(h.connections))
+	for _, conn := range h.connections {
+		conns = append(conns, conn)
+	}
+	return conns
 }
 
 // SendToUser sends a message to all connections of a specific user
 func (h *Hub) SendToUser(userID uint, message []byte) {
 	conns := h.GetUserConnections(userID)
 	for _, conn := range conns {
-		conn.Send(message)
+		h.Send(conn, message)
 	}
 }
 
 // SendToUserJSON sends a JSON message to all connections of a specific user
 func (h *Hub) SendToUserJSON(userID uint, v interface{}) error {
-	conns := h.GetUserConnections(userID)
-	for _, conn := range conns {
-		conn.SendJSON(v)
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
 	}
+	h.SendToUser(userID, data)
 	return nil
 }
 
@@ -199,6 +321,18 @@ func (h *Hub) UserCount() int {
 	return len(h.userConns)
 }
 
+// Stats returns connection and backpressure counters
+func (h *Hub) Stats() HubStats {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return HubStats{
+		Connections:     len(h.connections),
+		Users:           len(h.userConns),
+		DroppedMessages: h.droppedMessages.Load(),
+		SlowDisconnects: h.slowDisconnects.Load(),
+	}
+}
+
 // Close shuts down the hub and closes all connections
 func (h *Hub) Close() {
 	close(h.done)
@@ -0,0 +1,271 @@
+package websocket
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	wsclient "github.com/fasthttp/websocket"
+	"github.com/gofiber/fiber/v2"
+	"neonexcore/pkg/auth"
+	"neonexcore/pkg/errors"
+	"neonexcore/pkg/logger"
+)
+
+// newTestServer serves the hub's routes on a local port and returns the
+// WebSocket URL
+func newTestServer(t *testing.T, hub *Hub) string {
+	t.Helper()
+
+	app := fiber.New(fiber.Config{
+		ErrorHandler:          errors.ErrorHandler(logger.Default()),
+		DisableStartupMessage: true,
+	})
+	SetupRoutes(app, hub, nil)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go app.Listener(ln)
+	t.Cleanup(func() {
+		hub.Close()
+		app.Shutdown()
+	})
+	return "ws://" + ln.Addr().String() + "/ws"
+}
+
+// dialTest connects to url and reads the welcome message
+func dialTest(t *testing.T, url string, header http.Header) (*wsclient.Conn, *Message) {
+	t.Helper()
+
+	conn, resp, err := wsclient.DefaultDialer.Dial(url, header)
+	if err != nil {
+		status := 0
+		if resp != nil {
+			status = resp.StatusCode
+		}
+		t.Fatalf("dial %s: %v (status %d)", url, err, status)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn, readMessage(t, conn)
+}
+
+func readMessage(t *testing.T, conn *wsclient.Conn) *Message {
+	t.Helper()
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var msg Message
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	return &msg
+}
+
+// subscribe subscribes the client to topics and waits for the confirmation
+func subscribe(t *testing.T, conn *wsclient.Conn, topics ...string) {
+	t.Helper()
+
+	msg := NewMessage(TypeSubscribe, nil)
+	msg.Topics = topics
+	if err := conn.WriteJSON(msg); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	if reply := readMessage(t, conn); reply.Type != TypeSystem {
+		t.Fatalf("subscribe reply = %+v", reply)
+	}
+}
+
+func newAuthTestHub(t *testing.T) (*Hub, *auth.JWTManager) {
+	t.Helper()
+
+	jwtManager := auth.NewJWTManager(&auth.JWTConfig{SecretKey: "test-secret"})
+	hub := NewHub(DefaultHubConfig())
+	hub.SetAuthenticator(JWTAuthenticator(jwtManager))
+	return hub, jwtManager
+}
+
+func TestAuthenticateRejectsUnauthorized(t *testing.T) {
+	hub, _ := newAuthTestHub(t)
+	url := newTestServer(t, hub)
+	forged := auth.NewJWTManager(&auth.JWTConfig{SecretKey: "other-secret"})
+	forgedToken, _ := forged.GenerateAccessToken(1, "mallory@example.com", "admin", nil)
+
+	tests := []struct {
+		name   string
+		url    string
+		header http.Header
+	}{
+		{"no token", url, nil},
+		{"garbage token", url + "?token=garbage", nil},
+		{"wrong signature", url + "?token=" + forgedToken, nil},
+		{"non-bearer header", url, http.Header{"Authorization": {"Basic " + forgedToken}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, resp, err := wsclient.DefaultDialer.Dial(tt.url, tt.header)
+			if err == nil {
+				conn.Close()
+				t.Fatal("the upgrade was accepted")
+			}
+			if resp == nil || resp.StatusCode != fiber.StatusUnauthorized {
+				t.Fatalf("response = %v, want 401", resp)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			var envelope errors.ErrorResponse
+			if err := json.Unmarshal(body, &envelope); err != nil || envelope.Code != errors.ErrCodeUnauthorized {
+				t.Fatalf("body = %s, want the error envelope", body)
+			}
+		})
+	}
+	if count := hub.ConnectionCount(); count != 0 {
+		t.Fatalf("%d connections registered, want none", count)
+	}
+}
+
+func TestAuthenticateAcceptsToken(t *testing.T) {
+	hub, jwtManager := newAuthTestHub(t)
+	url := newTestServer(t, hub)
+	token, err := jwtManager.GenerateAccessToken(42, "ada@example.com", "user", nil)
+	if err != nil {
+		t.Fatalf("GenerateAccessToken: %v", err)
+	}
+
+	// Browsers pass the token in the query; other clients use the header
+	_, welcome := dialTest(t, url+"?token="+token, nil)
+	_, headerWelcome := dialTest(t, url, http.Header{"Authorization": {"Bearer " + token}})
+
+	for _, msg := range []*Message{welcome, headerWelcome} {
+		data, _ := msg.Payload.(map[string]interface{})["data"].(map[string]interface{})
+		if data["user_id"] != float64(42) {
+			t.Fatalf("welcome = %+v, want user 42", msg.Payload)
+		}
+	}
+	if conns := hub.GetUserConnections(42); len(conns) != 2 {
+		t.Fatalf("%d connections for user 42, want 2", len(conns))
+	}
+}
+
+func TestBroadcastTopicFiltersSubscriptions(t *testing.T) {
+	hub := NewHub(DefaultHubConfig())
+	url := newTestServer(t, hub)
+
+	system, _ := dialTest(t, url, nil)
+	subscribe(t, system, "system_*")
+	alerts, _ := dialTest(t, url, nil)
+	subscribe(t, alerts, "alerts")
+	everything, _ := dialTest(t, url, nil)
+
+	for _, topic := range []string{"system_memory", "http_requests_total", "alerts"} {
+		hub.BroadcastTopic(topic, []byte(`{"type":"message","payload":"`+topic+`"}`))
+	}
+
+	want := map[*wsclient.Conn][]string{
+		system:     {"system_memory"},
+		alerts:     {"alerts"},
+		everything: {"system_memory", "http_requests_total", "alerts"},
+	}
+	for conn, topics := range want {
+		for _, topic := range topics {
+			if msg := readMessage(t, conn); msg.Payload != topic {
+				t.Fatalf("received %v, want %s", msg.Payload, topic)
+			}
+		}
+		// Nothing else arrives
+		conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+		var extra Message
+		if err := conn.ReadJSON(&extra); err == nil {
+			t.Fatalf("received unsubscribed message %+v", extra)
+		}
+	}
+
+}
+
+// dialSlow connects a client that never reads, with a small socket receive
+// buffer so the server's writes back up quickly
+func dialSlow(t *testing.T, url string) *wsclient.Conn {
+	t.Helper()
+
+	dialer := wsclient.Dialer{
+		NetDial: func(network, addr string) (net.Conn, error) {
+			conn, err := net.Dial(network, addr)
+			if err == nil {
+				conn.(*net.TCPConn).SetReadBuffer(4096)
+			}
+			return conn, err
+		},
+	}
+	conn, _, err := dialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial %s: %v", url, err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestSlowClientDoesNotBlockBroadcast(t *testing.T) {
+	tests := []struct {
+		policy      OverflowPolicy
+		disconnects uint64
+		remaining   int
+	}{
+		{OverflowDisconnect, 1, 1},
+		{OverflowDrop, 0, 2},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			config := DefaultHubConfig()
+			config.SendBufferSize = 16
+			config.WriteTimeout = 200 * time.Millisecond
+			config.OverflowPolicy = tt.policy
+			hub := NewHub(config)
+			url := newTestServer(t, hub)
+
+			dialSlow(t, url)
+			fast, _ := dialTest(t, url, nil)
+			// Far more than the slow client's socket and send buffers hold
+			const broadcasts = 600
+			received := make(chan int, 1)
+			go func() {
+				count := 0
+				for count < broadcasts {
+					fast.SetReadDeadline(time.Now().Add(2 * time.Second))
+					if _, _, err := fast.ReadMessage(); err != nil {
+						break
+					}
+					count++
+				}
+				received <- count
+			}()
+
+			message := []byte(`{"type":"message","payload":"` + strings.Repeat("x", 32*1024) + `"}`)
+			var slowest time.Duration
+			for i := 0; i < broadcasts; i++ {
+				start := time.Now()
+				hub.Broadcast(message)
+				if elapsed := time.Since(start); elapsed > slowest {
+					slowest = elapsed
+				}
+				time.Sleep(time.Millisecond)
+			}
+			if slowest > 100*time.Millisecond {
+				t.Fatalf("slowest broadcast took %s, want the slow client never to block it", slowest)
+			}
+
+			if got := <-received; got != broadcasts {
+				t.Fatalf("fast client received %d messages, want %d", got, broadcasts)
+			}
+			stats := hub.Stats()
+			if stats.DroppedMessages == 0 || stats.SlowDisconnects != tt.disconnects {
+				t.Fatalf("stats = %+v, want dropped messages and %d disconnects", stats, tt.disconnects)
+			}
+			if stats.Connections != tt.remaining {
+				t.Fatalf("%d connections, want %d", stats.Connections, tt.remaining)
+			}
+		})
+	}
+}
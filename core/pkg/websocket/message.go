@@ -20,6 +20,8 @@ const (
 	TypeNotification MessageType = "notification"
 	TypeError        MessageType = "error"
 	TypeSystem       MessageType = "system"
+	TypeSubscribe    MessageType = "subscribe"
+	TypeUnsubscribe  MessageType = "unsubscribe"
 )
 
 // Message represents a WebSocket message
@@ -29,6 +31,7 @@ type Message struct {
 	Room      string                 `json:"room,omitempty"`
 	To        uint                   `json:"to,omitempty"`
 	From      uint                   `json:"from,omitempty"`
+	Topics    []string               `json:"topics,omitempty"`
 	Timestamp time.Time              `json:"timestamp"`
 	Metadata  map[string]interface{} `json:"metadata,omitempty"`
 }
@@ -104,6 +107,12 @@ type SystemPayload struct {
 	Data    interface{} `json:"data,omitempty"`
 }
 
+// SubscriptionPayload represents a subscription change confirmation
+type SubscriptionPayload struct {
+	Action string   `json:"action"` // subscribed, unsubscribed
+	Topics []string `json:"topics"`
+}
+
 // RoomPayload represents a room-related payload
 type RoomPayload struct {
 	Room    string      `json:"room"`
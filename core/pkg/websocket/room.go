@@ -1,6 +1,7 @@
 package websocket
 
 import (
+	"encoding/json"
 	"sync"
 )
 
@@ -10,6 +11,7 @@ type Room struct {
 	connections map[string]*Connection
 	mu          sync.RWMutex
 	Metadata    map[string]interface{}
+	hub         *Hub // Applies the hub's overflow policy; nil for standalone rooms
 }
 
 // NewRoom creates a new room
@@ -47,26 +49,27 @@ func (r *Room) Broadcast(message []byte, excludeConnID ...string) {
 	
 	for _, conn := range r.connections {
 		if !exclude[conn.ID] {
-			conn.Send(message)
+			r.send(conn, message)
 		}
 	}
 }
 
 // BroadcastJSON sends a JSON message to all connections in the room
 func (r *Room) BroadcastJSON(v interface{}, excludeConnID ...string) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-	
-	exclude := make(map[string]bool)
-	for _, id := range excludeConnID {
-		exclude[id] = true
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
 	}
-	
-	for _, conn := range r.connections {
-		if !exclude[conn.ID] {
-			conn.SendJSON(v)
-		}
+	r.Broadcast(data, excludeConnID...)
+}
+
+// send queues a message for a member connection
+func (r *Room) send(conn *Connection, message []byte) {
+	if r.hub != nil {
+		r.hub.Send(conn, message)
+		return
 	}
+	conn.Send(message)
 }
 
 // MemberCount returns the number of connections in the room
@@ -106,6 +109,7 @@ func (h *Hub) CreateRoom(name string) *Room {
 	}
 	
 	room := NewRoom(name)
+	room.hub = h
 	h.rooms[name] = room
 	return room
 }
@@ -80,6 +80,12 @@ func (s *StepBuilder) Action(action ActionFunc) *StepBuilder {
 	return s
 }
 
+// Compensate sets the action that undoes this step if a later step fails
+func (s *StepBuilder) Compensate(action ActionFunc) *StepBuilder {
+	s.step.Compensate = action
+	return s
+}
+
 // Condition sets step condition function
 func (s *StepBuilder) Condition(condition ConditionFunc) *StepBuilder {
 	s.step.Condition = condition
@@ -114,6 +120,12 @@ func (s *StepBuilder) Retry(maxAttempts int, delay time.Duration, backoffRate fl
 	return s
 }
 
+// Steps sets the child steps executed on each iteration of a loop step
+func (s *StepBuilder) Steps(steps ...Step) *StepBuilder {
+	s.step.Steps = steps
+	return s
+}
+
 // Parameter sets step parameter
 func (s *StepBuilder) Parameter(key string, value interface{}) *StepBuilder {
 	s.step.Parameters[key] = value
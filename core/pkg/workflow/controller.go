@@ -0,0 +1,299 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"time"
+
+	"neonexcore/pkg/api"
+	"neonexcore/pkg/errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// ExecutionInfo is the API representation of an execution
+type ExecutionInfo struct {
+	ID          string                     `json:"id"`
+	WorkflowID  string                     `json:"workflow_id"`
+	Status      WorkflowStatus             `json:"status"`
+	CurrentStep string                     `json:"current_step,omitempty"`
+	Input       map[string]interface{}     `json:"input,omitempty"`
+	Output      map[string]interface{}     `json:"output,omitempty"`
+	Steps       map[string]*StepResultInfo `json:"steps,omitempty"`
+	Error       string                     `json:"error,omitempty"`
+	StartedAt   time.Time                  `json:"started_at"`
+	CompletedAt *time.Time                 `json:"completed_at,omitempty"`
+}
+
+// StepResultInfo is the API representation of a step result
+type StepResultInfo struct {
+	Status      WorkflowStatus `json:"status"`
+	Output      interface{}    `json:"output,omitempty"`
+	Error       string         `json:"error,omitempty"`
+	Attempts    int            `json:"attempts"`
+	StartedAt   time.Time      `json:"started_at"`
+	CompletedAt *time.Time     `json:"completed_at,omitempty"`
+	DurationMs  int64          `json:"duration_ms"`
+}
+
+// EventInfo is the API representation of an event log entry
+type EventInfo struct {
+	ID        uint                   `json:"id"`
+	StepID    string                 `json:"step_id,omitempty"`
+	Type      string                 `json:"type"`
+	Message   string                 `json:"message"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+	Timestamp time.Time              `json:"timestamp"`
+}
+
+// WorkflowController exposes executions of a stateful workflow engine over
+// HTTP
+type WorkflowController struct {
+	engine *StatefulWorkflowEngine
+}
+
+// NewWorkflowController creates a new workflow controller
+func NewWorkflowController(engine *StatefulWorkflowEngine) *WorkflowController {
+	return &WorkflowController{
+		engine: engine,
+	}
+}
+
+// StartExecution handles POST /workflows/:id/executions
+func (c *WorkflowController) StartExecution(ctx *fiber.Ctx) error {
+	var req struct {
+		Input   map[string]interface{} `json:"input"`
+		Timeout string                 `json:"timeout"` // e.g. "30s"; empty means none
+	}
+	if len(ctx.Body()) > 0 {
+		if err := ctx.BodyParser(&req); err != nil {
+			return errors.NewBadRequest("Invalid request body")
+		}
+	}
+	if req.Input == nil {
+		req.Input = make(map[string]interface{})
+	}
+
+	var options ExecutionOptions
+	if req.Timeout != "" {
+		timeout, err := time.ParseDuration(req.Timeout)
+		if err != nil || timeout <= 0 {
+			return errors.NewBadRequest("timeout must be a positive duration, e.g. 30s")
+		}
+		options.Timeout = timeout
+	}
+
+	// The execution outlives the request, so it mustn't keep fiber's
+	// request-scoped strings
+	workflowID := utils.CopyString(ctx.Params("id"))
+	execution, err := c.engine.StartExecution(context.Background(), workflowID, req.Input, options)
+	if err != nil {
+		return executionError(err)
+	}
+
+	return api.Created(ctx, "Workflow execution started", executionInfo(execution))
+}
+
+// ListExecutions handles GET /workflows/executions
+// (optional workflow_id and status filters, paginated)
+func (c *WorkflowController) ListExecutions(ctx *fiber.Ctx) error {
+	pagination, err := api.ParsePagination(ctx)
+	if err != nil {
+		return err
+	}
+
+	status := WorkflowStatus(ctx.Query("status"))
+	if status != "" && !validStatus(status) {
+		return errors.NewBadRequest(fmt.Sprintf("Invalid status: %s", status))
+	}
+
+	offset := (pagination.Page - 1) * pagination.Limit
+	executions, total, err := c.engine.ListExecutionsByStatus(ctx.Query("workflow_id"), status, offset, pagination.Limit)
+	if err != nil {
+		return errors.NewInternal("Failed to list workflow executions").WithError(err)
+	}
+
+	infos := make([]*ExecutionInfo, len(executions))
+	for i, execution := range executions {
+		infos[i] = executionInfo(execution)
+	}
+
+	return api.Paginated(ctx, infos, pagination.Page, pagination.Limit, total)
+}
+
+// GetExecution handles GET /workflows/executions/:id
+func (c *WorkflowController) GetExecution(ctx *fiber.Ctx) error {
+	execution, err := c.engine.GetExecution(ctx.Params("id"))
+	if err != nil {
+		return executionError(err)
+	}
+
+	return api.Success(ctx, executionInfo(execution))
+}
+
+// CancelExecution handles POST /workflows/executions/:id/cancel
+func (c *WorkflowController) CancelExecution(ctx *fiber.Ctx) error {
+	id := ctx.Params("id")
+	if err := c.engine.CancelExecution(id); err != nil {
+		return executionError(err)
+	}
+
+	execution, err := c.engine.GetExecution(id)
+	if err != nil {
+		return executionError(err)
+	}
+
+	return api.SuccessWithMessage(ctx, "Workflow execution cancelled", executionInfo(execution))
+}
+
+// ResumeExecution handles POST /workflows/executions/:id/resume
+func (c *WorkflowController) ResumeExecution(ctx *fiber.Ctx) error {
+	id := ctx.Params("id")
+	if err := c.engine.ResumeExecution(context.Background(), id); err != nil {
+		return executionError(err)
+	}
+
+	execution, err := c.engine.GetExecution(id)
+	if err != nil {
+		return executionError(err)
+	}
+
+	return api.SuccessWithMessage(ctx, "Workflow execution resumed", executionInfo(execution))
+}
+
+// GetExecutionEvents handles GET /workflows/executions/:id/events
+// (newest first, paginated)
+func (c *WorkflowController) GetExecutionEvents(ctx *fiber.Ctx) error {
+	id := ctx.Params("id")
+	if _, err := c.engine.GetExecution(id); err != nil {
+		return executionError(err)
+	}
+
+	pagination, err := api.ParsePagination(ctx)
+	if err != nil {
+		return err
+	}
+
+	offset := (pagination.Page - 1) * pagination.Limit
+	events, total, err := c.engine.stateStore.GetEventsPage(id, offset, pagination.Limit)
+	if err != nil {
+		return errors.NewInternal("Failed to get workflow execution events").WithError(err)
+	}
+
+	infos := make([]*EventInfo, len(events))
+	for i, event := range events {
+		infos[i] = eventInfo(event)
+	}
+
+	return api.Paginated(ctx, infos, pagination.Page, pagination.Limit, total)
+}
+
+// RegisterRoutes registers workflow routes
+func (c *WorkflowController) RegisterRoutes(router fiber.Router) {
+	workflows := router.Group("/workflows")
+
+	// Executions
+	workflows.Get("/executions", c.ListExecutions)
+	workflows.Get("/executions/:id", c.GetExecution)
+	workflows.Get("/executions/:id/events", c.GetExecutionEvents)
+	workflows.Post("/executions/:id/cancel", c.CancelExecution)
+	workflows.Post("/executions/:id/resume", c.ResumeExecution)
+
+	// Start a workflow
+	workflows.Post("/:id/executions", c.StartExecution)
+}
+
+// executionError converts an engine error to an AppError
+func executionError(err error) error {
+	switch {
+	case stderrors.Is(err, ErrWorkflowNotFound):
+		return errors.NewNotFound("Workflow not found")
+	case stderrors.Is(err, ErrExecutionNotFound):
+		return errors.NewNotFound("Workflow execution not found")
+	case stderrors.Is(err, ErrExecutionNotRunning):
+		return errors.NewConflict("Workflow execution is not running")
+	case stderrors.Is(err, ErrNotResumable):
+		return errors.NewConflict("Workflow execution cannot be resumed")
+	case stderrors.Is(err, ErrDraining):
+		return errors.New(errors.ErrCodeInternal, "Workflow engine is shutting down", fiber.StatusServiceUnavailable)
+	default:
+		return errors.NewInternal("Workflow execution failed").WithError(err)
+	}
+}
+
+// validStatus reports whether status is a known execution status
+func validStatus(status WorkflowStatus) bool {
+	switch status {
+	case StatusPending, StatusRunning, StatusCompleted, StatusFailed, StatusCancelled, StatusPaused, StatusCompensated:
+		return true
+	}
+	return false
+}
+
+// executionInfo snapshots an execution for the API
+func executionInfo(execution *Execution) *ExecutionInfo {
+	execution.mu.RLock()
+	defer execution.mu.RUnlock()
+
+	info := &ExecutionInfo{
+		ID:          execution.ID,
+		WorkflowID:  execution.WorkflowID,
+		Status:      execution.Status,
+		CurrentStep: execution.CurrentStep,
+		Input:       make(map[string]interface{}, len(execution.Input)),
+		Output:      execution.Output,
+		Steps:       make(map[string]*StepResultInfo, len(execution.StepResults)),
+		StartedAt:   execution.StartedAt,
+		CompletedAt: execution.CompletedAt,
+	}
+	if execution.Error != nil {
+		info.Error = execution.Error.Error()
+	}
+
+	// Steps may set variables in the input map while we read it
+	if execution.Context != nil {
+		execution.Context.mu.RLock()
+		defer execution.Context.mu.RUnlock()
+	}
+	for key, value := range execution.Input {
+		info.Input[key] = value
+	}
+
+	for id, result := range execution.StepResults {
+		if result == nil {
+			continue
+		}
+		step := &StepResultInfo{
+			Status:      result.Status,
+			Output:      result.Output,
+			Attempts:    result.Attempts,
+			StartedAt:   result.StartedAt,
+			CompletedAt: result.CompletedAt,
+			DurationMs:  result.Duration.Milliseconds(),
+		}
+		if result.Error != nil {
+			step.Error = result.Error.Error()
+		}
+		info.Steps[id] = step
+	}
+
+	return info
+}
+
+// eventInfo converts an event log entry for the API
+func eventInfo(event *EventLog) *EventInfo {
+	info := &EventInfo{
+		ID:        event.ID,
+		StepID:    event.StepID,
+		Type:      event.EventType,
+		Message:   event.Message,
+		Timestamp: event.Timestamp,
+	}
+	if event.Data != "" {
+		json.Unmarshal([]byte(event.Data), &info.Data)
+	}
+	return info
+}
@@ -0,0 +1,265 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"neonexcore/pkg/api"
+	apperrors "neonexcore/pkg/errors"
+	"neonexcore/pkg/logger"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// flakyWorkflow fails its only step on the first run and succeeds after
+func flakyWorkflow() *Workflow {
+	var (
+		mu   sync.Mutex
+		runs int
+	)
+	workflow := NewWorkflowBuilder("flaky").
+		AddStep("try", "Try").
+		Action(func(context.Context, *ExecutionContext) (interface{}, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			runs++
+			if runs == 1 {
+				return nil, errors.New("not yet")
+			}
+			return "done", nil
+		}).
+		End().
+		Build()
+	workflow.ID = "flaky"
+	return workflow
+}
+
+func newControllerTestApp(t *testing.T, workflows ...*Workflow) (*fiber.App, *StatefulWorkflowEngine) {
+	t.Helper()
+
+	engine := newTestStatefulEngine(t)
+	for _, workflow := range workflows {
+		engine.RegisterWorkflow(workflow)
+	}
+	app := fiber.New(fiber.Config{ErrorHandler: apperrors.ErrorHandler(logger.Default())})
+	NewWorkflowController(engine).RegisterRoutes(app)
+	return app, engine
+}
+
+// call sends a request and decodes the response envelope, with Data left
+// as JSON for the caller to decode
+func call(t *testing.T, app *fiber.App, method, path, body string) (int, api.Response, json.RawMessage) {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	}
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+
+	var envelope struct {
+		api.Response
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		t.Fatalf("%s %s: decode response: %v", method, path, err)
+	}
+	return resp.StatusCode, envelope.Response, envelope.Data
+}
+
+// startOrder starts an execution of the order workflow over HTTP
+func startOrder(t *testing.T, app *fiber.App) *ExecutionInfo {
+	t.Helper()
+
+	status, _, data := call(t, app, fiber.MethodPost, "/workflows/order/executions", `{"input":{"order_id":"A1"}}`)
+	if status != fiber.StatusCreated {
+		t.Fatalf("start: status %d, want 201", status)
+	}
+	var info ExecutionInfo
+	json.Unmarshal(data, &info)
+	return &info
+}
+
+func TestControllerStartAndGetExecution(t *testing.T) {
+	steps := newStepRecorder()
+	app, engine := newControllerTestApp(t, steps.workflow(""))
+
+	started := startOrder(t, app)
+	if started.ID == "" || started.WorkflowID != "order" || started.Input["order_id"] != "A1" {
+		t.Fatalf("started execution = %+v", started)
+	}
+	engine.running.Wait()
+
+	status, resp, data := call(t, app, fiber.MethodGet, "/workflows/executions/"+started.ID, "")
+	var info ExecutionInfo
+	json.Unmarshal(data, &info)
+	if status != fiber.StatusOK || !resp.Success || info.Status != StatusCompleted {
+		t.Fatalf("get: status %d, execution %+v; want completed", status, info)
+	}
+	if step := info.Steps["ship"]; step == nil || step.Status != StatusCompleted || step.Output != "shipd" || step.Attempts != 1 {
+		t.Fatalf("ship step = %+v, want completed with its output", step)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{"unknown workflow", fiber.MethodPost, "/workflows/missing/executions", "", fiber.StatusNotFound},
+		{"invalid body", fiber.MethodPost, "/workflows/order/executions", `{"input":`, fiber.StatusBadRequest},
+		{"invalid timeout", fiber.MethodPost, "/workflows/order/executions", `{"timeout":"soon"}`, fiber.StatusBadRequest},
+		{"negative timeout", fiber.MethodPost, "/workflows/order/executions", `{"timeout":"-1s"}`, fiber.StatusBadRequest},
+		{"unknown execution", fiber.MethodGet, "/workflows/executions/missing", "", fiber.StatusNotFound},
+		{"unknown execution events", fiber.MethodGet, "/workflows/executions/missing/events", "", fiber.StatusNotFound},
+		{"cancel unknown execution", fiber.MethodPost, "/workflows/executions/missing/cancel", "", fiber.StatusNotFound},
+		{"cancel finished execution", fiber.MethodPost, "/workflows/executions/" + started.ID + "/cancel", "", fiber.StatusConflict},
+		{"resume completed execution", fiber.MethodPost, "/workflows/executions/" + started.ID + "/resume", "", fiber.StatusConflict},
+		{"invalid status filter", fiber.MethodGet, "/workflows/executions?status=done", "", fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, resp, _ := call(t, app, tt.method, tt.path, tt.body)
+			if status != tt.want || resp.Success {
+				t.Fatalf("status %d, want %d", status, tt.want)
+			}
+		})
+	}
+}
+
+func TestControllerListExecutions(t *testing.T) {
+	steps := newStepRecorder()
+	app, engine := newControllerTestApp(t, steps.workflow("charge"), noopWorkflow("noop"))
+	defer func() {
+		close(steps.release)
+		engine.running.Wait()
+	}()
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		ids = append(ids, startOrder(t, app).ID)
+		steps.waitForStep(t, "charge")
+	}
+	if status, _, _ := call(t, app, fiber.MethodPost, "/workflows/executions/"+ids[0]+"/cancel", ""); status != fiber.StatusOK {
+		t.Fatalf("cancel: status %d, want 200", status)
+	}
+	if status, _, _ := call(t, app, fiber.MethodPost, "/workflows/noop/executions", ""); status != fiber.StatusCreated {
+		t.Fatalf("start noop: status %d, want 201", status)
+	}
+
+	tests := []struct {
+		query     string
+		wantTotal int64
+		wantLen   int
+	}{
+		{"", 4, 4},
+		{"?workflow_id=order", 3, 3},
+		{"?workflow_id=order&status=cancelled", 1, 1},
+		{"?workflow_id=order&status=running", 2, 2},
+		{"?workflow_id=order&limit=2&page=2", 3, 1},
+	}
+	for _, tt := range tests {
+		status, resp, data := call(t, app, fiber.MethodGet, "/workflows/executions"+tt.query, "")
+		var infos []*ExecutionInfo
+		json.Unmarshal(data, &infos)
+		if status != fiber.StatusOK || resp.Meta == nil || resp.Meta.Total != tt.wantTotal || len(infos) != tt.wantLen {
+			t.Fatalf("list%s: status %d, meta %+v, %d executions; want %d of %d", tt.query, status, resp.Meta, len(infos), tt.wantLen, tt.wantTotal)
+		}
+		if strings.Contains(tt.query, "cancelled") && (infos[0].ID != ids[0] || infos[0].Status != StatusCancelled) {
+			t.Fatalf("cancelled executions = %+v, want %s", infos[0], ids[0])
+		}
+	}
+}
+
+func TestControllerCancelExecution(t *testing.T) {
+	steps := newStepRecorder()
+	app, engine := newControllerTestApp(t, steps.workflow("charge"))
+
+	started := startOrder(t, app)
+	steps.waitForStep(t, "charge")
+
+	status, resp, data := call(t, app, fiber.MethodPost, "/workflows/executions/"+started.ID+"/cancel", "")
+	var info ExecutionInfo
+	json.Unmarshal(data, &info)
+	if status != fiber.StatusOK || resp.Message == "" || info.Status != StatusCancelled {
+		t.Fatalf("cancel: status %d, execution %+v; want cancelled", status, info)
+	}
+
+	// The blocked step is interrupted and later steps never run
+	engine.running.Wait()
+	if steps.count("ship") != 0 {
+		t.Fatal("a step ran after the execution was cancelled")
+	}
+	if status, _, _ := call(t, app, fiber.MethodPost, "/workflows/executions/"+started.ID+"/cancel", ""); status != fiber.StatusConflict {
+		t.Fatalf("second cancel: status %d, want 409", status)
+	}
+}
+
+func TestControllerResumeExecution(t *testing.T) {
+	app, engine := newControllerTestApp(t, flakyWorkflow())
+
+	status, _, data := call(t, app, fiber.MethodPost, "/workflows/flaky/executions", "")
+	if status != fiber.StatusCreated {
+		t.Fatalf("start: status %d, want 201", status)
+	}
+	var started ExecutionInfo
+	json.Unmarshal(data, &started)
+	engine.running.Wait()
+
+	_, _, data = call(t, app, fiber.MethodGet, "/workflows/executions/"+started.ID, "")
+	var failed ExecutionInfo
+	json.Unmarshal(data, &failed)
+	if failed.Status != StatusFailed || failed.Error != "not yet" {
+		t.Fatalf("execution = %+v, want failed", failed)
+	}
+
+	// The failed state is persisted as soon as the execution stops, so it
+	// can be resumed right away
+	if status, _, _ := call(t, app, fiber.MethodPost, "/workflows/executions/"+started.ID+"/resume", ""); status != fiber.StatusOK {
+		t.Fatalf("resume: status %d, want 200", status)
+	}
+	engine.running.Wait()
+
+	_, _, data = call(t, app, fiber.MethodGet, "/workflows/executions/"+started.ID, "")
+	var resumed ExecutionInfo
+	json.Unmarshal(data, &resumed)
+	if resumed.Status != StatusCompleted || resumed.Steps["try"].Output != "done" {
+		t.Fatalf("resumed execution = %+v, want completed", resumed)
+	}
+}
+
+func TestControllerExecutionEvents(t *testing.T) {
+	steps := newStepRecorder()
+	app, engine := newControllerTestApp(t, steps.workflow("charge"))
+
+	started := startOrder(t, app)
+	steps.waitForStep(t, "charge")
+	call(t, app, fiber.MethodPost, "/workflows/executions/"+started.ID+"/cancel", "")
+	engine.running.Wait()
+
+	status, resp, data := call(t, app, fiber.MethodGet, "/workflows/executions/"+started.ID+"/events", "")
+	var events []*EventInfo
+	json.Unmarshal(data, &events)
+	if status != fiber.StatusOK || resp.Meta == nil || resp.Meta.Total != 2 || len(events) != 2 {
+		t.Fatalf("events: status %d, meta %+v, %d events; want 2", status, resp.Meta, len(events))
+	}
+	// Newest first
+	if events[0].Type != "cancelled" || events[1].Type != "started" {
+		t.Fatalf("event types = %s, %s; want cancelled, started", events[0].Type, events[1].Type)
+	}
+
+	_, resp, data = call(t, app, fiber.MethodGet, "/workflows/executions/"+started.ID+"/events?limit=1&page=2", "")
+	json.Unmarshal(data, &events)
+	if len(events) != 1 || events[0].Type != "started" || !resp.Meta.HasPrevPage {
+		t.Fatalf("second page = %+v, meta %+v; want the started event", events, resp.Meta)
+	}
+}
@@ -0,0 +1,140 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrDraining is returned when an execution is started or resumed after
+// Drain was called
+var ErrDraining = errors.New("workflow engine is draining")
+
+// inflightExecution is an execution whose goroutine is running
+type inflightExecution struct {
+	execution *Execution
+	cancel    context.CancelFunc
+}
+
+// launch runs an execution in the background, under its timeout if it has
+// one, and tracks it for Drain
+func (e *WorkflowEngine) launch(ctx context.Context, workflow *Workflow, execution *Execution) error {
+	var cancel context.CancelFunc
+	if execution.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, execution.Timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+
+	e.mu.Lock()
+	if e.draining {
+		e.mu.Unlock()
+		cancel()
+		return ErrDraining
+	}
+	e.executions[execution.ID] = execution
+	e.trackLocked(execution)
+	e.evictLocked(time.Now())
+	e.inflight[execution.ID] = &inflightExecution{execution: execution, cancel: cancel}
+	e.running.Add(1)
+	e.mu.Unlock()
+
+	go func() {
+		defer e.running.Done()
+		defer func() {
+			cancel()
+			e.mu.Lock()
+			delete(e.inflight, execution.ID)
+			e.mu.Unlock()
+		}()
+
+		e.executeWorkflow(ctx, workflow, execution)
+		if e.onFinish != nil {
+			e.onFinish(execution)
+		}
+	}()
+
+	return nil
+}
+
+// Drain stops accepting new executions and waits for running ones to finish
+// or pause at their next step boundary. When ctx is done first, executions
+// still inside a step are paused and their step's context is cancelled; the
+// interrupted step runs again on resume. Paused executions keep their
+// completed steps, so the stateful engine can resume them later.
+func (e *WorkflowEngine) Drain(ctx context.Context) error {
+	_, err := e.drain(ctx)
+	return err
+}
+
+// drain implements Drain and returns the executions that were in flight
+func (e *WorkflowEngine) drain(ctx context.Context) ([]*Execution, error) {
+	e.mu.Lock()
+	e.draining = true
+	inflight := make([]*inflightExecution, 0, len(e.inflight))
+	for _, f := range e.inflight {
+		inflight = append(inflight, f)
+	}
+	e.mu.Unlock()
+
+	executions := make([]*Execution, 0, len(inflight))
+	for _, f := range inflight {
+		executions = append(executions, f.execution)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		e.running.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return executions, nil
+	case <-ctx.Done():
+	}
+
+	interrupted := 0
+	for _, f := range inflight {
+		if e.pause(f.execution) {
+			interrupted++
+		}
+		f.cancel()
+	}
+	if interrupted == 0 {
+		return executions, nil
+	}
+	return executions, fmt.Errorf("%d workflow executions paused mid-step: %w", interrupted, ctx.Err())
+}
+
+// isDraining reports whether Drain was called
+func (e *WorkflowEngine) isDraining() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.draining
+}
+
+// pause marks a running execution paused and reports whether it did
+func (e *WorkflowEngine) pause(execution *Execution) bool {
+	execution.mu.Lock()
+	if execution.Status != StatusRunning {
+		execution.mu.Unlock()
+		return false
+	}
+	execution.Status = StatusPaused
+	step := execution.CurrentStep
+	execution.mu.Unlock()
+
+	e.emit(execution.ID, step, "paused", "Workflow execution paused for shutdown", nil)
+	return true
+}
+
+// stepCompleted reports whether a step of the execution already completed
+func (ex *Execution) stepCompleted(stepID string) bool {
+	ex.mu.RLock()
+	defer ex.mu.RUnlock()
+
+	result, ok := ex.StepResults[stepID]
+	return ok && result.Status == StatusCompleted
+}
@@ -0,0 +1,214 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stepRecorder counts step runs and can hold a step until released
+type stepRecorder struct {
+	mu      sync.Mutex
+	runs    map[string]int
+	seen    map[string]interface{} // Output of "reserve" seen by later steps
+	started chan string
+	release chan struct{}
+}
+
+func newStepRecorder() *stepRecorder {
+	return &stepRecorder{
+		runs:    make(map[string]int),
+		seen:    make(map[string]interface{}),
+		started: make(chan string, 10),
+		release: make(chan struct{}),
+	}
+}
+
+func (r *stepRecorder) count(name string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.runs[name]
+}
+
+// action records a run of the step. Blocking steps wait for release, or
+// for their context while block is set.
+func (r *stepRecorder) action(name string, block bool) ActionFunc {
+	return func(ctx context.Context, execCtx *ExecutionContext) (interface{}, error) {
+		r.mu.Lock()
+		r.runs[name]++
+		r.seen[name], _ = execCtx.GetStepResult("reserve")
+		r.mu.Unlock()
+		r.started <- name
+
+		if block {
+			select {
+			case <-r.release:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		return name + "d", nil
+	}
+}
+
+func (r *stepRecorder) workflow(blockOn string) *Workflow {
+	workflow := NewWorkflowBuilder("order").
+		AddStep("reserve", "Reserve stock").
+		Action(r.action("reserve", blockOn == "reserve")).
+		Then("charge", "Charge card").
+		Action(r.action("charge", blockOn == "charge")).
+		Then("ship", "Ship order").
+		Action(r.action("ship", blockOn == "ship")).
+		End().
+		Build()
+	workflow.ID = "order"
+	return workflow
+}
+
+// waitForStep waits until a step of the workflow starts
+func (r *stepRecorder) waitForStep(t *testing.T, name string) {
+	t.Helper()
+
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case started := <-r.started:
+			if started == name {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("step %s didn't start", name)
+		}
+	}
+}
+
+func TestDrainPausesAtStepBoundary(t *testing.T) {
+	steps := newStepRecorder()
+	engine := NewWorkflowEngine()
+	engine.RegisterWorkflow(steps.workflow("reserve"))
+
+	execution, err := engine.StartExecution(context.Background(), "order", map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("StartExecution: %v", err)
+	}
+	steps.waitForStep(t, "reserve")
+
+	drained := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		drained <- engine.Drain(ctx)
+	}()
+
+	// New executions are refused while the running one finishes its step
+	waitFor := time.Now().Add(2 * time.Second)
+	for !engine.isDraining() && time.Now().Before(waitFor) {
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := engine.StartExecution(context.Background(), "order", map[string]interface{}{}); !errors.Is(err, ErrDraining) {
+		t.Fatalf("StartExecution while draining = %v, want ErrDraining", err)
+	}
+
+	close(steps.release)
+	if err := <-drained; err != nil {
+		t.Fatalf("Drain: %v", err)
+	}
+
+	if status := waitForExecution(t, execution); status != StatusPaused {
+		t.Fatalf("status = %s, want paused", status)
+	}
+	if !execution.stepCompleted("reserve") || steps.count("charge") != 0 {
+		t.Fatalf("results = %v, charge ran %d times; want only reserve done", execution.StepResults, steps.count("charge"))
+	}
+}
+
+func TestDrainDeadlinePausesMidStep(t *testing.T) {
+	steps := newStepRecorder()
+	engine := NewWorkflowEngine()
+	engine.RegisterWorkflow(steps.workflow("charge"))
+
+	execution, err := engine.StartExecution(context.Background(), "order", map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("StartExecution: %v", err)
+	}
+	steps.waitForStep(t, "charge")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = engine.Drain(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Drain = %v, want the deadline exceeded", err)
+	}
+
+	// The interrupted step is not recorded, so it runs again on resume
+	engine.running.Wait()
+	execution.mu.RLock()
+	defer execution.mu.RUnlock()
+	if execution.Status != StatusPaused {
+		t.Fatalf("status = %s, want paused", execution.Status)
+	}
+	if _, ok := execution.StepResults["charge"]; ok {
+		t.Fatal("the interrupted step has a result")
+	}
+	if result := execution.StepResults["reserve"]; result == nil || result.Status != StatusCompleted {
+		t.Fatalf("reserve result = %+v, want completed", result)
+	}
+}
+
+func TestDrainPersistsExecutionForResume(t *testing.T) {
+	steps := newStepRecorder()
+	engine := newTestStatefulEngine(t)
+	engine.RegisterWorkflow(steps.workflow("charge"))
+
+	execution, err := engine.StartExecution(context.Background(), "order", map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("StartExecution: %v", err)
+	}
+	steps.waitForStep(t, "charge")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := engine.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Drain = %v, want the deadline exceeded", err)
+	}
+	engine.running.Wait()
+
+	if err := engine.ResumeExecution(context.Background(), execution.ID); !errors.Is(err, ErrDraining) {
+		t.Fatalf("ResumeExecution on the drained engine = %v, want ErrDraining", err)
+	}
+
+	paused, err := engine.stateStore.ListStates("order", StatusPaused, 0)
+	if err != nil || len(paused) != 1 || paused[0].ExecutionID != execution.ID {
+		t.Fatalf("paused states = %v, %v; want the drained execution", paused, err)
+	}
+
+	// After a restart the execution continues from the interrupted step
+	close(steps.release)
+	restarted := NewStatefulWorkflowEngine(engine.stateStore)
+	restarted.RegisterWorkflow(steps.workflow(""))
+	if err := restarted.ResumeExecution(context.Background(), execution.ID); err != nil {
+		t.Fatalf("ResumeExecution: %v", err)
+	}
+	resumed, err := restarted.WorkflowEngine.GetExecution(execution.ID)
+	if err != nil {
+		t.Fatalf("GetExecution: %v", err)
+	}
+	if status := waitForExecution(t, resumed); status != StatusCompleted {
+		t.Fatalf("resumed status = %s, want completed", status)
+	}
+
+	runs := map[string]int{"reserve": 1, "charge": 2, "ship": 1}
+	for name, want := range runs {
+		if got := steps.count(name); got != want {
+			t.Errorf("%s ran %d times, want %d", name, got, want)
+		}
+	}
+	// Steps after the resume read the outputs of steps completed before it
+	steps.mu.Lock()
+	defer steps.mu.Unlock()
+	if steps.seen["ship"] != "reserved" {
+		t.Fatalf("ship saw reserve output %v, want reserved", steps.seen["ship"])
+	}
+}
@@ -29,6 +29,7 @@ type StepDefinition struct {
 	Retry      *RetryDefinition       `yaml:"retry,omitempty" json:"retry,omitempty"`
 	Parameters map[string]interface{} `yaml:"parameters,omitempty" json:"parameters,omitempty"`
 	Metadata   map[string]string      `yaml:"metadata,omitempty" json:"metadata,omitempty"`
+	Steps      []StepDefinition       `yaml:"steps,omitempty" json:"steps,omitempty"` // Loop body
 }
 
 // RetryDefinition YAML/JSON retry definition
@@ -121,6 +122,15 @@ func buildStepFromDefinition(def *StepDefinition, actionRegistry map[string]Acti
 		}
 	}
 
+	// Build loop body
+	for _, childDef := range def.Steps {
+		child, err := buildStepFromDefinition(&childDef, actionRegistry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build step %s: %w", childDef.ID, err)
+		}
+		step.Steps = append(step.Steps, *child)
+	}
+
 	return step, nil
 }
 
@@ -147,30 +157,39 @@ func workflowToDefinition(workflow *Workflow) *WorkflowDefinition {
 	}
 
 	for _, step := range workflow.Steps {
-		stepDef := StepDefinition{
-			ID:         step.ID,
-			Name:       step.Name,
-			Type:       string(step.Type),
-			OnSuccess:  step.OnSuccess,
-			OnFailure:  step.OnFailure,
-			Parameters: step.Parameters,
-			Metadata:   step.Metadata,
-		}
+		def.Steps = append(def.Steps, stepToDefinition(step))
+	}
 
-		if step.Timeout > 0 {
-			stepDef.Timeout = step.Timeout.String()
-		}
+	return def
+}
+
+// stepToDefinition converts a step (and its loop body) to a definition
+func stepToDefinition(step Step) StepDefinition {
+	stepDef := StepDefinition{
+		ID:         step.ID,
+		Name:       step.Name,
+		Type:       string(step.Type),
+		OnSuccess:  step.OnSuccess,
+		OnFailure:  step.OnFailure,
+		Parameters: step.Parameters,
+		Metadata:   step.Metadata,
+	}
 
-		if step.RetryPolicy != nil {
-			stepDef.Retry = &RetryDefinition{
-				MaxAttempts: step.RetryPolicy.MaxAttempts,
-				Delay:       step.RetryPolicy.Delay.String(),
-				BackoffRate: step.RetryPolicy.BackoffRate,
-			}
+	if step.Timeout > 0 {
+		stepDef.Timeout = step.Timeout.String()
+	}
+
+	if step.RetryPolicy != nil {
+		stepDef.Retry = &RetryDefinition{
+			MaxAttempts: step.RetryPolicy.MaxAttempts,
+			Delay:       step.RetryPolicy.Delay.String(),
+			BackoffRate: step.RetryPolicy.BackoffRate,
 		}
+	}
 
-		def.Steps = append(def.Steps, stepDef)
+	for _, child := range step.Steps {
+		stepDef.Steps = append(stepDef.Steps, stepToDefinition(child))
 	}
 
-	return def
+	return stepDef
 }
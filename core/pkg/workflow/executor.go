@@ -125,15 +125,12 @@ func executeStepWithContext(ctx context.Context, step Step, execCtx *ExecutionCo
 
 		lastErr = err
 
-		// Retry with backoff
+		// Retry with backoff, unless the step or execution is cancelled
 		if attempt < maxAttempts && step.RetryPolicy != nil {
-			delay := step.RetryPolicy.Delay
-			if step.RetryPolicy.BackoffRate > 0 {
-				for i := 1; i < attempt; i++ {
-					delay = time.Duration(float64(delay) * step.RetryPolicy.BackoffRate)
-				}
+			if err := sleepContext(ctx, step.RetryPolicy.backoff(attempt)); err != nil {
+				lastErr = err
+				break
 			}
-			time.Sleep(delay)
 		}
 	}
 
@@ -0,0 +1,152 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// DefaultMaxLoopIterations bounds loop steps that don't set max_iterations
+const DefaultMaxLoopIterations = 1000
+
+// ErrLoopLimitExceeded is returned when a loop step hits its iteration guard
+var ErrLoopLimitExceeded = errors.New("loop iteration limit exceeded")
+
+// SetMaxLoopIterations sets the default iteration guard for loop steps.
+// A step can override it with Parameters["max_iterations"].
+func (e *WorkflowEngine) SetMaxLoopIterations(n int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.maxLoopIterations = n
+}
+
+// executeLoop runs a loop step. With Parameters["items"] it runs once per
+// item (for-each); otherwise it runs while step.Condition holds. Each
+// iteration executes the step's child Steps in order, or its Action when it
+// has no children. The current position is exposed in the execution context
+// as "current_index" (and "current_item" for for-each loops).
+func (e *WorkflowEngine) executeLoop(ctx context.Context, step *Step, execCtx *ExecutionContext) (interface{}, error) {
+	if len(step.Steps) == 0 && step.Action == nil {
+		return nil, fmt.Errorf("loop step %s has no child steps or action", step.ID)
+	}
+
+	maxIterations := e.loopLimit(step)
+
+	items, forEach, err := loopItems(step)
+	if err != nil {
+		return nil, err
+	}
+	if forEach && len(items) > maxIterations {
+		return nil, fmt.Errorf("%w: %d items, max %d", ErrLoopLimitExceeded, len(items), maxIterations)
+	}
+	if !forEach && step.Condition == nil {
+		return nil, fmt.Errorf("loop step %s needs parameters.items or a condition", step.ID)
+	}
+
+	outputs := make([]interface{}, 0)
+	for i := 0; ; i++ {
+		if err := ctx.Err(); err != nil {
+			return outputs, err
+		}
+
+		if forEach {
+			if i >= len(items) {
+				break
+			}
+			execCtx.Set("current_item", items[i])
+		} else {
+			if i >= maxIterations {
+				return outputs, fmt.Errorf("%w: %d iterations", ErrLoopLimitExceeded, maxIterations)
+			}
+			ok, err := step.Condition(execCtx)
+			if err != nil {
+				return outputs, err
+			}
+			if !ok {
+				break
+			}
+		}
+		execCtx.Set("current_index", i)
+
+		output, err := e.runLoopIteration(ctx, step, execCtx)
+		if err != nil {
+			return outputs, fmt.Errorf("loop step %s iteration %d: %w", step.ID, i, err)
+		}
+		outputs = append(outputs, output)
+	}
+
+	return outputs, nil
+}
+
+// runLoopIteration executes one pass of a loop body. A single child (or the
+// step's own Action) yields its output directly; several children yield a
+// map of child ID to output.
+func (e *WorkflowEngine) runLoopIteration(ctx context.Context, step *Step, execCtx *ExecutionContext) (interface{}, error) {
+	if len(step.Steps) == 0 {
+		return step.Action(ctx, execCtx)
+	}
+
+	outputs := make(map[string]interface{}, len(step.Steps))
+	for i := range step.Steps {
+		child := &step.Steps[i]
+		result := e.executeStep(ctx, child, execCtx)
+		if result.Error != nil {
+			return nil, fmt.Errorf("step %s: %w", child.ID, result.Error)
+		}
+		if len(step.Steps) == 1 {
+			return result.Output, nil
+		}
+		outputs[child.ID] = result.Output
+	}
+
+	return outputs, nil
+}
+
+// loopLimit returns the iteration guard for a loop step
+func (e *WorkflowEngine) loopLimit(step *Step) int {
+	if n, ok := intParam(step.Parameters["max_iterations"]); ok && n > 0 {
+		return n
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.maxLoopIterations > 0 {
+		return e.maxLoopIterations
+	}
+	return DefaultMaxLoopIterations
+}
+
+// loopItems returns the for-each collection from Parameters["items"]
+func loopItems(step *Step) ([]interface{}, bool, error) {
+	raw, ok := step.Parameters["items"]
+	if !ok || raw == nil {
+		return nil, false, nil
+	}
+
+	v := reflect.ValueOf(raw)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, false, fmt.Errorf("loop step %s: items must be a slice, got %T", step.ID, raw)
+	}
+
+	items := make([]interface{}, v.Len())
+	for i := range items {
+		items[i] = v.Index(i).Interface()
+	}
+	return items, true, nil
+}
+
+// intParam converts numeric parameters, which decode as int from YAML and
+// float64 from JSON
+func intParam(v interface{}) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case int64:
+		return int(n), true
+	case float64:
+		return int(n), true
+	default:
+		return 0, false
+	}
+}
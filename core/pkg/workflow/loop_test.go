@@ -0,0 +1,144 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// countTo returns a condition holding while the "count" variable is below n
+func countTo(n int) ConditionFunc {
+	return func(execCtx *ExecutionContext) (bool, error) {
+		count, _ := execCtx.Get("count")
+		return count.(int) < n, nil
+	}
+}
+
+func increment(_ context.Context, execCtx *ExecutionContext) (interface{}, error) {
+	count, _ := execCtx.Get("count")
+	execCtx.Set("count", count.(int)+1)
+	return count.(int) + 1, nil
+}
+
+func TestLoopStepCounts(t *testing.T) {
+	workflow := NewWorkflowBuilder("count").
+		AddStep("loop", "Count to 5").
+		Type(StepTypeLoop).
+		Condition(countTo(5)).
+		Action(increment).
+		End().
+		Build()
+
+	execution := runWorkflow(t, NewWorkflowEngine(), workflow, map[string]interface{}{"count": 0})
+	if execution.Status != StatusCompleted {
+		t.Fatalf("status = %s (%v), want completed", execution.Status, execution.Error)
+	}
+	if got := fmt.Sprint(execution.StepResults["loop"].Output); got != "[1 2 3 4 5]" {
+		t.Fatalf("loop outputs = %s, want [1 2 3 4 5]", got)
+	}
+	if index, _ := execution.Context.Get("current_index"); index != 4 {
+		t.Fatalf("current_index = %v, want the last iteration's 4", index)
+	}
+}
+
+func TestLoopStepForEach(t *testing.T) {
+	var seen []string
+	child := Step{
+		ID:   "visit",
+		Type: StepTypeTask,
+		Action: func(_ context.Context, execCtx *ExecutionContext) (interface{}, error) {
+			index, _ := execCtx.Get("current_index")
+			item, _ := execCtx.Get("current_item")
+			seen = append(seen, fmt.Sprintf("%v=%v", index, item))
+			return item.(string) + "!", nil
+		},
+	}
+	workflow := NewWorkflowBuilder("for-each").
+		AddStep("loop", "Visit items").
+		Type(StepTypeLoop).
+		Parameter("items", []string{"a", "b", "c"}).
+		Steps(child).
+		End().
+		Build()
+
+	execution := runWorkflow(t, NewWorkflowEngine(), workflow, nil)
+	if execution.Status != StatusCompleted {
+		t.Fatalf("status = %s (%v), want completed", execution.Status, execution.Error)
+	}
+	if got := fmt.Sprint(seen); got != "[0=a 1=b 2=c]" {
+		t.Fatalf("iterations = %s, want each index and item exposed", got)
+	}
+	if got := fmt.Sprint(execution.StepResults["loop"].Output); got != "[a! b! c!]" {
+		t.Fatalf("loop outputs = %s", got)
+	}
+}
+
+func TestLoopStepIterationGuard(t *testing.T) {
+	forever := func(*ExecutionContext) (bool, error) { return true, nil }
+
+	workflow := NewWorkflowBuilder("forever").
+		AddStep("loop", "Never stops").
+		Type(StepTypeLoop).
+		Condition(forever).
+		Action(increment).
+		End().
+		Build()
+	engine := NewWorkflowEngine()
+	engine.SetMaxLoopIterations(10)
+
+	execution := runWorkflow(t, engine, workflow, map[string]interface{}{"count": 0})
+	if execution.Status != StatusFailed || !errors.Is(execution.Error, ErrLoopLimitExceeded) {
+		t.Fatalf("execution = %s, %v; want ErrLoopLimitExceeded", execution.Status, execution.Error)
+	}
+	if count, _ := execution.Context.Get("count"); count != 10 {
+		t.Fatalf("ran %v iterations, want 10", count)
+	}
+
+	// The step's own limit takes precedence, and bounds for-each loops too
+	workflow = NewWorkflowBuilder("too-many").
+		AddStep("loop", "Too many items").
+		Type(StepTypeLoop).
+		Parameter("items", []int{1, 2, 3}).
+		Parameter("max_iterations", 2).
+		Action(increment).
+		End().
+		Build()
+	execution = runWorkflow(t, engine, workflow, map[string]interface{}{"count": 0})
+	if !errors.Is(execution.Error, ErrLoopLimitExceeded) {
+		t.Fatalf("error = %v, want ErrLoopLimitExceeded", execution.Error)
+	}
+}
+
+func TestLoopStepStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	iterations := 0
+	workflow := NewWorkflowBuilder("cancel").
+		AddStep("loop", "Cancelled midway").
+		Type(StepTypeLoop).
+		Parameter("items", []int{1, 2, 3, 4, 5}).
+		Action(func(context.Context, *ExecutionContext) (interface{}, error) {
+			iterations++
+			if iterations == 2 {
+				cancel()
+			}
+			return nil, nil
+		}).
+		End().
+		Build()
+
+	engine := NewWorkflowEngine()
+	if err := engine.RegisterWorkflow(workflow); err != nil {
+		t.Fatalf("RegisterWorkflow: %v", err)
+	}
+	execution, err := engine.StartExecution(ctx, workflow.ID, map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("StartExecution: %v", err)
+	}
+	if status := waitForExecution(t, execution); status != StatusCancelled {
+		t.Fatalf("status = %s, want cancelled", status)
+	}
+	if iterations != 2 {
+		t.Fatalf("ran %d iterations, want the loop to stop after cancellation", iterations)
+	}
+}
@@ -0,0 +1,127 @@
+package workflow
+
+import (
+	"sort"
+	"time"
+)
+
+// RetentionPolicy bounds how many finished executions the engine keeps in
+// memory. Running and paused executions are never evicted.
+type RetentionPolicy struct {
+	TTL           time.Duration // Evict finished executions this long after completion (0 = no TTL)
+	MaxExecutions int           // Evict least recently used finished executions above this count (0 = unbounded)
+}
+
+// DefaultRetentionPolicy returns the default in-memory retention policy
+func DefaultRetentionPolicy() RetentionPolicy {
+	return RetentionPolicy{
+		TTL:           time.Hour,
+		MaxExecutions: 10000,
+	}
+}
+
+// retentionSweepInterval limits how often the TTL scan runs
+const retentionSweepInterval = time.Minute
+
+// SetRetentionPolicy replaces the retention policy and applies it immediately
+func (e *WorkflowEngine) SetRetentionPolicy(policy RetentionPolicy) {
+	e.mu.Lock()
+	e.retention = policy
+	e.lastSweep = time.Time{}
+	e.mu.Unlock()
+
+	e.EvictExecutions()
+}
+
+// EvictExecutions applies the retention policy and returns the number of
+// executions removed from memory
+func (e *WorkflowEngine) EvictExecutions() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.evictLocked(time.Now())
+}
+
+// trackLocked records an execution as most recently used. Caller must hold e.mu.
+func (e *WorkflowEngine) trackLocked(execution *Execution) {
+	if elem, ok := e.lruIndex[execution.ID]; ok {
+		e.lru.MoveToFront(elem)
+		return
+	}
+	e.lruIndex[execution.ID] = e.lru.PushFront(execution.ID)
+}
+
+// removeLocked drops an execution from memory. Caller must hold e.mu.
+func (e *WorkflowEngine) removeLocked(id string) {
+	delete(e.executions, id)
+	if elem, ok := e.lruIndex[id]; ok {
+		e.lru.Remove(elem)
+		delete(e.lruIndex, id)
+	}
+}
+
+// evictLocked applies the retention policy. Caller must hold e.mu.
+func (e *WorkflowEngine) evictLocked(now time.Time) int {
+	evicted := 0
+
+	// TTL: scan at most once per sweep interval
+	if e.retention.TTL > 0 && now.Sub(e.lastSweep) >= retentionSweepInterval {
+		e.lastSweep = now
+		cutoff := now.Add(-e.retention.TTL)
+		for id, execution := range e.executions {
+			if completedAt, finished := execution.finishedAt(); finished && completedAt.Before(cutoff) {
+				e.removeLocked(id)
+				evicted++
+			}
+		}
+	}
+
+	// Size: walk from least recently used, skipping executions still in flight
+	if e.retention.MaxExecutions > 0 {
+		for elem := e.lru.Back(); elem != nil && len(e.executions) > e.retention.MaxExecutions; {
+			prev := elem.Prev()
+			id := elem.Value.(string)
+			if _, finished := e.executions[id].finishedAt(); finished {
+				e.removeLocked(id)
+				evicted++
+			}
+			elem = prev
+		}
+	}
+
+	return evicted
+}
+
+// finishedAt reports whether the execution reached a terminal status and when
+func (ex *Execution) finishedAt() (time.Time, bool) {
+	ex.mu.RLock()
+	defer ex.mu.RUnlock()
+
+	switch ex.Status {
+	case StatusCompleted, StatusFailed, StatusCancelled:
+		if ex.CompletedAt != nil {
+			return *ex.CompletedAt, true
+		}
+		return ex.StartedAt, true
+	default:
+		return time.Time{}, false
+	}
+}
+
+// paginateExecutions sorts executions newest first and returns one page
+func paginateExecutions(executions []*Execution, offset, limit int) []*Execution {
+	sort.Slice(executions, func(i, j int) bool {
+		return executions[i].StartedAt.After(executions[j].StartedAt)
+	})
+
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= len(executions) {
+		return []*Execution{}
+	}
+	end := len(executions)
+	if limit > 0 && offset+limit < end {
+		end = offset + limit
+	}
+	return executions[offset:end]
+}
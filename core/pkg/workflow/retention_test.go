@@ -0,0 +1,187 @@
+package workflow
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func noopWorkflow(id string) *Workflow {
+	workflow := NewWorkflowBuilder(id).
+		AddStep("noop", "Do nothing").
+		Action(func(context.Context, *ExecutionContext) (interface{}, error) { return nil, nil }).
+		End().
+		Build()
+	workflow.ID = id
+	return workflow
+}
+
+// runExecutions runs n executions of workflow one after another
+func runExecutions(t *testing.T, engine *WorkflowEngine, workflowID string, n int) []*Execution {
+	t.Helper()
+
+	executions := make([]*Execution, n)
+	for i := range executions {
+		execution, err := engine.StartExecution(context.Background(), workflowID, map[string]interface{}{})
+		if err != nil {
+			t.Fatalf("StartExecution: %v", err)
+		}
+		waitForExecution(t, execution)
+		executions[i] = execution
+	}
+	return executions
+}
+
+func inMemoryExecutions(engine *WorkflowEngine) int {
+	engine.mu.RLock()
+	defer engine.mu.RUnlock()
+	return len(engine.executions)
+}
+
+func TestRetentionBoundsExecutions(t *testing.T) {
+	engine := NewWorkflowEngine()
+	engine.SetRetentionPolicy(RetentionPolicy{MaxExecutions: 3})
+	engine.RegisterWorkflow(noopWorkflow("noop"))
+
+	executions := runExecutions(t, engine, "noop", 10)
+	if n := inMemoryExecutions(engine); n > 3 {
+		t.Fatalf("%d executions kept in memory, want at most 3", n)
+	}
+
+	// The most recently used are kept
+	if _, err := engine.GetExecution(executions[9].ID); err != nil {
+		t.Fatalf("GetExecution of the latest: %v", err)
+	}
+	if _, err := engine.GetExecution(executions[0].ID); err == nil {
+		t.Fatal("the oldest execution was kept")
+	}
+}
+
+func TestRetentionKeepsRunningExecutions(t *testing.T) {
+	release := make(chan struct{})
+	workflow := NewWorkflowBuilder("blocked").
+		AddStep("wait", "Wait for release").
+		Action(func(context.Context, *ExecutionContext) (interface{}, error) {
+			<-release
+			return nil, nil
+		}).
+		End().
+		Build()
+
+	engine := NewWorkflowEngine()
+	engine.SetRetentionPolicy(RetentionPolicy{MaxExecutions: 1})
+	engine.RegisterWorkflow(workflow)
+
+	var executions []*Execution
+	for i := 0; i < 3; i++ {
+		execution, err := engine.StartExecution(context.Background(), workflow.ID, map[string]interface{}{})
+		if err != nil {
+			t.Fatalf("StartExecution: %v", err)
+		}
+		executions = append(executions, execution)
+	}
+	if n := inMemoryExecutions(engine); n != 3 {
+		t.Fatalf("%d executions in memory, want all running ones kept", n)
+	}
+
+	close(release)
+	for _, execution := range executions {
+		waitForExecution(t, execution)
+	}
+	if evicted := engine.EvictExecutions(); evicted != 2 || inMemoryExecutions(engine) != 1 {
+		t.Fatalf("evicted %d, kept %d; want 2 and 1 once finished", evicted, inMemoryExecutions(engine))
+	}
+}
+
+func TestRetentionTTL(t *testing.T) {
+	engine := NewWorkflowEngine()
+	engine.RegisterWorkflow(noopWorkflow("noop"))
+	runExecutions(t, engine, "noop", 2)
+
+	time.Sleep(5 * time.Millisecond)
+	engine.SetRetentionPolicy(RetentionPolicy{TTL: time.Millisecond})
+	if n := inMemoryExecutions(engine); n != 0 {
+		t.Fatalf("%d expired executions kept", n)
+	}
+}
+
+func TestListExecutionsPaginates(t *testing.T) {
+	engine := NewWorkflowEngine()
+	engine.RegisterWorkflow(noopWorkflow("noop"))
+	engine.RegisterWorkflow(noopWorkflow("other"))
+	executions := runExecutions(t, engine, "noop", 5)
+	runExecutions(t, engine, "other", 1)
+
+	page, total, err := engine.ListExecutions("noop", 1, 2)
+	if err != nil {
+		t.Fatalf("ListExecutions: %v", err)
+	}
+	if total != 5 || len(page) != 2 {
+		t.Fatalf("got %d of %d, want 2 of 5", len(page), total)
+	}
+	// Newest first
+	if page[0].ID != executions[3].ID || page[1].ID != executions[2].ID {
+		t.Fatalf("page = %s, %s; want the 4th and 3rd executions", page[0].ID, page[1].ID)
+	}
+
+	if page, _, _ := engine.ListExecutions("noop", 10, 2); len(page) != 0 {
+		t.Fatalf("page past the end has %d executions", len(page))
+	}
+}
+
+func newTestStatefulEngine(t *testing.T) *StatefulWorkflowEngine {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "workflow.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	store, err := NewStateStore(db)
+	if err != nil {
+		t.Fatalf("NewStateStore: %v", err)
+	}
+	return NewStatefulWorkflowEngine(store)
+}
+
+func TestStatefulEngineServesEvictedExecutions(t *testing.T) {
+	engine := newTestStatefulEngine(t)
+	engine.SetRetentionPolicy(RetentionPolicy{MaxExecutions: 1})
+	engine.RegisterWorkflow(noopWorkflow("noop"))
+
+	var executions []*Execution
+	for i := 0; i < 3; i++ {
+		execution, err := engine.StartExecution(context.Background(), "noop", map[string]interface{}{})
+		if err != nil {
+			t.Fatalf("StartExecution: %v", err)
+		}
+		waitForExecution(t, execution)
+		// Persist the final state rather than waiting for the monitor
+		if err := engine.stateStore.SaveState(execution); err != nil {
+			t.Fatalf("SaveState: %v", err)
+		}
+		executions = append(executions, execution)
+	}
+	if n := inMemoryExecutions(engine.WorkflowEngine); n != 1 {
+		t.Fatalf("%d executions in memory, want 1", n)
+	}
+
+	loaded, err := engine.GetExecution(executions[0].ID)
+	if err != nil {
+		t.Fatalf("GetExecution of an evicted execution: %v", err)
+	}
+	if loaded.Status != StatusCompleted {
+		t.Fatalf("loaded status = %s, want completed", loaded.Status)
+	}
+
+	listed, total, err := engine.ListExecutions("noop", 0, 10)
+	if err != nil {
+		t.Fatalf("ListExecutions: %v", err)
+	}
+	if total != 3 || len(listed) != 3 {
+		t.Fatalf("listed %d of %d, want all 3 from the store", len(listed), total)
+	}
+}
@@ -3,6 +3,7 @@ package workflow
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -10,6 +11,10 @@ import (
 	"gorm.io/gorm"
 )
 
+// ErrNotResumable is returned when resuming an execution that is neither
+// paused nor failed
+var ErrNotResumable = errors.New("execution cannot be resumed")
+
 // StateStore stores workflow execution state
 type StateStore struct {
 	db *gorm.DB
@@ -28,6 +33,7 @@ type WorkflowState struct {
 	Variables    string                 `gorm:"type:jsonb"` // JSON serialized
 	StepResults  string                 `gorm:"type:jsonb"` // JSON serialized
 	Error        string                 `gorm:"type:text"`
+	Timeout      time.Duration          // Execution timeout, applied again on resume
 	StartedAt    time.Time              `gorm:"index"`
 	CompletedAt  *time.Time             `gorm:"index"`
 	UpdatedAt    time.Time              `gorm:"autoUpdateTime"`
@@ -71,6 +77,7 @@ func (s *StateStore) SaveState(execution *Execution) error {
 		ExecutionID: execution.ID,
 		Status:      execution.Status,
 		CurrentStep: execution.CurrentStep,
+		Timeout:     execution.Timeout,
 		StartedAt:   execution.StartedAt,
 		CompletedAt: execution.CompletedAt,
 	}
@@ -110,14 +117,23 @@ func (s *StateStore) LoadState(executionID string) (*Execution, error) {
 
 	var state WorkflowState
 	if err := s.db.Where("execution_id = ?", executionID).First(&state).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: %s", ErrExecutionNotFound, executionID)
+		}
 		return nil, fmt.Errorf("failed to load state: %w", err)
 	}
 
+	return state.toExecution(), nil
+}
+
+// toExecution rebuilds an execution from persisted state
+func (state *WorkflowState) toExecution() *Execution {
 	execution := &Execution{
 		ID:          state.ID,
 		WorkflowID:  state.WorkflowID,
 		Status:      state.Status,
 		CurrentStep: state.CurrentStep,
+		Timeout:     state.Timeout,
 		StartedAt:   state.StartedAt,
 		CompletedAt: state.CompletedAt,
 		Input:       make(map[string]interface{}),
@@ -153,7 +169,14 @@ func (s *StateStore) LoadState(executionID string) (*Execution, error) {
 		json.Unmarshal([]byte(state.StepResults), &execution.StepResults)
 	}
 
-	return execution, nil
+	// Later steps read the outputs of completed steps from the context
+	for stepID, result := range execution.StepResults {
+		if result != nil && result.Status == StatusCompleted {
+			execution.Context.StepResults[stepID] = result.Output
+		}
+	}
+
+	return execution
 }
 
 // DeleteState deletes workflow execution state
@@ -191,6 +214,40 @@ func (s *StateStore) ListStates(workflowID string, status WorkflowStatus, limit
 	return states, nil
 }
 
+// ListStatesPage lists workflow states newest first with offset pagination
+// and returns the total number of matching states
+func (s *StateStore) ListStatesPage(workflowID string, status WorkflowStatus, offset, limit int) ([]*WorkflowState, int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	query := s.db.Model(&WorkflowState{})
+	if workflowID != "" {
+		query = query.Where("workflow_id = ?", workflowID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if offset > 0 {
+		query = query.Offset(offset)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	var states []*WorkflowState
+	if err := query.Order("started_at DESC").Find(&states).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return states, total, nil
+}
+
 // LogEvent logs a workflow event
 func (s *StateStore) LogEvent(executionID, stepID, eventType, message string, data map[string]interface{}) error {
 	s.mu.Lock()
@@ -230,6 +287,34 @@ func (s *StateStore) GetEvents(executionID string, limit int) ([]*EventLog, erro
 	return events, nil
 }
 
+// GetEventsPage gets events for an execution, newest first, with offset
+// pagination and returns the total number of events
+func (s *StateStore) GetEventsPage(executionID string, offset, limit int) ([]*EventLog, int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	query := s.db.Model(&EventLog{}).Where("execution_id = ?", executionID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if offset > 0 {
+		query = query.Offset(offset)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	var events []*EventLog
+	if err := query.Order("timestamp DESC").Order("id DESC").Find(&events).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return events, total, nil
+}
+
 // CleanupOldStates removes old completed/failed states
 func (s *StateStore) CleanupOldStates(olderThan time.Duration) (int64, error) {
 	s.mu.Lock()
@@ -255,15 +340,29 @@ type StatefulWorkflowEngine struct {
 
 // NewStatefulWorkflowEngine creates a new stateful workflow engine
 func NewStatefulWorkflowEngine(stateStore *StateStore) *StatefulWorkflowEngine {
-	return &StatefulWorkflowEngine{
+	engine := &StatefulWorkflowEngine{
 		WorkflowEngine: NewWorkflowEngine(),
 		stateStore:     stateStore,
 	}
+
+	// Persist engine events (compensations, ...) to the event log
+	engine.SetEventHandler(func(executionID, stepID, eventType, message string, data map[string]interface{}) {
+		stateStore.LogEvent(executionID, stepID, eventType, message, data)
+	})
+
+	// Persist the final state right away, so the store's status can be
+	// filtered on and failed executions resumed without waiting for the
+	// monitor
+	engine.onFinish = func(execution *Execution) {
+		stateStore.SaveState(execution)
+	}
+
+	return engine
 }
 
 // StartExecution starts a workflow execution with state persistence
-func (e *StatefulWorkflowEngine) StartExecution(ctx context.Context, workflowID string, input map[string]interface{}) (*Execution, error) {
-	execution, err := e.WorkflowEngine.StartExecution(ctx, workflowID, input)
+func (e *StatefulWorkflowEngine) StartExecution(ctx context.Context, workflowID string, input map[string]interface{}, options ...ExecutionOptions) (*Execution, error) {
+	execution, err := e.WorkflowEngine.StartExecution(ctx, workflowID, input, options...)
 	if err != nil {
 		return nil, err
 	}
@@ -282,6 +381,80 @@ func (e *StatefulWorkflowEngine) StartExecution(ctx context.Context, workflowID
 	return execution, nil
 }
 
+// GetExecution returns the in-memory execution, falling back to the state
+// store for executions evicted by the retention policy
+func (e *StatefulWorkflowEngine) GetExecution(executionID string) (*Execution, error) {
+	if execution, err := e.WorkflowEngine.GetExecution(executionID); err == nil {
+		return execution, nil
+	}
+
+	execution, err := e.stateStore.LoadState(executionID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrExecutionNotFound, executionID)
+	}
+	return execution, nil
+}
+
+// ListExecutions lists executions from the state store, newest first, so
+// evicted executions remain queryable. Live in-memory instances are returned
+// where available.
+func (e *StatefulWorkflowEngine) ListExecutions(workflowID string, offset, limit int) ([]*Execution, int64, error) {
+	return e.ListExecutionsByStatus(workflowID, "", offset, limit)
+}
+
+// ListExecutionsByStatus lists executions like ListExecutions, keeping those
+// with the given persisted status (any status if empty)
+func (e *StatefulWorkflowEngine) ListExecutionsByStatus(workflowID string, status WorkflowStatus, offset, limit int) ([]*Execution, int64, error) {
+	states, total, err := e.stateStore.ListStatesPage(workflowID, status, offset, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list executions: %w", err)
+	}
+
+	executions := make([]*Execution, 0, len(states))
+	for _, state := range states {
+		if execution, err := e.WorkflowEngine.GetExecution(state.ExecutionID); err == nil {
+			executions = append(executions, execution)
+			continue
+		}
+		executions = append(executions, state.toExecution())
+	}
+
+	return executions, total, nil
+}
+
+// Drain stops accepting executions, waits for running ones like
+// WorkflowEngine.Drain and saves the state of every execution that was in
+// flight, so paused ones can be resumed with ResumeExecution.
+func (e *StatefulWorkflowEngine) Drain(ctx context.Context) error {
+	executions, err := e.drain(ctx)
+
+	for _, execution := range executions {
+		if saveErr := e.stateStore.SaveState(execution); saveErr != nil && err == nil {
+			err = fmt.Errorf("failed to save execution state: %w", saveErr)
+		}
+	}
+
+	return err
+}
+
+// CancelExecution cancels a running execution and saves its state
+func (e *StatefulWorkflowEngine) CancelExecution(executionID string) error {
+	if err := e.WorkflowEngine.CancelExecution(executionID); err != nil {
+		return err
+	}
+
+	execution, err := e.WorkflowEngine.GetExecution(executionID)
+	if err != nil {
+		return err
+	}
+	if err := e.stateStore.SaveState(execution); err != nil {
+		return fmt.Errorf("failed to save execution state: %w", err)
+	}
+
+	e.stateStore.LogEvent(executionID, "", "cancelled", "Workflow execution cancelled", nil)
+	return nil
+}
+
 // monitorExecution monitors execution and saves state
 func (e *StatefulWorkflowEngine) monitorExecution(ctx context.Context, execution *Execution) {
 	ticker := time.NewTicker(5 * time.Second) // Save state every 5 seconds
@@ -300,15 +473,16 @@ func (e *StatefulWorkflowEngine) monitorExecution(ctx context.Context, execution
 			// Save current state
 			e.stateStore.SaveState(execution)
 
-			// Exit if execution is complete
-			if status == StatusCompleted || status == StatusFailed || status == StatusCancelled {
+			// Exit if execution is complete or paused; resuming monitors it again
+			if status == StatusCompleted || status == StatusFailed || status == StatusCancelled || status == StatusPaused {
 				return
 			}
 		}
 	}
 }
 
-// ResumeExecution resumes a paused or failed execution
+// ResumeExecution resumes a paused or failed execution. An execution started
+// with a timeout gets the full timeout again.
 func (e *StatefulWorkflowEngine) ResumeExecution(ctx context.Context, executionID string) error {
 	// Load state from store
 	execution, err := e.stateStore.LoadState(executionID)
@@ -318,7 +492,7 @@ func (e *StatefulWorkflowEngine) ResumeExecution(ctx context.Context, executionI
 
 	// Check if execution can be resumed
 	if execution.Status != StatusPaused && execution.Status != StatusFailed {
-		return fmt.Errorf("execution cannot be resumed: status=%s", execution.Status)
+		return fmt.Errorf("%w: status=%s", ErrNotResumable, execution.Status)
 	}
 
 	// Get workflow
@@ -332,14 +506,18 @@ func (e *StatefulWorkflowEngine) ResumeExecution(ctx context.Context, executionI
 	execution.Status = StatusRunning
 	execution.mu.Unlock()
 
+	// Continue execution; completed steps are skipped
+	if err := e.launch(ctx, workflow, execution); err != nil {
+		return err
+	}
+
 	// Save state
 	e.stateStore.SaveState(execution)
 
 	// Log resume event
 	e.stateStore.LogEvent(execution.ID, "", "resumed", "Workflow execution resumed", nil)
 
-	// Continue execution
-	go e.executeWorkflow(ctx, workflow, execution)
+	go e.monitorExecution(ctx, execution)
 
 	return nil
 }
@@ -0,0 +1,166 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// retryingWorkflow has one step that always fails and retries after a
+// backoff far longer than any test waits
+func retryingWorkflow(attempts *int32, stepTimeout time.Duration) *Workflow {
+	workflow := NewWorkflowBuilder("retrying").
+		AddStep("flaky", "Always fails").
+		Action(func(context.Context, *ExecutionContext) (interface{}, error) {
+			atomic.AddInt32(attempts, 1)
+			return nil, errors.New("unavailable")
+		}).
+		Retry(5, time.Hour, 2).
+		Timeout(stepTimeout).
+		End().
+		Build()
+	workflow.ID = "retrying"
+	return workflow
+}
+
+func TestExecutionTimeoutCancelsSlowWorkflow(t *testing.T) {
+	var compensated, shipped int32
+	workflow := NewWorkflowBuilder("slow").
+		AddStep("reserve", "Reserve stock").
+		Action(func(context.Context, *ExecutionContext) (interface{}, error) { return "reserved", nil }).
+		Compensate(func(context.Context, *ExecutionContext) (interface{}, error) {
+			atomic.AddInt32(&compensated, 1)
+			return nil, nil
+		}).
+		Then("wait", "Wait for the warehouse").
+		Action(func(ctx context.Context, _ *ExecutionContext) (interface{}, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}).
+		Then("ship", "Ship order").
+		Action(func(context.Context, *ExecutionContext) (interface{}, error) {
+			atomic.AddInt32(&shipped, 1)
+			return nil, nil
+		}).
+		End().
+		Build()
+	workflow.ID = "slow"
+
+	engine := NewWorkflowEngine()
+	engine.RegisterWorkflow(workflow)
+
+	start := time.Now()
+	execution, err := engine.StartExecution(context.Background(), "slow", map[string]interface{}{}, ExecutionOptions{Timeout: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("StartExecution: %v", err)
+	}
+	if status := waitForExecution(t, execution); status != StatusCancelled {
+		t.Fatalf("status = %s, want cancelled", status)
+	}
+	engine.running.Wait()
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("timed out after %s, want about 50ms", elapsed)
+	}
+	if !errors.Is(execution.Error, context.DeadlineExceeded) {
+		t.Fatalf("error = %v, want the deadline exceeded", execution.Error)
+	}
+	if atomic.LoadInt32(&compensated) != 1 || atomic.LoadInt32(&shipped) != 0 {
+		t.Fatalf("compensated %d, shipped %d; want the reservation undone and nothing shipped", compensated, shipped)
+	}
+
+	// Executions without a timeout aren't limited
+	unlimited, _ := engine.StartExecution(context.Background(), "slow", map[string]interface{}{})
+	time.Sleep(100 * time.Millisecond)
+	unlimited.mu.RLock()
+	status := unlimited.Status
+	unlimited.mu.RUnlock()
+	if status != StatusRunning {
+		t.Fatalf("execution without a timeout is %s", status)
+	}
+	engine.CancelExecution(unlimited.ID)
+	engine.running.Wait()
+}
+
+func TestCancelInterruptsRetryBackoff(t *testing.T) {
+	var attempts int32
+	engine := NewWorkflowEngine()
+	engine.RegisterWorkflow(retryingWorkflow(&attempts, 0))
+
+	execution, err := engine.StartExecution(context.Background(), "retrying", map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("StartExecution: %v", err)
+	}
+	for atomic.LoadInt32(&attempts) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	start := time.Now()
+	if err := engine.CancelExecution(execution.ID); err != nil {
+		t.Fatalf("CancelExecution: %v", err)
+	}
+	engine.running.Wait()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("cancel took %s to interrupt the backoff", elapsed)
+	}
+
+	result := execution.StepResults["flaky"]
+	if result == nil || result.Attempts != 1 || !errors.Is(result.Error, context.Canceled) {
+		t.Fatalf("step result = %+v, want one attempt ended by the cancellation", result)
+	}
+	if execution.Status != StatusCancelled {
+		t.Fatalf("status = %s, want cancelled", execution.Status)
+	}
+}
+
+func TestStepTimeoutInterruptsRetryBackoff(t *testing.T) {
+	var attempts int32
+	engine := NewWorkflowEngine()
+	execution := runWorkflow(t, engine, retryingWorkflow(&attempts, 50*time.Millisecond), map[string]interface{}{})
+
+	if execution.Status != StatusFailed || !errors.Is(execution.Error, context.DeadlineExceeded) {
+		t.Fatalf("status = %s, error %v; want failed on the step deadline", execution.Status, execution.Error)
+	}
+	if attempts != 1 {
+		t.Fatalf("%d attempts, want 1", attempts)
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	tests := []struct {
+		policy  RetryPolicy
+		attempt int
+		want    time.Duration
+	}{
+		{RetryPolicy{Delay: time.Second}, 3, time.Second},
+		{RetryPolicy{Delay: time.Second, BackoffRate: 2}, 1, time.Second},
+		{RetryPolicy{Delay: time.Second, BackoffRate: 2}, 3, 4 * time.Second},
+		{RetryPolicy{Delay: 100 * time.Millisecond, BackoffRate: 1.5}, 2, 150 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		if got := tt.policy.backoff(tt.attempt); got != tt.want {
+			t.Errorf("%+v backoff(%d) = %s, want %s", tt.policy, tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestExecutionTimeoutIsPersisted(t *testing.T) {
+	engine := newTestStatefulEngine(t)
+	engine.RegisterWorkflow(noopWorkflow("noop"))
+
+	execution, err := engine.StartExecution(context.Background(), "noop", map[string]interface{}{}, ExecutionOptions{Timeout: time.Minute})
+	if err != nil {
+		t.Fatalf("StartExecution: %v", err)
+	}
+	engine.running.Wait()
+
+	loaded, err := engine.stateStore.LoadState(execution.ID)
+	if err != nil {
+		t.Fatalf("LoadState: %v", err)
+	}
+	if loaded.Timeout != time.Minute {
+		t.Fatalf("loaded timeout = %s, want 1m so a resume gets it again", loaded.Timeout)
+	}
+}
@@ -1,7 +1,9 @@
 package workflow
 
 import (
+	"container/list"
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -11,12 +13,20 @@ import (
 type WorkflowStatus string
 
 const (
-	StatusPending   WorkflowStatus = "pending"
-	StatusRunning   WorkflowStatus = "running"
-	StatusCompleted WorkflowStatus = "completed"
-	StatusFailed    WorkflowStatus = "failed"
-	StatusCancelled WorkflowStatus = "cancelled"
-	StatusPaused    WorkflowStatus = "paused"
+	StatusPending     WorkflowStatus = "pending"
+	StatusRunning     WorkflowStatus = "running"
+	StatusCompleted   WorkflowStatus = "completed"
+	StatusFailed      WorkflowStatus = "failed"
+	StatusCancelled   WorkflowStatus = "cancelled"
+	StatusPaused      WorkflowStatus = "paused"
+	StatusCompensated WorkflowStatus = "compensated"
+)
+
+// Errors returned by the engine, wrapped with the ID they concern
+var (
+	ErrWorkflowNotFound    = errors.New("workflow not found")
+	ErrExecutionNotFound   = errors.New("execution not found")
+	ErrExecutionNotRunning = errors.New("execution not running")
 )
 
 // Workflow represents a workflow definition
@@ -37,6 +47,7 @@ type Step struct {
 	Name         string
 	Type         StepType
 	Action       ActionFunc
+	Compensate   ActionFunc // Undoes Action when a later step fails (saga)
 	Condition    ConditionFunc
 	OnSuccess    []string // Next step IDs on success
 	OnFailure    []string // Next step IDs on failure
@@ -44,6 +55,7 @@ type Step struct {
 	Timeout      time.Duration
 	Parameters   map[string]interface{}
 	Metadata     map[string]string
+	Steps        []Step // Child steps run on each iteration of a loop step
 }
 
 // StepType represents the type of step
@@ -71,6 +83,35 @@ type RetryPolicy struct {
 	BackoffRate float64 // Exponential backoff multiplier
 }
 
+// backoff returns the delay before retrying after the given attempt
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.Delay
+	if p.BackoffRate > 0 {
+		for i := 1; i < attempt; i++ {
+			delay = time.Duration(float64(delay) * p.BackoffRate)
+		}
+	}
+	return delay
+}
+
+// sleepContext waits for d, returning ctx's error early if it is done first
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ExecutionOptions configures a single execution
+type ExecutionOptions struct {
+	Timeout time.Duration // Deadline for the whole run, across all steps and retries; 0 means none
+}
+
 // Execution represents a workflow execution instance
 type Execution struct {
 	ID           string
@@ -84,6 +125,7 @@ type Execution struct {
 	StartedAt    time.Time
 	CompletedAt  *time.Time
 	Error        error
+	Timeout      time.Duration // Execution deadline from ExecutionOptions
 	mu           sync.RWMutex
 }
 
@@ -109,11 +151,29 @@ type StepResult struct {
 	Duration    time.Duration
 }
 
+// EventFunc receives execution events (e.g. to persist them in an EventLog)
+type EventFunc func(executionID, stepID, eventType, message string, data map[string]interface{})
+
 // WorkflowEngine manages workflow execution
 type WorkflowEngine struct {
 	workflows  map[string]*Workflow
 	executions map[string]*Execution
+	onEvent    EventFunc
+	onFinish   func(execution *Execution) // Called when an execution stops running
 	mu         sync.RWMutex
+
+	maxLoopIterations int
+
+	// In-memory retention of finished executions
+	retention RetentionPolicy
+	lru       *list.List // execution IDs, most recently used first
+	lruIndex  map[string]*list.Element
+	lastSweep time.Time
+
+	// Draining for shutdown
+	draining bool
+	inflight map[string]*inflightExecution
+	running  sync.WaitGroup
 }
 
 // NewWorkflowEngine creates a new workflow engine
@@ -121,6 +181,28 @@ func NewWorkflowEngine() *WorkflowEngine {
 	return &WorkflowEngine{
 		workflows:  make(map[string]*Workflow),
 		executions: make(map[string]*Execution),
+		retention:  DefaultRetentionPolicy(),
+		lru:        list.New(),
+		lruIndex:   make(map[string]*list.Element),
+		inflight:   make(map[string]*inflightExecution),
+	}
+}
+
+// SetEventHandler sets a callback for execution events such as compensations
+func (e *WorkflowEngine) SetEventHandler(fn EventFunc) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.onEvent = fn
+}
+
+// emit reports an execution event to the configured handler
+func (e *WorkflowEngine) emit(executionID, stepID, eventType, message string, data map[string]interface{}) {
+	e.mu.RLock()
+	fn := e.onEvent
+	e.mu.RUnlock()
+
+	if fn != nil {
+		fn(executionID, stepID, eventType, message, data)
 	}
 }
 
@@ -146,19 +228,26 @@ func (e *WorkflowEngine) GetWorkflow(workflowID string) (*Workflow, error) {
 
 	workflow, exists := e.workflows[workflowID]
 	if !exists {
-		return nil, fmt.Errorf("workflow not found: %s", workflowID)
+		return nil, fmt.Errorf("%w: %s", ErrWorkflowNotFound, workflowID)
 	}
 
 	return workflow, nil
 }
 
-// StartExecution starts a workflow execution
-func (e *WorkflowEngine) StartExecution(ctx context.Context, workflowID string, input map[string]interface{}) (*Execution, error) {
+// StartExecution starts a workflow execution. With a Timeout option the run
+// is cancelled once it elapses, like CancelExecution; its error is then
+// context.DeadlineExceeded.
+func (e *WorkflowEngine) StartExecution(ctx context.Context, workflowID string, input map[string]interface{}, options ...ExecutionOptions) (*Execution, error) {
 	workflow, err := e.GetWorkflow(workflowID)
 	if err != nil {
 		return nil, err
 	}
 
+	var opts ExecutionOptions
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
 	execution := &Execution{
 		ID:          fmt.Sprintf("exec-%d", time.Now().UnixNano()),
 		WorkflowID:  workflowID,
@@ -167,6 +256,7 @@ func (e *WorkflowEngine) StartExecution(ctx context.Context, workflowID string,
 		Output:      make(map[string]interface{}),
 		StepResults: make(map[string]*StepResult),
 		StartedAt:   time.Now(),
+		Timeout:     opts.Timeout,
 		Context: &ExecutionContext{
 			WorkflowID:  workflowID,
 			ExecutionID: fmt.Sprintf("exec-%d", time.Now().UnixNano()),
@@ -176,12 +266,10 @@ func (e *WorkflowEngine) StartExecution(ctx context.Context, workflowID string,
 		},
 	}
 
-	e.mu.Lock()
-	e.executions[execution.ID] = execution
-	e.mu.Unlock()
-
 	// Execute workflow in background
-	go e.executeWorkflow(ctx, workflow, execution)
+	if err := e.launch(ctx, workflow, execution); err != nil {
+		return nil, err
+	}
 
 	return execution, nil
 }
@@ -199,18 +287,26 @@ func (e *WorkflowEngine) executeWorkflow(ctx context.Context, workflow *Workflow
 		}
 	}()
 
+	// Steps that completed successfully, in order, for compensation
+	var completed []Step
+
 	// Execute steps in order
-	for i, step := range workflow.Steps {
-		select {
-		case <-ctx.Done():
-			execution.mu.Lock()
-			execution.Status = StatusCancelled
-			execution.Error = ctx.Err()
-			now := time.Now()
-			execution.CompletedAt = &now
-			execution.mu.Unlock()
+	for _, step := range workflow.Steps {
+		// Steps completed before the execution was paused don't run again
+		if execution.stepCompleted(step.ID) {
+			completed = append(completed, step)
+			continue
+		}
+
+		// Stop at the step boundary so the state can be persisted and resumed
+		if e.isDraining() {
+			e.pause(execution)
+			return
+		}
+
+		if ctx.Err() != nil {
+			e.cancelled(execution, ctx.Err())
 			return
-		default:
 		}
 
 		execution.mu.Lock()
@@ -220,16 +316,32 @@ func (e *WorkflowEngine) executeWorkflow(ctx context.Context, workflow *Workflow
 		result := e.executeStep(ctx, &step, execution.Context)
 
 		execution.mu.Lock()
+		if execution.Status == StatusPaused {
+			// Drain gave up waiting for this step; it runs again on resume
+			execution.mu.Unlock()
+			return
+		}
 		execution.StepResults[step.ID] = result
 		execution.mu.Unlock()
 
 		if result.Error != nil {
+			// The execution was cancelled or timed out during the step;
+			// undo the work of earlier steps and stop
+			if ctx.Err() != nil {
+				e.compensate(ctx, completed, execution)
+				e.cancelled(execution, ctx.Err())
+				return
+			}
+
 			// Check if there are OnFailure steps
 			if len(step.OnFailure) > 0 {
 				// Continue to failure handler steps
 				continue
 			}
 
+			// Undo the work of earlier steps before giving up
+			e.compensate(ctx, completed, execution)
+
 			execution.mu.Lock()
 			execution.Status = StatusFailed
 			execution.Error = result.Error
@@ -239,15 +351,91 @@ func (e *WorkflowEngine) executeWorkflow(ctx context.Context, workflow *Workflow
 			return
 		}
 
-		// Check if this is the last step
-		if i == len(workflow.Steps)-1 {
-			execution.mu.Lock()
-			execution.Status = StatusCompleted
-			now := time.Now()
-			execution.CompletedAt = &now
-			execution.mu.Unlock()
-			return
+		completed = append(completed, step)
+	}
+
+	execution.mu.Lock()
+	if execution.Status == StatusRunning {
+		execution.Status = StatusCompleted
+		now := time.Now()
+		execution.CompletedAt = &now
+	}
+	execution.mu.Unlock()
+}
+
+// cancelled marks an execution cancelled because its context is done, with
+// the context's error. Executions paused by Drain stay paused.
+func (e *WorkflowEngine) cancelled(execution *Execution, err error) {
+	execution.mu.Lock()
+	defer execution.mu.Unlock()
+
+	switch execution.Status {
+	case StatusRunning:
+		execution.Status = StatusCancelled
+		now := time.Now()
+		execution.CompletedAt = &now
+	case StatusCancelled:
+		// Cancelled by CancelExecution
+	default:
+		return
+	}
+	if execution.Error == nil {
+		execution.Error = err
+	}
+}
+
+// CompensationResultID is the StepResults key holding a step's compensation result
+func CompensationResultID(stepID string) string {
+	return stepID + ":compensate"
+}
+
+// compensate runs the Compensate actions of completed steps in reverse order.
+// Every compensation is attempted even if an earlier one fails; results are
+// recorded in StepResults under CompensationResultID and reported as events.
+func (e *WorkflowEngine) compensate(ctx context.Context, completed []Step, execution *Execution) {
+	// Compensations must run even when the execution context was cancelled
+	ctx = context.WithoutCancel(ctx)
+
+	for i := len(completed) - 1; i >= 0; i-- {
+		step := completed[i]
+		if step.Compensate == nil {
+			continue
+		}
+
+		result := &StepResult{
+			StepID:    CompensationResultID(step.ID),
+			Status:    StatusRunning,
+			Attempts:  1,
+			StartedAt: time.Now(),
+		}
+
+		stepCtx := ctx
+		cancel := context.CancelFunc(func() {})
+		if step.Timeout > 0 {
+			stepCtx, cancel = context.WithTimeout(ctx, step.Timeout)
+		}
+		output, err := step.Compensate(stepCtx, execution.Context)
+		cancel()
+
+		now := time.Now()
+		result.CompletedAt = &now
+		result.Duration = now.Sub(result.StartedAt)
+		result.Output = output
+
+		if err != nil {
+			result.Status = StatusFailed
+			result.Error = err
+			e.emit(execution.ID, step.ID, "compensation_failed", "Step compensation failed", map[string]interface{}{
+				"error": err.Error(),
+			})
+		} else {
+			result.Status = StatusCompensated
+			e.emit(execution.ID, step.ID, "compensated", "Step compensated", nil)
 		}
+
+		execution.mu.Lock()
+		execution.StepResults[result.StepID] = result
+		execution.mu.Unlock()
 	}
 }
 
@@ -296,9 +484,18 @@ func (e *WorkflowEngine) executeStep(ctx context.Context, step *Step, execCtx *E
 				}
 			}
 
+		case StepTypeLoop:
+			output, err = e.executeLoop(ctx, step, execCtx)
+
 		case StepTypeWait:
 			if duration, ok := step.Parameters["duration"].(time.Duration); ok {
-				time.Sleep(duration)
+				timer := time.NewTimer(duration)
+				select {
+				case <-timer.C:
+				case <-ctx.Done():
+					timer.Stop()
+					err = ctx.Err()
+				}
 			}
 
 		case StepTypeSubflow:
@@ -326,15 +523,12 @@ func (e *WorkflowEngine) executeStep(ctx context.Context, step *Step, execCtx *E
 
 		lastErr = err
 
-		// Retry with backoff
+		// Retry with backoff, unless the step or execution is cancelled
 		if attempt < maxAttempts && step.RetryPolicy != nil {
-			delay := step.RetryPolicy.Delay
-			if step.RetryPolicy.BackoffRate > 0 {
-				for i := 1; i < attempt; i++ {
-					delay = time.Duration(float64(delay) * step.RetryPolicy.BackoffRate)
-				}
+			if err := sleepContext(ctx, step.RetryPolicy.backoff(attempt)); err != nil {
+				lastErr = err
+				break
 			}
-			time.Sleep(delay)
 		}
 	}
 
@@ -349,18 +543,20 @@ func (e *WorkflowEngine) executeStep(ctx context.Context, step *Step, execCtx *E
 
 // GetExecution gets an execution by ID
 func (e *WorkflowEngine) GetExecution(executionID string) (*Execution, error) {
-	e.mu.RLock()
-	defer e.mu.RUnlock()
+	e.mu.Lock()
+	defer e.mu.Unlock()
 
 	execution, exists := e.executions[executionID]
 	if !exists {
-		return nil, fmt.Errorf("execution not found: %s", executionID)
+		return nil, fmt.Errorf("%w: %s", ErrExecutionNotFound, executionID)
 	}
+	e.trackLocked(execution)
 
 	return execution, nil
 }
 
-// CancelExecution cancels a workflow execution
+// CancelExecution cancels a workflow execution. The context of the running
+// step is cancelled and no further steps run.
 func (e *WorkflowEngine) CancelExecution(executionID string) error {
 	execution, err := e.GetExecution(executionID)
 	if err != nil {
@@ -368,32 +564,40 @@ func (e *WorkflowEngine) CancelExecution(executionID string) error {
 	}
 
 	execution.mu.Lock()
-	defer execution.mu.Unlock()
-
 	if execution.Status != StatusRunning {
-		return fmt.Errorf("execution not running: %s", executionID)
+		execution.mu.Unlock()
+		return fmt.Errorf("%w: %s", ErrExecutionNotRunning, executionID)
 	}
-
 	execution.Status = StatusCancelled
 	now := time.Now()
 	execution.CompletedAt = &now
+	execution.mu.Unlock()
+
+	e.mu.RLock()
+	f, ok := e.inflight[executionID]
+	e.mu.RUnlock()
+	if ok {
+		f.cancel()
+	}
 
 	return nil
 }
 
-// ListExecutions lists all executions for a workflow
-func (e *WorkflowEngine) ListExecutions(workflowID string) []*Execution {
-	e.mu.RLock()
-	defer e.mu.RUnlock()
-
+// ListExecutions lists in-memory executions for a workflow, newest first.
+// It returns one page (limit <= 0 means no limit) and the total count.
+func (e *WorkflowEngine) ListExecutions(workflowID string, offset, limit int) ([]*Execution, int64, error) {
+	e.mu.Lock()
+	e.evictLocked(time.Now())
 	executions := make([]*Execution, 0)
 	for _, exec := range e.executions {
-		if exec.WorkflowID == workflowID {
+		if workflowID == "" || exec.WorkflowID == workflowID {
 			executions = append(executions, exec)
 		}
 	}
+	e.mu.Unlock()
 
-	return executions
+	total := int64(len(executions))
+	return paginateExecutions(executions, offset, limit), total, nil
 }
 
 // ListWorkflows lists all workflows
@@ -415,7 +619,7 @@ func (e *WorkflowEngine) DeleteWorkflow(workflowID string) error {
 	defer e.mu.Unlock()
 
 	if _, exists := e.workflows[workflowID]; !exists {
-		return fmt.Errorf("workflow not found: %s", workflowID)
+		return fmt.Errorf("%w: %s", ErrWorkflowNotFound, workflowID)
 	}
 
 	delete(e.workflows, workflowID)
@@ -0,0 +1,139 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// waitForExecution waits until the execution stops running and returns its status
+func waitForExecution(t *testing.T, execution *Execution) WorkflowStatus {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		execution.mu.RLock()
+		status := execution.Status
+		execution.mu.RUnlock()
+		if status != StatusRunning {
+			return status
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("execution %s still running", execution.ID)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// runWorkflow registers workflow on engine, runs it to completion and
+// returns the finished execution
+func runWorkflow(t *testing.T, engine *WorkflowEngine, workflow *Workflow, input map[string]interface{}) *Execution {
+	t.Helper()
+
+	if err := engine.RegisterWorkflow(workflow); err != nil {
+		t.Fatalf("RegisterWorkflow: %v", err)
+	}
+	if input == nil {
+		input = map[string]interface{}{}
+	}
+	execution, err := engine.StartExecution(context.Background(), workflow.ID, input)
+	if err != nil {
+		t.Fatalf("StartExecution: %v", err)
+	}
+	waitForExecution(t, execution)
+	return execution
+}
+
+// sagaLog records the actions and compensations run by a saga
+type sagaLog struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (l *sagaLog) action(name string, err error) ActionFunc {
+	return func(context.Context, *ExecutionContext) (interface{}, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.calls = append(l.calls, name)
+		return name, err
+	}
+}
+
+func (l *sagaLog) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return fmt.Sprint(l.calls)
+}
+
+func TestSagaCompensatesCompletedStepsInReverse(t *testing.T) {
+	log := &sagaLog{}
+	errPayment := errors.New("payment declined")
+	errRelease := errors.New("release failed")
+
+	workflow := NewWorkflowBuilder("order").
+		AddStep("reserve", "Reserve stock").
+		Action(log.action("reserve", nil)).
+		Compensate(log.action("release", errRelease)).
+		Then("invoice", "Create invoice").
+		Action(log.action("invoice", nil)).
+		Compensate(log.action("void", nil)).
+		Then("charge", "Charge card").
+		Action(log.action("charge", errPayment)).
+		Compensate(log.action("refund", nil)).
+		End().
+		Build()
+
+	var events []string
+	engine := NewWorkflowEngine()
+	engine.SetEventHandler(func(_, stepID, eventType, _ string, _ map[string]interface{}) {
+		events = append(events, stepID+":"+eventType)
+	})
+	execution := runWorkflow(t, engine, workflow, nil)
+
+	if execution.Status != StatusFailed || !errors.Is(execution.Error, errPayment) {
+		t.Fatalf("execution = %s, %v; want failed with the step 3 error", execution.Status, execution.Error)
+	}
+	// The failed step itself is not compensated
+	if got := log.String(); got != "[reserve invoice charge void release]" {
+		t.Fatalf("calls = %s, want steps 2 then 1 compensated", got)
+	}
+
+	voided := execution.StepResults[CompensationResultID("invoice")]
+	if voided == nil || voided.Status != StatusCompensated || voided.Output != "void" {
+		t.Fatalf("invoice compensation result = %+v", voided)
+	}
+	released := execution.StepResults[CompensationResultID("reserve")]
+	if released == nil || released.Status != StatusFailed || !errors.Is(released.Error, errRelease) {
+		t.Fatalf("reserve compensation result = %+v, want the failure recorded", released)
+	}
+	if _, ok := execution.StepResults[CompensationResultID("charge")]; ok {
+		t.Fatal("the failed step was compensated")
+	}
+
+	if got := fmt.Sprint(events); got != "[invoice:compensated reserve:compensation_failed]" {
+		t.Fatalf("events = %s", got)
+	}
+}
+
+func TestSagaWithoutFailureIsNotCompensated(t *testing.T) {
+	log := &sagaLog{}
+	workflow := NewWorkflowBuilder("order").
+		AddStep("reserve", "Reserve stock").
+		Action(log.action("reserve", nil)).
+		Compensate(log.action("release", nil)).
+		Then("charge", "Charge card").
+		Action(log.action("charge", nil)).
+		End().
+		Build()
+
+	execution := runWorkflow(t, NewWorkflowEngine(), workflow, nil)
+	if execution.Status != StatusCompleted {
+		t.Fatalf("status = %s, want completed", execution.Status)
+	}
+	if got := log.String(); got != "[reserve charge]" {
+		t.Fatalf("calls = %s, want no compensation", got)
+	}
+}